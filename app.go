@@ -4,21 +4,25 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	"github.com/anacrolix/torrent/metainfo"
-	"golang.org/x/sys/windows"
 )
 
 // TranscodeTask represents a video transcoding task
@@ -35,113 +39,149 @@ type TranscodeTask struct {
 	TimeRemaining string    `json:"timeRemaining,omitempty"`
 	FFmpegCommand string    `json:"ffmpegCommand"`
 	PID           int       `json:"pid,omitempty"`
+	PausedBy      string    `json:"pausedBy,omitempty"` // battery：使用电池供电时挂起了转码进程；thermal：温度或系统负载过高时挂起；disk：磁盘空间不足时挂起；all：全部暂停时挂起
 	Error         string    `json:"error,omitempty"`
+	UsedGPU       bool      `json:"usedGpu"` // 是否使用GPU编码，开始转码时确定
 	VideoCodec    string    `json:"videoCodec"`
 	AudioCodec    string    `json:"audioCodec"`
 	Resolution    string    `json:"resolution"`
 	Bitrate       string    `json:"bitrate"`
-}
-
-// GPUType 表示GPU的类型
-type GPUType string
-
-const (
-	GPUTypeNVIDIA GPUType = "nvidia"
-	GPUTypeAMD    GPUType = "amd"
-	GPUTypeIntel  GPUType = "intel"
-	GPUTypeOther  GPUType = "other"
-)
-
-// HasGPU 检测系统是否有可用的GPU，并返回GPU类型
-func HasGPU() (bool, GPUType) {
-	// 在Windows系统上，使用wmic命令检测GPU
-	cmd := exec.Command("wmic", "path", "win32_VideoController", "get", "Name")
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		fmt.Printf("检测GPU失败: %v\n", err)
-		return false, GPUTypeOther
-	}
-
-	// 将输出转换为字符串并检查是否包含GPU信息
-	outputStr := string(output)
-	// 过滤掉标题行和空行
-	outputStr = strings.TrimSpace(outputStr)
-	lines := strings.Split(outputStr, "\n")
-
-	// 如果有多行输出（标题行+至少一个GPU），则认为系统有GPU
-	if len(lines) > 1 {
-		gpuNames := lines[1:]
-		fmt.Printf("检测到GPU: %s\n", strings.Join(gpuNames, ", "))
-
-		// 检查GPU类型 - 增强AMD检测逻辑以更好地支持RX6400
-		outputLower := strings.ToLower(outputStr)
-		if strings.Contains(outputLower, "nvidia") {
-			return true, GPUTypeNVIDIA
-		} else if strings.Contains(outputLower, "amd") || strings.Contains(outputLower, "radeon") || strings.Contains(outputLower, "ati") ||
-			strings.Contains(outputLower, "amd radeon") || strings.Contains(outputLower, "rx") {
-			return true, GPUTypeAMD
-		} else if strings.Contains(outputLower, "intel") || strings.Contains(outputLower, "hd graphics") || strings.Contains(outputLower, "uhd graphics") || strings.Contains(outputLower, "iris") {
-			return true, GPUTypeIntel
-		}
-		return true, GPUTypeOther
-	}
-
-	fmt.Println("未检测到可用GPU")
-	return false, GPUTypeOther
+	DeletedFiles  []string  `json:"deletedFiles,omitempty"` // 已从视频库中删除的输入或输出文件（绝对路径）
+	// 转码期间最近一次采样的 ffmpeg CPU、内存占用和GPU使用率，每3秒更新
+	Resources *ResourceUsage `json:"resources,omitempty"`
+	// 用户填写的备注和颜色标签，见 SetTaskNote
+	Note  string `json:"note,omitempty"`
+	Label string `json:"label,omitempty"`
 }
 
 // CheckFFmpegGPU 检查ffmpeg是否支持GPU加速
 func CheckFFmpegGPU(ffmpegPath string) bool {
 	// 执行ffmpeg -hwaccels命令查看支持的硬件加速类型
 	cmd := exec.Command(ffmpegPath, "-hwaccels")
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
+	hideWindow(cmd)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		fmt.Printf("检查ffmpeg GPU支持失败: %v\n", err)
+		slog.Error("检查ffmpeg GPU支持失败", "error", err)
 		return false
 	}
 
 	outputStr := string(output)
 	outputLower := strings.ToLower(outputStr)
 	// 检查输出中是否包含常见的GPU加速关键字
-	gpuKeywords := []string{"cuda", "nvenc", "dxva2", "d3d11va", "qsv", "vulkan", "amf", "vce", "opencl"}
+	gpuKeywords := []string{"cuda", "nvenc", "dxva2", "d3d11va", "qsv", "vulkan", "amf", "vce", "opencl", "videotoolbox"}
 	for _, keyword := range gpuKeywords {
 		if strings.Contains(outputLower, keyword) {
-			fmt.Printf("ffmpeg支持GPU加速: %s\n", keyword)
+			slog.Info("ffmpeg支持GPU加速", "keyword", keyword)
 			return true
 		}
 	}
 
 	// 额外检查AMD VCE编码器支持
 	encodersCmd := exec.Command(ffmpegPath, "-encoders")
-	encodersCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	hideWindow(encodersCmd)
 	encodersOutput, _ := encodersCmd.CombinedOutput()
 	encodersLower := strings.ToLower(string(encodersOutput))
 	if strings.Contains(encodersLower, "h264_amf") || strings.Contains(encodersLower, "hevc_amf") {
-		fmt.Println("ffmpeg支持AMD AMF编码器")
+		slog.Info("ffmpeg支持AMD AMF编码器")
 		return true
 	}
 
-	fmt.Println("ffmpeg不支持GPU加速")
+	slog.Info("ffmpeg不支持GPU加速")
 	return false
 }
 
 // App struct
 type App struct {
-	ctx context.Context
+	ctx      context.Context
+	throttle *eventThrottle
+	store    TaskStore
+	tasks    *TaskManager
+	// taskLoadError 启动时读取任务数据失败的原因，此时任务只保存在内存中
+	taskLoadError string
+	// headless 为true时以无界面服务器模式运行，不调用Wails运行时
+	headless bool
+	// hub 管理WebSocket远程控制连接
+	hub *wsHub
+	// remote 桌面模式下的本机API和局域网访问服务
+	remote remoteServer
+	// telegram 可选的 Telegram 机器人
+	telegram *telegramBot
+	// tray 桌面模式下的托盘图标
+	tray trayIcon
+	// updater 后台检查新版本
+	updater *updateChecker
+	// stats 传输和转码统计
+	stats *statsTracker
+	// plugins 数据目录 plugins 下安装的插件
+	plugins *pluginManager
+	// library 视频库文件夹的扫描结果
+	library *libraryIndex
+	// media 视频库中视频的 ffprobe 信息
+	media *mediaProber
+	// scraper 视频库中视频在 TMDB/TVDB 上的匹配结果
+	scraper *mediaScraper
+	// playback 视频库中视频的播放进度
+	playback *playbackTracker
+	// dlna 局域网 DLNA 媒体服务器
+	dlna *dlnaServer
+	// integrity 视频库文件的完整性检查
+	integrity *integrityChecker
+	// checksums 视频库文件的校验和计算
+	checksums *checksumRunner
+	// torrentVerify 按种子校验本地文件
+	torrentVerify *torrentVerifier
+	// uploads 进行中的分块上传
+	uploads *uploadManager
+	// libraryWatch 监视视频库文件夹中的文件变化
+	libraryWatch *libraryWatcher
+	// stopHistory 停止定期清理历史记录
+	stopHistory context.CancelFunc
+	// sleep 有进行中的任务时阻止系统休眠
+	sleep sleepGuard
+	// power 使用电池供电时暂停转码、限制下载速度
+	power powerMonitor
+	// thermal 温度或系统负载过高时暂停转码或减少转码线程数
+	thermal thermalGovernor
+	// disk 下载或转码目录所在磁盘空间不足时暂停任务
+	disk diskGuard
+	// rates 正在进行的下载使用的限速，按优先级分配
+	rates downloadRates
+	// removed 最近从历史记录中删除、还可以撤销的任务
+	removed removedTasks
+	// shuttingDown 程序正在关闭，下载和转码进程退出后不再启动等待中的任务
+	shuttingDown atomic.Bool
+	// quitting 通过托盘菜单或更新退出时为true，关闭窗口不再最小化到托盘
+	quitting atomic.Bool
+	// quitConfirmed 用户已确认退出或通过更新退出，不再询问是否停止进行中的任务
+	quitConfirmed atomic.Bool
+	// pausedAll 已通过 PauseAll 暂停全部任务，等待中的任务在 ResumeAll 之前不会启动
+	pausedAll atomic.Bool
+	// queueAction 所有任务结束后执行的操作（关机、睡眠等）
+	queueAction queueActionState
 }
 
 // NewApp creates a new App application struct
 // NewApp 创建一个新的 App 应用程序
 func NewApp() *App {
-	return &App{}
+	return &App{
+		throttle:      newEventThrottle(progressEventInterval),
+		hub:           newWSHub(),
+		telegram:      newTelegramBot(),
+		updater:       newUpdateChecker(),
+		stats:         newStatsTracker(),
+		plugins:       newPluginManager(),
+		library:       newLibraryIndex(),
+		media:         newMediaProber(),
+		scraper:       newMediaScraper(),
+		playback:      newPlaybackTracker(),
+		dlna:          newDLNAServer(),
+		integrity:     &integrityChecker{},
+		checksums:     &checksumRunner{},
+		torrentVerify: &torrentVerifier{},
+		uploads:       newUploadManager(),
+		libraryWatch:  &libraryWatcher{},
+	}
 }
 
 // startup is called at application startup
@@ -150,147 +190,90 @@ func (a *App) startup(ctx context.Context) {
 	// Perform your setup here
 	// 在这里执行初始化设置
 	a.ctx = ctx
-
-	// 扫描下载进度文件，处理异常状态的任务
-	fmt.Println("应用程序启动，开始扫描下载进度文件...")
-
-	// 读取下载进度文件
-	progressFile := "download_progress.json"
-	data, err := os.ReadFile(progressFile)
+	a.stats.load()
+	a.plugins.load()
+	a.media.load()
+	a.scraper.load()
+	a.playback.load()
+
+	// 打开数据目录中的任务数据库，并导入旧版本的JSON进度文件；数据库不可用时退回到JSON进度文件
+	store, err := openSQLiteTaskStore(dataPath(taskDatabaseFile))
 	if err != nil {
-		// 如果文件不存在，创建一个空的进度文件
-		if os.IsNotExist(err) {
-			fmt.Println("下载进度文件不存在，创建空文件")
-			if err := os.WriteFile(progressFile, []byte("[]"), 0644); err != nil {
-				fmt.Printf("创建下载进度文件失败: %v\n", err)
-			}
-			return
-		}
-		fmt.Printf("读取下载进度文件失败: %v\n", err)
-		return
-	}
-
-	// 解析JSON数据
-	var progressList []map[string]interface{}
-	if err := json.Unmarshal(data, &progressList); err != nil {
-		fmt.Printf("解析下载进度数据失败: %v\n", err)
-		return
-	}
-
-	// 检查是否有正在下载的任务，如果有，将其状态改为等待中
-	var hasDownloadingTask bool
-	for i, task := range progressList {
-		if status, ok := task["status"].(string); ok && status == "downloading" {
-			fmt.Printf("发现异常下载中的任务: %s，将状态改为等待中\n", task["taskId"])
-			progressList[i]["status"] = "waiting"
-			progressList[i]["endTime"] = time.Now().Format(time.RFC3339)
-			// 移除PID，因为进程可能已经结束
-			delete(progressList[i], "pid")
-			hasDownloadingTask = true
-		}
-	}
-
-	// 如果有修改，写入更新后的进度信息
-	if hasDownloadingTask {
-		progressData, err := json.MarshalIndent(progressList, "", "  ")
-		if err != nil {
-			fmt.Printf("生成进度信息失败: %v\n", err)
-			return
-		}
-		if err := os.WriteFile(progressFile, progressData, 0644); err != nil {
-			fmt.Printf("写入进度文件失败: %v\n", err)
-			return
-		}
-		fmt.Println("已将异常下载中的任务状态改为等待中")
-	}
-
-	// 查找最早的等待中的任务
-	var earliestTask map[string]interface{}
-	var earliestTime time.Time
-	for _, task := range progressList {
-		if status, ok := task["status"].(string); ok && status == "waiting" {
-			// 解析开始时间
-			startTimeStr, ok := task["startTime"].(string)
-			if !ok {
-				continue
-			}
-			startTime, err := time.Parse(time.RFC3339, startTimeStr)
-			if err != nil {
-				fmt.Printf("解析任务开始时间失败: %v\n", err)
-				continue
-			}
-			// 找到最早的任务
-			if earliestTask == nil || startTime.Before(earliestTime) {
-				earliestTask = task
-				earliestTime = startTime
-			}
-		}
-	}
-
-	// 如果有等待中的任务，启动最早的那个
-	if earliestTask != nil {
-		taskId := earliestTask["taskId"].(string)
-		magnetLink := earliestTask["magnetLink"].(string)
-		outputDir := earliestTask["outputDir"].(string)
-		fmt.Printf("启动最早的等待中的任务: %s，开始时间: %s\n", taskId, earliestTime.Format(time.RFC3339))
-		if err := a.startDownload(taskId, magnetLink, outputDir, progressFile); err != nil {
-			fmt.Printf("启动等待任务失败: %v\n", err)
-		}
+		slog.Error("打开任务数据库失败，改用JSON进度文件", "error", err)
+		a.store = newJSONTaskStore(dataPath(legacyDownloadProgressFile), dataPath(legacyTranscodeProgressFile))
 	} else {
-		fmt.Println("没有等待中的任务")
-	}
-
-	// 扫描转码进度文件，处理异常状态的转码任务
-	fmt.Println("开始扫描转码进度文件...")
-
-	// 读取转码进度文件
-	transcodeProgressFile := "transcode_progress.json"
-	transcodeData, err := os.ReadFile(transcodeProgressFile)
-	if err != nil {
-		// 如果文件不存在，创建一个空的转码进度文件
-		if os.IsNotExist(err) {
-			fmt.Println("转码进度文件不存在，创建空文件")
-			if err := os.WriteFile(transcodeProgressFile, []byte("[]"), 0644); err != nil {
-				fmt.Printf("创建转码进度文件失败: %v\n", err)
+		a.store = store
+		// 旧版本将进度文件写在工作目录中
+		for _, dir := range []string{dataDir(), "."} {
+			downloadFile := filepath.Join(dir, legacyDownloadProgressFile)
+			transcodeFile := filepath.Join(dir, legacyTranscodeProgressFile)
+			if err := importLegacyProgressFiles(a.store, downloadFile, transcodeFile); err != nil {
+				slog.Error("导入旧版进度文件失败", "error", err)
 			}
-		} else {
-			fmt.Printf("读取转码进度文件失败: %v\n", err)
 		}
-		return
 	}
 
-	// 解析转码JSON数据
-	var transcodeTasks []TranscodeTask
-	if err := json.Unmarshal(transcodeData, &transcodeTasks); err != nil {
-		fmt.Printf("解析转码进度数据失败: %v\n", err)
-		return
-	}
-
-	// 检查是否有正在转码的任务，如果有，将其状态改为等待中
-	var hasTranscodingTask bool
+	// 加载任务到内存，并启动定期持久化
+	tasks, err := NewTaskManager(a.store)
+	if err != nil {
+		// 改用空的内存任务存储，其他功能照常可用，不会覆盖无法读取的数据；错误通过 GetGlobalStats 显示在界面上
+		slog.Error("加载任务失败，本次运行的任务只保存在内存中", "error", err)
+		a.taskLoadError = errorf(msgTasksLoadFailed, err).Error()
+		a.store.Close()
+		a.store = newMemoryTaskStore()
+		tasks, _ = NewTaskManager(a.store)
+	}
+	a.tasks = tasks
+	a.tasks.Start()
+	a.startHistoryCleanup()
+	a.startSleepGuard()
+	a.startPowerMonitor()
+	a.startThermalGovernor()
+	a.startDiskGuard()
+
+	// 桌面模式下启动本机API（供命令行使用），并按设置开启局域网访问；服务器模式由 runServer 提供同样的服务
+	if !a.headless {
+		if err := a.applyRemoteAccess(); err != nil {
+			slog.Error("启动API服务失败", "error", err)
+		}
+		a.startTray()
+		a.startFileDrop()
+		syncAutostart()
+		a.startUpdateChecks()
+	}
+	a.applyTelegram()
+	if err := a.applyDLNA(); err != nil {
+		slog.Error("启动DLNA服务失败", "error", err)
+	}
+	a.applyLibraryWatcher()
+
+	// 扫描下载任务，处理异常状态的任务
+	slog.Info("应用程序启动，开始扫描下载任务...")
+
+	// 恢复上次关闭时正在下载的任务，并启动等待中的任务
+	a.resumeInterruptedDownloads()
+
+	// 扫描转码任务，处理异常状态的转码任务
+	slog.Info("开始扫描转码任务...")
+
+	// 检查是否有正在转码或上次关闭时停止的任务，如果有，将其状态改为等待中；ffmpeg 不能从中断处继续，重新开始转码
+	transcodeTasks := a.tasks.Transcodes()
 	for i, task := range transcodeTasks {
-		if task.Status == "transcoding" {
-			fmt.Printf("发现异常转码中的任务: %s，将状态改为等待中\n", task.TaskID)
-			transcodeTasks[i].Status = "waiting"
-			transcodeTasks[i].EndTime = time.Now()
-			// 移除PID，因为进程可能已经结束
-			transcodeTasks[i].PID = 0
-			hasTranscodingTask = true
-		}
-	}
-
-	// 如果有修改，写入更新后的转码进度信息
-	if hasTranscodingTask {
-		transcodeProgressData, err := json.MarshalIndent(transcodeTasks, "", "  ")
-		if err != nil {
-			fmt.Printf("生成转码进度信息失败: %v\n", err)
-			return
-		}
-		if err := os.WriteFile(transcodeProgressFile, transcodeProgressData, 0644); err != nil {
-			fmt.Printf("写入转码进度文件失败: %v\n", err)
-			return
+		stoppedByShutdown := task.Status == "paused" && task.PausedBy == pausedByShutdown
+		if task.Status == "transcoding" || stoppedByShutdown {
+			if stoppedByShutdown {
+				slog.Info("恢复上次关闭时停止的转码任务", "taskId", task.TaskID)
+			} else {
+				slog.Warn("发现异常转码中的任务，将状态改为等待中", "taskId", task.TaskID)
+			}
+			transcodeTasks[i], _ = a.tasks.UpdateTranscode(task.TaskID, func(t *TranscodeTask) {
+				t.Status = "waiting"
+				t.EndTime = time.Now()
+				// 移除PID，因为进程可能已经结束
+				t.PID = 0
+				t.PausedBy = ""
+			})
 		}
-		fmt.Println("已将异常转码中的任务状态改为等待中")
 	}
 
 	// 查找最早的等待中的转码任务
@@ -307,13 +290,18 @@ func (a *App) startup(ctx context.Context) {
 
 	// 如果有等待中的转码任务，启动最早的那个
 	if earliestTranscodeTask != nil {
-		fmt.Printf("启动最早的等待中的转码任务: %s，开始时间: %s\n",
-			earliestTranscodeTask.TaskID, earliestTranscodeTime.Format(time.RFC3339))
-		if err := a.startTranscode(earliestTranscodeTask.TaskID, transcodeProgressFile); err != nil {
-			fmt.Printf("启动等待的转码任务失败: %v\n", err)
+		slog.Info("启动最早的等待中的转码任务", "taskId", earliestTranscodeTask.TaskID, "startTime", earliestTranscodeTime.Format(time.RFC3339))
+		if err := a.startTranscode(earliestTranscodeTask.TaskID); err != nil {
+			slog.Error("启动等待的转码任务失败", "error", err)
+		}
+		// 允许同时转码多个任务时，继续启动其余等待中的任务
+		if a.transcodeSlotsAvailable() > 0 {
+			if err := a.startNextTranscodeTask(); err != nil {
+				slog.Error("启动等待的转码任务失败", "error", err)
+			}
 		}
 	} else {
-		fmt.Println("没有等待中的转码任务")
+		slog.Info("没有等待中的转码任务")
 	}
 }
 
@@ -331,109 +319,217 @@ func (a *App) domReady(ctx context.Context) {
 // beforeClose在单击窗口关闭按钮或调用runtime.Quit即将退出应用程序时被调用.
 // 返回 true 将导致应用程序继续，false 将继续正常关闭。
 func (a *App) beforeClose(ctx context.Context) (prevent bool) {
-	// 调用shutdown函数终止所有下载进程
-	a.shutdown(ctx)
+	// 按设置最小化到托盘，任务在后台继续
+	if a.hideToTray(ctx) {
+		return true
+	}
+	// 有进行中的任务时先询问用户，由 ConfirmQuit 执行选择的操作后再退出
+	if a.confirmQuit(ctx) {
+		return true
+	}
+	// 继续关闭，由 shutdown 停止所有下载进程
 	return false
 }
 
+const (
+	// pausedByShutdown DownloadTask.PausedBy 和 TranscodeTask.PausedBy 的值，表示任务因程序关闭而暂停
+	pausedByShutdown = "shutdown"
+	// shutdownTimeout 关闭程序时等待下载和转码进程自行退出的时间，超时后强制终止
+	shutdownTimeout = 10 * time.Second
+)
+
 // shutdown is called at application termination
 // 在应用程序终止时被调用
 func (a *App) shutdown(ctx context.Context) {
 	// Perform your teardown here
 	// 在此处做一些资源释放的操作
-	fmt.Println("应用程序正在关闭，开始清理下载任务...")
-
-	// 读取下载进度文件
-	progressFile := "download_progress.json"
-	data, err := os.ReadFile(progressFile)
-	if err != nil {
-		fmt.Printf("读取下载进度文件失败: %v\n", err)
+	slog.Info("应用程序正在关闭，开始清理下载任务...")
+	a.stopRemote()
+	a.stopTelegram()
+	a.stopDLNA()
+	a.stopTray()
+	a.stopUpdateChecks()
+
+	if a.tasks == nil {
 		return
 	}
-
-	// 解析JSON数据
-	var progressList []map[string]interface{}
-	if err := json.Unmarshal(data, &progressList); err != nil {
-		fmt.Printf("解析下载进度数据失败: %v\n", err)
-		return
+	if a.stopHistory != nil {
+		a.stopHistory()
 	}
 
-	// 查找并终止所有下载中的任务
-	for _, task := range progressList {
-		status, ok := task["status"].(string)
-		if !ok {
-			continue
-		}
+	// 停止所有下载和转码，之后不再启动等待中的任务
+	a.shuttingDown.Store(true)
+	a.waitForShutdown(append(a.stopDownloadsForShutdown(), a.stopTranscodesForShutdown()...))
+	a.stopSleepGuard()
+	a.stopThermalGovernor()
+	a.stopDiskGuard()
+	a.stopPowerMonitor()
+	a.libraryWatch.stop()
+	liveSessions.stop("")
 
-		// 只处理下载中的任务
-		if status == "downloading" {
-			// 获取PID
-			pidValue, ok := task["pid"]
-			if !ok {
-				continue
-			}
+	slog.Info("下载任务清理完成")
 
-			// 处理不同类型的PID值（可能是float64或int）
-			var pid int
-			switch v := pidValue.(type) {
-			case float64:
-				pid = int(v)
-			case int:
-				pid = v
-			default:
-				continue
-			}
+	// 停止定期持久化并写入所有未保存的修改
+	a.tasks.Stop()
+	a.stats.save()
+	a.playback.save()
+}
 
-			// 获取任务ID用于日志
-			taskId := "unknown"
-			if id, ok := task["taskId"].(string); ok {
-				taskId = id
+// stopDownloadsForShutdown 关闭程序时停止所有下载：先将任务标记为因关闭而暂停，再通知下载进程自行退出，
+// 返回需要等待退出的进程
+// 以保存已下载的数据，超过 shutdownTimeout 仍未退出的进程强制终止。下次启动时按设置自动恢复这些任务
+func (a *App) stopDownloadsForShutdown() []int {
+	var pids []int
+	for _, task := range a.tasks.Downloads() {
+		if task.Status != "downloading" {
+			continue
+		}
+		// 在锁内更新状态，下载协程在进程退出后不会再修改任务
+		var pid int
+		a.tasks.UpdateDownload(task.TaskID, func(t *DownloadTask) {
+			if t.Status != "downloading" {
+				return
 			}
+			pid = t.PID
+			t.Status = "paused"
+			t.PausedBy = pausedByShutdown
+			t.Speed = 0
+			t.PID = 0
+		})
+		if pid == 0 {
+			continue
+		}
+		slog.Info("正在停止下载任务", "taskId", task.TaskID, "pid", pid)
+		if err := processes.Interrupt(pid); err != nil {
+			slog.Warn("通知下载进程退出失败", "pid", pid, "error", err)
+		}
+		pids = append(pids, pid)
+	}
+	return pids
+}
 
-			fmt.Printf("正在终止下载任务 %s (PID: %d)\n", taskId, pid)
-
-			// 尝试终止进程
-			// 在Windows上，我们使用taskkill命令
-			killCmd := exec.Command("taskkill", "/F", "/PID", strconv.Itoa(pid))
-			output, err := killCmd.CombinedOutput()
-			if err != nil {
-				fmt.Printf("终止进程 %d 时出错: %v\n输出: %s\n", pid, err, string(output))
-			} else {
-				fmt.Printf("成功终止进程 %d\n", pid)
+// stopTranscodesForShutdown 关闭程序时停止所有转码（包括挂起的转码）：先将任务标记为因关闭而暂停，
+// 监控协程不会再把任务改为失败，再通知 ffmpeg 所在的进程组退出，返回需要等待退出的进程
+func (a *App) stopTranscodesForShutdown() []int {
+	var pids []int
+	for _, task := range a.tasks.Transcodes() {
+		if task.Status != "transcoding" {
+			continue
+		}
+		var pid int
+		a.tasks.UpdateTranscode(task.TaskID, func(t *TranscodeTask) {
+			if t.Status != "transcoding" {
+				return
 			}
+			pid = t.PID
+			t.Status = "paused"
+			t.PausedBy = pausedByShutdown
+			t.PID = 0
+		})
+		if pid == 0 {
+			continue
+		}
+		slog.Info("正在停止转码任务", "taskId", task.TaskID, "pid", pid)
+		if err := processes.Interrupt(pid); err != nil {
+			slog.Warn("通知转码进程退出失败", "pid", pid, "error", err)
 		}
+		pids = append(pids, pid)
 	}
-
-	fmt.Println("下载任务清理完成")
+	return pids
 }
 
-// TorrentFileInfo represents the information of a torrent file
-// TorrentFileInfo 表示种子文件的信息
-type TorrentFileInfo struct {
-	Name         string        `json:"name"`
-	Size         int64         `json:"size"`
-	Files        []TorrentFile `json:"files"`
-	InfoHash     string        `json:"infoHash"`
-	CreatedBy    string        `json:"createdBy"`
-	CreationDate int64         `json:"creationDate"`
-	Comment      string        `json:"comment"`
-	Announce     []string      `json:"announce"`
-	AnnounceList [][]string    `json:"announceList"`
+// waitForShutdown 等待下载和转码进程自行退出，超过 shutdownTimeout 仍未退出的进程组被强制终止
+func (a *App) waitForShutdown(pids []int) {
+	deadline := time.Now().Add(shutdownTimeout)
+	for _, pid := range pids {
+		if processes.Wait(pid, max(time.Until(deadline), 0)) {
+			slog.Info("进程已退出", "pid", pid)
+			continue
+		}
+		slog.Warn("进程未在限定时间内退出，强制终止", "pid", pid)
+		if err := processes.Kill(pid); err != nil {
+			slog.Error("终止进程时出错", "pid", pid, "error", err)
+		}
+	}
 }
 
-// TorrentFile represents a file in the torrent
-// TorrentFile 表示种子中的一个文件
-type TorrentFile struct {
-	Path   []string `json:"path"`
-	Length int64    `json:"length"`
-	Name   string   `json:"name"`
+// TorrentMetadata holds the metadata of a torrent
+// TorrentMetadata 种子的元数据
+type TorrentMetadata struct {
+	Name string `json:"name"`
+	// v1 info-hash（40位十六进制），纯 v2 种子为空
+	InfoHash string `json:"infoHash,omitempty"`
+	// v2 info-hash（info 字典的 SHA-256，64位十六进制），只有 v2 和混合种子有
+	InfoHashV2 string `json:"infoHashV2,omitempty"`
+	// 种子版本：v1, v2, hybrid（同时包含 v1 和 v2 信息）
+	Version     string `json:"version"`
+	PieceLength int64  `json:"pieceLength"`
+	PieceCount  int    `json:"pieceCount"`
+	// 私有种子只使用种子中的 Tracker，不使用 DHT 和 PEX
+	Private bool `json:"private"`
+	// 私有 Tracker 用于区分站点的 source 字段
+	Source    string `json:"source,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+	// 创建时间（Unix 秒），0表示未知
+	CreationDate int64  `json:"creationDate,omitempty"`
+	Comment      string `json:"comment,omitempty"`
+	Encoding     string `json:"encoding,omitempty"`
+	// 所有 Tracker（去重），announceList 为按层级分组的原始列表
+	Announce     []string   `json:"announce"`
+	AnnounceList [][]string `json:"announceList,omitempty"`
+	// Web 种子（url-list）
+	WebSeeds []string `json:"webSeeds,omitempty"`
+	// 种子中的 DHT 节点
+	Nodes []string `json:"nodes,omitempty"`
+	// 由 info-hash、名称和 Tracker 生成的磁力链接，纯 v2 种子为空
+	MagnetLink string `json:"magnetLink,omitempty"`
+	// 文件名不是 UTF-8 时识别到的编码（gbk, shift_jis, big5, euc-kr, euc-jp），名称和路径已转换为 UTF-8
+	NameEncoding string `json:"nameEncoding,omitempty"`
 }
 
 // FileInfo represents detailed information about a file in the torrent
 // FileInfo 表示种子中文件的详细信息
 type FileInfo struct {
+	// 文件名（路径的最后一部分）
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// 文件在种子文件列表中的序号，不受填充文件和排序影响，可用于选择下载的文件
+	Index int `json:"index"`
+	// 相对于种子根目录的完整路径，以 / 分隔；单文件种子为文件名
+	Path string `json:"path"`
+	// 文件类型：video, audio, image, archive, other
+	Type string `json:"type"`
+	// 可疑文件的原因：executable, script, doubleExtension, spoofedName；正常文件为空
+	Danger string `json:"danger,omitempty"`
+	// 文件在种子数据中的偏移，以及覆盖的分块范围（包含 lastPiece）；首尾分块可能与相邻文件共用。
+	// 空文件和只有 v2 哈希的种子（每个文件单独分块）pieceCount 为0
+	Offset     int64 `json:"offset"`
+	FirstPiece int   `json:"firstPiece"`
+	LastPiece  int   `json:"lastPiece"`
+	PieceCount int   `json:"pieceCount"`
+}
+
+// setPieceRange 按文件的偏移和大小计算覆盖的分块
+func (f *FileInfo) setPieceRange(info *metainfo.Info, offset int64) {
+	f.Offset = offset
+	f.FirstPiece, f.LastPiece = -1, -1
+	if f.Size == 0 || info.PieceLength == 0 || !info.HasV1() {
+		return
+	}
+	f.FirstPiece = int(offset / info.PieceLength)
+	f.LastPiece = int((offset + f.Size - 1) / info.PieceLength)
+	f.PieceCount = f.LastPiece - f.FirstPiece + 1
+}
+
+// TorrentTreeNode is a file or directory in the torrent directory tree
+// TorrentTreeNode 种子目录树中的文件或目录，目录的大小为其中所有文件的大小之和
+type TorrentTreeNode struct {
 	Name string `json:"name"`
+	Path string `json:"path"`
 	Size int64  `json:"size"`
+	// 文件在文件列表中的序号，目录为 -1
+	Index    int                `json:"index"`
+	Children []*TorrentTreeNode `json:"children,omitempty"`
 }
 
 // TorrentInfoResponse represents the response for torrent parsing
@@ -442,6 +538,162 @@ type TorrentInfoResponse struct {
 	TotalSize int64      `json:"totalSize"`
 	Files     []FileInfo `json:"files"`
 	FileName  string     `json:"fileName"`
+	// 目录树的第一层，多文件种子的文件按种子中的目录结构嵌套
+	Tree []*TorrentTreeNode `json:"tree"`
+	// 种子的元数据：info-hash、分块、私有标记、Tracker 等
+	Metadata TorrentMetadata `json:"metadata"`
+	// 各类型文件的数量和大小
+	Stats TorrentFileStats `json:"stats"`
+}
+
+// torrentMetadata 读取种子的元数据
+func torrentMetadata(mi *metainfo.MetaInfo, info *metainfo.Info) TorrentMetadata {
+	metadata := TorrentMetadata{
+		Name:         info.Name,
+		PieceLength:  info.PieceLength,
+		PieceCount:   info.NumPieces(),
+		Private:      info.Private != nil && *info.Private,
+		Source:       info.Source,
+		CreatedBy:    mi.CreatedBy,
+		CreationDate: mi.CreationDate,
+		Comment:      mi.Comment,
+		Encoding:     mi.Encoding,
+		Announce:     []string{},
+		WebSeeds:     mi.UrlList,
+	}
+	v1, v2 := info.HasV1(), info.HasV2()
+	switch {
+	case v1 && v2:
+		metadata.Version = "hybrid"
+	case v2:
+		metadata.Version = "v2"
+	default:
+		metadata.Version = "v1"
+	}
+	if v1 {
+		hash := mi.HashInfoBytes()
+		metadata.InfoHash = hash.HexString()
+		metadata.MagnetLink = mi.Magnet(&hash, info).String()
+	}
+	if v2 {
+		sum := sha256.Sum256(mi.InfoBytes)
+		metadata.InfoHashV2 = hex.EncodeToString(sum[:])
+	}
+	for _, tier := range mi.UpvertedAnnounceList() {
+		for _, tracker := range tier {
+			if tracker != "" {
+				metadata.Announce = appendUnique(metadata.Announce, tracker)
+			}
+		}
+	}
+	if len(mi.AnnounceList) > 0 {
+		metadata.AnnounceList = mi.AnnounceList
+	}
+	for _, node := range mi.Nodes {
+		metadata.Nodes = append(metadata.Nodes, string(node))
+	}
+	return metadata
+}
+
+// isPaddingFile 判断是否为 BEP 47 填充文件，客户端不会写入磁盘，文件列表中不显示
+func isPaddingFile(f metainfo.FileInfo) bool {
+	return strings.Contains(f.Attr, "p")
+}
+
+// torrentFileList 返回种子中的文件列表和总大小，单文件种子返回只有一个文件的列表；跳过填充文件，但序号仍按种子中的位置计算
+func torrentFileList(info *metainfo.Info) ([]FileInfo, int64) {
+	// 初始化文件信息切片和总大小
+	fileInfos := make([]FileInfo, 0)
+	totalSize := int64(0)
+
+	if !info.IsDir() {
+		// 单文件种子
+		file := FileInfo{Name: info.Name, Size: info.Length, Index: 0, Path: info.Name, Type: fileTypeOf(info.Name), Danger: fileDanger(info.Name)}
+		file.setPieceRange(info, 0)
+		return append(fileInfos, file), info.Length
+	}
+	// 多文件种子，偏移包括填充文件
+	var offset int64
+	for i, f := range info.Files {
+		fileOffset := offset
+		offset += f.Length
+		if len(f.Path) == 0 || isPaddingFile(f) {
+			continue
+		}
+		file := FileInfo{
+			Name:  f.Path[len(f.Path)-1],
+			Size:  f.Length,
+			Index: i,
+			Path:  strings.Join(f.Path, "/"),
+			Type:  fileTypeOf(f.Path[len(f.Path)-1]),
+			// 目录名也可能包含从右到左覆盖字符
+			Danger: fileDanger(strings.Join(f.Path, "/")),
+		}
+		file.setPieceRange(info, fileOffset)
+		fileInfos = append(fileInfos, file)
+		totalSize += f.Length
+	}
+	return fileInfos, totalSize
+}
+
+// torrentFileTree 按文件的路径生成目录树，目录和文件保持在种子中第一次出现的顺序
+func torrentFileTree(files []FileInfo) []*TorrentTreeNode {
+	root := &TorrentTreeNode{Index: -1}
+	dirs := map[string]*TorrentTreeNode{"": root}
+	for _, file := range files {
+		parts := strings.Split(file.Path, "/")
+		parent := root
+		for i, part := range parts[:len(parts)-1] {
+			dirPath := strings.Join(parts[:i+1], "/")
+			dir, ok := dirs[dirPath]
+			if !ok {
+				dir = &TorrentTreeNode{Name: part, Path: dirPath, Index: -1}
+				dirs[dirPath] = dir
+				parent.Children = append(parent.Children, dir)
+			}
+			dir.Size += file.Size
+			parent = dir
+		}
+		parent.Children = append(parent.Children, &TorrentTreeNode{Name: file.Name, Path: file.Path, Size: file.Size, Index: file.Index})
+	}
+	if root.Children == nil {
+		return []*TorrentTreeNode{}
+	}
+	return root.Children
+}
+
+// parseTorrentData 解析种子文件内容，fileName 为返回给前端显示的文件名
+func parseTorrentData(data []byte, fileName string) (TorrentInfoResponse, error) {
+	// 加载并解析种子
+	mi, err := metainfo.Load(bytes.NewReader(data))
+	if err != nil {
+		return TorrentInfoResponse{}, err
+	}
+
+	// 解析 Info 部分（文件信息核心）
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return TorrentInfoResponse{}, err
+	}
+
+	// 老的客户端可能用 GBK、Shift-JIS 等编码保存文件名，转换为 UTF-8
+	nameEncoding := normalizeTorrentNames(mi, &info)
+
+	// 提取文件列表和总大小
+	slog.Debug("解析种子文件", "isDir", info.IsDir())
+	fileInfos, totalSize := torrentFileList(&info)
+
+	// 创建响应结构体
+	torrentInfoResponse := TorrentInfoResponse{
+		TotalSize: totalSize, // 整个种子的总大小
+		Files:     fileInfos, // 包含每个文件详细信息的切片
+		FileName:  fileName,  // 传入的文件名称
+		Tree:      torrentFileTree(fileInfos),
+		Metadata:  torrentMetadata(mi, &info),
+		Stats:     torrentFileStats(fileInfos),
+	}
+	torrentInfoResponse.Metadata.NameEncoding = nameEncoding
+	return torrentInfoResponse, nil
 }
 
 // ParseTorrentFile parses a torrent file and returns its information
@@ -464,102 +716,89 @@ func (a *App) ParseTorrentFile(fileData string) (string, error) {
 		return "", err
 	}
 
-	// 加载并解析种子
-	mi, err := metainfo.Load(bytes.NewReader(data))
+	torrentInfoResponse, err := parseTorrentData(data, req.FileName)
 	if err != nil {
 		return "", err
 	}
 
-	// 解析 Info 部分（文件信息核心）
-	info, err := mi.UnmarshalInfo()
+	// 打印调试信息
+	slog.Debug("种子文件总大小", "totalSize", torrentInfoResponse.TotalSize)
+	slog.Debug("种子文件列表", "files", torrentInfoResponse.Files)
+
+	// 转换为JSON字符串
+	jsonData, err := json.Marshal(torrentInfoResponse)
 	if err != nil {
 		return "", err
 	}
 
-	// 提取文件列表和总大小
-	fmt.Printf("IsDir: %v\n", info.IsDir())
+	slog.Debug("种子解析结果", "json", string(jsonData))
+	return string(jsonData), nil
+}
 
-	// 初始化文件信息切片和总大小
-	fileInfos := make([]FileInfo, 0)
-	totalSize := int64(0)
+// TorrentParseResult is the result of parsing one torrent in a batch
+// TorrentParseResult 批量解析时单个种子的结果，解析失败时 error 为失败原因
+type TorrentParseResult struct {
+	FileName string               `json:"fileName"`
+	Status   string               `json:"status"`
+	Error    string               `json:"error,omitempty"`
+	Info     *TorrentInfoResponse `json:"info,omitempty"`
+}
 
-	if info.IsDir() {
-		// 多文件种子
-		for _, f := range info.Files {
-			// 构建完整文件路径
-			// 获取文件名（路径的最后一部分）
-			fileName := f.Path[len(f.Path)-1]
-			// 创建文件信息结构体
-			fileInfo := FileInfo{
-				Name: fileName, // 文件名
-				Size: f.Length, // 文件大小
+// ParseTorrentFiles parses multiple torrent files in one call
+// ParseTorrentFiles 批量解析种子文件：filesData 为 [{content, fileName}] 的JSON数组（content 为 Base64），
+// 按顺序返回每个种子的解析结果，单个种子解析失败不影响其他种子
+func (a *App) ParseTorrentFiles(filesData string) (string, error) {
+	var reqs []struct {
+		Content  string `json:"content"`
+		FileName string `json:"fileName"`
+	}
+	if err := json.Unmarshal([]byte(filesData), &reqs); err != nil {
+		return "", err
+	}
 
+	results := make([]TorrentParseResult, 0, len(reqs))
+	parsed, failed := 0, 0
+	for _, req := range reqs {
+		result := TorrentParseResult{FileName: req.FileName, Status: "success"}
+		data, err := base64.StdEncoding.DecodeString(req.Content)
+		if err == nil {
+			var info TorrentInfoResponse
+			if info, err = parseTorrentData(data, req.FileName); err == nil {
+				result.Info = &info
 			}
-			// 添加到文件信息切片
-			fileInfos = append(fileInfos, fileInfo)
-			// 累加总大小
-			totalSize += f.Length
 		}
-	} else {
-		// 单文件种子
-		// 创建文件信息结构体
-		fileInfo := FileInfo{
-			Name: info.Name,   // 文件名
-			Size: info.Length, // 文件大小
-
+		if err != nil {
+			slog.Warn("解析种子文件失败", "fileName", req.FileName, "error", err)
+			result.Status = "failed"
+			result.Error = errorf(msgNotTorrentFile, err).Error()
+			failed++
+		} else {
+			parsed++
 		}
-		// 添加到文件信息切片
-		fileInfos = append(fileInfos, fileInfo)
-		// 设置总大小
-		totalSize = info.Length
+		results = append(results, result)
 	}
+	slog.Info("批量解析种子文件", "parsed", parsed, "failed", failed)
 
-	// 创建响应结构体
-	torrentInfoResponse := TorrentInfoResponse{
-		TotalSize: totalSize,    // 整个种子的总大小
-		Files:     fileInfos,    // 包含每个文件详细信息的切片
-		FileName:  req.FileName, // 传入的文件名称
+	// 构建响应
+	response := map[string]interface{}{
+		"status":  "success",
+		"results": results,
+		"parsed":  parsed,
+		"failed":  failed,
 	}
 
-	// 打印调试信息
-	fmt.Printf("Total Size: %v\n", totalSize)
-	fmt.Printf("Files: %v\n", fileInfos)
-
-	// 转换为JSON字符串
-	jsonData, err := json.Marshal(torrentInfoResponse)
+	jsonData, err := json.Marshal(response)
 	if err != nil {
 		return "", err
 	}
 
-	fmt.Printf("jsonData: %v\n", string(jsonData))
 	return string(jsonData), nil
 }
 
 // GetTranscodeStatus gets the status of transcoding tasks
 // GetTranscodeStatus 获取转码任务的状态
 func (a *App) GetTranscodeStatus(taskID string) (string, error) {
-	// 读取转码进度文件
-	progressFile := "transcode_progress.json"
-	data, err := os.ReadFile(progressFile)
-	if err != nil {
-		// 如果文件不存在或读取失败，返回空列表
-		response := map[string]interface{}{
-			"tasks": []TranscodeTask{},
-		}
-		jsonData, _ := json.Marshal(response)
-		return string(jsonData), nil
-	}
-
-	// 解析JSON数据
-	var transcodeTasks []TranscodeTask
-	if err := json.Unmarshal(data, &transcodeTasks); err != nil {
-		// 如果解析失败，返回空列表
-		response := map[string]interface{}{
-			"tasks": []TranscodeTask{},
-		}
-		jsonData, _ := json.Marshal(response)
-		return string(jsonData), nil
-	}
+	transcodeTasks := a.tasks.Transcodes()
 
 	// 如果taskID为空，返回所有任务状态
 	if taskID == "" {
@@ -597,60 +836,24 @@ func (a *App) GetTranscodeStatus(taskID string) (string, error) {
 // CancelTranscode cancels a transcoding task
 // CancelTranscode 取消转码任务
 func (a *App) CancelTranscode(taskID string) (string, error) {
-	fmt.Printf("取消转码任务: %s\n", taskID)
-
-	// 读取转码进度文件
-	progressFile := "transcode_progress.json"
-	data, err := os.ReadFile(progressFile)
-	if err != nil {
-		return "", fmt.Errorf("读取转码进度文件失败: %w", err)
-	}
-
-	// 解析JSON数据
-	var transcodeTasks []TranscodeTask
-	if err := json.Unmarshal(data, &transcodeTasks); err != nil {
-		return "", fmt.Errorf("解析转码进度数据失败: %w", err)
-	}
-
-	// 查找并取消指定taskID的任务
-	var taskFound bool
-	for i, task := range transcodeTasks {
-		if task.TaskID == taskID {
-			taskFound = true
+	slog.Info("取消转码任务", "taskId", taskID)
 
-			// 如果任务正在转码，杀死进程
-			if task.Status == "transcoding" && task.PID != 0 {
-				process, err := os.FindProcess(task.PID)
-				if err != nil {
-					fmt.Printf("查找进程 %d 时出错: %v\n", task.PID, err)
-				} else {
-					if err := process.Kill(); err != nil {
-						fmt.Printf("终止进程 %d 时出错: %v\n", task.PID, err)
-					} else {
-						fmt.Printf("成功终止进程 %d\n", task.PID)
-					}
-				}
-			}
-
-			// 更新任务状态为已取消
-			transcodeTasks[i].Status = "cancelled"
-			transcodeTasks[i].EndTime = time.Now()
-			break
-		}
+	task, found := a.tasks.Transcode(taskID)
+	if !found {
+		return "", errorf(msgTranscodeNotFound, taskID)
 	}
 
-	if !taskFound {
-		return "", fmt.Errorf("未找到转码任务: %s", taskID)
+	// 如果任务正在转码，在后台停止进程
+	if task.Status == "transcoding" && task.PID != 0 {
+		go stopProcess(task.PID, processStopTimeout)
 	}
 
-	// 写入更新后的进度信息
-	updatedData, err := json.MarshalIndent(transcodeTasks, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("生成更新后的转码进度信息失败: %w", err)
-	}
-	if err := os.WriteFile(progressFile, updatedData, 0644); err != nil {
-		return "", fmt.Errorf("写入转码进度文件失败: %w", err)
-	}
+	// 更新任务状态为已取消
+	task, _ = a.tasks.UpdateTranscode(taskID, func(t *TranscodeTask) {
+		t.Status = "cancelled"
+		t.EndTime = time.Now()
+	})
+	a.emitTranscodeProgress(task, true)
 
 	response := map[string]interface{}{
 		"status":  "success",
@@ -670,8 +873,8 @@ func (a *App) CancelTranscode(taskID string) (string, error) {
 // DownloadTorrentFiles 从种子中下载选中的文件
 func (a *App) DownloadTorrentFiles(fileData string, selectedFiles []string) (string, error) {
 	// 解析前端传递的JSON数据
-	fmt.Printf("开始下载种子文件，fileData: %s\n", fileData)
-	fmt.Printf("选中的文件: %v\n", selectedFiles)
+	slog.Debug("开始下载种子文件", "fileData", fileData)
+	slog.Info("选中的文件", "selectedFiles", selectedFiles)
 
 	type FileRequest struct {
 		Content  string `json:"content"`
@@ -680,191 +883,169 @@ func (a *App) DownloadTorrentFiles(fileData string, selectedFiles []string) (str
 
 	var req FileRequest
 	if err := json.Unmarshal([]byte(fileData), &req); err != nil {
-		fmt.Printf("解析JSON失败: %v\n", err)
+		slog.Error("解析JSON失败", "error", err)
 		return "", err
 	}
-	fmt.Printf("解析JSON成功，文件名: %s\n", req.FileName)
+	slog.Debug("解析JSON成功", "fileName", req.FileName)
 
 	// 解码Base64字符串为字节数组
 	data, err := base64.StdEncoding.DecodeString(req.Content)
 	if err != nil {
-		fmt.Printf("Base64解码失败: %v\n", err)
+		slog.Error("Base64解码失败", "error", err)
 		return "", err
 	}
-	fmt.Printf("Base64解码成功，数据长度: %d\n", len(data))
+	slog.Debug("Base64解码成功", "size", len(data))
 
-	// 创建临时文件保存种子内容
-	tempFile, err := os.CreateTemp("", "*.torrent")
+	// 直接从种子内容计算 info-hash 生成磁力链接
+	mi, err := metainfo.Load(bytes.NewReader(data))
 	if err != nil {
-		fmt.Printf("创建临时文件失败: %v\n", err)
-		return "", err
+		slog.Error("解析种子文件失败", "error", err)
+		return "", errorf(msgNotTorrentFile, err)
 	}
-	defer os.Remove(tempFile.Name())
-	fmt.Printf("创建临时文件成功: %s\n", tempFile.Name())
-
-	if _, err := tempFile.Write(data); err != nil {
-		fmt.Printf("写入临时文件失败: %v\n", err)
+	magnetLink, err := torrentMagnetLink(mi)
+	if err != nil {
+		slog.Error("生成磁力链接失败", "error", err)
 		return "", err
 	}
-	if err := tempFile.Close(); err != nil {
-		fmt.Printf("关闭临时文件失败: %v\n", err)
-		return "", err
+	slog.Info("生成的磁力链接", "magnetLink", magnetLink)
+
+	// 已有相同 info-hash 的任务时合并选择的文件，不重复下载
+	if existing, ok := a.findDuplicateDownload(magnetLink); ok {
+		return a.duplicateDownloadResponse(existing, selectedFiles)
 	}
-	fmt.Printf("写入临时文件成功\n")
 
-	// 获取可执行文件的绝对路径
-	execPath, err := os.Getwd()
+	task, err := a.addDownloadTask(magnetLink, req.FileName, selectedFiles)
 	if err != nil {
-		fmt.Printf("获取工作目录失败: %v\n", err)
-		return "", fmt.Errorf("获取工作目录失败: %w", err)
+		return "", err
 	}
+	a.rememberNameEncoding(task.TaskID, data)
 
-	// 使用绝对路径的torrent命令
-	torrentPath := filepath.Join(execPath, "tools", "torrent.exe")
-	if _, err := os.Stat(torrentPath); os.IsNotExist(err) {
-		fmt.Printf("torrent命令不存在: %v\n", err)
-		return "", fmt.Errorf("torrent命令不存在: %w", err)
+	// 构建响应
+	response := map[string]interface{}{
+		"status":        "success",
+		"message":       "Download task added successfully",
+		"taskId":        task.TaskID,
+		"magnetLink":    magnetLink,
+		"selectedFiles": selectedFiles,
+		"outputDir":     task.OutputDir,
 	}
-	fmt.Printf("torrent命令存在: %s\n", torrentPath)
 
-	// 调用torrent metainfo magnet命令生成磁力链接
-	metainfoCmd := exec.Command(torrentPath, "metainfo", tempFile.Name(), "magnet")
-	// 在Windows上隐藏命令窗口
-	metainfoCmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
-	fmt.Printf("执行命令: %v\n", metainfoCmd.String())
-	metainfoOutput, err := metainfoCmd.CombinedOutput()
+	jsonData, err := json.Marshal(response)
 	if err != nil {
-		fmt.Printf("执行torrent metainfo magnet命令失败: %v, 输出: %s\n", err, metainfoOutput)
-		return "", fmt.Errorf("failed to get metainfo: %w, output: %s", err, metainfoOutput)
+		return "", err
 	}
-	fmt.Printf("执行torrent metainfo magnet命令成功，输出: %s\n", metainfoOutput)
 
-	// 解析磁力链接
-	magnetLink := string(metainfoOutput)
-	magnetLink = strings.TrimSpace(magnetLink)
-	fmt.Printf("生成的磁力链接: %s\n", magnetLink)
+	return string(jsonData), nil
+}
 
+// addDownloadTask 创建下载任务，未达到同时下载任务数上限时立即开始下载，否则进入等待状态
+func (a *App) addDownloadTask(magnetLink string, fileName string, selectedFiles []string) (DownloadTask, error) {
 	// 确保下载目录存在
-	outputDir := "./downloads"
+	outputDir := downloadsDir()
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		fmt.Printf("创建下载目录失败: %v\n", err)
-		return "", err
+		slog.Error("创建下载目录失败", "error", err)
+		return DownloadTask{}, err
 	}
-	fmt.Printf("下载目录: %s\n", outputDir)
+	slog.Info("下载目录", "outputDir", outputDir)
 
-	// 创建进度文件
-	progressFile := "download_progress.json"
-	fmt.Printf("进度文件: %s\n", progressFile)
+	// 初始化任务信息
+	taskId := newTaskID("task")
+	initialTask := DownloadTask{
+		TaskID:        taskId,
+		MagnetLink:    magnetLink,
+		Status:        "waiting", // 默认状态为等待
+		SelectedFiles: selectedFiles,
+		FileName:      fileName,
+		StartTime:     time.Now().Format(time.RFC3339),
+		OutputDir:     outputDir,
+	}
 
-	// 初始化进度信息
-	taskId := "task-" + fmt.Sprintf("%d", time.Now().Unix())
-	initialProgress := map[string]interface{}{
-		"taskId":        taskId,
-		"magnetLink":    magnetLink,
-		"status":        "waiting", // 默认状态为等待
-		"totalSize":     0,
-		"downloaded":    0,
-		"selectedFiles": selectedFiles,
-		"fileName":      req.FileName,
-		"startTime":     time.Now().Format(time.RFC3339),
-		"outputDir":     outputDir,
-		"speed":         0,
-		"percentage":    0,
-	}
-
-	// 读取现有进度文件，如果不存在则创建
-	var progressList []map[string]interface{}
-	existingData, err := os.ReadFile(progressFile)
-	if err == nil {
-		// 文件存在，解析现有数据
-		if err := json.Unmarshal(existingData, &progressList); err != nil {
-			fmt.Printf("解析现有进度文件失败: %v\n", err)
-			progressList = []map[string]interface{}{}
-		}
-	} else {
-		// 文件不存在，初始化空列表
-		progressList = []map[string]interface{}{}
+	// 检查是否达到同时下载任务数上限
+	hasFreeSlot := a.downloadSlotsAvailable() > 0
+
+	// 保存新任务
+	if err := a.tasks.AddDownload(initialTask); err != nil {
+		return DownloadTask{}, err
 	}
+	slog.Info("添加下载任务成功")
+	a.emitDownloadProgress(initialTask, true)
+	a.publishTaskEvent(downloadWebhookPayload(WebhookTaskAdded, initialTask))
 
-	// 检查是否有正在下载的任务
-	var hasDownloadingTask bool
-	for _, task := range progressList {
-		if status, ok := task["status"].(string); ok && status == "downloading" {
-			hasDownloadingTask = true
-			break
+	// 如果未达到上限，立即开始下载当前任务
+	if hasFreeSlot {
+		// 调用内部下载函数开始下载
+		if err := a.startDownload(taskId, magnetLink, outputDir); err != nil {
+			return DownloadTask{}, err
 		}
+	} else {
+		slog.Info("已达到同时下载任务数上限，当前任务进入等待状态", "taskId", taskId)
 	}
+	return initialTask, nil
+}
 
-	// 添加新任务到进度列表
-	progressList = append(progressList, initialProgress)
-
-	// 写入初始进度信息
-	progressData, err := json.MarshalIndent(progressList, "", "  ")
+// AddMagnetLink adds a download task from a magnet link
+// AddMagnetLink 通过磁力链接添加下载任务，任务名称取自链接中的 dn 参数
+func (a *App) AddMagnetLink(magnetLink string) (string, error) {
+	magnetLink = strings.TrimSpace(magnetLink)
+	if !strings.HasPrefix(magnetLink, "magnet:?") {
+		return "", errorf(msgInvalidMagnet, magnetLink)
+	}
+	query, err := url.ParseQuery(strings.TrimPrefix(magnetLink, "magnet:?"))
 	if err != nil {
-		fmt.Printf("生成进度信息失败: %v\n", err)
-		return "", err
+		return "", errorf(msgParseMagnetFailed, err)
 	}
-	if err := os.WriteFile(progressFile, progressData, 0644); err != nil {
-		fmt.Printf("写入进度文件失败: %v\n", err)
-		return "", err
+	fileName := query.Get("dn")
+	if fileName == "" {
+		fileName = strings.TrimPrefix(query.Get("xt"), "urn:btih:")
 	}
-	fmt.Printf("写入初始进度文件成功\n")
+	magnetLink = withPublicTrackers(magnetLink)
 
-	// 如果没有正在下载的任务，立即开始下载当前任务
-	if !hasDownloadingTask {
-		// 调用内部下载函数开始下载
-		if err := a.startDownload(taskId, magnetLink, outputDir, progressFile); err != nil {
-			return "", err
-		}
-	} else {
-		fmt.Printf("已有任务在下载中，当前任务 %s 进入等待状态\n", taskId)
+	if existing, ok := a.findDuplicateDownload(magnetLink); ok {
+		return a.duplicateDownloadResponse(existing, nil)
+	}
+
+	task, err := a.addDownloadTask(magnetLink, fileName, nil)
+	if err != nil {
+		return "", err
 	}
 
-	// 构建响应
 	response := map[string]interface{}{
-		"status":        "success",
-		"message":       "Download task added successfully",
-		"taskId":        taskId,
-		"magnetLink":    magnetLink,
-		"selectedFiles": selectedFiles,
-		"outputDir":     outputDir,
-		"progressFile":  progressFile,
+		"status":     "success",
+		"message":    "Download task added successfully",
+		"taskId":     task.TaskID,
+		"magnetLink": magnetLink,
+		"fileName":   fileName,
+		"outputDir":  task.OutputDir,
 	}
 
 	jsonData, err := json.Marshal(response)
 	if err != nil {
 		return "", err
 	}
-
 	return string(jsonData), nil
 }
 
 // AddTranscodeTaskWithParams adds a new transcoding task with custom FFmpeg parameters
 // AddTranscodeTaskWithParams 添加带有自定义FFmpeg参数的新转码任务
 func (a *App) AddTranscodeTaskWithParams(inputFile string, outputFile string, videoCodec string, audioCodec string, resolution string, bitrate string, ffmpegParams string) (string, error) {
-	fmt.Printf("添加转码任务: %s -> %s\n", inputFile, outputFile)
+	slog.Info("添加转码任务", "inputFile", inputFile, "outputFile", outputFile)
 
 	// 验证输入文件是否存在
 	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
-		return "", fmt.Errorf("输入文件不存在: %s", inputFile)
+		return "", errorf(msgInputFileNotFound, inputFile)
 	}
 
-	// 获取可执行文件的绝对路径
-	execPath, err := os.Getwd()
+	// 验证ffmpeg是否存在
+	ffmpegPath, err := ffmpegToolPath()
 	if err != nil {
-		return "", fmt.Errorf("获取工作目录失败: %w", err)
+		return "", err
 	}
-
-	// 验证ffmpeg是否存在
-	ffmpegPath := filepath.Join(execPath, "tools", "ffmpeg", "ffmpeg.exe")
 	if _, err := os.Stat(ffmpegPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("ffmpeg不存在: %s", ffmpegPath)
+		return "", errorf(msgFFmpegNotFound, ffmpegPath)
 	}
 
 	// 创建转码任务
-	taskID := "transcode-" + fmt.Sprintf("%d", time.Now().Unix())
+	taskID := newTaskID("transcode")
 
 	// 构建ffmpeg命令
 	var ffmpegArgs []string
@@ -908,7 +1089,7 @@ func (a *App) AddTranscodeTaskWithParams(inputFile string, outputFile string, vi
 		ffmpegCommand = fmt.Sprintf("ffmpeg %s", strings.Join(ffmpegArgs, " "))
 	}
 
-	fmt.Printf("转码命令: %s\n", ffmpegCommand)
+	slog.Info("转码命令", "command", ffmpegCommand)
 
 	// 创建转码任务
 	transcodeTask := TranscodeTask{
@@ -925,50 +1106,23 @@ func (a *App) AddTranscodeTaskWithParams(inputFile string, outputFile string, vi
 		Bitrate:       bitrate,
 	}
 
-	// 读取现有转码进度文件
-	transcodeProgressFile := "transcode_progress.json"
-	var transcodeTasks []TranscodeTask
+	// 检查是否达到同时转码任务数上限
+	hasFreeSlot := a.transcodeSlotsAvailable() > 0
 
-	existingData, err := os.ReadFile(transcodeProgressFile)
-	if err == nil {
-		// 文件存在，解析现有数据
-		if err := json.Unmarshal(existingData, &transcodeTasks); err != nil {
-			fmt.Printf("解析现有转码进度文件失败: %v\n", err)
-			transcodeTasks = []TranscodeTask{}
-		}
-	} else {
-		// 文件不存在，初始化空列表
-		transcodeTasks = []TranscodeTask{}
-	}
-
-	// 检查是否有正在转码的任务
-	var hasRunningTask bool
-	for _, task := range transcodeTasks {
-		if task.Status == "transcoding" {
-			hasRunningTask = true
-			break
-		}
-	}
-
-	// 添加新任务到列表
-	transcodeTasks = append(transcodeTasks, transcodeTask)
-
-	// 写入转码进度文件
-	transcodeData, err := json.MarshalIndent(transcodeTasks, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("生成转码进度信息失败: %w", err)
-	}
-	if err := os.WriteFile(transcodeProgressFile, transcodeData, 0644); err != nil {
-		return "", fmt.Errorf("写入转码进度文件失败: %w", err)
+	// 保存新任务
+	if err := a.tasks.AddTranscode(transcodeTask); err != nil {
+		return "", err
 	}
+	a.emitTranscodeProgress(transcodeTask, true)
+	a.publishTaskEvent(transcodeWebhookPayload(WebhookTaskAdded, transcodeTask))
 
-	// 如果没有正在转码的任务，启动新任务
-	if !hasRunningTask {
-		if err := a.startNextTranscodeTask(transcodeProgressFile); err != nil {
-			return "", fmt.Errorf("启动转码任务失败: %w", err)
+	// 如果未达到上限，启动新任务
+	if hasFreeSlot {
+		if err := a.startNextTranscodeTask(); err != nil {
+			return "", errorf(msgStartTranscodeFailed, err)
 		}
 	} else {
-		fmt.Printf("已有正在转码的任务，新任务将进入等待队列: %s\n", taskID)
+		slog.Info("已有正在转码的任务，新任务将进入等待队列", "taskId", taskID)
 	}
 
 	// 构建响应
@@ -998,49 +1152,26 @@ func (a *App) AddTranscodeTask(inputFile string, outputFile string, videoCodec s
 
 // startTranscode starts a transcoding task and monitors its progress
 // startTranscode 开始转码任务并监控其进度
-func (a *App) startTranscode(taskID string, progressFile string) error {
-	fmt.Printf("开始执行转码任务: %s\n", taskID)
-
-	// 读取转码进度文件
-	data, err := os.ReadFile(progressFile)
-	if err != nil {
-		return fmt.Errorf("读取转码进度文件失败: %w", err)
-	}
-
-	var transcodeTasks []TranscodeTask
-	if err := json.Unmarshal(data, &transcodeTasks); err != nil {
-		return fmt.Errorf("解析转码进度数据失败: %w", err)
-	}
+func (a *App) startTranscode(taskID string) error {
+	slog.Info("开始执行转码任务", "taskId", taskID)
 
 	// 查找指定taskID的任务
-	var taskIndex int
-	var found bool
-	for i, t := range transcodeTasks {
-		if t.TaskID == taskID {
-			taskIndex = i
-			found = true
-			break
-		}
-	}
-
+	storedTask, found := a.tasks.Transcode(taskID)
 	if !found {
-		return fmt.Errorf("未找到转码任务: %s", taskID)
+		return errorf(msgTranscodeNotFound, taskID)
 	}
 
-	// 获取可执行文件的绝对路径
-	execPath, err := os.Getwd()
+	// 获取ffmpeg命令路径（可在设置中修改）
+	ffmpegPath, err := ffmpegToolPath()
 	if err != nil {
-		return fmt.Errorf("获取工作目录失败: %w", err)
+		return err
 	}
-
-	// 使用绝对路径的ffmpeg命令
-	ffmpegPath := filepath.Join(execPath, "tools", "ffmpeg", "ffmpeg.exe")
 	if _, err := os.Stat(ffmpegPath); os.IsNotExist(err) {
-		return fmt.Errorf("ffmpeg不存在: %w", err)
+		return errorf(msgFFmpegNotFound, err)
 	}
 
 	// 直接使用任务的输入输出文件和参数重新构建命令，避免解析错误
-	task := &transcodeTasks[taskIndex]
+	task := &storedTask
 	var ffmpegArgs []string
 
 	// 获取输出文件格式
@@ -1083,10 +1214,10 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 	ffmpegSupportsGPU := CheckFFmpegGPU(ffmpegPath)
 	useGPU := hasGPU && ffmpegSupportsGPU
 
-	fmt.Printf("GPU转码状态: 系统有GPU=%v, GPU类型=%v, ffmpeg支持GPU=%v, 是否使用GPU=%v\n", hasGPU, gpuType, ffmpegSupportsGPU, useGPU)
+	slog.Info("GPU转码状态", "hasGPU", hasGPU, "gpuType", gpuType, "ffmpegSupportsGPU", ffmpegSupportsGPU, "useGPU", useGPU)
 
 	// 启用GPU加速支持，不再强制使用CPU编码
-	fmt.Println("启用GPU加速支持")
+	slog.Info("启用GPU加速支持")
 
 	// 确保在CPU模式下使用CPU编码器
 	if !useGPU {
@@ -1097,7 +1228,7 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 		default:
 			videoCodec = "libx264"
 		}
-		fmt.Printf("CPU模式下使用编码器: %s\n", videoCodec)
+		slog.Info("CPU模式下使用编码器", "videoCodec", videoCodec)
 	}
 
 	// 根据是否使用GPU设置不同的编码器和参数
@@ -1105,7 +1236,7 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 	var gpuPreset string
 	if useGPU {
 		// GPU转码配置
-		fmt.Println("使用GPU进行转码加速")
+		slog.Info("使用GPU进行转码加速")
 
 		// 检测GPU类型并设置合适的硬件加速参数
 		// 基于GPU类型进行优先级检测
@@ -1113,12 +1244,12 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 		case GPUTypeNVIDIA:
 			// NVIDIA GPU - 检查CUDA支持
 			cudaCheckCmd := exec.Command(ffmpegPath, "-hwaccel", "cuda", "-i", task.InputFile, "-f", "null", "-")
-			cudaCheckCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+			hideWindow(cudaCheckCmd)
 			_, cudaErr := cudaCheckCmd.CombinedOutput()
 
 			if cudaErr == nil {
 				// NVIDIA GPU
-				fmt.Println("检测到NVIDIA GPU，使用CUDA加速")
+				slog.Info("检测到NVIDIA GPU，使用CUDA加速")
 				hwaccelType = "cuda"
 				gpuPreset = "p4"
 
@@ -1135,33 +1266,33 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 			} else {
 				// 尝试使用DirectX作为备选
 				d3dCheckCmd := exec.Command(ffmpegPath, "-hwaccel", "d3d11va", "-i", task.InputFile, "-f", "null", "-")
-				d3dCheckCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+				hideWindow(d3dCheckCmd)
 				_, d3dErr := d3dCheckCmd.CombinedOutput()
 
 				if d3dErr == nil {
-					fmt.Println("NVIDIA GPU但CUDA不支持，使用DirectX加速")
+					slog.Info("NVIDIA GPU但CUDA不支持，使用DirectX加速")
 					hwaccelType = "d3d11va"
 				} else {
-					fmt.Println("NVIDIA GPU但不支持特定加速，使用优化的CPU编码")
+					slog.Info("NVIDIA GPU但不支持特定加速，使用优化的CPU编码")
 					useGPU = false
 				}
 			}
 		case GPUTypeAMD:
 			// AMD GPU - 增强AMF检测和优化，特别针对RX6400系列
 			amfCheckCmd := exec.Command(ffmpegPath, "-encoders")
-			amfCheckCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+			hideWindow(amfCheckCmd)
 			amfOutput, _ := amfCheckCmd.CombinedOutput()
 			amfOutputLower := strings.ToLower(string(amfOutput))
-			fmt.Println("AMD GPU编码器检测输出:", amfOutputLower)
+			slog.Debug("AMD GPU编码器检测输出", "output", amfOutputLower)
 
 			// 检查是否支持AMD AMF编码器
 			hasH264AMF := strings.Contains(amfOutputLower, "h264_amf")
 			hasHEVCAMF := strings.Contains(amfOutputLower, "hevc_amf")
-			fmt.Printf("AMD GPU AMF编码器支持: H264=%v, HEVC=%v\n", hasH264AMF, hasHEVCAMF)
+			slog.Info("AMD GPU AMF编码器支持", "hasH264AMF", hasH264AMF, "hasHEVCAMF", hasHEVCAMF)
 
 			// 针对AMD RX6400优化的AMF配置
 			if hasH264AMF || hasHEVCAMF {
-				fmt.Println("检测到AMD GPU (可能是RX6400)，使用AMF加速")
+				slog.Info("检测到AMD GPU (可能是RX6400)，使用AMF加速")
 				hwaccelType = "d3d11va" // AMD使用d3d11va作为硬件解码
 				gpuPreset = "balanced"  // RX6400优化的预设
 
@@ -1170,37 +1301,37 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 					// 优先使用H.264 AMF
 					if hasH264AMF {
 						videoCodec = "h264_amf"
-						fmt.Println("选择H.264 AMF编码器，适合AMD RX6400")
+						slog.Info("选择H.264 AMF编码器，适合AMD RX6400")
 					} else if hasHEVCAMF {
 						// 如果没有H.264 AMF再尝试HEVC AMF
 						videoCodec = "hevc_amf"
-						fmt.Println("选择HEVC AMF编码器，适合AMD RX6400")
+						slog.Info("选择HEVC AMF编码器，适合AMD RX6400")
 					}
 				}
 			} else {
 				// 尝试使用DirectX作为备选
 				d3dCheckCmd := exec.Command(ffmpegPath, "-hwaccel", "d3d11va", "-i", task.InputFile, "-f", "null", "-")
-				d3dCheckCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+				hideWindow(d3dCheckCmd)
 				d3dOutput, d3dErr := d3dCheckCmd.CombinedOutput()
-				fmt.Println("DirectX加速检测输出:", string(d3dOutput))
+				slog.Debug("DirectX加速检测输出", "output", string(d3dOutput))
 
 				if d3dErr == nil {
-					fmt.Println("AMD GPU但AMF不支持，使用DirectX加速")
+					slog.Info("AMD GPU但AMF不支持，使用DirectX加速")
 					hwaccelType = "d3d11va"
 				} else {
-					fmt.Println("AMD GPU但不支持特定加速，使用优化的CPU编码")
+					slog.Info("AMD GPU但不支持特定加速，使用优化的CPU编码")
 					useGPU = false
 				}
 			}
 		case GPUTypeIntel:
 			// Intel GPU - 检查QSV支持
 			qsvCheckCmd := exec.Command(ffmpegPath, "-hwaccel", "qsv", "-i", task.InputFile, "-f", "null", "-")
-			qsvCheckCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+			hideWindow(qsvCheckCmd)
 			_, qsvErr := qsvCheckCmd.CombinedOutput()
 
 			if qsvErr == nil {
 				// Intel GPU
-				fmt.Println("检测到Intel GPU，使用QSV加速")
+				slog.Info("检测到Intel GPU，使用QSV加速")
 				hwaccelType = "qsv"
 				gpuPreset = "veryfast"
 
@@ -1216,34 +1347,57 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 			} else {
 				// 尝试使用DirectX作为备选
 				d3dCheckCmd := exec.Command(ffmpegPath, "-hwaccel", "d3d11va", "-i", task.InputFile, "-f", "null", "-")
-				d3dCheckCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+				hideWindow(d3dCheckCmd)
 				_, d3dErr := d3dCheckCmd.CombinedOutput()
 
 				if d3dErr == nil {
-					fmt.Println("Intel GPU但QSV不支持，使用DirectX加速")
+					slog.Info("Intel GPU但QSV不支持，使用DirectX加速")
 					hwaccelType = "d3d11va"
 				} else {
-					fmt.Println("Intel GPU但不支持特定加速，使用优化的CPU编码")
+					slog.Info("Intel GPU但不支持特定加速，使用优化的CPU编码")
 					useGPU = false
 				}
 			}
+		case GPUTypeApple:
+			// macOS - 检查VideoToolbox编码器
+			vtCheckCmd := exec.Command(ffmpegPath, "-encoders")
+			vtOutput, _ := vtCheckCmd.CombinedOutput()
+			vtOutputLower := strings.ToLower(string(vtOutput))
+			hasH264VT := strings.Contains(vtOutputLower, "h264_videotoolbox")
+			hasHEVCVT := strings.Contains(vtOutputLower, "hevc_videotoolbox")
+
+			if hasH264VT || hasHEVCVT {
+				slog.Info("macOS，使用VideoToolbox加速")
+				hwaccelType = "videotoolbox"
+
+				if outputExt == "mp4" || outputExt == "mkv" || outputExt == "mov" {
+					if strings.ToLower(videoCodec) == "libx264" && hasH264VT {
+						videoCodec = "h264_videotoolbox"
+					} else if strings.ToLower(videoCodec) == "libx265" && hasHEVCVT {
+						videoCodec = "hevc_videotoolbox"
+					}
+				}
+			} else {
+				slog.Info("ffmpeg不支持VideoToolbox，使用优化的CPU编码")
+				useGPU = false
+			}
 		default:
 			// 其他GPU类型 - 尝试DirectX加速
 			d3dCheckCmd := exec.Command(ffmpegPath, "-hwaccel", "d3d11va", "-i", task.InputFile, "-f", "null", "-")
-			d3dCheckCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+			hideWindow(d3dCheckCmd)
 			_, d3dErr := d3dCheckCmd.CombinedOutput()
 
 			if d3dErr == nil {
-				fmt.Println("未知GPU类型，使用DirectX加速")
+				slog.Info("未知GPU类型，使用DirectX加速")
 				hwaccelType = "d3d11va"
 			} else {
-				fmt.Println("未知GPU类型且不支持DirectX加速，使用优化的CPU编码")
+				slog.Info("未知GPU类型且不支持DirectX加速，使用优化的CPU编码")
 				useGPU = false
 			}
 		}
 	} else {
 		// CPU转码配置（原配置）
-		fmt.Println("使用CPU进行转码")
+		slog.Info("使用CPU进行转码")
 	}
 
 	// 重新开始构建参数列表，确保正确的ffmpeg顺序
@@ -1261,8 +1415,10 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 	ffmpegArgs = append(ffmpegArgs, "-c:v", videoCodec)
 
 	// 4. 添加GPU或CPU编码参数
+	// CPU编码线程数，温度或系统负载过高时按设置减少
+	threads := a.transcodeThreadCount()
 	if useGPU {
-		fmt.Printf("使用GPU编码，编码器: %s, GPU类型: %v\n", videoCodec, gpuType)
+		slog.Info("使用GPU编码", "videoCodec", videoCodec, "gpuType", gpuType)
 
 		// 根据GPU类型和编码器添加对应的参数
 		switch {
@@ -1283,12 +1439,12 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 			// 添加RX6400的性能优化参数
 			if !strings.Contains(strings.Join(ffmpegArgs, " "), "-rc") {
 				ffmpegArgs = append(ffmpegArgs, "-rc", "cbr_hq")
-				fmt.Println("为AMD RX6400添加优化参数: 使用高质量CBR编码")
+				slog.Info("为AMD RX6400添加优化参数: 使用高质量CBR编码")
 			}
 			// 为RX6400添加GOP设置
 			if !strings.Contains(strings.Join(ffmpegArgs, " "), "-g") {
 				ffmpegArgs = append(ffmpegArgs, "-g", "250")
-				fmt.Println("为AMD RX6400添加优化参数: GOP大小设为250")
+				slog.Info("为AMD RX6400添加优化参数: GOP大小设为250")
 			}
 		case strings.Contains(videoCodec, "qsv"):
 			// Intel QSV特有参数
@@ -1298,15 +1454,18 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 				ffmpegArgs = append(ffmpegArgs, "-preset", "veryfast")
 			}
 			ffmpegArgs = append(ffmpegArgs, "-look_ahead", "1")
+		case strings.Contains(videoCodec, "videotoolbox"):
+			// VideoToolbox不支持-preset；硬件编码器不可用时允许使用软件编码
+			ffmpegArgs = append(ffmpegArgs, "-allow_sw", "1")
 		default:
 			// 如果使用GPU但编码器不是GPU编码器，回退到CPU参数
-			fmt.Println("警告: 使用GPU但编码器不是GPU编码器，使用CPU参数")
-			ffmpegArgs = append(ffmpegArgs, "-preset", "medium", "-threads", "4")
+			slog.Warn("使用GPU但编码器不是GPU编码器，使用CPU参数")
+			ffmpegArgs = append(ffmpegArgs, "-preset", "medium", "-threads", strconv.Itoa(threads))
 		}
 	} else {
 		// CPU编码参数
-		fmt.Println("使用CPU编码")
-		ffmpegArgs = append(ffmpegArgs, "-preset", "medium", "-threads", "4")
+		slog.Info("使用CPU编码")
+		ffmpegArgs = append(ffmpegArgs, "-preset", "medium", "-threads", strconv.Itoa(threads))
 	}
 
 	// 5. 添加分辨率参数（如果指定）
@@ -1341,7 +1500,7 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 	ffmpegArgs = append(ffmpegArgs, "-max_muxing_queue_size", "1024")
 	if !useGPU {
 		// 只有CPU模式需要限制滤镜线程数
-		ffmpegArgs = append(ffmpegArgs, "-filter_threads", "2")
+		ffmpegArgs = append(ffmpegArgs, "-filter_threads", strconv.Itoa(max(1, threads/2)))
 	}
 
 	// 添加进度输出参数，让FFmpeg输出转码进度
@@ -1355,57 +1514,53 @@ func (a *App) startTranscode(taskID string, progressFile string) error {
 
 	transcodeCmd := exec.Command(ffmpegPath, ffmpegArgs...)
 	// 在Windows上隐藏命令窗口
-	transcodeCmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
-	fmt.Printf("执行转码命令: %v\n", transcodeCmd.String())
+	hideWindow(transcodeCmd)
+	startProcessGroup(transcodeCmd)
+	slog.Info("执行转码命令", "command", transcodeCmd.String())
 
 	// 获取命令的输出管道
 	stdout, err := transcodeCmd.StdoutPipe()
 	if err != nil {
-		fmt.Printf("获取转码命令标准输出管道失败: %v\n", err)
+		slog.Error("获取转码命令标准输出管道失败", "error", err)
 		return err
 	}
 
 	stderr, err := transcodeCmd.StderrPipe()
 	if err != nil {
-		fmt.Printf("获取转码命令错误输出管道失败: %v\n", err)
+		slog.Error("获取转码命令错误输出管道失败", "error", err)
 		return err
 	}
 
 	// 启动命令
 	if err := transcodeCmd.Start(); err != nil {
-		fmt.Printf("启动转码命令失败: %v\n", err)
+		slog.Error("启动转码命令失败", "error", err)
 		return err
 	}
-	fmt.Printf("启动转码命令成功，进程ID: %d\n", transcodeCmd.Process.Pid)
+	slog.Info("启动转码命令成功", "pid", transcodeCmd.Process.Pid)
+	if err := processes.Adopt(transcodeCmd.Process.Pid); err != nil {
+		slog.Warn("无法使转码进程随程序退出而结束", "pid", transcodeCmd.Process.Pid, "error", err)
+	}
 
 	// 更新任务状态为转码中
-	transcodeTasks[taskIndex].Status = "transcoding"
-	transcodeTasks[taskIndex].PID = transcodeCmd.Process.Pid
-	transcodeTasks[taskIndex].StartTime = time.Now()
-
-	// 写入更新后的进度信息
-	progressData, err := json.MarshalIndent(transcodeTasks, "", "  ")
-	if err != nil {
-		fmt.Printf("生成转码进度信息失败: %v\n", err)
-		return err
-	}
-	if err := os.WriteFile(progressFile, progressData, 0644); err != nil {
-		fmt.Printf("写入转码进度文件失败: %v\n", err)
-		return err
-	}
+	updatedTask, _ := a.tasks.UpdateTranscode(taskID, func(t *TranscodeTask) {
+		t.Status = "transcoding"
+		t.PID = transcodeCmd.Process.Pid
+		t.StartTime = time.Now()
+		t.UsedGPU = useGPU
+	})
+	a.emitTranscodeProgress(updatedTask, true)
+	a.publishTaskEvent(transcodeWebhookPayload(WebhookTaskStarted, updatedTask))
 
 	// 在后台goroutine中监控转码进度，同时处理标准输出和标准错误
-	go a.monitorTranscodeProgress(taskID, transcodeCmd, stdout, stderr, progressFile)
+	go a.monitorTranscodeProgress(taskID, transcodeCmd, stdout, stderr)
 
 	return nil
 }
 
 // monitorTranscodeProgress monitors the progress of a transcoding task
 // monitorTranscodeProgress 监控转码任务的进度
-func (a *App) monitorTranscodeProgress(taskID string, cmd *exec.Cmd, stdout io.ReadCloser, stderr io.ReadCloser, progressFile string) {
-	fmt.Printf("开始监控转码任务进度: %s\n", taskID)
+func (a *App) monitorTranscodeProgress(taskID string, cmd *exec.Cmd, stdout io.ReadCloser, stderr io.ReadCloser) {
+	slog.Debug("开始监控转码任务进度", "taskId", taskID)
 
 	// 用于存储当前进度信息
 	var currentProgress float64
@@ -1422,7 +1577,7 @@ func (a *App) monitorTranscodeProgress(taskID string, cmd *exec.Cmd, stdout io.R
 	go func() {
 		for stdoutScanner.Scan() {
 			line := stdoutScanner.Text()
-			fmt.Printf("FFmpeg输出: %s\n", line)
+			slog.Debug("FFmpeg输出", "line", line)
 
 			// 解析FFmpeg progress信息（来自-progress参数，每行一个字段）
 			parts := strings.SplitN(line, "=", 2)
@@ -1454,15 +1609,20 @@ func (a *App) monitorTranscodeProgress(taskID string, cmd *exec.Cmd, stdout io.R
 			case "speed":
 				// 解析转码速度信息，例如：speed=4.62x
 				currentSpeed = value
-				// 更新转码速度
-				a.updateTranscodeSpeed(taskID, progressFile, currentSpeed)
+				// 根据速度倍率和剩余时长估算剩余时间
+				timeRemaining := ""
+				if hasTotalDuration && totalDuration > currentTime {
+					timeRemaining = estimateTimeRemaining(totalDuration-currentTime, currentSpeed)
+				}
+				// 更新转码速度和剩余时间
+				a.updateTranscodeSpeed(taskID, currentSpeed, timeRemaining)
 			case "progress":
 				// 解析进度状态，例如：progress=continue 或 progress=end
 				if value == "end" {
 					// 转码结束，进度设为1.0
 					currentProgress = 1.0
-					a.updateTranscodeProgress(taskID, progressFile, currentProgress, "")
-					fmt.Printf("转码结束，进度设为100%%\n")
+					a.updateTranscodeProgress(taskID, currentProgress, "")
+					slog.Info("转码结束，进度设为100%")
 				}
 			}
 
@@ -1506,14 +1666,14 @@ func (a *App) monitorTranscodeProgress(taskID string, cmd *exec.Cmd, stdout io.R
 			// 只有当进度有明显增加时才更新（避免频繁更新）
 			if calculatedProgress > currentProgress+0.005 || calculatedProgress == 1.0 {
 				currentProgress = calculatedProgress
-				a.updateTranscodeProgress(taskID, progressFile, currentProgress, "")
-				fmt.Printf("%s进度: 当前帧=%d, 当前时间=%.2f秒, 进度=%.2f%%\n", progressType, currentFrame, currentTime, currentProgress*100)
+				a.updateTranscodeProgress(taskID, currentProgress, "")
+				slog.Debug("转码进度", "type", progressType, "frame", currentFrame, "seconds", currentTime, "progress", currentProgress*100)
 			}
 		}
 		if err := stdoutScanner.Err(); err != nil {
 			// 忽略正常的管道关闭错误
 			if !strings.Contains(err.Error(), "file already closed") {
-				fmt.Printf("读取ffmpeg标准输出时出错: %v\n", err)
+				slog.Error("读取ffmpeg标准输出时出错", "error", err)
 			}
 		}
 	}()
@@ -1523,12 +1683,12 @@ func (a *App) monitorTranscodeProgress(taskID string, cmd *exec.Cmd, stdout io.R
 	go func() {
 		for stderrScanner.Scan() {
 			line := stderrScanner.Text()
-			fmt.Printf("FFmpeg错误输出: %s\n", line)
+			slog.Debug("FFmpeg错误输出", "line", line)
 
 			// 检查是否有错误信息
 			if strings.Contains(line, "Error") || strings.Contains(line, "error") {
 				// 解析错误信息并更新任务状态，保持当前进度不变
-				a.updateTranscodeProgress(taskID, progressFile, currentProgress, line)
+				a.updateTranscodeProgress(taskID, currentProgress, line)
 				continue
 			}
 
@@ -1543,7 +1703,7 @@ func (a *App) monitorTranscodeProgress(taskID string, cmd *exec.Cmd, stdout io.R
 					seconds, _ := strconv.ParseFloat(durationMatches[3], 64)
 					totalDuration = hours*3600 + minutes*60 + seconds
 					hasTotalDuration = true
-					fmt.Printf("获取到总时长: %.2f秒\n", totalDuration)
+					slog.Info("获取到总时长", "seconds", totalDuration)
 
 					// 如果已经有当前时间信息，计算并更新进度
 					if currentTime > 0 {
@@ -1551,8 +1711,8 @@ func (a *App) monitorTranscodeProgress(taskID string, cmd *exec.Cmd, stdout io.R
 						if currentProgress > 1.0 {
 							currentProgress = 1.0
 						}
-						a.updateTranscodeProgress(taskID, progressFile, currentProgress, "")
-						fmt.Printf("使用时间计算进度: 当前时间=%.2f秒, 总时长=%.2f秒, 进度=%.2f%%\n", currentTime, totalDuration, currentProgress*100)
+						a.updateTranscodeProgress(taskID, currentProgress, "")
+						slog.Debug("使用时间计算进度", "seconds", currentTime, "totalSeconds", totalDuration, "progress", currentProgress*100)
 					}
 				}
 			}
@@ -1560,191 +1720,172 @@ func (a *App) monitorTranscodeProgress(taskID string, cmd *exec.Cmd, stdout io.R
 		if err := stderrScanner.Err(); err != nil {
 			// 忽略正常的管道关闭错误
 			if !strings.Contains(err.Error(), "file already closed") {
-				fmt.Printf("读取ffmpeg错误输出时出错: %v\n", err)
+				slog.Error("读取ffmpeg错误输出时出错", "error", err)
 			}
 		}
 	}()
 
+	// 转码期间定期采样 ffmpeg 的资源占用，可以看到GPU是否真的在工作
+	sampling := make(chan struct{})
+	go a.sampleTranscodeResources(taskID, cmd.Process.Pid, sampling)
+
 	// 等待命令完成
 	cmdErr := cmd.Wait()
-
-	// 读取最终的进度信息
-	data, readErr := os.ReadFile(progressFile)
-	if readErr != nil {
-		fmt.Printf("读取转码进度文件失败: %v\n", readErr)
-		return
-	}
-
-	var transcodeTasks []TranscodeTask
-	if err := json.Unmarshal(data, &transcodeTasks); err != nil {
-		fmt.Printf("解析转码进度数据失败: %v\n", err)
-		return
-	}
-
-	// 查找并更新任务状态
-	for i, task := range transcodeTasks {
-		if task.TaskID == taskID {
-			if cmdErr != nil {
-				// 转码失败
-				transcodeTasks[i].Status = "failed"
-				// 如果已经有详细的错误信息（从FFmpeg输出中提取），则保留，否则使用cmdErr
-				if transcodeTasks[i].Error == "" {
-					transcodeTasks[i].Error = cmdErr.Error()
-				}
-				fmt.Printf("转码任务失败: %s, 错误: %v\n", taskID, cmdErr)
-			} else {
-				// 转码成功
-				transcodeTasks[i].Status = "completed"
-				transcodeTasks[i].Progress = 1.0
-				fmt.Printf("转码任务完成: %s\n", taskID)
+	close(sampling)
+
+	// 更新任务状态
+	stoppedByShutdown := false
+	task, found := a.tasks.UpdateTranscode(taskID, func(t *TranscodeTask) {
+		if t.Status == "paused" && t.PausedBy == pausedByShutdown {
+			// 程序关闭时停止的转码，保持暂停状态，下次启动时重新开始
+			stoppedByShutdown = true
+			return
+		}
+		if cmdErr != nil {
+			// 转码失败
+			t.Status = "failed"
+			// 如果已经有详细的错误信息（从FFmpeg输出中提取），则保留，否则使用cmdErr
+			if t.Error == "" {
+				t.Error = cmdErr.Error()
 			}
-			transcodeTasks[i].EndTime = time.Now()
-			break
+			slog.Error("转码任务失败", "taskId", taskID, "error", cmdErr)
+		} else {
+			// 转码成功
+			t.Status = "completed"
+			t.Progress = 1.0
+			t.TimeRemaining = ""
+			slog.Info("转码任务完成", "taskId", taskID)
 		}
+		t.EndTime = time.Now()
+	})
+	if found && !stoppedByShutdown {
+		a.emitTranscodeProgress(task, true)
+		a.notifyTranscodeFinished(task)
+		a.stats.transcodeFinished(task)
+		event := WebhookTaskCompleted
+		if task.Status == "failed" {
+			event = WebhookTaskFailed
+		}
+		a.publishTaskEvent(transcodeWebhookPayload(event, task))
 	}
+	a.throttle.forget(EventTranscodeProgress + ":" + taskID)
 
-	// 写入更新后的进度信息
-	progressData, err := json.MarshalIndent(transcodeTasks, "", "  ")
-	if err != nil {
-		fmt.Printf("生成转码进度信息失败: %v\n", err)
-		return
-	}
-	if err := os.WriteFile(progressFile, progressData, 0644); err != nil {
-		fmt.Printf("写入转码进度文件失败: %v\n", err)
-		return
-	}
-
-	fmt.Printf("转码任务监控结束: %s\n", taskID)
+	slog.Info("转码任务监控结束", "taskId", taskID)
 
 	// 检查是否有等待中的转码任务
-	a.startNextTranscodeTask(progressFile)
+	a.startNextTranscodeTask()
 }
 
 // updateTranscodeProgress updates the progress of a transcoding task
 // updateTranscodeProgress 更新转码任务的进度
-func (a *App) updateTranscodeProgress(taskID string, progressFile string, progress float64, errorMsg string) error {
-	// 读取进度文件
-	data, err := os.ReadFile(progressFile)
-	if err != nil {
-		return fmt.Errorf("读取转码进度文件失败: %w", err)
+func (a *App) updateTranscodeProgress(taskID string, progress float64, errorMsg string) error {
+	task, found := a.tasks.UpdateTranscode(taskID, func(t *TranscodeTask) {
+		// 只有当progress >= 0时才更新进度，否则保持当前进度不变
+		if progress >= 0 {
+			t.Progress = progress
+		}
+		// 更新错误信息
+		if errorMsg != "" {
+			t.Error = errorMsg
+		}
+	})
+	if !found {
+		return errorf(msgTranscodeNotFound, taskID)
 	}
+	a.emitTranscodeProgress(task, false)
 
-	var transcodeTasks []TranscodeTask
-	if err := json.Unmarshal(data, &transcodeTasks); err != nil {
-		return fmt.Errorf("解析转码进度数据失败: %w", err)
-	}
+	return nil
+}
 
-	// 查找并更新任务进度
-	for i, task := range transcodeTasks {
-		if task.TaskID == taskID {
-			// 只有当progress >= 0时才更新进度，否则保持当前进度不变
-			if progress >= 0 {
-				transcodeTasks[i].Progress = progress
-			}
-			// 更新错误信息
-			if errorMsg != "" {
-				transcodeTasks[i].Error = errorMsg
-			}
-			break
+// updateTranscodeSpeed updates the speed and estimated time remaining of a transcoding task
+// updateTranscodeSpeed 更新转码任务的速度和预计剩余时间
+func (a *App) updateTranscodeSpeed(taskID string, speed string, timeRemaining string) error {
+	task, found := a.tasks.UpdateTranscode(taskID, func(t *TranscodeTask) {
+		// 更新转码速度，剩余时间无法估算时保留上一次的值
+		t.Speed = speed
+		if timeRemaining != "" {
+			t.TimeRemaining = timeRemaining
 		}
+	})
+	if !found {
+		return errorf(msgTranscodeNotFound, taskID)
 	}
-
-	// 写入更新后的进度信息
-	progressData, err := json.MarshalIndent(transcodeTasks, "", "  ")
-	if err != nil {
-		return fmt.Errorf("生成转码进度信息失败: %w", err)
-	}
-	if err := os.WriteFile(progressFile, progressData, 0644); err != nil {
-		return fmt.Errorf("写入转码进度文件失败: %w", err)
-	}
+	a.emitTranscodeProgress(task, false)
 
 	return nil
 }
 
-// updateTranscodeSpeed updates the speed of a transcoding task
-// updateTranscodeSpeed 更新转码任务的速度
-func (a *App) updateTranscodeSpeed(taskID string, progressFile string, speed string) error {
-	// 读取进度文件
-	data, err := os.ReadFile(progressFile)
-	if err != nil {
-		return fmt.Errorf("读取转码进度文件失败: %w", err)
+// estimateTimeRemaining estimates the wall-clock time left from the remaining media duration and ffmpeg's speed multiplier
+// estimateTimeRemaining 根据剩余媒体时长和FFmpeg速度倍率（如 "4.62x"）估算剩余时间，无法估算时返回空字符串
+func estimateTimeRemaining(remainingSeconds float64, speed string) string {
+	multiplier, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(speed), "x"), 64)
+	if err != nil || multiplier <= 0 {
+		// speed=N/A 或 0x 时无法估算
+		return ""
 	}
+	return formatDuration(remainingSeconds / multiplier)
+}
 
-	var transcodeTasks []TranscodeTask
-	if err := json.Unmarshal(data, &transcodeTasks); err != nil {
-		return fmt.Errorf("解析转码进度数据失败: %w", err)
+// formatDuration 将秒数格式化为 HH:MM:SS
+func formatDuration(seconds float64) string {
+	if seconds < 0 {
+		seconds = 0
 	}
+	total := int64(seconds + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, (total%3600)/60, total%60)
+}
 
-	// 查找并更新任务速度
-	for i, task := range transcodeTasks {
-		if task.TaskID == taskID {
-			// 更新转码速度
-			transcodeTasks[i].Speed = speed
-			break
+// transcodeSlotsAvailable 返回按设置还可以同时启动的转码任务数
+func (a *App) transcodeSlotsAvailable() int {
+	running := 0
+	for _, task := range a.tasks.Transcodes() {
+		if task.Status == "transcoding" {
+			running++
 		}
 	}
+	return currentSettings().MaxConcurrentTranscodes - running
+}
 
-	// 写入更新后的进度信息
-	progressData, err := json.MarshalIndent(transcodeTasks, "", "  ")
-	if err != nil {
-		return fmt.Errorf("生成转码进度信息失败: %w", err)
+// startNextTranscodeTask starts the next waiting transcoding tasks
+// startNextTranscodeTask 启动等待中的转码任务直到达到同时转码任务数上限，实现任务队列
+func (a *App) startNextTranscodeTask() error {
+	if a.shuttingDown.Load() {
+		return nil
 	}
-	if err := os.WriteFile(progressFile, progressData, 0644); err != nil {
-		return fmt.Errorf("写入转码进度文件失败: %w", err)
+	// 全部暂停、使用电池供电、温度过高或磁盘空间不足时不启动转码，原因消失后再启动
+	if reason := a.transcodePauseReason(); reason != "" {
+		slog.Info("暂不启动转码任务", "reason", reason)
+		return nil
 	}
 
-	return nil
-}
-
-// startNextTranscodeTask starts the next waiting transcoding task
-// startNextTranscodeTask 启动下一个等待中的转码任务，实现任务队列
-func (a *App) startNextTranscodeTask(progressFile string) error {
-	// 读取进度文件
-	data, err := os.ReadFile(progressFile)
-	if err != nil {
-		fmt.Printf("读取转码进度文件失败: %v\n", err)
-		return err
-	}
+	slots := a.transcodeSlotsAvailable()
 
-	var transcodeTasks []TranscodeTask
-	if err := json.Unmarshal(data, &transcodeTasks); err != nil {
-		fmt.Printf("解析转码进度数据失败: %v\n", err)
-		return err
+	// 如果已达到上限，不启动新任务
+	if slots <= 0 {
+		slog.Info("已达到同时转码任务数上限，等待完成后再启动下一个")
+		return nil
 	}
 
-	// 检查是否有正在转码的任务
-	var hasRunningTask bool
-	for _, task := range transcodeTasks {
-		if task.Status == "transcoding" {
-			hasRunningTask = true
-			break
+	// 按顺序查找等待中的任务
+	var nextTaskIDs []string
+	for _, task := range a.tasks.Transcodes() {
+		if task.Status == "waiting" && len(nextTaskIDs) < slots {
+			nextTaskIDs = append(nextTaskIDs, task.TaskID)
 		}
 	}
 
-	// 如果有正在转码的任务，不启动新任务
-	if hasRunningTask {
-		fmt.Printf("已有正在转码的任务，等待完成后再启动下一个\n")
+	if len(nextTaskIDs) == 0 {
+		slog.Info("没有等待中的转码任务")
 		return nil
 	}
 
-	// 查找第一个等待中的任务
-	var nextTaskID string
-	for _, task := range transcodeTasks {
-		if task.Status == "waiting" {
-			nextTaskID = task.TaskID
-			break
-		}
-	}
-
-	// 如果有等待中的任务，启动第一个
-	if nextTaskID != "" {
-		fmt.Printf("启动下一个等待中的转码任务: %s\n", nextTaskID)
-		if err := a.startTranscode(nextTaskID, progressFile); err != nil {
-			fmt.Printf("启动等待的转码任务失败: %v\n", err)
+	// 启动等待中的任务
+	for _, nextTaskID := range nextTaskIDs {
+		slog.Info("启动下一个等待中的转码任务", "taskId", nextTaskID)
+		if err := a.startTranscode(nextTaskID); err != nil {
+			slog.Error("启动等待的转码任务失败", "error", err)
 			return err
 		}
-	} else {
-		fmt.Printf("没有等待中的转码任务\n")
 	}
 
 	return nil
@@ -1752,75 +1893,65 @@ func (a *App) startNextTranscodeTask(progressFile string) error {
 
 // startDownload starts a download task and monitors its progress
 // startDownload 开始下载任务并监控其进度
-func (a *App) startDownload(taskId string, magnetLink string, outputDir string, progressFile string) error {
-	// 获取可执行文件的绝对路径
-	execPath, err := os.Getwd()
+func (a *App) startDownload(taskId string, magnetLink string, outputDir string) error {
+	// 获取torrent命令路径（可在设置中修改）
+	torrentPath, err := torrentToolPath()
 	if err != nil {
-		return fmt.Errorf("获取工作目录失败: %w", err)
+		return err
 	}
-
-	// 使用绝对路径的torrent命令
-	torrentPath := filepath.Join(execPath, "tools", "torrent.exe")
 	if _, err := os.Stat(torrentPath); os.IsNotExist(err) {
-		return fmt.Errorf("torrent命令不存在: %w", err)
+		return errorf(msgTorrentNotFound, err)
 	}
 
-	// 调用torrent download命令下载种子文件
-	downloadCmd := exec.Command(torrentPath, "download", magnetLink)
-	// 在Windows上隐藏命令窗口
-	downloadCmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
+	// 调用torrent download命令下载种子文件，按设置限制上传和下载速度（使用电池供电时可能更低，
+	// 同时下载多个任务时按优先级分配）
+	downloadArgs := []string{"download"}
+	current := currentSettings()
+	rateLimit := a.taskRateLimit(taskId)
+	if rateLimit > 0 {
+		downloadArgs = append(downloadArgs, fmt.Sprintf("--download-rate=%dKiB", rateLimit))
+	}
+	if current.UploadSpeedLimit > 0 {
+		downloadArgs = append(downloadArgs, fmt.Sprintf("--upload-rate=%dKiB", current.UploadSpeedLimit))
 	}
+	downloadArgs = append(downloadArgs, magnetLink)
+	downloadCmd := exec.Command(torrentPath, downloadArgs...)
+	// 在Windows上隐藏命令窗口
+	hideWindow(downloadCmd)
+	startProcessGroup(downloadCmd)
 	// 设置工作目录为downloads文件夹
 	downloadCmd.Dir = outputDir
-	fmt.Printf("执行命令: %v, 工作目录: %s\n", downloadCmd.String(), outputDir)
+	slog.Info("执行命令", "command", downloadCmd.String(), "dir", outputDir)
 
 	// 获取命令的输出管道
 	stdout, err := downloadCmd.StdoutPipe()
 	if err != nil {
-		fmt.Printf("获取命令输出管道失败: %v\n", err)
-		return err
-	}
-
-	// 启动命令
-	if err := downloadCmd.Start(); err != nil {
-		fmt.Printf("启动下载命令失败: %v\n", err)
-		return err
-	}
-	fmt.Printf("启动下载命令成功，进程ID: %d\n", downloadCmd.Process.Pid)
-
-	// 更新进度信息为下载中
-	existingData, err := os.ReadFile(progressFile)
-	if err != nil {
-		fmt.Printf("读取进度文件失败: %v\n", err)
+		slog.Error("获取命令输出管道失败", "error", err)
 		return err
 	}
 
-	var progressList []map[string]interface{}
-	if err := json.Unmarshal(existingData, &progressList); err != nil {
-		fmt.Printf("解析进度文件失败: %v\n", err)
+	// 启动命令
+	if err := downloadCmd.Start(); err != nil {
+		slog.Error("启动下载命令失败", "error", err)
 		return err
 	}
-
-	for i, p := range progressList {
-		if p["taskId"] == taskId {
-			progressList[i]["status"] = "downloading"
-			progressList[i]["pid"] = downloadCmd.Process.Pid
-			break
-		}
+	slog.Info("启动下载命令成功", "pid", downloadCmd.Process.Pid)
+	if err := processes.Adopt(downloadCmd.Process.Pid); err != nil {
+		slog.Warn("无法使下载进程随程序退出而结束", "pid", downloadCmd.Process.Pid, "error", err)
 	}
 
-	// 写入更新后的进度信息
-	progressData, err := json.MarshalIndent(progressList, "", "  ")
-	if err != nil {
-		fmt.Printf("生成进度信息失败: %v\n", err)
-		return err
-	}
-	if err := os.WriteFile(progressFile, progressData, 0644); err != nil {
-		fmt.Printf("写入进度文件失败: %v\n", err)
-		return err
+	// 更新任务状态为下载中
+	if task, found := a.tasks.UpdateDownload(taskId, func(t *DownloadTask) {
+		t.Status = "downloading"
+		t.PID = downloadCmd.Process.Pid
+	}); found {
+		slog.Info("更新任务状态为下载中成功")
+		a.emitDownloadProgress(task, true)
+		a.publishTaskEvent(downloadWebhookPayload(WebhookTaskStarted, task))
 	}
-	fmt.Printf("更新进度状态为下载中成功\n")
+	// 优先级更高的任务开始下载后，其他任务的限速需要减少
+	a.setAppliedRate(taskId, rateLimit)
+	go a.restartDownloadsForRateLimit()
 
 	// 启动异步线程监控下载进度
 	go func() {
@@ -1829,10 +1960,11 @@ func (a *App) startDownload(taskId string, magnetLink string, outputDir string,
 		// 正则表达式匹配进度行，支持各种时间格式和单位格式
 		progressRegex := regexp.MustCompile(`(?:(\d+)m)?(\d+(?:\.\d+)?)s: (\d+) torrents, (\d+) infos, (\d+(?:\.\d+)?\s*\w+)/(\d+(?:\.\d+)?\s*\w+) ready, upload (\d+(?:\.\d+)?\s*\w+), download (\d+(?:\.\d+)?\s*\w+)/s`)
 		// 注意：matches[1]是分钟部分（可能为空），matches[2]是秒部分
+		lastDownloaded, lastUploaded := int64(-1), int64(0)
 
 		for scanner.Scan() {
 			line := scanner.Text()
-			fmt.Printf("下载输出: %s\n", line)
+			slog.Debug("下载输出", "line", line)
 
 			// 匹配进度行
 			matches := progressRegex.FindStringSubmatch(line)
@@ -1850,266 +1982,245 @@ func (a *App) startDownload(taskId string, magnetLink string, outputDir string,
 				// 解析已下载大小
 				downloaded, err := parseFileSize(matches[5])
 				if err != nil {
-					fmt.Printf("解析已下载大小失败: %v\n", err)
+					slog.Error("解析已下载大小失败", "error", err)
 					continue
 				}
 
 				// 解析总大小
 				totalSize, err := parseFileSize(matches[6])
 				if err != nil {
-					fmt.Printf("解析总大小失败: %v\n", err)
+					slog.Error("解析总大小失败", "error", err)
 					continue
 				}
 
 				// 解析下载速度
 				speed, err := parseFileSize(matches[8])
 				if err != nil {
-					fmt.Printf("解析下载速度失败: %v\n", err)
+					slog.Error("解析下载速度失败", "error", err)
 					continue
 				}
 
+				// 统计新传输的字节数：已下载大小从进程的第一条进度开始计算（之前的是已有数据），上传大小是进程启动后的累计值
+				if lastDownloaded >= 0 && downloaded > lastDownloaded {
+					a.stats.addTransfer(downloaded-lastDownloaded, 0)
+				}
+				lastDownloaded = downloaded
+				if uploaded, err := parseFileSize(matches[7]); err == nil && uploaded > lastUploaded {
+					a.stats.addTransfer(0, uploaded-lastUploaded)
+					lastUploaded = uploaded
+				}
+
 				// 计算下载百分比
 				percentage := 0.0
 				if totalSize > 0 {
 					percentage = (float64(downloaded) / float64(totalSize)) * 100
 				}
 
-				// 读取最新的进度文件
-				existingData, err := os.ReadFile(progressFile)
-				if err != nil {
-					fmt.Printf("读取进度文件失败: %v\n", err)
-					continue
-				}
-
-				var currentProgressList []map[string]interface{}
-				if err := json.Unmarshal(existingData, &currentProgressList); err != nil {
-					fmt.Printf("解析进度文件失败: %v\n", err)
-					continue
-				}
-
-				// 更新当前任务的进度
-				for i, p := range currentProgressList {
-					if p["taskId"] == taskId {
-						currentProgressList[i]["downloaded"] = downloaded
-						currentProgressList[i]["totalSize"] = totalSize
-						currentProgressList[i]["speed"] = speed
-						currentProgressList[i]["percentage"] = percentage
-						currentProgressList[i]["lastUpdate"] = time.Now().Format(time.RFC3339)
-						break
-					}
-				}
-
-				// 写入更新后的进度信息
-				updatedData, err := json.MarshalIndent(currentProgressList, "", "  ")
-				if err != nil {
-					fmt.Printf("生成更新后的进度信息失败: %v\n", err)
-					continue
-				}
-				if err := os.WriteFile(progressFile, updatedData, 0644); err != nil {
-					fmt.Printf("写入更新后的进度信息失败: %v\n", err)
+				// 更新当前任务的进度，只修改进度字段，不影响并发的状态变更
+				task, found := a.tasks.UpdateDownload(taskId, func(t *DownloadTask) {
+					t.Downloaded = downloaded
+					t.TotalSize = totalSize
+					t.Speed = speed
+					t.Percentage = percentage
+					t.LastUpdate = time.Now().Format(time.RFC3339)
+				})
+				if !found {
 					continue
 				}
 
-				fmt.Printf("更新进度成功: 已下载 %s/%s, 速度 %s/s, 百分比 %.2f%%\n", matches[5], matches[6], matches[8], percentage)
+				slog.Debug("更新进度成功", "downloaded", matches[5], "total", matches[6], "speed", matches[8], "percentage", percentage)
+				a.emitDownloadProgress(task, false)
 			}
 		}
 
 		// 命令执行完成，更新状态为完成
 		if err := scanner.Err(); err != nil {
-			fmt.Printf("读取命令输出失败: %v\n", err)
+			slog.Error("读取命令输出失败", "error", err)
 		}
 
 		// 等待命令执行完成
-		if err := downloadCmd.Wait(); err != nil {
-			fmt.Printf("下载命令执行失败: %v\n", err)
-
-			// 检查任务是否已经被取消
-			existingData, err := os.ReadFile(progressFile)
-			if err != nil {
-				fmt.Printf("读取进度文件失败: %v\n", err)
-				return
-			}
+		cmdErr := downloadCmd.Wait()
+		if cmdErr != nil {
+			slog.Error("下载命令执行失败", "error", cmdErr)
+		}
 
-			var currentProgressList []map[string]interface{}
-			if err := json.Unmarshal(existingData, &currentProgressList); err != nil {
-				fmt.Printf("解析进度文件失败: %v\n", err)
+		// 在锁内检查并更新任务状态，如果已经是cancelled或paused，或者限速变化后已由新进程接管（见 restartDownload），则不更新为completed
+		var skipped bool
+		task, found := a.tasks.UpdateDownload(taskId, func(t *DownloadTask) {
+			if t.Status == "cancelled" || t.Status == "paused" || t.PID != downloadCmd.Process.Pid {
+				skipped = true
 				return
 			}
-
-			// 检查任务状态，如果已经是cancelled或paused，则不更新为completed
-			for i, p := range currentProgressList {
-				if p["taskId"] == taskId {
-					currentStatus, ok := p["status"].(string)
-					if ok && (currentStatus == "cancelled" || currentStatus == "paused") {
-						fmt.Printf("任务已被取消或暂停，不更新为completed\n")
-						// 启动下一个等待中的任务
-						a.startNextWaitingTask()
-						return
-					}
-					// 下载线程异常结束，将任务状态改为等待中
-					currentProgressList[i]["status"] = "waiting"
-					currentProgressList[i]["endTime"] = time.Now().Format(time.RFC3339)
-					// 移除PID，因为进程已经结束
-					delete(currentProgressList[i], "pid")
-					break
-				}
-			}
-
-			// 写入更新后的进度信息
-			updatedData, err := json.MarshalIndent(currentProgressList, "", "  ")
-			if err != nil {
-				fmt.Printf("生成更新后的进度信息失败: %v\n", err)
-				return
+			if cmdErr != nil {
+				// 下载线程异常结束，将任务状态改为等待中
+				t.Status = "waiting"
+				// 移除PID，因为进程已经结束
+				t.PID = 0
+				slog.Warn("下载线程异常结束，任务状态改为等待中", "taskId", taskId)
+			} else {
+				// 只有当命令正常完成时，才更新状态为completed
+				t.Status = "completed"
+				slog.Info("下载完成，更新状态为completed")
 			}
-			if err := os.WriteFile(progressFile, updatedData, 0644); err != nil {
-				fmt.Printf("写入更新后的进度信息失败: %v\n", err)
-				return
+			t.EndTime = time.Now().Format(time.RFC3339)
+		})
+		if skipped {
+			slog.Info("任务已被取消或暂停，不更新为completed")
+		}
+		if found && !skipped {
+			a.emitDownloadProgress(task, true)
+			a.notifyDownloadFinished(task)
+			a.stats.downloadFinished(task)
+			if task.Status == "completed" {
+				a.fixDownloadNames(task)
+				a.autoExtractDownload(task)
 			}
-			fmt.Printf("下载线程异常结束，任务 %s 状态改为等待中\n", taskId)
-			// 启动下一个等待中的任务
-			a.startNextWaitingTask()
-			return
-		}
-
-		// 只有当命令正常完成时，才更新状态为completed
-		// 读取最新的进度文件
-		existingData, err := os.ReadFile(progressFile)
-		if err != nil {
-			fmt.Printf("读取进度文件失败: %v\n", err)
-			return
-		}
-
-		var currentProgressList []map[string]interface{}
-		if err := json.Unmarshal(existingData, &currentProgressList); err != nil {
-			fmt.Printf("解析进度文件失败: %v\n", err)
-			return
-		}
-
-		// 更新当前任务的状态为完成
-		for i, p := range currentProgressList {
-			if p["taskId"] == taskId {
-				// 再次检查状态，确保没有被其他操作修改
-				currentStatus, ok := p["status"].(string)
-				if ok && (currentStatus == "cancelled" || currentStatus == "paused") {
-					fmt.Printf("任务已被取消或暂停，不更新为completed\n")
-					// 启动下一个等待中的任务
-					a.startNextWaitingTask()
-					return
-				}
-				currentProgressList[i]["status"] = "completed"
-				currentProgressList[i]["endTime"] = time.Now().Format(time.RFC3339)
-				break
+			// 异常结束的任务会重新排队，对外报告为失败
+			event := WebhookTaskCompleted
+			if cmdErr != nil {
+				event = WebhookTaskFailed
 			}
+			a.publishTaskEvent(downloadWebhookPayload(event, task))
 		}
+		a.throttle.forget(EventDownloadProgress + ":" + taskId)
 
-		// 写入更新后的进度信息
-		updatedData, err := json.MarshalIndent(currentProgressList, "", "  ")
-		if err != nil {
-			fmt.Printf("生成更新后的进度信息失败: %v\n", err)
-			return
-		}
-		if err := os.WriteFile(progressFile, updatedData, 0644); err != nil {
-			fmt.Printf("写入更新后的进度信息失败: %v\n", err)
-			return
-		}
-
-		fmt.Printf("下载完成，更新状态为completed\n")
-		// 启动下一个等待中的任务
+		// 启动下一个等待中的任务，并按剩余的任务重新分配限速
 		a.startNextWaitingTask()
+		a.restartDownloadsForRateLimit()
 	}()
 
 	return nil
 }
 
-// startNextWaitingTask starts the next waiting download task
-// startNextWaitingTask 启动下一个等待中的下载任务
+// downloadSlotsAvailable 返回按设置还可以同时启动的下载任务数
+func (a *App) downloadSlotsAvailable() int {
+	running := 0
+	for _, task := range a.tasks.Downloads() {
+		if task.Status == "downloading" {
+			running++
+		}
+	}
+	return currentSettings().MaxConcurrentDownloads - running
+}
+
+// startNextWaitingTask starts the next waiting download tasks
+// startNextWaitingTask 启动等待中的下载任务直到达到同时下载任务数上限
 func (a *App) startNextWaitingTask() {
-	// 读取进度文件
-	progressFile := "download_progress.json"
-	existingData, err := os.ReadFile(progressFile)
-	if err != nil {
-		fmt.Printf("读取进度文件失败: %v\n", err)
+	if a.shuttingDown.Load() {
 		return
 	}
+	// 全部暂停后不启动等待中的任务，全部继续时再启动
+	if a.pausedAll.Load() {
+		slog.Info("任务已全部暂停，暂不启动下载任务")
+		return
+	}
+	// 下载目录所在磁盘空间不足，空间恢复后再启动
+	if a.diskSpaceLow(diskDownload) {
+		slog.Info("下载目录磁盘空间不足，暂不启动下载任务")
+		return
+	}
+	slots := a.downloadSlotsAvailable()
 
-	var progressList []map[string]interface{}
-	if err := json.Unmarshal(existingData, &progressList); err != nil {
-		fmt.Printf("解析进度文件失败: %v\n", err)
+	// 检查是否已达到上限
+	if slots <= 0 {
+		slog.Info("已达到同时下载任务数上限，不启动新任务")
 		return
 	}
 
-	// 检查是否有正在下载的任务
-	for _, task := range progressList {
-		if status, ok := task["status"].(string); ok && status == "downloading" {
-			fmt.Printf("已有任务在下载中，不启动新任务\n")
+	// 按优先级启动等待中的任务，相同优先级按添加顺序
+	started := 0
+	for _, task := range a.waitingDownloads() {
+		if started >= slots {
 			return
 		}
+		slog.Info("启动等待中的任务", "taskId", task.TaskID, "priority", task.Priority)
+		if err := a.startDownload(task.TaskID, task.MagnetLink, task.OutputDir); err != nil {
+			slog.Error("启动等待任务失败", "error", err)
+		}
+		started++
 	}
 
-	// 查找第一个等待中的任务
-	var nextTask map[string]interface{}
-	for _, task := range progressList {
-		if status, ok := task["status"].(string); ok && status == "waiting" {
-			nextTask = task
-			break
+	if started == 0 {
+		slog.Info("没有等待中的任务")
+	}
+}
+
+// resumeInterruptedDownloads 处理上次关闭时正在下载的任务（包括异常退出和关闭时暂停的任务）：开启自动恢复时按开始时间优先启动，
+// 在同时下载任务数上限内并行下载，剩余的名额按添加顺序启动等待中的任务；关闭自动恢复时暂停这些任务，由用户手动继续
+func (a *App) resumeInterruptedDownloads() {
+	resume := currentSettings().ResumeInterruptedDownloads
+	var interrupted []DownloadTask
+	for _, task := range a.tasks.Downloads() {
+		// 异常退出时仍为下载中；正常关闭时已标记为因关闭而暂停
+		if task.Status != "downloading" && !(task.Status == "paused" && task.PausedBy == pausedByShutdown) {
+			continue
+		}
+		status := "waiting"
+		if !resume {
+			status = "paused"
+		}
+		slog.Info("发现上次未完成的下载任务", "taskId", task.TaskID, "status", status)
+		updated, _ := a.tasks.UpdateDownload(task.TaskID, func(t *DownloadTask) {
+			t.Status = status
+			if status == "waiting" {
+				t.PausedBy = ""
+			}
+			t.EndTime = time.Now().Format(time.RFC3339)
+			t.Speed = 0
+			// 移除PID，因为进程可能已经结束
+			t.PID = 0
+		})
+		if resume {
+			interrupted = append(interrupted, updated)
 		}
 	}
 
-	if nextTask != nil {
-		// 启动该任务
-		taskId := nextTask["taskId"].(string)
-		magnetLink := nextTask["magnetLink"].(string)
-		outputDir := nextTask["outputDir"].(string)
-		fmt.Printf("启动等待中的任务: %s\n", taskId)
-		if err := a.startDownload(taskId, magnetLink, outputDir, progressFile); err != nil {
-			fmt.Printf("启动等待任务失败: %v\n", err)
+	sort.SliceStable(interrupted, func(i, j int) bool {
+		return interrupted[i].StartTime < interrupted[j].StartTime
+	})
+	for _, task := range interrupted {
+		if a.downloadSlotsAvailable() <= 0 {
+			slog.Info("已达到同时下载任务数上限，其余未完成的任务进入等待队列", "taskId", task.TaskID)
+			break
 		}
-	} else {
-		fmt.Printf("没有等待中的任务\n")
+		slog.Info("恢复上次未完成的下载任务", "taskId", task.TaskID)
+		if err := a.startDownload(task.TaskID, task.MagnetLink, task.OutputDir); err != nil {
+			slog.Error("恢复下载任务失败", "taskId", task.TaskID, "error", err)
+		}
+	}
+
+	if a.downloadSlotsAvailable() > 0 {
+		a.startNextWaitingTask()
 	}
 }
 
 // StartWaitingTask starts a specific waiting download task
 // StartWaitingTask 启动指定的等待中的下载任务
 func (a *App) StartWaitingTask(taskId string) (string, error) {
-	// 读取进度文件
-	progressFile := "download_progress.json"
-	existingData, err := os.ReadFile(progressFile)
-	if err != nil {
-		return "", fmt.Errorf("读取进度文件失败: %w", err)
-	}
-
-	var progressList []map[string]interface{}
-	if err := json.Unmarshal(existingData, &progressList); err != nil {
-		return "", fmt.Errorf("解析进度文件失败: %w", err)
-	}
+	downloads := a.tasks.Downloads()
 
-	// 检查是否有正在下载的任务
-	for _, task := range progressList {
-		if status, ok := task["status"].(string); ok && status == "downloading" {
-			return "", fmt.Errorf("已有任务在下载中，无法启动新任务")
-		}
+	// 检查是否达到同时下载任务数上限
+	if a.downloadSlotsAvailable() <= 0 {
+		return "", errorf(msgDownloadLimitReached)
 	}
 
 	// 查找指定的等待中的任务
-	var targetTask map[string]interface{}
-	for _, task := range progressList {
-		if task["taskId"] == taskId && task["status"] == "waiting" {
-			targetTask = task
+	var targetTask *DownloadTask
+	for i, task := range downloads {
+		if task.TaskID == taskId && task.Status == "waiting" {
+			targetTask = &downloads[i]
 			break
 		}
 	}
 
 	if targetTask == nil {
-		return "", fmt.Errorf("未找到指定的等待中的任务: %s", taskId)
+		return "", errorf(msgWaitingTaskNotFound, taskId)
 	}
 
 	// 启动该任务
-	magnetLink := targetTask["magnetLink"].(string)
-	outputDir := targetTask["outputDir"].(string)
-	fmt.Printf("手动启动等待中的任务: %s\n", taskId)
-	if err := a.startDownload(taskId, magnetLink, outputDir, progressFile); err != nil {
-		return "", fmt.Errorf("启动等待任务失败: %w", err)
+	slog.Info("手动启动等待中的任务", "taskId", taskId)
+	if err := a.startDownload(taskId, targetTask.MagnetLink, targetTask.OutputDir); err != nil {
+		return "", errorf(msgStartWaitingFailed, err)
 	}
 
 	// 构建响应
@@ -2127,152 +2238,21 @@ func (a *App) StartWaitingTask(taskId string) (string, error) {
 	return string(jsonData), nil
 }
 
-// GetDiskSpace gets the disk space information of the current drive
-// GetDiskSpace 获取当前驱动器的磁盘空间信息
-func (a *App) GetDiskSpace() (string, error) {
-	// 获取当前工作目录
-	cwd, err := os.Getwd()
-	if err != nil {
-		return "", fmt.Errorf("获取当前工作目录失败: %w", err)
-	}
-
-	// 获取当前驱动器
-	drive := cwd[:2] // Windows驱动器格式为 "C:" 等
-
-	// 获取磁盘空间信息（Windows系统）
-	var freeBytesAvailable, totalNumberOfBytes, totalNumberOfFreeBytes uint64
-	if err := windows.GetDiskFreeSpaceEx(windows.StringToUTF16Ptr(drive), &freeBytesAvailable, &totalNumberOfBytes, &totalNumberOfFreeBytes); err != nil {
-		return "", fmt.Errorf("获取磁盘空间信息失败: %w", err)
-	}
-
-	// 计算可用空间和总空间
-	available := freeBytesAvailable
-	total := totalNumberOfBytes
-	used := total - available
-
-	// 构建响应
-	response := map[string]interface{}{
-		"status":    "success",
-		"drive":     drive,
-		"total":     total,
-		"available": available,
-		"used":      used,
-	}
-
-	jsonData, err := json.Marshal(response)
-	if err != nil {
-		return "", err
-	}
-
-	return string(jsonData), nil
-}
-
-// GetVideoLibrary gets the list of video files in the downloads directory
-// GetVideoLibrary 获取下载目录中的视频文件列表
-func (a *App) GetVideoLibrary() (string, error) {
-	// 下载目录
-	downloadDir := "./downloads"
-
-	// 读取目录中的所有文件
-	files, err := os.ReadDir(downloadDir)
-	if err != nil {
-		return "", fmt.Errorf("读取下载目录失败: %w", err)
-	}
-
-	// 视频文件扩展名列表
-	videoExtensions := map[string]bool{
-		".mp4":  true,
-		".mkv":  true,
-		".avi":  true,
-		".mov":  true,
-		".wmv":  true,
-		".flv":  true,
-		".webm": true,
-		".m4v":  true,
-		".ts":   true,
-	}
-
-	// 过滤视频文件
-	var videoFiles []map[string]interface{}
-	for _, file := range files {
-		if !file.IsDir() {
-			// 获取文件扩展名
-			ext := strings.ToLower(filepath.Ext(file.Name()))
-			if videoExtensions[ext] {
-				// 获取文件信息
-				fileInfo, err := file.Info()
-				if err != nil {
-					continue
-				}
-
-				// 获取文件的完整路径
-				fullPath, err := filepath.Abs(filepath.Join(downloadDir, file.Name()))
-				if err != nil {
-					continue
-				}
-
-				// 添加到视频文件列表
-				videoFiles = append(videoFiles, map[string]interface{}{
-					"name":      file.Name(),
-					"size":      fileInfo.Size(),
-					"path":      fullPath,
-					"extension": ext[1:], // 移除点号
-					"modTime":   fileInfo.ModTime().Format(time.RFC3339),
-				})
-			}
-		}
-	}
-
-	// 构建响应
-	response := map[string]interface{}{
-		"status":     "success",
-		"videoFiles": videoFiles,
-		"total":      len(videoFiles),
-	}
-
-	jsonData, err := json.Marshal(response)
-	if err != nil {
-		return "", err
-	}
-
-	return string(jsonData), nil
-}
-
-// GenerateMagnetLink generates magnet link from torrent file using external tool
-// GenerateMagnetLink 使用外部工具从种子文件生成磁力链接
+// GenerateMagnetLink generates a magnet link from a torrent file
+// GenerateMagnetLink 从种子文件生成磁力链接，包含 info-hash、显示名称和 Tracker
 func (a *App) GenerateMagnetLink(torrentFilePath string) (string, error) {
-	fmt.Printf("Generating magnet link for: %s\n", torrentFilePath)
+	slog.Info("Generating magnet link", "file", torrentFilePath)
 
-	// 获取可执行文件的绝对路径
-	execPath, err := os.Getwd()
+	mi, err := metainfo.LoadFromFile(torrentFilePath)
 	if err != nil {
-		return "", fmt.Errorf("获取工作目录失败: %w", err)
-	}
-
-	// 使用绝对路径的torrent命令
-	torrentPath := filepath.Join(execPath, "tools", "torrent.exe")
-	if _, err := os.Stat(torrentPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("torrent命令不存在: %w", err)
-	}
-
-	// 调用torrent metainfo magnet命令生成磁力链接
-	metainfoCmd := exec.Command(torrentPath, "metainfo", torrentFilePath, "magnet")
-	// 在Windows上隐藏命令窗口
-	metainfoCmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
+		slog.Error("读取种子文件失败", "file", torrentFilePath, "error", err)
+		return "", errorf(msgNotTorrentFile, err)
 	}
-	fmt.Printf("执行命令: %v\n", metainfoCmd.String())
-	metainfoOutput, err := metainfoCmd.CombinedOutput()
+	magnetLink, err := torrentMagnetLink(mi)
 	if err != nil {
-		fmt.Printf("执行torrent metainfo magnet命令失败: %v, 输出: %s\n", err, metainfoOutput)
-		return "", fmt.Errorf("failed to get metainfo: %w, output: %s", err, metainfoOutput)
+		return "", err
 	}
-	fmt.Printf("执行torrent metainfo magnet命令成功，输出: %s\n", metainfoOutput)
-
-	// 解析磁力链接
-	magnetLink := string(metainfoOutput)
-	magnetLink = strings.TrimSpace(magnetLink)
-	fmt.Printf("生成的磁力链接: %s\n", magnetLink)
+	slog.Info("生成的磁力链接", "magnetLink", magnetLink)
 
 	response := map[string]interface{}{
 		"status":     "success",
@@ -2341,8 +2321,7 @@ func parseFileSize(sizeStr string) (int64, error) {
 func (a *App) DownloadWithTool(magnetLink string, outputDir string) (string, error) {
 	// TODO: Implement download using external tool
 	// 实现使用外部工具下载种子
-	fmt.Printf("Downloading from magnet link: %s\n", magnetLink)
-	fmt.Printf("Output directory: %s\n", outputDir)
+	slog.Info("Downloading from magnet link", "magnetLink", magnetLink, "outputDir", outputDir)
 
 	// Mock response for now
 	// 暂时返回模拟数据
@@ -2363,31 +2342,13 @@ func (a *App) DownloadWithTool(magnetLink string, outputDir string) (string, err
 // GetDownloadStatus gets the status of a download task
 // GetDownloadStatus 获取下载任务的状态
 func (a *App) GetDownloadStatus(taskId string) (string, error) {
-	// 读取下载进度文件
-	progressFile := "download_progress.json"
-	data, err := os.ReadFile(progressFile)
-	if err != nil {
-		// 如果文件不存在，返回空列表
-		if os.IsNotExist(err) {
-			response := map[string]interface{}{
-				"tasks": []map[string]interface{}{},
-			}
-			jsonData, _ := json.Marshal(response)
-			return string(jsonData), nil
-		}
-		return "", err
-	}
-
-	// 解析JSON数据
-	var progressList []map[string]interface{}
-	if err := json.Unmarshal(data, &progressList); err != nil {
-		return "", err
-	}
+	downloads := a.tasks.Downloads()
 
-	// 如果taskId为空，返回所有任务状态
+	// 如果taskId为空，返回所有任务状态，以及是否已全部暂停
 	if taskId == "" {
 		response := map[string]interface{}{
-			"tasks": progressList,
+			"tasks":     downloads,
+			"pausedAll": a.pausedAll.Load(),
 		}
 		jsonData, err := json.Marshal(response)
 		if err != nil {
@@ -2397,8 +2358,8 @@ func (a *App) GetDownloadStatus(taskId string) (string, error) {
 	}
 
 	// 查找指定taskId的任务
-	for _, task := range progressList {
-		if task["taskId"] == taskId {
+	for _, task := range downloads {
+		if task.TaskID == taskId {
 			jsonData, err := json.Marshal(task)
 			if err != nil {
 				return "", err
@@ -2415,14 +2376,14 @@ func (a *App) GetDownloadStatus(taskId string) (string, error) {
 // ServeVideoFile 从下载目录提供视频文件访问
 func (a *App) ServeVideoFile(fileName string) (string, error) {
 	// 下载目录
-	downloadDir := "./downloads"
+	downloadDir := downloadsDir()
 
 	// 构建完整的文件路径
 	filePath := filepath.Join(downloadDir, fileName)
 
 	// 验证文件存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return "", fmt.Errorf("文件不存在: %s", fileName)
+		return "", errorf(msgFileNotFound, fileName)
 	}
 
 	// 返回文件路径，Wails运行时会处理安全的文件访问
@@ -2441,7 +2402,9 @@ func (a *App) ServeVideoFile(fileName string) (string, error) {
 }
 
 // UploadFile handles file upload
-// UploadFile 处理文件上传
+// UploadFile 处理文件上传，整个文件以 Base64 传入，只适合小文件；大文件使用 BeginUpload、AppendChunk 和 FinishUpload 分块上传。
+// 文件在后台保存，返回的 uploadId 用于匹配 upload:progress 事件，保存完成或失败时推送 completed 或 failed；
+// 与分块上传相同，只接受视频扩展名、大小不超过上限、内容是视频的文件
 func (a *App) UploadFile(fileData string) (string, error) {
 	// 解析前端传递的JSON数据
 	type UploadRequest struct {
@@ -2454,26 +2417,33 @@ func (a *App) UploadFile(fileData string) (string, error) {
 		return "", err
 	}
 
-	// 创建转码目录
-	transcodeDir := "./transcode"
-	if err := os.MkdirAll(transcodeDir, 0755); err != nil {
-		return "", fmt.Errorf("创建转码目录失败: %w", err)
+	// 保存到转码目录中与文件名同名的子目录
+	req.FileName = filepath.Base(strings.TrimSpace(req.FileName))
+	if err := validateUpload(req.FileName, int64(base64.StdEncoding.DecodedLen(len(req.Content)))); err != nil {
+		return "", err
 	}
-
-	// 创建与文件名同名的子目录
-	baseName := strings.TrimSuffix(req.FileName, filepath.Ext(req.FileName))
-	videoSubDir := filepath.Join(transcodeDir, baseName)
-	if err := os.MkdirAll(videoSubDir, 0755); err != nil {
-		return "", fmt.Errorf("创建视频子目录失败: %w", err)
+	// 只解码开头的部分识别内容类型，完整的解码在后台进行
+	head, err := base64.StdEncoding.DecodeString(req.Content[:min(len(req.Content), base64.StdEncoding.EncodedLen(uploadSniffSize))])
+	if err != nil {
+		return "", errorf(msgInvalidArg, 1, err)
+	}
+	if err := validateUploadContent(req.FileName, head); err != nil {
+		return "", err
+	}
+	inputFilePath, baseName, err := uploadTarget(req.FileName)
+	if err != nil {
+		return "", err
+	}
+	uploadID, err := randomToken(16)
+	if err != nil {
+		return "", err
 	}
-
-	// 保存上传的文件到子目录中
-	inputFilePath := filepath.Join(videoSubDir, req.FileName)
 
 	// 立即返回响应，不等待文件保存完成
 	response := map[string]interface{}{
 		"status":     "success",
 		"message":    "File uploaded successfully",
+		"uploadId":   uploadID,
 		"fileName":   req.FileName,
 		"filePath":   inputFilePath,
 		"subDirName": baseName,
@@ -2484,47 +2454,105 @@ func (a *App) UploadFile(fileData string) (string, error) {
 		return "", err
 	}
 
-	// 使用goroutine后台处理文件保存，不阻塞响应返回
+	// 返回前记录为接收中，StartTranscode 会等待保存完成
+	progress := UploadProgress{
+		UploadID: uploadID,
+		FileName: req.FileName,
+		FilePath: inputFilePath,
+		Total:    int64(base64.StdEncoding.DecodedLen(len(req.Content))),
+		Status:   uploadStatusReceiving,
+	}
+	a.emitUploadProgress(progress, true)
+
+	// 使用goroutine后台处理文件保存，不阻塞响应返回，通过 upload:progress 事件报告进度
 	go func() {
-		fmt.Printf("开始后台保存文件: %s\n", req.FileName)
+		slog.Info("开始后台保存文件", "fileName", req.FileName, "uploadId", uploadID)
+		fail := func(err error) {
+			slog.Error("保存上传的文件失败", "fileName", req.FileName, "error", err)
+			progress.Status = uploadStatusFailed
+			progress.Error = err.Error()
+			a.emitUploadProgress(progress, true)
+		}
 
 		// 解码Base64字符串为字节数组
 		data, err := base64.StdEncoding.DecodeString(req.Content)
 		if err != nil {
-			fmt.Printf("解码Base64失败: %v\n", err)
+			fail(errorf(msgInvalidArg, 1, err))
+			return
+		}
+		progress.Total = int64(len(data))
+
+		// 转码目录中已有内容相同的视频时不保存第二份
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		if existing, ok := a.uploads.hashes.find(hash, progress.Total); ok {
+			os.Remove(filepath.Dir(inputFilePath))
+			slog.Info("上传的文件与已有视频相同，使用已有的视频", "fileName", req.FileName, "existing", existing, "sha256", hash)
+			progress.Written = progress.Total
+			progress.Status = uploadStatusCompleted
+			progress.ExistingPath = existing
+			a.emitUploadProgress(progress, true)
 			return
 		}
 
-		// 使用缓冲写入优化大文件写入
 		file, err := os.Create(inputFilePath)
 		if err != nil {
-			fmt.Printf("创建文件失败: %v\n", err)
+			fail(errorf(msgCreateFileFailed, err))
 			return
 		}
-		defer file.Close()
-
-		// 使用4KB缓冲区写入文件
-		writer := bufio.NewWriterSize(file, 4096)
-		defer writer.Flush()
 
-		if _, err := writer.Write(data); err != nil {
-			fmt.Printf("写入文件失败: %v\n", err)
+		// 分段写入，每段写完推送一次进度（按时间节流）
+		for len(data) > 0 {
+			n := min(len(data), uploadWriteSize)
+			if _, err := file.Write(data[:n]); err != nil {
+				file.Close()
+				os.Remove(inputFilePath)
+				fail(errorf(msgCreateFileFailed, err))
+				return
+			}
+			data = data[n:]
+			progress.Written += int64(n)
+			a.emitUploadProgress(progress, false)
+		}
+		if err := file.Close(); err != nil {
+			os.Remove(inputFilePath)
+			fail(errorf(msgCreateFileFailed, err))
 			return
 		}
 
-		fmt.Printf("文件保存成功: %s\n", inputFilePath)
+		a.uploads.hashes.add(inputFilePath, hash)
+		progress.Status = uploadStatusCompleted
+		a.emitUploadProgress(progress, true)
+		slog.Info("文件保存成功", "file", inputFilePath)
 	}()
 
 	// 立即返回响应，提升前端体验
 	return string(jsonData), nil
 }
 
+// checkRemoteTranscode 检查通过REST API或WebSocket开始的转码，自定义 FFmpeg 参数只能在桌面端使用：
+// ffmpeg 的参数可以读写本机的任意文件
+func checkRemoteTranscode(transcodeData string) error {
+	var req struct {
+		FFmpegParams string `json:"ffmpegParams"`
+	}
+	if err := json.Unmarshal([]byte(transcodeData), &req); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.FFmpegParams) != "" {
+		return errorf(msgDesktopOnlyFFmpegParams)
+	}
+	return nil
+}
+
 // StartTranscode starts transcoding for an uploaded file
-// StartTranscode 开始转码已上传的文件
+// StartTranscode 开始转码已上传的文件；filePath 不为空时直接转码该文件（通过 SelectVideoFile 选择的本地文件），
+// 输出保存在原文件旁边
 func (a *App) StartTranscode(transcodeData string) (string, error) {
 	// 解析前端传递的JSON数据
 	type TranscodeRequest struct {
 		FileName     string `json:"fileName"`
+		FilePath     string `json:"filePath"`
 		OutputFormat string `json:"outputFormat"`
 		Resolution   string `json:"resolution"`
 		Quality      int    `json:"quality"`
@@ -2540,12 +2568,29 @@ func (a *App) StartTranscode(transcodeData string) (string, error) {
 
 	// 构建输入文件路径
 	baseName := strings.TrimSuffix(req.FileName, filepath.Ext(req.FileName))
-	videoSubDir := filepath.Join("./transcode", baseName)
+	videoSubDir := filepath.Join(transcodeDir(), baseName)
 	inputFilePath := filepath.Join(videoSubDir, req.FileName)
+	if req.FilePath != "" {
+		inputFilePath = absPath(req.FilePath)
+		videoSubDir = filepath.Dir(inputFilePath)
+		baseName = strings.TrimSuffix(filepath.Base(inputFilePath), filepath.Ext(inputFilePath))
+	}
+
+	// 文件还在上传或保存时等待完成，上传失败时不转码
+	// 上传的文件与已有视频相同时转码已有的视频，输出保存在已有视频旁边
+	readyPath, err := a.uploads.waitReady(inputFilePath, uploadReadyTimeout)
+	if err != nil {
+		return "", err
+	}
+	if readyPath != inputFilePath {
+		inputFilePath = readyPath
+		videoSubDir = filepath.Dir(inputFilePath)
+		baseName = strings.TrimSuffix(filepath.Base(inputFilePath), filepath.Ext(inputFilePath))
+	}
 
 	// 验证输入文件是否存在
 	if _, err := os.Stat(inputFilePath); os.IsNotExist(err) {
-		return "", fmt.Errorf("输入文件不存在: %s", inputFilePath)
+		return "", errorf(msgInputFileNotFound, inputFilePath)
 	}
 
 	// 生成输出文件名
@@ -2562,76 +2607,125 @@ func (a *App) StartTranscode(transcodeData string) (string, error) {
 // CancelDownload cancels a download task
 // CancelDownload 取消下载任务
 func (a *App) CancelDownload(taskId string) (string, error) {
-	fmt.Printf("Cancelling download task: %s\n", taskId)
+	slog.Info("Cancelling download task", "taskId", taskId)
+
+	task, found := a.tasks.Download(taskId)
+	if !found {
+		return "", errorf(msgTaskNotFound, taskId)
+	}
+
+	// 如果任务正在下载，在后台停止进程
+	if task.Status == "downloading" && task.PID != 0 {
+		go stopProcess(task.PID, processStopTimeout)
+	}
+
+	// 更新任务状态为已取消
+	task, _ = a.tasks.UpdateDownload(taskId, func(t *DownloadTask) {
+		t.Status = "cancelled"
+		t.EndTime = time.Now().Format(time.RFC3339)
+	})
+	a.emitDownloadProgress(task, true)
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Download cancelled successfully",
+		"taskId":  taskId,
+	}
 
-	// 读取下载进度文件
-	progressFile := "download_progress.json"
-	data, err := os.ReadFile(progressFile)
+	jsonData, err := json.Marshal(response)
 	if err != nil {
-		return "", fmt.Errorf("读取下载进度文件失败: %w", err)
-	}
-
-	// 解析JSON数据
-	var progressList []map[string]interface{}
-	if err := json.Unmarshal(data, &progressList); err != nil {
-		return "", fmt.Errorf("解析下载进度数据失败: %w", err)
-	}
-
-	// 查找并取消指定taskId的任务
-	var taskFound bool
-	for i, task := range progressList {
-		if task["taskId"] == taskId {
-			taskFound = true
-
-			// 如果任务正在下载，杀死进程
-			if task["status"] == "downloading" && task["pid"] != nil {
-				// 获取PID
-				var pid int
-				switch v := task["pid"].(type) {
-				case float64:
-					pid = int(v)
-				case int:
-					pid = v
-				default:
-					continue
-				}
+		return "", err
+	}
 
-				// 尝试终止进程
-				process, err := os.FindProcess(pid)
-				if err != nil {
-					fmt.Printf("查找进程 %d 时出错: %v\n", pid, err)
-				} else {
-					if err := process.Kill(); err != nil {
-						fmt.Printf("终止进程 %d 时出错: %v\n", pid, err)
-					} else {
-						fmt.Printf("成功终止进程 %d\n", pid)
-					}
-				}
-			}
+	return string(jsonData), nil
+}
 
-			// 更新任务状态为已取消
-			progressList[i]["status"] = "cancelled"
-			progressList[i]["endTime"] = time.Now().Format(time.RFC3339)
-			break
-		}
+// PauseDownload pauses a downloading or waiting task
+// PauseDownload 暂停下载任务：终止下载进程并保留已下载的数据，恢复时从已有数据继续
+func (a *App) PauseDownload(taskId string) (string, error) {
+	slog.Info("暂停下载任务", "taskId", taskId)
+	if err := a.pauseDownload(taskId, ""); err != nil {
+		return "", err
 	}
 
-	if !taskFound {
-		return "", fmt.Errorf("task not found: %s", taskId)
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Download paused successfully",
+		"taskId":  taskId,
 	}
 
-	// 写入更新后的进度信息
-	updatedData, err := json.MarshalIndent(progressList, "", "  ")
+	jsonData, err := json.Marshal(response)
 	if err != nil {
-		return "", fmt.Errorf("生成更新后的进度信息失败: %w", err)
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// pauseDownload 暂停下载任务并记录暂停原因，用户暂停时 pausedBy 为空
+func (a *App) pauseDownload(taskId, pausedBy string) error {
+	// 先在锁内更新状态，避免下载协程在进程退出后将任务改为等待中
+	var pid int
+	var pausable bool
+	task, found := a.tasks.UpdateDownload(taskId, func(t *DownloadTask) {
+		if t.Status != "downloading" && t.Status != "waiting" {
+			return
+		}
+		pausable = true
+		if t.Status == "downloading" {
+			pid = t.PID
+		}
+		t.Status = "paused"
+		t.PausedBy = pausedBy
+		t.Speed = 0
+		t.PID = 0
+	})
+	if !found {
+		return errorf(msgTaskNotFound, taskId)
+	}
+	if !pausable {
+		return errorf(msgCannotPause, task.Status)
+	}
+
+	// 在后台停止下载进程，让它保存已下载的数据；下载协程结束后会启动下一个等待中的任务
+	if pid != 0 {
+		go stopProcess(pid, processStopTimeout)
+	}
+	a.emitDownloadProgress(task, true)
+	return nil
+}
+
+// ResumeDownload resumes a paused task
+// ResumeDownload 恢复已暂停的下载任务，未达到同时下载任务数上限时立即开始，否则进入等待队列
+func (a *App) ResumeDownload(taskId string) (string, error) {
+	slog.Info("恢复下载任务", "taskId", taskId)
+
+	var resumable bool
+	task, found := a.tasks.UpdateDownload(taskId, func(t *DownloadTask) {
+		if t.Status != "paused" {
+			return
+		}
+		resumable = true
+		t.Status = "waiting"
+		t.PausedBy = ""
+	})
+	if !found {
+		return "", errorf(msgTaskNotFound, taskId)
 	}
-	if err := os.WriteFile(progressFile, updatedData, 0644); err != nil {
-		return "", fmt.Errorf("写入进度文件失败: %w", err)
+	if !resumable {
+		return "", errorf(msgCannotResume, task.Status)
+	}
+	a.emitDownloadProgress(task, true)
+
+	if a.downloadSlotsAvailable() > 0 {
+		if err := a.startDownload(taskId, task.MagnetLink, task.OutputDir); err != nil {
+			return "", errorf(msgResumeFailed, err)
+		}
 	}
 
 	response := map[string]interface{}{
 		"status":  "success",
-		"message": "Download cancelled successfully",
+		"message": "Download resumed successfully",
 		"taskId":  taskId,
 	}
 
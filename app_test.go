@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+// fakeProcesses 记录对进程的操作，exited 中的进程在 Interrupt 后退出，其他进程需要 Kill
+type fakeProcesses struct {
+	mu          sync.Mutex
+	exited      map[int]bool
+	interrupted []int
+	killed      []int
+}
+
+func (p *fakeProcesses) Interrupt(pid int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.interrupted = append(p.interrupted, pid)
+	return nil
+}
+
+func (p *fakeProcesses) Kill(pid int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.killed = append(p.killed, pid)
+	return nil
+}
+
+func (p *fakeProcesses) Wait(pid int, timeout time.Duration) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exited[pid]
+}
+
+func (p *fakeProcesses) Suspend(pid int) error { return nil }
+func (p *fakeProcesses) Resume(pid int) error  { return nil }
+func (p *fakeProcesses) Adopt(pid int) error   { return nil }
+
+// useFakeProcesses 在测试期间替换 processes
+func useFakeProcesses(t *testing.T, exited ...int) *fakeProcesses {
+	t.Helper()
+	fake := &fakeProcesses{exited: make(map[int]bool)}
+	for _, pid := range exited {
+		fake.exited[pid] = true
+	}
+	previous := processes
+	processes = fake
+	t.Cleanup(func() { processes = previous })
+	return fake
+}
+
+func TestStopTranscodesForShutdown(t *testing.T) {
+	fake := useFakeProcesses(t, 101)
+	tasks, err := NewTaskManager(newMemoryTaskStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{tasks: tasks}
+	tasks.AddTranscode(TranscodeTask{TaskID: "running", Status: "transcoding", PID: 101})
+	tasks.AddTranscode(TranscodeTask{TaskID: "suspended", Status: "transcoding", PID: 102, PausedBy: pausedByBattery})
+	tasks.AddTranscode(TranscodeTask{TaskID: "waiting", Status: "waiting"})
+	tasks.AddTranscode(TranscodeTask{TaskID: "done", Status: "completed"})
+
+	pids := a.stopTranscodesForShutdown()
+	a.waitForShutdown(pids)
+
+	if len(fake.interrupted) != 2 {
+		t.Fatalf("应通知 2 个转码进程退出，实际为 %v", fake.interrupted)
+	}
+	if len(fake.killed) != 1 || fake.killed[0] != 102 {
+		t.Fatalf("只有未退出的进程应被强制终止，实际为 %v", fake.killed)
+	}
+	for _, taskID := range []string{"running", "suspended"} {
+		task, _ := tasks.Transcode(taskID)
+		if task.Status != "paused" || task.PausedBy != pausedByShutdown || task.PID != 0 {
+			t.Errorf("%s: 状态为 %s/%s，PID %d，应为因关闭而暂停", taskID, task.Status, task.PausedBy, task.PID)
+		}
+	}
+	for taskID, status := range map[string]string{"waiting": "waiting", "done": "completed"} {
+		if task, _ := tasks.Transcode(taskID); task.Status != status || task.PausedBy != "" {
+			t.Errorf("%s: 状态为 %s/%s，不应改变", taskID, task.Status, task.PausedBy)
+		}
+	}
+}
+
+// testTorrentInfo 多文件种子：文件跨越分块边界，包含空文件和填充文件，分块大小为16字节
+func testTorrentInfo() *metainfo.Info {
+	return &metainfo.Info{
+		Name:        "Show",
+		PieceLength: 16,
+		Files: []metainfo.FileInfo{
+			{Path: []string{"Season 1", "E01.mkv"}, Length: 10},
+			{Path: []string{"Season 1", "empty.nfo"}, Length: 0},
+			{Path: []string{"Extras", "Making of", "clip.mp4"}, Length: 20},
+			{Path: []string{".pad", "2"}, Length: 2, ExtendedFileAttrs: metainfo.ExtendedFileAttrs{Attr: "p"}},
+			{Path: []string{"Season 1", "E02.mkv"}, Length: 16},
+			{Path: []string{"readme.txt"}, Length: 1},
+		},
+	}
+}
+
+// formatTorrentTree 按缩进输出目录树：名称、路径、大小和序号
+func formatTorrentTree(b *strings.Builder, nodes []*TorrentTreeNode, depth int) {
+	for _, node := range nodes {
+		fmt.Fprintf(b, "%s%s %s %d %d\n", strings.Repeat("  ", depth), node.Name, node.Path, node.Size, node.Index)
+		formatTorrentTree(b, node.Children, depth+1)
+	}
+}
+
+func TestTorrentFileTree(t *testing.T) {
+	files, total := torrentFileList(testTorrentInfo())
+	if total != 47 || len(files) != 5 {
+		t.Fatalf("文件列表有 %d 个文件（共 %d 字节），应为 5 个（共 47 字节），不包括填充文件", len(files), total)
+	}
+
+	// 目录按第一次出现的顺序排列，后出现的同目录文件归入已有目录；填充文件不显示，但之后的序号不变
+	var b strings.Builder
+	formatTorrentTree(&b, torrentFileTree(files), 0)
+	want := `Season 1 Season 1 26 -1
+  E01.mkv Season 1/E01.mkv 10 0
+  empty.nfo Season 1/empty.nfo 0 1
+  E02.mkv Season 1/E02.mkv 16 4
+Extras Extras 20 -1
+  Making of Extras/Making of 20 -1
+    clip.mp4 Extras/Making of/clip.mp4 20 2
+readme.txt readme.txt 1 5
+`
+	if got := b.String(); got != want {
+		t.Fatalf("目录树为:\n%s应为:\n%s", got, want)
+	}
+
+	// 单文件种子只有一个文件节点，没有文件时返回空列表而不是 null
+	single, _ := torrentFileList(&metainfo.Info{Name: "movie.mkv", Length: 5, PieceLength: 16})
+	if tree := torrentFileTree(single); len(tree) != 1 || tree[0].Path != "movie.mkv" || tree[0].Index != 0 || tree[0].Children != nil {
+		t.Fatalf("单文件种子的目录树为 %+v", tree)
+	}
+	if tree := torrentFileTree(nil); tree == nil || len(tree) != 0 {
+		t.Fatalf("没有文件时目录树为 %#v", tree)
+	}
+}
+
+func TestSetPieceRange(t *testing.T) {
+	files, _ := torrentFileList(testTorrentInfo())
+	tests := []struct {
+		path                    string
+		offset                  int64
+		first, last, pieceCount int
+	}{
+		{"Season 1/E01.mkv", 0, 0, 0, 1},
+		// 空文件不覆盖任何分块
+		{"Season 1/empty.nfo", 10, -1, -1, 0},
+		// 跨越分块边界，第一个分块与上一个文件共用
+		{"Extras/Making of/clip.mp4", 10, 0, 1, 2},
+		// 填充文件之后的文件从分块开始处对齐
+		{"Season 1/E02.mkv", 32, 2, 2, 1},
+		{"readme.txt", 48, 3, 3, 1},
+	}
+	for i, tt := range tests {
+		f := files[i]
+		if f.Path != tt.path || f.Offset != tt.offset || f.FirstPiece != tt.first || f.LastPiece != tt.last || f.PieceCount != tt.pieceCount {
+			t.Errorf("%s: 偏移 %d，分块 %d-%d（%d 个），应为 %s: 偏移 %d，分块 %d-%d（%d 个）",
+				f.Path, f.Offset, f.FirstPiece, f.LastPiece, f.PieceCount, tt.path, tt.offset, tt.first, tt.last, tt.pieceCount)
+		}
+	}
+
+	single := []struct {
+		name                    string
+		info                    *metainfo.Info
+		size                    int64
+		first, last, pieceCount int
+	}{
+		{"最后一个分块不完整", &metainfo.Info{PieceLength: 16, Length: 33}, 33, 0, 2, 3},
+		{"正好一个分块", &metainfo.Info{PieceLength: 16, Length: 16}, 16, 0, 0, 1},
+		{"空文件", &metainfo.Info{PieceLength: 16}, 0, -1, -1, 0},
+		{"只有 v2 哈希", &metainfo.Info{PieceLength: 16, MetaVersion: 2}, 100, -1, -1, 0},
+	}
+	for _, tt := range single {
+		f := FileInfo{Size: tt.size}
+		f.setPieceRange(tt.info, 0)
+		if f.FirstPiece != tt.first || f.LastPiece != tt.last || f.PieceCount != tt.pieceCount {
+			t.Errorf("%s: 分块 %d-%d（%d 个），应为 %d-%d（%d 个）", tt.name, f.FirstPiece, f.LastPiece, f.PieceCount, tt.first, tt.last, tt.pieceCount)
+		}
+	}
+}
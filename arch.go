@@ -0,0 +1,105 @@
+package main
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"runtime"
+	"strings"
+)
+
+// archAliases 安装包和工具文件名中各架构的常见写法
+var archAliases = map[string][]string{
+	"amd64": {"amd64", "x86_64", "x64"},
+	"arm64": {"arm64", "aarch64"},
+}
+
+// platformDirName 按平台区分的工具子目录名，例如 darwin-arm64、windows-amd64
+func platformDirName() string {
+	return runtime.GOOS + "-" + runtime.GOARCH
+}
+
+// nameArch 返回文件名中出现的架构（GOARCH 写法），没有出现时返回空字符串；
+// macOS 的 universal 安装包同时包含 Intel 和 Apple 芯片版本，视为当前架构
+func nameArch(name string) string {
+	lower := strings.ToLower(name)
+	if runtime.GOOS == "darwin" && strings.Contains(lower, "universal") {
+		return runtime.GOARCH
+	}
+	for _, arch := range []string{"arm64", "amd64"} {
+		for _, alias := range archAliases[arch] {
+			if strings.Contains(lower, alias) {
+				return arch
+			}
+		}
+	}
+	return ""
+}
+
+// binaryArches 读取可执行文件支持的架构（GOARCH 写法）：Windows PE、Linux ELF、macOS Mach-O（包括 universal）。
+// 无法识别（例如脚本）时返回 nil
+func binaryArches(path string) []string {
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		switch f.Machine {
+		case pe.IMAGE_FILE_MACHINE_AMD64:
+			return []string{"amd64"}
+		case pe.IMAGE_FILE_MACHINE_ARM64:
+			return []string{"arm64"}
+		case pe.IMAGE_FILE_MACHINE_I386:
+			return []string{"386"}
+		}
+		return nil
+	}
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		switch f.Machine {
+		case elf.EM_X86_64:
+			return []string{"amd64"}
+		case elf.EM_AARCH64:
+			return []string{"arm64"}
+		case elf.EM_386:
+			return []string{"386"}
+		case elf.EM_ARM:
+			return []string{"arm"}
+		}
+		return nil
+	}
+	machoArch := func(cpu macho.Cpu) string {
+		switch cpu {
+		case macho.CpuAmd64:
+			return "amd64"
+		case macho.CpuArm64:
+			return "arm64"
+		}
+		return cpu.String()
+	}
+	if f, err := macho.OpenFat(path); err == nil {
+		defer f.Close()
+		var arches []string
+		for _, arch := range f.Arches {
+			arches = append(arches, machoArch(arch.Cpu))
+		}
+		return arches
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		return []string{machoArch(f.Cpu)}
+	}
+	return nil
+}
+
+// runsNatively 判断可执行文件是否为当前架构编译；无法识别架构时视为可以运行。
+// 其他架构的程序在 Apple 芯片（Rosetta）和 ARM 版 Windows 上可以通过模拟运行，但速度慢且无法使用硬件编码
+func runsNatively(path string) bool {
+	arches := binaryArches(path)
+	if arches == nil {
+		return true
+	}
+	for _, arch := range arches {
+		if arch == runtime.GOARCH {
+			return true
+		}
+	}
+	return false
+}
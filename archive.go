@@ -0,0 +1,377 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventArchiveExtracted 解压完一个压缩包后推送，负载为 ArchiveResult
+const EventArchiveExtracted = "archive:extracted"
+
+// archiveExtractTimeout 解压单个压缩包的超时，多卷 RAR 可能有几十GB
+const archiveExtractTimeout = 2 * time.Hour
+
+// 解压结果
+const (
+	archiveExtracted = "extracted"
+	archiveFailed    = "failed"
+)
+
+var (
+	// rarPartPattern 新式多卷 RAR：name.part01.rar、name.part02.rar
+	rarPartPattern = regexp.MustCompile(`(?i)\.part(\d+)\.rar$`)
+	// splitVolumePattern 分卷的后续部分：旧式 RAR 的 .r00、.r01，ZIP 的 .z01
+	splitVolumePattern = regexp.MustCompile(`(?i)\.(r|z)\d{2,3}$`)
+	// numberedVolumePattern 按编号分割的压缩包：name.7z.001、name.zip.001
+	numberedVolumePattern = regexp.MustCompile(`(?i)\.(7z|zip|rar)\.(\d{3})$`)
+)
+
+// extractMu 同一时间只解压一个压缩包，避免自动解压和手动解压同时写入同一目录
+var extractMu sync.Mutex
+
+// ArchiveResult is the result of extracting an archive
+// ArchiveResult 一个压缩包（多卷压缩包为第一卷）的解压结果
+type ArchiveResult struct {
+	Archive string `json:"archive"`
+	// 解压到的目录，与压缩包相同
+	Dir string `json:"dir"`
+	// 状态：extracted, failed
+	Status string `json:"status"`
+	// 解压出的文件数，已存在的文件跳过不计
+	Files int    `json:"files"`
+	Error string `json:"error,omitempty"`
+}
+
+// archiveVolume 判断文件是否为压缩包，first 表示是单个压缩包或多卷压缩包的第一卷，解压时只需要打开第一卷
+func archiveVolume(name string) (archive bool, first bool) {
+	lower := strings.ToLower(name)
+	if m := rarPartPattern.FindStringSubmatch(lower); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return true, n == 1
+	}
+	if m := numberedVolumePattern.FindStringSubmatch(lower); m != nil {
+		return true, m[2] == "001"
+	}
+	if splitVolumePattern.MatchString(lower) {
+		return true, false
+	}
+	switch filepath.Ext(lower) {
+	case ".rar", ".zip", ".7z":
+		return true, true
+	}
+	return false, false
+}
+
+// findArchives 返回路径中需要解压的压缩包（多卷压缩包只返回第一卷），path 可以是压缩包或文件夹
+func findArchives(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if _, first := archiveVolume(info.Name()); first {
+			return []string{path}, nil
+		}
+		return nil, nil
+	}
+	var archives []string
+	err = filepath.WalkDir(path, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if p != path && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, first := archiveVolume(entry.Name()); first {
+			archives = append(archives, p)
+		}
+		return nil
+	})
+	return archives, err
+}
+
+// singleZip 判断是否为没有分卷的 ZIP，这种压缩包直接用 archive/zip 解压，不需要外部程序
+func singleZip(archive string) bool {
+	if !strings.EqualFold(filepath.Ext(archive), ".zip") {
+		return false
+	}
+	base := strings.TrimSuffix(archive, filepath.Ext(archive))
+	_, err := os.Stat(base + ".z01")
+	if err != nil {
+		_, err = os.Stat(base + ".Z01")
+	}
+	return err != nil
+}
+
+// extractZip 解压 ZIP 到 dir，已存在的文件跳过；拒绝指向目录之外的路径（zip slip），不支持加密的 ZIP
+func extractZip(archive, dir string) (int, error) {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	extracted := 0
+	for _, file := range reader.File {
+		name := filepath.FromSlash(file.Name)
+		target := filepath.Join(dir, name)
+		if filepath.IsAbs(name) || !pathWithin(target, dir) {
+			return extracted, errorf(msgInvalidArchivePath, file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return extracted, err
+			}
+			continue
+		}
+		if file.Flags&0x1 != 0 {
+			return extracted, errorf(msgArchiveEncrypted)
+		}
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		}
+		if err := extractZipFile(file, target); err != nil {
+			return extracted, err
+		}
+		extracted++
+	}
+	return extracted, nil
+}
+
+// extractZipFile 解压 ZIP 中的一个文件，先写入临时文件，完成后改名，中断时不留下不完整的文件
+func extractZipFile(file *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	part := target + ".part"
+	dst, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(part, target)
+	}
+	if err != nil {
+		os.Remove(part)
+		return err
+	}
+	os.Chtimes(target, file.Modified, file.Modified)
+	return nil
+}
+
+// extractorInstallPaths 常见的 7-Zip 安装位置，Windows 安装程序不会加入 PATH
+func extractorInstallPaths() []string {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+	var paths []string
+	for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+		if dir := os.Getenv(env); dir != "" {
+			paths = append(paths, filepath.Join(dir, "7-Zip", "7z.exe"))
+		}
+	}
+	return paths
+}
+
+// extractorPath 返回解压 RAR、7z 和分卷压缩包的程序：设置中指定的路径，或依次查找 7z、7zz（7-Zip 官方的 macOS/Linux 版本）、
+// 7za 和 unrar（只能解压 RAR）
+func extractorPath() (string, error) {
+	configured := currentSettings().ExtractorPath
+	if configured != "" {
+		return configured, nil
+	}
+	var firstErr error
+	for _, name := range []string{"7z", "7zz", "7za", "unrar"} {
+		path, err := resolveTool("", name)
+		if err == nil {
+			return path, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, path := range extractorInstallPaths() {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", errorf(msgExtractorNotFound, firstErr)
+}
+
+// extractExternal 用 7-Zip 或 unrar 解压到 dir，已存在的文件跳过；加密的压缩包不询问密码，直接失败
+func extractExternal(ctx context.Context, extractor, archive, dir string) (int, error) {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(extractor), filepath.Ext(extractor)))
+	var args []string
+	if name == "unrar" {
+		if !strings.EqualFold(filepath.Ext(archive), ".rar") {
+			return 0, errorf(msgExtractorNotFound, errorf(msgUnrarUnsupported, filepath.Base(archive)))
+		}
+		args = []string{"x", "-o-", "-p-", "-y", archive, dir + string(filepath.Separator)}
+	} else {
+		args = []string{"x", "-y", "-aos", "-p", "-bd", "-o" + dir, archive}
+	}
+
+	before := countFiles(dir)
+	cmd := exec.CommandContext(ctx, extractor, args...)
+	hideWindow(cmd)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", err, lastLines(string(output), 3))
+	}
+	return max(countFiles(dir)-before, 0), nil
+}
+
+// countFiles 统计目录中的文件数
+func countFiles(dir string) int {
+	_, files, _ := directorySize(dir)
+	return files
+}
+
+// lastLines 返回输出的最后几行非空内容，用于错误信息
+func lastLines(output string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "; ")
+}
+
+// extractArchive 解压一个压缩包到所在目录
+func extractArchive(archive string) ArchiveResult {
+	extractMu.Lock()
+	defer extractMu.Unlock()
+
+	dir := filepath.Dir(archive)
+	result := ArchiveResult{Archive: archive, Dir: dir}
+	started := time.Now()
+	var files int
+	var err error
+	if singleZip(archive) {
+		files, err = extractZip(archive, dir)
+	} else {
+		var extractor string
+		extractor, err = extractorPath()
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), archiveExtractTimeout)
+			files, err = extractExternal(ctx, extractor, archive, dir)
+			cancel()
+		}
+	}
+	result.Files = files
+	if err != nil {
+		result.Status, result.Error = archiveFailed, err.Error()
+		slog.Error("解压失败", "archive", archive, "error", err)
+		return result
+	}
+	result.Status = archiveExtracted
+	slog.Info("解压完成", "archive", archive, "files", files, "elapsed", time.Since(started).Round(time.Millisecond))
+	return result
+}
+
+// extractArchives 解压路径中的所有压缩包，每解压完一个推送 EventArchiveExtracted，完成后清除视频库缓存
+func (a *App) extractArchives(path string) []ArchiveResult {
+	archives, err := findArchives(path)
+	if err != nil {
+		slog.Warn("查找压缩包失败", "path", path, "error", err)
+		return nil
+	}
+	results := []ArchiveResult{}
+	for _, archive := range archives {
+		result := extractArchive(archive)
+		a.emitEvent(EventArchiveExtracted, result)
+		results = append(results, result)
+	}
+	if root, ok := libraryRootOf(path); ok && len(results) > 0 {
+		a.library.invalidate(root.name)
+	}
+	return results
+}
+
+// autoExtractDownload 下载完成后按设置在后台解压其中的压缩包，结果以通知显示
+func (a *App) autoExtractDownload(task DownloadTask) {
+	if !currentSettings().AutoExtract || task.FileName == "" {
+		return
+	}
+	go func() {
+		results := a.extractArchives(filepath.Join(task.OutputDir, task.FileName))
+		if len(results) == 0 {
+			return
+		}
+		failed := 0
+		for _, result := range results {
+			if result.Status == archiveFailed {
+				failed++
+			}
+		}
+		if failed > 0 {
+			a.notify(tr(msgNotifyExtractFailed), tr(msgNotifyExtractFailedCount, task.FileName, failed), "")
+			return
+		}
+		a.notify(tr(msgNotifyExtractCompleted), task.FileName, filepath.Join(task.OutputDir, task.FileName))
+	}()
+}
+
+// ExtractArchives extracts the archives in a library file or folder
+// ExtractArchives 解压视频库中的压缩包：path 为压缩包或文件夹（例如下载任务的内容），解压文件夹中的所有压缩包。
+// ZIP 直接解压；RAR、7z 和分卷压缩包需要 7-Zip（7z/7zz）或 unrar，路径可以在设置中指定。
+// 解压到压缩包所在目录，已存在的文件跳过；压缩包保留，以便继续做种
+func (a *App) ExtractArchives(path string) (string, error) {
+	path = absPath(path)
+	if _, ok := libraryRootOf(path); !ok {
+		return "", errorf(msgPathOutsideLibrary, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", errorf(msgFileNotFound, path)
+	}
+	if err := a.checkFileNotInUse(path); err != nil {
+		return "", err
+	}
+	results := a.extractArchives(path)
+	if len(results) == 0 {
+		return "", errorf(msgNoArchives)
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":  "success",
+		"results": results,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
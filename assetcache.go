@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// hashedAssetPrefix 构建时文件名带内容哈希的前端资源（见 vite.config 的 assets/js、assets/img 等），内容不会变化
+const hashedAssetPrefix = "/assets/"
+
+// staticCacheControl 返回前端资源和缩略图的 Cache-Control：按设置缓存指定的小时数，0表示每次使用前都向服务器验证；
+// immutable 用于文件名带内容哈希的资源，缓存期内浏览器刷新页面也不会重新验证
+func staticCacheControl(immutable bool) string {
+	hours := currentSettings().StaticCacheHours
+	if hours <= 0 {
+		return "no-cache"
+	}
+	value := "private, max-age=" + strconv.Itoa(hours*3600)
+	if immutable {
+		value += ", immutable"
+	}
+	return value
+}
+
+// fileETag 按文件大小和修改时间生成 ETag，文件被替换或修改后 ETag 随之改变
+func fileETag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+}
+
+// assetETags 内嵌前端资源的 ETag（内容的 SHA-256），内嵌文件没有修改时间，不能使用 Last-Modified；首次请求时计算
+var assetETags = sync.OnceValue(func() map[string]string {
+	etags := make(map[string]string)
+	dist, err := fs.Sub(assets, "frontend/dist")
+	if err != nil {
+		return etags
+	}
+	err = fs.WalkDir(dist, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(dist, path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		etags["/"+path] = `"` + hex.EncodeToString(sum[:16]) + `"`
+		return nil
+	})
+	if err != nil {
+		slog.Warn("计算前端资源的 ETag 失败", "error", err)
+	}
+	return etags
+})
+
+// etagMatches 判断 If-None-Match 是否包含 etag（忽略弱验证前缀）
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setAssetCacheHeaders 为内嵌的前端资源设置 ETag 和 Cache-Control，浏览器缓存仍有效时直接返回 304，返回是否已处理请求。
+// index.html 每次都向服务器验证且不使用 ETag：开发模式下页面来自 Vite 开发服务器，与内嵌的版本不同
+func setAssetCacheHeaders(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if r.URL.Path == "/" || r.URL.Path == "/index.html" {
+		w.Header().Set("Cache-Control", "no-cache")
+		return false
+	}
+	etag, ok := assetETags()[r.URL.Path]
+	if !ok {
+		return false
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", staticCacheControl(strings.HasPrefix(r.URL.Path, hashedAssetPrefix)))
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
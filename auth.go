@@ -0,0 +1,468 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// sessionCookieName 网页版登录后的会话Cookie
+	sessionCookieName = "seedparser_session"
+	// authRealm 浏览器登录框中显示的名称
+	authRealm = "SeedParser"
+	// authPrefix 登录、退出和登录状态接口
+	authPrefix = "/auth/"
+	// maxLoginFailures 同一地址在 loginFailureWindow 内允许的登录失败次数，超过后暂时拒绝登录
+	maxLoginFailures   = 5
+	loginFailureWindow = 5 * time.Minute
+	// localTokenFile 数据目录中保存本机API令牌的文件，命令行和插件读取该文件调用本机API
+	localTokenFile = "api-token.local"
+)
+
+// routeAuth 路由的认证要求
+type routeAuth int
+
+const (
+	// routeProtected 需要登录或API令牌
+	routeProtected routeAuth = iota
+	// routePublic 无需认证
+	routePublic
+)
+
+// authRoutes 按路径前缀匹配的认证要求，未列出的路径（网页界面）需要认证
+var authRoutes = []struct {
+	prefix string
+	auth   routeAuth
+}{
+	{authPrefix, routePublic},
+	{apiPrefix, routeProtected},
+	{wsPath, routeProtected},
+	{metricsPath, routeProtected},
+	{"/downloads/", routeProtected},
+	{"/transcode/", routeProtected},
+	{libraryURLPrefix, routeProtected},
+	{thumbnailURLPrefix, routeProtected},
+	{frameURLPrefix, routeProtected},
+	{streamURLPrefix, routeProtected},
+	{liveURLPrefix, routeProtected},
+}
+
+// routeAuthFor 返回路径的认证要求
+func routeAuthFor(path string) routeAuth {
+	for _, route := range authRoutes {
+		if strings.HasPrefix(path, route.prefix) {
+			return route.auth
+		}
+	}
+	return routeProtected
+}
+
+// sessionStore 网页登录会话，保存在内存中，重启后需要重新登录
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]time.Time
+}
+
+// remoteSessions 当前的登录会话
+var remoteSessions = &sessionStore{sessions: make(map[string]time.Time)}
+
+// create 创建会话，返回会话ID和过期时间
+func (s *sessionStore) create(ttl time.Duration) (string, time.Time, error) {
+	id, err := randomToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().Add(ttl)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id] = expires
+	return id, expires, nil
+}
+
+// valid 判断会话是否有效，顺便清理已过期的会话
+func (s *sessionStore) valid(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for sid, expires := range s.sessions {
+		if now.After(expires) {
+			delete(s.sessions, sid)
+		}
+	}
+	_, ok := s.sessions[id]
+	return ok
+}
+
+func (s *sessionStore) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
+
+// clear 移除所有会话，修改账号或密码后调用
+func (s *sessionStore) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]time.Time)
+}
+
+// loginLimiter 按来源地址限制登录失败次数，防止暴力猜测密码
+type loginLimiter struct {
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+}
+
+type loginFailures struct {
+	count int
+	first time.Time
+}
+
+var remoteLoginLimiter = &loginLimiter{failures: make(map[string]*loginFailures)}
+
+// allowed 判断该地址当前是否允许尝试登录
+func (l *loginLimiter) allowed(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.failures[ip]
+	if !ok {
+		return true
+	}
+	if time.Since(f.first) >= loginFailureWindow {
+		delete(l.failures, ip)
+		return true
+	}
+	return f.count < maxLoginFailures
+}
+
+func (l *loginLimiter) fail(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.failures[ip]
+	if !ok || time.Since(f.first) >= loginFailureWindow {
+		f = &loginFailures{first: time.Now()}
+		l.failures[ip] = f
+	}
+	f.count++
+}
+
+func (l *loginLimiter) succeed(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, ip)
+}
+
+// randomToken 生成 n 字节的随机令牌（十六进制）
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errorf(msgGenerateTokenFailed, err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// secureEqual 以固定时间比较字符串，避免通过响应时间猜测密码
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// authRequired 是否设置了访问凭据；未设置时只允许本机访问
+func authRequired(s Settings) bool {
+	return s.RemotePassword != "" || s.APIToken != ""
+}
+
+// checkCredentials 校验用户名和密码，设置中的用户名为空时接受任意用户名
+func checkCredentials(s Settings, username, password string) bool {
+	if s.RemotePassword == "" {
+		return false
+	}
+	userOK := s.RemoteUsername == "" || secureEqual(username, s.RemoteUsername)
+	return secureEqual(password, s.RemotePassword) && userOK
+}
+
+// requestTokenMatches 检查请求是否携带指定的令牌：Authorization: Bearer <令牌> 或 ?token=<令牌>
+func requestTokenMatches(r *http.Request, expected string) bool {
+	if expected == "" {
+		return false
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secureEqual(token, expected) {
+		return true
+	}
+	if token := r.URL.Query().Get("token"); token != "" && secureEqual(token, expected) {
+		return true
+	}
+	return false
+}
+
+// tokenProvided 检查请求是否携带设置中的API令牌
+func tokenProvided(r *http.Request, s Settings) bool {
+	return requestTokenMatches(r, s.APIToken)
+}
+
+// localAPIToken 本次运行的本机API令牌，每次启动重新生成；生成失败时为空，本机请求只能使用登录会话或设置中的API令牌。
+// 未设置访问密码时本机请求也需要该令牌，浏览器中打开的其他网页无法读取令牌文件，也就不能通过跨站请求调用本机API
+var localAPIToken = sync.OnceValue(func() string {
+	token, err := randomToken(24)
+	if err != nil {
+		slog.Error("生成本机API令牌失败", "error", err)
+		return ""
+	}
+	return token
+})
+
+// writeLocalToken 将本机API令牌写入只有当前用户可读的文件，API服务启动时调用
+func writeLocalToken() error {
+	return writeFileAtomic(dataPath(localTokenFile), []byte(localAPIToken()), 0600)
+}
+
+// removeLocalToken 删除本机API令牌文件，API服务关闭时调用
+func removeLocalToken() {
+	if err := os.Remove(dataPath(localTokenFile)); err != nil && !os.IsNotExist(err) {
+		slog.Warn("删除本机API令牌文件失败", "error", err)
+	}
+}
+
+// readLocalToken 读取正在运行的实例写入的本机API令牌，没有运行的实例时返回空字符串
+func readLocalToken() string {
+	data, err := os.ReadFile(dataPath(localTokenFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// localTokenProvided 检查本机请求是否携带本机API令牌
+func localTokenProvided(r *http.Request) bool {
+	return isLoopbackRequest(r) && requestTokenMatches(r, localAPIToken())
+}
+
+// sameOrigin 判断浏览器发起的请求是否来自本服务的页面：Origin（没有时为 Referer）的主机必须与请求的 Host 相同。
+// 命令行、脚本和外部播放器不发送这两个请求头，不受限制
+func sameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return true
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// clientIP 返回请求的来源地址
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isLoopbackRequest 判断请求是否来自本机
+func isLoopbackRequest(r *http.Request) bool {
+	ip := net.ParseIP(clientIP(r))
+	return ip != nil && ip.IsLoopback()
+}
+
+// sessionTTL 登录会话的有效期
+func sessionTTL(s Settings) time.Duration {
+	return time.Duration(s.SessionHours) * time.Hour
+}
+
+// startSession 创建登录会话并下发Cookie
+func startSession(w http.ResponseWriter, s Settings) error {
+	id, expires, err := remoteSessions.create(sessionTTL(s))
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    id,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return nil
+}
+
+// hasSession 判断请求是否带有有效的登录会话
+func hasSession(r *http.Request) bool {
+	cookie, err := r.Cookie(sessionCookieName)
+	return err == nil && remoteSessions.valid(cookie.Value)
+}
+
+// requireAuth enforces the per-route authentication policy
+// requireAuth 按路由要求认证：
+//
+//   - 拒绝来自其他网站的浏览器请求（Origin 或 Referer 与本服务不同）
+//   - 未设置密码和API令牌时只允许本机访问，并且需要本机API令牌或登录会话
+//   - 浏览器使用登录会话Cookie，可以通过 /auth/login 或 Basic 认证登录；
+//     本机打开带本机API令牌的地址（?token=）后同样下发会话Cookie
+//   - 脚本使用 API 令牌（Authorization: Bearer 或 ?token=），本机的命令行和插件也可以使用本机API令牌
+//
+// 每次请求读取当前设置，修改凭据立即生效
+func requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sameOrigin(r) {
+			slog.Warn("拒绝跨站请求", "remote", clientIP(r), "path", r.URL.Path, "origin", r.Header.Get("Origin"))
+			writeJSONError(w, http.StatusForbidden, errorf(msgCrossOriginRequest))
+			return
+		}
+		if routeAuthFor(r.URL.Path) == routePublic {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s := currentSettings()
+		if !authRequired(s) && !isLoopbackRequest(r) {
+			writeJSONError(w, http.StatusForbidden, errorf(msgLocalOnly))
+			return
+		}
+		if hasSession(r) || tokenProvided(r, s) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if localTokenProvided(r) {
+			if r.URL.Query().Get("token") != "" {
+				if err := startSession(w, s); err != nil {
+					writeJSONError(w, http.StatusInternalServerError, err)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !authRequired(s) {
+			writeJSONError(w, http.StatusUnauthorized, errorf(msgLocalTokenRequired))
+			return
+		}
+
+		ip := clientIP(r)
+		if username, password, ok := r.BasicAuth(); ok {
+			if !remoteLoginLimiter.allowed(ip) {
+				writeJSONError(w, http.StatusTooManyRequests, errorf(msgTooManyLoginAttempts))
+				return
+			}
+			if checkCredentials(s, username, password) {
+				remoteLoginLimiter.succeed(ip)
+				if err := startSession(w, s); err != nil {
+					writeJSONError(w, http.StatusInternalServerError, err)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			remoteLoginLimiter.fail(ip)
+			slog.Warn("远程登录失败", "remote", ip, "username", username)
+		}
+
+		slog.Debug("拒绝未认证的远程请求", "remote", ip, "path", r.URL.Path)
+		w.Header().Set("WWW-Authenticate", `Basic realm="`+authRealm+`", charset="UTF-8"`)
+		writeJSONError(w, http.StatusUnauthorized, errorf(msgLoginRequired))
+	})
+}
+
+// newAuthHandler creates the login/logout endpoints
+// newAuthHandler 创建登录相关接口：
+//
+//	POST /auth/login   请求体 {"username":"...","password":"..."}，成功后下发会话Cookie
+//	POST /auth/logout  退出登录
+//	GET  /auth/status  返回是否需要登录以及当前是否已登录
+func newAuthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := currentSettings()
+		writeJSON := func(data map[string]interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(data)
+		}
+
+		switch strings.TrimPrefix(r.URL.Path, authPrefix) {
+		case "login":
+			if r.Method != http.MethodPost {
+				writeJSONError(w, http.StatusMethodNotAllowed, errorf(msgLoginMethod))
+				return
+			}
+			ip := clientIP(r)
+			if !remoteLoginLimiter.allowed(ip) {
+				writeJSONError(w, http.StatusTooManyRequests, errorf(msgTooManyLoginAttempts))
+				return
+			}
+			var req struct {
+				Username string `json:"username"`
+				Password string `json:"password"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSONError(w, http.StatusBadRequest, errorf(msgInvalidLoginRequest))
+				return
+			}
+			if !checkCredentials(s, req.Username, req.Password) {
+				remoteLoginLimiter.fail(ip)
+				slog.Warn("远程登录失败", "remote", ip, "username", req.Username)
+				writeJSONError(w, http.StatusUnauthorized, errorf(msgInvalidCredentials))
+				return
+			}
+			remoteLoginLimiter.succeed(ip)
+			if err := startSession(w, s); err != nil {
+				writeJSONError(w, http.StatusInternalServerError, err)
+				return
+			}
+			slog.Info("远程登录成功", "remote", ip)
+			writeJSON(map[string]interface{}{"status": "success"})
+		case "logout":
+			if cookie, err := r.Cookie(sessionCookieName); err == nil {
+				remoteSessions.remove(cookie.Value)
+			}
+			http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Value: "", Path: "/", MaxAge: -1})
+			writeJSON(map[string]interface{}{"status": "success"})
+		case "status":
+			required := authRequired(s)
+			authenticated := hasSession(r) || tokenProvided(r, s) || localTokenProvided(r)
+			writeJSON(map[string]interface{}{
+				"status":        "success",
+				"required":      required,
+				"authenticated": authenticated,
+			})
+		default:
+			writeJSONError(w, http.StatusNotFound, errorf(msgUnknownEndpoint, r.URL.Path))
+		}
+	})
+}
+
+// GenerateAPIToken creates and saves a new API token, invalidating the previous one
+// GenerateAPIToken 生成新的API令牌并保存到设置，旧令牌立即失效
+func (a *App) GenerateAPIToken() (string, error) {
+	token, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	s := currentSettings()
+	s.APIToken = token
+	if err := saveSettings(&s); err != nil {
+		return "", err
+	}
+
+	response := map[string]interface{}{
+		"status":   "success",
+		"apiToken": token,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newLocalAPIRequest 创建来自本机的API请求
+func newLocalAPIRequest(method, path string) *http.Request {
+	r := httptest.NewRequest(method, "http://127.0.0.1:8686"+path, strings.NewReader("[]"))
+	r.RemoteAddr = "127.0.0.1:50000"
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func TestRequireAuthLoopback(t *testing.T) {
+	handler := requireAuth(newAPIHandler(&App{}))
+	bearer := "Bearer " + localAPIToken()
+
+	tests := []struct {
+		name   string
+		setup  func(r *http.Request)
+		method string
+		remote string
+		want   int
+	}{
+		{
+			name:   "没有本机令牌",
+			setup:  func(r *http.Request) {},
+			method: http.MethodPost,
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "本机令牌",
+			setup:  func(r *http.Request) { r.Header.Set("Authorization", bearer) },
+			method: http.MethodPost,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "错误的令牌",
+			setup:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") },
+			method: http.MethodPost,
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "局域网地址使用本机令牌",
+			setup:  func(r *http.Request) { r.Header.Set("Authorization", bearer) },
+			method: http.MethodPost,
+			remote: "192.168.1.20:50000",
+			want:   http.StatusForbidden,
+		},
+		{
+			name: "其他网站发起的请求",
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", bearer)
+				r.Header.Set("Origin", "https://evil.example")
+			},
+			method: http.MethodPost,
+			want:   http.StatusForbidden,
+		},
+		{
+			name: "其他网站的Referer",
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", bearer)
+				r.Header.Set("Referer", "https://evil.example/page")
+			},
+			method: http.MethodPost,
+			want:   http.StatusForbidden,
+		},
+		{
+			name: "本服务页面发起的请求",
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", bearer)
+				r.Header.Set("Origin", "http://127.0.0.1:8686")
+			},
+			method: http.MethodPost,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "GET调用方法",
+			setup:  func(r *http.Request) { r.Header.Set("Authorization", bearer) },
+			method: http.MethodGet,
+			want:   http.StatusMethodNotAllowed,
+		},
+		{
+			name: "表单请求",
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", bearer)
+				r.Header.Set("Content-Type", "text/plain")
+			},
+			method: http.MethodPost,
+			want:   http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newLocalAPIRequest(tt.method, apiPrefix+"GetRemoteAccess")
+			if tt.remote != "" {
+				r.RemoteAddr = tt.remote
+			}
+			tt.setup(r)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("状态码为 %d，应为 %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAuthLocalTokenStartsSession(t *testing.T) {
+	handler := requireAuth(newAPIHandler(&App{}))
+
+	r := newLocalAPIRequest(http.MethodPost, apiPrefix+"GetRemoteAccess?token="+localAPIToken())
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码为 %d: %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 || cookies[0].Name != sessionCookieName {
+		t.Fatal("使用本机令牌打开页面后应下发会话Cookie")
+	}
+
+	// 之后的请求只带会话Cookie
+	r = newLocalAPIRequest(http.MethodPost, apiPrefix+"GetRemoteAccess")
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("使用会话Cookie的状态码为 %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// autostartFlag 开机自动启动时传入的参数，窗口以最小化状态启动
+const autostartFlag = "--minimized"
+
+// launchedAtLogin 判断程序是否由登录时自动启动
+func launchedAtLogin(args []string) bool {
+	for _, arg := range args {
+		if arg == autostartFlag || arg == "-minimized" {
+			return true
+		}
+	}
+	return false
+}
+
+// autostartCommand 返回自动启动使用的程序路径，解析符号链接以免程序移动后失效
+func autostartCommand() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("获取程序路径失败: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe, nil
+}
+
+// syncAutostart 启动时按设置重新注册自动启动，程序更新或移动后路径保持正确
+func syncAutostart() {
+	if !currentSettings().LaunchAtLogin {
+		return
+	}
+	if err := setAutostart(true); err != nil {
+		slog.Error("注册开机自动启动失败", "error", err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// launchAgentLabel LaunchAgent 的标识，也是 plist 的文件名
+const launchAgentLabel = "com.seedparser.app"
+
+// launchAgentPlist 登录时启动 SeedParser 的 LaunchAgent
+const launchAgentPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>%s</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`
+
+// setAutostart 在 ~/Library/LaunchAgents 中添加或移除 LaunchAgent
+func setAutostart(enabled bool) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return errorf(msgAutostartHomeDirFailed, err)
+	}
+	path := filepath.Join(home, "Library", "LaunchAgents", launchAgentLabel+".plist")
+	if !enabled {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errorf(msgAutostartRemoveFailed, err)
+		}
+		return nil
+	}
+
+	exe, err := autostartCommand()
+	if err != nil {
+		return err
+	}
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(exe))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errorf(msgCreateDirFailed, err)
+	}
+	content := fmt.Sprintf(launchAgentPlist, launchAgentLabel, escaped.String(), autostartFlag)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return errorf(msgAutostartRegisterFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// autostartDesktopEntry 桌面环境登录时启动 SeedParser 的 XDG 自动启动项
+const autostartDesktopEntry = `[Desktop Entry]
+Type=Application
+Name=SeedParser
+Exec=%s %s
+Terminal=false
+X-GNOME-Autostart-enabled=true
+`
+
+// setAutostart 在 XDG 自动启动目录（~/.config/autostart）中添加或移除启动项
+func setAutostart(enabled bool) error {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return errorf(msgAutostartConfigDirFailed, err)
+	}
+	path := filepath.Join(configDir, "autostart", "seedparser.desktop")
+	if !enabled {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errorf(msgAutostartRemoveFailed, err)
+		}
+		return nil
+	}
+
+	exe, err := autostartCommand()
+	if err != nil {
+		return err
+	}
+	// Exec 中包含空格等特殊字符的路径需要加引号，引号内的 " ` $ \ 需要转义
+	quoted := `"` + strings.NewReplacer(`\`, `\\\\`, `"`, `\\"`, "`", "\\\\`", "$", `\\$`).Replace(exe) + `"`
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errorf(msgCreateDirFailed, err)
+	}
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(autostartDesktopEntry, quoted, autostartFlag)), 0644); err != nil {
+		return errorf(msgAutostartRegisterFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	// autostartRunKey 当前用户登录时运行的程序
+	autostartRunKey = `Software\Microsoft\Windows\CurrentVersion\Run`
+	autostartName   = "SeedParser"
+)
+
+// setAutostart 在当前用户的 Run 注册表项中添加或移除 SeedParser
+func setAutostart(enabled bool) error {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, autostartRunKey, registry.SET_VALUE)
+	if err != nil {
+		return errorf(msgAutostartRegistryFailed, err)
+	}
+	defer key.Close()
+
+	if !enabled {
+		if err := key.DeleteValue(autostartName); err != nil && !errors.Is(err, registry.ErrNotExist) {
+			return errorf(msgAutostartRemoveFailed, err)
+		}
+		return nil
+	}
+	exe, err := autostartCommand()
+	if err != nil {
+		return err
+	}
+	if err := key.SetStringValue(autostartName, fmt.Sprintf(`"%s" %s`, exe, autostartFlag)); err != nil {
+		return errorf(msgAutostartRegisterFailed, err)
+	}
+	return nil
+}
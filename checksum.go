@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventChecksumProgress 校验和计算的进度，负载为 ChecksumJob；进度按 progressEventInterval 节流，每个文件计算完立即推送
+const EventChecksumProgress = "library:checksum"
+
+// 支持的校验和算法，也是导出的清单文件的扩展名
+const (
+	checksumSHA256 = "sha256"
+	checksumMD5    = "md5"
+)
+
+// 校验和计算任务和单个文件的状态，任务状态与完整性检查相同
+const (
+	checksumPending = "pending"
+	checksumHashing = "hashing"
+	checksumOK      = "ok"
+	checksumFailed  = "failed"
+	// checksumSkipped 计算被取消，文件未计算
+	checksumSkipped = "skipped"
+)
+
+// checksumBufferSize 读取文件的缓冲区大小，每读完一块检查是否已取消
+const checksumBufferSize = 1 << 20
+
+// ChecksumResult is the checksum of a single file
+// ChecksumResult 一个文件的校验和
+type ChecksumResult struct {
+	Path    string `json:"path"`
+	RelPath string `json:"relPath"`
+	Root    string `json:"root"`
+	Size    int64  `json:"size"`
+	// 状态：pending, hashing, ok, failed, skipped
+	Status string `json:"status"`
+	// 计算中的文件的进度（0-100）
+	Progress float64 `json:"progress,omitempty"`
+	// 算法到十六进制校验和的映射
+	Hashes map[string]string `json:"hashes,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// ChecksumJob is a checksum computation run
+// ChecksumJob 一次校验和计算
+type ChecksumJob struct {
+	// 状态：running, completed, cancelled
+	Status     string           `json:"status"`
+	Algorithms []string         `json:"algorithms"`
+	StartTime  time.Time        `json:"startTime"`
+	EndTime    time.Time        `json:"endTime,omitempty"`
+	Files      []ChecksumResult `json:"files"`
+	Hashed     int              `json:"hashed"`
+	Failed     int              `json:"failed"`
+	// 所有文件的总大小和已读取的字节数，用于显示总进度
+	TotalBytes  int64 `json:"totalBytes"`
+	HashedBytes int64 `json:"hashedBytes"`
+}
+
+// checksumRunner 同一时间只运行一次计算，保留最近一次计算的结果，用于导出清单
+type checksumRunner struct {
+	mu     sync.Mutex
+	job    *ChecksumJob
+	cancel context.CancelFunc
+}
+
+// snapshot 返回当前任务的副本，没有计算过时返回 nil
+func (c *checksumRunner) snapshot() *ChecksumJob {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.job == nil {
+		return nil
+	}
+	job := *c.job
+	job.Files = append([]ChecksumResult(nil), c.job.Files...)
+	return &job
+}
+
+// newChecksumHash 创建算法对应的哈希
+func newChecksumHash(algorithm string) hash.Hash {
+	if algorithm == checksumMD5 {
+		return md5.New()
+	}
+	return sha256.New()
+}
+
+// hashFileChecksums 读取一遍文件，同时计算所有算法的校验和；每读完一块调用 progress 报告本块的字节数
+func hashFileChecksums(ctx context.Context, path string, algorithms []string, progress func(int64)) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := make([]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, len(algorithms))
+	for i, algorithm := range algorithms {
+		hashes[i] = newChecksumHash(algorithm)
+		writers[i] = hashes[i]
+	}
+	w := io.MultiWriter(writers...)
+	buf := make([]byte, checksumBufferSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := f.Read(buf)
+		if n > 0 {
+			w.Write(buf[:n])
+			progress(int64(n))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sums := make(map[string]string, len(algorithms))
+	for i, algorithm := range algorithms {
+		sums[algorithm] = hex.EncodeToString(hashes[i].Sum(nil))
+	}
+	return sums, nil
+}
+
+// isChecksumManifest 判断是否为导出的清单文件，计算文件夹的校验和时跳过，避免清单包含自己
+func isChecksumManifest(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case "." + checksumSHA256, "." + checksumMD5:
+		return true
+	}
+	return false
+}
+
+// checksumFiles 返回需要计算的文件：paths 为视频库中的文件或文件夹（文件夹包括其中的所有文件，跳过隐藏文件夹），
+// 为空时返回视频库中的所有视频
+func (a *App) checksumFiles(paths []string) ([]ChecksumResult, error) {
+	var files []ChecksumResult
+	if len(paths) == 0 {
+		videos, _, err := a.libraryVideos()
+		if err != nil {
+			return nil, err
+		}
+		for _, video := range videos {
+			files = append(files, ChecksumResult{Path: video.Path, RelPath: video.RelPath, Root: video.Root, Size: video.Size})
+		}
+		return files, nil
+	}
+
+	seen := make(map[string]bool)
+	add := func(root libraryRoot, path string, size int64) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		relPath, err := filepath.Rel(absPath(root.dir), path)
+		if err != nil {
+			relPath = filepath.Base(path)
+		}
+		files = append(files, ChecksumResult{Path: path, RelPath: filepath.ToSlash(relPath), Root: root.name, Size: size})
+	}
+	for _, path := range paths {
+		path = absPath(path)
+		root, ok := libraryRootOf(path)
+		if !ok {
+			return nil, errorf(msgPathOutsideLibrary, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errorf(msgFileNotFound, path)
+		}
+		if !info.IsDir() {
+			add(root, path, info.Size())
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				slog.Warn("读取文件夹失败", "path", p, "error", err)
+				return nil
+			}
+			if entry.IsDir() {
+				if p != path && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !entry.Type().IsRegular() || isChecksumManifest(entry.Name()) {
+				return nil
+			}
+			if info, err := entry.Info(); err == nil {
+				add(root, p, info.Size())
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// runChecksums 逐个计算文件的校验和，每个文件计算完推送进度；取消后未计算的文件标记为 skipped
+func (a *App) runChecksums(ctx context.Context, job *ChecksumJob) {
+	runner := a.checksums
+	emit := func(force bool) {
+		if a.throttle.allow(EventChecksumProgress, force) {
+			a.emitEvent(EventChecksumProgress, runner.snapshot())
+		}
+	}
+	for i := range job.Files {
+		if ctx.Err() != nil {
+			break
+		}
+		runner.mu.Lock()
+		result := &job.Files[i]
+		result.Status = checksumHashing
+		path, size := result.Path, result.Size
+		runner.mu.Unlock()
+		emit(true)
+
+		var read int64
+		sums, err := hashFileChecksums(ctx, path, job.Algorithms, func(n int64) {
+			runner.mu.Lock()
+			read += n
+			job.HashedBytes += n
+			if size > 0 {
+				job.Files[i].Progress = min(float64(read)/float64(size)*100, 100)
+			}
+			runner.mu.Unlock()
+			emit(false)
+		})
+
+		runner.mu.Lock()
+		result = &job.Files[i]
+		result.Progress = 0
+		switch {
+		case ctx.Err() != nil:
+			result.Status = checksumSkipped
+		case err != nil:
+			result.Status, result.Error = checksumFailed, err.Error()
+			job.Failed++
+		default:
+			result.Status, result.Hashes = checksumOK, sums
+			job.Hashed++
+		}
+		// 文件大小可能与开始时不同，按实际读取的字节数修正总大小
+		job.TotalBytes += read - size
+		runner.mu.Unlock()
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("计算校验和失败", "path", path, "error", err)
+		}
+		emit(true)
+	}
+
+	runner.mu.Lock()
+	job.EndTime = time.Now()
+	job.Status = integrityCompleted
+	if ctx.Err() != nil {
+		job.Status = integrityCancelled
+		for i := range job.Files {
+			if job.Files[i].Status == checksumPending {
+				job.Files[i].Status = checksumSkipped
+			}
+		}
+	}
+	runner.cancel = nil
+	hashed, failed, status := job.Hashed, job.Failed, job.Status
+	elapsed := job.EndTime.Sub(job.StartTime).Round(time.Millisecond)
+	runner.mu.Unlock()
+	emit(true)
+
+	slog.Info("校验和计算结束", "status", status, "hashed", hashed, "failed", failed, "elapsed", elapsed)
+	if status == integrityCompleted {
+		message := tr(msgNotifyChecksumsHashed, hashed)
+		if failed > 0 {
+			message = tr(msgNotifyChecksumsReadFailed, hashed, failed)
+		}
+		a.notify(tr(msgNotifyChecksumsCompleted), message, "")
+	}
+}
+
+// StartChecksums computes checksums of library files in the background
+// StartChecksums 在后台计算视频库中文件的校验和：paths 为视频库中的文件或文件夹（文件夹包括其中的所有文件，例如下载的字幕和图片），
+// 为空时计算视频库中的所有视频；algorithms 为 sha256 和/或 md5，为空时只计算 SHA-256，每个文件只读取一遍。
+// 进度通过 EventChecksumProgress 推送，同一时间只能运行一次计算，完成后可以用 ExportChecksums 导出清单
+func (a *App) StartChecksums(paths []string, algorithms []string) (string, error) {
+	if len(algorithms) == 0 {
+		algorithms = []string{checksumSHA256}
+	}
+	var selected []string
+	for _, algorithm := range algorithms {
+		algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+		if algorithm != checksumSHA256 && algorithm != checksumMD5 {
+			return "", errorf(msgInvalidChecksumAlgorithm, algorithm)
+		}
+		if !slices.Contains(selected, algorithm) {
+			selected = append(selected, algorithm)
+		}
+	}
+	files, err := a.checksumFiles(paths)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", errorf(msgNoFilesToHash)
+	}
+
+	runner := a.checksums
+	runner.mu.Lock()
+	if runner.cancel != nil {
+		runner.mu.Unlock()
+		return "", errorf(msgChecksumRunning)
+	}
+	job := &ChecksumJob{Status: integrityRunning, Algorithms: selected, StartTime: time.Now(), Files: files}
+	for i := range job.Files {
+		job.Files[i].Status = checksumPending
+		job.TotalBytes += job.Files[i].Size
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	runner.job, runner.cancel = job, cancel
+	runner.mu.Unlock()
+
+	slog.Info("开始计算校验和", "files", len(files), "bytes", job.TotalBytes, "algorithms", selected)
+	go a.runChecksums(ctx, job)
+	return a.GetChecksums()
+}
+
+// GetChecksums gets the progress or result of the latest checksum computation
+// GetChecksums 获取正在进行或最近一次校验和计算的进度和结果，没有计算过时 job 为 null
+func (a *App) GetChecksums() (string, error) {
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"job":    a.checksums.snapshot(),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// CancelChecksums cancels the running checksum computation
+// CancelChecksums 取消正在进行的校验和计算，已计算的文件保留结果
+func (a *App) CancelChecksums() (string, error) {
+	a.checksums.mu.Lock()
+	cancel := a.checksums.cancel
+	a.checksums.mu.Unlock()
+	if cancel == nil {
+		return "", errorf(msgChecksumNotRunning)
+	}
+	cancel()
+	return a.GetChecksums()
+}
+
+// commonDir 返回所有文件共同的上级目录，没有共同目录时（例如在不同的磁盘上）返回空字符串
+func commonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	dir := filepath.Dir(paths[0])
+	for _, path := range paths[1:] {
+		for !pathWithin(path, dir) {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return ""
+			}
+			dir = parent
+		}
+	}
+	return dir
+}
+
+// checksumManifest 生成 sha256sum/md5sum 格式的清单（每行为“校验和  路径”），路径相对于清单所在目录并使用正斜杠，
+// 可以在复制到其他存储后用 sha256sum -c 校验；不在清单目录中的文件使用绝对路径
+func checksumManifest(files []ChecksumResult, algorithm, dir string) []byte {
+	var sb strings.Builder
+	for _, file := range files {
+		name := file.Path
+		if rel, err := filepath.Rel(dir, file.Path); err == nil && pathWithin(file.Path, dir) {
+			name = rel
+		}
+		fmt.Fprintf(&sb, "%s  %s\n", file.Hashes[algorithm], filepath.ToSlash(name))
+	}
+	return []byte(sb.String())
+}
+
+// ExportChecksums exports a checksum manifest of the latest computation
+// ExportChecksums 将最近一次计算的校验和导出为清单文件（sha256sum/md5sum 格式），algorithm 为空时使用计算的第一个算法。
+// path 为空时写入所有文件共同的上级目录，文件名为 checksums.sha256 或 checksums.md5，清单随文件一起复制后可以直接校验。
+// 只导出计算成功的文件，计算进行中时导出已完成的部分
+func (a *App) ExportChecksums(path string, algorithm string) (string, error) {
+	job := a.checksums.snapshot()
+	if job == nil {
+		return "", errorf(msgNoChecksums)
+	}
+	algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+	if algorithm == "" {
+		algorithm = job.Algorithms[0]
+	}
+	if !slices.Contains(job.Algorithms, algorithm) {
+		return "", errorf(msgInvalidChecksumAlgorithm, algorithm)
+	}
+	var files []ChecksumResult
+	var paths []string
+	for _, file := range job.Files {
+		if file.Status == checksumOK {
+			files = append(files, file)
+			paths = append(paths, file.Path)
+		}
+	}
+	if len(files) == 0 {
+		return "", errorf(msgNoChecksums)
+	}
+
+	if path == "" {
+		dir := commonDir(paths)
+		if dir == "" {
+			dir = filepath.Dir(paths[0])
+		}
+		path = filepath.Join(dir, "checksums."+algorithm)
+	}
+	path = absPath(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", errorf(msgWriteManifestFailed, err)
+	}
+	if err := writeFileAtomic(path, checksumManifest(files, algorithm, filepath.Dir(path)), 0644); err != nil {
+		slog.Error("导出校验和清单失败", "path", path, "error", err)
+		return "", errorf(msgWriteManifestFailed, err)
+	}
+	slog.Info("已导出校验和清单", "path", path, "algorithm", algorithm, "files", len(files))
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":    "success",
+		"path":      path,
+		"algorithm": algorithm,
+		"files":     len(files),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// cliCommand 命令行子命令，通过本机API调用正在运行的 SeedParser；
+// setup 注册子命令自己的参数，返回解析参数后执行的函数
+type cliCommand struct {
+	usage string
+	setup func(fs *flag.FlagSet) func(c *cliClient, args []string) error
+}
+
+// cliCommands 支持的子命令
+var cliCommands = map[string]cliCommand{
+	"add": {
+		usage: "add <种子文件|磁力链接>...  添加下载任务",
+		setup: cliAdd,
+	},
+	"status": {
+		usage: "status [任务ID]  查看下载和转码任务",
+		setup: cliStatus,
+	},
+	"transcode": {
+		usage: "transcode <视频文件> [--preset 720p] [--output 输出文件]  添加转码任务",
+		setup: cliTranscode,
+	},
+	"pause": {
+		usage: "pause <任务ID>  暂停下载任务",
+		setup: cliTaskAction("PauseDownload"),
+	},
+	"resume": {
+		usage: "resume <任务ID>  继续下载任务",
+		setup: cliTaskAction("ResumeDownload"),
+	},
+	"cancel": {
+		usage: "cancel <任务ID>  取消下载任务",
+		setup: cliTaskAction("CancelDownload"),
+	},
+	"priority": {
+		usage: "priority <任务ID> <high|normal|low>  设置下载任务的优先级",
+		setup: cliPriority,
+	},
+}
+
+// isCLICommand 判断命令行参数是否为子命令
+func isCLICommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	_, ok := cliCommands[args[0]]
+	return ok
+}
+
+// cliClient 调用本机或远程 SeedParser 的REST API
+type cliClient struct {
+	addr     string
+	token    string
+	username string
+	password string
+	// localToken 本机实例的本机API令牌，没有设置API令牌和访问密码时使用
+	localToken string
+	http       *http.Client
+}
+
+// call 调用API方法，返回方法的JSON结果
+func (c *cliClient) call(method string, args ...interface{}) ([]byte, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	body, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(c.addr, "/")+apiPrefix+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	} else if c.localToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.localToken)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) {
+			return nil, fmt.Errorf("无法连接到 %s，请确认 SeedParser 正在运行: %w", c.addr, err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s: %s", method, apiErr.Message)
+		}
+		return nil, fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	return data, nil
+}
+
+// isLoopbackAddr 判断API地址是否指向本机
+func isLoopbackAddr(addr string) bool {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Hostname(), "localhost") {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+// parseInterspersed 解析允许标志出现在位置参数之后的命令行，返回位置参数
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// runCLI runs a companion subcommand against a running instance and returns the exit code
+// runCLI 执行命令行子命令，返回进程退出码
+func runCLI(args []string) int {
+	name := args[0]
+	cmd := cliCommands[name]
+	s := currentSettings()
+
+	fs := flag.NewFlagSet("SeedParser "+name, flag.ContinueOnError)
+	addr := fs.String("addr", "http://127.0.0.1:"+strconv.Itoa(s.ServerPort), "SeedParser 的API地址")
+	token := fs.String("token", s.APIToken, "API令牌，默认使用设置中的令牌")
+	username := fs.String("username", s.RemoteUsername, "用户名，未使用API令牌时与密码一起登录")
+	password := fs.String("password", s.RemotePassword, "访问密码，默认使用设置中的密码")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: SeedParser %s\n\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+	run := cmd.setup(fs)
+
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	client := &cliClient{
+		addr:     *addr,
+		token:    *token,
+		username: *username,
+		password: *password,
+		http:     &http.Client{Timeout: 2 * time.Minute},
+	}
+	// 本机API令牌只发送给本机的实例
+	if isLoopbackAddr(*addr) {
+		client.localToken = readLocalToken()
+	}
+	if err := run(client, positional); err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		return 1
+	}
+	return 0
+}
+
+// cliAdd 添加下载任务，参数可以是种子文件路径或磁力链接
+func cliAdd(fs *flag.FlagSet) func(c *cliClient, args []string) error {
+	return func(c *cliClient, args []string) error {
+		if len(args) == 0 {
+			fs.Usage()
+			return errors.New("缺少种子文件或磁力链接")
+		}
+		for _, arg := range args {
+			var data []byte
+			var err error
+			if strings.HasPrefix(arg, "magnet:?") {
+				data, err = c.call("AddMagnetLink", arg)
+			} else {
+				var content []byte
+				content, err = os.ReadFile(arg)
+				if err != nil {
+					return fmt.Errorf("读取种子文件失败: %w", err)
+				}
+				fileData, _ := json.Marshal(map[string]string{
+					"content":  base64.StdEncoding.EncodeToString(content),
+					"fileName": filepath.Base(arg),
+				})
+				data, err = c.call("DownloadTorrentFiles", string(fileData), []string{})
+			}
+			if err != nil {
+				return err
+			}
+			var result struct {
+				Status string `json:"status"`
+				TaskID string `json:"taskId"`
+			}
+			json.Unmarshal(data, &result)
+			if result.Status == "exists" {
+				fmt.Printf("下载任务已存在 %s: %s\n", result.TaskID, arg)
+				continue
+			}
+			fmt.Printf("已添加下载任务 %s: %s\n", result.TaskID, arg)
+		}
+		return nil
+	}
+}
+
+// cliStatus 以表格显示下载和转码任务，指定任务ID时只显示该任务
+func cliStatus(fs *flag.FlagSet) func(c *cliClient, args []string) error {
+	asJSON := fs.Bool("json", false, "输出原始JSON")
+	return func(c *cliClient, args []string) error {
+		var filter string
+		if len(args) > 0 {
+			filter = args[0]
+		}
+
+		downloadData, err := c.call("GetDownloadStatus", "")
+		if err != nil {
+			return err
+		}
+		transcodeData, err := c.call("GetTranscodeStatus", "")
+		if err != nil {
+			return err
+		}
+		var downloads struct {
+			Tasks []DownloadTask `json:"tasks"`
+		}
+		var transcodes struct {
+			Tasks []TranscodeTask `json:"tasks"`
+		}
+		if err := json.Unmarshal(downloadData, &downloads); err != nil {
+			return fmt.Errorf("解析下载任务失败: %w", err)
+		}
+		if err := json.Unmarshal(transcodeData, &transcodes); err != nil {
+			return fmt.Errorf("解析转码任务失败: %w", err)
+		}
+		if filter != "" {
+			downloads.Tasks = filterTasks(downloads.Tasks, func(t DownloadTask) bool { return t.TaskID == filter })
+			transcodes.Tasks = filterTasks(transcodes.Tasks, func(t TranscodeTask) bool { return t.TaskID == filter })
+			if len(downloads.Tasks) == 0 && len(transcodes.Tasks) == 0 {
+				return fmt.Errorf("未找到任务: %s", filter)
+			}
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]interface{}{
+				"downloads":  downloads.Tasks,
+				"transcodes": transcodes.Tasks,
+			})
+		}
+
+		sort.Slice(downloads.Tasks, func(i, j int) bool { return downloads.Tasks[i].StartTime < downloads.Tasks[j].StartTime })
+		sort.Slice(transcodes.Tasks, func(i, j int) bool { return transcodes.Tasks[i].StartTime.Before(transcodes.Tasks[j].StartTime) })
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "下载任务\t状态\t进度\t速度\t名称")
+		for _, t := range downloads.Tasks {
+			fmt.Fprintf(w, "%s\t%s\t%.1f%%\t%s/s\t%s\n", t.TaskID, t.Status, t.Percentage, formatBytes(t.Speed), t.FileName)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "转码任务\t状态\t进度\t速度\t输出文件")
+		for _, t := range transcodes.Tasks {
+			fmt.Fprintf(w, "%s\t%s\t%.1f%%\t%s\t%s\n", t.TaskID, t.Status, t.Progress*100, t.Speed, t.OutputFile)
+		}
+		return w.Flush()
+	}
+}
+
+// cliTranscode 使用预设添加转码任务，文件路径是运行 SeedParser 的机器上的路径
+func cliTranscode(fs *flag.FlagSet) func(c *cliClient, args []string) error {
+	preset := fs.String("preset", "720p", "转码预设，可用预设见 GetTranscodePresets")
+	output := fs.String("output", "", "输出文件，默认与输入文件同目录")
+	return func(c *cliClient, args []string) error {
+		if len(args) == 0 {
+			fs.Usage()
+			return errors.New("缺少视频文件")
+		}
+		for _, arg := range args {
+			input, err := filepath.Abs(arg)
+			if err != nil {
+				return err
+			}
+			outputFile := *output
+			if outputFile != "" {
+				if outputFile, err = filepath.Abs(outputFile); err != nil {
+					return err
+				}
+			}
+			data, err := c.call("AddTranscodeTaskWithPreset", input, outputFile, *preset)
+			if err != nil {
+				return err
+			}
+			var result struct {
+				TaskID     string `json:"taskId"`
+				OutputFile string `json:"outputFile"`
+			}
+			json.Unmarshal(data, &result)
+			fmt.Printf("已添加转码任务 %s: %s -> %s\n", result.TaskID, input, result.OutputFile)
+		}
+		return nil
+	}
+}
+
+// cliTaskAction 对一个或多个下载任务执行暂停、继续或取消
+func cliTaskAction(method string) func(fs *flag.FlagSet) func(c *cliClient, args []string) error {
+	return func(fs *flag.FlagSet) func(c *cliClient, args []string) error {
+		return func(c *cliClient, args []string) error {
+			if len(args) == 0 {
+				fs.Usage()
+				return errors.New("缺少任务ID")
+			}
+			for _, taskID := range args {
+				data, err := c.call(method, taskID)
+				if err != nil {
+					return err
+				}
+				var result struct {
+					Message string `json:"message"`
+				}
+				json.Unmarshal(data, &result)
+				fmt.Printf("%s: %s\n", taskID, result.Message)
+			}
+			return nil
+		}
+	}
+}
+
+// cliPriority 设置下载任务的优先级
+func cliPriority(fs *flag.FlagSet) func(c *cliClient, args []string) error {
+	return func(c *cliClient, args []string) error {
+		if len(args) != 2 {
+			fs.Usage()
+			return errors.New("需要任务ID和优先级")
+		}
+		if _, err := c.call("SetDownloadPriority", args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("%s: 优先级已设置为 %s\n", args[0], args[1])
+		return nil
+	}
+}
+
+// filterTasks 返回满足条件的任务
+func filterTasks[T any](tasks []T, keep func(T) bool) []T {
+	var result []T
+	for _, t := range tasks {
+		if keep(t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// formatBytes 将字节数格式化为易读的大小
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
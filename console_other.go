@@ -0,0 +1,6 @@
+//go:build !windows
+
+package main
+
+// attachConsole 非Windows平台的程序总是可以直接输出到终端
+func attachConsole() {}
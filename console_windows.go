@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// attachParentProcess AttachConsole 的参数，表示附加到父进程的控制台
+const attachParentProcess = ^uint32(0)
+
+// attachConsole 发布版本是窗口程序，没有控制台；从命令提示符运行子命令时附加到父进程的控制台以显示输出。
+// 输出已被重定向到文件或管道时保持不变
+func attachConsole() {
+	if handle, err := windows.GetStdHandle(windows.STD_OUTPUT_HANDLE); err == nil && handle != 0 && handle != windows.InvalidHandle {
+		return
+	}
+	proc := windows.NewLazySystemDLL("kernel32.dll").NewProc("AttachConsole")
+	if r, _, _ := proc.Call(uintptr(attachParentProcess)); r == 0 {
+		return
+	}
+	if out, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+		os.Stdout = out
+		os.Stderr = out
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// appDirName 应用数据目录名
+	appDirName = "SeedParser"
+	// portableMarkerFile 可执行文件同目录下存在此文件时启用便携模式，数据保存在程序目录
+	portableMarkerFile = "portable"
+	// dataDirEnv 通过环境变量指定数据目录，优先级最高
+	dataDirEnv = "SEEDPARSER_DATA_DIR"
+	// dataDirPointerFile 默认数据目录中记录迁移后数据目录位置的文件
+	dataDirPointerFile = "datadir.txt"
+
+	downloadsDirName = "downloads"
+	transcodeDirName = "transcode"
+)
+
+// currentDataDir 当前使用的数据目录（绝对路径），启动时由 initDataDir 设置，迁移数据目录时切换；
+// 统计、插件、缩略图等在其他协程中读取，通过 dataDir 和 setDataDir 访问
+var currentDataDir atomic.Pointer[string]
+
+// dataDir 返回当前使用的数据目录
+func dataDir() string {
+	if dir := currentDataDir.Load(); dir != nil {
+		return *dir
+	}
+	return ""
+}
+
+// setDataDir 切换当前使用的数据目录
+func setDataDir(dir string) {
+	currentDataDir.Store(&dir)
+}
+
+// portableMode 是否以便携模式运行
+var portableMode bool
+
+// executableDir returns the directory containing the running executable
+// executableDir 返回可执行文件所在目录
+func executableDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Dir(exe), nil
+}
+
+// defaultDataDir 返回各平台的默认应用数据目录：
+// Windows 为 %AppData%\SeedParser，macOS 为 ~/Library/Application Support/SeedParser，
+// Linux 为 $XDG_CONFIG_HOME/SeedParser 或 ~/.config/SeedParser
+func defaultDataDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户数据目录失败: %w", err)
+	}
+	return filepath.Join(base, appDirName), nil
+}
+
+// resolveDataDir determines the data directory: environment override, portable mode, relocated dir, then the per-OS default
+// resolveDataDir 按优先级确定数据目录：环境变量 > 便携模式 > 用户迁移后的目录 > 默认目录
+func resolveDataDir() (dir string, portable bool, err error) {
+	if env := strings.TrimSpace(os.Getenv(dataDirEnv)); env != "" {
+		dir, err := filepath.Abs(env)
+		return dir, false, err
+	}
+
+	if exeDir, err := executableDir(); err == nil {
+		if _, err := os.Stat(filepath.Join(exeDir, portableMarkerFile)); err == nil {
+			return exeDir, true, nil
+		}
+	}
+
+	defaultDir, err := defaultDataDir()
+	if err != nil {
+		return "", false, err
+	}
+	if data, err := os.ReadFile(filepath.Join(defaultDir, dataDirPointerFile)); err == nil {
+		if relocated := strings.TrimSpace(string(data)); relocated != "" {
+			return relocated, false, nil
+		}
+	}
+	return defaultDir, false, nil
+}
+
+// initDataDir resolves the data directory and creates its sub directories
+// initDataDir 确定数据目录并创建下载和转码子目录
+func initDataDir() error {
+	dir, portable, err := resolveDataDir()
+	if err != nil {
+		return err
+	}
+	for _, d := range []string{dir, filepath.Join(dir, downloadsDirName), filepath.Join(dir, transcodeDirName)} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return fmt.Errorf("创建数据目录失败: %w", err)
+		}
+	}
+	setDataDir(dir)
+	portableMode = portable
+	slog.Info("数据目录", "dir", dir, "portable", portableMode)
+	return nil
+}
+
+// dataPath 返回数据目录下的路径
+func dataPath(elem ...string) string {
+	return filepath.Join(append([]string{dataDir()}, elem...)...)
+}
+
+// downloadsDir 返回下载目录，设置中指定的目录优先
+func downloadsDir() string {
+	if dir := currentSettings().DownloadDir; dir != "" {
+		return dir
+	}
+	return dataPath(downloadsDirName)
+}
+
+// transcodeDir 返回转码目录，设置中指定的目录优先
+func transcodeDir() string {
+	if dir := currentSettings().TranscodeDir; dir != "" {
+		return dir
+	}
+	return dataPath(transcodeDirName)
+}
+
+// GetDataDir returns information about the data directory
+// GetDataDir 获取数据目录信息
+func (a *App) GetDataDir() (string, error) {
+	defaultDir, err := defaultDataDir()
+	if err != nil {
+		return "", err
+	}
+
+	response := map[string]interface{}{
+		"status":       "success",
+		"dataDir":      dataDir(),
+		"defaultDir":   defaultDir,
+		"portable":     portableMode,
+		"envOverride":  os.Getenv(dataDirEnv) != "",
+		"downloadsDir": downloadsDir(),
+		"transcodeDir": transcodeDir(),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// dataDirEntries 迁移数据目录时复制的文件和目录：任务数据库（包括下载和转码历史）、设置、统计、播放进度、
+// 视频库元数据、保存的种子、插件和缩略图。日志留在原目录，下载和转码目录保持在原位置
+var dataDirEntries = []string{
+	taskDatabaseFile, taskDatabaseFile + "-wal", taskDatabaseFile + "-shm",
+	legacyDownloadProgressFile, legacyTranscodeProgressFile,
+	settingsFile, statsFile, playbackFile, metadataFile, matchesFile, trackerListFile, uploadHashesFile, localTokenFile,
+	"torrents", pluginsDirName, thumbnailsDirName,
+}
+
+// dataDirOtherEntries 数据目录中不随迁移复制的文件和目录：默认目录中的 datadir.txt、默认的下载和转码目录、日志和更新包
+var dataDirOtherEntries = []string{dataDirPointerFile, downloadsDirName, transcodeDirName, logDirName, "updates"}
+
+// checkNewDataDir 创建新的数据目录，并确认它可以写入，而且是空目录或者已有的数据目录（例如迁移前使用的目录）。
+// 返回是否为已有的数据目录：目录中只有数据目录的文件，并且包含 dataDirEntries 中的数据文件
+func checkNewDataDir(dir string) (bool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, errorf(msgCreateDataDirFailed, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, errorf(msgDataDirNotWritable, err)
+	}
+	existing := false
+	for _, entry := range entries {
+		switch name := entry.Name(); {
+		case slices.Contains(dataDirEntries, strings.TrimSuffix(name, backupSuffix)):
+			existing = true
+		case !slices.Contains(dataDirOtherEntries, name):
+			return false, errorf(msgDataDirNotEmpty, dir)
+		}
+	}
+	probe, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return false, errorf(msgDataDirNotWritable, err)
+	}
+	probe.Close()
+	return existing, os.Remove(probe.Name())
+}
+
+// copyDataEntry 将数据目录中的文件或目录复制到新的数据目录，不存在时跳过
+func copyDataEntry(oldDir, newDir, name string) error {
+	source := filepath.Join(oldDir, name)
+	if _, err := os.Lstat(source); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(oldDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(newDir, rel)
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, 0755)
+		case entry.Type().IsRegular():
+			return copyFile(path, target)
+		}
+		return nil
+	})
+}
+
+// removeDataEntries 删除目录中的数据文件和它们的 .bak 备份：迁移失败时删除已复制的文件，迁移到已有的数据目录时删除以前的数据
+func removeDataEntries(dir string) {
+	for _, name := range dataDirEntries {
+		os.RemoveAll(filepath.Join(dir, name))
+		os.Remove(filepath.Join(dir, name+backupSuffix))
+	}
+}
+
+// reopenTaskStore 在 dir 中重新打开与 store 同类型的任务存储；内存存储没有文件，直接继续使用
+func reopenTaskStore(store TaskStore, dir string) (TaskStore, error) {
+	switch store.(type) {
+	case *sqliteTaskStore:
+		return openSQLiteTaskStore(filepath.Join(dir, taskDatabaseFile))
+	case *jsonTaskStore:
+		return newJSONTaskStore(filepath.Join(dir, legacyDownloadProgressFile), filepath.Join(dir, legacyTranscodeProgressFile)), nil
+	}
+	return store, nil
+}
+
+// migrateDataDir 将数据复制到新的数据目录并切换到新目录：关闭任务存储，复制数据文件，在新目录中重新打开任务存储，
+// 最后更新 datadir.txt。新目录是已有的数据目录时，其中的数据文件是以前的副本，先删除再复制当前的数据，
+// 避免旧的数据库日志（-wal）与复制的数据库混在一起。任何一步失败时删除已复制的文件，继续使用原来的目录
+func (a *App) migrateDataDir(newDir string, existing bool, updatePointer func() error) error {
+	oldDir := dataDir()
+
+	// 下载和转码目录使用默认位置时，固定为原来的目录，已下载的文件不需要移动
+	s := currentSettings()
+	if s.DownloadDir == "" || s.TranscodeDir == "" {
+		s.DownloadDir = downloadsDir()
+		s.TranscodeDir = transcodeDir()
+		if err := saveSettings(&s); err != nil {
+			return err
+		}
+	}
+
+	move := func(store TaskStore) (TaskStore, error) {
+		fail := func(err error) (TaskStore, error) {
+			removeDataEntries(newDir)
+			reopened, reopenErr := reopenTaskStore(store, oldDir)
+			if reopenErr != nil {
+				slog.Error("重新打开任务存储失败", "error", reopenErr)
+			}
+			return reopened, errorf(msgMigrateDataDirFailed, err)
+		}
+		if existing {
+			slog.Info("用当前的数据替换目标目录中以前的数据", "dir", newDir)
+			removeDataEntries(newDir)
+		}
+		for _, name := range dataDirEntries {
+			if err := copyDataEntry(oldDir, newDir, name); err != nil {
+				return fail(err)
+			}
+		}
+		reopened, err := reopenTaskStore(store, newDir)
+		if err != nil {
+			return fail(err)
+		}
+		if err := updatePointer(); err != nil {
+			if reopened != store {
+				reopened.Close()
+			}
+			return fail(err)
+		}
+		setDataDir(newDir)
+		return reopened, nil
+	}
+
+	if a.tasks == nil {
+		_, err := move(a.store)
+		return err
+	}
+	store := a.store
+	return a.tasks.Relocate(func() (TaskStore, error) {
+		reopened, err := move(store)
+		if reopened != nil {
+			a.store = reopened
+		}
+		return reopened, err
+	})
+}
+
+// SetDataDir relocates the data directory, copying the existing data into it
+// SetDataDir 迁移数据目录：新目录必须是空目录或者已有的数据目录（例如迁移前使用的目录，其中以前的数据被当前的数据替换），
+// 任务数据库、设置等数据复制到新目录后立即使用新目录，原目录中的文件保留，确认无误后可以删除；传入空字符串迁移回默认目录。
+// 便携模式和环境变量指定的目录不能修改
+func (a *App) SetDataDir(dir string) (string, error) {
+	if portableMode {
+		return "", errorf(msgPortableDataDir)
+	}
+	if os.Getenv(dataDirEnv) != "" {
+		return "", errorf(msgEnvDataDir)
+	}
+
+	defaultDir, err := defaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(defaultDir, 0755); err != nil {
+		return "", errorf(msgCreateDefaultDirFailed, err)
+	}
+	pointerFile := filepath.Join(defaultDir, dataDirPointerFile)
+
+	newDir := defaultDir
+	updatePointer := func() error {
+		if err := os.Remove(pointerFile); err != nil && !os.IsNotExist(err) {
+			return errorf(msgRestoreDataDirFailed, err)
+		}
+		return nil
+	}
+	if dir = strings.TrimSpace(dir); dir != "" {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return "", errorf(msgInvalidDataDir, err)
+		}
+		newDir = absDir
+		updatePointer = func() error {
+			return writeFileAtomic(pointerFile, []byte(absDir), 0644)
+		}
+	}
+
+	if filepath.Clean(newDir) != filepath.Clean(dataDir()) {
+		existing, err := checkNewDataDir(newDir)
+		if err != nil {
+			return "", err
+		}
+		if err := a.migrateDataDir(newDir, existing, updatePointer); err != nil {
+			return "", err
+		}
+		slog.Info("数据目录已迁移", "dir", newDir)
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": tr(msgDataDirMigrated),
+		"dataDir": newDir,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// newDataDirTestApp 创建使用临时数据目录和任务数据库的 App，默认数据目录也在临时目录中
+func newDataDirTestApp(t *testing.T) *App {
+	t.Helper()
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("HOME", config)
+	t.Setenv("AppData", config)
+	t.Setenv(dataDirEnv, "")
+
+	setDataDir(t.TempDir())
+	previous := currentSettings()
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		settings = previous
+		settingsMu.Unlock()
+	})
+	s := defaultSettings()
+	if err := saveSettings(&s); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dataPath("torrents"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dataPath("torrents", "movie.torrent"), []byte("torrent"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := openSQLiteTaskStore(dataPath(taskDatabaseFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := NewTaskManager(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tasks.store.Close() })
+	tasks.AddDownload(DownloadTask{TaskID: "before", Status: "completed"})
+	return &App{store: store, tasks: tasks}
+}
+
+func TestSetDataDirMigratesData(t *testing.T) {
+	a := newDataDirTestApp(t)
+	oldDir := dataDir()
+	oldDownloads := downloadsDir()
+	newDir := filepath.Join(t.TempDir(), "data")
+
+	if _, err := a.SetDataDir(newDir); err != nil {
+		t.Fatalf("SetDataDir 失败: %v", err)
+	}
+	if dataDir() != newDir {
+		t.Fatalf("数据目录为 %s，应为 %s", dataDir(), newDir)
+	}
+	for _, name := range []string{taskDatabaseFile, settingsFile, filepath.Join("torrents", "movie.torrent")} {
+		if _, err := os.Stat(filepath.Join(newDir, name)); err != nil {
+			t.Errorf("%s 没有复制到新目录: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(oldDir, settingsFile)); err != nil {
+		t.Errorf("原目录中的文件应保留: %v", err)
+	}
+	if got := downloadsDir(); got != oldDownloads {
+		t.Errorf("下载目录为 %s，应保持为原来的 %s", got, oldDownloads)
+	}
+
+	defaultDir, _ := defaultDataDir()
+	pointer, err := os.ReadFile(filepath.Join(defaultDir, dataDirPointerFile))
+	if err != nil || strings.TrimSpace(string(pointer)) != newDir {
+		t.Fatalf("datadir.txt 为 %q (%v)，应为 %s", pointer, err, newDir)
+	}
+	if dir, _, err := resolveDataDir(); err != nil || dir != newDir {
+		t.Fatalf("下次启动的数据目录为 %s (%v)，应为 %s", dir, err, newDir)
+	}
+
+	// 迁移后的修改写入新目录中的数据库
+	a.tasks.AddDownload(DownloadTask{TaskID: "after", Status: "waiting"})
+	if err := a.tasks.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for _, taskID := range []string{"before", "after"} {
+		if _, found, err := a.store.GetDownload(taskID); err != nil || !found {
+			t.Errorf("新目录的数据库中没有任务 %s (%v)", taskID, err)
+		}
+	}
+}
+
+func TestSetDataDirMovesBackToPreviousDir(t *testing.T) {
+	a := newDataDirTestApp(t)
+	oldDir := dataDir()
+	if _, err := a.SetDataDir(filepath.Join(t.TempDir(), "data")); err != nil {
+		t.Fatalf("SetDataDir 失败: %v", err)
+	}
+	a.tasks.AddDownload(DownloadTask{TaskID: "after", Status: "waiting"})
+
+	// 原目录中还有迁移前的数据，迁移回去时用当前的数据替换
+	if _, err := a.SetDataDir(oldDir); err != nil {
+		t.Fatalf("迁移回原来的目录失败: %v", err)
+	}
+	if dataDir() != oldDir {
+		t.Fatalf("数据目录为 %s，应为 %s", dataDir(), oldDir)
+	}
+	if err := a.tasks.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for _, taskID := range []string{"before", "after"} {
+		if _, found, err := a.store.GetDownload(taskID); err != nil || !found {
+			t.Errorf("原目录的数据库中没有任务 %s (%v)", taskID, err)
+		}
+	}
+}
+
+func TestSetDataDirRejectsNonEmptyDir(t *testing.T) {
+	a := newDataDirTestApp(t)
+	oldDir := dataDir()
+	newDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(newDir, "other.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.SetDataDir(newDir); errorKey(err) != msgDataDirNotEmpty {
+		t.Fatalf("非空目录应被拒绝，实际为 %v", err)
+	}
+	if dataDir() != oldDir {
+		t.Fatalf("迁移失败后数据目录变为 %s", dataDir())
+	}
+	if _, err := os.Stat(filepath.Join(newDir, settingsFile)); !os.IsNotExist(err) {
+		t.Fatal("被拒绝的目录中不应复制任何文件")
+	}
+	// 仍然使用原来的数据库
+	a.tasks.AddDownload(DownloadTask{TaskID: "after", Status: "waiting"})
+	if err := a.tasks.Flush(); err != nil {
+		t.Fatalf("迁移失败后写入原数据库失败: %v", err)
+	}
+}
+
+func TestSetDataDirRejectsReadOnlyDir(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("Windows 和 root 用户不受目录权限限制")
+	}
+	a := newDataDirTestApp(t)
+	newDir := t.TempDir()
+	if err := os.Chmod(newDir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(newDir, 0755) })
+
+	if _, err := a.SetDataDir(newDir); errorKey(err) != msgDataDirNotWritable {
+		t.Fatalf("无法写入的目录应被拒绝，实际为 %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// videoFileFilter 选择视频文件时的过滤器，由 videoExtensions 生成
+func videoFileFilter() runtime.FileFilter {
+	patterns := make([]string, 0, len(videoExtensions))
+	for ext := range videoExtensions {
+		patterns = append(patterns, "*"+ext)
+	}
+	sort.Strings(patterns)
+	return runtime.FileFilter{DisplayName: "视频文件", Pattern: strings.Join(patterns, ";")}
+}
+
+// selectFile 打开系统的文件选择对话框，用户取消时返回空字符串。服务器模式下没有窗口，对话框也不会显示在远程的浏览器中
+func (a *App) selectFile(title string, filters ...runtime.FileFilter) (string, error) {
+	if a.headless || a.ctx == nil {
+		return "", errorf(msgDialogHeadless)
+	}
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   title,
+		Filters: filters,
+	})
+	if err != nil {
+		slog.Error("打开文件选择对话框失败", "error", err)
+		return "", errorf(msgOpenDialogFailed, err)
+	}
+	return path, nil
+}
+
+// cancelledResponse 用户关闭文件选择对话框时的响应
+func cancelledResponse() (string, error) {
+	jsonData, err := json.Marshal(map[string]interface{}{"status": "cancelled"})
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// SelectVideoFile opens a native dialog to pick a video file
+// SelectVideoFile 打开系统的文件选择对话框选择视频文件，返回文件路径、名称和大小，不复制文件；
+// 把 filePath 传给 StartTranscode 即可直接转码原文件，输出保存在原文件旁边。用户取消时 status 为 cancelled
+func (a *App) SelectVideoFile() (string, error) {
+	path, err := a.selectFile("选择视频文件", videoFileFilter(), runtime.FileFilter{DisplayName: "所有文件", Pattern: "*.*"})
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return cancelledResponse()
+	}
+	stat, err := os.Stat(path)
+	if err != nil || stat.IsDir() {
+		return "", errorf(msgFileNotFound, path)
+	}
+	slog.Info("已选择视频文件", "path", path, "size", stat.Size())
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":   "success",
+		"filePath": path,
+		"fileName": filepath.Base(path),
+		"size":     stat.Size(),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// readTorrentFile 从磁盘读取并解析种子文件（最大10MB），返回内容和解析结果
+func readTorrentFile(path string) ([]byte, TorrentInfoResponse, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, TorrentInfoResponse{}, errorf(msgReadFileFailed, err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, torrentURLMaxSize+1))
+	if err != nil {
+		return nil, TorrentInfoResponse{}, errorf(msgReadFileFailed, err)
+	}
+	if len(data) > torrentURLMaxSize {
+		return nil, TorrentInfoResponse{}, errorf(msgTorrentTooLarge, torrentURLMaxSize/1024/1024)
+	}
+	torrentInfo, err := parseTorrentData(data, filepath.Base(path))
+	if err != nil {
+		return nil, TorrentInfoResponse{}, errorf(msgNotTorrentFile, err)
+	}
+	return data, torrentInfo, nil
+}
+
+// SelectTorrentFile opens a native dialog to pick a torrent file and parses it
+// SelectTorrentFile 打开系统的文件选择对话框选择种子文件，直接从磁盘读取并解析，返回与 ParseTorrentFile 相同的信息；
+// content 为 Base64 编码的种子内容，可以直接传给 DownloadTorrentFiles 添加下载。用户取消时 status 为 cancelled
+func (a *App) SelectTorrentFile() (string, error) {
+	path, err := a.selectFile("选择种子文件", runtime.FileFilter{DisplayName: "种子文件 (*.torrent)", Pattern: "*.torrent"})
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return cancelledResponse()
+	}
+	data, torrentInfo, err := readTorrentFile(path)
+	if err != nil {
+		return "", err
+	}
+	slog.Info("已从磁盘解析种子", "path", path, "files", len(torrentInfo.Files))
+
+	// 构建响应
+	response := struct {
+		TorrentInfoResponse
+		Status   string `json:"status"`
+		FilePath string `json:"filePath"`
+		Content  string `json:"content"`
+	}{
+		TorrentInfoResponse: torrentInfo,
+		Status:              "success",
+		FilePath:            path,
+		Content:             base64.StdEncoding.EncodeToString(data),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// volumeInfo 磁盘（Windows 盘符）或挂载点的空间信息，平台相关的部分见 disk_*.go 和 volumes_*.go
+type volumeInfo struct {
+	// 盘符根目录（C:\）或挂载点（/、/home）
+	Path string `json:"path"`
+	// 设备文件（/dev/sda1），Windows 没有
+	Device string `json:"device,omitempty"`
+	// 文件系统类型，Windows 没有
+	FSType string `json:"fsType,omitempty"`
+	// 驱动器类型，只有 Windows 有：fixed、removable、network、cdrom、ramdisk
+	Type      string `json:"type,omitempty"`
+	Total     uint64 `json:"total"`
+	Available uint64 `json:"available"`
+	Used      uint64 `json:"used"`
+}
+
+// fillUsage 读取空间使用情况
+func (v *volumeInfo) fillUsage(path string) error {
+	total, available, err := diskUsage(path)
+	if err != nil {
+		return err
+	}
+	v.Total, v.Available, v.Used = total, available, total-available
+	return nil
+}
+
+// volumeUsage 返回路径所在磁盘的空间信息
+func volumeUsage(path string) (volumeInfo, error) {
+	root, err := volumeOf(path)
+	if err != nil {
+		return volumeInfo{}, err
+	}
+	volume := volumeInfo{Path: root}
+	return volume, volume.fillUsage(path)
+}
+
+// GetDiskSpace gets disk usage for a path and for the configured directories
+// GetDiskSpace 获取路径所在磁盘的空间信息，path 为空时使用下载目录。
+// directories 为下载目录（视频库也在其中）和转码目录所在磁盘的空间信息
+func (a *App) GetDiskSpace(path string) (string, error) {
+	if path == "" {
+		path = downloadsDir()
+	}
+	volume, err := volumeUsage(path)
+	if err != nil {
+		return "", errorf(msgDiskSpaceFailed, err)
+	}
+
+	var directories []map[string]interface{}
+	for _, dir := range []struct{ name, path string }{
+		{"download", downloadsDir()},
+		{"transcode", transcodeDir()},
+	} {
+		dirVolume, err := volumeUsage(dir.path)
+		if err != nil {
+			slog.Warn("获取目录所在磁盘的空间信息失败", "dir", dir.path, "error", err)
+			continue
+		}
+		directories = append(directories, map[string]interface{}{
+			"name":   dir.name,
+			"dir":    dir.path,
+			"volume": dirVolume,
+		})
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"path":        path,
+		"drive":       volume.Path,
+		"total":       volume.Total,
+		"available":   volume.Available,
+		"used":        volume.Used,
+		"directories": directories,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// EnumerateDrives lists all mounted volumes with their usage
+// EnumerateDrives 列出所有盘符或挂载的卷及其空间信息，读取失败的（例如没有光盘的光驱）不列出
+func (a *App) EnumerateDrives() (string, error) {
+	volumes, err := listVolumes()
+	if err != nil {
+		return "", errorf(msgDiskSpaceFailed, err)
+	}
+	drives := make([]volumeInfo, 0, len(volumes))
+	for _, volume := range volumes {
+		if err := volume.fillUsage(volume.Path); err != nil {
+			slog.Debug("获取磁盘空间信息失败", "path", volume.Path, "error", err)
+			continue
+		}
+		drives = append(drives, volume)
+	}
+
+	response := map[string]interface{}{
+		"status": "success",
+		"drives": drives,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
@@ -0,0 +1,53 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// diskUsage 返回路径所在文件系统的总空间和非特权用户可用的空间（字节）
+func diskUsage(path string) (total, available uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Blocks * uint64(stat.Bsize), stat.Bavail * uint64(stat.Bsize), nil
+}
+
+// volumeOf 返回路径所在文件系统的挂载点：向上查找直到父目录属于另一个设备
+func volumeOf(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	device, err := deviceOf(path)
+	if err != nil {
+		return "", err
+	}
+	for {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path, nil
+		}
+		if parentDevice, err := deviceOf(parent); err != nil || parentDevice != device {
+			return path, nil
+		}
+		path = parent
+	}
+}
+
+// deviceOf 返回文件所在的设备号
+func deviceOf(path string) (uint64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, os.ErrInvalid
+	}
+	return uint64(stat.Dev), nil
+}
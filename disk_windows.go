@@ -0,0 +1,70 @@
+package main
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// diskUsage 返回路径所在磁盘的总空间和当前用户可用的空间（字节）
+func diskUsage(path string) (total, available uint64, err error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &available, &total, &totalFree); err != nil {
+		return 0, 0, err
+	}
+	return total, available, nil
+}
+
+// volumeOf 返回路径所在卷的根目录，例如 C:\ 或挂载到文件夹的卷
+func volumeOf(path string) (string, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(pathPtr, &buf[0], uint32(len(buf))); err != nil {
+		return "", err
+	}
+	return windows.UTF16ToString(buf), nil
+}
+
+// driveTypes GetDriveType 的返回值，没有根目录（未使用的盘符）的不列出
+var driveTypes = map[uint32]string{
+	windows.DRIVE_REMOVABLE: "removable",
+	windows.DRIVE_FIXED:     "fixed",
+	windows.DRIVE_REMOTE:    "network",
+	windows.DRIVE_CDROM:     "cdrom",
+	windows.DRIVE_RAMDISK:   "ramdisk",
+}
+
+// listVolumes 列出所有盘符
+func listVolumes() ([]volumeInfo, error) {
+	buf := make([]uint16, 256)
+	n, err := windows.GetLogicalDriveStrings(uint32(len(buf)), &buf[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// 缓冲区中是以 \0 分隔的 A:\ B:\ ...
+	var volumes []volumeInfo
+	start := 0
+	for i := 0; i < int(n); i++ {
+		if buf[i] != 0 {
+			continue
+		}
+		root := windows.UTF16ToString(buf[start:i])
+		start = i + 1
+		rootPtr, err := windows.UTF16PtrFromString(root)
+		if err != nil {
+			continue
+		}
+		kind, ok := driveTypes[windows.GetDriveType(rootPtr)]
+		if !ok {
+			continue
+		}
+		volumes = append(volumes, volumeInfo{Path: root, Type: kind})
+	}
+	return volumes, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// diskCheckInterval 检查下载和转码目录所在磁盘剩余空间的间隔，下载和转码写入很快，间隔不能太长
+	diskCheckInterval = 5 * time.Second
+	// diskResumeMargin 剩余空间超过下限这么多之后才恢复任务，避免在下限附近反复暂停和恢复
+	diskResumeMargin = 512 << 20
+	// pausedByDiskSpace DownloadTask.PausedBy 和 TranscodeTask.PausedBy 的值，表示任务因磁盘空间不足而暂停
+	pausedByDiskSpace = "disk"
+
+	// 检查剩余空间的目录
+	diskDownload  = "download"
+	diskTranscode = "transcode"
+)
+
+// diskGuard 跟踪下载和转码目录所在磁盘的剩余空间：低于下限时暂停下载、挂起转码进程，
+// 避免 torrent 或 ffmpeg 写入到一半时因磁盘已满而失败；空间恢复后自动继续
+type diskGuard struct {
+	mu     sync.Mutex
+	low    map[string]bool
+	cancel context.CancelFunc
+}
+
+// diskSpaceLow 下载目录或转码目录所在磁盘的剩余空间是否低于下限（最近一次检查的结果）
+func (a *App) diskSpaceLow(dir string) bool {
+	a.disk.mu.Lock()
+	defer a.disk.mu.Unlock()
+	return a.disk.low[dir]
+}
+
+// checkDiskSpace 检查剩余空间，低于下限或恢复时暂停或继续任务。修改设置后也会调用
+func (a *App) checkDiskSpace() {
+	if a.tasks == nil {
+		return
+	}
+	limit := uint64(max(0, currentSettings().MinFreeSpaceMB)) << 20
+	for _, dir := range []struct{ name, path string }{
+		{diskDownload, downloadsDir()},
+		{diskTranscode, transcodeDir()},
+	} {
+		a.disk.mu.Lock()
+		wasLow := a.disk.low[dir.name]
+		a.disk.mu.Unlock()
+
+		low := false
+		var available uint64
+		if limit > 0 {
+			var err error
+			if _, available, err = diskUsage(dir.path); err != nil {
+				// 目录还不存在时没有需要保护的写入
+				slog.Debug("读取磁盘剩余空间失败", "dir", dir.path, "error", err)
+				low = wasLow
+			} else if wasLow {
+				low = available < limit+diskResumeMargin
+			} else {
+				low = available < limit
+			}
+		}
+
+		a.disk.mu.Lock()
+		if a.disk.low == nil {
+			a.disk.low = make(map[string]bool)
+		}
+		a.disk.low[dir.name] = low
+		a.disk.mu.Unlock()
+
+		switch {
+		case low && !wasLow:
+			slog.Warn("磁盘空间不足，暂停任务", "dir", dir.path, "available", available, "limit", limit)
+			message := msgNotifyDiskLowDownloads
+			if dir.name == diskTranscode {
+				message = msgNotifyDiskLowTranscodes
+			}
+			a.notify(tr(msgNotifyDiskLow), tr(message, dir.path, formatBytes(int64(available))), dir.path)
+		case !low && wasLow:
+			slog.Info("磁盘空间已恢复，继续任务", "dir", dir.path, "available", available)
+		}
+		switch {
+		case dir.name == diskTranscode:
+			if low != wasLow {
+				a.applyTranscodePause()
+			}
+		case low:
+			// 用户手动恢复的下载也会再次暂停
+			a.pauseDownloadsForDiskSpace()
+		default:
+			a.resumeDownloadsForDiskSpace()
+		}
+	}
+}
+
+// pauseDownloadsForDiskSpace 暂停正在进行的下载，等待中的任务在空间恢复前不会启动
+func (a *App) pauseDownloadsForDiskSpace() {
+	for _, t := range a.tasks.Downloads() {
+		if t.Status != "downloading" {
+			continue
+		}
+		if err := a.pauseDownload(t.TaskID, pausedByDiskSpace); err != nil {
+			slog.Warn("暂停下载任务失败", "taskId", t.TaskID, "error", err)
+		}
+	}
+}
+
+// resumeDownloadsForDiskSpace 将因磁盘空间不足而暂停的下载（包括上次运行时暂停的）重新加入等待队列
+func (a *App) resumeDownloadsForDiskSpace() {
+	resumed := 0
+	for _, t := range a.tasks.Downloads() {
+		if t.Status != "paused" || t.PausedBy != pausedByDiskSpace {
+			continue
+		}
+		task, found := a.tasks.UpdateDownload(t.TaskID, func(t *DownloadTask) {
+			if t.Status == "paused" && t.PausedBy == pausedByDiskSpace {
+				t.Status = "waiting"
+				t.PausedBy = ""
+			}
+		})
+		if found {
+			a.emitDownloadProgress(task, true)
+			resumed++
+		}
+	}
+	if resumed > 0 {
+		a.startNextWaitingTask()
+	}
+}
+
+// startDiskGuard 定期检查剩余空间
+func (a *App) startDiskGuard() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.disk.cancel = cancel
+	a.checkDiskSpace()
+	go func() {
+		ticker := time.NewTicker(diskCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.checkDiskSpace()
+			}
+		}
+	}()
+}
+
+// stopDiskGuard 停止检查剩余空间，挂起的转码进程由 stopPowerMonitor 恢复
+func (a *App) stopDiskGuard() {
+	if a.disk.cancel != nil {
+		a.disk.cancel()
+	}
+}
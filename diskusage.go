@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DirectoryUsage is the space used by a directory
+// DirectoryUsage 目录占用的空间
+type DirectoryUsage struct {
+	// 目录：download, transcode, thumbnails, logs
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	Files int    `json:"files"`
+	// 统计失败的原因，目录不存在时为空且大小为0
+	Error string `json:"error,omitempty"`
+}
+
+// DownloadUsage is the space used by a top-level entry in the download directory
+// DownloadUsage 下载目录中一个文件或文件夹（通常是一个种子的内容）占用的空间
+type DownloadUsage struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	IsDir   bool   `json:"isDir"`
+	Size    int64  `json:"size"`
+	Files   int    `json:"files"`
+	ModTime string `json:"modTime"`
+	// 下载该内容的任务，没有对应任务（任务已删除或手动放入的文件）时为空
+	TaskID     string `json:"taskId,omitempty"`
+	TaskStatus string `json:"taskStatus,omitempty"`
+}
+
+// directorySize 统计目录（或文件）中所有文件的大小和数量，不跟随符号链接；无法读取的子目录跳过
+func directorySize(path string) (int64, int, error) {
+	var size int64
+	var files int
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == path {
+				return err
+			}
+			slog.Warn("统计目录大小时跳过无法读取的路径", "path", p, "error", err)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+				files++
+			}
+		}
+		return nil
+	})
+	return size, files, err
+}
+
+// downloadTaskFor 返回下载内容对应的任务，有多个任务时优先返回未结束的任务
+func (a *App) downloadTaskFor(path string) (DownloadTask, bool) {
+	var found DownloadTask
+	ok := false
+	for _, task := range a.tasks.Downloads() {
+		if task.FileName == "" || absPath(filepath.Join(task.OutputDir, task.FileName)) != path {
+			continue
+		}
+		if !ok || !downloadFinished(task.Status) {
+			found, ok = task, true
+		}
+	}
+	return found, ok
+}
+
+// GetDiskUsage reports the space used by the download, transcode, thumbnail and log directories
+// GetDiskUsage 统计下载目录、转码目录、缩略图和日志占用的空间，以及下载目录中每个文件或文件夹（每个种子的内容）的大小，
+// 按大小从大到小排列，标出对应的下载任务。需要遍历所有文件，文件很多时较慢
+func (a *App) GetDiskUsage() (string, error) {
+	started := time.Now()
+	directories := []DirectoryUsage{}
+	for _, dir := range []struct{ name, path string }{
+		{"download", downloadsDir()},
+		{"transcode", transcodeDir()},
+		{"thumbnails", thumbnailsDir()},
+		{"logs", dataPath(logDirName)},
+	} {
+		usage := DirectoryUsage{Name: dir.name, Path: dir.path}
+		size, files, err := directorySize(dir.path)
+		if err != nil && !os.IsNotExist(err) {
+			usage.Error = err.Error()
+		}
+		usage.Size, usage.Files = size, files
+		directories = append(directories, usage)
+	}
+
+	downloads := []DownloadUsage{}
+	entries, err := os.ReadDir(downloadsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return "", errorf(msgDiskSpaceFailed, err)
+	}
+	for _, entry := range entries {
+		path := absPath(filepath.Join(downloadsDir(), entry.Name()))
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		usage := DownloadUsage{
+			Name:    entry.Name(),
+			Path:    path,
+			IsDir:   entry.IsDir(),
+			ModTime: info.ModTime().Format(time.RFC3339),
+		}
+		usage.Size, usage.Files, _ = directorySize(path)
+		if task, ok := a.downloadTaskFor(path); ok {
+			usage.TaskID, usage.TaskStatus = task.TaskID, task.Status
+		}
+		downloads = append(downloads, usage)
+	}
+	sort.SliceStable(downloads, func(i, j int) bool {
+		return downloads[i].Size > downloads[j].Size
+	})
+	slog.Debug("统计磁盘占用完成", "downloads", len(downloads), "elapsed", time.Since(started).Round(time.Millisecond))
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":      "success",
+		"directories": directories,
+		"downloads":   downloads,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// DeleteDownloadEntry deletes a file or folder in the download directory
+// DeleteDownloadEntry 删除下载目录中的一个文件或文件夹（GetDiskUsage 返回的 downloads 中的 name），
+// 默认移到系统回收站，permanent 为 true 时直接删除。正在下载的内容不能删除；对应的下载任务会记录内容已删除
+func (a *App) DeleteDownloadEntry(name string, permanent bool) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", errorf(msgFileNotFound, name)
+	}
+	path := absPath(filepath.Join(downloadsDir(), name))
+	if _, err := os.Lstat(path); err != nil {
+		return "", errorf(msgFileNotFound, name)
+	}
+	if err := a.checkFileNotInUse(path); err != nil {
+		return "", err
+	}
+	var err error
+	if permanent {
+		err = os.RemoveAll(path)
+	} else {
+		err = moveToTrash(path)
+	}
+	if err != nil {
+		slog.Error("删除下载内容失败", "path", path, "permanent", permanent, "error", err)
+		return "", errorf(msgDeleteFileFailed, err)
+	}
+	slog.Info("已删除下载内容", "path", path, "permanent", permanent)
+	tasks := a.markFileDeleted(path)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":    "success",
+		"path":      path,
+		"permanent": permanent,
+		"tasks":     tasks,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// ClearThumbnailCache deletes all generated thumbnails
+// ClearThumbnailCache 删除所有已生成的缩略图，返回释放的空间；之后获取视频库时在后台重新生成
+func (a *App) ClearThumbnailCache() (string, error) {
+	size, files, err := directorySize(thumbnailsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return "", errorf(msgDeleteFileFailed, err)
+	}
+	if err := os.RemoveAll(thumbnailsDir()); err != nil {
+		return "", errorf(msgDeleteFileFailed, err)
+	}
+	slog.Info("已清除缩略图缓存", "files", files, "size", size)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"files":  files,
+		"freed":  size,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
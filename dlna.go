@@ -0,0 +1,651 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// dlnaDefaultPort DLNA 服务的默认端口，与 API 服务分开，不需要登录
+	dlnaDefaultPort = 8687
+	// dlnaLibraryCacheTTL 浏览时复用视频库扫描结果的时间，电视翻页时会连续请求同一目录
+	dlnaLibraryCacheTTL = 15 * time.Second
+
+	dlnaDeviceType        = "urn:schemas-upnp-org:device:MediaServer:1"
+	dlnaContentDirectory  = "urn:schemas-upnp-org:service:ContentDirectory:1"
+	dlnaConnectionManager = "urn:schemas-upnp-org:service:ConnectionManager:1"
+
+	// DLNA 服务的地址
+	dlnaDescriptionPath = "/dlna/device.xml"
+	dlnaCDSControlPath  = "/dlna/control/ContentDirectory"
+	dlnaCMSControlPath  = "/dlna/control/ConnectionManager"
+	dlnaEventPrefix     = "/dlna/event/"
+)
+
+// dlnaStreamFlags 视频资源的 DLNA 标志：支持按字节跳转，流式传输
+const dlnaStreamFlags = "DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01700000000000000000000000000000"
+
+// dlnaServer 局域网内的 DLNA 媒体服务器：SSDP 宣告和发现、设备描述、ContentDirectory 浏览，以及不需要登录的视频播放地址
+type dlnaServer struct {
+	mu     sync.Mutex
+	server *http.Server
+	cancel context.CancelFunc
+	port   int
+	name   string
+	uuid   string
+	// updateID ContentDirectory 的 SystemUpdateID，每次启动和视频库文件夹有变化时改变
+	updateID atomic.Uint32
+
+	cacheMu  sync.Mutex
+	cached   []LibraryVideo
+	folders  []LibraryFolderStatus
+	cachedAt time.Time
+}
+
+func newDLNAServer() *dlnaServer {
+	return &dlnaServer{}
+}
+
+// dlnaUUID 返回设备的 UUID，由计算机名和数据目录计算，重启后不变，电视不会把它当成新设备
+func dlnaUUID() string {
+	host, _ := os.Hostname()
+	sum := sha1.Sum([]byte("SeedParser DLNA " + host + " " + dataPath()))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// dlnaFriendlyName 返回在电视上显示的服务器名称
+func dlnaFriendlyName(s Settings) string {
+	if name := strings.TrimSpace(s.DLNAName); name != "" {
+		return name
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "SeedParser"
+	}
+	return "SeedParser (" + host + ")"
+}
+
+// applyDLNA 按设置启动、重启或停止 DLNA 服务
+func (a *App) applyDLNA() error {
+	s := currentSettings()
+	d := a.dlna
+	name := dlnaFriendlyName(s)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.server != nil {
+		if s.DLNAEnabled && d.port == s.DLNAPort && d.name == name {
+			return nil
+		}
+		d.stopLocked()
+	}
+	if !s.DLNAEnabled {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("0.0.0.0", strconv.Itoa(s.DLNAPort)))
+	if err != nil {
+		return err
+	}
+	d.port, d.name, d.uuid = s.DLNAPort, name, dlnaUUID()
+	d.updateID.Store(uint32(time.Now().Unix()))
+	d.server = &http.Server{
+		Handler:           d.handler(a),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	server := d.server
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("DLNA服务异常退出", "error", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go runSSDP(ctx, d.uuid, d.port)
+	slog.Info("DLNA服务已启动", "name", name, "port", d.port, "uuid", d.uuid)
+	return nil
+}
+
+// stopDLNA 停止 DLNA 服务
+func (a *App) stopDLNA() {
+	a.dlna.mu.Lock()
+	defer a.dlna.mu.Unlock()
+	a.dlna.stopLocked()
+}
+
+func (d *dlnaServer) stopLocked() {
+	if d.server == nil {
+		return
+	}
+	d.cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.server.Shutdown(ctx); err != nil {
+		slog.Error("关闭DLNA服务失败", "error", err)
+	}
+	d.server = nil
+	slog.Info("DLNA服务已关闭")
+}
+
+// lanClient 判断请求是否来自局域网，DLNA 服务不需要登录，只允许局域网内的设备访问
+func lanClient(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast())
+}
+
+// handler 创建 DLNA 服务的处理器
+func (d *dlnaServer) handler(a *App) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(dlnaDescriptionPath, d.serveDescription)
+	mux.HandleFunc("/dlna/ContentDirectory.xml", serveSCPD(contentDirectorySCPD))
+	mux.HandleFunc("/dlna/ConnectionManager.xml", serveSCPD(connectionManagerSCPD))
+	mux.HandleFunc(dlnaCDSControlPath, func(w http.ResponseWriter, r *http.Request) {
+		d.serveContentDirectory(w, r, a)
+	})
+	mux.HandleFunc(dlnaCMSControlPath, serveConnectionManager)
+	mux.HandleFunc(dlnaEventPrefix, serveEventSubscription)
+	mux.HandleFunc(streamURLPrefix, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("transferMode.dlna.org", "Streaming")
+		w.Header().Set("contentFeatures.dlna.org", dlnaStreamFlags)
+		serveStream(w, r)
+	})
+	mux.HandleFunc(thumbnailURLPrefix, serveThumbnail)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !lanClient(r) {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Server", dlnaServerHeader())
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// dlnaServerHeader 返回 HTTP 和 SSDP 的 Server 头
+func dlnaServerHeader() string {
+	return fmt.Sprintf("%s/1.0 UPnP/1.0 DLNADOC/1.50 SeedParser/%s", runtime.GOOS, appVersion)
+}
+
+// serveDescription 提供设备描述
+func (d *dlnaServer) serveDescription(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	name, uuid := d.name, d.uuid
+	d.mu.Unlock()
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	fmt.Fprintf(w, deviceDescription, xmlEscape(name), appVersion, uuid, dlnaDeviceType,
+		dlnaContentDirectory, dlnaCDSControlPath, dlnaEventPrefix+"ContentDirectory",
+		dlnaConnectionManager, dlnaCMSControlPath, dlnaEventPrefix+"ConnectionManager")
+}
+
+// serveSCPD 提供服务描述
+func serveSCPD(scpd string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+		w.Write([]byte(scpd))
+	}
+}
+
+// serveEventSubscription 响应事件订阅。内容变化不会推送事件，但部分电视在订阅失败时拒绝使用服务器
+func serveEventSubscription(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "SUBSCRIBE":
+		sid := r.Header.Get("SID")
+		if sid == "" {
+			token, err := randomToken(16)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			sid = "uuid:" + token
+		}
+		w.Header().Set("SID", sid)
+		w.Header().Set("TIMEOUT", "Second-1800")
+		w.WriteHeader(http.StatusOK)
+	case "UNSUBSCRIBE":
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// soapAction SOAP 请求中的操作名和参数
+type soapAction struct {
+	Body struct {
+		Action struct {
+			XMLName xml.Name
+			Args    []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+// parseSOAP 解析 SOAP 请求，返回操作名和参数
+func parseSOAP(r *http.Request) (string, map[string]string, error) {
+	var envelope soapAction
+	if err := xml.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&envelope); err != nil {
+		return "", nil, err
+	}
+	args := make(map[string]string)
+	for _, arg := range envelope.Body.Action.Args {
+		args[arg.XMLName.Local] = arg.Value
+	}
+	return envelope.Body.Action.XMLName.Local, args, nil
+}
+
+// writeSOAP 输出 SOAP 响应，values 为成对的参数名和值
+func writeSOAP(w http.ResponseWriter, service, action string, values ...string) {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(&b, `<u:%sResponse xmlns:u="%s">`, action, service)
+	for i := 0; i+1 < len(values); i += 2 {
+		fmt.Fprintf(&b, "<%s>%s</%s>", values[i], xmlEscape(values[i+1]), values[i])
+	}
+	fmt.Fprintf(&b, `</u:%sResponse></s:Body></s:Envelope>`, action)
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	w.Header().Set("EXT", "")
+	w.Write([]byte(b.String()))
+}
+
+// writeSOAPFault 输出 UPnP 错误，例如 701 对象不存在
+func writeSOAPFault(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>`+
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`+
+		`<s:Fault><faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>`+
+		`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>%d</errorCode><errorDescription>%s</errorDescription></UPnPError>`+
+		`</detail></s:Fault></s:Body></s:Envelope>`, code, xmlEscape(description))
+}
+
+// xmlEscape 转义 XML 文本
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// serveConnectionManager 处理 ConnectionManager 服务的请求
+func serveConnectionManager(w http.ResponseWriter, r *http.Request) {
+	action, _, err := parseSOAP(r)
+	if err != nil {
+		writeSOAPFault(w, 401, "Invalid Action")
+		return
+	}
+	switch action {
+	case "GetProtocolInfo":
+		protocols := make([]string, 0, len(videoContentTypes))
+		for _, contentType := range videoContentTypes {
+			protocols = append(protocols, "http-get:*:"+contentType+":*")
+		}
+		sort.Strings(protocols)
+		writeSOAP(w, dlnaConnectionManager, action, "Source", strings.Join(protocols, ","), "Sink", "")
+	case "GetCurrentConnectionIDs":
+		writeSOAP(w, dlnaConnectionManager, action, "ConnectionIDs", "0")
+	case "GetCurrentConnectionInfo":
+		writeSOAP(w, dlnaConnectionManager, action, "RcsID", "-1", "AVTransportID", "-1", "ProtocolInfo", "",
+			"PeerConnectionManager", "", "PeerConnectionID", "-1", "Direction", "Output", "Status", "OK")
+	default:
+		writeSOAPFault(w, 401, "Invalid Action")
+	}
+}
+
+// contentChanged 视频库文件夹有变化时调用：改变 SystemUpdateID 并丢弃缓存的视频库，电视下次浏览时看到新内容
+func (d *dlnaServer) contentChanged() {
+	d.updateID.Add(1)
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+	d.cached = nil
+}
+
+// library 返回视频库，短时间内复用上次的结果
+func (d *dlnaServer) library(a *App) ([]LibraryVideo, []LibraryFolderStatus, error) {
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+	if d.cached != nil && time.Since(d.cachedAt) < dlnaLibraryCacheTTL {
+		return d.cached, d.folders, nil
+	}
+	videos, folders, err := a.libraryVideos()
+	if err != nil {
+		return nil, nil, err
+	}
+	d.cached, d.folders, d.cachedAt = videos, folders, time.Now()
+	return videos, folders, nil
+}
+
+// DLNA 对象ID：根目录为0，目录为 c<根目录和相对路径的编码>，视频为 v<播放ID>
+func dlnaContainerID(root, dir string) string {
+	return "c" + streamID(root, dir)
+}
+
+func dlnaItemID(root, relPath string) string {
+	return "v" + streamID(root, relPath)
+}
+
+// didlObject 浏览结果中的一个目录或视频
+type didlObject struct {
+	id       string
+	parentID string
+	title    string
+	// 目录的子项数量，视频为-1
+	childCount int
+	video      *LibraryVideo
+}
+
+// dlnaTree 由视频库生成的目录结构
+type dlnaTree struct {
+	objects  map[string]didlObject
+	children map[string][]string
+}
+
+// buildDLNATree 按根目录和子目录组织视频库：根目录下是下载目录、转码目录和视频库文件夹，其下按实际目录结构排列
+func buildDLNATree(videos []LibraryVideo, folders []LibraryFolderStatus) dlnaTree {
+	tree := dlnaTree{
+		objects:  map[string]didlObject{"0": {id: "0", parentID: "-1", title: "SeedParser"}},
+		children: make(map[string][]string),
+	}
+	add := func(obj didlObject) {
+		if _, ok := tree.objects[obj.id]; ok {
+			return
+		}
+		tree.objects[obj.id] = obj
+		tree.children[obj.parentID] = append(tree.children[obj.parentID], obj.id)
+	}
+
+	roots := []struct{ name, title string }{
+		{libraryRootDownload, tr(msgDLNADownloads)},
+		{libraryRootTranscode, tr(msgDLNATranscodes)},
+	}
+	for _, folder := range folders {
+		if folder.Enabled && folder.Online {
+			roots = append(roots, struct{ name, title string }{folder.ID, folder.Name})
+		}
+	}
+	for _, root := range roots {
+		add(didlObject{id: dlnaContainerID(root.name, ""), parentID: "0", title: root.title})
+	}
+
+	sorted := append([]LibraryVideo(nil), videos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].RelPath) < strings.ToLower(sorted[j].RelPath)
+	})
+	for i := range sorted {
+		video := &sorted[i]
+		parent := dlnaContainerID(video.Root, "")
+		if _, ok := tree.objects[parent]; !ok {
+			continue
+		}
+		dir := path.Dir(video.RelPath)
+		if dir != "." {
+			// 逐级添加目录
+			parts := strings.Split(dir, "/")
+			for j := range parts {
+				id := dlnaContainerID(video.Root, strings.Join(parts[:j+1], "/"))
+				add(didlObject{id: id, parentID: parent, title: parts[j]})
+				parent = id
+			}
+		}
+		add(didlObject{
+			id:         dlnaItemID(video.Root, video.RelPath),
+			parentID:   parent,
+			title:      strings.TrimSuffix(video.Name, filepath.Ext(video.Name)),
+			childCount: -1,
+			video:      video,
+		})
+	}
+	for id, obj := range tree.objects {
+		if obj.childCount >= 0 {
+			obj.childCount = len(tree.children[id])
+			tree.objects[id] = obj
+		}
+	}
+	return tree
+}
+
+// formatDIDLDuration 时长格式化为 H:MM:SS.mmm
+func formatDIDLDuration(seconds float64) string {
+	ms := int64(seconds * 1000)
+	return fmt.Sprintf("%d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// writeDIDL 输出对象的 DIDL-Lite 描述，地址使用客户端访问服务器时的主机名
+func writeDIDL(b *strings.Builder, obj didlObject, baseURL string) {
+	if obj.video == nil {
+		fmt.Fprintf(b, `<container id="%s" parentID="%s" restricted="1" childCount="%d"><dc:title>%s</dc:title>`+
+			`<upnp:class>object.container.storageFolder</upnp:class></container>`,
+			xmlEscape(obj.id), xmlEscape(obj.parentID), obj.childCount, xmlEscape(obj.title))
+		return
+	}
+	video := obj.video
+	contentType, ok := videoContentTypes["."+video.Extension]
+	if !ok {
+		contentType = "video/mpeg"
+	}
+	fmt.Fprintf(b, `<item id="%s" parentID="%s" restricted="1"><dc:title>%s</dc:title><upnp:class>object.item.videoItem</upnp:class>`,
+		xmlEscape(obj.id), xmlEscape(obj.parentID), xmlEscape(obj.title))
+	if video.Thumbnail != "" {
+		fmt.Fprintf(b, `<upnp:albumArtURI dlna:profileID="JPEG_TN">%s</upnp:albumArtURI>`, xmlEscape(baseURL+video.Thumbnail))
+	}
+	fmt.Fprintf(b, `<res protocolInfo="http-get:*:%s:%s" size="%d"`, contentType, dlnaStreamFlags, video.Size)
+	if m := video.Metadata; m != nil {
+		if m.Duration > 0 {
+			fmt.Fprintf(b, ` duration="%s"`, formatDIDLDuration(m.Duration))
+		}
+		if m.Width > 0 && m.Height > 0 {
+			fmt.Fprintf(b, ` resolution="%dx%d"`, m.Width, m.Height)
+		}
+		if m.Bitrate > 0 {
+			fmt.Fprintf(b, ` bitrate="%d"`, m.Bitrate/8)
+		}
+	}
+	fmt.Fprintf(b, `>%s</res></item>`, xmlEscape(baseURL+video.Stream))
+}
+
+// serveContentDirectory 处理 ContentDirectory 服务的请求：浏览目录和读取对象信息
+func (d *dlnaServer) serveContentDirectory(w http.ResponseWriter, r *http.Request, a *App) {
+	action, args, err := parseSOAP(r)
+	if err != nil {
+		writeSOAPFault(w, 401, "Invalid Action")
+		return
+	}
+	switch action {
+	case "GetSearchCapabilities":
+		writeSOAP(w, dlnaContentDirectory, action, "SearchCaps", "")
+		return
+	case "GetSortCapabilities":
+		writeSOAP(w, dlnaContentDirectory, action, "SortCaps", "")
+		return
+	case "GetSystemUpdateID":
+		writeSOAP(w, dlnaContentDirectory, action, "Id", strconv.FormatUint(uint64(d.updateID.Load()), 10))
+		return
+	case "Browse":
+	default:
+		writeSOAPFault(w, 401, "Invalid Action")
+		return
+	}
+
+	videos, folders, err := d.library(a)
+	if err != nil {
+		slog.Warn("DLNA浏览视频库失败", "error", err)
+		writeSOAPFault(w, 501, "Action Failed")
+		return
+	}
+	tree := buildDLNATree(videos, folders)
+	obj, ok := tree.objects[args["ObjectID"]]
+	if !ok {
+		writeSOAPFault(w, 701, "No such object")
+		return
+	}
+
+	var ids []string
+	total := 1
+	switch args["BrowseFlag"] {
+	case "BrowseMetadata":
+		ids = []string{obj.id}
+	case "BrowseDirectChildren":
+		ids = tree.children[obj.id]
+		total = len(ids)
+		start, _ := strconv.Atoi(args["StartingIndex"])
+		count, _ := strconv.Atoi(args["RequestedCount"])
+		if start < 0 || start > len(ids) {
+			start = len(ids)
+		}
+		ids = ids[start:]
+		if count > 0 && count < len(ids) {
+			ids = ids[:count]
+		}
+	default:
+		writeSOAPFault(w, 402, "Invalid Args")
+		return
+	}
+
+	baseURL := "http://" + r.Host
+	var b strings.Builder
+	b.WriteString(`<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" ` +
+		`xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/" xmlns:dlna="urn:schemas-dlna-org:metadata-1-0/">`)
+	for _, id := range ids {
+		writeDIDL(&b, tree.objects[id], baseURL)
+	}
+	b.WriteString(`</DIDL-Lite>`)
+	writeSOAP(w, dlnaContentDirectory, action, "Result", b.String(), "NumberReturned", strconv.Itoa(len(ids)),
+		"TotalMatches", strconv.Itoa(total), "UpdateID", strconv.FormatUint(uint64(d.updateID.Load()), 10))
+}
+
+// deviceDescription 设备描述模板
+const deviceDescription = `<?xml version="1.0" encoding="utf-8"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0" xmlns:dlna="urn:schemas-dlna-org:device-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <device>
+    <dlna:X_DLNADOC>DMS-1.50</dlna:X_DLNADOC>
+    <friendlyName>%s</friendlyName>
+    <manufacturer>SeedParser</manufacturer>
+    <modelName>SeedParser</modelName>
+    <modelNumber>%s</modelNumber>
+    <UDN>uuid:%s</UDN>
+    <deviceType>%s</deviceType>
+    <serviceList>
+      <service>
+        <serviceType>%s</serviceType>
+        <serviceId>urn:upnp-org:serviceId:ContentDirectory</serviceId>
+        <SCPDURL>/dlna/ContentDirectory.xml</SCPDURL>
+        <controlURL>%s</controlURL>
+        <eventSubURL>%s</eventSubURL>
+      </service>
+      <service>
+        <serviceType>%s</serviceType>
+        <serviceId>urn:upnp-org:serviceId:ConnectionManager</serviceId>
+        <SCPDURL>/dlna/ConnectionManager.xml</SCPDURL>
+        <controlURL>%s</controlURL>
+        <eventSubURL>%s</eventSubURL>
+      </service>
+    </serviceList>
+  </device>
+</root>`
+
+// contentDirectorySCPD ContentDirectory 服务描述
+const contentDirectorySCPD = `<?xml version="1.0" encoding="utf-8"?>
+<scpd xmlns="urn:schemas-upnp-org:service-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <actionList>
+    <action><name>Browse</name><argumentList>
+      <argument><name>ObjectID</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_ObjectID</relatedStateVariable></argument>
+      <argument><name>BrowseFlag</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_BrowseFlag</relatedStateVariable></argument>
+      <argument><name>Filter</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_Filter</relatedStateVariable></argument>
+      <argument><name>StartingIndex</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_Index</relatedStateVariable></argument>
+      <argument><name>RequestedCount</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_Count</relatedStateVariable></argument>
+      <argument><name>SortCriteria</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_SortCriteria</relatedStateVariable></argument>
+      <argument><name>Result</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_Result</relatedStateVariable></argument>
+      <argument><name>NumberReturned</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_Count</relatedStateVariable></argument>
+      <argument><name>TotalMatches</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_Count</relatedStateVariable></argument>
+      <argument><name>UpdateID</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_UpdateID</relatedStateVariable></argument>
+    </argumentList></action>
+    <action><name>GetSearchCapabilities</name><argumentList>
+      <argument><name>SearchCaps</name><direction>out</direction><relatedStateVariable>SearchCapabilities</relatedStateVariable></argument>
+    </argumentList></action>
+    <action><name>GetSortCapabilities</name><argumentList>
+      <argument><name>SortCaps</name><direction>out</direction><relatedStateVariable>SortCapabilities</relatedStateVariable></argument>
+    </argumentList></action>
+    <action><name>GetSystemUpdateID</name><argumentList>
+      <argument><name>Id</name><direction>out</direction><relatedStateVariable>SystemUpdateID</relatedStateVariable></argument>
+    </argumentList></action>
+  </actionList>
+  <serviceStateTable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_ObjectID</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_BrowseFlag</name><dataType>string</dataType>
+      <allowedValueList><allowedValue>BrowseMetadata</allowedValue><allowedValue>BrowseDirectChildren</allowedValue></allowedValueList></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Filter</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Index</name><dataType>ui4</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Count</name><dataType>ui4</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_SortCriteria</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Result</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_UpdateID</name><dataType>ui4</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>SearchCapabilities</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>SortCapabilities</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="yes"><name>SystemUpdateID</name><dataType>ui4</dataType></stateVariable>
+  </serviceStateTable>
+</scpd>`
+
+// connectionManagerSCPD ConnectionManager 服务描述
+const connectionManagerSCPD = `<?xml version="1.0" encoding="utf-8"?>
+<scpd xmlns="urn:schemas-upnp-org:service-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <actionList>
+    <action><name>GetProtocolInfo</name><argumentList>
+      <argument><name>Source</name><direction>out</direction><relatedStateVariable>SourceProtocolInfo</relatedStateVariable></argument>
+      <argument><name>Sink</name><direction>out</direction><relatedStateVariable>SinkProtocolInfo</relatedStateVariable></argument>
+    </argumentList></action>
+    <action><name>GetCurrentConnectionIDs</name><argumentList>
+      <argument><name>ConnectionIDs</name><direction>out</direction><relatedStateVariable>CurrentConnectionIDs</relatedStateVariable></argument>
+    </argumentList></action>
+    <action><name>GetCurrentConnectionInfo</name><argumentList>
+      <argument><name>ConnectionID</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_ConnectionID</relatedStateVariable></argument>
+      <argument><name>RcsID</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_RcsID</relatedStateVariable></argument>
+      <argument><name>AVTransportID</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_AVTransportID</relatedStateVariable></argument>
+      <argument><name>ProtocolInfo</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_ProtocolInfo</relatedStateVariable></argument>
+      <argument><name>PeerConnectionManager</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_ConnectionManager</relatedStateVariable></argument>
+      <argument><name>PeerConnectionID</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_ConnectionID</relatedStateVariable></argument>
+      <argument><name>Direction</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_Direction</relatedStateVariable></argument>
+      <argument><name>Status</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_ConnectionStatus</relatedStateVariable></argument>
+    </argumentList></action>
+  </actionList>
+  <serviceStateTable>
+    <stateVariable sendEvents="yes"><name>SourceProtocolInfo</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="yes"><name>SinkProtocolInfo</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="yes"><name>CurrentConnectionIDs</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_ConnectionStatus</name><dataType>string</dataType>
+      <allowedValueList><allowedValue>OK</allowedValue><allowedValue>ContentFormatMismatch</allowedValue><allowedValue>InsufficientBandwidth</allowedValue><allowedValue>UnreliableChannel</allowedValue><allowedValue>Unknown</allowedValue></allowedValueList></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_ConnectionManager</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Direction</name><dataType>string</dataType>
+      <allowedValueList><allowedValue>Input</allowedValue><allowedValue>Output</allowedValue></allowedValueList></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_ProtocolInfo</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_ConnectionID</name><dataType>i4</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_AVTransportID</name><dataType>i4</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_RcsID</name><dataType>i4</dataType></stateVariable>
+  </serviceStateTable>
+</scpd>`
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	_ "image/jpeg"
+	"io"
+	"log/slog"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"time"
+)
+
+// 重复视频分组的类型
+const (
+	// duplicateExact 内容相同的文件（大小和哈希相同）
+	duplicateExact = "exact"
+	// duplicateSimilar 画面和时长相近的视频，例如同一内容的不同编码版本
+	duplicateSimilar = "similar"
+)
+
+const (
+	// similarDurationTolerance 相似视频的时长最多相差的秒数，时长较长时按 similarDurationRatio 放宽
+	similarDurationTolerance = 2.0
+	similarDurationRatio     = 0.01
+	// similarHashDistance 相似视频的缩略图差异哈希（64位）最多不同的位数
+	similarHashDistance = 10
+)
+
+// DuplicateFile is a library video in a duplicate group
+// DuplicateFile 重复分组中的一个视频
+type DuplicateFile struct {
+	LibraryVideo
+	// 与分组中前面的某个文件是同一个文件的硬链接，删除它不会释放空间
+	Linked bool `json:"linked,omitempty"`
+}
+
+// DuplicateGroup is a group of duplicate library videos
+// DuplicateGroup 一组重复的视频：内容相同的文件按修改时间从早到晚排列，相似的视频按文件大小从大到小排列
+type DuplicateGroup struct {
+	// 类型：exact（内容相同）或 similar（画面和时长相近）
+	Kind string `json:"kind"`
+	// 内容相同的文件的哈希，校验整个文件时为完整文件的 SHA-256
+	Hash  string          `json:"hash,omitempty"`
+	Files []DuplicateFile `json:"files"`
+	// 只保留一个文件时可以释放的空间（字节）：内容相同时保留一份，相似时保留最大的文件
+	Reclaimable int64 `json:"reclaimable"`
+}
+
+// fullFileHash 计算整个文件的 SHA-256，用于确认快速哈希相同的文件内容完全一致
+func fullFileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// groupByHash 将视频按哈希分组，计算失败的视频跳过，只返回有多个视频的分组
+func groupByHash(videos []LibraryVideo, hash func(string) (string, error)) map[string][]LibraryVideo {
+	groups := make(map[string][]LibraryVideo)
+	for _, video := range videos {
+		sum, err := hash(video.Path)
+		if err != nil {
+			slog.Warn("计算文件哈希失败", "path", video.Path, "error", err)
+			continue
+		}
+		groups[sum] = append(groups[sum], video)
+	}
+	for sum, group := range groups {
+		if len(group) < 2 {
+			delete(groups, sum)
+		}
+	}
+	return groups
+}
+
+// exactDuplicateGroup 生成内容相同的视频分组，标记硬链接；所有文件都是同一个文件的硬链接时返回 false
+func exactDuplicateGroup(hash string, videos []LibraryVideo) (DuplicateGroup, bool) {
+	sort.SliceStable(videos, func(i, j int) bool {
+		return videos[i].ModTime < videos[j].ModTime
+	})
+	group := DuplicateGroup{Kind: duplicateExact, Hash: hash}
+	var distinct []os.FileInfo
+	for _, video := range videos {
+		file := DuplicateFile{LibraryVideo: video}
+		if info, err := os.Stat(video.Path); err == nil {
+			for _, seen := range distinct {
+				if os.SameFile(seen, info) {
+					file.Linked = true
+					break
+				}
+			}
+			if !file.Linked {
+				distinct = append(distinct, info)
+			}
+		}
+		group.Files = append(group.Files, file)
+	}
+	if len(distinct) < 2 {
+		return DuplicateGroup{}, false
+	}
+	group.Reclaimable = videos[0].Size * int64(len(distinct)-1)
+	return group, true
+}
+
+// thumbnailDHash 计算缩略图的差异哈希：缩小到 9x8 的灰度图，每行比较相邻像素的亮度，
+// 重新编码、调整分辨率后哈希基本不变
+func thumbnailDHash(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return 0, err
+	}
+
+	bounds := img.Bounds()
+	var gray [8][9]float64
+	for y := 0; y < 8; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/8
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/8
+		for x := 0; x < 9; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/9
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/9
+			// 取区域内像素的平均亮度
+			var sum float64
+			var count int
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					r, g, b, _ := img.At(px, py).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			if count > 0 {
+				gray[y][x] = sum / float64(count)
+			}
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+// similarVideo 用于比较相似度的视频
+type similarVideo struct {
+	video LibraryVideo
+	hash  uint64
+}
+
+// similarDuplicateGroups 按时长和缩略图找出相似的视频，只比较已读取时长并生成缩略图的视频
+func (a *App) similarDuplicateGroups(videos []LibraryVideo) []DuplicateGroup {
+	var candidates []similarVideo
+	for _, video := range videos {
+		if video.Metadata == nil || video.Metadata.Duration <= 0 {
+			continue
+		}
+		entry := a.media.lookup(video)
+		if entry.Hash == "" || !thumbnailExists(entry.Hash) {
+			continue
+		}
+		hash, err := thumbnailDHash(thumbnailPath(entry.Hash))
+		if err != nil {
+			slog.Warn("读取缩略图失败", "path", video.Path, "error", err)
+			continue
+		}
+		candidates = append(candidates, similarVideo{video: video, hash: hash})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].video.Metadata.Duration < candidates[j].video.Metadata.Duration
+	})
+
+	var groups []DuplicateGroup
+	grouped := make([]bool, len(candidates))
+	for i, first := range candidates {
+		if grouped[i] {
+			continue
+		}
+		duration := first.video.Metadata.Duration
+		tolerance := math.Max(similarDurationTolerance, duration*similarDurationRatio)
+		members := []LibraryVideo{first.video}
+		for j := i + 1; j < len(candidates) && candidates[j].video.Metadata.Duration-duration <= tolerance; j++ {
+			if !grouped[j] && bits.OnesCount64(first.hash^candidates[j].hash) <= similarHashDistance {
+				grouped[j] = true
+				members = append(members, candidates[j].video)
+			}
+		}
+		if len(members) < 2 {
+			continue
+		}
+
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].Size > members[j].Size
+		})
+		group := DuplicateGroup{Kind: duplicateSimilar}
+		for _, video := range members {
+			group.Files = append(group.Files, DuplicateFile{LibraryVideo: video})
+			group.Reclaimable += video.Size
+		}
+		group.Reclaimable -= members[0].Size
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// FindDuplicates finds duplicate videos in the library
+// FindDuplicates 查找视频库中的重复视频：先按文件大小、再按文件开头和结尾的哈希分组，verify 为 true 时
+// 再校验整个文件的 SHA-256（较慢，但可以确认内容完全一致）；同一文件的硬链接会标记出来，不计入可释放空间。
+// similar 为 true 时还会按时长和缩略图找出画面相近的视频（例如同一内容的不同编码版本），这些视频需要人工确认。
+// 分组按可释放的空间从大到小排列
+func (a *App) FindDuplicates(verify bool, similar bool) (string, error) {
+	started := time.Now()
+	videos, _, err := a.libraryVideos()
+	if err != nil {
+		return "", err
+	}
+
+	bySize := make(map[int64][]LibraryVideo)
+	for _, video := range videos {
+		if video.Size > 0 {
+			bySize[video.Size] = append(bySize[video.Size], video)
+		}
+	}
+	groups := []DuplicateGroup{}
+	// 已经在内容相同的分组中的视频只保留第一个参与相似度比较，避免同一组文件再出现在相似分组中
+	exactDuplicates := make(map[string]bool)
+	for _, sameSize := range bySize {
+		if len(sameSize) < 2 {
+			continue
+		}
+		for hash, sameHash := range groupByHash(sameSize, fileHash) {
+			candidates := map[string][]LibraryVideo{hash: sameHash}
+			if verify {
+				candidates = groupByHash(sameHash, fullFileHash)
+			}
+			for hash, files := range candidates {
+				group, ok := exactDuplicateGroup(hash, files)
+				if !ok {
+					continue
+				}
+				groups = append(groups, group)
+				for _, file := range group.Files[1:] {
+					exactDuplicates[file.Path] = true
+				}
+			}
+		}
+	}
+
+	if similar {
+		var unique []LibraryVideo
+		for _, video := range videos {
+			if !exactDuplicates[video.Path] {
+				unique = append(unique, video)
+			}
+		}
+		groups = append(groups, a.similarDuplicateGroups(unique)...)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Reclaimable > groups[j].Reclaimable
+	})
+	var reclaimable int64
+	for _, group := range groups {
+		reclaimable += group.Reclaimable
+	}
+	slog.Info("查找重复视频完成", "videos", len(videos), "groups", len(groups), "reclaimable", reclaimable, "verify", verify, "similar", similar, "elapsed", time.Since(started).Round(time.Millisecond))
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":      "success",
+		"groups":      groups,
+		"total":       len(groups),
+		"reclaimable": reclaimable,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
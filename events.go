@@ -0,0 +1,100 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 推送给前端的事件名称
+//
+// download:progress  负载为单个 DownloadTask 对象，字段与 GetDownloadStatus 返回的 tasks 元素一致
+//
+//	{taskId, magnetLink, status, totalSize, downloaded, speed, percentage, fileName,
+//	 selectedFiles, outputDir, startTime, endTime, lastUpdate, pid}
+//
+// transcode:progress 负载为单个 TranscodeTask 对象，字段与 GetTranscodeStatus 返回的 tasks 元素一致
+//
+//	{taskId, inputFile, outputFile, status, progress, speed, timeRemaining, startTime,
+//	 endTime, error, videoCodec, audioCodec, resolution, bitrate, pid, ffmpegCommand}
+//
+// 进度类更新按任务节流（同一任务最多每 progressEventInterval 推送一次），
+// 状态变化（开始、完成、失败、取消、进入等待）总是立即推送。
+const (
+	EventDownloadProgress  = "download:progress"
+	EventTranscodeProgress = "transcode:progress"
+)
+
+// progressEventInterval 同一任务两次进度事件之间的最小间隔
+const progressEventInterval = 500 * time.Millisecond
+
+// eventThrottle 按任务ID限制事件推送频率
+type eventThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+}
+
+// newEventThrottle 创建事件节流器
+func newEventThrottle(interval time.Duration) *eventThrottle {
+	return &eventThrottle{
+		interval: interval,
+		last:     make(map[string]time.Time),
+	}
+}
+
+// allow 判断指定key当前是否允许推送，force为true时总是允许并刷新时间戳
+func (t *eventThrottle) allow(key string, force bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if !force {
+		if last, ok := t.last[key]; ok && now.Sub(last) < t.interval {
+			return false
+		}
+	}
+	t.last[key] = now
+	return true
+}
+
+// forget 移除指定key的节流记录，任务结束后调用避免map无限增长
+func (t *eventThrottle) forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.last, key)
+}
+
+// emitEvent 向前端和WebSocket客户端推送事件，应用尚未启动完成时忽略；
+// 以无界面模式运行时只推送给WebSocket客户端
+func (a *App) emitEvent(name string, data interface{}) {
+	if a.ctx == nil {
+		return
+	}
+	if a.hub != nil {
+		a.hub.broadcast(name, data)
+	}
+	if a.headless {
+		return
+	}
+	runtime.EventsEmit(a.ctx, name, data)
+}
+
+// emitDownloadProgress 推送下载任务进度，force为true表示状态变化需要立即推送
+func (a *App) emitDownloadProgress(task DownloadTask, force bool) {
+	key := EventDownloadProgress + ":" + task.TaskID
+	if !a.throttle.allow(key, force) {
+		return
+	}
+	a.emitEvent(EventDownloadProgress, task)
+}
+
+// emitTranscodeProgress 推送转码任务进度，force为true表示状态变化需要立即推送
+func (a *App) emitTranscodeProgress(task TranscodeTask, force bool) {
+	key := EventTranscodeProgress + ":" + task.TaskID
+	if !a.throttle.allow(key, force) {
+		return
+	}
+	a.emitEvent(EventTranscodeProgress, task)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// EventFileDrop 文件拖放到窗口上，负载为 {files: [DroppedFile]}。种子已在后端读取并解析，
+// 视频只包含路径，前端把种子交给解析页面、把视频交给转码页面（StartTranscode 的 filePath）
+const EventFileDrop = "file:drop"
+
+// 拖放文件的类型
+const (
+	droppedTorrent     = "torrent"
+	droppedVideo       = "video"
+	droppedUnsupported = "unsupported"
+)
+
+// DroppedFile is a file dropped onto the window
+// DroppedFile 拖放到窗口上的文件；种子解析失败时 error 为失败原因
+type DroppedFile struct {
+	Kind     string `json:"kind"`
+	FilePath string `json:"filePath"`
+	FileName string `json:"fileName"`
+	Size     int64  `json:"size"`
+	Error    string `json:"error,omitempty"`
+	// 种子的解析结果和 Base64 编码的内容，可以直接传给 DownloadTorrentFiles
+	Torrent *TorrentInfoResponse `json:"torrent,omitempty"`
+	Content string               `json:"content,omitempty"`
+}
+
+// droppedFile 按扩展名识别拖放的文件，种子直接从磁盘读取并解析；文件夹和无法访问的文件返回 false
+func droppedFile(path string) (DroppedFile, bool) {
+	stat, err := os.Stat(path)
+	if err != nil || stat.IsDir() {
+		return DroppedFile{}, false
+	}
+	file := DroppedFile{
+		Kind:     droppedUnsupported,
+		FilePath: path,
+		FileName: filepath.Base(path),
+		Size:     stat.Size(),
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".torrent":
+		file.Kind = droppedTorrent
+		data, torrentInfo, err := readTorrentFile(path)
+		if err != nil {
+			file.Error = err.Error()
+			break
+		}
+		file.Torrent = &torrentInfo
+		file.Content = base64.StdEncoding.EncodeToString(data)
+	case videoExtensions[ext]:
+		file.Kind = droppedVideo
+	}
+	return file, true
+}
+
+// startFileDrop 接收拖放到窗口上的文件，只用于桌面模式
+func (a *App) startFileDrop() {
+	runtime.OnFileDrop(a.ctx, a.handleFileDrop)
+}
+
+// handleFileDrop 处理拖放到窗口上的文件，识别后推送给本机窗口
+func (a *App) handleFileDrop(x, y int, paths []string) {
+	files := make([]DroppedFile, 0, len(paths))
+	for _, path := range paths {
+		if file, ok := droppedFile(path); ok {
+			files = append(files, file)
+		}
+	}
+	slog.Info("文件拖放到窗口", "paths", len(paths), "files", len(files))
+	if len(files) == 0 {
+		return
+	}
+	// 拖放只发生在本机窗口，不推送给局域网访问的网页
+	runtime.EventsEmit(a.ctx, EventFileDrop, map[string]interface{}{
+		"files": files,
+	})
+}
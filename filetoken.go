@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// fileTokenHeader 访问下载、转码和视频库文件时也可以通过请求头传入令牌，URL 中使用 ?token=
+const fileTokenHeader = "X-File-Token"
+
+// fileAccessToken 本次运行的文件访问令牌，只保存在内存中，每次启动重新生成；生成失败时为空，所有文件请求都会被拒绝
+var fileAccessToken = sync.OnceValue(func() string {
+	token, err := randomToken(16)
+	if err != nil {
+		slog.Error("生成文件访问令牌失败", "error", err)
+		return ""
+	}
+	return token
+})
+
+// fileTokenValid 判断请求是否携带文件访问令牌（?token= 或 X-File-Token），也接受设置中的 API 令牌（外部播放器使用）
+// 和浏览器界面的登录会话。本机的其他程序和局域网中的设备不知道令牌，不能直接读取下载目录中的文件
+func fileTokenValid(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = r.Header.Get(fileTokenHeader)
+	}
+	if expected := fileAccessToken(); token != "" && expected != "" && secureEqual(token, expected) {
+		return true
+	}
+	return tokenProvided(r, currentSettings()) || hasSession(r)
+}
+
+// requireFileToken 检查文件访问令牌，没有令牌时返回 403 并返回 false
+func requireFileToken(w http.ResponseWriter, r *http.Request) bool {
+	if fileTokenValid(r) {
+		return true
+	}
+	slog.Warn("拒绝没有文件访问令牌的请求", "path", r.URL.Path, "remote", r.RemoteAddr)
+	http.Error(w, "Access denied", http.StatusForbidden)
+	return false
+}
+
+// withFileToken 在文件访问地址后附加令牌。带令牌的地址只给桌面端前端和 DLNA 播放器使用，
+// 通过REST API和WebSocket返回前用 stripFileToken 去掉
+func withFileToken(fileURL string) string {
+	return fileURL + "?token=" + fileAccessToken()
+}
+
+// stripFileToken 去掉返回给远程客户端的内容中的文件访问令牌：远程客户端使用登录会话或 API 令牌访问文件，
+// 不应得到只给桌面端使用的令牌
+func stripFileToken(data string) string {
+	token := fileAccessToken()
+	if token == "" {
+		return data
+	}
+	return strings.ReplaceAll(data, "?token="+token, "")
+}
+
+// GetFileAccessToken returns the token required by /downloads/ and /transcode/ file URLs
+// GetFileAccessToken 返回访问下载目录、转码目录和视频库文件夹中的文件所需的令牌，拼接地址时附加 ?token=<令牌>；
+// 视频库返回的地址已经包含令牌。令牌在每次启动时重新生成。只绑定给桌面端前端，不通过REST API提供（不在 remoteMethods 中）
+func (a *App) GetFileAccessToken() (string, error) {
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"token":  fileAccessToken(),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileHandlersRequireToken(t *testing.T) {
+	setDataDir(t.TempDir())
+	video := filepath.Join(downloadsDir(), "movie.mp4")
+	hash := strings.Repeat("a", 32)
+	for _, file := range []string{video, thumbnailPath(hash)} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(filepath.Base(file)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		handler http.HandlerFunc
+	}{
+		{"播放地址", streamURL(libraryRootDownload, "movie.mp4"), serveStream},
+		{"缩略图", thumbnailURL(hash), serveThumbnail},
+		{"画面预览", frameURL(libraryRootDownload, "movie.mp4") + "&t=1", serveFrame},
+		{"实时转码", liveURL(libraryRootDownload, "movie.mp4") + "&start=0&session=test", serveLiveStream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withoutToken := strings.Replace(tt.url, "token="+fileAccessToken(), "token=", 1)
+			for _, target := range []string{withoutToken, strings.Replace(tt.url, fileAccessToken(), "wrong", 1)} {
+				w := httptest.NewRecorder()
+				tt.handler(w, httptest.NewRequest(http.MethodGet, target, nil))
+				if w.Code != http.StatusForbidden {
+					t.Fatalf("%s 返回 %d，应为 403", target, w.Code)
+				}
+			}
+
+			// 地址中已经包含令牌，令牌检查之后的处理（例如没有 ffmpeg）可能失败，但不应返回 403
+			w := httptest.NewRecorder()
+			tt.handler(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if w.Code == http.StatusForbidden {
+				t.Fatalf("%s 带有令牌时返回 403", tt.url)
+			}
+		})
+	}
+}
+
+func TestGetFileAccessTokenNotExposedByAPI(t *testing.T) {
+	methods := apiMethods(&App{})
+	if _, ok := methods["GetFileAccessToken"]; ok {
+		t.Fatal("GetFileAccessToken 不应通过REST API提供")
+	}
+	if _, ok := methods["GetRemoteAccess"]; !ok {
+		t.Fatal("其他方法应通过REST API提供")
+	}
+}
+
+func TestRemoteResultsOmitFileToken(t *testing.T) {
+	stream := streamURL(libraryRootDownload, "movie.mp4")
+	library := func() (string, error) {
+		data, err := json.Marshal(map[string]string{"stream": stream, "frames": frameURL(libraryRootDownload, "movie.mp4")})
+		return string(data), err
+	}
+
+	// REST API 和 WebSocket 命令的结果
+	m := apiMethod{name: "GetVideoLibrary", method: reflect.ValueOf(library)}
+	result, err := m.call(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result, fileAccessToken()) {
+		t.Fatalf("远程调用的结果包含文件访问令牌: %s", result)
+	}
+	var urls map[string]string
+	if err := json.Unmarshal([]byte(result), &urls); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.TrimSuffix(stream, "?token="+fileAccessToken()); urls["stream"] != want {
+		t.Fatalf("播放地址为 %s，应为 %s", urls["stream"], want)
+	}
+
+	// WebSocket 推送的事件
+	hub := newWSHub()
+	client := &wsClient{send: make(chan wsMessage, 1)}
+	hub.add(client)
+	hub.broadcast("library:thumbnail", map[string]string{"thumbnail": thumbnailURL(strings.Repeat("a", 32))})
+	event, _ := json.Marshal((<-client.send).Data)
+	if strings.Contains(string(event), fileAccessToken()) {
+		t.Fatalf("推送的事件包含文件访问令牌: %s", event)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// backupSuffix 上一个有效版本的备份文件后缀
+const backupSuffix = ".bak"
+
+// writeFileAtomic writes data to a temp file in the same directory and renames it over path
+// writeFileAtomic 先写入同目录下的临时文件并落盘，再重命名覆盖目标文件，
+// 原文件保留为 .bak 作为最后一个有效版本，写入中途崩溃不会损坏已有数据
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpName := tmp.Name()
+	// 出错时清理临时文件
+	defer func() {
+		if tmpName != "" {
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("同步临时文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭临时文件失败: %w", err)
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+
+	// 保留当前版本作为备份
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Rename(path, path+backupSuffix); err != nil {
+			return fmt.Errorf("备份原文件失败: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("替换文件失败: %w", err)
+	}
+	tmpName = ""
+	return nil
+}
+
+// readFileWithRecovery reads path, falling back to the .bak copy when the file is missing or fails validation
+// readFileWithRecovery 读取文件并用 validate 校验内容，文件缺失或损坏时从 .bak 恢复；
+// 两者都不存在时返回 os.ErrNotExist 类错误
+func readFileWithRecovery(path string, validate func(data []byte) error) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		validateErr := validate(data)
+		if validateErr == nil {
+			return data, nil
+		}
+		slog.Warn("文件已损坏，尝试从备份恢复", "path", path, "error", validateErr)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	backup, backupErr := os.ReadFile(path + backupSuffix)
+	if backupErr != nil {
+		if err != nil {
+			// 主文件和备份都不存在
+			return nil, err
+		}
+		return nil, fmt.Errorf("文件 %s 已损坏且没有可用备份", path)
+	}
+	if err := validate(backup); err != nil {
+		return nil, fmt.Errorf("文件 %s 的备份也已损坏: %w", path, err)
+	}
+
+	// 用备份覆盖损坏的文件，但不覆盖备份本身
+	if err := os.WriteFile(path, backup, 0644); err != nil {
+		slog.Error("恢复文件失败", "path", path, "error", err)
+	} else {
+		slog.Info("已从备份恢复文件", "path", path)
+	}
+	return backup, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// frameURLPrefix 视频任意位置画面的地址前缀，完整地址为 /thumb/<播放ID>?t=<秒>，播放ID与 /stream/ 相同
+	frameURLPrefix = "/thumb/"
+	// framesDirName 画面缓存目录名，位于缩略图目录中，清理缩略图时一起删除
+	framesDirName = "frames"
+	// frameConcurrency 同时运行的截取画面的 ffmpeg 进程数，拖动进度条时请求很多，超出的请求排队等待
+	frameConcurrency = 2
+)
+
+// frameSlots 限制同时截取画面的进程数
+var frameSlots = make(chan struct{}, frameConcurrency)
+
+// framePending 正在截取的画面，同一画面的并发请求等待第一个请求的结果，不重复运行 ffmpeg
+var framePending = struct {
+	mu   sync.Mutex
+	jobs map[string]chan struct{}
+}{jobs: make(map[string]chan struct{})}
+
+// frameURL 返回视频画面的地址，包含文件访问令牌，前端追加 &t=<秒>
+func frameURL(root, relPath string) string {
+	return withFileToken(frameURLPrefix + streamID(root, relPath))
+}
+
+// framePath 返回画面的缓存路径：文件哈希和截取的秒数，视频移动或重命名后仍然有效
+func framePath(hash string, second int64) string {
+	return filepath.Join(thumbnailsDir(), framesDirName, hash+"-"+strconv.FormatInt(second, 10)+".jpg")
+}
+
+// ensureFrame 返回 second 秒处画面的缓存文件，没有缓存时截取；客户端断开时停止等待
+func ensureFrame(ctx context.Context, path, hash string, second int64) (string, error) {
+	output := framePath(hash, second)
+	if info, err := os.Stat(output); err == nil && info.Size() > 0 {
+		return output, nil
+	}
+
+	framePending.mu.Lock()
+	if done, ok := framePending.jobs[output]; ok {
+		framePending.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if info, err := os.Stat(output); err == nil && info.Size() > 0 {
+			return output, nil
+		}
+		return "", errors.New("ffmpeg did not write a frame")
+	}
+	done := make(chan struct{})
+	framePending.jobs[output] = done
+	framePending.mu.Unlock()
+	defer func() {
+		framePending.mu.Lock()
+		delete(framePending.jobs, output)
+		framePending.mu.Unlock()
+		close(done)
+	}()
+
+	select {
+	case frameSlots <- struct{}{}:
+		defer func() { <-frameSlots }()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	ffmpegPath, err := ffmpegToolPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return "", err
+	}
+	tmp := output + ".tmp.jpg"
+	defer os.Remove(tmp)
+	ctx, cancel := context.WithTimeout(ctx, thumbnailTimeout)
+	defer cancel()
+	if err := extractFrame(ctx, ffmpegPath, path, tmp, float64(second)); err != nil {
+		return "", err
+	}
+	return output, os.Rename(tmp, output)
+}
+
+// serveFrame 返回视频库中视频 t 秒处的画面（JPEG），用于进度条上的悬停预览；t 按整秒缓存，
+// 第一次请求时用 ffmpeg 截取，之后直接返回缓存，按设置的缓存时间缓存并以文件哈希和秒数作为 ETag
+func serveFrame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, errorf(msgUseGet, r.URL.Path))
+		return
+	}
+	if !requireFileToken(w, r) {
+		return
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, frameURLPrefix), "/")
+	path, ok := resolveStream(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	t, err := strconv.ParseFloat(r.URL.Query().Get("t"), 64)
+	if err != nil || t < 0 || math.IsInf(t, 0) || math.IsNaN(t) {
+		t = 0
+	}
+	second := int64(min(t, math.MaxInt32))
+	hash, err := fileHash(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	etag := `"` + hash + "-" + strconv.FormatInt(second, 10) + `"`
+	w.Header().Set("Cache-Control", staticCacheControl(true))
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	frame, err := ensureFrame(r.Context(), path, hash, second)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		if errorKey(err) != "" {
+			writeJSONError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		slog.Warn("截取视频画面失败", "path", path, "t", second, "error", err)
+		writeJSONError(w, http.StatusUnprocessableEntity, errorf(msgExtractFrameFailed, err))
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	http.ServeFile(w, r, frame)
+}
@@ -4,6 +4,8 @@ import TorrentView from "../views/TorrentView.vue";
 import DownloadsView from "../views/DownloadsView.vue";
 import LibraryView from "../views/LibraryView.vue";
 import TranscodeView from "../views/TranscodeView.vue";
+import SettingsView from "../views/SettingsView.vue";
+import HistoryView from "../views/HistoryView.vue";
 
 const router = createRouter({
   history: createWebHashHistory(),
@@ -38,6 +40,18 @@ const router = createRouter({
       component: TranscodeView,
       meta: { title: "视频转码", icon: "transcode" }
     },
+    {
+      path: "/history",
+      name: "history",
+      component: HistoryView,
+      meta: { title: "历史记录", icon: "history" }
+    },
+    {
+      path: "/settings",
+      name: "settings",
+      component: SettingsView,
+      meta: { title: "设置", icon: "settings" }
+    },
     // 404 兜底路由
     {
       path: "/:pathMatch(.*)*",
@@ -0,0 +1,36 @@
+import { ref } from "vue";
+import { defineStore } from "pinia";
+
+// 拖放到窗口上的文件（由后端 file:drop 事件提供真实路径），字段与后端 DroppedFile 一致
+export interface DroppedFile {
+  kind: "torrent" | "video" | "unsupported";
+  filePath: string;
+  fileName: string;
+  size: number;
+  error?: string;
+  torrent?: any;
+  content?: string;
+}
+
+// 桌面窗口中拖放由后端处理，页面不再自行读取文件；局域网访问的网页仍使用浏览器的拖放
+export const nativeFileDrop = () => Boolean((window as any).go);
+
+// 等待页面处理的拖放文件：App.vue 收到事件后切换到对应页面，页面挂载或更新时取走
+export const useFileDropStore = defineStore("fileDrop", () => {
+  const torrents = ref<DroppedFile[]>([]);
+  const video = ref<DroppedFile | null>(null);
+
+  function takeTorrents() {
+    const files = torrents.value;
+    torrents.value = [];
+    return files;
+  }
+
+  function takeVideo() {
+    const file = video.value;
+    video.value = null;
+    return file;
+  }
+
+  return { torrents, video, takeTorrents, takeVideo };
+});
@@ -1,34 +1,200 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddMagnetLink(arg1:string):Promise<string>;
+
 export function AddTranscodeTask(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string):Promise<string>;
 
 export function AddTranscodeTaskWithParams(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string):Promise<string>;
 
+export function AddTranscodeTaskWithPreset(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function AppendChunk(arg1:string,arg2:number,arg3:string,arg4:string):Promise<string>;
+
+export function ApplyOrganize(arg1:Array<string>):Promise<string>;
+
+export function BeginUpload(arg1:string,arg2:number,arg3:string):Promise<string>;
+
+export function CancelChecksums():Promise<string>;
+
 export function CancelDownload(arg1:string):Promise<string>;
 
+export function CancelIntegrityCheck():Promise<string>;
+
+export function CancelTorrentVerify():Promise<string>;
+
 export function CancelTranscode(arg1:string):Promise<string>;
 
+export function CancelUpload(arg1:string):Promise<string>;
+
+export function CheckForUpdate():Promise<string>;
+
+export function ClearHistory():Promise<string>;
+
+export function ClearMediaMatch(arg1:string):Promise<string>;
+
+export function ClearThumbnailCache():Promise<string>;
+
+export function ConfirmQuit(arg1:string):Promise<string>;
+
+export function ConvertSubtitle(arg1:string,arg2:string):Promise<string>;
+
+export function DeleteDownloadEntry(arg1:string,arg2:boolean):Promise<string>;
+
+export function DeleteHistoryTask(arg1:string):Promise<string>;
+
+export function DeleteLibraryFile(arg1:string,arg2:boolean):Promise<string>;
+
+export function DownloadSubtitle(arg1:string,arg2:number,arg3:string):Promise<string>;
+
 export function DownloadTorrentFiles(arg1:string,arg2:Array<string>):Promise<string>;
 
 export function DownloadWithTool(arg1:string,arg2:string):Promise<string>;
 
+export function EditTorrentFile(arg1:string,arg2:string):Promise<string>;
+
+export function EnumerateDrives():Promise<string>;
+
+export function ExportChecksums(arg1:string,arg2:string):Promise<string>;
+
+export function ExtractArchives(arg1:string):Promise<string>;
+
+export function ExtractSubtitle(arg1:string,arg2:number,arg3:string):Promise<string>;
+
+export function FindDuplicates(arg1:boolean,arg2:boolean):Promise<string>;
+
+export function FinishUpload(arg1:string,arg2:string):Promise<string>;
+
+export function GenerateAPIToken():Promise<string>;
+
 export function GenerateMagnetLink(arg1:string):Promise<string>;
 
-export function GetDiskSpace():Promise<string>;
+export function GetChecksums():Promise<string>;
+
+export function GetContinueWatching(arg1:number):Promise<string>;
+
+export function GetDataDir():Promise<string>;
+
+export function GetDiskSpace(arg1:string):Promise<string>;
+
+export function GetDiskUsage():Promise<string>;
 
 export function GetDownloadStatus(arg1:string):Promise<string>;
 
+export function GetFileAccessToken():Promise<string>;
+
+export function GetGlobalStats():Promise<string>;
+
+export function GetHistory():Promise<string>;
+
+export function GetIntegrityCheck():Promise<string>;
+
+export function GetPlugins():Promise<string>;
+
+export function GetPublicTrackers(arg1:boolean):Promise<string>;
+
+export function GetQueueAction():Promise<string>;
+
+export function GetRecentLogs(arg1:string,arg2:number):Promise<string>;
+
+export function GetRecentlyAdded(arg1:number):Promise<string>;
+
+export function GetRemoteAccess():Promise<string>;
+
+export function GetRemovedTasks():Promise<string>;
+
+export function GetSettings():Promise<string>;
+
+export function GetSubtitleTracks(arg1:string):Promise<string>;
+
+export function GetSystemInfo():Promise<string>;
+
+export function GetTranscodePresets():Promise<string>;
+
 export function GetTranscodeStatus(arg1:string):Promise<string>;
 
+export function GetUploadStatus(arg1:string):Promise<string>;
+
 export function GetVideoLibrary():Promise<string>;
 
+export function InstallUpdate():Promise<string>;
+
+export function OpenContainingFolder(arg1:string):Promise<string>;
+
+export function ParseMagnetLink(arg1:string,arg2:boolean):Promise<string>;
+
 export function ParseTorrentFile(arg1:string):Promise<string>;
 
+export function ParseTorrentFiles(arg1:string):Promise<string>;
+
+export function ParseTorrentURL(arg1:string):Promise<string>;
+
+export function PauseAll():Promise<string>;
+
+export function PauseAllDownloads():Promise<string>;
+
+export function PauseDownload(arg1:string):Promise<string>;
+
+export function PlayExternal(arg1:string,arg2:string):Promise<string>;
+
+export function PreviewOrganize(arg1:Array<string>):Promise<string>;
+
+export function QueryDownloads(arg1:string):Promise<string>;
+
+export function QueryTranscodes(arg1:string):Promise<string>;
+
+export function QueryVideoLibrary(arg1:string):Promise<string>;
+
+export function ReloadPlugins():Promise<string>;
+
+export function RescanLibrary(arg1:string):Promise<string>;
+
+export function ResumeAll():Promise<string>;
+
+export function ResumeAllDownloads():Promise<string>;
+
+export function ResumeDownload(arg1:string):Promise<string>;
+
+export function SavePlaybackPosition(arg1:string,arg2:number,arg3:number):Promise<string>;
+
+export function SaveSettings(arg1:string):Promise<string>;
+
+export function SearchMediaMetadata(arg1:string):Promise<string>;
+
+export function SearchSubtitles(arg1:string,arg2:string):Promise<string>;
+
+export function SelectTorrentFile():Promise<string>;
+
+export function SelectVideoFile():Promise<string>;
+
 export function ServeVideoFile(arg1:string):Promise<string>;
 
+export function SetDataDir(arg1:string):Promise<string>;
+
+export function SetDownloadPriority(arg1:string,arg2:string):Promise<string>;
+
+export function SetMediaMatch(arg1:string,arg2:string):Promise<string>;
+
+export function SetQueueAction(arg1:string):Promise<string>;
+
+export function SetTaskNote(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function StartChecksums(arg1:Array<string>,arg2:Array<string>):Promise<string>;
+
+export function StartIntegrityCheck(arg1:Array<string>):Promise<string>;
+
 export function StartTranscode(arg1:string):Promise<string>;
 
 export function StartWaitingTask(arg1:string):Promise<string>;
 
+export function StopLiveTranscode(arg1:string):Promise<string>;
+
+export function TestNotification():Promise<string>;
+
+export function TestWebhook(arg1:string,arg2:string):Promise<string>;
+
+export function UndoRemove(arg1:string):Promise<string>;
+
 export function UploadFile(arg1:string):Promise<string>;
+
+export function VerifyAgainstTorrent(arg1:string,arg2:string):Promise<string>;
@@ -2,6 +2,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddMagnetLink(arg1) {
+  return window['go']['main']['App']['AddMagnetLink'](arg1);
+}
+
 export function AddTranscodeTask(arg1, arg2, arg3, arg4, arg5, arg6) {
   return window['go']['main']['App']['AddTranscodeTask'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
@@ -10,14 +14,86 @@ export function AddTranscodeTaskWithParams(arg1, arg2, arg3, arg4, arg5, arg6, a
   return window['go']['main']['App']['AddTranscodeTaskWithParams'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
 }
 
+export function AddTranscodeTaskWithPreset(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddTranscodeTaskWithPreset'](arg1, arg2, arg3);
+}
+
+export function AppendChunk(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['AppendChunk'](arg1, arg2, arg3, arg4);
+}
+
+export function ApplyOrganize(arg1) {
+  return window['go']['main']['App']['ApplyOrganize'](arg1);
+}
+
+export function BeginUpload(arg1, arg2, arg3) {
+  return window['go']['main']['App']['BeginUpload'](arg1, arg2, arg3);
+}
+
+export function CancelChecksums() {
+  return window['go']['main']['App']['CancelChecksums']();
+}
+
 export function CancelDownload(arg1) {
   return window['go']['main']['App']['CancelDownload'](arg1);
 }
 
+export function CancelIntegrityCheck() {
+  return window['go']['main']['App']['CancelIntegrityCheck']();
+}
+
+export function CancelTorrentVerify() {
+  return window['go']['main']['App']['CancelTorrentVerify']();
+}
+
 export function CancelTranscode(arg1) {
   return window['go']['main']['App']['CancelTranscode'](arg1);
 }
 
+export function CancelUpload(arg1) {
+  return window['go']['main']['App']['CancelUpload'](arg1);
+}
+
+export function CheckForUpdate() {
+  return window['go']['main']['App']['CheckForUpdate']();
+}
+
+export function ClearHistory() {
+  return window['go']['main']['App']['ClearHistory']();
+}
+
+export function ClearMediaMatch(arg1) {
+  return window['go']['main']['App']['ClearMediaMatch'](arg1);
+}
+
+export function ClearThumbnailCache() {
+  return window['go']['main']['App']['ClearThumbnailCache']();
+}
+
+export function ConfirmQuit(arg1) {
+  return window['go']['main']['App']['ConfirmQuit'](arg1);
+}
+
+export function ConvertSubtitle(arg1, arg2) {
+  return window['go']['main']['App']['ConvertSubtitle'](arg1, arg2);
+}
+
+export function DeleteDownloadEntry(arg1, arg2) {
+  return window['go']['main']['App']['DeleteDownloadEntry'](arg1, arg2);
+}
+
+export function DeleteHistoryTask(arg1) {
+  return window['go']['main']['App']['DeleteHistoryTask'](arg1);
+}
+
+export function DeleteLibraryFile(arg1, arg2) {
+  return window['go']['main']['App']['DeleteLibraryFile'](arg1, arg2);
+}
+
+export function DownloadSubtitle(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DownloadSubtitle'](arg1, arg2, arg3);
+}
+
 export function DownloadTorrentFiles(arg1, arg2) {
   return window['go']['main']['App']['DownloadTorrentFiles'](arg1, arg2);
 }
@@ -26,34 +102,270 @@ export function DownloadWithTool(arg1, arg2) {
   return window['go']['main']['App']['DownloadWithTool'](arg1, arg2);
 }
 
+export function EditTorrentFile(arg1, arg2) {
+  return window['go']['main']['App']['EditTorrentFile'](arg1, arg2);
+}
+
+export function EnumerateDrives() {
+  return window['go']['main']['App']['EnumerateDrives']();
+}
+
+export function ExportChecksums(arg1, arg2) {
+  return window['go']['main']['App']['ExportChecksums'](arg1, arg2);
+}
+
+export function ExtractArchives(arg1) {
+  return window['go']['main']['App']['ExtractArchives'](arg1);
+}
+
+export function ExtractSubtitle(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExtractSubtitle'](arg1, arg2, arg3);
+}
+
+export function FindDuplicates(arg1, arg2) {
+  return window['go']['main']['App']['FindDuplicates'](arg1, arg2);
+}
+
+export function FinishUpload(arg1, arg2) {
+  return window['go']['main']['App']['FinishUpload'](arg1, arg2);
+}
+
+export function GenerateAPIToken() {
+  return window['go']['main']['App']['GenerateAPIToken']();
+}
+
 export function GenerateMagnetLink(arg1) {
   return window['go']['main']['App']['GenerateMagnetLink'](arg1);
 }
 
-export function GetDiskSpace() {
-  return window['go']['main']['App']['GetDiskSpace']();
+export function GetChecksums() {
+  return window['go']['main']['App']['GetChecksums']();
+}
+
+export function GetContinueWatching(arg1) {
+  return window['go']['main']['App']['GetContinueWatching'](arg1);
+}
+
+export function GetDataDir() {
+  return window['go']['main']['App']['GetDataDir']();
+}
+
+export function GetDiskSpace(arg1) {
+  return window['go']['main']['App']['GetDiskSpace'](arg1);
+}
+
+export function GetDiskUsage() {
+  return window['go']['main']['App']['GetDiskUsage']();
 }
 
 export function GetDownloadStatus(arg1) {
   return window['go']['main']['App']['GetDownloadStatus'](arg1);
 }
 
+export function GetFileAccessToken() {
+  return window['go']['main']['App']['GetFileAccessToken']();
+}
+
+export function GetGlobalStats() {
+  return window['go']['main']['App']['GetGlobalStats']();
+}
+
+export function GetHistory() {
+  return window['go']['main']['App']['GetHistory']();
+}
+
+export function GetIntegrityCheck() {
+  return window['go']['main']['App']['GetIntegrityCheck']();
+}
+
+export function GetPlugins() {
+  return window['go']['main']['App']['GetPlugins']();
+}
+
+export function GetPublicTrackers(arg1) {
+  return window['go']['main']['App']['GetPublicTrackers'](arg1);
+}
+
+export function GetQueueAction() {
+  return window['go']['main']['App']['GetQueueAction']();
+}
+
+export function GetRecentLogs(arg1, arg2) {
+  return window['go']['main']['App']['GetRecentLogs'](arg1, arg2);
+}
+
+export function GetRecentlyAdded(arg1) {
+  return window['go']['main']['App']['GetRecentlyAdded'](arg1);
+}
+
+export function GetRemoteAccess() {
+  return window['go']['main']['App']['GetRemoteAccess']();
+}
+
+export function GetRemovedTasks() {
+  return window['go']['main']['App']['GetRemovedTasks']();
+}
+
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
+export function GetSubtitleTracks(arg1) {
+  return window['go']['main']['App']['GetSubtitleTracks'](arg1);
+}
+
+export function GetSystemInfo() {
+  return window['go']['main']['App']['GetSystemInfo']();
+}
+
+export function GetTranscodePresets() {
+  return window['go']['main']['App']['GetTranscodePresets']();
+}
+
 export function GetTranscodeStatus(arg1) {
   return window['go']['main']['App']['GetTranscodeStatus'](arg1);
 }
 
+export function GetUploadStatus(arg1) {
+  return window['go']['main']['App']['GetUploadStatus'](arg1);
+}
+
 export function GetVideoLibrary() {
   return window['go']['main']['App']['GetVideoLibrary']();
 }
 
+export function InstallUpdate() {
+  return window['go']['main']['App']['InstallUpdate']();
+}
+
+export function OpenContainingFolder(arg1) {
+  return window['go']['main']['App']['OpenContainingFolder'](arg1);
+}
+
+export function ParseMagnetLink(arg1, arg2) {
+  return window['go']['main']['App']['ParseMagnetLink'](arg1, arg2);
+}
+
 export function ParseTorrentFile(arg1) {
   return window['go']['main']['App']['ParseTorrentFile'](arg1);
 }
 
+export function ParseTorrentFiles(arg1) {
+  return window['go']['main']['App']['ParseTorrentFiles'](arg1);
+}
+
+export function ParseTorrentURL(arg1) {
+  return window['go']['main']['App']['ParseTorrentURL'](arg1);
+}
+
+export function PauseAll() {
+  return window['go']['main']['App']['PauseAll']();
+}
+
+export function PauseAllDownloads() {
+  return window['go']['main']['App']['PauseAllDownloads']();
+}
+
+export function PauseDownload(arg1) {
+  return window['go']['main']['App']['PauseDownload'](arg1);
+}
+
+export function PlayExternal(arg1, arg2) {
+  return window['go']['main']['App']['PlayExternal'](arg1, arg2);
+}
+
+export function PreviewOrganize(arg1) {
+  return window['go']['main']['App']['PreviewOrganize'](arg1);
+}
+
+export function QueryDownloads(arg1) {
+  return window['go']['main']['App']['QueryDownloads'](arg1);
+}
+
+export function QueryTranscodes(arg1) {
+  return window['go']['main']['App']['QueryTranscodes'](arg1);
+}
+
+export function QueryVideoLibrary(arg1) {
+  return window['go']['main']['App']['QueryVideoLibrary'](arg1);
+}
+
+export function ReloadPlugins() {
+  return window['go']['main']['App']['ReloadPlugins']();
+}
+
+export function RescanLibrary(arg1) {
+  return window['go']['main']['App']['RescanLibrary'](arg1);
+}
+
+export function ResumeAll() {
+  return window['go']['main']['App']['ResumeAll']();
+}
+
+export function ResumeAllDownloads() {
+  return window['go']['main']['App']['ResumeAllDownloads']();
+}
+
+export function ResumeDownload(arg1) {
+  return window['go']['main']['App']['ResumeDownload'](arg1);
+}
+
+export function SavePlaybackPosition(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SavePlaybackPosition'](arg1, arg2, arg3);
+}
+
+export function SaveSettings(arg1) {
+  return window['go']['main']['App']['SaveSettings'](arg1);
+}
+
+export function SearchMediaMetadata(arg1) {
+  return window['go']['main']['App']['SearchMediaMetadata'](arg1);
+}
+
+export function SearchSubtitles(arg1, arg2) {
+  return window['go']['main']['App']['SearchSubtitles'](arg1, arg2);
+}
+
+export function SelectTorrentFile() {
+  return window['go']['main']['App']['SelectTorrentFile']();
+}
+
+export function SelectVideoFile() {
+  return window['go']['main']['App']['SelectVideoFile']();
+}
+
 export function ServeVideoFile(arg1) {
   return window['go']['main']['App']['ServeVideoFile'](arg1);
 }
 
+export function SetDataDir(arg1) {
+  return window['go']['main']['App']['SetDataDir'](arg1);
+}
+
+export function SetDownloadPriority(arg1, arg2) {
+  return window['go']['main']['App']['SetDownloadPriority'](arg1, arg2);
+}
+
+export function SetMediaMatch(arg1, arg2) {
+  return window['go']['main']['App']['SetMediaMatch'](arg1, arg2);
+}
+
+export function SetQueueAction(arg1) {
+  return window['go']['main']['App']['SetQueueAction'](arg1);
+}
+
+export function SetTaskNote(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetTaskNote'](arg1, arg2, arg3);
+}
+
+export function StartChecksums(arg1, arg2) {
+  return window['go']['main']['App']['StartChecksums'](arg1, arg2);
+}
+
+export function StartIntegrityCheck(arg1) {
+  return window['go']['main']['App']['StartIntegrityCheck'](arg1);
+}
+
 export function StartTranscode(arg1) {
   return window['go']['main']['App']['StartTranscode'](arg1);
 }
@@ -62,6 +374,26 @@ export function StartWaitingTask(arg1) {
   return window['go']['main']['App']['StartWaitingTask'](arg1);
 }
 
+export function StopLiveTranscode(arg1) {
+  return window['go']['main']['App']['StopLiveTranscode'](arg1);
+}
+
+export function TestNotification() {
+  return window['go']['main']['App']['TestNotification']();
+}
+
+export function TestWebhook(arg1, arg2) {
+  return window['go']['main']['App']['TestWebhook'](arg1, arg2);
+}
+
+export function UndoRemove(arg1) {
+  return window['go']['main']['App']['UndoRemove'](arg1);
+}
+
 export function UploadFile(arg1) {
   return window['go']['main']['App']['UploadFile'](arg1);
 }
+
+export function VerifyAgainstTorrent(arg1, arg2) {
+  return window['go']['main']['App']['VerifyAgainstTorrent'](arg1, arg2);
+}
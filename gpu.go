@@ -0,0 +1,75 @@
+package main
+
+import (
+	"log/slog"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// GPUType 表示GPU的类型
+type GPUType string
+
+const (
+	GPUTypeNVIDIA GPUType = "nvidia"
+	GPUTypeAMD    GPUType = "amd"
+	GPUTypeIntel  GPUType = "intel"
+	// GPUTypeApple macOS 上所有显卡（包括 Apple 芯片和 Intel Mac 的独立显卡）都通过 VideoToolbox 编码
+	GPUTypeApple GPUType = "apple"
+	GPUTypeOther GPUType = "other"
+)
+
+// amdGPUPattern 匹配AMD显卡名称。按单词匹配 ati 和 rx，避免 lspci 输出的 "Intel Corporation" 被识别为AMD
+var amdGPUPattern = regexp.MustCompile(`\b(amd|radeon|ati)\b|\brx\s?\d`)
+
+// gpuDetection 缓存GPU检测结果，显卡在程序运行期间不会变化，不必每次转码都重新检测
+var gpuDetection struct {
+	once    sync.Once
+	hasGPU  bool
+	gpuType GPUType
+}
+
+// HasGPU 检测系统是否有可用的GPU，并返回GPU类型。只在第一次调用时检测
+func HasGPU() (bool, GPUType) {
+	gpuDetection.once.Do(func() {
+		gpuDetection.hasGPU, gpuDetection.gpuType = detectGPU()
+	})
+	return gpuDetection.hasGPU, gpuDetection.gpuType
+}
+
+// detectGPU 检测GPU，显卡列表由各平台的 listGPUs 提供
+func detectGPU() (bool, GPUType) {
+	names, err := gpuList()
+	if err != nil {
+		slog.Error("检测GPU失败", "error", err)
+		return false, GPUTypeOther
+	}
+	if len(names) == 0 {
+		slog.Info("未检测到可用GPU")
+		return false, GPUTypeOther
+	}
+	slog.Info("检测到GPU", "gpus", strings.Join(names, ", "))
+	if runtime.GOOS == "darwin" {
+		return true, GPUTypeApple
+	}
+	// ARM64 的 Windows 和 Linux（骁龙、树莓派、Jetson 等）没有 NVENC、AMF 和 QSV 编码器
+	if runtime.GOARCH == "arm64" {
+		return true, GPUTypeOther
+	}
+	return true, gpuTypeOf(names)
+}
+
+// gpuTypeOf 按显卡名称判断GPU类型，有多块显卡时依次优先 NVIDIA、AMD、Intel
+func gpuTypeOf(names []string) GPUType {
+	// 检查GPU类型 - 增强AMD检测逻辑以更好地支持RX6400
+	outputLower := strings.ToLower(strings.Join(names, "\n"))
+	if strings.Contains(outputLower, "nvidia") {
+		return GPUTypeNVIDIA
+	} else if amdGPUPattern.MatchString(outputLower) {
+		return GPUTypeAMD
+	} else if strings.Contains(outputLower, "intel") || strings.Contains(outputLower, "hd graphics") || strings.Contains(outputLower, "uhd graphics") || strings.Contains(outputLower, "iris") {
+		return GPUTypeIntel
+	}
+	return GPUTypeOther
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// listGPUs 使用 system_profiler 列出显卡名称（Chipset Model，例如 Apple M1、AMD Radeon Pro 5500M）
+func listGPUs() ([]string, error) {
+	output, err := exec.Command("system_profiler", "SPDisplaysDataType").Output()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "Chipset Model:"); ok {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	return names, nil
+}
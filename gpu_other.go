@@ -0,0 +1,61 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// drmDir 内核导出的显卡设备，card0、card1 等为显卡，card0-HDMI-A-1 等为接口
+const drmDir = "/sys/class/drm"
+
+// pciVendors 显卡厂商的 PCI ID
+var pciVendors = map[string]string{
+	"0x10de": "NVIDIA",
+	"0x1002": "AMD Radeon",
+	"0x8086": "Intel",
+}
+
+// listGPUs 列出显卡：优先使用 lspci 获取完整名称，没有 lspci 时按 sysfs 中的厂商ID判断
+func listGPUs() ([]string, error) {
+	if output, err := exec.Command("lspci").Output(); err == nil {
+		var names []string
+		for _, line := range strings.Split(string(output), "\n") {
+			// 00:02.0 VGA compatible controller: Intel Corporation ...
+			_, rest, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			class, name, ok := strings.Cut(rest, ": ")
+			if ok && (strings.Contains(class, "VGA") || strings.Contains(class, "3D controller") || strings.Contains(class, "Display controller")) {
+				names = append(names, strings.TrimSpace(name))
+			}
+		}
+		return names, nil
+	}
+
+	cards, err := filepath.Glob(filepath.Join(drmDir, "card*"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, card := range cards {
+		if strings.Contains(filepath.Base(card), "-") {
+			continue
+		}
+		vendor, err := os.ReadFile(filepath.Join(card, "device", "vendor"))
+		if err != nil {
+			continue
+		}
+		id := strings.TrimSpace(string(vendor))
+		if name, ok := pciVendors[id]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, "GPU "+id)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// displayAdapterClassKey 显示适配器设备类的注册表项，每个适配器是一个 0000、0001 等子项，DriverDesc 为显卡名称
+const displayAdapterClassKey = `SYSTEM\CurrentControlSet\Control\Class\{4d36e968-e325-11ce-bfc1-08002be10318}`
+
+// listGPUs 列出显卡名称：优先读取注册表，失败时使用 PowerShell 的 Get-CimInstance。
+// wmic 在新版 Windows 11 中已被移除，不再使用
+func listGPUs() ([]string, error) {
+	names, err := registryGPUs()
+	if err == nil && len(names) > 0 {
+		return names, nil
+	}
+	return cimGPUs()
+}
+
+// registryGPUs 从注册表读取显示适配器，跳过 Microsoft 基本显示适配器等软件适配器
+func registryGPUs() ([]string, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, displayAdapterClassKey, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+	subkeys, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, subkey := range subkeys {
+		adapter, err := registry.OpenKey(key, subkey, registry.QUERY_VALUE)
+		if err != nil {
+			// Properties 等子项没有读取权限
+			continue
+		}
+		name, _, err := adapter.GetStringValue("DriverDesc")
+		adapter.Close()
+		if err != nil || name == "" || strings.HasPrefix(name, "Microsoft ") {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// cimGPUs 使用 PowerShell 的 Get-CimInstance 查询 Win32_VideoController
+func cimGPUs() ([]string, error) {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"Get-CimInstance Win32_VideoController | ForEach-Object { $_.Name }")
+	hideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// historyCleanupInterval 按保留策略清理历史记录的间隔
+const historyCleanupInterval = time.Hour
+
+// HistoryEntry is a finished download or transcode task
+// HistoryEntry 一条历史记录：已结束（完成、取消或失败）的下载或转码任务
+type HistoryEntry struct {
+	TaskType string      `json:"taskType"` // download, transcode
+	TaskID   string      `json:"taskId"`
+	Name     string      `json:"name"`
+	Status   string      `json:"status"`
+	EndTime  time.Time   `json:"endTime"`
+	Task     interface{} `json:"task"`
+}
+
+// downloadFinished 下载任务是否已结束，结束的任务进入历史记录
+func downloadFinished(status string) bool {
+	return status == "completed" || status == "cancelled"
+}
+
+// transcodeFinished 转码任务是否已结束，结束的任务进入历史记录
+func transcodeFinished(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
+
+// historyEntries 返回所有历史记录，最近结束的在前
+func (a *App) historyEntries() []HistoryEntry {
+	var entries []HistoryEntry
+	for _, t := range a.tasks.Downloads() {
+		if !downloadFinished(t.Status) {
+			continue
+		}
+		// 旧版本的任务可能没有结束时间，使用开始时间
+		endTime, err := time.Parse(time.RFC3339, t.EndTime)
+		if err != nil {
+			endTime, _ = time.Parse(time.RFC3339, t.StartTime)
+		}
+		name := t.FileName
+		if name == "" {
+			name = t.TaskID
+		}
+		entries = append(entries, HistoryEntry{
+			TaskType: "download",
+			TaskID:   t.TaskID,
+			Name:     name,
+			Status:   t.Status,
+			EndTime:  endTime,
+			Task:     t,
+		})
+	}
+	for _, t := range a.tasks.Transcodes() {
+		if !transcodeFinished(t.Status) {
+			continue
+		}
+		endTime := t.EndTime
+		if endTime.IsZero() {
+			endTime = t.StartTime
+		}
+		entries = append(entries, HistoryEntry{
+			TaskType: "transcode",
+			TaskID:   t.TaskID,
+			Name:     filepath.Base(t.OutputFile),
+			Status:   t.Status,
+			EndTime:  endTime,
+			Task:     t,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].EndTime.After(entries[j].EndTime)
+	})
+	return entries
+}
+
+// removeHistoryEntry 删除一条历史记录（只删除任务记录，不删除下载或转码的文件）
+func (a *App) removeHistoryEntry(entry HistoryEntry) error {
+	if entry.TaskType == "transcode" {
+		return a.tasks.RemoveTranscode(entry.TaskID)
+	}
+	os.Remove(filepath.Join(torrentsDir(), entry.TaskID+".torrent"))
+	return a.tasks.RemoveDownload(entry.TaskID)
+}
+
+// pruneHistory 按设置的保留天数和条数删除较早的历史记录，返回删除的条数
+func (a *App) pruneHistory() int {
+	if a.tasks == nil {
+		return 0
+	}
+	s := currentSettings()
+	if s.HistoryRetentionDays == 0 && s.HistoryMaxEntries == 0 {
+		return 0
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -s.HistoryRetentionDays)
+	removed := 0
+	for i, entry := range a.historyEntries() {
+		expired := s.HistoryRetentionDays > 0 && entry.EndTime.Before(cutoff)
+		overLimit := s.HistoryMaxEntries > 0 && i >= s.HistoryMaxEntries
+		if !expired && !overLimit {
+			continue
+		}
+		if err := a.removeHistoryEntry(entry); err != nil {
+			slog.Error("删除历史记录失败", "taskId", entry.TaskID, "error", err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		slog.Info("已按保留策略清理历史记录", "count", removed)
+	}
+	return removed
+}
+
+// startHistoryCleanup 启动时清理一次历史记录，之后定期清理
+func (a *App) startHistoryCleanup() {
+	a.pruneHistory()
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopHistory = cancel
+	go func() {
+		ticker := time.NewTicker(historyCleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.pruneHistory()
+			}
+		}
+	}()
+}
+
+// GetHistory returns finished tasks, most recent first
+// GetHistory 获取历史记录（已结束的下载和转码任务），最近结束的在前
+func (a *App) GetHistory() (string, error) {
+	entries := a.historyEntries()
+	if entries == nil {
+		entries = []HistoryEntry{}
+	}
+	s := currentSettings()
+
+	response := map[string]interface{}{
+		"status":        "success",
+		"history":       entries,
+		"retentionDays": s.HistoryRetentionDays,
+		"maxEntries":    s.HistoryMaxEntries,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// ClearHistory removes all finished tasks
+// ClearHistory 清空历史记录，下载和转码的文件保留；删除的任务在撤销期限内可以通过 UndoRemove 恢复
+func (a *App) ClearHistory() (string, error) {
+	taskIDs := []string{}
+	var undoUntil time.Time
+	for _, entry := range a.historyEntries() {
+		expires, err := a.trashHistoryEntry(entry)
+		if err != nil {
+			return "", errorf(msgClearHistoryFailed, err)
+		}
+		taskIDs = append(taskIDs, entry.TaskID)
+		undoUntil = expires
+	}
+	slog.Info("已清空历史记录", "count", len(taskIDs))
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"message":   "History cleared successfully",
+		"count":     len(taskIDs),
+		"taskIds":   taskIDs,
+		"undoUntil": undoUntil,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// DeleteHistoryTask removes a single finished task
+// DeleteHistoryTask 删除一条历史记录，只能删除已结束的任务，文件保留；撤销期限内可以通过 UndoRemove 恢复
+func (a *App) DeleteHistoryTask(taskId string) (string, error) {
+	var found *HistoryEntry
+	entries := a.historyEntries()
+	for i := range entries {
+		if entries[i].TaskID == taskId {
+			found = &entries[i]
+			break
+		}
+	}
+	if found == nil {
+		return "", errorf(msgHistoryNotFound, taskId)
+	}
+	undoUntil, err := a.trashHistoryEntry(*found)
+	if err != nil {
+		return "", errorf(msgDeleteHistoryFailed, err)
+	}
+	slog.Info("已删除历史记录", "taskId", taskId)
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"message":   "History entry deleted successfully",
+		"taskId":    taskId,
+		"undoUntil": undoUntil,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
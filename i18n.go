@@ -0,0 +1,846 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 支持的界面语言，与前端 vue-i18n 的 locale 一致
+const (
+	localeZhHans = "zh-Hans"
+	localeEn     = "en"
+)
+
+// defaultLocale 设置未指定语言或缺少翻译时使用的语言
+const defaultLocale = localeZhHans
+
+// supportedLocales 可选的语言
+var supportedLocales = []string{localeZhHans, localeEn}
+
+// msgKey 返回给前端的消息键，对应 messages 中各语言的文本
+type msgKey string
+
+const (
+	// 任务
+	msgTaskNotFound            msgKey = "task.notFound"
+	msgDuplicateTaskID         msgKey = "task.duplicateId"
+	msgTasksLoadFailed         msgKey = "task.loadFailed"
+	msgTranscodeNotFound       msgKey = "task.transcodeNotFound"
+	msgWaitingTaskNotFound     msgKey = "task.waitingNotFound"
+	msgCannotPause             msgKey = "task.cannotPause"
+	msgCannotResume            msgKey = "task.cannotResume"
+	msgInvalidPriority         msgKey = "task.invalidPriority"
+	msgTaskNoteTooLong         msgKey = "task.noteTooLong"
+	msgInvalidTaskLabel        msgKey = "task.invalidLabel"
+	msgParseTaskQueryFailed    msgKey = "task.parseQueryFailed"
+	msgInvalidQueryTime        msgKey = "task.invalidQueryTime"
+	msgInvalidTaskQuery        msgKey = "task.invalidQuery"
+	msgResumeFailed            msgKey = "task.resumeFailed"
+	msgDownloadLimitReached    msgKey = "task.downloadLimitReached"
+	msgStartWaitingFailed      msgKey = "task.startWaitingFailed"
+	msgStartTranscodeFailed    msgKey = "task.startTranscodeFailed"
+	msgInvalidMagnet           msgKey = "task.invalidMagnet"
+	msgParseMagnetFailed       msgKey = "task.parseMagnetFailed"
+	msgInvalidInfoHashLength   msgKey = "task.invalidInfoHashLength"
+	msgUnsupportedMultihash    msgKey = "task.unsupportedMultihash"
+	msgUnsupportedMagnetXT     msgKey = "task.unsupportedMagnetXT"
+	msgMagnetMetadataNotSaved  msgKey = "task.magnetMetadataNotSaved"
+	msgMagnetMetadataTimeout   msgKey = "task.magnetMetadataTimeout"
+	msgInvalidTorrentURL       msgKey = "task.invalidTorrentURL"
+	msgFetchTorrentFailed      msgKey = "task.fetchTorrentFailed"
+	msgTorrentTooLarge         msgKey = "task.torrentTooLarge"
+	msgNotTorrentFile          msgKey = "task.notTorrentFile"
+	msgInvalidTracker          msgKey = "task.invalidTracker"
+	msgTorrentV2Only           msgKey = "task.torrentV2Only"
+	msgTorrentVerifyRunning    msgKey = "task.torrentVerifyRunning"
+	msgTorrentVerifyNotRunning msgKey = "task.torrentVerifyNotRunning"
+	msgTorrentVerifyCancelled  msgKey = "task.torrentVerifyCancelled"
+	msgFetchTrackersFailed     msgKey = "task.fetchTrackersFailed"
+	msgInputFileNotFound       msgKey = "task.inputFileNotFound"
+	msgFileNotFound            msgKey = "task.fileNotFound"
+	msgUnknownPreset           msgKey = "task.unknownPreset"
+	msgTorrentNotFound         msgKey = "tool.torrentNotFound"
+	msgFFmpegNotFound          msgKey = "tool.ffmpegNotFound"
+	msgFFprobeNotFound         msgKey = "tool.ffprobeNotFound"
+	msgDiskSpaceFailed         msgKey = "fs.diskSpaceFailed"
+	msgReadDownloadDirFailed   msgKey = "fs.readDownloadDirFailed"
+	msgCreateDirFailed         msgKey = "fs.createDirFailed"
+	msgCreateFileFailed        msgKey = "fs.createFileFailed"
+	msgUploadNotFound          msgKey = "fs.uploadNotFound"
+	msgUploadOffsetMismatch    msgKey = "fs.uploadOffsetMismatch"
+	msgUploadChunkTooLarge     msgKey = "fs.uploadChunkTooLarge"
+	msgUploadChecksumMismatch  msgKey = "fs.uploadChecksumMismatch"
+	msgUploadChecksumRequired  msgKey = "fs.uploadChecksumRequired"
+	msgUploadChunkConflict     msgKey = "fs.uploadChunkConflict"
+	msgUploadIncomplete        msgKey = "fs.uploadIncomplete"
+	msgNotEnoughSpace          msgKey = "fs.notEnoughSpace"
+	msgUploadNotReady          msgKey = "fs.uploadNotReady"
+	msgUploadFailed            msgKey = "fs.uploadFailed"
+	msgUploadTooLarge          msgKey = "fs.uploadTooLarge"
+	msgUploadUnsupportedType   msgKey = "fs.uploadUnsupportedType"
+	msgUploadNotVideo          msgKey = "fs.uploadNotVideo"
+	msgDialogHeadless          msgKey = "dialog.headless"
+	msgOpenDialogFailed        msgKey = "dialog.openFailed"
+	msgReadFileFailed          msgKey = "fs.readFileFailed"
+
+	// 历史记录
+	msgHistoryNotFound     msgKey = "history.notFound"
+	msgClearHistoryFailed  msgKey = "history.clearFailed"
+	msgDeleteHistoryFailed msgKey = "history.deleteFailed"
+	msgRemovedTaskNotFound msgKey = "history.removedNotFound"
+
+	// 视频库
+	msgInvalidLibraryFolder     msgKey = "library.invalidFolder"
+	msgDuplicateLibraryFolder   msgKey = "library.duplicateFolder"
+	msgLibraryFolderNotFound    msgKey = "library.folderNotFound"
+	msgScraperDisabled          msgKey = "library.scraperDisabled"
+	msgScraperRequestFailed     msgKey = "library.scraperFailed"
+	msgParseMatchFailed         msgKey = "library.parseMatchFailed"
+	msgEmptySearchQuery         msgKey = "library.emptySearchQuery"
+	msgInvalidMetadataProvider  msgKey = "settings.invalidMetadataProvider"
+	msgMetadataKeyRequired      msgKey = "settings.metadataKeyRequired"
+	msgInvalidOrganizeMode      msgKey = "settings.invalidOrganizeMode"
+	msgInvalidOrganizeTemplate  msgKey = "library.invalidOrganizeTemplate"
+	msgOrganizeNoTitle          msgKey = "library.organizeNoTitle"
+	msgOrganizeUnfinished       msgKey = "library.organizeUnfinished"
+	msgOrganizeTargetExists     msgKey = "library.organizeTargetExists"
+	msgDLNADownloads            msgKey = "library.dlnaDownloads"
+	msgDLNATranscodes           msgKey = "library.dlnaTranscodes"
+	msgFileInUse                msgKey = "library.fileInUse"
+	msgDeleteFileFailed         msgKey = "library.deleteFileFailed"
+	msgPathOutsideLibrary       msgKey = "library.pathOutsideLibrary"
+	msgExtractFrameFailed       msgKey = "library.extractFrameFailed"
+	msgOpenFolderFailed         msgKey = "library.openFolderFailed"
+	msgPlayerNotFound           msgKey = "library.playerNotFound"
+	msgLaunchPlayerFailed       msgKey = "library.launchPlayerFailed"
+	msgNoVideosToCheck          msgKey = "library.noVideosToCheck"
+	msgIntegrityCheckRunning    msgKey = "library.integrityCheckRunning"
+	msgIntegrityCheckNotRunning msgKey = "library.integrityCheckNotRunning"
+	msgNoArchives               msgKey = "library.noArchives"
+	msgArchiveEncrypted         msgKey = "library.archiveEncrypted"
+	msgExtractorNotFound        msgKey = "library.extractorNotFound"
+	msgInvalidArchivePath       msgKey = "library.invalidArchivePath"
+	msgUnrarUnsupported         msgKey = "library.unrarUnsupported"
+	msgInvalidChecksumAlgorithm msgKey = "library.invalidChecksumAlgorithm"
+	msgNoFilesToHash            msgKey = "library.noFilesToHash"
+	msgChecksumRunning          msgKey = "library.checksumRunning"
+	msgChecksumNotRunning       msgKey = "library.checksumNotRunning"
+	msgNoChecksums              msgKey = "library.noChecksums"
+	msgWriteManifestFailed      msgKey = "library.writeManifestFailed"
+	msgParseLibraryQueryFailed  msgKey = "library.parseQueryFailed"
+	msgInvalidLibrarySort       msgKey = "library.invalidSort"
+	msgInvalidResolution        msgKey = "library.invalidResolution"
+	msgInvalidLibraryPage       msgKey = "library.invalidPage"
+	msgSubtitleKeyRequired      msgKey = "library.subtitleKeyRequired"
+	msgSubtitleRequestFailed    msgKey = "library.subtitleRequestFailed"
+	msgSaveSubtitleFailed       msgKey = "library.saveSubtitleFailed"
+	msgReadSubtitleTracksFailed msgKey = "library.readSubtitleTracksFailed"
+	msgSubtitleTrackNotFound    msgKey = "library.subtitleTrackNotFound"
+	msgImageSubtitle            msgKey = "library.imageSubtitle"
+	msgInvalidSubtitleFormat    msgKey = "library.invalidSubtitleFormat"
+	msgConvertSubtitleFailed    msgKey = "library.convertSubtitleFailed"
+
+	// 设置
+	msgInvalidConcurrentDownloads  msgKey = "settings.invalidConcurrentDownloads"
+	msgInvalidConcurrentTranscodes msgKey = "settings.invalidConcurrentTranscodes"
+	msgInvalidSpeedLimit           msgKey = "settings.invalidSpeedLimit"
+	msgInvalidTheme                msgKey = "settings.invalidTheme"
+	msgInvalidLanguage             msgKey = "settings.invalidLanguage"
+	msgInvalidLogLevel             msgKey = "settings.invalidLogLevel"
+	msgInvalidPort                 msgKey = "settings.invalidPort"
+	msgRemoteAccessNeedsPassword   msgKey = "settings.remoteAccessNeedsPassword"
+	msgInvalidSessionHours         msgKey = "settings.invalidSessionHours"
+	msgInvalidStaticCacheHours     msgKey = "settings.invalidStaticCacheHours"
+	msgInvalidMaxUploadSize        msgKey = "settings.invalidMaxUploadSize"
+	msgInvalidMinFreeSpace         msgKey = "settings.invalidMinFreeSpace"
+	msgInvalidThermalAction        msgKey = "settings.invalidThermalAction"
+	msgInvalidThermalLimits        msgKey = "settings.invalidThermalLimits"
+	msgInvalidHistoryRetention     msgKey = "settings.invalidHistoryRetention"
+	msgInvalidLibraryScan          msgKey = "settings.invalidLibraryScan"
+	msgInvalidPath                 msgKey = "settings.invalidPath"
+	msgToolNotFound                msgKey = "settings.toolNotFound"
+	msgToolsDirNotFound            msgKey = "settings.toolsDirNotFound"
+	msgParseSettingsFailed         msgKey = "settings.parseFailed"
+	msgDesktopOnlySetting          msgKey = "settings.desktopOnly"
+	msgSaveSettingsFailed          msgKey = "settings.saveFailed"
+	msgAutostartFailed             msgKey = "settings.autostartFailed"
+	msgAutostartHomeDirFailed      msgKey = "settings.autostartHomeDirFailed"
+	msgAutostartConfigDirFailed    msgKey = "settings.autostartConfigDirFailed"
+	msgAutostartRegistryFailed     msgKey = "settings.autostartRegistryFailed"
+	msgAutostartRemoveFailed       msgKey = "settings.autostartRemoveFailed"
+	msgAutostartRegisterFailed     msgKey = "settings.autostartRegisterFailed"
+	msgRemoteAccessFailed          msgKey = "settings.remoteAccessFailed"
+	msgInvalidDLNAPort             msgKey = "settings.invalidDlnaPort"
+	msgInvalidPlayer               msgKey = "settings.invalidPlayer"
+	msgInvalidTrackerListURL       msgKey = "settings.invalidTrackerListUrl"
+	msgInvalidSubtitleLanguage     msgKey = "settings.invalidSubtitleLanguage"
+	msgDLNAFailed                  msgKey = "settings.dlnaFailed"
+	msgInvalidWebhookURL           msgKey = "settings.invalidWebhookURL"
+	msgInvalidWebhookFormat        msgKey = "settings.invalidWebhookFormat"
+	msgInvalidWebhookEvent         msgKey = "settings.invalidWebhookEvent"
+	msgSendWebhookFailed           msgKey = "settings.sendWebhookFailed"
+	msgNotificationFailed          msgKey = "settings.notificationFailed"
+
+	// 数据目录
+	msgPortableDataDir        msgKey = "dataDir.portable"
+	msgCreateDefaultDirFailed msgKey = "dataDir.createDefaultFailed"
+	msgRestoreDataDirFailed   msgKey = "dataDir.restoreFailed"
+	msgInvalidDataDir         msgKey = "dataDir.invalid"
+	msgCreateDataDirFailed    msgKey = "dataDir.createFailed"
+	msgEnvDataDir             msgKey = "dataDir.env"
+	msgDataDirNotEmpty        msgKey = "dataDir.notEmpty"
+	msgDataDirNotWritable     msgKey = "dataDir.notWritable"
+	msgMigrateDataDirFailed   msgKey = "dataDir.migrateFailed"
+	msgDataDirMigrated        msgKey = "dataDir.migrated"
+
+	// 更新
+	msgNoUpdateKey          msgKey = "update.noPublicKey"
+	msgInvalidUpdateKey     msgKey = "update.invalidPublicKey"
+	msgNoUpdateAsset        msgKey = "update.noAsset"
+	msgNoUpdateSignature    msgKey = "update.noSignature"
+	msgDownloadSigFailed    msgKey = "update.downloadSignatureFailed"
+	msgInvalidSignature     msgKey = "update.invalidSignature"
+	msgDownloadUpdateFailed msgKey = "update.downloadFailed"
+	msgVerifyUpdateFailed   msgKey = "update.verifyFailed"
+	msgSaveUpdateFailed     msgKey = "update.saveFailed"
+	msgCheckUpdateFailed    msgKey = "update.checkFailed"
+	msgParseReleaseFailed   msgKey = "update.parseReleaseFailed"
+	msgUpdateWindowsOnly    msgKey = "update.windowsOnly"
+	msgUpdateHeadless       msgKey = "update.headless"
+	msgUpdateInProgress     msgKey = "update.inProgress"
+	msgAlreadyLatest        msgKey = "update.alreadyLatest"
+	msgStartInstallerFailed msgKey = "update.startInstallerFailed"
+
+	// 插件
+	msgPluginNoName         msgKey = "plugin.noName"
+	msgPluginNoCommand      msgKey = "plugin.noCommand"
+	msgPluginInvalidTimeout msgKey = "plugin.invalidTimeout"
+	msgPluginInvalidHook    msgKey = "plugin.invalidHook"
+	msgPluginDuplicateName  msgKey = "plugin.duplicateName"
+	msgPluginTimeout        msgKey = "plugin.timeout"
+
+	// 退出
+	msgInvalidQuitAction msgKey = "quit.invalidAction"
+	msgQuitHeadless      msgKey = "quit.headless"
+
+	// 队列完成后的操作
+	msgInvalidQueueAction   msgKey = "queueAction.invalid"
+	msgHibernateUnsupported msgKey = "queueAction.hibernateUnsupported"
+
+	// 系统通知
+	msgNotifyDownloadCompleted   msgKey = "notify.downloadCompleted"
+	msgNotifyDownloadInterrupted msgKey = "notify.downloadInterrupted"
+	msgNotifyDownloadRequeued    msgKey = "notify.downloadRequeued"
+	msgNotifyTranscodeCompleted  msgKey = "notify.transcodeCompleted"
+	msgNotifyTranscodeFailed     msgKey = "notify.transcodeFailed"
+	msgNotifyEnabled             msgKey = "notify.enabled"
+	msgNotifyExtractCompleted    msgKey = "notify.extractCompleted"
+	msgNotifyExtractFailed       msgKey = "notify.extractFailed"
+	msgNotifyExtractFailedCount  msgKey = "notify.extractFailedCount"
+	msgNotifyChecksumsCompleted  msgKey = "notify.checksumsCompleted"
+	msgNotifyChecksumsHashed     msgKey = "notify.checksumsHashed"
+	msgNotifyChecksumsReadFailed msgKey = "notify.checksumsReadFailed"
+	msgNotifyIntegrityCompleted  msgKey = "notify.integrityCompleted"
+	msgNotifyIntegrityOK         msgKey = "notify.integrityOK"
+	msgNotifyIntegrityProblems   msgKey = "notify.integrityProblems"
+	msgNotifyDiskLow             msgKey = "notify.diskLow"
+	msgNotifyDiskLowDownloads    msgKey = "notify.diskLowDownloads"
+	msgNotifyDiskLowTranscodes   msgKey = "notify.diskLowTranscodes"
+	msgNotifyQueueIdle           msgKey = "notify.queueIdle"
+	msgNotifyQueueCountdown      msgKey = "notify.queueCountdown"
+	msgNotifyQueueActionFailed   msgKey = "notify.queueActionFailed"
+	msgQueueActionQuit           msgKey = "queueAction.quit"
+	msgQueueActionSleep          msgKey = "queueAction.sleep"
+	msgQueueActionHibernate      msgKey = "queueAction.hibernate"
+	msgQueueActionShutdown       msgKey = "queueAction.shutdown"
+	msgNotifyUpdateAvailable     msgKey = "notify.updateAvailable"
+	msgNotifyUpdateReleased      msgKey = "notify.updateReleased"
+
+	// 远程访问和API
+	msgServerReturnedStatus    msgKey = "http.serverReturned"
+	msgGenerateTokenFailed     msgKey = "auth.generateTokenFailed"
+	msgLocalOnly               msgKey = "auth.localOnly"
+	msgLocalTokenRequired      msgKey = "auth.localTokenRequired"
+	msgCrossOriginRequest      msgKey = "auth.crossOrigin"
+	msgTooManyLoginAttempts    msgKey = "auth.tooManyAttempts"
+	msgLoginRequired           msgKey = "auth.loginRequired"
+	msgLoginMethod             msgKey = "auth.loginMethod"
+	msgInvalidLoginRequest     msgKey = "auth.invalidLoginRequest"
+	msgInvalidCredentials      msgKey = "auth.invalidCredentials"
+	msgUnknownEndpoint         msgKey = "http.unknownEndpoint"
+	msgUnknownMethod           msgKey = "http.unknownMethod"
+	msgDesktopOnlyFFmpegParams msgKey = "http.desktopOnlyFFmpegParams"
+	msgUsePost                 msgKey = "http.usePost"
+	msgJSONContentType         msgKey = "http.jsonContentType"
+	msgUseGet                  msgKey = "http.useGet"
+	msgReadRequestFailed       msgKey = "http.readRequestFailed"
+	msgArgsMustBeArray         msgKey = "http.argsMustBeArray"
+	msgWrongArgCount           msgKey = "http.wrongArgCount"
+	msgInvalidArg              msgKey = "http.invalidArg"
+)
+
+// messages 各语言的消息文本，格式与 fmt.Sprintf 相同；错误参数使用 %v
+var messages = map[string]map[msgKey]string{
+	localeZhHans: {
+		msgTaskNotFound:            "未找到任务: %s",
+		msgDuplicateTaskID:         "任务ID重复: %s",
+		msgTasksLoadFailed:         "读取任务数据失败，本次运行添加的任务退出后不会保存: %v",
+		msgTranscodeNotFound:       "未找到转码任务: %s",
+		msgWaitingTaskNotFound:     "未找到指定的等待中的任务: %s",
+		msgCannotPause:             "任务当前状态为 %s，无法暂停",
+		msgCannotResume:            "任务当前状态为 %s，无法恢复",
+		msgInvalidPriority:         "无效的优先级: %s（可选 high、normal、low）",
+		msgTaskNoteTooLong:         "备注不能超过 %d 个字符",
+		msgInvalidTaskLabel:        "无效的颜色标签: %s",
+		msgParseTaskQueryFailed:    "解析任务查询失败: %v",
+		msgInvalidQueryTime:        "无效的时间: %s，应为 RFC3339 时间或 2006-01-02 格式的日期",
+		msgInvalidTaskQuery:        "无效的分页参数: offset 和 limit 不能为负数",
+		msgResumeFailed:            "恢复下载失败: %v",
+		msgDownloadLimitReached:    "已达到同时下载任务数上限，无法启动新任务",
+		msgStartWaitingFailed:      "启动等待任务失败: %v",
+		msgStartTranscodeFailed:    "启动转码任务失败: %v",
+		msgInvalidMagnet:           "无效的磁力链接: %s",
+		msgParseMagnetFailed:       "解析磁力链接失败: %v",
+		msgInvalidInfoHashLength:   "info-hash 长度无效: %s",
+		msgUnsupportedMultihash:    "不支持的 multihash: %s",
+		msgUnsupportedMagnetXT:     "不支持的 xt 参数: %s",
+		msgMagnetMetadataNotSaved:  "torrent 命令没有保存元数据就结束了",
+		msgMagnetMetadataTimeout:   "等待元数据超时",
+		msgInvalidTorrentURL:       "无效的种子网址: %s",
+		msgFetchTorrentFailed:      "下载种子文件失败: %v",
+		msgTorrentTooLarge:         "种子文件超过 %d MB",
+		msgNotTorrentFile:          "不是有效的种子文件: %v",
+		msgInvalidTracker:          "无效的 Tracker 地址: %s",
+		msgTorrentV2Only:           "只有 v2 哈希的种子暂不支持校验",
+		msgTorrentVerifyRunning:    "正在校验其他本地文件，请等待完成或取消",
+		msgTorrentVerifyNotRunning: "没有正在进行的校验",
+		msgTorrentVerifyCancelled:  "校验已取消",
+		msgFetchTrackersFailed:     "下载公共 Tracker 列表失败: %v",
+		msgInputFileNotFound:       "输入文件不存在: %s",
+		msgFileNotFound:            "文件不存在: %s",
+		msgUnknownPreset:           "未知的转码预设: %s",
+		msgTorrentNotFound:         "torrent命令不存在: %v",
+		msgFFmpegNotFound:          "ffmpeg不存在: %v",
+		msgFFprobeNotFound:         "ffprobe不存在: %v",
+		msgDiskSpaceFailed:         "获取磁盘空间信息失败: %v",
+		msgReadDownloadDirFailed:   "读取下载目录失败: %v",
+		msgCreateDirFailed:         "创建目录失败: %v",
+
+		msgHistoryNotFound:     "历史记录中没有该任务，只能删除已结束的任务: %s",
+		msgClearHistoryFailed:  "清空历史记录失败: %v",
+		msgDeleteHistoryFailed: "删除历史记录失败: %v",
+		msgRemovedTaskNotFound: "没有可以撤销的已删除任务（超过撤销期限或程序已重启）: %s",
+
+		msgInvalidLibraryFolder:     "无效的视频库文件夹: %s",
+		msgDuplicateLibraryFolder:   "视频库文件夹重复: %s",
+		msgLibraryFolderNotFound:    "视频库文件夹不存在: %s",
+		msgScraperDisabled:          "未启用元数据刮削，请在设置中选择数据源并填写API密钥",
+		msgScraperRequestFailed:     "查询 %s 失败: %v",
+		msgParseMatchFailed:         "解析匹配信息失败: %v",
+		msgEmptySearchQuery:         "搜索内容不能为空",
+		msgInvalidMetadataProvider:  "无效的元数据数据源: %s",
+		msgMetadataKeyRequired:      "使用 %s 需要填写API密钥",
+		msgInvalidOrganizeMode:      "无效的整理方式: %s",
+		msgInvalidOrganizeTemplate:  "无效的整理模板 %s: %s",
+		msgOrganizeNoTitle:          "无法识别标题",
+		msgOrganizeUnfinished:       "下载尚未完成",
+		msgOrganizeTargetExists:     "目标文件已存在",
+		msgDLNADownloads:            "下载目录",
+		msgDLNATranscodes:           "转码目录",
+		msgFileInUse:                "文件正在被下载或转码任务使用，无法删除: %s",
+		msgDeleteFileFailed:         "删除文件失败: %v",
+		msgPathOutsideLibrary:       "路径不在视频库中: %s",
+		msgExtractFrameFailed:       "截取视频画面失败: %v",
+		msgOpenFolderFailed:         "打开所在文件夹失败: %v",
+		msgPlayerNotFound:           "找不到 %s，请安装或在设置中指定路径: %v",
+		msgLaunchPlayerFailed:       "打开外部播放器失败: %v",
+		msgNoVideosToCheck:          "没有需要检查的视频",
+		msgIntegrityCheckRunning:    "完整性检查正在进行，请等待完成或取消后再开始",
+		msgIntegrityCheckNotRunning: "没有正在进行的完整性检查",
+		msgNoArchives:               "没有找到压缩包",
+		msgArchiveEncrypted:         "压缩包已加密，请手动解压",
+		msgExtractorNotFound:        "找不到解压程序，请安装 7-Zip 或在设置中指定路径: %v",
+		msgInvalidArchivePath:       "压缩包中的路径无效: %s",
+		msgUnrarUnsupported:         "unrar 不能解压 %s",
+		msgInvalidChecksumAlgorithm: "不支持的校验和算法: %s（可选 sha256、md5）",
+		msgNoFilesToHash:            "没有需要计算校验和的文件",
+		msgChecksumRunning:          "校验和计算正在进行中",
+		msgChecksumNotRunning:       "没有正在进行的校验和计算",
+		msgNoChecksums:              "没有可以导出的校验和，请先计算校验和",
+		msgWriteManifestFailed:      "写入校验和清单失败: %v",
+		msgParseLibraryQueryFailed:  "解析视频库查询失败: %v",
+		msgInvalidLibrarySort:       "无效的排序方式: %s",
+		msgInvalidResolution:        "无效的分辨率: %s",
+		msgInvalidLibraryPage:       "分页参数不能为负数",
+		msgSubtitleKeyRequired:      "搜索字幕需要在设置中填写 OpenSubtitles API 密钥",
+		msgSubtitleRequestFailed:    "查询 OpenSubtitles 失败: %v",
+		msgSaveSubtitleFailed:       "保存字幕失败: %v",
+		msgReadSubtitleTracksFailed: "读取字幕轨道失败: %v",
+		msgSubtitleTrackNotFound:    "字幕轨道不存在: %d",
+		msgImageSubtitle:            "%s 是图片字幕，不能提取为文本格式",
+		msgInvalidSubtitleFormat:    "无效的字幕格式: %s",
+		msgConvertSubtitleFailed:    "转换字幕失败: %v",
+
+		msgInvalidConcurrentDownloads:  "同时下载任务数至少为1",
+		msgInvalidConcurrentTranscodes: "同时转码任务数至少为1",
+		msgInvalidSpeedLimit:           "限速不能为负数",
+		msgInvalidTheme:                "无效的主题: %s",
+		msgInvalidLanguage:             "无效的语言: %s",
+		msgInvalidLogLevel:             "无效的日志级别: %s",
+		msgInvalidPort:                 "无效的端口: %d",
+		msgRemoteAccessNeedsPassword:   "开启局域网访问需要设置访问密码",
+		msgInvalidSessionHours:         "登录有效期至少为1小时",
+		msgInvalidStaticCacheHours:     "缓存时间不能为负数",
+		msgInvalidMaxUploadSize:        "上传大小上限不能为负数",
+		msgInvalidMinFreeSpace:         "磁盘剩余空间下限不能为负数",
+		msgInvalidThermalAction:        "无效的温度保护处理方式: %s",
+		msgInvalidThermalLimits:        "温度上限和系统负载上限不能为负数，系统负载上限不能超过100",
+		msgInvalidHistoryRetention:     "历史记录保留天数和条数不能为负数",
+		msgInvalidLibraryScan:          "视频库扫描层数和最小文件大小不能为负数",
+		msgInvalidPath:                 "无效的路径 %s: %v",
+		msgToolNotFound:                "程序不存在: %s",
+		msgToolsDirNotFound:            "工具目录不存在: %s",
+		msgParseSettingsFailed:         "解析设置失败: %v",
+		msgDesktopOnlySetting:          "设置 %s 指定本机运行的程序，只能在桌面端修改",
+		msgSaveSettingsFailed:          "保存设置失败: %v",
+		msgAutostartFailed:             "设置已保存，但修改开机自动启动失败: %v",
+		msgAutostartHomeDirFailed:      "获取用户目录失败: %v",
+		msgAutostartConfigDirFailed:    "获取配置目录失败: %v",
+		msgAutostartRegistryFailed:     "打开注册表失败: %v",
+		msgAutostartRemoveFailed:       "移除开机自动启动失败: %v",
+		msgAutostartRegisterFailed:     "注册开机自动启动失败: %v",
+		msgRemoteAccessFailed:          "设置已保存，但开启局域网访问失败: %v",
+		msgInvalidDLNAPort:             "无效的DLNA端口（不能与API端口相同）: %d",
+		msgInvalidPlayer:               "无效的外部播放器: %s",
+		msgInvalidTrackerListURL:       "无效的 Tracker 列表地址: %s",
+		msgInvalidSubtitleLanguage:     "无效的字幕语言: %s",
+		msgDLNAFailed:                  "设置已保存，但启动DLNA服务失败: %v",
+		msgInvalidWebhookURL:           "无效的Webhook地址: %s",
+		msgInvalidWebhookFormat:        "无效的Webhook格式: %s",
+		msgInvalidWebhookEvent:         "无效的Webhook事件: %s",
+		msgSendWebhookFailed:           "发送Webhook失败: %v",
+		msgNotificationFailed:          "显示系统通知失败: %v",
+
+		msgPortableDataDir:        "便携模式下不能修改数据目录",
+		msgCreateDefaultDirFailed: "创建默认数据目录失败: %v",
+		msgRestoreDataDirFailed:   "恢复默认数据目录失败: %v",
+		msgInvalidDataDir:         "无效的目录: %v",
+		msgCreateDataDirFailed:    "创建数据目录失败: %v",
+		msgEnvDataDir:             "数据目录由环境变量 SEEDPARSER_DATA_DIR 指定，不能在程序中修改",
+		msgDataDirNotEmpty:        "新的数据目录必须是空目录: %s",
+		msgDataDirNotWritable:     "无法写入新的数据目录: %v",
+		msgMigrateDataDirFailed:   "迁移数据失败，继续使用原数据目录: %v",
+		msgDataDirMigrated:        "数据已复制到新的数据目录，原目录中的文件可以在确认无误后删除",
+
+		msgNoUpdateKey:          "此版本未配置更新签名公钥，请从发布页面手动下载",
+		msgInvalidUpdateKey:     "无效的更新签名公钥",
+		msgNoUpdateAsset:        "最新版本没有当前平台的安装包，请从发布页面手动下载",
+		msgNoUpdateSignature:    "安装包没有签名，请从发布页面手动下载",
+		msgDownloadSigFailed:    "下载签名失败: %v",
+		msgInvalidSignature:     "无效的签名文件: %v",
+		msgDownloadUpdateFailed: "下载安装包失败: %v",
+		msgVerifyUpdateFailed:   "安装包签名校验失败: %v",
+		msgSaveUpdateFailed:     "保存安装包失败: %v",
+		msgCheckUpdateFailed:    "检查更新失败: %v",
+		msgParseReleaseFailed:   "解析版本信息失败: %v",
+		msgUpdateWindowsOnly:    "自动安装只支持 Windows，请从发布页面下载新版本",
+		msgUpdateHeadless:       "服务器模式不支持自动安装，请停止服务后手动更新",
+		msgUpdateInProgress:     "正在下载更新",
+		msgAlreadyLatest:        "已是最新版本 %s",
+		msgStartInstallerFailed: "启动安装程序失败: %v",
+
+		msgPluginNoName:         "插件清单缺少 name",
+		msgPluginNoCommand:      "插件清单缺少 command",
+		msgPluginInvalidTimeout: "无效的超时: %d",
+		msgPluginInvalidHook:    "无效的钩子: %s",
+		msgPluginDuplicateName:  "插件名称重复: %s",
+		msgPluginTimeout:        "运行超过 %s，已终止",
+
+		msgInvalidQuitAction: "无效的退出操作: %s",
+		msgQuitHeadless:      "服务器模式下不能通过界面退出",
+
+		msgInvalidQueueAction:   "无效的操作: %s",
+		msgHibernateUnsupported: "当前系统不支持手动休眠",
+
+		msgNotifyDownloadCompleted:   "下载完成",
+		msgNotifyDownloadInterrupted: "下载中断",
+		msgNotifyDownloadRequeued:    "%s 下载异常结束，已重新加入等待队列",
+		msgNotifyTranscodeCompleted:  "转码完成",
+		msgNotifyTranscodeFailed:     "转码失败",
+		msgNotifyEnabled:             "通知已开启，任务完成或失败时会在这里提醒你",
+		msgNotifyExtractCompleted:    "解压完成",
+		msgNotifyExtractFailed:       "解压失败",
+		msgNotifyExtractFailedCount:  "%s: %d 个压缩包解压失败",
+		msgNotifyChecksumsCompleted:  "校验和计算完成",
+		msgNotifyChecksumsHashed:     "计算了 %d 个文件的校验和",
+		msgNotifyChecksumsReadFailed: "计算了 %d 个文件的校验和，%d 个文件读取失败",
+		msgNotifyIntegrityCompleted:  "完整性检查完成",
+		msgNotifyIntegrityOK:         "检查了 %d 个视频，没有发现问题",
+		msgNotifyIntegrityProblems:   "检查了 %d 个视频，%d 个可能已损坏",
+		msgNotifyDiskLow:             "磁盘空间不足",
+		msgNotifyDiskLowDownloads:    "%s 所在磁盘只剩 %s，已暂停下载",
+		msgNotifyDiskLowTranscodes:   "%s 所在磁盘只剩 %s，已暂停转码",
+		msgNotifyQueueIdle:           "所有任务已结束",
+		msgNotifyQueueCountdown:      "将在 %d 秒后%s，可以在程序中取消",
+		msgNotifyQueueActionFailed:   "%s失败",
+		msgQueueActionQuit:           "退出程序",
+		msgQueueActionSleep:          "睡眠",
+		msgQueueActionHibernate:      "休眠",
+		msgQueueActionShutdown:       "关机",
+		msgNotifyUpdateAvailable:     "发现新版本",
+		msgNotifyUpdateReleased:      "SeedParser %s 已发布，可以在设置中更新",
+
+		msgServerReturnedStatus:    "服务器返回 %s",
+		msgCreateFileFailed:        "创建文件失败: %v",
+		msgUploadNotFound:          "上传不存在或已过期: %s",
+		msgUploadOffsetMismatch:    "分块位置 %d 与已接收的 %d 字节不连续",
+		msgUploadChunkTooLarge:     "分块过大或超出文件大小（单个分块最大 %d MB）",
+		msgUploadChecksumMismatch:  "位置 %d 的数据校验和不匹配，请重新上传",
+		msgUploadChecksumRequired:  "缺少整个文件的 SHA-256，需要 64 位十六进制的校验值",
+		msgUploadChunkConflict:     "位置 %d 的分块与已接收的内容不同，请重新开始上传",
+		msgUploadIncomplete:        "上传未完成：已接收 %d / %d 字节",
+		msgNotEnoughSpace:          "磁盘空间不足：需要 %s，可用 %s",
+		msgUploadNotReady:          "文件还在上传中，请等待上传完成后再转码: %s",
+		msgUploadFailed:            "文件上传失败，请重新上传: %s",
+		msgUploadTooLarge:          "文件过大：%s，上传大小上限为 %s",
+		msgUploadUnsupportedType:   "不支持的文件类型: %s，只能上传视频文件（%s）",
+		msgUploadNotVideo:          "文件内容不是视频（识别为 %s）: %s",
+		msgDialogHeadless:          "服务器模式下不能打开文件选择对话框，请使用上传",
+		msgOpenDialogFailed:        "打开文件选择对话框失败: %v",
+		msgReadFileFailed:          "读取文件失败: %v",
+		msgGenerateTokenFailed:     "生成随机令牌失败: %v",
+		msgLocalOnly:               "未设置访问密码，只允许本机访问",
+		msgLocalTokenRequired:      "本机访问需要本机API令牌（数据目录中的 api-token.local）",
+		msgCrossOriginRequest:      "拒绝来自其他网站的请求",
+		msgTooManyLoginAttempts:    "登录失败次数过多，请稍后再试",
+		msgLoginRequired:           "需要登录",
+		msgLoginMethod:             "请使用POST登录",
+		msgInvalidLoginRequest:     "无效的登录请求",
+		msgInvalidCredentials:      "用户名或密码错误",
+		msgUnknownEndpoint:         "未知的接口: %s",
+		msgUnknownMethod:           "未知的方法: %s",
+		msgDesktopOnlyFFmpegParams: "自定义 FFmpeg 参数只能在桌面端使用",
+		msgUsePost:                 "请使用POST调用: %s",
+		msgJSONContentType:         "请求的 Content-Type 必须为 application/json",
+		msgUseGet:                  "请使用GET访问: %s",
+		msgReadRequestFailed:       "读取请求失败: %v",
+		msgArgsMustBeArray:         "请求体必须是参数数组: %v",
+		msgWrongArgCount:           "%s 需要 %d 个参数，收到 %d 个",
+		msgInvalidArg:              "第 %d 个参数无效: %v",
+	},
+	localeEn: {
+		msgTaskNotFound:            "Task not found: %s",
+		msgDuplicateTaskID:         "Duplicate task ID: %s",
+		msgTasksLoadFailed:         "Failed to load saved tasks; tasks added in this session will not be kept after exit: %v",
+		msgTranscodeNotFound:       "Transcode task not found: %s",
+		msgWaitingTaskNotFound:     "Waiting task not found: %s",
+		msgCannotPause:             "Cannot pause a task that is %s",
+		msgCannotResume:            "Cannot resume a task that is %s",
+		msgInvalidPriority:         "Invalid priority: %s (use high, normal or low)",
+		msgTaskNoteTooLong:         "Note cannot be longer than %d characters",
+		msgInvalidTaskLabel:        "Invalid color label: %s",
+		msgParseTaskQueryFailed:    "Failed to parse task query: %v",
+		msgInvalidQueryTime:        "Invalid time: %s, expected an RFC3339 time or a 2006-01-02 date",
+		msgInvalidTaskQuery:        "Invalid paging: offset and limit cannot be negative",
+		msgResumeFailed:            "Failed to resume download: %v",
+		msgDownloadLimitReached:    "The concurrent download limit has been reached",
+		msgStartWaitingFailed:      "Failed to start waiting task: %v",
+		msgStartTranscodeFailed:    "Failed to start transcode task: %v",
+		msgInvalidMagnet:           "Invalid magnet link: %s",
+		msgParseMagnetFailed:       "Failed to parse magnet link: %v",
+		msgInvalidInfoHashLength:   "Invalid info-hash length: %s",
+		msgUnsupportedMultihash:    "Unsupported multihash: %s",
+		msgUnsupportedMagnetXT:     "Unsupported xt parameter: %s",
+		msgMagnetMetadataNotSaved:  "The torrent command exited without saving the metadata",
+		msgMagnetMetadataTimeout:   "Timed out waiting for the metadata",
+		msgInvalidTorrentURL:       "Invalid torrent URL: %s",
+		msgFetchTorrentFailed:      "Failed to download the torrent file: %v",
+		msgTorrentTooLarge:         "The torrent file is larger than %d MB",
+		msgNotTorrentFile:          "Not a valid torrent file: %v",
+		msgInvalidTracker:          "Invalid tracker URL: %s",
+		msgTorrentV2Only:           "Torrents with only v2 hashes cannot be verified yet",
+		msgTorrentVerifyRunning:    "Another verification is running, wait for it to finish or cancel it",
+		msgTorrentVerifyNotRunning: "No verification is running",
+		msgTorrentVerifyCancelled:  "Verification cancelled",
+		msgFetchTrackersFailed:     "Failed to download the public tracker list: %v",
+		msgInputFileNotFound:       "Input file does not exist: %s",
+		msgFileNotFound:            "File does not exist: %s",
+		msgUnknownPreset:           "Unknown transcode preset: %s",
+		msgTorrentNotFound:         "torrent command not found: %v",
+		msgFFmpegNotFound:          "ffmpeg not found: %v",
+		msgFFprobeNotFound:         "ffprobe not found: %v",
+		msgDiskSpaceFailed:         "Failed to get disk space: %v",
+		msgReadDownloadDirFailed:   "Failed to read download directory: %v",
+		msgCreateDirFailed:         "Failed to create directory: %v",
+
+		msgHistoryNotFound:     "Task is not in history, only finished tasks can be deleted: %s",
+		msgClearHistoryFailed:  "Failed to clear history: %v",
+		msgDeleteHistoryFailed: "Failed to delete history entry: %v",
+		msgRemovedTaskNotFound: "No removed task to restore (the undo period has passed or the app was restarted): %s",
+
+		msgInvalidLibraryFolder:     "Invalid library folder: %s",
+		msgDuplicateLibraryFolder:   "Duplicate library folder: %s",
+		msgLibraryFolderNotFound:    "Library folder not found: %s",
+		msgScraperDisabled:          "Metadata scraping is disabled, choose a provider and enter an API key in settings",
+		msgScraperRequestFailed:     "Failed to query %s: %v",
+		msgParseMatchFailed:         "Failed to parse match: %v",
+		msgEmptySearchQuery:         "Search query cannot be empty",
+		msgInvalidMetadataProvider:  "Invalid metadata provider: %s",
+		msgMetadataKeyRequired:      "%s requires an API key",
+		msgInvalidOrganizeMode:      "Invalid organize mode: %s",
+		msgInvalidOrganizeTemplate:  "Invalid organize template %s: %s",
+		msgOrganizeNoTitle:          "Title could not be recognized",
+		msgOrganizeUnfinished:       "Download is not finished",
+		msgOrganizeTargetExists:     "Target file already exists",
+		msgDLNADownloads:            "Downloads",
+		msgDLNATranscodes:           "Transcoded",
+		msgFileInUse:                "The file is in use by a download or transcode task and cannot be deleted: %s",
+		msgDeleteFileFailed:         "Failed to delete the file: %v",
+		msgPathOutsideLibrary:       "The path is not in the video library: %s",
+		msgExtractFrameFailed:       "Failed to extract a video frame: %v",
+		msgOpenFolderFailed:         "Failed to open the containing folder: %v",
+		msgPlayerNotFound:           "Cannot find %s, install it or set its path in the settings: %v",
+		msgLaunchPlayerFailed:       "Failed to open the external player: %v",
+		msgNoVideosToCheck:          "There are no videos to check",
+		msgIntegrityCheckRunning:    "An integrity check is already running, wait for it to finish or cancel it first",
+		msgIntegrityCheckNotRunning: "No integrity check is running",
+		msgNoArchives:               "No archives found",
+		msgArchiveEncrypted:         "The archive is encrypted, extract it manually",
+		msgExtractorNotFound:        "Extraction program not found, install 7-Zip or set its path in settings: %v",
+		msgInvalidArchivePath:       "Invalid path in the archive: %s",
+		msgUnrarUnsupported:         "unrar cannot extract %s",
+		msgInvalidChecksumAlgorithm: "Unsupported checksum algorithm: %s (use sha256 or md5)",
+		msgNoFilesToHash:            "No files to compute checksums for",
+		msgChecksumRunning:          "A checksum computation is already running",
+		msgChecksumNotRunning:       "No checksum computation is running",
+		msgNoChecksums:              "No checksums to export, compute checksums first",
+		msgWriteManifestFailed:      "Failed to write the checksum manifest: %v",
+		msgParseLibraryQueryFailed:  "Failed to parse library query: %v",
+		msgInvalidLibrarySort:       "Invalid sort order: %s",
+		msgInvalidResolution:        "Invalid resolution: %s",
+		msgInvalidLibraryPage:       "Offset and limit cannot be negative",
+		msgSubtitleKeyRequired:      "Searching subtitles requires an OpenSubtitles API key in settings",
+		msgSubtitleRequestFailed:    "OpenSubtitles request failed: %v",
+		msgSaveSubtitleFailed:       "Failed to save subtitle: %v",
+		msgReadSubtitleTracksFailed: "Failed to read subtitle tracks: %v",
+		msgSubtitleTrackNotFound:    "Subtitle track does not exist: %d",
+		msgImageSubtitle:            "%s is an image-based subtitle and cannot be extracted as text",
+		msgInvalidSubtitleFormat:    "Invalid subtitle format: %s",
+		msgConvertSubtitleFailed:    "Failed to convert subtitle: %v",
+
+		msgInvalidConcurrentDownloads:  "Concurrent downloads must be at least 1",
+		msgInvalidConcurrentTranscodes: "Concurrent transcodes must be at least 1",
+		msgInvalidSpeedLimit:           "Speed limits cannot be negative",
+		msgInvalidTheme:                "Invalid theme: %s",
+		msgInvalidLanguage:             "Invalid language: %s",
+		msgInvalidLogLevel:             "Invalid log level: %s",
+		msgInvalidPort:                 "Invalid port: %d",
+		msgRemoteAccessNeedsPassword:   "LAN access requires a password",
+		msgInvalidSessionHours:         "Login sessions must last at least 1 hour",
+		msgInvalidStaticCacheHours:     "Cache duration cannot be negative",
+		msgInvalidMaxUploadSize:        "Upload size limit cannot be negative",
+		msgInvalidMinFreeSpace:         "Minimum free disk space cannot be negative",
+		msgInvalidThermalAction:        "Invalid thermal action: %s",
+		msgInvalidThermalLimits:        "Temperature and system load limits cannot be negative, and the load limit cannot exceed 100",
+		msgInvalidHistoryRetention:     "History retention days and entries cannot be negative",
+		msgInvalidLibraryScan:          "Library scan depth and minimum file size cannot be negative",
+		msgInvalidPath:                 "Invalid path %s: %v",
+		msgToolNotFound:                "Program does not exist: %s",
+		msgToolsDirNotFound:            "Tools directory does not exist: %s",
+		msgParseSettingsFailed:         "Failed to parse settings: %v",
+		msgDesktopOnlySetting:          "The %s setting selects a program to run on this computer and can only be changed in the desktop app",
+		msgSaveSettingsFailed:          "Failed to save settings: %v",
+		msgAutostartFailed:             "Settings saved, but changing launch at login failed: %v",
+		msgAutostartHomeDirFailed:      "Failed to get the home directory: %v",
+		msgAutostartConfigDirFailed:    "Failed to get the config directory: %v",
+		msgAutostartRegistryFailed:     "Failed to open the registry: %v",
+		msgAutostartRemoveFailed:       "Failed to remove launch at login: %v",
+		msgAutostartRegisterFailed:     "Failed to register launch at login: %v",
+		msgRemoteAccessFailed:          "Settings saved, but enabling LAN access failed: %v",
+		msgInvalidDLNAPort:             "Invalid DLNA port (must differ from the API port): %d",
+		msgInvalidPlayer:               "Invalid external player: %s",
+		msgInvalidTrackerListURL:       "Invalid tracker list URL: %s",
+		msgInvalidSubtitleLanguage:     "Invalid subtitle language: %s",
+		msgDLNAFailed:                  "Settings saved, but starting the DLNA server failed: %v",
+		msgInvalidWebhookURL:           "Invalid webhook URL: %s",
+		msgInvalidWebhookFormat:        "Invalid webhook format: %s",
+		msgInvalidWebhookEvent:         "Invalid webhook event: %s",
+		msgSendWebhookFailed:           "Failed to send webhook: %v",
+		msgNotificationFailed:          "Failed to show notification: %v",
+
+		msgPortableDataDir:        "The data directory cannot be changed in portable mode",
+		msgCreateDefaultDirFailed: "Failed to create default data directory: %v",
+		msgRestoreDataDirFailed:   "Failed to restore default data directory: %v",
+		msgInvalidDataDir:         "Invalid directory: %v",
+		msgCreateDataDirFailed:    "Failed to create data directory: %v",
+		msgEnvDataDir:             "The data directory is set by the SEEDPARSER_DATA_DIR environment variable and cannot be changed here",
+		msgDataDirNotEmpty:        "The new data directory must be empty: %s",
+		msgDataDirNotWritable:     "Cannot write to the new data directory: %v",
+		msgMigrateDataDirFailed:   "Failed to move the data; the current data directory is still in use: %v",
+		msgDataDirMigrated:        "The data was copied to the new data directory; the files in the old directory can be deleted once you have checked it",
+
+		msgNoUpdateKey:          "This build has no update signing key, please download the update from the releases page",
+		msgInvalidUpdateKey:     "Invalid update signing key",
+		msgNoUpdateAsset:        "The latest release has no installer for this platform, please download it from the releases page",
+		msgNoUpdateSignature:    "The installer is not signed, please download it from the releases page",
+		msgDownloadSigFailed:    "Failed to download signature: %v",
+		msgInvalidSignature:     "Invalid signature file: %v",
+		msgDownloadUpdateFailed: "Failed to download installer: %v",
+		msgVerifyUpdateFailed:   "Installer signature verification failed: %v",
+		msgSaveUpdateFailed:     "Failed to save installer: %v",
+		msgCheckUpdateFailed:    "Failed to check for updates: %v",
+		msgParseReleaseFailed:   "Failed to parse release info: %v",
+		msgUpdateWindowsOnly:    "Automatic install is only supported on Windows, please download the new version from the releases page",
+		msgUpdateHeadless:       "Automatic install is not supported in server mode, please stop the server and update manually",
+		msgUpdateInProgress:     "An update is already downloading",
+		msgAlreadyLatest:        "Already on the latest version %s",
+		msgStartInstallerFailed: "Failed to start installer: %v",
+
+		msgPluginNoName:         "Plugin manifest is missing name",
+		msgPluginNoCommand:      "Plugin manifest is missing command",
+		msgPluginInvalidTimeout: "Invalid timeout: %d",
+		msgPluginInvalidHook:    "Invalid hook: %s",
+		msgPluginDuplicateName:  "Duplicate plugin name: %s",
+		msgPluginTimeout:        "Killed after running for %s",
+
+		msgInvalidQuitAction: "Invalid quit action: %s",
+		msgQuitHeadless:      "Cannot quit from the interface in server mode",
+
+		msgInvalidQueueAction:   "Invalid action: %s",
+		msgHibernateUnsupported: "Hibernate cannot be triggered on this system",
+
+		msgNotifyDownloadCompleted:   "Download complete",
+		msgNotifyDownloadInterrupted: "Download interrupted",
+		msgNotifyDownloadRequeued:    "%s stopped unexpectedly and was put back in the queue",
+		msgNotifyTranscodeCompleted:  "Transcode complete",
+		msgNotifyTranscodeFailed:     "Transcode failed",
+		msgNotifyEnabled:             "Notifications are on. You will be notified here when tasks finish or fail",
+		msgNotifyExtractCompleted:    "Extraction complete",
+		msgNotifyExtractFailed:       "Extraction failed",
+		msgNotifyExtractFailedCount:  "%s: %d archives failed to extract",
+		msgNotifyChecksumsCompleted:  "Checksums complete",
+		msgNotifyChecksumsHashed:     "Computed checksums of %d files",
+		msgNotifyChecksumsReadFailed: "Computed checksums of %d files, %d files could not be read",
+		msgNotifyIntegrityCompleted:  "Integrity check complete",
+		msgNotifyIntegrityOK:         "Checked %d videos, no problems found",
+		msgNotifyIntegrityProblems:   "Checked %d videos, %d may be corrupted",
+		msgNotifyDiskLow:             "Low disk space",
+		msgNotifyDiskLowDownloads:    "The disk of %s has only %s left, downloads are paused",
+		msgNotifyDiskLowTranscodes:   "The disk of %s has only %s left, transcodes are paused",
+		msgNotifyQueueIdle:           "All tasks finished",
+		msgNotifyQueueCountdown:      "Will %[2]s in %[1]d seconds, you can cancel this in SeedParser",
+		msgNotifyQueueActionFailed:   "Failed to %s",
+		msgQueueActionQuit:           "quit",
+		msgQueueActionSleep:          "sleep",
+		msgQueueActionHibernate:      "hibernate",
+		msgQueueActionShutdown:       "shut down",
+		msgNotifyUpdateAvailable:     "New version available",
+		msgNotifyUpdateReleased:      "SeedParser %s has been released, you can update it in Settings",
+
+		msgServerReturnedStatus:    "Server returned %s",
+		msgCreateFileFailed:        "Failed to create file: %v",
+		msgUploadNotFound:          "Upload not found or expired: %s",
+		msgUploadOffsetMismatch:    "Chunk offset %d does not match the %d bytes received",
+		msgUploadChunkTooLarge:     "Chunk is too large or exceeds the file size (max %d MB per chunk)",
+		msgUploadChecksumMismatch:  "Checksum mismatch at offset %d, please upload again",
+		msgUploadChecksumRequired:  "The SHA-256 of the whole file is required as 64 hexadecimal characters",
+		msgUploadChunkConflict:     "The chunk at offset %d differs from the data already received; restart the upload",
+		msgUploadIncomplete:        "Upload incomplete: received %d of %d bytes",
+		msgNotEnoughSpace:          "Not enough disk space: %s needed, %s available",
+		msgUploadNotReady:          "The file is still being uploaded, wait for it to finish before transcoding: %s",
+		msgUploadFailed:            "The upload failed, upload the file again: %s",
+		msgUploadTooLarge:          "File is too large: %s, the upload limit is %s",
+		msgUploadUnsupportedType:   "Unsupported file type: %s, only video files can be uploaded (%s)",
+		msgUploadNotVideo:          "The file content is not a video (detected as %s): %s",
+		msgDialogHeadless:          "Cannot open a file dialog in server mode, upload the file instead",
+		msgOpenDialogFailed:        "Failed to open the file dialog: %v",
+		msgReadFileFailed:          "Failed to read the file: %v",
+		msgGenerateTokenFailed:     "Failed to generate random token: %v",
+		msgLocalOnly:               "No access password is set, only local access is allowed",
+		msgLocalTokenRequired:      "Local access requires the local API token (api-token.local in the data directory)",
+		msgCrossOriginRequest:      "Requests from other websites are not allowed",
+		msgTooManyLoginAttempts:    "Too many failed login attempts, please try again later",
+		msgLoginRequired:           "Login required",
+		msgLoginMethod:             "Please use POST to log in",
+		msgInvalidLoginRequest:     "Invalid login request",
+		msgInvalidCredentials:      "Incorrect username or password",
+		msgUnknownEndpoint:         "Unknown endpoint: %s",
+		msgUnknownMethod:           "Unknown method: %s",
+		msgDesktopOnlyFFmpegParams: "Custom FFmpeg parameters can only be used in the desktop app",
+		msgUsePost:                 "Please use POST to call: %s",
+		msgJSONContentType:         "Request Content-Type must be application/json",
+		msgUseGet:                  "Please use GET to access: %s",
+		msgReadRequestFailed:       "Failed to read request: %v",
+		msgArgsMustBeArray:         "Request body must be an array of arguments: %v",
+		msgWrongArgCount:           "%s takes %d arguments, got %d",
+		msgInvalidArg:              "Argument %d is invalid: %v",
+	},
+}
+
+// validLocale 判断是否支持该语言
+func validLocale(locale string) bool {
+	_, ok := messages[locale]
+	return ok
+}
+
+// currentLocale 返回设置中的语言，未设置时使用默认语言
+func currentLocale() string {
+	if locale := currentSettings().Language; validLocale(locale) {
+		return locale
+	}
+	return defaultLocale
+}
+
+// translate 按指定语言格式化消息，缺少翻译时依次使用默认语言和消息键
+func translate(locale string, key msgKey, args ...interface{}) string {
+	format, ok := messages[locale][key]
+	if !ok {
+		if format, ok = messages[defaultLocale][key]; !ok {
+			format = string(key)
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// tr 按当前设置的语言格式化消息
+func tr(key msgKey, args ...interface{}) string {
+	return translate(currentLocale(), key, args...)
+}
+
+// localizedError 带消息键的错误，Error() 按当前设置的语言输出，
+// 因此修改语言后返回给前端的错误立即使用新语言
+type localizedError struct {
+	key  msgKey
+	args []interface{}
+}
+
+func (e *localizedError) Error() string {
+	return tr(e.key, e.args...)
+}
+
+// Unwrap 返回参数中的第一个错误，与 fmt.Errorf 的 %w 相同，errors.Is/As 可以继续使用
+func (e *localizedError) Unwrap() error {
+	for _, arg := range e.args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorf 创建本地化错误，用法与 fmt.Errorf 相同，格式由消息键决定
+func errorf(key msgKey, args ...interface{}) error {
+	return &localizedError{key: key, args: args}
+}
+
+// errorKey 返回错误对应的消息键，不是本地化错误时返回空字符串
+func errorKey(err error) msgKey {
+	var le *localizedError
+	if errors.As(err, &le) {
+		return le.key
+	}
+	return ""
+}
@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+// 错误消息在每种语言中都有翻译
+func TestMessagesTranslated(t *testing.T) {
+	for key := range messages[defaultLocale] {
+		for _, locale := range supportedLocales {
+			if _, ok := messages[locale][key]; !ok {
+				t.Errorf("%s 没有 %s 的翻译", key, locale)
+			}
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventIntegrityProgress 完整性检查的进度，负载为 IntegrityCheck；进度按 progressEventInterval 节流，每个文件检查完立即推送
+const EventIntegrityProgress = "library:integrity"
+
+// 完整性检查和单个文件的状态
+const (
+	integrityRunning   = "running"
+	integrityCompleted = "completed"
+	integrityCancelled = "cancelled"
+
+	integrityPending  = "pending"
+	integrityChecking = "checking"
+	// integrityOK 解码整个文件没有错误
+	integrityOK = "ok"
+	// integrityCorrupt 解码时报告了错误，文件可能损坏或不完整
+	integrityCorrupt = "corrupt"
+	// integrityFailed 无法检查，例如文件无法打开
+	integrityFailed = "failed"
+	// integritySkipped 检查被取消，文件未检查
+	integritySkipped = "skipped"
+)
+
+// integrityMaxErrors 每个文件最多保留的错误信息条数，损坏严重的文件可能输出上万行
+const integrityMaxErrors = 20
+
+// IntegrityResult is the integrity check result of a single file
+// IntegrityResult 一个文件的检查结果
+type IntegrityResult struct {
+	Path    string `json:"path"`
+	RelPath string `json:"relPath"`
+	Root    string `json:"root"`
+	// 状态：pending, checking, ok, corrupt, failed, skipped
+	Status string `json:"status"`
+	// 检查中的文件的进度（0-100）
+	Progress float64 `json:"progress,omitempty"`
+	// ffmpeg 报告的错误总数
+	ErrorCount int `json:"errorCount,omitempty"`
+	// 去重后的前 integrityMaxErrors 条错误信息
+	Errors []string `json:"errors,omitempty"`
+	// 检查用时（秒）
+	Elapsed float64 `json:"elapsed,omitempty"`
+}
+
+// IntegrityCheck is a library integrity check run
+// IntegrityCheck 一次完整性检查
+type IntegrityCheck struct {
+	// 状态：running, completed, cancelled
+	Status    string            `json:"status"`
+	StartTime time.Time         `json:"startTime"`
+	EndTime   time.Time         `json:"endTime,omitempty"`
+	Files     []IntegrityResult `json:"files"`
+	Checked   int               `json:"checked"`
+	Corrupt   int               `json:"corrupt"`
+	Failed    int               `json:"failed"`
+}
+
+// integrityChecker 同一时间只运行一次检查，保留最近一次检查的结果
+type integrityChecker struct {
+	mu     sync.Mutex
+	check  *IntegrityCheck
+	cancel context.CancelFunc
+}
+
+// snapshot 返回当前检查的副本，没有检查过时返回 nil
+func (c *integrityChecker) snapshot() *IntegrityCheck {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.check == nil {
+		return nil
+	}
+	check := *c.check
+	check.Files = append([]IntegrityResult(nil), c.check.Files...)
+	return &check
+}
+
+// update 修改第 i 个文件的结果
+func (c *integrityChecker) update(i int, update func(result *IntegrityResult)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	update(&c.check.Files[i])
+}
+
+// integrityOutput 收集 ffmpeg 的错误输出，相同的错误只保留一条
+type integrityOutput struct {
+	count  int
+	errors []string
+	seen   map[string]bool
+}
+
+// add 记录一行错误输出
+func (o *integrityOutput) add(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	o.count++
+	if len(o.errors) >= integrityMaxErrors || o.seen[line] {
+		return
+	}
+	if o.seen == nil {
+		o.seen = make(map[string]bool)
+	}
+	o.seen[line] = true
+	o.errors = append(o.errors, line)
+}
+
+// checkFileIntegrity 完整解码文件并丢弃输出（ffmpeg -v error -f null），解码错误写到标准错误；
+// 通过 -progress 读取解码到的位置，duration 大于0时据此计算进度
+func checkFileIntegrity(ctx context.Context, ffmpegPath, path string, duration float64, progress func(float64)) (integrityOutput, error) {
+	var output integrityOutput
+	cmd := exec.CommandContext(ctx, ffmpegPath, "-nostdin", "-v", "error", "-i", path, "-map", "0:v?", "-map", "0:a?",
+		"-f", "null", "-progress", "pipe:1", "-nostats", "-")
+	hideWindow(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return output, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return output, err
+	}
+	if err := cmd.Start(); err != nil {
+		return output, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			output.add(scanner.Text())
+		}
+	}()
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "out_time_us=")
+		if !ok || duration <= 0 {
+			continue
+		}
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil && us > 0 {
+			progress(min(float64(us)/1e6/duration*100, 100))
+		}
+	}
+	<-done
+	return output, cmd.Wait()
+}
+
+// runIntegrityCheck 逐个检查文件，每个文件检查完推送进度；取消后未检查的文件标记为 skipped
+func (a *App) runIntegrityCheck(ctx context.Context, ffmpegPath string, videos []LibraryVideo) {
+	checker := a.integrity
+	emit := func(force bool) {
+		if a.throttle.allow(EventIntegrityProgress, force) {
+			a.emitEvent(EventIntegrityProgress, checker.snapshot())
+		}
+	}
+	for i, video := range videos {
+		if ctx.Err() != nil {
+			break
+		}
+		checker.update(i, func(r *IntegrityResult) { r.Status = integrityChecking })
+		emit(true)
+
+		started := time.Now()
+		var duration float64
+		if video.Metadata != nil {
+			duration = video.Metadata.Duration
+		}
+		output, err := checkFileIntegrity(ctx, ffmpegPath, video.Path, duration, func(progress float64) {
+			checker.update(i, func(r *IntegrityResult) { r.Progress = progress })
+			emit(false)
+		})
+		if ctx.Err() != nil {
+			// 取消时 ffmpeg 被终止，结果不完整
+			checker.update(i, func(r *IntegrityResult) { r.Status, r.Progress = integritySkipped, 0 })
+			break
+		}
+
+		checker.mu.Lock()
+		result := &checker.check.Files[i]
+		result.Progress = 0
+		result.Elapsed = time.Since(started).Round(time.Millisecond).Seconds()
+		result.ErrorCount, result.Errors = output.count, output.errors
+		switch {
+		case output.count > 0:
+			result.Status = integrityCorrupt
+			checker.check.Corrupt++
+		case err != nil:
+			// 没有错误输出但 ffmpeg 异常退出，无法判断文件是否完好
+			result.Status = integrityFailed
+			result.ErrorCount, result.Errors = 1, []string{err.Error()}
+			checker.check.Failed++
+		default:
+			result.Status = integrityOK
+		}
+		checker.check.Checked++
+		status := result.Status
+		checker.mu.Unlock()
+		if status != integrityOK {
+			slog.Warn("视频完整性检查发现问题", "path", video.Path, "status", status, "errors", output.count, "error", err)
+		}
+		emit(true)
+	}
+
+	checker.mu.Lock()
+	check := checker.check
+	check.EndTime = time.Now()
+	check.Status = integrityCompleted
+	if ctx.Err() != nil {
+		check.Status = integrityCancelled
+		for i := range check.Files {
+			if check.Files[i].Status == integrityPending {
+				check.Files[i].Status = integritySkipped
+			}
+		}
+	}
+	checker.cancel = nil
+	checked, problems := check.Checked, check.Corrupt+check.Failed
+	status := check.Status
+	checker.mu.Unlock()
+	emit(true)
+
+	slog.Info("视频完整性检查结束", "status", status, "checked", checked, "problems", problems)
+	if status == integrityCompleted {
+		message := tr(msgNotifyIntegrityOK, checked)
+		if problems > 0 {
+			message = tr(msgNotifyIntegrityProblems, checked, problems)
+		}
+		a.notify(tr(msgNotifyIntegrityCompleted), message, "")
+	}
+}
+
+// StartIntegrityCheck starts checking library videos for corruption in the background
+// StartIntegrityCheck 在后台检查视频库中的视频是否损坏：用 ffmpeg 完整解码每个文件（-v error -f null），
+// 记录解码时报告的错误。paths 为空时检查整个视频库。检查一个文件需要完整解码，大文件需要几分钟；
+// 进度通过 EventIntegrityProgress 推送，同一时间只能运行一次检查
+func (a *App) StartIntegrityCheck(paths []string) (string, error) {
+	ffmpegPath, err := ffmpegToolPath()
+	if err != nil {
+		return "", err
+	}
+	videos, _, err := a.libraryVideos()
+	if err != nil {
+		return "", err
+	}
+	if len(paths) > 0 {
+		byPath := make(map[string]LibraryVideo, len(videos))
+		for _, video := range videos {
+			byPath[absPath(video.Path)] = video
+		}
+		videos = videos[:0:0]
+		for _, path := range paths {
+			video, ok := byPath[absPath(path)]
+			if !ok {
+				return "", errorf(msgFileNotFound, path)
+			}
+			videos = append(videos, video)
+		}
+	}
+	if len(videos) == 0 {
+		return "", errorf(msgNoVideosToCheck)
+	}
+
+	checker := a.integrity
+	checker.mu.Lock()
+	if checker.cancel != nil {
+		checker.mu.Unlock()
+		return "", errorf(msgIntegrityCheckRunning)
+	}
+	check := &IntegrityCheck{Status: integrityRunning, StartTime: time.Now()}
+	for _, video := range videos {
+		check.Files = append(check.Files, IntegrityResult{
+			Path:    video.Path,
+			RelPath: video.RelPath,
+			Root:    video.Root,
+			Status:  integrityPending,
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	checker.check, checker.cancel = check, cancel
+	checker.mu.Unlock()
+
+	slog.Info("开始视频完整性检查", "files", len(videos))
+	go a.runIntegrityCheck(ctx, ffmpegPath, videos)
+	return a.GetIntegrityCheck()
+}
+
+// GetIntegrityCheck gets the progress or result of the latest integrity check
+// GetIntegrityCheck 获取正在进行或最近一次完整性检查的进度和结果，没有检查过时 check 为 null
+func (a *App) GetIntegrityCheck() (string, error) {
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"check":  a.integrity.snapshot(),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// CancelIntegrityCheck cancels the running integrity check
+// CancelIntegrityCheck 取消正在进行的完整性检查，已检查的文件保留结果
+func (a *App) CancelIntegrityCheck() (string, error) {
+	a.integrity.mu.Lock()
+	cancel := a.integrity.cancel
+	a.integrity.mu.Unlock()
+	if cancel == nil {
+		return "", errorf(msgIntegrityCheckNotRunning)
+	}
+	cancel()
+	return a.GetIntegrityCheck()
+}
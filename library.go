@@ -0,0 +1,461 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 视频库的根目录：下载目录和转码目录，对应 /downloads/ 和 /transcode/ 文件地址
+const (
+	libraryRootDownload  = "download"
+	libraryRootTranscode = "transcode"
+)
+
+// libraryURLPrefix 用户添加的视频库文件夹中文件的地址前缀，完整地址为 /library/<文件夹ID>/<相对路径>
+const libraryURLPrefix = "/library/"
+
+// videoExtensions 视频文件扩展名列表
+var videoExtensions = map[string]bool{
+	".mp4":  true,
+	".mkv":  true,
+	".avi":  true,
+	".mov":  true,
+	".wmv":  true,
+	".flv":  true,
+	".webm": true,
+	".m4v":  true,
+	".ts":   true,
+}
+
+// LibraryVideo is a video file found while scanning the library
+// LibraryVideo 视频库中的视频文件
+type LibraryVideo struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// 完整路径
+	Path string `json:"path"`
+	// 相对于根目录的路径，使用 / 分隔
+	RelPath string `json:"relPath"`
+	// 所在的根目录：download、transcode 或用户添加的文件夹ID
+	Root string `json:"root"`
+	// 播放和下载地址，例如 /downloads/剧集/第1集.mkv
+	URL string `json:"url"`
+	// 支持 Range 请求的播放地址，例如 /stream/<ID>/第1集.mkv
+	Stream string `json:"stream"`
+	// 实时转码地址，内置播放器不支持视频的编码或容器时使用
+	Live string `json:"live"`
+	// 视频画面地址，追加 ?t=<秒> 得到该位置的画面，用于进度条上的悬停预览
+	Frames string `json:"frames"`
+	// 内置播放器不能直接播放，需要使用实时转码地址
+	NeedsTranscode bool   `json:"needsTranscode,omitempty"`
+	Extension      string `json:"extension"`
+	ModTime        string `json:"modTime"`
+	// 从路径中解析的标题、年份和季、集，用于按剧集分组和整理文件
+	Parsed ParsedName `json:"parsed"`
+	// 时长、分辨率、编码等信息，尚未读取时为空
+	Metadata *VideoMetadata `json:"metadata,omitempty"`
+	// 缩略图地址，尚未生成时为空
+	Thumbnail string `json:"thumbnail,omitempty"`
+	// TMDB/TVDB 上匹配到的电影或剧集，未启用刮削或没有找到时为空
+	Match *MediaMatch `json:"match,omitempty"`
+	// 播放进度，没有播放过时为空
+	Playback *PlaybackPosition `json:"playback,omitempty"`
+	// 与视频同名的字幕文件
+	Subtitles []LibrarySubtitle `json:"subtitles,omitempty"`
+}
+
+// LibraryFolder is a user registered library folder
+// LibraryFolder 用户添加的视频库文件夹。文件夹可能位于未连接的外接硬盘或网络位置，
+// 保存设置时不要求存在，扫描时不存在的文件夹显示为离线
+type LibraryFolder struct {
+	// 文件夹ID，用于文件地址，留空时自动生成
+	ID string `json:"id"`
+	// 显示名称，留空时使用文件夹名
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Enabled bool   `json:"enabled"`
+}
+
+// validate 检查视频库文件夹，生成ID并将路径转换为绝对路径
+func (f *LibraryFolder) validate() error {
+	f.Path = strings.TrimSpace(f.Path)
+	if f.Path == "" {
+		return errorf(msgInvalidLibraryFolder, f.Path)
+	}
+	abs, err := filepath.Abs(f.Path)
+	if err != nil {
+		return errorf(msgInvalidPath, f.Path, err)
+	}
+	f.Path = abs
+	if f.ID == "" {
+		id, err := randomToken(4)
+		if err != nil {
+			return err
+		}
+		f.ID = id
+	}
+	// ID 是文件地址的一部分，不能包含需要转义的字符，也不能与内置根目录同名
+	if url.PathEscape(f.ID) != f.ID || f.ID == "." || f.ID == ".." || f.ID == libraryRootDownload || f.ID == libraryRootTranscode {
+		return errorf(msgInvalidLibraryFolder, f.ID)
+	}
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" {
+		f.Name = filepath.Base(f.Path)
+	}
+	return nil
+}
+
+// root 返回文件夹对应的视频库根目录
+func (f LibraryFolder) root() libraryRoot {
+	return libraryRoot{name: f.ID, dir: f.Path, urlPrefix: libraryURLPrefix + f.ID + "/"}
+}
+
+// libraryFolder 按ID查找用户添加的视频库文件夹
+func libraryFolder(id string) (LibraryFolder, bool) {
+	for _, folder := range currentSettings().LibraryFolders {
+		if folder.ID == id {
+			return folder, true
+		}
+	}
+	return LibraryFolder{}, false
+}
+
+// LibraryFolderStatus is a library folder with its latest scan result
+// LibraryFolderStatus 视频库文件夹及最近一次扫描的结果
+type LibraryFolderStatus struct {
+	LibraryFolder
+	// 文件夹当前是否可以访问，未连接的外接硬盘、网络位置为 false
+	Online    bool   `json:"online"`
+	Videos    int    `json:"videos"`
+	ScannedAt string `json:"scannedAt,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// libraryRoot 视频库的一个根目录
+type libraryRoot struct {
+	name      string
+	dir       string
+	urlPrefix string
+}
+
+// libraryRoots 返回视频库的内置根目录
+func libraryRoots() []libraryRoot {
+	return []libraryRoot{
+		{name: libraryRootDownload, dir: downloadsDir(), urlPrefix: "/downloads/"},
+		{name: libraryRootTranscode, dir: transcodeDir(), urlPrefix: "/transcode/"},
+	}
+}
+
+// libraryScanKey 决定扫描结果是否可以复用：文件夹路径或扫描设置变化后需要重新扫描
+type libraryScanKey struct {
+	path     string
+	maxDepth int
+	minSize  int64
+}
+
+// libraryScan 一个文件夹的扫描结果
+type libraryScan struct {
+	key       libraryScanKey
+	videos    []LibraryVideo
+	scannedAt time.Time
+	err       error
+}
+
+// libraryIndex 缓存用户添加的文件夹的扫描结果。这些文件夹通常较大或位于网络位置，
+// 扫描一次后复用结果，直到用户重新扫描；下载目录和转码目录每次都重新扫描
+type libraryIndex struct {
+	mu    sync.Mutex
+	scans map[string]libraryScan
+}
+
+func newLibraryIndex() *libraryIndex {
+	return &libraryIndex{scans: make(map[string]libraryScan)}
+}
+
+// folder 返回文件夹的扫描结果，没有缓存或扫描设置已变化时重新扫描
+func (l *libraryIndex) folder(folder LibraryFolder, maxDepth int, minSize int64) libraryScan {
+	key := libraryScanKey{path: folder.Path, maxDepth: maxDepth, minSize: minSize}
+	l.mu.Lock()
+	scan, ok := l.scans[folder.ID]
+	l.mu.Unlock()
+	if ok && scan.key == key {
+		return scan
+	}
+
+	videos, err := folder.root().scan(maxDepth, minSize)
+	if err != nil && !os.IsNotExist(err) {
+		slog.Warn("扫描视频库文件夹失败", "folder", folder.Name, "dir", folder.Path, "error", err)
+	}
+	scan = libraryScan{key: key, videos: videos, scannedAt: time.Now(), err: err}
+	if err == nil {
+		// 离线的文件夹不缓存，重新连接后下次获取视频库时自动扫描
+		l.mu.Lock()
+		l.scans[folder.ID] = scan
+		l.mu.Unlock()
+	}
+	return scan
+}
+
+// invalidate 清除文件夹的扫描结果，id 为空时清除全部
+func (l *libraryIndex) invalidate(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if id == "" {
+		l.scans = make(map[string]libraryScan)
+		return
+	}
+	delete(l.scans, id)
+}
+
+// fileURL 返回根目录中文件的访问地址，每一级路径分别转义，并附加文件访问令牌
+func (r libraryRoot) fileURL(relPath string) string {
+	parts := strings.Split(relPath, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return withFileToken(r.urlPrefix + strings.Join(parts, "/"))
+}
+
+// scan 递归扫描根目录中的视频文件：跳过隐藏目录、超过 maxDepth 层（0表示不限制）的子目录
+// 和小于 minSize 字节的文件（例如种子中附带的预览片段），同时找出与视频同名的字幕文件
+func (r libraryRoot) scan(maxDepth int, minSize int64) ([]LibraryVideo, error) {
+	var videos []LibraryVideo
+	// 每个目录中的字幕文件名
+	subtitles := make(map[string][]string)
+	err := filepath.WalkDir(r.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == r.dir {
+				return err
+			}
+			slog.Warn("扫描视频库时读取失败", "path", path, "error", err)
+			return nil
+		}
+		rel, err := filepath.Rel(r.dir, path)
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path == r.dir {
+				return nil
+			}
+			depth := strings.Count(rel, string(filepath.Separator)) + 1
+			if strings.HasPrefix(entry.Name(), ".") || (maxDepth > 0 && depth > maxDepth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if subtitleExtensions[ext] {
+			dir := filepath.Dir(path)
+			subtitles[dir] = append(subtitles[dir], entry.Name())
+			return nil
+		}
+		if !videoExtensions[ext] {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil || info.Size() < minSize {
+			return nil
+		}
+		relPath := filepath.ToSlash(rel)
+		videos = append(videos, LibraryVideo{
+			Name:      entry.Name(),
+			Size:      info.Size(),
+			Path:      path,
+			RelPath:   relPath,
+			Root:      r.name,
+			URL:       r.fileURL(relPath),
+			Stream:    streamURL(r.name, relPath),
+			Live:      liveURL(r.name, relPath),
+			Frames:    frameURL(r.name, relPath),
+			Extension: ext[1:], // 移除点号
+			ModTime:   info.ModTime().Format(time.RFC3339),
+			Parsed:    parseVideoPath(relPath),
+		})
+		return nil
+	})
+	for i := range videos {
+		videos[i].Subtitles = r.videoSubtitles(videos[i], subtitles[filepath.Dir(videos[i].Path)])
+	}
+	return videos, err
+}
+
+// GetVideoLibrary gets the video files in the download and transcode directories
+// GetVideoLibrary 获取视频库：递归扫描下载目录（包括多文件种子的子目录）、转码目录和已启用的视频库文件夹中的视频文件，
+// 扫描深度和最小文件大小可在设置中修改；视频库文件夹使用上次扫描的结果，调用 RescanLibrary 重新扫描。
+// 时长、分辨率、编码等信息在后台通过 ffprobe 读取，缩略图通过 ffmpeg 生成，结果缓存在数据目录中，
+// 每处理完一个视频推送 library:metadata 事件；thumbnails 为 false 表示找不到 ffmpeg，不会生成缩略图。
+// 设置了元数据数据源时在后台匹配 TMDB/TVDB，每匹配完一个推送 library:scraped 事件。播放过的视频带有播放进度
+func (a *App) GetVideoLibrary() (string, error) {
+	videoFiles, folders, err := a.libraryVideos()
+	if err != nil {
+		return "", err
+	}
+	_, ffmpegErr := ffmpegToolPath()
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":     "success",
+		"videoFiles": videoFiles,
+		"total":      len(videoFiles),
+		"folders":    folders,
+		"thumbnails": ffmpegErr == nil,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// libraryVideos 扫描视频库中的所有视频并填写已缓存的视频信息、匹配结果和播放进度，同时返回视频库文件夹的状态
+func (a *App) libraryVideos() ([]LibraryVideo, []LibraryFolderStatus, error) {
+	current := currentSettings()
+	minSize := current.LibraryMinSizeMB * 1024 * 1024
+
+	videoFiles := []LibraryVideo{}
+	for _, root := range libraryRoots() {
+		dir, err := filepath.Abs(root.dir)
+		if err == nil {
+			root.dir = dir
+		}
+		videos, err := root.scan(current.LibraryMaxDepth, minSize)
+		if err != nil {
+			if root.name == libraryRootDownload {
+				return nil, nil, errorf(msgReadDownloadDirFailed, err)
+			}
+			if !os.IsNotExist(err) {
+				slog.Warn("扫描视频库失败", "dir", root.dir, "error", err)
+			}
+			continue
+		}
+		videoFiles = append(videoFiles, videos...)
+	}
+
+	folders := make([]LibraryFolderStatus, 0, len(current.LibraryFolders))
+	for _, folder := range current.LibraryFolders {
+		status := LibraryFolderStatus{LibraryFolder: folder}
+		if folder.Enabled {
+			scan := a.library.folder(folder, current.LibraryMaxDepth, minSize)
+			status.Online = scan.err == nil
+			status.Videos = len(scan.videos)
+			status.ScannedAt = scan.scannedAt.Format(time.RFC3339)
+			if scan.err != nil {
+				status.Error = scan.err.Error()
+			}
+			videoFiles = append(videoFiles, scan.videos...)
+		}
+		folders = append(folders, status)
+	}
+	a.attachMetadata(videoFiles)
+	a.attachMatches(videoFiles)
+	a.playback.attach(videoFiles)
+	for i := range videoFiles {
+		videoFiles[i].NeedsTranscode = !playableInBrowser(filepath.Ext(videoFiles[i].Name), videoFiles[i].Metadata)
+	}
+	return videoFiles, folders, nil
+}
+
+// findLibraryVideo 在视频库中查找视频，不在视频库中的文件返回错误
+func (a *App) findLibraryVideo(path string) (LibraryVideo, error) {
+	videos, _, err := a.libraryVideos()
+	if err != nil {
+		return LibraryVideo{}, err
+	}
+	path = filepath.Clean(path)
+	for _, video := range videos {
+		if video.Path == path {
+			return video, nil
+		}
+	}
+	return LibraryVideo{}, errorf(msgFileNotFound, path)
+}
+
+// RescanLibrary rescans a library folder, or all of them when id is empty
+// RescanLibrary 重新扫描视频库文件夹，id 为空时重新扫描所有文件夹，返回新的视频库
+func (a *App) RescanLibrary(id string) (string, error) {
+	if id != "" {
+		if _, ok := libraryFolder(id); !ok {
+			return "", errorf(msgLibraryFolderNotFound, id)
+		}
+	}
+	a.library.invalidate(id)
+	return a.GetVideoLibrary()
+}
+
+// serveLibraryFile 提供视频库文件夹中的文件，地址为 /library/<文件夹ID>/<相对路径>，只允许访问已启用文件夹内的文件
+func serveLibraryFile(w http.ResponseWriter, r *http.Request) {
+	id, relPath, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, libraryURLPrefix), "/")
+	folder, ok := libraryFolder(id)
+	if !ok || !folder.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	// 安全检查：确保文件（包括符号链接指向的文件）在视频库文件夹内
+	serveFromRoot(w, r, folder.Path, relPath)
+}
+
+// videoQueue 在后台逐个处理的视频队列，同一文件不会重复加入
+type videoQueue struct {
+	mu      sync.Mutex
+	pending []LibraryVideo
+	queued  map[string]bool
+	running bool
+}
+
+// push 将视频加入队列，返回是否需要启动后台处理
+func (q *videoQueue) push(videos []LibraryVideo) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.queued == nil {
+		q.queued = make(map[string]bool)
+	}
+	added := false
+	for _, video := range videos {
+		if !q.queued[video.Path] {
+			q.queued[video.Path] = true
+			q.pending = append(q.pending, video)
+			added = true
+		}
+	}
+	if !added || q.running {
+		return false
+	}
+	q.running = true
+	return true
+}
+
+// next 取出队列中的下一个视频，队列为空时结束后台处理
+func (q *videoQueue) next() (LibraryVideo, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		q.running = false
+		return LibraryVideo{}, false
+	}
+	video := q.pending[0]
+	q.pending = q.pending[1:]
+	delete(q.queued, video.Path)
+	return video, true
+}
+
+// clear 清空队列并结束后台处理
+func (q *videoQueue) clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = nil
+	q.queued = nil
+	q.running = false
+}
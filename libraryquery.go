@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 视频库的排序方式
+const (
+	librarySortName     = "name"
+	librarySortSize     = "size"
+	librarySortModTime  = "mtime"
+	librarySortDuration = "duration"
+	// librarySortEpisode 按剧集标题、季、集排列，浏览一部剧集时使用
+	librarySortEpisode = "episode"
+)
+
+// 视频库按分辨率过滤的档位，按视频的宽度和高度划分
+const (
+	resolution2160p   = "2160p"
+	resolution1080p   = "1080p"
+	resolution720p    = "720p"
+	resolutionSD      = "sd"
+	resolutionUnknown = "unknown"
+)
+
+// LibraryQuery filters, sorts and pages the video library
+// LibraryQuery 视频库的查询条件，所有条件都为空时返回按名称排列的全部视频
+type LibraryQuery struct {
+	// 搜索文字，匹配相对路径和匹配到的标题，不区分大小写，多个词需要全部匹配
+	Search string `json:"search"`
+	// 扩展名（不含点号），例如 mkv、mp4
+	Extensions []string `json:"extensions"`
+	// 分辨率档位：2160p, 1080p, 720p, sd, unknown（尚未读取视频信息）
+	Resolutions []string `json:"resolutions"`
+	// 所在位置：download、transcode 或视频库文件夹ID
+	Roots []string `json:"roots"`
+	// 剧集标题，只返回该剧集的视频
+	Show string `json:"show"`
+	// 排序方式：name, size, mtime, duration, episode，默认为 name
+	Sort string `json:"sort"`
+	// 是否倒序
+	Desc   bool `json:"desc"`
+	Offset int  `json:"offset"`
+	// 每页数量，0表示返回全部
+	Limit int `json:"limit"`
+}
+
+// validate 检查查询条件并填写默认排序方式
+func (q *LibraryQuery) validate() error {
+	switch q.Sort {
+	case "":
+		q.Sort = librarySortName
+	case librarySortName, librarySortSize, librarySortModTime, librarySortDuration, librarySortEpisode:
+	default:
+		return errorf(msgInvalidLibrarySort, q.Sort)
+	}
+	for _, resolution := range q.Resolutions {
+		switch resolution {
+		case resolution2160p, resolution1080p, resolution720p, resolutionSD, resolutionUnknown:
+		default:
+			return errorf(msgInvalidResolution, resolution)
+		}
+	}
+	if q.Offset < 0 || q.Limit < 0 {
+		return errorf(msgInvalidLibraryPage)
+	}
+	return nil
+}
+
+// videoResolution 返回视频的分辨率档位，宽度或高度达到档位即可（裁掉黑边的 1920x800 也是 1080p），竖屏视频交换宽高
+func videoResolution(metadata *VideoMetadata) string {
+	if metadata == nil || metadata.Width == 0 || metadata.Height == 0 {
+		return resolutionUnknown
+	}
+	long, short := metadata.Width, metadata.Height
+	if short > long {
+		long, short = short, long
+	}
+	switch {
+	case long >= 3200 || short >= 1800:
+		return resolution2160p
+	case long >= 1700 || short >= 900:
+		return resolution1080p
+	case long >= 1200 || short >= 600:
+		return resolution720p
+	default:
+		return resolutionSD
+	}
+}
+
+// containsFold 判断 values 中是否有与 value 相同（不区分大小写）的值，values 为空时返回 true
+func containsFold(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches 判断视频是否满足过滤条件
+func (q LibraryQuery) matches(video LibraryVideo, terms []string) bool {
+	if !containsFold(q.Extensions, video.Extension) || !containsFold(q.Roots, video.Root) ||
+		!containsFold(q.Resolutions, videoResolution(video.Metadata)) {
+		return false
+	}
+	if q.Show != "" && (!video.Parsed.IsEpisode() || video.Parsed.Title != q.Show) {
+		return false
+	}
+	if len(terms) == 0 {
+		return true
+	}
+	text := strings.ToLower(video.RelPath + "\n" + video.Parsed.Title)
+	if video.Match != nil {
+		text += "\n" + strings.ToLower(video.Match.Title)
+	}
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// naturalLess 按自然顺序比较字符串，数字部分按数值比较（第2集排在第10集之前），不区分大小写
+func naturalLess(a, b string) bool {
+	ra, rb := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if unicode.IsDigit(ra[i]) && unicode.IsDigit(rb[j]) {
+			si, sj := i, j
+			for i < len(ra) && unicode.IsDigit(ra[i]) {
+				i++
+			}
+			for j < len(rb) && unicode.IsDigit(rb[j]) {
+				j++
+			}
+			// 去掉前导零后先比较位数，再逐位比较
+			na := strings.TrimLeft(string(ra[si:i]), "0")
+			nb := strings.TrimLeft(string(rb[sj:j]), "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			continue
+		}
+		if ra[i] != rb[j] {
+			return ra[i] < rb[j]
+		}
+		i++
+		j++
+	}
+	return len(ra)-i < len(rb)-j
+}
+
+// sortLibraryVideos 按查询的排序方式排列视频，值相同时按相对路径排列；时长未知的视频总是排在最后
+func sortLibraryVideos(videos []LibraryVideo, sortBy string, desc bool) {
+	byPath := func(a, b LibraryVideo) bool {
+		return naturalLess(a.RelPath, b.RelPath)
+	}
+	sort.SliceStable(videos, func(i, j int) bool {
+		a, b := videos[i], videos[j]
+		// 返回 a 是否排在 b 前面（正序），equal 表示排序值相同
+		var less, equal bool
+		switch sortBy {
+		case librarySortSize:
+			less, equal = a.Size < b.Size, a.Size == b.Size
+		case librarySortModTime:
+			less, equal = a.ModTime < b.ModTime, a.ModTime == b.ModTime
+		case librarySortDuration:
+			da, db := 0.0, 0.0
+			if a.Metadata != nil {
+				da = a.Metadata.Duration
+			}
+			if b.Metadata != nil {
+				db = b.Metadata.Duration
+			}
+			if (da == 0) != (db == 0) {
+				return db == 0
+			}
+			less, equal = da < db, da == db
+		case librarySortEpisode:
+			pa, pb := a.Parsed, b.Parsed
+			switch {
+			case !strings.EqualFold(pa.Title, pb.Title):
+				less = naturalLess(pa.Title, pb.Title)
+			case pa.Season != pb.Season:
+				less = pa.Season < pb.Season
+			case pa.Episode != pb.Episode:
+				less = pa.Episode < pb.Episode
+			default:
+				equal = true
+			}
+		default:
+			less, equal = naturalLess(a.Name, b.Name), strings.EqualFold(a.Name, b.Name)
+		}
+		if equal {
+			return byPath(a, b)
+		}
+		if desc {
+			return !less
+		}
+		return less
+	})
+}
+
+// libraryFacets 返回视频库中所有视频的剧集标题和扩展名，用于过滤选项，不受查询条件影响
+func libraryFacets(videos []LibraryVideo) ([]string, []string) {
+	showSet := make(map[string]bool)
+	extensionSet := make(map[string]bool)
+	for _, video := range videos {
+		if video.Parsed.IsEpisode() && video.Parsed.Title != "" {
+			showSet[video.Parsed.Title] = true
+		}
+		extensionSet[video.Extension] = true
+	}
+	shows := make([]string, 0, len(showSet))
+	for show := range showSet {
+		shows = append(shows, show)
+	}
+	sort.Slice(shows, func(i, j int) bool {
+		return naturalLess(shows[i], shows[j])
+	})
+	extensions := make([]string, 0, len(extensionSet))
+	for extension := range extensionSet {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+	return shows, extensions
+}
+
+// QueryVideoLibrary searches, filters, sorts and pages the video library
+// QueryVideoLibrary 按条件查询视频库，queryData 为 LibraryQuery 的 JSON：按文字搜索，按扩展名、分辨率、位置和剧集过滤，
+// 按名称、大小、修改时间、时长或季集排序并分页。total 为满足条件的视频数，shows 和 extensions 为整个视频库中的剧集和扩展名。
+// 扫描和后台读取视频信息、匹配元数据与 GetVideoLibrary 相同
+func (a *App) QueryVideoLibrary(queryData string) (string, error) {
+	var query LibraryQuery
+	if strings.TrimSpace(queryData) != "" {
+		if err := json.Unmarshal([]byte(queryData), &query); err != nil {
+			return "", errorf(msgParseLibraryQueryFailed, err)
+		}
+	}
+	if err := query.validate(); err != nil {
+		return "", err
+	}
+
+	videos, folders, err := a.libraryVideos()
+	if err != nil {
+		return "", err
+	}
+	shows, extensions := libraryFacets(videos)
+
+	terms := strings.Fields(strings.ToLower(query.Search))
+	videoFiles := []LibraryVideo{}
+	for _, video := range videos {
+		if query.matches(video, terms) {
+			videoFiles = append(videoFiles, video)
+		}
+	}
+	sortLibraryVideos(videoFiles, query.Sort, query.Desc)
+	total := len(videoFiles)
+	if query.Offset > total {
+		query.Offset = total
+	}
+	videoFiles = videoFiles[query.Offset:]
+	if query.Limit > 0 && len(videoFiles) > query.Limit {
+		videoFiles = videoFiles[:query.Limit]
+	}
+	_, ffmpegErr := ffmpegToolPath()
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":     "success",
+		"videoFiles": videoFiles,
+		"total":      total,
+		"offset":     query.Offset,
+		"limit":      query.Limit,
+		"shows":      shows,
+		"extensions": extensions,
+		"folders":    folders,
+		"thumbnails": ffmpegErr == nil,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
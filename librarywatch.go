@@ -0,0 +1,242 @@
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// EventLibraryChanged 视频库文件夹中有视频或字幕文件出现、消失或改名时推送，负载为 {roots}（发生变化的根目录名称），
+// 同一批变化合并推送一次
+const EventLibraryChanged = "library:changed"
+
+// libraryWatchDelay 收到文件变化后等待的时间，复制、解压等连续操作合并为一次刷新
+const libraryWatchDelay = 2 * time.Second
+
+// libraryWatcher 监视视频库的根目录及其子目录（文件系统通知不递归，每个子目录单独监视），
+// 文件变化后清除扫描缓存并通知前端刷新，不需要手动重新扫描
+type libraryWatcher struct {
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	// roots 根目录名称到绝对路径
+	roots map[string]string
+	// key 监视的目录和扫描层数，设置变化后据此判断是否需要重新监视
+	key      string
+	maxDepth int
+	// pending 等待通知的根目录
+	pending map[string]bool
+	timer   *time.Timer
+}
+
+// watchedLibraryRoots 返回需要监视的根目录：下载目录、转码目录和已启用的视频库文件夹
+func watchedLibraryRoots() map[string]string {
+	roots := make(map[string]string)
+	for _, root := range libraryRoots() {
+		roots[root.name] = absPath(root.dir)
+	}
+	for _, folder := range currentSettings().LibraryFolders {
+		if folder.Enabled {
+			roots[folder.ID] = absPath(folder.Path)
+		}
+	}
+	return roots
+}
+
+// libraryWatchKey 由根目录和扫描层数生成，用于判断监视范围是否变化
+func libraryWatchKey(roots map[string]string, maxDepth int) string {
+	var parts []string
+	for name, dir := range roots {
+		parts = append(parts, name+"="+dir)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\n") + "\n" + strconv.Itoa(maxDepth)
+}
+
+// applyLibraryWatcher 按当前设置监视视频库，监视范围没有变化时保持不变；启动和保存设置时调用
+func (a *App) applyLibraryWatcher() {
+	w := a.libraryWatch
+	roots := watchedLibraryRoots()
+	maxDepth := currentSettings().LibraryMaxDepth
+	key := libraryWatchKey(roots, maxDepth)
+
+	w.mu.Lock()
+	if w.watcher != nil && w.key == key {
+		w.mu.Unlock()
+		return
+	}
+	w.stopLocked()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		w.mu.Unlock()
+		slog.Warn("无法监视视频库文件夹，需要手动刷新", "error", err)
+		return
+	}
+	w.watcher, w.roots, w.key, w.maxDepth = watcher, roots, key, maxDepth
+	w.pending = make(map[string]bool)
+	w.mu.Unlock()
+
+	// 先开始处理事件再添加目录，见 addWatchTree
+	go a.watchLibrary(watcher)
+	watched := 0
+	for _, dir := range roots {
+		watched += addWatchTree(watcher, dir, dir, maxDepth)
+	}
+	slog.Info("开始监视视频库文件夹", "roots", len(roots), "dirs", watched)
+}
+
+// stop 停止监视，程序关闭时调用
+func (w *libraryWatcher) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopLocked()
+}
+
+func (w *libraryWatcher) stopLocked() {
+	if w.watcher == nil {
+		return
+	}
+	w.watcher.Close()
+	w.watcher = nil
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+// addWatchTree 监视目录及其子目录，与扫描视频库一样跳过隐藏目录和超过扫描层数的目录，返回添加的目录数。
+// 监视数量达到系统限制（Linux 的 fs.inotify.max_user_watches）时其余目录不再监视。
+// 调用时不能持有 libraryWatcher.mu，也不能在事件循环中调用：Windows 上 Add 需要等待发送事件的协程处理请求，
+// 该协程可能正阻塞在向事件循环发送事件上
+func addWatchTree(watcher *fsnotify.Watcher, dir, root string, maxDepth int) int {
+	added := 0
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if path != root {
+			rel, _ := filepath.Rel(root, path)
+			depth := strings.Count(rel, string(filepath.Separator)) + 1
+			if strings.HasPrefix(entry.Name(), ".") || (maxDepth > 0 && depth > maxDepth) {
+				return filepath.SkipDir
+			}
+		}
+		if err := watcher.Add(path); err != nil {
+			slog.Warn("监视目录失败", "path", path, "error", err)
+			return filepath.SkipAll
+		}
+		added++
+		return nil
+	})
+	return added
+}
+
+// watchNewDir 监视新创建的目录及其子目录，监视已重新开始时跳过
+func (w *libraryWatcher) watchNewDir(watcher *fsnotify.Watcher, dir, root string) {
+	w.mu.Lock()
+	current, maxDepth := w.watcher == watcher, w.maxDepth
+	w.mu.Unlock()
+	if current {
+		addWatchTree(watcher, dir, root, maxDepth)
+	}
+}
+
+// rootOf 返回路径所在的根目录名称和路径，嵌套的根目录取最深的一个
+func (w *libraryWatcher) rootOf(path string) (string, string) {
+	var name, dir string
+	for n, d := range w.roots {
+		if pathWithin(path, d) && len(d) > len(dir) {
+			name, dir = n, d
+		}
+	}
+	return name, dir
+}
+
+// handle 处理一个文件变化事件：新目录加入监视；视频、字幕和目录的创建、删除和改名记录到待通知的根目录中。
+// 文件写入不处理，正在下载的文件会持续写入
+func (w *libraryWatcher) handle(event fsnotify.Event, notify func()) {
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.watcher == nil {
+		return
+	}
+	name, root := w.rootOf(event.Name)
+	if name == "" {
+		return
+	}
+
+	isDir := false
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			isDir = true
+			// 不能在事件循环中添加监视，见 addWatchTree
+			go w.watchNewDir(w.watcher, event.Name, root)
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(event.Name))
+	// 删除和改名时已无法判断是否为目录，没有扩展名的按目录处理
+	if !isDir && ext != "" && !videoExtensions[ext] && !subtitleExtensions[ext] {
+		return
+	}
+
+	w.pending[name] = true
+	if w.timer == nil {
+		w.timer = time.AfterFunc(libraryWatchDelay, notify)
+	}
+}
+
+// flush 返回并清空待通知的根目录
+func (w *libraryWatcher) flush() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = nil
+	roots := make([]string, 0, len(w.pending))
+	for name := range w.pending {
+		roots = append(roots, name)
+	}
+	sort.Strings(roots)
+	w.pending = make(map[string]bool)
+	return roots
+}
+
+// watchLibrary 处理文件变化事件，直到监视停止
+func (a *App) watchLibrary(watcher *fsnotify.Watcher) {
+	w := a.libraryWatch
+	notify := func() {
+		roots := w.flush()
+		if len(roots) == 0 {
+			return
+		}
+		for _, root := range roots {
+			a.library.invalidate(root)
+		}
+		a.dlna.contentChanged()
+		slog.Debug("视频库文件夹有变化", "roots", roots)
+		a.emitEvent(EventLibraryChanged, map[string]interface{}{"roots": roots})
+	}
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			w.handle(event, notify)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("监视视频库文件夹出错", "error", err)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// liveURLPrefix 实时转码的播放地址前缀，完整地址为 /live/<ID>?start=<秒>&session=<会话ID>
+	liveURLPrefix = "/live/"
+	// liveIdleTimeout 输出超过该时间没有被读取的会话视为已放弃（播放器关闭但连接未断开），结束 ffmpeg
+	liveIdleTimeout = 2 * time.Minute
+	// liveReapInterval 检查放弃的会话的间隔
+	liveReapInterval = 15 * time.Second
+	// maxLiveSessions 同时进行的实时转码数量上限，超过时结束最久没有读取的会话
+	maxLiveSessions = 2
+)
+
+// 内置播放器（WebView2、WebKit）可以直接播放的编码和容器，其他视频需要实时转码
+var (
+	browserVideoCodecs = map[string]bool{"h264": true, "vp8": true, "vp9": true, "av1": true}
+	browserAudioCodecs = map[string]bool{"aac": true, "mp3": true, "opus": true, "vorbis": true, "flac": true}
+	browserContainers  = map[string]bool{".mp4": true, ".m4v": true, ".webm": true, ".mov": true}
+)
+
+// playableInBrowser 判断内置播放器能否直接播放视频，视频信息未知时返回 true，由播放器在出错时改用实时转码
+func playableInBrowser(ext string, metadata *VideoMetadata) bool {
+	if metadata == nil {
+		return true
+	}
+	if !browserContainers[strings.ToLower(ext)] || !browserVideoCodecs[metadata.VideoCodec] {
+		return false
+	}
+	return metadata.AudioCodec == "" || browserAudioCodecs[metadata.AudioCodec]
+}
+
+// liveURL 返回视频的实时转码地址，包含文件访问令牌，前端追加 &start= 和 &session=
+func liveURL(root, relPath string) string {
+	return withFileToken(liveURLPrefix + streamID(root, relPath))
+}
+
+// liveTranscodeArgs 返回实时转码的 ffmpeg 参数：输出 fragmented MP4 到标准输出，浏览器可以边接收边播放。
+// 视频是 H.264 时只重新封装，否则使用 libx264 的 veryfast 预设编码；音频不是 AAC 时转为双声道 AAC
+func liveTranscodeArgs(path string, start float64, metadata *VideoMetadata) []string {
+	args := []string{"-v", "error", "-nostdin"}
+	if start > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(start, 'f', 3, 64))
+	}
+	args = append(args, "-i", path, "-map", "0:v:0", "-map", "0:a:0?", "-sn", "-dn")
+	if metadata != nil && metadata.VideoCodec == "h264" {
+		args = append(args, "-c:v", "copy")
+	} else {
+		args = append(args, "-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-pix_fmt", "yuv420p", "-g", "48")
+	}
+	if metadata != nil && metadata.AudioCodec == "aac" {
+		args = append(args, "-c:a", "copy")
+	} else {
+		args = append(args, "-c:a", "aac", "-b:a", "192k", "-ac", "2")
+	}
+	return append(args, "-movflags", "frag_keyframe+empty_moov+default_base_moof", "-f", "mp4", "pipe:1")
+}
+
+// liveSession 一个实时转码会话，对应一个 ffmpeg 进程
+type liveSession struct {
+	id        string
+	path      string
+	startedAt time.Time
+	cancel    context.CancelFunc
+	// lastActive 最近一次输出被读取的时间（UnixNano）
+	lastActive atomic.Int64
+}
+
+// touch 记录输出被读取
+func (s *liveSession) touch() {
+	s.lastActive.Store(time.Now().UnixNano())
+}
+
+// idle 返回输出没有被读取的时长
+func (s *liveSession) idle() time.Duration {
+	return time.Since(time.Unix(0, s.lastActive.Load()))
+}
+
+// liveSessionManager 管理实时转码会话：同一会话ID（一个播放器）重新开始时结束之前的进程，
+// 数量超过上限时结束最久没有读取的会话，并定期结束放弃的会话
+type liveSessionManager struct {
+	mu       sync.Mutex
+	sessions map[string]*liveSession
+	reaping  bool
+}
+
+var liveSessions = &liveSessionManager{sessions: make(map[string]*liveSession)}
+
+// start 登记新会话，返回会话使用的 context
+func (m *liveSessionManager) start(parent context.Context, id, path string) (*liveSession, context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	session := &liveSession{id: id, path: path, startedAt: time.Now(), cancel: cancel}
+	session.touch()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.sessions[id]; ok {
+		old.cancel()
+	}
+	m.sessions[id] = session
+	for len(m.sessions) > maxLiveSessions {
+		var oldest *liveSession
+		for _, s := range m.sessions {
+			if s != session && (oldest == nil || s.idle() > oldest.idle()) {
+				oldest = s
+			}
+		}
+		slog.Info("实时转码会话过多，结束最久没有读取的会话", "session", oldest.id, "path", oldest.path)
+		oldest.cancel()
+		delete(m.sessions, oldest.id)
+	}
+	if !m.reaping {
+		m.reaping = true
+		go m.reap()
+	}
+	return session, ctx
+}
+
+// remove 会话结束后移除，会话已被同一ID的新会话替换时不处理
+func (m *liveSessionManager) remove(session *liveSession) {
+	session.cancel()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions[session.id] == session {
+		delete(m.sessions, session.id)
+	}
+}
+
+// stop 结束会话，id 为空时结束全部
+func (m *liveSessionManager) stop(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for sid, session := range m.sessions {
+		if id == "" || sid == id {
+			session.cancel()
+			delete(m.sessions, sid)
+		}
+	}
+}
+
+// reap 定期结束放弃的会话，没有会话时退出
+func (m *liveSessionManager) reap() {
+	ticker := time.NewTicker(liveReapInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.mu.Lock()
+		for id, session := range m.sessions {
+			if session.idle() > liveIdleTimeout {
+				slog.Info("结束放弃的实时转码会话", "session", id, "path", session.path, "idle", session.idle().Round(time.Second))
+				session.cancel()
+				delete(m.sessions, id)
+			}
+		}
+		if len(m.sessions) == 0 {
+			m.reaping = false
+			m.mu.Unlock()
+			return
+		}
+		m.mu.Unlock()
+	}
+}
+
+// liveWriter 将 ffmpeg 的输出写入响应并立即发送，同时记录会话活动
+type liveWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+	session *liveSession
+}
+
+func (l liveWriter) Write(p []byte) (int, error) {
+	n, err := l.w.Write(p)
+	if n > 0 {
+		l.session.touch()
+		if l.flusher != nil {
+			l.flusher.Flush()
+		}
+	}
+	return n, err
+}
+
+// serveLiveStream 实时转码视频库中的视频（HEVC、AC3 等内置播放器不支持的编码），输出 fragmented MP4。
+// 输出不能按字节跳转，播放器跳转时带上新的 start 参数重新请求；同一 session 的旧进程会被结束
+func serveLiveStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, errorf(msgUseGet, r.URL.Path))
+		return
+	}
+	if !requireFileToken(w, r) {
+		return
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, liveURLPrefix), "/")
+	path, ok := resolveStream(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	ffmpegPath, err := ffmpegToolPath()
+	if err != nil {
+		writeJSONError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	start, _ := strconv.ParseFloat(r.URL.Query().Get("start"), 64)
+	if start < 0 {
+		start = 0
+	}
+	sessionID := r.URL.Query().Get("session")
+	if sessionID == "" {
+		if sessionID, err = randomToken(8); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "video/mp4")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// 读取编码信息决定是否可以直接复制视频和音频，读取失败时全部重新编码
+	var metadata *VideoMetadata
+	if ffprobePath, err := ffprobeToolPath(); err == nil {
+		if metadata, err = probeVideo(ffprobePath, path); err != nil {
+			slog.Warn("读取视频信息失败，实时转码时重新编码", "path", path, "error", err)
+		}
+	}
+
+	session, ctx := liveSessions.start(r.Context(), sessionID, path)
+	defer liveSessions.remove(session)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpegPath, liveTranscodeArgs(path, start, metadata)...)
+	hideWindow(cmd)
+	flusher, _ := w.(http.Flusher)
+	cmd.Stdout = liveWriter{w: w, flusher: flusher, session: session}
+	cmd.Stderr = &stderr
+
+	slog.Info("开始实时转码", "session", sessionID, "path", path, "start", start)
+	w.WriteHeader(http.StatusOK)
+	err = cmd.Run()
+	if err != nil && ctx.Err() == nil {
+		slog.Warn("实时转码失败", "session", sessionID, "path", path, "error", err, "output", strings.TrimSpace(stderr.String()))
+		return
+	}
+	slog.Info("实时转码结束", "session", sessionID, "path", filepath.Base(path), "elapsed", time.Since(session.startedAt).Round(time.Second))
+}
+
+// StopLiveTranscode stops a live transcoding session
+// StopLiveTranscode 结束实时转码会话，关闭播放器时调用；会话不存在时忽略
+func (a *App) StopLiveTranscode(session string) (string, error) {
+	if session != "" {
+		liveSessions.stop(session)
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// logDirName 日志目录名，位于数据目录中
+	logDirName = "logs"
+	// logFileName 当前日志文件名，轮转后的文件依次为 seedparser.log.1、seedparser.log.2 ...
+	logFileName = "seedparser.log"
+	// logMaxSize 单个日志文件的最大字节数
+	logMaxSize = 5 * 1024 * 1024
+	// logMaxBackups 保留的轮转日志文件数
+	logMaxBackups = 3
+	// recentLogCapacity 内存中保留的最近日志条数，供 GetRecentLogs 使用
+	recentLogCapacity = 1000
+)
+
+// logLevel 当前日志级别，可通过设置实时修改
+var logLevel = new(slog.LevelVar)
+
+// recentLogs 最近的日志记录
+var recentLogs = newLogBuffer(recentLogCapacity)
+
+// LogEntry is a single log record kept in memory
+// LogEntry 内存中保存的一条日志
+type LogEntry struct {
+	Time    string            `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+
+	level slog.Level
+}
+
+// logBuffer 固定容量的环形日志缓冲区
+type logBuffer struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int
+	full    bool
+}
+
+func newLogBuffer(capacity int) *logBuffer {
+	return &logBuffer{entries: make([]LogEntry, capacity)}
+}
+
+func (b *logBuffer) add(entry LogEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// recent 按时间顺序返回不低于 minLevel 的最近 limit 条日志
+func (b *logBuffer) recent(minLevel slog.Level, limit int) []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var ordered []LogEntry
+	if b.full {
+		ordered = append(ordered, b.entries[b.next:]...)
+	}
+	ordered = append(ordered, b.entries[:b.next]...)
+
+	result := []LogEntry{}
+	for _, entry := range ordered {
+		if entry.level >= minLevel {
+			result = append(result, entry)
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	return result
+}
+
+// logHandler 将日志同时写入多个输出，并记录到内存缓冲区
+type logHandler struct {
+	handlers []slog.Handler
+	attrs    []slog.Attr
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= logLevel.Level()
+}
+
+func (h *logHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := LogEntry{
+		Time:    record.Time.Format(time.RFC3339),
+		Level:   record.Level.String(),
+		Message: record.Message,
+		level:   record.Level,
+	}
+	addAttr := func(attr slog.Attr) bool {
+		if entry.Attrs == nil {
+			entry.Attrs = make(map[string]string)
+		}
+		entry.Attrs[attr.Key] = attr.Value.String()
+		return true
+	}
+	for _, attr := range h.attrs {
+		addAttr(attr)
+	}
+	record.Attrs(addAttr)
+	recentLogs.add(entry)
+
+	var firstErr error
+	for _, handler := range h.handlers {
+		if err := handler.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &logHandler{handlers: handlers, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &logHandler{handlers: handlers, attrs: h.attrs}
+}
+
+// rotatingFile is an io.Writer that rotates the log file when it grows past maxSize
+// rotatingFile 按大小轮转的日志文件
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	w := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingFile) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate 关闭当前文件，将 .N 依次后移，当前文件改名为 .1
+func (w *rotatingFile) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	os.Remove(fmt.Sprintf("%s.%d", w.path, w.maxBackups))
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+func (w *rotatingFile) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.size+int64(len(p)) > w.maxSize && w.size > 0 {
+		if err := w.rotate(); err != nil {
+			return 0, fmt.Errorf("轮转日志文件失败: %w", err)
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// initLogging sets up the default slog logger writing to the console and a rotating file in the data directory
+// initLogging 初始化默认日志：控制台输出文本格式，数据目录中的日志文件为JSON格式并按大小轮转
+func initLogging() error {
+	logDir := dataPath(logDirName)
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	file, err := openRotatingFile(filepath.Join(logDir, logFileName), logMaxSize, logMaxBackups)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+
+	// 级别由 logHandler 统一判断，内部输出全部接收
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	slog.SetDefault(slog.New(&logHandler{
+		handlers: []slog.Handler{
+			slog.NewTextHandler(os.Stdout, opts),
+			slog.NewJSONHandler(file, opts),
+		},
+	}))
+	return nil
+}
+
+// parseLogLevel 解析日志级别名称（debug, info, warn, error）
+func parseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return slog.LevelInfo, errorf(msgInvalidLogLevel, name)
+	}
+	return level, nil
+}
+
+// wailsLogger 将Wails运行时的日志转发到默认日志
+type wailsLogger struct{}
+
+func (wailsLogger) Print(message string)   { slog.Info(message, "source", "wails") }
+func (wailsLogger) Trace(message string)   { slog.Debug(message, "source", "wails") }
+func (wailsLogger) Debug(message string)   { slog.Debug(message, "source", "wails") }
+func (wailsLogger) Info(message string)    { slog.Info(message, "source", "wails") }
+func (wailsLogger) Warning(message string) { slog.Warn(message, "source", "wails") }
+func (wailsLogger) Error(message string)   { slog.Error(message, "source", "wails") }
+func (wailsLogger) Fatal(message string) {
+	slog.Error(message, "source", "wails")
+	os.Exit(1)
+}
+
+// GetRecentLogs returns recent log entries at or above the given level
+// GetRecentLogs 获取最近的日志，level 为最低级别（debug, info, warn, error，留空为全部），limit 为最多返回条数（0为全部）
+func (a *App) GetRecentLogs(level string, limit int) (string, error) {
+	minLevel := slog.LevelDebug
+	if level != "" {
+		parsed, err := parseLogLevel(level)
+		if err != nil {
+			return "", err
+		}
+		minLevel = parsed
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"logFile": filepath.Join(dataPath(logDirName), logFileName),
+		"logs":    recentLogs.recent(minLevel, limit),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+const (
+	// magnetMetadataTimeout 从 DHT 和 Tracker 获取磁力链接元数据的超时，冷门资源可能一直找不到节点
+	magnetMetadataTimeout = 60 * time.Second
+	// magnetPollInterval 检查元数据是否已保存的间隔
+	magnetPollInterval = 200 * time.Millisecond
+)
+
+// MagnetInfo is the information contained in a magnet link
+// MagnetInfo 磁力链接中包含的信息，不需要连接网络
+type MagnetInfo struct {
+	// v1 info-hash（40位小写十六进制），32位 Base32 编码的 info-hash 会转换为十六进制
+	InfoHash string `json:"infoHash,omitempty"`
+	// v2 info-hash（urn:btmh，64位十六进制的 SHA-256）
+	InfoHashV2 string `json:"infoHashV2,omitempty"`
+	// 显示名称（dn 参数）
+	Name     string   `json:"name"`
+	Trackers []string `json:"trackers"`
+	// Web 种子（ws 参数）
+	WebSeeds []string `json:"webSeeds,omitempty"`
+	// 链接中声明的总大小（xl 参数），0表示未知
+	Size int64 `json:"size,omitempty"`
+}
+
+// parseMagnetHash 解析 xt 参数中的 info-hash，返回版本（1或2）和十六进制的哈希
+func parseMagnetHash(xt string) (int, string, error) {
+	lower := strings.ToLower(xt)
+	switch {
+	case strings.HasPrefix(lower, "urn:btih:"):
+		value := xt[len("urn:btih:"):]
+		switch len(value) {
+		case 40:
+			if _, err := hex.DecodeString(value); err != nil {
+				return 0, "", err
+			}
+			return 1, strings.ToLower(value), nil
+		case 32:
+			decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(value))
+			if err != nil {
+				return 0, "", err
+			}
+			return 1, hex.EncodeToString(decoded), nil
+		}
+		return 0, "", errorf(msgInvalidInfoHashLength, value)
+	case strings.HasPrefix(lower, "urn:btmh:"):
+		// multihash：0x12 表示 SHA-256，0x20 表示长度32字节
+		value := strings.ToLower(xt[len("urn:btmh:"):])
+		if len(value) != 68 || !strings.HasPrefix(value, "1220") {
+			return 0, "", errorf(msgUnsupportedMultihash, value)
+		}
+		if _, err := hex.DecodeString(value[4:]); err != nil {
+			return 0, "", err
+		}
+		return 2, value[4:], nil
+	}
+	return 0, "", errorf(msgUnsupportedMagnetXT, xt)
+}
+
+// parseMagnet 解析并校验磁力链接，至少需要一个 BitTorrent info-hash（v1 或 v2）
+func parseMagnet(uri string) (MagnetInfo, error) {
+	uri = strings.TrimSpace(uri)
+	if len(uri) < len("magnet:?") || !strings.EqualFold(uri[:len("magnet:?")], "magnet:?") {
+		return MagnetInfo{}, errorf(msgInvalidMagnet, uri)
+	}
+	query, err := url.ParseQuery(uri[len("magnet:?"):])
+	if err != nil {
+		return MagnetInfo{}, errorf(msgParseMagnetFailed, err)
+	}
+
+	magnet := MagnetInfo{Name: query.Get("dn"), Trackers: []string{}}
+	for _, xt := range query["xt"] {
+		version, hash, err := parseMagnetHash(xt)
+		if err != nil {
+			return MagnetInfo{}, errorf(msgParseMagnetFailed, err)
+		}
+		if version == 1 {
+			magnet.InfoHash = hash
+		} else {
+			magnet.InfoHashV2 = hash
+		}
+	}
+	if magnet.InfoHash == "" && magnet.InfoHashV2 == "" {
+		return MagnetInfo{}, errorf(msgInvalidMagnet, uri)
+	}
+	for _, tracker := range query["tr"] {
+		if tracker = strings.TrimSpace(tracker); tracker != "" {
+			magnet.Trackers = appendUnique(magnet.Trackers, tracker)
+		}
+	}
+	for _, seed := range query["ws"] {
+		if seed = strings.TrimSpace(seed); seed != "" {
+			magnet.WebSeeds = appendUnique(magnet.WebSeeds, seed)
+		}
+	}
+	if xl := query.Get("xl"); xl != "" {
+		magnet.Size, _ = strconv.ParseInt(xl, 10, 64)
+	}
+	return magnet, nil
+}
+
+// torrentMagnetLink 从种子生成磁力链接：v1 和混合种子使用 urn:btih，只有 v2 哈希的种子使用 urn:btmh；
+// 显示名称使用转换为 UTF-8 后的名称，Tracker 按层级顺序去重，公共种子按设置追加公共 Tracker
+func torrentMagnetLink(mi *metainfo.MetaInfo) (string, error) {
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return "", errorf(msgNotTorrentFile, err)
+	}
+	normalizeTorrentNames(mi, &info)
+	magnetLink := ""
+	if info.HasV1() {
+		hash := mi.HashInfoBytes()
+		magnetLink = mi.Magnet(&hash, &info).String()
+	} else {
+		magnetLink = magnetLinkV2(mi, &info)
+	}
+	// 私有种子只能使用站点的 Tracker
+	if info.Private != nil && *info.Private {
+		return magnetLink, nil
+	}
+	return withPublicTrackers(magnetLink), nil
+}
+
+// magnetLinkV2 生成只有 v2 哈希的种子的磁力链接
+func magnetLinkV2(mi *metainfo.MetaInfo, info *metainfo.Info) string {
+	sum := sha256.Sum256(mi.InfoBytes)
+	query := url.Values{}
+	query.Set("dn", info.BestName())
+	var trackers []string
+	for _, tier := range mi.UpvertedAnnounceList() {
+		for _, tracker := range tier {
+			if tracker != "" {
+				trackers = appendUnique(trackers, tracker)
+			}
+		}
+	}
+	query["tr"] = trackers
+	return "magnet:?xt=urn:btmh:1220" + hex.EncodeToString(sum[:]) + "&" + query.Encode()
+}
+
+// fetchMagnetMetadata 用 torrent download --save-metainfos 从 DHT 和 Tracker 获取元数据：命令在临时目录中运行，
+// 获取到元数据后会保存为 <info-hash>.torrent，读取成功后立即结束命令，不下载文件内容
+func fetchMagnetMetadata(ctx context.Context, uri string) (*metainfo.MetaInfo, error) {
+	torrentPath, err := torrentToolPath()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "seedparser-magnet-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(ctx, magnetMetadataTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, torrentPath, "download", "--save-metainfos", uri)
+	cmd.Dir = dir
+	hideWindow(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	defer func() {
+		cancel()
+		<-exited
+	}()
+
+	ticker := time.NewTicker(magnetPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case err := <-exited:
+			exited <- err
+			if err == nil {
+				err = errorf(msgMagnetMetadataNotSaved)
+			}
+			return nil, err
+		case <-ticker.C:
+		}
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.torrent"))
+		for _, path := range matches {
+			// 文件可能还没有写完，读取失败时下次再试
+			mi, err := metainfo.LoadFromFile(path)
+			if err != nil {
+				continue
+			}
+			if _, err := mi.UnmarshalInfo(); err == nil {
+				return mi, nil
+			}
+		}
+	}
+}
+
+// ParseMagnetLink parses a magnet link and optionally fetches its metadata
+// ParseMagnetLink 解析磁力链接，立即返回 info-hash、名称和 Tracker；fetchMetadata 为 true 时还会通过 DHT 和 Tracker
+// 获取元数据（最多等待一分钟），返回与 ParseTorrentFile 相同的文件列表（totalSize、files、fileName、tree、metadata），
+// 获取失败时只返回链接中的信息，metadataError 为失败原因
+func (a *App) ParseMagnetLink(uri string, fetchMetadata bool) (string, error) {
+	magnet, err := parseMagnet(uri)
+	if err != nil {
+		return "", err
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":     "success",
+		"magnetLink": strings.TrimSpace(uri),
+		"magnet":     magnet,
+		"fileName":   magnet.Name,
+		"hasInfo":    false,
+	}
+
+	if fetchMetadata {
+		started := time.Now()
+		mi, err := fetchMagnetMetadata(context.Background(), strings.TrimSpace(uri))
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = errorf(msgMagnetMetadataTimeout)
+			}
+			slog.Warn("获取磁力链接元数据失败", "infoHash", magnet.InfoHash, "error", err)
+			response["metadataError"] = err.Error()
+		} else {
+			info, _ := mi.UnmarshalInfo()
+			nameEncoding := normalizeTorrentNames(mi, &info)
+			files, totalSize := torrentFileList(&info)
+			response["hasInfo"] = true
+			response["files"] = files
+			response["tree"] = torrentFileTree(files)
+			response["stats"] = torrentFileStats(files)
+			metadata := torrentMetadata(mi, &info)
+			metadata.NameEncoding = nameEncoding
+			response["metadata"] = metadata
+			response["totalSize"] = totalSize
+			if magnet.Name == "" {
+				response["fileName"] = info.Name
+			}
+			slog.Info("已获取磁力链接元数据", "infoHash", magnet.InfoHash, "files", len(files), "elapsed", time.Since(started).Round(time.Millisecond))
+		}
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestParseMagnetHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		xt      string
+		version int
+		hash    string
+		wantErr msgKey
+	}{
+		{"v1 十六进制", "urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A", 1, "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", ""},
+		{"v1 base32", "urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK", 1, "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", ""},
+		{"v2", "urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e", 2, "caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e", ""},
+		{"长度无效", "urn:btih:abc", 0, "", msgInvalidInfoHashLength},
+		{"不支持的 multihash", "urn:btmh:1120abcd", 0, "", msgUnsupportedMultihash},
+		{"不支持的 xt", "urn:ed2k:abc", 0, "", msgUnsupportedMagnetXT},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, hash, err := parseMagnetHash(tt.xt)
+			if tt.wantErr != "" {
+				if errorKey(err) != tt.wantErr {
+					t.Fatalf("parseMagnetHash(%q) 的错误为 %v，应为 %s", tt.xt, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || version != tt.version || hash != tt.hash {
+				t.Fatalf("parseMagnetHash(%q) = %d, %q, %v，应为 %d, %q", tt.xt, version, hash, err, tt.version, tt.hash)
+			}
+		})
+	}
+}
@@ -2,11 +2,15 @@ package main
 
 import (
 	"embed"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"path/filepath"
+	"runtime"
+	"strings"
 
 	// 导入wails相关包
 	"github.com/wailsapp/wails/v2"
@@ -30,58 +34,40 @@ var icon []byte
 func customMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// 处理下载目录的文件请求
-		if filepath.HasPrefix(r.URL.Path, "/downloads/") {
-			filePath := filepath.Join(".", r.URL.Path)
-
-			// 安全检查：确保文件在下载目录内
-			absPath, err := filepath.Abs(filePath)
-			if err != nil {
-				http.Error(w, "Invalid file path", http.StatusBadRequest)
-				return
-			}
-
-			absDownloadDir, err := filepath.Abs("./downloads")
-			if err != nil {
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-				return
-			}
-
-			if !filepath.HasPrefix(absPath, absDownloadDir) {
-				http.Error(w, "Access denied", http.StatusForbidden)
-				return
-			}
-
-			// 提供文件
-			http.ServeFile(w, r, absPath)
+		if strings.HasPrefix(r.URL.Path, "/downloads/") {
+			// 安全检查：确保文件（包括符号链接指向的文件）在下载目录内
+			serveFromRoot(w, r, downloadsDir(), strings.TrimPrefix(r.URL.Path, "/downloads/"))
 			return
-		} else if filepath.HasPrefix(r.URL.Path, "/transcode/") {
-			// 处理转码目录的文件请求
-			filePath := filepath.Join(".", r.URL.Path)
-
-			// 安全检查：确保文件在转码目录内
-			absPath, err := filepath.Abs(filePath)
-			if err != nil {
-				http.Error(w, "Invalid file path", http.StatusBadRequest)
-				return
-			}
-
-			absTranscodeDir, err := filepath.Abs("./transcode")
-			if err != nil {
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-				return
-			}
-
-			if !filepath.HasPrefix(absPath, absTranscodeDir) {
-				http.Error(w, "Access denied", http.StatusForbidden)
-				return
-			}
-
-			// 提供文件
-			http.ServeFile(w, r, absPath)
+		} else if strings.HasPrefix(r.URL.Path, "/transcode/") {
+			// 处理转码目录的文件请求，同样确保文件在转码目录内
+			serveFromRoot(w, r, transcodeDir(), strings.TrimPrefix(r.URL.Path, "/transcode/"))
+			return
+		} else if strings.HasPrefix(r.URL.Path, libraryURLPrefix) {
+			// 处理视频库文件夹的文件请求
+			serveLibraryFile(w, r)
+			return
+		} else if strings.HasPrefix(r.URL.Path, thumbnailURLPrefix) {
+			// 处理视频库缩略图请求
+			serveThumbnail(w, r)
+			return
+		} else if strings.HasPrefix(r.URL.Path, frameURLPrefix) {
+			// 处理视频画面预览请求
+			serveFrame(w, r)
+			return
+		} else if strings.HasPrefix(r.URL.Path, streamURLPrefix) {
+			// 处理视频库播放请求
+			serveStream(w, r)
+			return
+		} else if strings.HasPrefix(r.URL.Path, liveURLPrefix) {
+			// 处理实时转码请求
+			serveLiveStream(w, r)
 			return
 		}
 
-		// 其他请求继续使用默认处理
+		// 其他请求继续使用默认处理，前端资源附加 ETag 和缓存头，缓存有效时直接返回 304
+		if setAssetCacheHeaders(w, r) {
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -93,40 +79,72 @@ func main() {
 	app := NewApp()
 	var err error
 
-	// 运行GPU检测测试
-	fmt.Println("===== GPU识别测试 =====")
-	// 由于我们正在测试CPU转码功能，暂时跳过GPU检测
-	fmt.Println("当前模式: CPU编码模式")
-
-	// 检查FFmpeg路径
-	ffmpegPath := ".\tools\ffmpeg\bin\ffmpeg.exe"
-	// 检查ffmpeg是否存在
-	if _, err := os.Stat(ffmpegPath); os.IsNotExist(err) {
-		// 如果指定路径不存在，尝试从系统PATH中查找
-		fmt.Println("指定的FFmpeg路径不存在，尝试从系统PATH中查找...")
-		ffmpegPath = "ffmpeg" // 尝试使用系统PATH中的ffmpeg
+	// macOS 从 Finder 启动时 PATH 不包含 Homebrew 等安装目录
+	extendSearchPath()
+
+	// 确定数据目录，并确保downloads和transcode目录存在
+	if err := initDataDir(); err != nil {
+		log.Fatalf("初始化数据目录失败: %v\n", err)
 	}
-	fmt.Printf("使用FFmpeg路径: %s\n", ffmpegPath)
-	fmt.Println("===== GPU识别测试完成 =====")
-
-	// 确保downloads目录存在
-	if _, err := os.Stat("./downloads"); os.IsNotExist(err) {
-		err := os.Mkdir("./downloads", 0755)
-		if err != nil {
-			log.Printf("创建downloads目录失败: %v\n", err)
+
+	// 命令行子命令（add、status、transcode 等）调用正在运行的实例后直接退出，不初始化日志
+	if isCLICommand(os.Args[1:]) {
+		attachConsole()
+		if err := loadSettings(); err != nil {
+			fmt.Fprintln(os.Stderr, "加载设置失败，使用默认设置:", err)
 		}
+		os.Exit(runCLI(os.Args[1:]))
 	}
 
-	// 确保transcode目录存在
-	if _, err := os.Stat("./transcode"); os.IsNotExist(err) {
-		err := os.Mkdir("./transcode", 0755)
-		if err != nil {
-			log.Printf("创建transcode目录失败: %v\n", err)
+	// 初始化日志，之后的日志同时写入数据目录中的日志文件
+	if err := initLogging(); err != nil {
+		log.Printf("初始化日志失败: %v\n", err)
+	}
+
+	// 加载用户设置，设置文件无效时使用默认设置
+	if err := loadSettings(); err != nil {
+		slog.Error("加载设置失败，使用默认设置", "error", err)
+	}
+
+	// 使用 --server 时不创建窗口，以REST API提供服务
+	serverOpts, serverMode, err := parseServerFlags(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
 		}
+		log.Fatalf("解析命令行参数失败: %v\n", err)
+	}
+	if serverMode {
+		if err := runServer(app, serverOpts); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
+	// 运行GPU检测测试
+	slog.Info("===== GPU识别测试 =====")
+	slog.Info("运行平台", "os", runtime.GOOS, "arch", runtime.GOARCH)
+	// 由于我们正在测试CPU转码功能，暂时跳过GPU检测
+	slog.Info("当前模式: CPU编码模式")
+
+	// 检查FFmpeg路径：设置中的路径、工具目录或系统PATH
+	if ffmpegPath, err := ffmpegToolPath(); err != nil {
+		slog.Warn("未找到FFmpeg", "error", err)
+	} else {
+		slog.Info("使用FFmpeg路径", "path", ffmpegPath)
+	}
+	slog.Info("===== GPU识别测试完成 =====")
+
 	// 移除了复制tools目录的操作
 
+	// 登录时自动启动的窗口以最小化状态启动；开启了最小化到托盘时只显示托盘图标
+	startState := options.Normal
+	startHidden := false
+	if launchedAtLogin(os.Args[1:]) {
+		startState = options.Minimised
+		startHidden = traySupported && currentSettings().MinimizeToTray
+	}
+
 	// Create application with options
 	// 使用选项创建应用
 	err = wails.Run(&options.App{
@@ -140,17 +158,17 @@ func main() {
 		DisableResize:     false,
 		Fullscreen:        false,
 		Frameless:         false,
-		StartHidden:       false,
+		StartHidden:       startHidden,
 		HideWindowOnClose: false,
 		BackgroundColour:  &options.RGBA{R: 255, G: 255, B: 255, A: 0},
 		Menu:              nil,
-		Logger:            nil,
+		Logger:            wailsLogger{},
 		LogLevel:          logger.DEBUG,
 		OnStartup:         app.startup,
 		OnDomReady:        app.domReady,
 		OnBeforeClose:     app.beforeClose,
 		OnShutdown:        app.shutdown,
-		WindowStartState:  options.Normal,
+		WindowStartState:  startState,
 		AssetServer: &assetserver.Options{
 			Assets:     assets,
 			Handler:    nil,
@@ -159,6 +177,10 @@ func main() {
 		Bind: []interface{}{
 			app,
 		},
+		// 拖放到窗口上的文件由后端获取真实路径（OnFileDrop），不需要在前端读取并以 Base64 上传
+		DragAndDrop: &options.DragAndDrop{
+			EnableFileDrop: true,
+		},
 		// Windows platform specific options
 		// Windows平台特定选项
 		Windows: &windows.Options{
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// metadataFile 视频信息缓存文件名，保存在数据目录中
+	metadataFile = "library_metadata.json"
+	// probeTimeout 读取单个文件信息的超时，网络位置上的大文件可能较慢
+	probeTimeout = 30 * time.Second
+)
+
+// EventLibraryMetadata 后台读取完一个视频的信息并生成缩略图后推送，负载为 {path, metadata, thumbnail}
+const EventLibraryMetadata = "library:metadata"
+
+// VideoMetadata holds the media information read by ffprobe
+// VideoMetadata ffprobe 读取的视频信息
+type VideoMetadata struct {
+	// 时长（秒）
+	Duration   float64 `json:"duration"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	VideoCodec string  `json:"videoCodec"`
+	AudioCodec string  `json:"audioCodec"`
+	// 总码率（bit/s）
+	Bitrate        int64 `json:"bitrate"`
+	AudioTracks    int   `json:"audioTracks"`
+	SubtitleTracks int   `json:"subtitleTracks"`
+}
+
+// metadataEntry 缓存的视频信息，文件大小或修改时间变化后重新读取
+type metadataEntry struct {
+	Size     int64          `json:"size"`
+	ModTime  string         `json:"modTime"`
+	Metadata *VideoMetadata `json:"metadata,omitempty"`
+	// 读取失败的原因，文件变化前不再重试
+	Error string `json:"error,omitempty"`
+	// 文件哈希，缩略图以此命名
+	Hash string `json:"hash,omitempty"`
+	// 生成缩略图失败的原因，文件变化前不再重试
+	ThumbnailError string `json:"thumbnailError,omitempty"`
+}
+
+// matches 判断缓存是否对应文件的当前版本
+func (e metadataEntry) matches(video LibraryVideo) bool {
+	return e.Size == video.Size && e.ModTime == video.ModTime
+}
+
+// ffprobeOutput ffprobe -print_format json 输出中用到的字段
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		CodecName   string `json:"codec_name"`
+		Width       int    `json:"width"`
+		Height      int    `json:"height"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+			Default     int `json:"default"`
+			Forced      int `json:"forced"`
+		} `json:"disposition"`
+		Tags struct {
+			Language string `json:"language"`
+			Title    string `json:"title"`
+		} `json:"tags"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+		BitRate  string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+// probeVideo 使用 ffprobe 读取视频信息
+func probeVideo(ffprobePath, path string) (*VideoMetadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, ffprobePath, "-v", "error", "-print_format", "json", "-show_format", "-show_streams", path)
+	hideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, err
+	}
+	metadata := &VideoMetadata{}
+	metadata.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	metadata.Bitrate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			// 跳过 mkv、mp4 中作为封面的图片
+			if stream.Disposition.AttachedPic != 0 || metadata.VideoCodec != "" {
+				continue
+			}
+			metadata.VideoCodec = stream.CodecName
+			metadata.Width, metadata.Height = stream.Width, stream.Height
+		case "audio":
+			if metadata.AudioCodec == "" {
+				metadata.AudioCodec = stream.CodecName
+			}
+			metadata.AudioTracks++
+		case "subtitle":
+			metadata.SubtitleTracks++
+		}
+	}
+	return metadata, nil
+}
+
+// mediaProber 在后台逐个读取视频信息、生成缩略图并缓存到数据目录，获取视频库时只返回已缓存的结果，不等待读取
+type mediaProber struct {
+	mu      sync.Mutex
+	entries map[string]metadataEntry
+	dirty   bool
+	queue   videoQueue
+}
+
+func newMediaProber() *mediaProber {
+	return &mediaProber{entries: make(map[string]metadataEntry)}
+}
+
+// load 读取视频信息缓存，文件不存在时从空缓存开始
+func (m *mediaProber) load() {
+	data, err := readFileWithRecovery(dataPath(metadataFile), func(data []byte) error {
+		var entries map[string]metadataEntry
+		return json.Unmarshal(data, &entries)
+	})
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("读取视频信息缓存失败", "error", err)
+		}
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := json.Unmarshal(data, &m.entries); err != nil {
+		slog.Error("解析视频信息缓存失败", "error", err)
+	}
+}
+
+// save 写入视频信息缓存，没有变化时跳过
+func (m *mediaProber) save() {
+	m.mu.Lock()
+	if !m.dirty {
+		m.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(m.entries)
+	m.dirty = false
+	m.mu.Unlock()
+	if err != nil {
+		slog.Error("保存视频信息缓存失败", "error", err)
+		return
+	}
+	if err := writeFileAtomic(dataPath(metadataFile), data, 0644); err != nil {
+		slog.Error("保存视频信息缓存失败", "error", err)
+	}
+}
+
+// attach 填写已缓存的视频信息和缩略图地址，缺少信息或缩略图的视频加入读取队列，返回是否需要启动后台读取
+func (m *mediaProber) attach(videos []LibraryVideo) bool {
+	m.mu.Lock()
+	var missing []LibraryVideo
+	for i := range videos {
+		if entry, ok := m.entries[videos[i].Path]; ok && entry.matches(videos[i]) {
+			videos[i].Metadata = entry.Metadata
+			hasThumbnail := entry.Hash != "" && thumbnailExists(entry.Hash)
+			if hasThumbnail {
+				videos[i].Thumbnail = thumbnailURL(entry.Hash)
+			}
+			probed := entry.Metadata != nil || entry.Error != ""
+			if probed && (hasThumbnail || entry.ThumbnailError != "") {
+				continue
+			}
+		}
+		missing = append(missing, videos[i])
+	}
+	m.mu.Unlock()
+	return m.queue.push(missing)
+}
+
+// lookup 返回视频的缓存，文件已变化时返回空记录
+func (m *mediaProber) lookup(video LibraryVideo) metadataEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.entries[video.Path]; ok && entry.matches(video) {
+		return entry
+	}
+	return metadataEntry{Size: video.Size, ModTime: video.ModTime}
+}
+
+// store 记录读取结果
+func (m *mediaProber) store(path string, entry metadataEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[path] = entry
+	m.dirty = true
+}
+
+// rename 将视频的缓存转移到新路径，keep 为 true 时同时保留原路径的缓存（例如创建硬链接）
+func (m *mediaProber) rename(from, to string, keep bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[from]
+	if !ok {
+		return
+	}
+	m.entries[to] = entry
+	if !keep {
+		delete(m.entries, from)
+	}
+	m.dirty = true
+}
+
+// remove 删除视频的缓存，用于文件已删除时
+func (m *mediaProber) remove(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[path]; ok {
+		delete(m.entries, path)
+		m.dirty = true
+	}
+}
+
+// attachMetadata 为视频库中的视频填写已缓存的视频信息和缩略图，并在后台读取其余视频的信息、生成缩略图，
+// 每处理完一个推送 EventLibraryMetadata 事件
+func (a *App) attachMetadata(videos []LibraryVideo) {
+	if a.media.attach(videos) {
+		go a.probeQueuedVideos()
+	}
+}
+
+// probeQueuedVideos 逐个读取队列中视频的信息并生成缩略图，队列为空后保存缓存。
+// 找不到 ffprobe 时只生成缩略图，找不到 ffmpeg 时只读取信息
+func (a *App) probeQueuedVideos() {
+	defer a.media.save()
+	ffprobePath, probeErr := ffprobeToolPath()
+	if probeErr != nil {
+		slog.Warn("无法读取视频信息", "error", probeErr)
+	}
+	ffmpegPath, ffmpegErr := ffmpegToolPath()
+	if ffmpegErr != nil {
+		slog.Warn("无法生成缩略图", "error", ffmpegErr)
+	}
+	if probeErr != nil && ffmpegErr != nil {
+		// 清空队列，之后获取视频库时重新加入
+		a.media.queue.clear()
+		return
+	}
+	for {
+		video, ok := a.media.queue.next()
+		if !ok {
+			return
+		}
+		entry := a.media.lookup(video)
+		if probeErr == nil && entry.Metadata == nil && entry.Error == "" {
+			metadata, err := probeVideo(ffprobePath, video.Path)
+			entry.Metadata = metadata
+			if err != nil {
+				slog.Warn("读取视频信息失败", "path", video.Path, "error", err)
+				entry.Error = err.Error()
+			}
+		}
+		if ffmpegErr == nil {
+			ensureThumbnail(ffmpegPath, video.Path, &entry)
+		}
+		a.media.store(video.Path, entry)
+
+		thumbnail := ""
+		if entry.Hash != "" && thumbnailExists(entry.Hash) {
+			thumbnail = thumbnailURL(entry.Hash)
+		}
+		if entry.Metadata != nil || thumbnail != "" {
+			a.emitEvent(EventLibraryMetadata, map[string]interface{}{
+				"path":      video.Path,
+				"metadata":  entry.Metadata,
+				"thumbnail": thumbnail,
+			})
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metricsPath Prometheus 指标地址，认证方式与 REST API 相同（抓取配置中使用 bearer_token 填写API令牌）
+const metricsPath = "/metrics"
+
+// transcodeDurationBuckets 转码耗时直方图的区间上限（秒）
+var transcodeDurationBuckets = []float64{60, 300, 600, 1800, 3600, 7200, 14400}
+
+// histogram 累计分布直方图，counts[i] 为耗时不超过 buckets[i] 的次数
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) histogram {
+	return histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// observe 记录一次观测值
+func (h *histogram) observe(v float64) {
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// clone 返回副本，供加锁外输出
+func (h histogram) clone() histogram {
+	h.counts = append([]uint64(nil), h.counts...)
+	return h
+}
+
+// metricsWriter 按 Prometheus 文本格式输出指标
+type metricsWriter struct {
+	w io.Writer
+}
+
+// header 输出指标的说明和类型
+func (m metricsWriter) header(name, kind, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample 输出一个样本，labels 为成对的标签名和值
+func (m metricsWriter) sample(name string, value float64, labels ...string) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i])
+			b.WriteString(`="`)
+			b.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[i+1]))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(m.w, "%s %s\n", b.String(), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// single 输出只有一个样本的指标
+func (m metricsWriter) single(name, kind, help string, value float64) {
+	m.header(name, kind, help)
+	m.sample(name, value)
+}
+
+// byState 按状态输出任务数，已知状态即使为0也输出，便于告警规则使用
+func (m metricsWriter) byState(name, help string, states []string, counts map[string]int) {
+	m.header(name, "gauge", help)
+	for _, state := range states {
+		if _, ok := counts[state]; !ok {
+			counts[state] = 0
+		}
+	}
+	keys := make([]string, 0, len(counts))
+	for state := range counts {
+		keys = append(keys, state)
+	}
+	sort.Strings(keys)
+	for _, state := range keys {
+		m.sample(name, float64(counts[state]), "state", state)
+	}
+}
+
+// histogram 输出直方图
+func (m metricsWriter) histogram(name, help string, h histogram) {
+	m.header(name, "histogram", help)
+	for i, upper := range h.buckets {
+		m.sample(name+"_bucket", float64(h.counts[i]), "le", strconv.FormatFloat(upper, 'g', -1, 64))
+	}
+	m.sample(name+"_bucket", float64(h.count), "le", "+Inf")
+	m.sample(name+"_sum", h.sum)
+	m.sample(name+"_count", float64(h.count))
+}
+
+// writeMetrics 输出所有指标：任务数（按状态）、队列长度、速度、传输量和转码耗时
+func (a *App) writeMetrics(w io.Writer) {
+	downloads := make(map[string]int)
+	transcodes := make(map[string]int)
+	var speed int64
+	if a.tasks != nil {
+		for _, t := range a.tasks.Downloads() {
+			downloads[t.Status]++
+			if t.Status == "downloading" {
+				speed += t.Speed
+			}
+		}
+		for _, t := range a.tasks.Transcodes() {
+			transcodes[t.Status]++
+		}
+	}
+	session, _, startedAt := a.stats.snapshot()
+	durations := a.stats.transcodeDurations()
+
+	m := metricsWriter{w: w}
+	m.header("seedparser_info", "gauge", "SeedParser version and platform.")
+	m.sample("seedparser_info", 1, "version", appVersion, "os", runtime.GOOS, "arch", runtime.GOARCH)
+	m.single("seedparser_uptime_seconds", "gauge", "Seconds since SeedParser started.", time.Since(startedAt).Seconds())
+
+	m.byState("seedparser_download_tasks", "Download tasks by state.",
+		[]string{"downloading", "waiting", "paused", "completed", "cancelled"}, downloads)
+	m.byState("seedparser_transcode_tasks", "Transcode tasks by state.",
+		[]string{"waiting", "transcoding", "completed", "failed", "cancelled"}, transcodes)
+	m.single("seedparser_download_queue_length", "gauge", "Download tasks waiting for a free slot.", float64(downloads["waiting"]))
+	m.single("seedparser_transcode_queue_length", "gauge", "Transcode tasks waiting for a free slot.", float64(transcodes["waiting"]))
+
+	m.single("seedparser_download_speed_bytes", "gauge", "Current total download speed in bytes per second.", float64(speed))
+	m.single("seedparser_downloaded_bytes_total", "counter", "Bytes downloaded since start.", float64(session.BytesDownloaded))
+	m.single("seedparser_uploaded_bytes_total", "counter", "Bytes uploaded since start.", float64(session.BytesUploaded))
+	m.single("seedparser_downloads_completed_total", "counter", "Downloads completed since start.", float64(session.DownloadsCompleted))
+	m.single("seedparser_transcodes_completed_total", "counter", "Transcodes completed since start.", float64(session.TranscodesCompleted))
+	m.single("seedparser_transcodes_failed_total", "counter", "Transcodes failed since start.", float64(session.TranscodesFailed))
+	m.histogram("seedparser_transcode_duration_seconds", "Duration of finished ffmpeg jobs since start.", durations)
+}
+
+// newMetricsHandler 创建 Prometheus 指标处理器
+func newMetricsHandler(app *App) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeJSONError(w, http.StatusMethodNotAllowed, errorf(msgUseGet, r.URL.Path))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		app.writeMetrics(w)
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+)
+
+// notify 显示系统通知，openPath 不为空时点击通知打开该文件或目录；设置中关闭通知时忽略
+func (a *App) notify(title, message, openPath string) {
+	if !currentSettings().Notifications {
+		return
+	}
+	go func() {
+		if err := showNotification(title, message, openPath); err != nil {
+			slog.Warn("显示系统通知失败", "error", err)
+		}
+	}()
+}
+
+// notifyDownloadFinished 下载结束时通知：完成时点击打开下载目录，异常中断时提示已重新排队
+func (a *App) notifyDownloadFinished(task DownloadTask) {
+	name := task.FileName
+	if name == "" {
+		name = task.TaskID
+	}
+	switch task.Status {
+	case "completed":
+		a.notify(tr(msgNotifyDownloadCompleted), name, task.OutputDir)
+	case "waiting":
+		a.notify(tr(msgNotifyDownloadInterrupted), tr(msgNotifyDownloadRequeued, name), "")
+	}
+}
+
+// notifyTranscodeFinished 转码结束时通知：完成时点击打开输出文件
+func (a *App) notifyTranscodeFinished(task TranscodeTask) {
+	name := filepath.Base(task.OutputFile)
+	switch task.Status {
+	case "completed":
+		a.notify(tr(msgNotifyTranscodeCompleted), name, task.OutputFile)
+	case "failed":
+		a.notify(tr(msgNotifyTranscodeFailed), fmt.Sprintf("%s: %s", filepath.Base(task.InputFile), task.Error), "")
+	}
+}
+
+// TestNotification shows a sample notification so users can check their system settings
+// TestNotification 显示一条测试通知，用于检查系统是否允许 SeedParser 显示通知
+func (a *App) TestNotification() (string, error) {
+	if err := showNotification("SeedParser", tr(msgNotifyEnabled), downloadsDir()); err != nil {
+		return "", errorf(msgNotificationFailed, err)
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Notification shown",
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// appleScriptString 转义为 AppleScript 字符串字面量
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// showNotification 显示 macOS 通知中心通知；安装了 terminal-notifier 时支持点击打开 openPath
+func showNotification(title, message, openPath string) error {
+	if path, err := exec.LookPath("terminal-notifier"); err == nil {
+		args := []string{"-title", title, "-message", message, "-group", "SeedParser"}
+		if openPath != "" {
+			args = append(args, "-open", (&url.URL{Scheme: "file", Path: openPath}).String())
+		}
+		if output, err := exec.Command(path, args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return nil
+	}
+
+	script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+	if output, err := exec.Command("osascript", "-e", script).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
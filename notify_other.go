@@ -0,0 +1,28 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// showNotification 通过 notify-send 显示桌面通知；支持通知操作时，点击通知用 xdg-open 打开 openPath
+func showNotification(title, message, openPath string) error {
+	if openPath != "" {
+		// --action 需要 libnotify 0.7.9 以上，notify-send 会等待通知关闭后输出被点击的操作
+		cmd := exec.Command("notify-send", "--app-name=SeedParser", "--action=default=打开", "--wait", title, message)
+		if output, err := cmd.Output(); err == nil {
+			if strings.TrimSpace(string(output)) == "default" {
+				return exec.Command("xdg-open", openPath).Start()
+			}
+			return nil
+		}
+	}
+
+	if output, err := exec.Command("notify-send", "--app-name=SeedParser", title, message).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// toastAppID 显示通知使用的应用ID；程序未注册自己的 AppUserModelID，借用系统自带的 PowerShell
+const toastAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// toastScript 通过 WinRT 显示 toast 通知，通知内容（XML）从环境变量读取，避免转义问题
+const toastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml($env:SEEDPARSER_TOAST)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($env:SEEDPARSER_TOAST_APP).Show([Windows.UI.Notifications.ToastNotification]::new($xml))
+`
+
+// showNotification 显示 Windows toast 通知，点击时通过 file:// 协议打开 openPath
+func showNotification(title, message, openPath string) error {
+	launch := ""
+	if openPath != "" {
+		fileURL := url.URL{Scheme: "file", Path: "/" + filepath.ToSlash(openPath)}
+		launch = fmt.Sprintf(` activationType="protocol" launch="%s"`, xmlEscape(fileURL.String()))
+	}
+	toast := fmt.Sprintf(`<toast%s><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual></toast>`,
+		launch, xmlEscape(title), xmlEscape(message))
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-Command", toastScript)
+	cmd.Env = append(os.Environ(), "SEEDPARSER_TOAST="+toast, "SEEDPARSER_TOAST_APP="+toastAppID)
+	// 在Windows上隐藏命令窗口
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 整理方式：移动文件，或创建硬链接（原文件保留在下载目录中，可以继续做种）
+const (
+	organizeMove     = "move"
+	organizeHardlink = "hardlink"
+)
+
+// 默认的整理模板，使用 / 分隔目录
+const (
+	defaultOrganizeShowTemplate  = "Shows/{Title}/Season {Season}/{Title} - S{Season:00}E{Episode:00}.{Ext}"
+	defaultOrganizeMovieTemplate = "Movies/{Title} ({Year})/{Title} ({Year}).{Ext}"
+)
+
+// organizePlaceholder 模板中的占位符，例如 {Title}、{Season:00}（补零到两位）
+var organizePlaceholder = regexp.MustCompile(`\{(\w+)(?::(0+))?\}`)
+
+// organizeEmptyBrackets 年份等为空时留下的空括号
+var organizeEmptyBrackets = regexp.MustCompile(`\(\s*\)|\[\s*\]`)
+
+// organizeMu 同一时间只执行一次整理
+var organizeMu sync.Mutex
+
+// OrganizeAction is one file in an organize plan
+// OrganizeAction 整理计划中的一个文件
+type OrganizeAction struct {
+	Source string `json:"source"`
+	// 相对于下载目录的路径，使用 / 分隔
+	RelPath string `json:"relPath"`
+	Target  string `json:"target"`
+	// 相对于整理目录的路径，使用 / 分隔
+	TargetRel string `json:"targetRel"`
+	// show 或 movie
+	Kind string `json:"kind"`
+	// 不整理的原因，为空表示会整理
+	Skip string `json:"skip,omitempty"`
+	// 执行结果
+	Done  bool   `json:"done,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// organizeValues 返回模板中可以使用的值，优先使用 TMDB/TVDB 上匹配到的标题和年份
+func organizeValues(video LibraryVideo) map[string]string {
+	title, year := video.Parsed.Title, video.Parsed.Year
+	if video.Match != nil && video.Match.Title != "" {
+		title = video.Match.Title
+		if video.Match.Year > 0 {
+			year = video.Match.Year
+		}
+	}
+	number := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	ext := filepath.Ext(video.Name)
+	return map[string]string{
+		"Title":      title,
+		"Year":       number(year),
+		"Season":     number(video.Parsed.Season),
+		"Episode":    number(video.Parsed.Episode),
+		"EpisodeEnd": number(video.Parsed.EpisodeEnd),
+		"Ext":        strings.TrimPrefix(ext, "."),
+		"Name":       strings.TrimSuffix(video.Name, ext),
+	}
+}
+
+// sanitizePathPart 去掉文件名中不允许的字符，例如 Star Wars: Episode IV 得到 Star Wars - Episode IV
+func sanitizePathPart(s string) string {
+	s = strings.ReplaceAll(s, ":", " -")
+	s = strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`\/*?"<>|`, r) {
+			return ' '
+		}
+		return r
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// renderOrganizeTemplate 按模板生成相对路径（使用 / 分隔）。缺少的值留空，并去掉因此产生的空括号和多余的分隔符
+func renderOrganizeTemplate(template string, values map[string]string) (string, error) {
+	var unknown string
+	rendered := organizePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		m := organizePlaceholder.FindStringSubmatch(placeholder)
+		value, ok := values[m[1]]
+		if !ok {
+			unknown = placeholder
+			return ""
+		}
+		if width := len(m[2]); width > 0 && value != "" {
+			if n, err := strconv.Atoi(value); err == nil {
+				value = fmt.Sprintf("%0*d", width, n)
+			}
+		}
+		return sanitizePathPart(value)
+	})
+	if unknown != "" {
+		return "", errorf(msgInvalidOrganizeTemplate, template, unknown)
+	}
+
+	parts := strings.Split(rendered, "/")
+	for i, part := range parts {
+		part = organizeEmptyBrackets.ReplaceAllString(part, "")
+		part = strings.Trim(strings.Join(strings.Fields(part), " "), " -.")
+		// 扩展名前的分隔符，例如 Title - .mkv
+		if ext := filepath.Ext(part); i == len(parts)-1 && ext != "" {
+			part = strings.Trim(strings.TrimSuffix(part, ext), " -.") + ext
+		}
+		if part == "" || part == ".." || strings.HasPrefix(part, ".") {
+			return "", errorf(msgInvalidOrganizeTemplate, template, rendered)
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// validateOrganizeTemplate 用示例值检查模板
+func validateOrganizeTemplate(template string) error {
+	if strings.TrimSpace(template) == "" || filepath.IsAbs(template) {
+		return errorf(msgInvalidOrganizeTemplate, template, template)
+	}
+	_, err := renderOrganizeTemplate(template, map[string]string{
+		"Title": "Title", "Year": "2000", "Season": "1", "Episode": "1", "EpisodeEnd": "2", "Ext": "mkv", "Name": "Name",
+	})
+	return err
+}
+
+// organizeDir 返回整理的目标目录，未设置时为下载目录
+func organizeDir() string {
+	if dir := currentSettings().OrganizeDir; dir != "" {
+		return dir
+	}
+	return downloadsDir()
+}
+
+// unfinishedDownloadPaths 返回未完成的下载任务的文件路径，这些文件不会被整理
+func (a *App) unfinishedDownloadPaths() []string {
+	var paths []string
+	for _, task := range a.tasks.Downloads() {
+		if task.Status != "completed" && task.FileName != "" && task.OutputDir != "" {
+			paths = append(paths, filepath.Join(task.OutputDir, task.FileName))
+		}
+	}
+	return paths
+}
+
+// planOrganize 生成下载目录中视频的整理计划，paths 不为空时只整理其中的文件
+func (a *App) planOrganize(paths []string) ([]OrganizeAction, error) {
+	current := currentSettings()
+	root := libraryRoots()[0]
+	if dir, err := filepath.Abs(root.dir); err == nil {
+		root.dir = dir
+	}
+	videos, err := root.scan(current.LibraryMaxDepth, current.LibraryMinSizeMB*1024*1024)
+	if err != nil {
+		return nil, errorf(msgReadDownloadDirFailed, err)
+	}
+	// 只使用已缓存的匹配结果，不触发刮削
+	a.scraper.attach(videos, "")
+
+	selected := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		selected[filepath.Clean(path)] = true
+	}
+	unfinished := a.unfinishedDownloadPaths()
+	targetDir := organizeDir()
+	targets := make(map[string]bool)
+
+	actions := []OrganizeAction{}
+	for _, video := range videos {
+		if len(selected) > 0 && !selected[video.Path] {
+			continue
+		}
+		action := OrganizeAction{Source: video.Path, RelPath: video.RelPath, Kind: "movie"}
+		template := current.OrganizeMovieTemplate
+		if video.Parsed.IsEpisode() {
+			action.Kind, template = "show", current.OrganizeShowTemplate
+		}
+
+		values := organizeValues(video)
+		rel, err := renderOrganizeTemplate(template, values)
+		switch {
+		case values["Title"] == "":
+			action.Skip = tr(msgOrganizeNoTitle)
+		case err != nil:
+			action.Skip = err.Error()
+		default:
+			action.TargetRel = rel
+			action.Target = filepath.Join(targetDir, filepath.FromSlash(rel))
+		}
+		if action.Skip == "" {
+			for _, path := range unfinished {
+				if video.Path == path || strings.HasPrefix(video.Path, path+string(filepath.Separator)) {
+					action.Skip = tr(msgOrganizeUnfinished)
+					break
+				}
+			}
+		}
+		if action.Skip == "" {
+			if info, err := os.Lstat(action.Target); err == nil {
+				// 已经在目标位置的文件不列出
+				if source, err := os.Stat(video.Path); err == nil && os.SameFile(source, info) {
+					continue
+				}
+				action.Skip = tr(msgOrganizeTargetExists)
+			} else if targets[strings.ToLower(action.Target)] {
+				action.Skip = tr(msgOrganizeTargetExists)
+			}
+		}
+		if action.Skip == "" {
+			targets[strings.ToLower(action.Target)] = true
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// copyFile 复制文件并保留修改时间，先写入临时文件，完成后重命名
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := target + ".part"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// moveFile 移动文件，不在同一个磁盘时改为复制后删除原文件
+func moveFile(source, target string) error {
+	err := os.Rename(source, target)
+	if err == nil {
+		return nil
+	}
+	if _, statErr := os.Lstat(target); statErr == nil {
+		return err
+	}
+	slog.Info("重命名失败，改为复制文件", "source", source, "target", target, "error", err)
+	if err := copyFile(source, target); err != nil {
+		return err
+	}
+	return os.Remove(source)
+}
+
+// removeEmptyDirs 删除移动文件后留下的空目录，直到 root 为止
+func removeEmptyDirs(dir, root string) {
+	for dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// applyOrganize 执行一个整理操作
+func (a *App) applyOrganize(action *OrganizeAction, mode, root string) error {
+	if _, err := os.Lstat(action.Target); err == nil {
+		return errorf(msgOrganizeTargetExists)
+	}
+	if err := os.MkdirAll(filepath.Dir(action.Target), 0755); err != nil {
+		return err
+	}
+	if mode == organizeHardlink {
+		if err := os.Link(action.Source, action.Target); err != nil {
+			return err
+		}
+	} else {
+		if err := moveFile(action.Source, action.Target); err != nil {
+			return err
+		}
+		removeEmptyDirs(filepath.Dir(action.Source), root)
+	}
+	// 视频信息、缩略图、匹配结果和播放进度随文件转移
+	keep := mode == organizeHardlink
+	a.media.rename(action.Source, action.Target, keep)
+	a.scraper.rename(action.Source, action.Target, keep)
+	a.playback.rename(action.Source, action.Target, keep)
+	return nil
+}
+
+// PreviewOrganize previews how downloaded videos would be organized
+// PreviewOrganize 预览整理结果：按设置中的模板为下载目录中的视频生成新路径，例如
+// Shows/{Title}/Season {Season}/{Title} - S{Season:00}E{Episode:00}.{Ext}，不修改任何文件。
+// paths 为空时包括下载目录中的所有视频；未完成的下载、识别不出标题或目标已存在的文件会标明跳过原因
+func (a *App) PreviewOrganize(paths []string) (string, error) {
+	actions, err := a.planOrganize(paths)
+	if err != nil {
+		return "", err
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":    "success",
+		"actions":   actions,
+		"targetDir": organizeDir(),
+		"mode":      currentSettings().OrganizeMode,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// ApplyOrganize moves or hardlinks downloaded videos into the organized layout
+// ApplyOrganize 按预览的规则整理视频：移动文件或创建硬链接（取决于设置）。整理计划在执行时重新生成，
+// 预览之后发生变化的文件按新的情况处理；返回每个文件的结果
+func (a *App) ApplyOrganize(paths []string) (string, error) {
+	organizeMu.Lock()
+	defer organizeMu.Unlock()
+
+	actions, err := a.planOrganize(paths)
+	if err != nil {
+		return "", err
+	}
+	mode := currentSettings().OrganizeMode
+	root, err := filepath.Abs(downloadsDir())
+	if err != nil {
+		root = downloadsDir()
+	}
+
+	organized, failed := 0, 0
+	for i := range actions {
+		action := &actions[i]
+		if action.Skip != "" {
+			continue
+		}
+		if err := a.applyOrganize(action, mode, root); err != nil {
+			slog.Warn("整理视频失败", "source", action.Source, "target", action.Target, "error", err)
+			action.Error = err.Error()
+			failed++
+			continue
+		}
+		slog.Info("整理视频", "source", action.Source, "target", action.Target, "mode", mode)
+		action.Done = true
+		organized++
+	}
+	a.media.save()
+	a.scraper.save()
+	a.playback.save()
+	// 整理目录可能是视频库文件夹
+	a.library.invalidate("")
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":    "success",
+		"actions":   actions,
+		"organized": organized,
+		"failed":    failed,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// pausedByPauseAll DownloadTask.PausedBy 和 TranscodeTask.PausedBy 的值，表示任务由 PauseAll 暂停，
+// ResumeAll 只恢复这些任务，之前已由用户暂停的任务保持暂停
+const pausedByPauseAll = "all"
+
+// PauseAll pauses every active download and suspends every running transcode
+// PauseAll 暂停所有正在进行的下载、挂起所有正在进行的转码，等待中的任务保持等待但不会启动，
+// 直到调用 ResumeAll。之前已暂停的任务不受影响
+func (a *App) PauseAll() (string, error) {
+	a.pausedAll.Store(true)
+	slog.Info("全部暂停")
+
+	downloads := 0
+	for _, t := range a.tasks.Downloads() {
+		if t.Status != "downloading" {
+			continue
+		}
+		if err := a.pauseDownload(t.TaskID, pausedByPauseAll); err != nil {
+			slog.Warn("暂停下载任务失败", "taskId", t.TaskID, "error", err)
+			continue
+		}
+		downloads++
+	}
+
+	// 转码进程挂起后可以从原位置继续，已因电池、温度等原因挂起的转码改为全部暂停
+	a.applyTranscodePause()
+	transcodes := 0
+	for _, t := range a.tasks.Transcodes() {
+		if t.PausedBy == pausedByPauseAll {
+			transcodes++
+		}
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":     "success",
+		"message":    "All tasks paused",
+		"pausedAll":  true,
+		"downloads":  downloads,
+		"transcodes": transcodes,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// ResumeAll resumes the tasks paused by PauseAll
+// ResumeAll 恢复由 PauseAll 暂停的下载和转码，并重新开始启动等待中的任务；
+// 程序重启后仍可以恢复之前全部暂停的下载
+func (a *App) ResumeAll() (string, error) {
+	a.pausedAll.Store(false)
+	slog.Info("全部继续")
+
+	// 先恢复之前正在下载的任务，再按优先级启动等待中的任务
+	downloads := 0
+	for _, t := range a.tasks.Downloads() {
+		if t.Status != "paused" || t.PausedBy != pausedByPauseAll {
+			continue
+		}
+		if _, err := a.ResumeDownload(t.TaskID); err != nil {
+			slog.Warn("恢复下载任务失败", "taskId", t.TaskID, "error", err)
+			continue
+		}
+		downloads++
+	}
+	a.startNextWaitingTask()
+
+	transcodes := 0
+	for _, t := range a.tasks.Transcodes() {
+		if t.PausedBy == pausedByPauseAll {
+			transcodes++
+		}
+	}
+	// 仍在使用电池、温度过高或磁盘空间不足时转码保持挂起，只更新暂停原因
+	a.applyTranscodePause()
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":     "success",
+		"message":    "All tasks resumed",
+		"pausedAll":  false,
+		"downloads":  downloads,
+		"transcodes": transcodes,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
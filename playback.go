@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// playbackFile 播放进度文件名，保存在数据目录中
+const playbackFile = "playback_positions.json"
+
+const (
+	// playbackMinPosition 播放不足该秒数时不记录进度，避免只是打开看一眼的视频出现在继续观看中
+	playbackMinPosition = 10
+	// playbackFinishedRatio 播放超过该比例视为看完，下次从头播放
+	playbackFinishedRatio = 0.95
+	// continueWatchingLimit 继续观看默认返回的数量
+	continueWatchingLimit = 20
+)
+
+// PlaybackPosition is the saved playback progress of a video
+// PlaybackPosition 视频的播放进度
+type PlaybackPosition struct {
+	// 上次播放到的位置（秒），看完后为0
+	Position float64 `json:"position"`
+	// 播放器报告的时长（秒）
+	Duration float64 `json:"duration"`
+	// 是否看完过
+	Watched   bool   `json:"watched"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// playbackTracker 记录视频库中视频的播放进度，以完整路径为键
+type playbackTracker struct {
+	mu      sync.Mutex
+	entries map[string]PlaybackPosition
+	dirty   bool
+}
+
+func newPlaybackTracker() *playbackTracker {
+	return &playbackTracker{entries: make(map[string]PlaybackPosition)}
+}
+
+// load 读取播放进度，文件不存在时从空记录开始
+func (p *playbackTracker) load() {
+	data, err := readFileWithRecovery(dataPath(playbackFile), func(data []byte) error {
+		var entries map[string]PlaybackPosition
+		return json.Unmarshal(data, &entries)
+	})
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("读取播放进度失败", "error", err)
+		}
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := json.Unmarshal(data, &p.entries); err != nil {
+		slog.Error("解析播放进度失败", "error", err)
+	}
+}
+
+// save 写入播放进度，没有变化时跳过
+func (p *playbackTracker) save() {
+	p.mu.Lock()
+	if !p.dirty {
+		p.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(p.entries)
+	p.dirty = false
+	p.mu.Unlock()
+	if err != nil {
+		slog.Error("保存播放进度失败", "error", err)
+		return
+	}
+	if err := writeFileAtomic(dataPath(playbackFile), data, 0644); err != nil {
+		slog.Error("保存播放进度失败", "error", err)
+	}
+}
+
+// attach 填写视频的播放进度
+func (p *playbackTracker) attach(videos []LibraryVideo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range videos {
+		if entry, ok := p.entries[videos[i].Path]; ok {
+			videos[i].Playback = &entry
+		}
+	}
+}
+
+// update 记录播放器报告的进度，返回是否有变化
+func (p *playbackTracker) update(path string, position, duration float64) (PlaybackPosition, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry := p.entries[path]
+	switch {
+	case duration > 0 && position >= duration*playbackFinishedRatio:
+		entry.Position, entry.Watched = 0, true
+	case position >= playbackMinPosition:
+		entry.Position = position
+	default:
+		return entry, false
+	}
+	if duration > 0 {
+		entry.Duration = duration
+	}
+	entry.UpdatedAt = time.Now().Format(time.RFC3339)
+	p.entries[path] = entry
+	p.dirty = true
+	return entry, true
+}
+
+// rename 将视频的播放进度转移到新路径，keep 为 true 时同时保留原路径的进度（例如创建硬链接）
+func (p *playbackTracker) rename(from, to string, keep bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[from]
+	if !ok {
+		return
+	}
+	p.entries[to] = entry
+	if !keep {
+		delete(p.entries, from)
+	}
+	p.dirty = true
+}
+
+// remove 删除视频的播放进度，用于文件已删除时
+func (p *playbackTracker) remove(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.entries[path]; ok {
+		delete(p.entries, path)
+		p.dirty = true
+	}
+}
+
+// SavePlaybackPosition saves the playback position reported by the player
+// SavePlaybackPosition 保存播放器报告的播放进度（秒），播放时定期调用以及暂停、关闭播放器时调用。
+// 不足10秒的进度不记录，超过时长95%视为看完，下次从头播放
+func (a *App) SavePlaybackPosition(path string, position float64, duration float64) (string, error) {
+	path = filepath.Clean(path)
+	if info, err := os.Stat(path); err != nil || info.IsDir() || !videoExtensions[strings.ToLower(filepath.Ext(path))] {
+		return "", errorf(msgFileNotFound, path)
+	}
+	entry, changed := a.playback.update(path, position, duration)
+	if changed {
+		a.playback.save()
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":   "success",
+		"playback": entry,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// GetContinueWatching gets the library videos that were partially watched
+// GetContinueWatching 获取继续观看列表：视频库中播放到一半的视频，最近播放的在前，limit 不大于0时返回20个
+func (a *App) GetContinueWatching(limit int) (string, error) {
+	if limit <= 0 {
+		limit = continueWatchingLimit
+	}
+	videos, _, err := a.libraryVideos()
+	if err != nil {
+		return "", err
+	}
+
+	videoFiles := []LibraryVideo{}
+	for _, video := range videos {
+		if video.Playback != nil && video.Playback.Position > 0 {
+			videoFiles = append(videoFiles, video)
+		}
+	}
+	sort.SliceStable(videoFiles, func(i, j int) bool {
+		return videoFiles[i].Playback.UpdatedAt > videoFiles[j].Playback.UpdatedAt
+	})
+	if len(videoFiles) > limit {
+		videoFiles = videoFiles[:limit]
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":     "success",
+		"videoFiles": videoFiles,
+		"total":      len(videoFiles),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// 外部播放器
+const (
+	// playerDefault 使用系统中与文件类型关联的默认程序打开
+	playerDefault = "default"
+	playerVLC     = "vlc"
+	playerMPV     = "mpv"
+)
+
+// playerInstallPaths 各平台播放器的常见安装位置，不在 PATH 中时查找（Windows 安装程序和 macOS 应用程序包都不会加入 PATH）
+func playerInstallPaths(player string) []string {
+	switch runtime.GOOS {
+	case "windows":
+		var paths []string
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)", "LocalAppData"} {
+			dir := os.Getenv(env)
+			if dir == "" {
+				continue
+			}
+			if player == playerVLC {
+				paths = append(paths, filepath.Join(dir, "VideoLAN", "VLC", "vlc.exe"))
+			} else {
+				paths = append(paths, filepath.Join(dir, "mpv", "mpv.exe"), filepath.Join(dir, "Programs", "mpv", "mpv.exe"))
+			}
+		}
+		return paths
+	case "darwin":
+		if player == playerVLC {
+			return []string{"/Applications/VLC.app/Contents/MacOS/VLC"}
+		}
+		return []string{"/Applications/mpv.app/Contents/MacOS/mpv"}
+	}
+	return nil
+}
+
+// playerPath 返回播放器路径：设置了路径时直接使用，否则在工具目录、PATH 和常见安装位置中查找
+func playerPath(player string) (string, error) {
+	configured := currentSettings().VLCPath
+	if player == playerMPV {
+		configured = currentSettings().MPVPath
+	}
+	path, err := resolveTool(configured, player)
+	if err == nil {
+		return path, nil
+	}
+	for _, candidate := range playerInstallPaths(player) {
+		if info, statErr := os.Stat(candidate); statErr == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", errorf(msgPlayerNotFound, player, err)
+}
+
+// launchPlayer 用播放器打开文件，不等待播放器退出
+func launchPlayer(player, path string) error {
+	if player == playerDefault {
+		if err := openWithDefault(path); err != nil {
+			return errorf(msgLaunchPlayerFailed, err)
+		}
+		return nil
+	}
+	exe, err := playerPath(player)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, path)
+	if err := cmd.Start(); err != nil {
+		return errorf(msgLaunchPlayerFailed, err)
+	}
+	// 回收播放器进程，避免留下僵尸进程
+	go cmd.Wait()
+	return nil
+}
+
+// PlayExternal opens a library video in an external player
+// PlayExternal 用外部播放器打开视频库中的视频，用于内置播放器不支持的格式（例如 HEVC、DTS 音轨的 mkv）。
+// player 为 vlc、mpv 或 default（系统默认程序），留空使用设置中的默认外部播放器；
+// VLC 和 mpv 的路径可以在设置中指定，留空时在工具目录、PATH 和常见安装位置中查找
+func (a *App) PlayExternal(path string, player string) (string, error) {
+	if player == "" {
+		player = currentSettings().ExternalPlayer
+	}
+	switch player {
+	case playerDefault, playerVLC, playerMPV:
+	default:
+		return "", errorf(msgInvalidPlayer, player)
+	}
+	video, err := a.findLibraryVideo(path)
+	if err != nil {
+		return "", err
+	}
+	if err := launchPlayer(player, video.Path); err != nil {
+		slog.Error("打开外部播放器失败", "path", video.Path, "player", player, "error", err)
+		return "", err
+	}
+	slog.Info("已用外部播放器打开视频", "path", video.Path, "player", player)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"path":   video.Path,
+		"player": player,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 插件是数据目录 plugins 下的子目录，包含清单文件 plugin.json：
+//
+//	{
+//	  "name": "auto-tag",
+//	  "description": "按文件名给下载打标签",
+//	  "command": "python",
+//	  "args": ["tag.py"],
+//	  "hooks": ["torrent.added", "download.completed"],
+//	  "timeout": 60
+//	}
+//
+// 事件发生时在插件目录中运行 command，标准输入为 JSON 格式的 HookPayload，
+// 环境变量 SEEDPARSER_HOOK、SEEDPARSER_TASK_ID 为钩子名和任务ID，SEEDPARSER_API_URL 为本机API地址，
+// 设置了API令牌时 SEEDPARSER_API_TOKEN 为令牌，插件可以通过API继续操作（例如添加转码任务）。
+// 退出码非0视为失败，输出记录到日志
+const (
+	// pluginsDirName 插件目录名，位于数据目录中
+	pluginsDirName = "plugins"
+	// pluginManifestFile 插件的清单文件
+	pluginManifestFile = "plugin.json"
+	// defaultPluginTimeout 清单未指定 timeout 时单次运行的超时
+	defaultPluginTimeout = 5 * time.Minute
+	// pluginOutputLimit 记录到日志的插件输出长度上限
+	pluginOutputLimit = 4096
+)
+
+// 插件可以订阅的钩子
+const (
+	HookTorrentAdded       = "torrent.added"
+	HookDownloadCompleted  = "download.completed"
+	HookTranscodeCompleted = "transcode.completed"
+)
+
+// pluginHooks 所有钩子，用于校验清单
+var pluginHooks = []string{HookTorrentAdded, HookDownloadCompleted, HookTranscodeCompleted}
+
+// PluginManifest is the content of a plugin's plugin.json
+// PluginManifest 插件清单
+type PluginManifest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+	// 运行的命令，插件目录中存在同名文件时使用该文件，否则在 PATH 中查找
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// 订阅的钩子
+	Hooks []string `json:"hooks"`
+	// 单次运行的超时（秒），0表示使用默认值
+	Timeout int `json:"timeout"`
+}
+
+// Plugin is an installed plugin and its latest run result
+// Plugin 已安装的插件及最近一次运行的结果
+type Plugin struct {
+	PluginManifest
+	Dir     string `json:"dir"`
+	Enabled bool   `json:"enabled"`
+	// 清单无效时的原因，无效的插件不会运行
+	LoadError string    `json:"loadError,omitempty"`
+	LastRun   time.Time `json:"lastRun"`
+	LastHook  string    `json:"lastHook,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// HookPayload is written to the plugin's standard input
+// HookPayload 传给插件标准输入的事件信息
+type HookPayload struct {
+	Hook         string      `json:"hook"`
+	Time         string      `json:"time"`
+	TaskType     string      `json:"taskType"` // download, transcode
+	TaskID       string      `json:"taskId"`
+	Name         string      `json:"name"`
+	Status       string      `json:"status"`
+	Task         interface{} `json:"task"`
+	DownloadDir  string      `json:"downloadDir"`
+	TranscodeDir string      `json:"transcodeDir"`
+	APIURL       string      `json:"apiUrl"`
+}
+
+// pluginManager 加载插件并在事件发生时运行
+type pluginManager struct {
+	mu      sync.Mutex
+	plugins []*Plugin
+}
+
+func newPluginManager() *pluginManager {
+	return &pluginManager{}
+}
+
+// pluginsDir 返回插件目录
+func pluginsDir() string {
+	return dataPath(pluginsDirName)
+}
+
+// validate 检查插件清单
+func (m *PluginManifest) validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return errorf(msgPluginNoName)
+	}
+	if strings.TrimSpace(m.Command) == "" {
+		return errorf(msgPluginNoCommand)
+	}
+	if m.Timeout < 0 {
+		return errorf(msgPluginInvalidTimeout, m.Timeout)
+	}
+	for _, hook := range m.Hooks {
+		valid := false
+		for _, h := range pluginHooks {
+			valid = valid || hook == h
+		}
+		if !valid {
+			return errorf(msgPluginInvalidHook, hook)
+		}
+	}
+	return nil
+}
+
+// load 重新扫描插件目录，保留同名插件最近一次运行的结果
+func (m *pluginManager) load() {
+	entries, err := os.ReadDir(pluginsDir())
+	if err != nil && !os.IsNotExist(err) {
+		slog.Error("读取插件目录失败", "error", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous := make(map[string]*Plugin)
+	for _, p := range m.plugins {
+		previous[p.Dir] = p
+	}
+
+	var plugins []*Plugin
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(pluginsDir(), entry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, pluginManifestFile))
+		if os.IsNotExist(err) {
+			continue
+		}
+		p := &Plugin{Dir: dir}
+		if old, ok := previous[dir]; ok {
+			p.LastRun, p.LastHook, p.LastError = old.LastRun, old.LastHook, old.LastError
+		}
+		if err == nil {
+			err = json.Unmarshal(data, &p.PluginManifest)
+		}
+		if err == nil {
+			err = p.validate()
+		}
+		if err == nil && names[p.Name] {
+			err = errorf(msgPluginDuplicateName, p.Name)
+		}
+		if p.Name == "" {
+			p.Name = entry.Name()
+		}
+		if err != nil {
+			p.LoadError = err.Error()
+			slog.Warn("插件无效", "dir", dir, "error", err)
+		}
+		names[p.Name] = true
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	m.plugins = plugins
+	slog.Info("已加载插件", "dir", pluginsDir(), "count", len(plugins))
+}
+
+// list 返回所有插件的副本，Enabled 按当前设置填写
+func (m *pluginManager) list() []Plugin {
+	disabled := make(map[string]bool)
+	for _, name := range currentSettings().DisabledPlugins {
+		disabled[name] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	plugins := make([]Plugin, 0, len(m.plugins))
+	for _, p := range m.plugins {
+		plugin := *p
+		plugin.Enabled = plugin.LoadError == "" && !disabled[plugin.Name]
+		plugins = append(plugins, plugin)
+	}
+	return plugins
+}
+
+// subscribed 判断插件是否订阅了钩子
+func (p Plugin) subscribed(hook string) bool {
+	for _, h := range p.Hooks {
+		if h == hook {
+			return true
+		}
+	}
+	return false
+}
+
+// command 返回要运行的程序：插件目录中存在该文件时使用插件目录中的文件
+func (p Plugin) command() string {
+	if filepath.IsAbs(p.Command) {
+		return p.Command
+	}
+	path := filepath.Join(p.Dir, p.Command)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return path
+	}
+	return p.Command
+}
+
+// run 运行一次插件，超时后终止
+func (p Plugin) run(payload HookPayload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	timeout := defaultPluginTimeout
+	if p.Timeout > 0 {
+		timeout = time.Duration(p.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.command(), p.Args...)
+	cmd.Dir = p.Dir
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"SEEDPARSER_HOOK="+payload.Hook,
+		"SEEDPARSER_TASK_ID="+payload.TaskID,
+		"SEEDPARSER_API_URL="+payload.APIURL,
+	)
+	// 没有设置API令牌时使用本机API令牌，插件只通过本机地址调用API
+	token := currentSettings().APIToken
+	if token == "" {
+		token = localAPIToken()
+	}
+	if token != "" {
+		cmd.Env = append(cmd.Env, "SEEDPARSER_API_TOKEN="+token)
+	}
+	hideWindow(cmd)
+
+	output, err := cmd.CombinedOutput()
+	if len(output) > pluginOutputLimit {
+		output = output[:pluginOutputLimit]
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = errorf(msgPluginTimeout, timeout)
+	}
+	if err != nil {
+		slog.Warn("插件运行失败", "plugin", p.Name, "hook", payload.Hook, "taskId", payload.TaskID, "error", err, "output", string(output))
+		return err
+	}
+	slog.Info("插件运行完成", "plugin", p.Name, "hook", payload.Hook, "taskId", payload.TaskID, "output", string(output))
+	return nil
+}
+
+// finished 记录插件最近一次运行的结果
+func (m *pluginManager) finished(dir, hook string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.plugins {
+		if p.Dir != dir {
+			continue
+		}
+		p.LastRun = time.Now()
+		p.LastHook = hook
+		p.LastError = ""
+		if err != nil {
+			p.LastError = err.Error()
+		}
+	}
+}
+
+// pluginHookFor 任务事件对应的插件钩子，没有对应钩子时返回空字符串
+func pluginHookFor(payload WebhookPayload) string {
+	switch {
+	case payload.Event == WebhookTaskAdded && payload.TaskType == "download":
+		return HookTorrentAdded
+	case payload.Event == WebhookTaskCompleted && payload.TaskType == "download":
+		return HookDownloadCompleted
+	case payload.Event == WebhookTaskCompleted && payload.TaskType == "transcode":
+		return HookTranscodeCompleted
+	}
+	return ""
+}
+
+// runPluginHooks 在后台运行订阅了该事件的所有已启用插件
+func (a *App) runPluginHooks(payload WebhookPayload) {
+	hook := pluginHookFor(payload)
+	if hook == "" {
+		return
+	}
+	hookPayload := HookPayload{
+		Hook:         hook,
+		Time:         payload.Time,
+		TaskType:     payload.TaskType,
+		TaskID:       payload.TaskID,
+		Name:         payload.Name,
+		Status:       payload.Status,
+		Task:         payload.Task,
+		DownloadDir:  downloadsDir(),
+		TranscodeDir: transcodeDir(),
+		APIURL:       "http://127.0.0.1:" + strconv.Itoa(currentSettings().ServerPort) + apiPrefix,
+	}
+	for _, p := range a.plugins.list() {
+		if !p.Enabled || !p.subscribed(hook) {
+			continue
+		}
+		go func(p Plugin) {
+			a.plugins.finished(p.Dir, hook, p.run(hookPayload))
+		}(p)
+	}
+}
+
+// GetPlugins lists installed plugins
+// GetPlugins 获取已安装的插件、插件目录和可订阅的钩子
+func (a *App) GetPlugins() (string, error) {
+	response := map[string]interface{}{
+		"status":  "success",
+		"plugins": a.plugins.list(),
+		"dir":     pluginsDir(),
+		"hooks":   pluginHooks,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// ReloadPlugins rescans the plugins directory
+// ReloadPlugins 重新扫描插件目录，安装或修改插件后调用；插件目录不存在时创建
+func (a *App) ReloadPlugins() (string, error) {
+	if err := os.MkdirAll(pluginsDir(), 0755); err != nil {
+		return "", errorf(msgCreateDirFailed, err)
+	}
+	a.plugins.load()
+	return a.GetPlugins()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// powerCheckInterval 检查是否使用电池供电的间隔
+	powerCheckInterval = 30 * time.Second
+	// pausedByBattery TranscodeTask.PausedBy 的值，表示转码进程因使用电池而挂起
+	pausedByBattery = "battery"
+)
+
+// powerMonitor 跟踪电源状态：切换到电池时按设置挂起转码、限制下载速度，接通电源后自动恢复
+type powerMonitor struct {
+	mu        sync.Mutex
+	onBattery bool
+	cancel    context.CancelFunc
+}
+
+// onBattery 当前是否使用电池供电
+func (a *App) onBattery() bool {
+	a.power.mu.Lock()
+	defer a.power.mu.Unlock()
+	return a.power.onBattery
+}
+
+// transcodesPausedForBattery 使用电池时是否暂停转码，暂停期间不启动新的转码任务
+func (a *App) transcodesPausedForBattery() bool {
+	return currentSettings().BatteryPauseTranscodes && a.onBattery()
+}
+
+// downloadRateLimit 返回新启动的下载使用的下载限速（KB/s），使用电池时取设置的限速和电池限速中较小的一个
+func (a *App) downloadRateLimit() int64 {
+	s := currentSettings()
+	limit := s.DownloadSpeedLimit
+	if s.BatteryDownloadLimit > 0 && a.onBattery() && (limit == 0 || s.BatteryDownloadLimit < limit) {
+		limit = s.BatteryDownloadLimit
+	}
+	return limit
+}
+
+// checkPower 读取电源状态，发生变化时调整任务
+func (a *App) checkPower() {
+	battery, err := onBatteryPower()
+	if err != nil {
+		slog.Debug("读取电源状态失败", "error", err)
+		return
+	}
+	a.power.mu.Lock()
+	changed := battery != a.power.onBattery
+	a.power.onBattery = battery
+	a.power.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if battery {
+		slog.Info("已切换到电池供电")
+	} else {
+		slog.Info("已接通电源")
+	}
+	a.applyPowerState()
+}
+
+// applyPowerState 按电源状态和设置挂起或恢复转码、重启下载以应用新的限速。
+// 修改相关设置后也会调用
+func (a *App) applyPowerState() {
+	if a.tasks == nil {
+		return
+	}
+	a.applyTranscodePause()
+	a.restartDownloadsForRateLimit()
+}
+
+// transcodePauseReason 返回当前应暂停转码的原因（全部暂停、磁盘空间不足、使用电池或温度过高），不需要暂停时为空
+func (a *App) transcodePauseReason() string {
+	switch {
+	case a.pausedAll.Load():
+		return pausedByPauseAll
+	case a.diskSpaceLow(diskTranscode):
+		return pausedByDiskSpace
+	case a.transcodesPausedForBattery():
+		return pausedByBattery
+	case a.transcodesPausedForThermal():
+		return pausedByThermal
+	}
+	return ""
+}
+
+// applyTranscodePause 按暂停原因挂起或恢复正在进行的转码；已挂起的转码只更新原因，
+// 所有原因都消失后才恢复
+func (a *App) applyTranscodePause() {
+	if a.tasks == nil {
+		return
+	}
+	reason := a.transcodePauseReason()
+	for _, t := range a.tasks.Transcodes() {
+		if t.Status != "transcoding" || t.PID == 0 {
+			continue
+		}
+		switch {
+		case reason != "" && t.PausedBy == "":
+			if err := processes.Suspend(t.PID); err != nil {
+				slog.Error("挂起转码进程失败", "taskId", t.TaskID, "pid", t.PID, "error", err)
+				continue
+			}
+			slog.Info("已挂起转码", "taskId", t.TaskID, "reason", reason)
+			a.setTranscodePausedBy(t.TaskID, reason)
+		case reason != "" && t.PausedBy != reason:
+			a.setTranscodePausedBy(t.TaskID, reason)
+		case reason == "" && t.PausedBy != "":
+			if err := processes.Resume(t.PID); err != nil {
+				slog.Error("恢复转码进程失败", "taskId", t.TaskID, "pid", t.PID, "error", err)
+				continue
+			}
+			slog.Info("已恢复转码", "taskId", t.TaskID)
+			a.setTranscodePausedBy(t.TaskID, "")
+		}
+	}
+	if reason == "" {
+		if err := a.startNextTranscodeTask(); err != nil {
+			slog.Error("启动等待的转码任务失败", "error", err)
+		}
+	}
+}
+
+// setTranscodePausedBy 修改转码任务的暂停原因并通知前端
+func (a *App) setTranscodePausedBy(taskID, pausedBy string) {
+	task, found := a.tasks.UpdateTranscode(taskID, func(t *TranscodeTask) {
+		t.PausedBy = pausedBy
+	})
+	if found {
+		a.emitTranscodeProgress(task, true)
+	}
+}
+
+// startPowerMonitor 定期检查电源状态
+func (a *App) startPowerMonitor() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.power.cancel = cancel
+	a.checkPower()
+	go func() {
+		ticker := time.NewTicker(powerCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.checkPower()
+			}
+		}
+	}()
+}
+
+// stopPowerMonitor 停止检查电源状态，恢复挂起的转码进程，避免程序退出后进程一直处于挂起状态
+func (a *App) stopPowerMonitor() {
+	if a.power.cancel != nil {
+		a.power.cancel()
+	}
+	for _, t := range a.tasks.Transcodes() {
+		if t.PausedBy != "" && t.PID != 0 {
+			if err := processes.Resume(t.PID); err != nil {
+				slog.Warn("恢复转码进程失败", "taskId", t.TaskID, "pid", t.PID, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// suspendSystem 使系统睡眠；macOS 的休眠由系统的 hibernatemode 决定，不能单独触发
+func suspendSystem(hibernate bool) error {
+	if hibernate {
+		return errorf(msgHibernateUnsupported)
+	}
+	return exec.Command("pmset", "sleepnow").Run()
+}
+
+// shutdownSystem 通过 System Events 关机，与在菜单中选择关机相同，其他程序有机会保存数据
+func shutdownSystem() error {
+	return exec.Command("osascript", "-e", `tell application "System Events" to shut down`).Run()
+}
+
+// onBatteryPower 是否使用电池供电，pmset -g batt 的第一行为 Now drawing from 'Battery Power' 或 'AC Power'
+func onBatteryPower() (bool, error) {
+	output, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(output), "'Battery Power'"), nil
+}
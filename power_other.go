@@ -0,0 +1,52 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// powerSupplyDir 内核导出的电源信息
+const powerSupplyDir = "/sys/class/power_supply"
+
+// suspendSystem 通过 systemd 使系统挂起或休眠
+func suspendSystem(hibernate bool) error {
+	action := "suspend"
+	if hibernate {
+		action = "hibernate"
+	}
+	return exec.Command("systemctl", action).Run()
+}
+
+// shutdownSystem 通过 systemd 关机
+func shutdownSystem() error {
+	return exec.Command("systemctl", "poweroff").Run()
+}
+
+// onBatteryPower 是否使用电池供电：存在交流电源（type 为 Mains）且都未接通时视为使用电池。
+// 台式机和服务器通常没有 Mains 电源，按接通电源处理
+func onBatteryPower() (bool, error) {
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	mains := false
+	for _, entry := range entries {
+		dir := filepath.Join(powerSupplyDir, entry.Name())
+		kind, err := os.ReadFile(filepath.Join(dir, "type"))
+		if err != nil || strings.TrimSpace(string(kind)) != "Mains" {
+			continue
+		}
+		mains = true
+		if online, err := os.ReadFile(filepath.Join(dir, "online")); err == nil && strings.TrimSpace(string(online)) == "1" {
+			return false, nil
+		}
+	}
+	return mains, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os/exec"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	procSetSuspendState      = windows.NewLazySystemDLL("powrprof.dll").NewProc("SetSuspendState")
+	procGetSystemPowerStatus = kernel32.NewProc("GetSystemPowerStatus")
+)
+
+// systemPowerStatus SYSTEM_POWER_STATUS 结构
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// suspendSystem 使系统睡眠或休眠
+func suspendSystem(hibernate bool) error {
+	var h uintptr
+	if hibernate {
+		h = 1
+	}
+	if r, _, err := procSetSuspendState.Call(h, 0, 0); r == 0 {
+		return err
+	}
+	return nil
+}
+
+// shutdownSystem 关机，其他程序有机会保存数据
+func shutdownSystem() error {
+	cmd := exec.Command("shutdown", "/s", "/t", "0")
+	hideWindow(cmd)
+	return cmd.Run()
+}
+
+// onBatteryPower 是否使用电池供电；ACLineStatus 为0表示未接通电源，255（未知）按接通电源处理
+func onBatteryPower() (bool, error) {
+	var status systemPowerStatus
+	if r, _, err := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status))); r == 0 {
+		return false, err
+	}
+	return status.ACLineStatus == 0, nil
+}
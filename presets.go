@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TranscodePreset is a named set of FFmpeg options
+// TranscodePreset 转码预设，Params 为传给 ffmpeg 的参数（不含输入和输出文件）
+type TranscodePreset struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	VideoCodec  string `json:"videoCodec"`
+	AudioCodec  string `json:"audioCodec"`
+	Resolution  string `json:"resolution"`
+	Extension   string `json:"extension"`
+	Params      string `json:"params"`
+}
+
+// transcodePresets 内置的转码预设
+var transcodePresets = map[string]TranscodePreset{
+	"720p": {
+		Name:        "720p",
+		Description: "H.264 720p，兼顾体积和画质",
+		VideoCodec:  "libx264",
+		AudioCodec:  "aac",
+		Resolution:  "720p",
+		Extension:   "mp4",
+		Params:      "-c:v libx264 -c:a aac -preset medium -crf 23 -vf scale=-2:720",
+	},
+	"1080p": {
+		Name:        "1080p",
+		Description: "H.264 1080p",
+		VideoCodec:  "libx264",
+		AudioCodec:  "aac",
+		Resolution:  "1080p",
+		Extension:   "mp4",
+		Params:      "-c:v libx264 -c:a aac -preset medium -crf 21 -vf scale=-2:1080",
+	},
+	"h265": {
+		Name:        "h265",
+		Description: "H.265 保持原分辨率，体积约为 H.264 的一半",
+		VideoCodec:  "libx265",
+		AudioCodec:  "aac",
+		Extension:   "mp4",
+		Params:      "-c:v libx265 -c:a aac -preset medium -crf 28 -tag:v hvc1",
+	},
+	"mobile": {
+		Name:        "mobile",
+		Description: "H.264 480p，适合手机播放和在线预览",
+		VideoCodec:  "libx264",
+		AudioCodec:  "aac",
+		Resolution:  "480p",
+		Extension:   "mp4",
+		Params:      "-c:v libx264 -c:a aac -preset fast -crf 26 -vf scale=-2:480 -movflags +faststart",
+	},
+	"audio": {
+		Name:        "audio",
+		Description: "只提取音频为 MP3",
+		AudioCodec:  "libmp3lame",
+		Extension:   "mp3",
+		Params:      "-vn -c:a libmp3lame -q:a 2",
+	},
+}
+
+// presetOutputFile 生成预设的默认输出路径：与输入文件同目录，文件名加上预设名
+func presetOutputFile(inputFile string, preset TranscodePreset) string {
+	base := strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile))
+	return filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s_%s.%s", base, preset.Name, preset.Extension))
+}
+
+// GetTranscodePresets returns the built-in transcode presets
+// GetTranscodePresets 获取内置的转码预设
+func (a *App) GetTranscodePresets() (string, error) {
+	presets := make([]TranscodePreset, 0, len(transcodePresets))
+	for _, preset := range transcodePresets {
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"presets": presets,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// AddTranscodeTaskWithPreset adds a transcoding task using a built-in preset
+// AddTranscodeTaskWithPreset 使用内置预设添加转码任务，outputFile 留空时输出到输入文件所在目录
+func (a *App) AddTranscodeTaskWithPreset(inputFile string, outputFile string, presetName string) (string, error) {
+	preset, ok := transcodePresets[presetName]
+	if !ok {
+		return "", errorf(msgUnknownPreset, presetName)
+	}
+	if outputFile == "" {
+		outputFile = presetOutputFile(inputFile, preset)
+	}
+	return a.AddTranscodeTaskWithParams(inputFile, outputFile, preset.VideoCodec, preset.AudioCodec, preset.Resolution, "", preset.Params)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+// 下载任务的优先级，没有设置（旧任务）时为 normal
+const (
+	priorityHigh   = "high"
+	priorityNormal = "normal"
+	priorityLow    = "low"
+)
+
+// priorityWeights 优先级的权重：等待中的任务按权重从高到低启动；设置了下载限速时，
+// 同时下载的任务按权重比例分配限速，各任务的限速之和不超过设置的限速
+var priorityWeights = map[string]int64{
+	priorityHigh:   4,
+	priorityNormal: 2,
+	priorityLow:    1,
+}
+
+// priorityWeight 返回优先级的权重，未设置时按 normal 计算
+func priorityWeight(priority string) int64 {
+	if weight, ok := priorityWeights[priority]; ok {
+		return weight
+	}
+	return priorityWeights[priorityNormal]
+}
+
+// downloadRates 正在进行的下载启动时使用的下载限速（KB/s）。下载限速只能在启动下载进程时指定，
+// 全局限速或同时下载的任务的优先级变化后，限速不同的任务需要重启
+type downloadRates struct {
+	mu      sync.Mutex
+	applied map[string]int64
+	// rebalance 同一时间只进行一次重新分配，重启下载期间的其他请求等待完成后再检查
+	rebalance sync.Mutex
+}
+
+// setAppliedRate 记录下载进程启动时使用的限速
+func (a *App) setAppliedRate(taskID string, limit int64) {
+	a.rates.mu.Lock()
+	defer a.rates.mu.Unlock()
+	if a.rates.applied == nil {
+		a.rates.applied = make(map[string]int64)
+	}
+	a.rates.applied[taskID] = limit
+}
+
+// splitRateLimit 按权重把下载限速 limit（KB/s）分给同时下载的任务，各任务的限速之和不超过 limit。
+// 限速为 0 表示不限速，所以每个任务至少 1 KB/s，只有 limit 小于任务数时总和才会超过 limit
+func splitRateLimit(limit int64, weights map[string]int64) map[string]int64 {
+	var total int64
+	for _, weight := range weights {
+		total += weight
+	}
+	rates := make(map[string]int64, len(weights))
+	for taskID, weight := range weights {
+		rates[taskID] = max(1, limit*weight/total)
+	}
+	return rates
+}
+
+// taskRateLimit 返回下载任务应使用的下载限速（KB/s），0表示不限速：在同时下载的任务（包括该任务）中按权重分配，
+// 没有设置下载限速时无法分配，所有任务都不限速
+func (a *App) taskRateLimit(taskID string) int64 {
+	limit := a.downloadRateLimit()
+	if limit <= 0 {
+		return 0
+	}
+	weights := map[string]int64{taskID: priorityWeight(priorityNormal)}
+	for _, t := range a.tasks.Downloads() {
+		if t.TaskID == taskID || t.Status == "downloading" {
+			weights[t.TaskID] = priorityWeight(t.Priority)
+		}
+	}
+	return splitRateLimit(limit, weights)[taskID]
+}
+
+// waitingDownloads 返回等待中的下载任务，优先级高的在前，相同优先级按添加顺序
+func (a *App) waitingDownloads() []DownloadTask {
+	var waiting []DownloadTask
+	for _, t := range a.tasks.Downloads() {
+		if t.Status == "waiting" {
+			waiting = append(waiting, t)
+		}
+	}
+	sort.SliceStable(waiting, func(i, j int) bool {
+		return priorityWeight(waiting[i].Priority) > priorityWeight(waiting[j].Priority)
+	})
+	return waiting
+}
+
+// restartDownloadsForRateLimit 下载限速只对新启动的下载生效，全局限速或同时下载的任务变化后，
+// 原地重启限速与应分配的限速不同的下载
+func (a *App) restartDownloadsForRateLimit() {
+	a.rates.rebalance.Lock()
+	defer a.rates.rebalance.Unlock()
+
+	downloads := a.tasks.Downloads()
+	running := make(map[string]bool)
+	for _, t := range downloads {
+		if t.Status == "downloading" {
+			running[t.TaskID] = true
+		}
+	}
+	a.rates.mu.Lock()
+	for taskID := range a.rates.applied {
+		if !running[taskID] {
+			delete(a.rates.applied, taskID)
+		}
+	}
+	applied := make(map[string]int64, len(a.rates.applied))
+	for taskID, limit := range a.rates.applied {
+		applied[taskID] = limit
+	}
+	a.rates.mu.Unlock()
+
+	for _, t := range downloads {
+		previous, ok := applied[t.TaskID]
+		if !running[t.TaskID] || !ok {
+			continue
+		}
+		limit := a.taskRateLimit(t.TaskID)
+		if limit == previous {
+			continue
+		}
+		slog.Info("下载限速已变化，重启下载", "taskId", t.TaskID, "priority", t.Priority, "limit", limit)
+		if err := a.restartDownload(t.TaskID); err != nil {
+			slog.Warn("重启下载任务失败", "taskId", t.TaskID, "error", err)
+		}
+	}
+}
+
+// restartDownload 重启正在进行的下载，使新的限速生效。任务一直保持下载中，不会让出下载名额，
+// 也不会重新排队到等待中的任务之后；原进程的下载协程发现 PID 已变化后不再修改任务
+func (a *App) restartDownload(taskID string) error {
+	var pid int
+	task, _ := a.tasks.UpdateDownload(taskID, func(t *DownloadTask) {
+		if t.Status != "downloading" {
+			return
+		}
+		pid = t.PID
+		t.PID = 0
+		t.Speed = 0
+	})
+	if pid == 0 {
+		return nil
+	}
+	stopProcess(pid, processStopTimeout)
+
+	// 停止进程期间任务可能被暂停或取消
+	if current, found := a.tasks.Download(taskID); !found || current.Status != "downloading" || current.PID != 0 {
+		return nil
+	}
+	if err := a.startDownload(taskID, task.MagnetLink, task.OutputDir); err != nil {
+		// 无法重启时重新排队，避免任务一直显示为下载中
+		task, _ = a.tasks.UpdateDownload(taskID, func(t *DownloadTask) {
+			if t.Status == "downloading" && t.PID == 0 {
+				t.Status = "waiting"
+			}
+		})
+		a.emitDownloadProgress(task, true)
+		return err
+	}
+	return nil
+}
+
+// SetDownloadPriority sets the priority of a download task: high, normal or low
+// SetDownloadPriority 设置下载任务的优先级（high、normal、low）：等待中的任务按优先级启动，
+// 设置了下载限速时优先级高的任务分到更多带宽
+func (a *App) SetDownloadPriority(taskId string, priority string) (string, error) {
+	if _, ok := priorityWeights[priority]; !ok {
+		return "", errorf(msgInvalidPriority, priority)
+	}
+	task, found := a.tasks.UpdateDownload(taskId, func(t *DownloadTask) {
+		t.Priority = priority
+	})
+	if !found {
+		return "", errorf(msgTaskNotFound, taskId)
+	}
+	slog.Info("设置下载任务优先级", "taskId", taskId, "priority", priority)
+	a.emitDownloadProgress(task, true)
+	if task.Status == "downloading" {
+		go a.restartDownloadsForRateLimit()
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":   "success",
+		"message":  "Download priority updated",
+		"taskId":   taskId,
+		"priority": priority,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import "testing"
+
+func TestSplitRateLimit(t *testing.T) {
+	high, normal, low := priorityWeight(priorityHigh), priorityWeight(priorityNormal), priorityWeight(priorityLow)
+	tests := []struct {
+		name    string
+		limit   int64
+		weights map[string]int64
+		want    map[string]int64
+	}{
+		{"一个任务使用全部限速", 1000, map[string]int64{"a": high}, map[string]int64{"a": 1000}},
+		{"相同优先级平分", 1000, map[string]int64{"a": high, "b": high}, map[string]int64{"a": 500, "b": 500}},
+		{"按权重比例分配", 700, map[string]int64{"a": high, "b": normal, "c": low}, map[string]int64{"a": 400, "b": 200, "c": 100}},
+		{"不能整除时向下取整", 1000, map[string]int64{"a": high, "b": normal, "c": low}, map[string]int64{"a": 571, "b": 285, "c": 142}},
+		{"每个任务至少 1 KB/s", 2, map[string]int64{"a": high, "b": low}, map[string]int64{"a": 1, "b": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitRateLimit(tt.limit, tt.weights)
+			for taskID, want := range tt.want {
+				if got[taskID] != want {
+					t.Errorf("%s 的限速为 %d，应为 %d", taskID, got[taskID], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitRateLimitSumWithinLimit(t *testing.T) {
+	weights := map[string]int64{}
+	priorities := []string{priorityHigh, priorityNormal, priorityLow, ""}
+	for i := 0; i < 8; i++ {
+		weights[string(rune('a'+i))] = priorityWeight(priorities[i%len(priorities)])
+		for _, limit := range []int64{100, 999, 1024, 5000, 12345} {
+			var sum int64
+			for _, rate := range splitRateLimit(limit, weights) {
+				sum += rate
+			}
+			if sum > limit {
+				t.Fatalf("%d 个任务的限速之和 %d 超过了 %d", len(weights), sum, limit)
+			}
+		}
+	}
+}
+
+func TestTaskRateLimit(t *testing.T) {
+	previous := currentSettings()
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		settings = previous
+		settingsMu.Unlock()
+	})
+	settingsMu.Lock()
+	settings.DownloadSpeedLimit = 700
+	settings.BatteryDownloadLimit = 0
+	settingsMu.Unlock()
+
+	tasks, err := NewTaskManager(newMemoryTaskStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{tasks: tasks}
+	tasks.AddDownload(DownloadTask{TaskID: "high", Status: "downloading", Priority: priorityHigh})
+	tasks.AddDownload(DownloadTask{TaskID: "normal", Status: "downloading"})
+	tasks.AddDownload(DownloadTask{TaskID: "low", Status: "waiting", Priority: priorityLow})
+	tasks.AddDownload(DownloadTask{TaskID: "paused", Status: "paused", Priority: priorityHigh})
+
+	// 正在下载的任务之间分配，等待和暂停的任务不占用限速
+	if got := a.taskRateLimit("high"); got != 466 {
+		t.Errorf("high 的限速为 %d，应为 466", got)
+	}
+	if got := a.taskRateLimit("normal"); got != 233 {
+		t.Errorf("normal 的限速为 %d，应为 233", got)
+	}
+	// 即将启动的任务也参与分配
+	if got := a.taskRateLimit("low"); got != 100 {
+		t.Errorf("low 启动时的限速为 %d，应为 100", got)
+	}
+}
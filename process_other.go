@@ -0,0 +1,75 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// platformProcesses 通过信号控制进程：SIGTERM 通知退出，SIGKILL 强制终止，SIGSTOP/SIGCONT 挂起和恢复
+type platformProcesses struct{}
+
+// signalGroup 向进程所在的进程组发送信号；进程不是组长（不是由 startProcessGroup 启动）时只发给该进程
+func signalGroup(pid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		err = syscall.Kill(pid, sig)
+	}
+	return err
+}
+
+// Interrupt 发送 SIGTERM，让进程自己保存状态后退出；被挂起的进程需要 SIGCONT 才能处理信号
+func (platformProcesses) Interrupt(pid int) error {
+	if err := signalGroup(pid, syscall.SIGTERM); err != nil {
+		return err
+	}
+	return signalGroup(pid, syscall.SIGCONT)
+}
+
+// Kill 发送 SIGKILL
+func (platformProcesses) Kill(pid int) error {
+	return signalGroup(pid, syscall.SIGKILL)
+}
+
+// Wait 等待进程结束，超时返回 false；进程已不存在时返回 true
+func (platformProcesses) Wait(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if syscall.Kill(pid, syscall.Signal(0)) != nil {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Suspend 发送 SIGSTOP 暂停进程
+func (platformProcesses) Suspend(pid int) error {
+	return signalGroup(pid, syscall.SIGSTOP)
+}
+
+// Resume 发送 SIGCONT 恢复被 Suspend 暂停的进程
+func (platformProcesses) Resume(pid int) error {
+	return signalGroup(pid, syscall.SIGCONT)
+}
+
+// Adopt 不做处理：外部进程在独立的进程组中，程序正常退出时由 stopDownloadsForShutdown 和 stopTranscodesForShutdown 停止
+func (platformProcesses) Adopt(pid int) error {
+	return nil
+}
+
+// hideWindow 只有 Windows 会为控制台程序显示窗口，其他系统无需处理
+func hideWindow(cmd *exec.Cmd) {}
+
+// startProcessGroup 以新的进程组启动，终端中按 Ctrl+C 时不会直接发给外部进程，由程序按顺序停止
+func startProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	procAttachConsole            = kernel32.NewProc("AttachConsole")
+	procFreeConsole              = kernel32.NewProc("FreeConsole")
+	procGenerateConsoleCtrlEvent = kernel32.NewProc("GenerateConsoleCtrlEvent")
+
+	ntdll                = windows.NewLazySystemDLL("ntdll.dll")
+	procNtSuspendProcess = ntdll.NewProc("NtSuspendProcess")
+	procNtResumeProcess  = ntdll.NewProc("NtResumeProcess")
+)
+
+// processSuspendResume OpenProcess 的 PROCESS_SUSPEND_RESUME 权限
+const processSuspendResume = 0x0800
+
+// platformProcesses 通过控制台事件通知进程退出，TerminateProcess 强制终止
+type platformProcesses struct{}
+
+// consoleMu 同一时间只能附加到一个控制台
+var consoleMu sync.Mutex
+
+// Interrupt 向进程组发送 Ctrl+Break，torrent（Go 程序）和 ffmpeg 收到后保存状态退出。
+// 进程以独立的进程组启动，进程组ID即PID，事件不会发给本进程。
+// 桌面模式下外部进程有自己的（隐藏的）控制台，需要先附加到该控制台；服务器模式下与本进程共用控制台，附加会失败，直接发送
+func (platformProcesses) Interrupt(pid int) error {
+	consoleMu.Lock()
+	defer consoleMu.Unlock()
+
+	if r, _, _ := procAttachConsole.Call(uintptr(pid)); r != 0 {
+		defer procFreeConsole.Call()
+	}
+	if r, _, err := procGenerateConsoleCtrlEvent.Call(windows.CTRL_BREAK_EVENT, uintptr(pid)); r == 0 {
+		return fmt.Errorf("发送Ctrl+Break失败: %w", err)
+	}
+	return nil
+}
+
+// Kill 使用 TerminateProcess 终止进程
+func (platformProcesses) Kill(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
+
+// Wait 等待进程结束，超时返回 false；进程已不存在时返回 true
+func (platformProcesses) Wait(pid int, timeout time.Duration) bool {
+	handle, err := windows.OpenProcess(windows.SYNCHRONIZE, false, uint32(pid))
+	if err != nil {
+		return true
+	}
+	defer windows.CloseHandle(handle)
+	event, err := windows.WaitForSingleObject(handle, uint32(timeout.Milliseconds()))
+	return err == nil && event == windows.WAIT_OBJECT_0
+}
+
+// killOnCloseJob 外部进程所在的作业对象，设置了 JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE：
+// 句柄只由本进程持有，本进程退出（包括崩溃）时系统关闭句柄，作业中的进程及其子进程全部结束
+var killOnCloseJob struct {
+	once   sync.Once
+	handle windows.Handle
+	err    error
+}
+
+// jobObject 第一次使用时创建作业对象
+func jobObject() (windows.Handle, error) {
+	killOnCloseJob.once.Do(func() {
+		job, err := windows.CreateJobObject(nil, nil)
+		if err != nil {
+			killOnCloseJob.err = err
+			return
+		}
+		var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+		info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+		if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+			uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+			windows.CloseHandle(job)
+			killOnCloseJob.err = err
+			return
+		}
+		killOnCloseJob.handle = job
+	})
+	return killOnCloseJob.handle, killOnCloseJob.err
+}
+
+// Adopt 将进程加入作业对象，之后由它启动的子进程也在作业中
+func (platformProcesses) Adopt(pid int) error {
+	job, err := jobObject()
+	if err != nil {
+		return err
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(handle)
+	return windows.AssignProcessToJobObject(job, handle)
+}
+
+// Suspend 挂起进程的所有线程
+func (platformProcesses) Suspend(pid int) error {
+	return callProcessProc(procNtSuspendProcess, pid)
+}
+
+// Resume 恢复被 Suspend 挂起的进程
+func (platformProcesses) Resume(pid int) error {
+	return callProcessProc(procNtResumeProcess, pid)
+}
+
+// callProcessProc 以进程句柄调用 NtSuspendProcess/NtResumeProcess
+func callProcessProc(proc *windows.LazyProc, pid int) error {
+	handle, err := windows.OpenProcess(processSuspendResume, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(handle)
+	if status, _, _ := proc.Call(uintptr(handle)); status != 0 {
+		return windows.NTStatus(uint32(status))
+	}
+	return nil
+}
+
+// hideWindow 不为控制台程序显示窗口
+func hideWindow(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.HideWindow = true
+}
+
+// startProcessGroup 以新的进程组启动，Interrupt 可以只向该进程发送 Ctrl+Break
+func startProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_NEW_PROCESS_GROUP
+}
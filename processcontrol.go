@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// processStopTimeout 暂停或取消任务时等待外部进程自行退出的时间，超时后强制终止
+const processStopTimeout = 5 * time.Second
+
+// processController 控制下载和转码使用的外部进程，平台实现见 process_windows.go 和 process_other.go。
+// 外部进程通过 startProcessGroup 以独立的进程组启动，停止时只影响该进程
+type processController interface {
+	// Interrupt 通知进程保存状态后自行退出
+	Interrupt(pid int) error
+	// Kill 立即终止进程
+	Kill(pid int) error
+	// Wait 等待进程结束，超时返回 false；进程已不存在时返回 true
+	Wait(pid int, timeout time.Duration) bool
+	// Suspend 挂起进程
+	Suspend(pid int) error
+	// Resume 恢复被 Suspend 挂起的进程
+	Resume(pid int) error
+	// Adopt 使刚启动的进程随本程序退出而结束，即使本程序崩溃也不会继续下载或转码
+	Adopt(pid int) error
+}
+
+// processes 当前平台的进程控制
+var processes processController = platformProcesses{}
+
+// stopProcess 先通知进程自行退出，超过 timeout 仍未退出时强制终止
+func stopProcess(pid int, timeout time.Duration) {
+	if err := processes.Interrupt(pid); err != nil {
+		slog.Warn("通知进程退出失败，强制终止", "pid", pid, "error", err)
+	} else if processes.Wait(pid, timeout) {
+		slog.Info("进程已退出", "pid", pid)
+		return
+	} else {
+		slog.Warn("进程未在限定时间内退出，强制终止", "pid", pid)
+	}
+	if err := processes.Kill(pid); err != nil {
+		slog.Error("终止进程时出错", "pid", pid, "error", err)
+	}
+}
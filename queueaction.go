@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// 所有任务结束后执行的操作，只在本次运行有效，执行或取消后清除
+const (
+	queueActionNone      = ""
+	queueActionQuit      = "quit"
+	queueActionSleep     = "sleep"
+	queueActionHibernate = "hibernate"
+	queueActionShutdown  = "shutdown"
+)
+
+const (
+	// queueActionCountdown 队列空闲后等待多久执行操作，期间可以取消
+	queueActionCountdown = 60 * time.Second
+	// queueIdleCheckInterval 检查队列是否空闲的间隔
+	queueIdleCheckInterval = 5 * time.Second
+)
+
+// EventQueueAction 队列完成后操作的状态变化，负载与 GetQueueAction 相同：
+// {action, countdown}，countdown 为倒计时剩余秒数，未在倒计时时为0
+const EventQueueAction = "queue:action"
+
+// queueActionNames 操作的说明，用于通知
+var queueActionNames = map[string]msgKey{
+	queueActionQuit:      msgQueueActionQuit,
+	queueActionSleep:     msgQueueActionSleep,
+	queueActionHibernate: msgQueueActionHibernate,
+	queueActionShutdown:  msgQueueActionShutdown,
+}
+
+// queueActionState 等待执行的队列完成后操作
+type queueActionState struct {
+	mu     sync.Mutex
+	action string
+	// deadline 倒计时结束的时间，未在倒计时时为零值
+	deadline time.Time
+	cancel   context.CancelFunc
+}
+
+// queueIdle 是否所有下载和转码任务都已结束（已暂停的下载不计入）
+func (a *App) queueIdle() bool {
+	if a.tasks == nil {
+		return true
+	}
+	for _, t := range a.tasks.Downloads() {
+		if t.Status == "downloading" || t.Status == "waiting" {
+			return false
+		}
+	}
+	for _, t := range a.tasks.Transcodes() {
+		if t.Status == "transcoding" || t.Status == "waiting" {
+			return false
+		}
+	}
+	return true
+}
+
+// queueActionStatus 返回当前操作和倒计时剩余秒数
+func (a *App) queueActionStatus() map[string]interface{} {
+	q := &a.queueAction
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	countdown := 0
+	if !q.deadline.IsZero() {
+		countdown = int(time.Until(q.deadline).Round(time.Second).Seconds())
+	}
+	return map[string]interface{}{
+		"action":    q.action,
+		"countdown": countdown,
+	}
+}
+
+// setQueueDeadline 开始或结束倒计时，并通知前端
+func (a *App) setQueueDeadline(deadline time.Time) {
+	a.queueAction.mu.Lock()
+	a.queueAction.deadline = deadline
+	a.queueAction.mu.Unlock()
+	a.emitEvent(EventQueueAction, a.queueActionStatus())
+}
+
+// watchQueue 等待队列空闲后倒计时，倒计时期间有新任务开始时重新等待，结束后执行操作
+func (a *App) watchQueue(ctx context.Context, action string) {
+	ticker := time.NewTicker(queueIdleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !a.queueIdle() {
+			continue
+		}
+
+		slog.Info("所有任务已结束，开始倒计时", "action", action, "countdown", queueActionCountdown)
+		a.setQueueDeadline(time.Now().Add(queueActionCountdown))
+		a.notify(tr(msgNotifyQueueIdle), tr(msgNotifyQueueCountdown, int(queueActionCountdown.Seconds()), tr(queueActionNames[action])), "")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(queueActionCountdown):
+		}
+		if !a.queueIdle() {
+			slog.Info("倒计时期间有新任务，继续等待", "action", action)
+			a.setQueueDeadline(time.Time{})
+			continue
+		}
+
+		a.queueAction.mu.Lock()
+		a.queueAction.action = queueActionNone
+		a.queueAction.deadline = time.Time{}
+		a.queueAction.cancel = nil
+		a.queueAction.mu.Unlock()
+		a.emitEvent(EventQueueAction, a.queueActionStatus())
+		a.runQueueAction(action)
+		return
+	}
+}
+
+// runQueueAction 执行操作；关机和睡眠前先保存任务
+func (a *App) runQueueAction(action string) {
+	slog.Info("执行队列完成后的操作", "action", action)
+	if a.tasks != nil {
+		if err := a.tasks.Flush(); err != nil {
+			slog.Error("保存任务失败", "error", err)
+		}
+	}
+	a.stats.save()
+
+	var err error
+	switch action {
+	case queueActionQuit:
+		a.quitConfirmed.Store(true)
+		a.quit()
+		return
+	case queueActionSleep:
+		err = suspendSystem(false)
+	case queueActionHibernate:
+		err = suspendSystem(true)
+	case queueActionShutdown:
+		err = shutdownSystem()
+	}
+	if err != nil {
+		slog.Error("执行队列完成后的操作失败", "action", action, "error", err)
+		a.notify(tr(msgNotifyQueueActionFailed, tr(queueActionNames[action])), err.Error(), "")
+	}
+}
+
+// GetQueueAction returns the action scheduled for when all tasks finish
+// GetQueueAction 获取所有任务结束后执行的操作，以及倒计时剩余秒数
+func (a *App) GetQueueAction() (string, error) {
+	response := a.queueActionStatus()
+	response["status"] = "success"
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// SetQueueAction schedules an action for when all tasks finish
+// SetQueueAction 设置所有任务结束后执行的操作：quit（退出程序）、sleep（睡眠）、hibernate（休眠）、shutdown（关机），
+// 空字符串表示取消。队列空闲后先倒计时 queueActionCountdown，期间可以取消；只在本次运行有效
+func (a *App) SetQueueAction(action string) (string, error) {
+	switch action {
+	case queueActionNone, queueActionSleep, queueActionHibernate, queueActionShutdown:
+	case queueActionQuit:
+		if a.headless {
+			return "", errorf(msgQuitHeadless)
+		}
+	default:
+		return "", errorf(msgInvalidQueueAction, action)
+	}
+
+	q := &a.queueAction
+	q.mu.Lock()
+	if q.cancel != nil {
+		q.cancel()
+		q.cancel = nil
+	}
+	q.action = action
+	q.deadline = time.Time{}
+	if action != queueActionNone {
+		ctx, cancel := context.WithCancel(context.Background())
+		q.cancel = cancel
+		go a.watchQueue(ctx, action)
+	}
+	q.mu.Unlock()
+
+	if action == queueActionNone {
+		slog.Info("已取消队列完成后的操作")
+	} else {
+		slog.Info("已设置队列完成后的操作", "action", action)
+	}
+	a.emitEvent(EventQueueAction, a.queueActionStatus())
+	return a.GetQueueAction()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// EventQuitRequested 关闭窗口时仍有进行中的任务，负载为 {downloading, transcoding}。
+// 前端询问用户后调用 ConfirmQuit 退出，或者忽略以取消关闭
+const EventQuitRequested = "app:quit-requested"
+
+// 退出提示中可选的操作
+const (
+	// quitActionPause 暂停所有下载后退出，下次启动时不自动恢复
+	quitActionPause = "pause"
+	// quitActionQuit 直接退出，下载按设置在下次启动时恢复
+	quitActionQuit = "quit"
+)
+
+// confirmQuit 关闭窗口时有进行中的任务则显示窗口并请前端询问用户，返回是否阻止关闭。
+// 用户已确认或通过更新退出时不再询问
+func (a *App) confirmQuit(ctx context.Context) bool {
+	if a.quitConfirmed.Load() {
+		return false
+	}
+	downloading, transcoding := a.activeTaskCount()
+	if downloading+transcoding == 0 {
+		return false
+	}
+	slog.Info("有进行中的任务，等待用户确认退出", "downloading", downloading, "transcoding", transcoding)
+	a.showWindow()
+	// 只推送给本机窗口，局域网访问的网页不应弹出退出提示
+	runtime.EventsEmit(ctx, EventQuitRequested, map[string]int{
+		"downloading": downloading,
+		"transcoding": transcoding,
+	})
+	return true
+}
+
+// ConfirmQuit quits after the user answered the exit prompt
+// ConfirmQuit 用户在退出提示中确认后退出：action 为 pause 时先暂停所有下载（下次启动不自动恢复），
+// 为 quit 时直接退出（下载按设置在下次启动时恢复）。正在进行的转码在下次启动时重新开始
+func (a *App) ConfirmQuit(action string) (string, error) {
+	if a.headless {
+		return "", errorf(msgQuitHeadless)
+	}
+	switch action {
+	case quitActionPause:
+		if _, err := a.PauseAllDownloads(); err != nil {
+			return "", err
+		}
+	case quitActionQuit:
+	default:
+		return "", errorf(msgInvalidQuitAction, action)
+	}
+	slog.Info("用户确认退出", "action", action)
+	a.quitConfirmed.Store(true)
+	// 在返回结果之后再退出，避免阻塞前端的调用
+	go a.quit()
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Quitting",
+		"action":  action,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// recentlyAddedLimit 最近添加默认返回的数量
+const recentlyAddedLimit = 20
+
+// RecentVideo is a library video with the time it was added
+// RecentVideo 最近添加的视频
+type RecentVideo struct {
+	LibraryVideo
+	// 加入视频库的时间：下载或转码任务的完成时间，不是任务产生的文件为文件修改时间
+	AddedAt string `json:"addedAt"`
+	// 产生该视频的下载或转码任务
+	TaskID string `json:"taskId,omitempty"`
+}
+
+// completionTimes 已完成的下载和转码任务，用于查找视频的完成时间；转码任务以输出文件的绝对路径为键
+type completionTimes struct {
+	downloads  []DownloadTask
+	transcodes map[string]TranscodeTask
+}
+
+// newCompletionTimes 收集已完成的任务
+func (a *App) newCompletionTimes() completionTimes {
+	times := completionTimes{transcodes: make(map[string]TranscodeTask)}
+	for _, task := range a.tasks.Downloads() {
+		if task.Status == "completed" && task.EndTime != "" {
+			times.downloads = append(times.downloads, task)
+		}
+	}
+	for _, task := range a.tasks.Transcodes() {
+		if task.Status == "completed" && !task.EndTime.IsZero() {
+			times.transcodes[absPath(task.OutputFile)] = task
+		}
+	}
+	return times
+}
+
+// addedAt 返回视频加入视频库的时间和产生它的任务ID，没有对应的任务时使用文件修改时间
+func (c completionTimes) addedAt(video LibraryVideo) (string, string) {
+	path := absPath(video.Path)
+	if task, ok := c.transcodes[path]; ok {
+		return task.EndTime.Format(time.RFC3339), task.TaskID
+	}
+	for _, task := range c.downloads {
+		if downloadContains(task, path) {
+			return task.EndTime, task.TaskID
+		}
+	}
+	return video.ModTime, ""
+}
+
+// GetRecentlyAdded gets the library videos that were added most recently
+// GetRecentlyAdded 获取最近添加的视频，按下载或转码完成的时间（其他视频按文件修改时间）从新到旧排列，
+// 包括播放进度，界面可以据此标出未看过的视频；limit 不大于0时返回20个
+func (a *App) GetRecentlyAdded(limit int) (string, error) {
+	if limit <= 0 {
+		limit = recentlyAddedLimit
+	}
+	videos, _, err := a.libraryVideos()
+	if err != nil {
+		return "", err
+	}
+
+	times := a.newCompletionTimes()
+	videoFiles := make([]RecentVideo, 0, len(videos))
+	for _, video := range videos {
+		addedAt, taskID := times.addedAt(video)
+		videoFiles = append(videoFiles, RecentVideo{LibraryVideo: video, AddedAt: addedAt, TaskID: taskID})
+	}
+	sort.SliceStable(videoFiles, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, videoFiles[i].AddedAt)
+		tj, _ := time.Parse(time.RFC3339, videoFiles[j].AddedAt)
+		if ti.Equal(tj) {
+			return naturalLess(videoFiles[i].RelPath, videoFiles[j].RelPath)
+		}
+		return ti.After(tj)
+	})
+	if len(videoFiles) > limit {
+		videoFiles = videoFiles[:limit]
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":     "success",
+		"videoFiles": videoFiles,
+		"total":      len(videoFiles),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParsedName is the title and episode information parsed from a release name
+// ParsedName 从发布名称（文件名、文件夹名）中解析的标题、年份和季、集信息
+type ParsedName struct {
+	Title string `json:"title"`
+	Year  int    `json:"year,omitempty"`
+	// 季和集，电影为0；只有集数的名称（例如动画）季为1
+	Season  int `json:"season,omitempty"`
+	Episode int `json:"episode,omitempty"`
+	// 多集合并的文件的最后一集，例如 S01E01-E02 为2
+	EpisodeEnd int `json:"episodeEnd,omitempty"`
+	// 名称中没有季数，Season 是默认的1
+	defaultSeason bool
+}
+
+// IsEpisode 判断是否为剧集中的一集
+func (p ParsedName) IsEpisode() bool {
+	return p.Episode > 0
+}
+
+// episodePatterns 剧集编号的写法，按可靠程度排列，第一个分组为季（没有季的写法为空），第二个为集，
+// 第三个为多集文件的最后一集
+var episodePatterns = []*regexp.Regexp{
+	// S01E02、S01E02E03、S01E02-E03、S01E02-03
+	regexp.MustCompile(`(?i)\bs(\d{1,2}) ?e(\d{1,4})(?:-?e(\d{1,4})|-(\d{1,4})\b)?`),
+	// 1x02
+	regexp.MustCompile(`(?i)\b(\d{1,2})x(\d{2,3})\b()`),
+	// Season 1 Episode 2
+	regexp.MustCompile(`(?i)\bseason ?(\d{1,2}) ?episode ?(\d{1,4})\b()`),
+	// 第1季第2集
+	regexp.MustCompile(`第 ?(\d{1,2}) ?季 ?第 ?(\d{1,4}) ?[集话話]()`),
+	// 第2集
+	regexp.MustCompile(`()第 ?(\d{1,4}) ?[集话話]()`),
+	// E02、EP02
+	regexp.MustCompile(`(?i)\b()ep? ?(\d{1,4})\b()`),
+	// 动画常见的 Title - 02、Title - 02v2
+	regexp.MustCompile(` - ()(\d{1,4})(?:v\d)?\b()`),
+	// 只有集数的文件名，例如 02.mkv，标题来自上级文件夹
+	regexp.MustCompile(`^ *()(\d{1,3}) *$()`),
+}
+
+// seasonPattern 只有季的名称，例如季文件夹 Season 1、S01、第1季
+var seasonPattern = regexp.MustCompile(`(?i)^(?:season ?(\d{1,2})|s(\d{1,2})|第 ?(\d{1,2}) ?季)$`)
+
+// releaseNoise 发布名称中标题之后常见的片段：分辨率、片源、编码等，从第一个出现的位置截断
+var releaseNoise = regexp.MustCompile(`(?i)\b(s\d{1,2}|\d{3,4}p|4k|uhd|blu-?ray|bdrip|brrip|web-?dl|webrip|hdtv|dvdrip|remux|x26[45]|h ?26[45]|hevc|avc|aac|ac3|dts|hdr|10bit|proper|repack|complete)\b`)
+
+// releaseYear 标题后的年份
+var releaseYear = regexp.MustCompile(`\b(19\d{2}|20\d{2})\b`)
+
+// releaseBrackets 方括号中的发布组、网站等信息
+var releaseBrackets = regexp.MustCompile(`\[[^\]]*\]|【[^】]*】`)
+
+// normalizeReleaseName 去掉扩展名和方括号中的内容，点号和下划线换成空格
+func normalizeReleaseName(name string) string {
+	if videoExtensions[strings.ToLower(filepath.Ext(name))] {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	name = releaseBrackets.ReplaceAllString(name, " ")
+	return strings.NewReplacer(".", " ", "_", " ").Replace(name)
+}
+
+// parseTitle 从标题部分中去掉发布信息并提取年份（年份未知时为0），
+// 例如 The Matrix 1999 1080p BluRay 得到 The Matrix 和 1999
+func parseTitle(name string) (string, int) {
+	if loc := releaseNoise.FindStringIndex(name); loc != nil && loc[0] > 0 {
+		name = name[:loc[0]]
+	}
+	year := 0
+	// 使用最后一个年份，开头的年份是标题的一部分，例如 2001 A Space Odyssey 1968
+	if all := releaseYear.FindAllStringIndex(name, -1); len(all) > 0 && all[len(all)-1][0] > 0 {
+		loc := all[len(all)-1]
+		year, _ = strconv.Atoi(name[loc[0]:loc[1]])
+		name = name[:loc[0]]
+	}
+	return strings.Join(strings.Fields(strings.Trim(strings.TrimSpace(name), "-([ ")), " "), year
+}
+
+// parseReleaseName 解析发布名称，例如 Show.Name.S01E02.1080p.WEB-DL.mkv 得到 Show Name 第1季第2集，
+// The.Matrix.1999.1080p.BluRay.x264.mkv 得到 The Matrix 和 1999
+func parseReleaseName(name string) ParsedName {
+	name = normalizeReleaseName(name)
+	for _, pattern := range episodePatterns {
+		m := pattern.FindStringSubmatchIndex(name)
+		if m == nil {
+			continue
+		}
+		group := func(i int) int {
+			if m[2*i] < 0 {
+				return 0
+			}
+			n, _ := strconv.Atoi(name[m[2*i]:m[2*i+1]])
+			return n
+		}
+		parsed := ParsedName{Season: group(1), Episode: group(2), EpisodeEnd: group(3)}
+		if len(m) > 8 && parsed.EpisodeEnd == 0 {
+			parsed.EpisodeEnd = group(4)
+		}
+		// 没有季数时集数不能是年份，例如 Movie - 2019
+		if parsed.Episode == 0 || (parsed.Season == 0 && releaseYear.MatchString(strconv.Itoa(parsed.Episode))) {
+			continue
+		}
+		if parsed.Season == 0 {
+			parsed.Season, parsed.defaultSeason = 1, true
+		}
+		if parsed.EpisodeEnd <= parsed.Episode {
+			parsed.EpisodeEnd = 0
+		}
+		parsed.Title, parsed.Year = parseTitle(name[:m[0]])
+		return parsed
+	}
+	title, year := parseTitle(name)
+	return ParsedName{Title: title, Year: year}
+}
+
+// parseSeasonDir 解析季文件夹名，不是季文件夹时返回0
+func parseSeasonDir(name string) int {
+	m := seasonPattern.FindStringSubmatch(strings.TrimSpace(normalizeReleaseName(name)))
+	if m == nil {
+		return 0
+	}
+	for _, g := range m[1:] {
+		if n, err := strconv.Atoi(g); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// parseVideoPath 解析视频库中的相对路径（使用 / 分隔）。文件名中没有标题时（例如 Show/Season 1/S01E02.mkv、
+// Show/02.mkv）使用上级文件夹名作为标题，季文件夹提供文件名中没有的季数，电影文件夹提供文件名中没有的年份
+func parseVideoPath(relPath string) ParsedName {
+	dir, file := path.Split(relPath)
+	parsed := parseReleaseName(file)
+	complete := parsed.Year > 0
+	if parsed.IsEpisode() {
+		complete = !parsed.defaultSeason
+	}
+	if dir == "" || (parsed.Title != "" && complete) {
+		return parsed
+	}
+
+	dirs := strings.Split(strings.Trim(dir, "/"), "/")
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] == "" {
+			continue
+		}
+		if season := parseSeasonDir(dirs[i]); season > 0 {
+			if parsed.IsEpisode() && parsed.defaultSeason {
+				parsed.Season, parsed.defaultSeason = season, false
+			}
+			continue
+		}
+		folder := parseReleaseName(dirs[i])
+		if parsed.Title == "" {
+			parsed.Title = folder.Title
+		}
+		if parsed.Year == 0 {
+			parsed.Year = folder.Year
+		}
+		break
+	}
+	return parsed
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestParseReleaseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want ParsedName
+	}{
+		{"Show.Name.S01E02.1080p.WEB-DL.mkv", ParsedName{Title: "Show Name", Season: 1, Episode: 2}},
+		{"show.name.s02e10.720p.hdtv.x264.mkv", ParsedName{Title: "show name", Season: 2, Episode: 10}},
+		{"Show.Name.S01E02-E03.mkv", ParsedName{Title: "Show Name", Season: 1, Episode: 2, EpisodeEnd: 3}},
+		{"Show.Name.S01E02E03.mkv", ParsedName{Title: "Show Name", Season: 1, Episode: 2, EpisodeEnd: 3}},
+		{"Show_Name_1x02_HDTV.mkv", ParsedName{Title: "Show Name", Season: 1, Episode: 2}},
+		{"Show Name 3x115.mkv", ParsedName{Title: "Show Name", Season: 3, Episode: 115}},
+		{"Show.Name.2019.S03E04.2160p.mkv", ParsedName{Title: "Show Name", Year: 2019, Season: 3, Episode: 4}},
+		{"[Group] Anime Title - 02 [1080p].mkv", ParsedName{Title: "Anime Title", Season: 1, Episode: 2}},
+		{"电视剧 第2季第5集.mp4", ParsedName{Title: "电视剧", Season: 2, Episode: 5}},
+		{"The.Matrix.1999.1080p.BluRay.x264.mkv", ParsedName{Title: "The Matrix", Year: 1999}},
+		{"2001.A.Space.Odyssey.1968.2160p.UHD.mkv", ParsedName{Title: "2001 A Space Odyssey", Year: 1968}},
+		{"Inception (2010) 4K HDR.mkv", ParsedName{Title: "Inception", Year: 2010}},
+		{"Movie - 2019.mkv", ParsedName{Title: "Movie", Year: 2019}},
+		{"Home Video.mp4", ParsedName{Title: "Home Video"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseReleaseName(tt.name)
+			got.defaultSeason = false
+			if got != tt.want {
+				t.Fatalf("parseReleaseName(%q) = %+v，应为 %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVideoPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want ParsedName
+	}{
+		{"Show Name/Season 2/S02E03.mkv", ParsedName{Title: "Show Name", Season: 2, Episode: 3}},
+		{"Show Name/Season 2/03.mkv", ParsedName{Title: "Show Name", Season: 2, Episode: 3}},
+		{"Anime Title/第2季/Anime Title - 05.mkv", ParsedName{Title: "Anime Title", Season: 2, Episode: 5}},
+		{"The Matrix (1999)/The Matrix.mkv", ParsedName{Title: "The Matrix", Year: 1999}},
+		{"Movies/Home Video.mp4", ParsedName{Title: "Home Video"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := parseVideoPath(tt.path)
+			got.defaultSeason = false
+			if got != tt.want {
+				t.Fatalf("parseVideoPath(%q) = %+v，应为 %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeasonDir(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"Season 1", 1},
+		{"season.02", 2},
+		{"S03", 3},
+		{"第4季", 4},
+		{"Show Name", 0},
+		{"Season 1 Extras", 0},
+	}
+	for _, tt := range tests {
+		if got := parseSeasonDir(tt.name); got != tt.want {
+			t.Errorf("parseSeasonDir(%q) = %d，应为 %d", tt.name, got, tt.want)
+		}
+	}
+}
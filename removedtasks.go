@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// removedTaskGrace 删除的任务可以撤销的时间，只保存在内存中，程序重启后无法撤销
+const removedTaskGrace = 10 * time.Minute
+
+// RemovedTask is a task removed from history that can still be restored
+// RemovedTask 从历史记录中删除、还可以撤销的任务
+type RemovedTask struct {
+	TaskType  string    `json:"taskType"`
+	TaskID    string    `json:"taskId"`
+	Name      string    `json:"name"`
+	RemovedAt time.Time `json:"removedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// entry 删除前的历史记录（包含完整的任务），torrent 为同时删除的种子文件内容
+	entry   HistoryEntry
+	torrent []byte
+}
+
+// removedTasks 最近删除的任务，过期的在访问时清除
+type removedTasks struct {
+	mu    sync.Mutex
+	items map[string]*RemovedTask
+}
+
+// purge 清除已超过撤销期限的任务；调用时已持有锁
+func (r *removedTasks) purge() {
+	now := time.Now()
+	for taskID, item := range r.items {
+		if now.After(item.ExpiresAt) {
+			delete(r.items, taskID)
+		}
+	}
+}
+
+// trashHistoryEntry 删除一条历史记录并保留一份副本，撤销期限内可以通过 UndoRemove 恢复，返回撤销期限
+func (a *App) trashHistoryEntry(entry HistoryEntry) (time.Time, error) {
+	item := &RemovedTask{
+		TaskType:  entry.TaskType,
+		TaskID:    entry.TaskID,
+		Name:      entry.Name,
+		RemovedAt: time.Now(),
+		ExpiresAt: time.Now().Add(removedTaskGrace),
+		entry:     entry,
+	}
+	if entry.TaskType == "download" {
+		item.torrent, _ = os.ReadFile(filepath.Join(torrentsDir(), entry.TaskID+".torrent"))
+	}
+	if err := a.removeHistoryEntry(entry); err != nil {
+		return time.Time{}, err
+	}
+
+	a.removed.mu.Lock()
+	defer a.removed.mu.Unlock()
+	a.removed.purge()
+	if a.removed.items == nil {
+		a.removed.items = make(map[string]*RemovedTask)
+	}
+	a.removed.items[entry.TaskID] = item
+	return item.ExpiresAt, nil
+}
+
+// GetRemovedTasks lists recently removed tasks that can be restored with UndoRemove
+// GetRemovedTasks 列出最近删除、还可以通过 UndoRemove 恢复的任务，最近删除的在前
+func (a *App) GetRemovedTasks() (string, error) {
+	a.removed.mu.Lock()
+	a.removed.purge()
+	tasks := make([]RemovedTask, 0, len(a.removed.items))
+	for _, item := range a.removed.items {
+		tasks = append(tasks, *item)
+	}
+	a.removed.mu.Unlock()
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].RemovedAt.After(tasks[j].RemovedAt)
+	})
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":       "success",
+		"tasks":        tasks,
+		"graceSeconds": int(removedTaskGrace.Seconds()),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// UndoRemove restores a task removed from history within the grace period
+// UndoRemove 恢复撤销期限内删除的任务，下载任务的种子文件一并恢复
+func (a *App) UndoRemove(taskId string) (string, error) {
+	a.removed.mu.Lock()
+	a.removed.purge()
+	item, ok := a.removed.items[taskId]
+	if ok {
+		delete(a.removed.items, taskId)
+	}
+	a.removed.mu.Unlock()
+	if !ok {
+		return "", errorf(msgRemovedTaskNotFound, taskId)
+	}
+
+	var err error
+	switch task := item.entry.Task.(type) {
+	case DownloadTask:
+		if len(item.torrent) > 0 {
+			if err := os.WriteFile(filepath.Join(torrentsDir(), taskId+".torrent"), item.torrent, 0644); err != nil {
+				slog.Warn("恢复种子文件失败", "taskId", taskId, "error", err)
+			}
+		}
+		err = a.tasks.AddDownload(task)
+	case TranscodeTask:
+		err = a.tasks.AddTranscode(task)
+	}
+	if err != nil {
+		return "", err
+	}
+	slog.Info("已恢复删除的任务", "taskId", taskId, "type", item.TaskType)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":   "success",
+		"message":  "Task restored successfully",
+		"taskId":   taskId,
+		"taskType": item.TaskType,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resourceSampleInterval 转码期间采样CPU、内存和GPU使用率的间隔
+const resourceSampleInterval = 3 * time.Second
+
+// ResourceUsage is a resource usage sample taken while ffmpeg runs
+// ResourceUsage 转码期间的资源占用采样，随 transcode:progress 事件推送；无法读取的项为空
+type ResourceUsage struct {
+	// ffmpeg 进程的CPU占用，100表示占满所有逻辑处理器
+	CPUPercent float64 `json:"cpuPercent"`
+	// ffmpeg 进程占用的物理内存（字节）
+	MemoryBytes uint64 `json:"memoryBytes"`
+	// 整个系统已使用的内存百分比
+	SystemMemoryPercent *float64 `json:"systemMemoryPercent,omitempty"`
+	// GPU 使用率和视频编码单元的使用率，可以判断转码是否真的在使用GPU
+	GPUPercent        *float64 `json:"gpuPercent,omitempty"`
+	GPUEncoderPercent *float64 `json:"gpuEncoderPercent,omitempty"`
+	// GPU 使用率的来源：nvidia-smi、perfcounter（Windows 性能计数器，只统计 ffmpeg 进程）或 ioreg（macOS）
+	GPUSource string    `json:"gpuSource,omitempty"`
+	SampledAt time.Time `json:"sampledAt"`
+}
+
+// gpuUsage 一次GPU使用率读数
+type gpuUsage struct {
+	percent        *float64
+	encoderPercent *float64
+	source         string
+}
+
+// errNoGPUUsage 当前系统无法读取GPU使用率
+var errNoGPUUsage = errors.New("GPU utilization is not available")
+
+// nvidiaSMIPath nvidia-smi 的路径，只查找一次；没有安装 NVIDIA 驱动时为空
+var nvidiaSMIPath = sync.OnceValue(func() string {
+	path, err := exec.LookPath("nvidia-smi")
+	if err != nil {
+		return ""
+	}
+	return path
+})
+
+// nvidiaGPUUsage 使用 nvidia-smi 读取 GPU 和编码单元的使用率，有多块显卡时取最高值。
+// 旧版驱动不支持 utilization.encoder 字段，此时只读取 GPU 使用率
+func nvidiaGPUUsage() (gpuUsage, error) {
+	path := nvidiaSMIPath()
+	if path == "" {
+		return gpuUsage{}, errNoGPUUsage
+	}
+	query := func(fields string) ([][]float64, error) {
+		cmd := exec.Command(path, "--query-gpu="+fields, "--format=csv,noheader,nounits")
+		hideWindow(cmd)
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, err
+		}
+		var rows [][]float64
+		for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+			var row []float64
+			for _, field := range strings.Split(line, ",") {
+				value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+				if err != nil {
+					return nil, err
+				}
+				row = append(row, value)
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+
+	usage := gpuUsage{source: "nvidia-smi"}
+	rows, err := query("utilization.gpu,utilization.encoder")
+	if err != nil {
+		if rows, err = query("utilization.gpu"); err != nil {
+			return gpuUsage{}, err
+		}
+	}
+	for _, row := range rows {
+		if usage.percent == nil || row[0] > *usage.percent {
+			usage.percent = &row[0]
+		}
+		if len(row) > 1 && (usage.encoderPercent == nil || row[1] > *usage.encoderPercent) {
+			usage.encoderPercent = &row[1]
+		}
+	}
+	return usage, nil
+}
+
+// sampleTranscodeResources 在 ffmpeg 运行期间定期采样进程的CPU、内存占用和GPU使用率，记录到任务中并推送进度，done 关闭时结束。
+// CPU 占用按两次采样之间进程使用的CPU时间计算，第一次采样只记录起点
+func (a *App) sampleTranscodeResources(taskID string, pid int, done <-chan struct{}) {
+	ticker := time.NewTicker(resourceSampleInterval)
+	defer ticker.Stop()
+
+	lastCPU, _, err := processUsage(pid)
+	if err != nil {
+		slog.Debug("无法读取转码进程的资源占用", "taskId", taskID, "pid", pid, "error", err)
+	}
+	lastSample := time.Now()
+	gpuUnavailable := false
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		cpuTime, memory, err := processUsage(pid)
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+		usage := ResourceUsage{MemoryBytes: memory, SampledAt: now}
+		if elapsed := now.Sub(lastSample); elapsed > 0 && cpuTime >= lastCPU {
+			usage.CPUPercent = float64(cpuTime-lastCPU) / float64(elapsed) / float64(runtime.NumCPU()) * 100
+		}
+		lastCPU, lastSample = cpuTime, now
+		if percent, err := systemMemoryPercent(); err == nil {
+			usage.SystemMemoryPercent = &percent
+		}
+		// 读取失败一次后不再尝试，避免每次采样都启动外部命令
+		if !gpuUnavailable {
+			gpu, err := platformGPUUsage(pid)
+			if err != nil {
+				slog.Debug("无法读取GPU使用率", "taskId", taskID, "error", err)
+				gpuUnavailable = true
+			} else {
+				usage.GPUPercent, usage.GPUEncoderPercent, usage.GPUSource = gpu.percent, gpu.encoderPercent, gpu.source
+			}
+		}
+
+		task, found := a.tasks.UpdateTranscode(taskID, func(t *TranscodeTask) {
+			if t.Status == "transcoding" {
+				t.Resources = &usage
+			}
+		})
+		if !found || task.Status != "transcoding" {
+			return
+		}
+		a.emitTranscodeProgress(task, false)
+	}
+}
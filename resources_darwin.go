@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// deviceUtilizationPattern ioreg 输出的 IOAccelerator 性能统计中的GPU使用率
+var deviceUtilizationPattern = regexp.MustCompile(`"Device Utilization %"\s*=\s*(\d+)`)
+
+// processUsage 使用 ps 读取进程使用的CPU时间和占用的物理内存
+func processUsage(pid int) (time.Duration, uint64, error) {
+	output, err := exec.Command("ps", "-o", "time=,rss=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, errors.New("unexpected ps output")
+	}
+	cpu, err := parseCPUTime(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	rss, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cpu, rss * 1024, nil
+}
+
+// parseCPUTime 解析 ps 输出的CPU时间，格式为 [[dd-]hh:]mm:ss.cc
+func parseCPUTime(value string) (time.Duration, error) {
+	var days float64
+	if d, rest, ok := strings.Cut(value, "-"); ok {
+		days, _ = strconv.ParseFloat(d, 64)
+		value = rest
+	}
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, err
+		}
+		seconds = seconds*60 + v
+	}
+	return time.Duration((days*86400 + seconds) * float64(time.Second)), nil
+}
+
+// systemMemoryPercent macOS 的可用内存需要从 vm_stat 的多个页面统计估算，结果不准确，不读取
+func systemMemoryPercent() (float64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// platformGPUUsage 从 ioreg 的 IOAccelerator 性能统计读取GPU使用率（Apple 芯片和 Intel Mac 的显卡都支持），有多块显卡时取最高值
+func platformGPUUsage(pid int) (gpuUsage, error) {
+	output, err := exec.Command("ioreg", "-r", "-d", "1", "-w", "0", "-c", "IOAccelerator").Output()
+	if err != nil {
+		return gpuUsage{}, err
+	}
+	usage := gpuUsage{source: "ioreg"}
+	for _, match := range deviceUtilizationPattern.FindAllStringSubmatch(string(output), -1) {
+		value, _ := strconv.ParseFloat(match[1], 64)
+		if usage.percent == nil || value > *usage.percent {
+			usage.percent = &value
+		}
+	}
+	if usage.percent == nil {
+		return gpuUsage{}, errNoGPUUsage
+	}
+	return usage, nil
+}
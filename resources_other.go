@@ -0,0 +1,61 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks /proc 中CPU时间的单位（USER_HZ），Linux 固定为每秒100
+const clockTicks = 100
+
+// processUsage 从 /proc/<pid>/stat 读取进程使用的CPU时间（用户态和内核态）和占用的物理内存
+func processUsage(pid int) (time.Duration, uint64, error) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	// 进程名在括号中，可能包含空格，从最后一个右括号之后开始按空格分隔；之后第1个字段是 state
+	end := strings.LastIndexByte(string(data), ')')
+	if end < 0 {
+		return 0, 0, errors.New("invalid /proc stat format")
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	// utime、stime 为第14、15个字段，rss 为第24个字段（页数）
+	if len(fields) < 22 {
+		return 0, 0, errors.New("invalid /proc stat format")
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	rss, _ := strconv.ParseUint(fields[21], 10, 64)
+	cpu := time.Duration(utime+stime) * time.Second / clockTicks
+	return cpu, rss * uint64(os.Getpagesize()), nil
+}
+
+// systemMemoryPercent 按 /proc/meminfo 的 MemTotal 和 MemAvailable 计算已使用的内存百分比
+func systemMemoryPercent() (float64, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	values := map[string]float64{}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && (key == "MemTotal" || key == "MemAvailable") {
+			values[key], _ = strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 64)
+		}
+	}
+	if values["MemTotal"] <= 0 {
+		return 0, errors.New("MemTotal not found in /proc/meminfo")
+	}
+	return (values["MemTotal"] - values["MemAvailable"]) / values["MemTotal"] * 100, nil
+}
+
+// platformGPUUsage Linux 上只能通过 nvidia-smi 读取 NVIDIA 显卡的使用率
+func platformGPUUsage(pid int) (gpuUsage, error) {
+	return nvidiaGPUUsage()
+}
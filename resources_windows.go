@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// gpuEngineCounter GPU 各引擎使用率的性能计数器，实例名包含进程ID和引擎类型，
+// 例如 pid_1234_luid_0x00000000_0x0000C2F8_phys_0_eng_0_engtype_VideoEncode
+const gpuEngineCounter = `\GPU Engine(*)\Utilization Percentage`
+
+var procK32GetProcessMemoryInfo = kernel32.NewProc("K32GetProcessMemoryInfo")
+
+// processMemoryCounters PROCESS_MEMORY_COUNTERS 结构
+type processMemoryCounters struct {
+	CB                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+// filetimeDuration 将 FILETIME 表示的时长（100纳秒为单位）转换为 time.Duration
+func filetimeDuration(ft windows.Filetime) time.Duration {
+	return time.Duration(uint64(ft.HighDateTime)<<32|uint64(ft.LowDateTime)) * 100
+}
+
+// processUsage 使用 GetProcessTimes 和 GetProcessMemoryInfo 读取进程使用的CPU时间和工作集大小
+func processUsage(pid int) (time.Duration, uint64, error) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer windows.CloseHandle(handle)
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return 0, 0, err
+	}
+	counters := processMemoryCounters{}
+	counters.CB = uint32(unsafe.Sizeof(counters))
+	if r, _, err := procK32GetProcessMemoryInfo.Call(uintptr(handle), uintptr(unsafe.Pointer(&counters)), uintptr(counters.CB)); r == 0 {
+		return 0, 0, err
+	}
+	return filetimeDuration(kernel) + filetimeDuration(user), uint64(counters.WorkingSetSize), nil
+}
+
+// systemMemoryPercent 使用 GlobalMemoryStatusEx 读取已使用的内存百分比
+func systemMemoryPercent() (float64, error) {
+	status := memoryStatusEx{}
+	status.Length = uint32(unsafe.Sizeof(status))
+	if r, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status))); r == 0 {
+		return 0, err
+	}
+	return float64(status.MemoryLoad), nil
+}
+
+// perfCounterGPUUsage 使用 typeperf 读取 GPU Engine 性能计数器，只统计 ffmpeg 进程：
+// 按引擎类型（3D、VideoEncode、VideoDecode 等）汇总，GPU 使用率取最忙的引擎，与任务管理器相同。
+// 非英文系统的计数器名称是本地化的，读取会失败
+func perfCounterGPUUsage(pid int) (gpuUsage, error) {
+	cmd := exec.Command("typeperf", gpuEngineCounter, "-sc", "1")
+	hideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return gpuUsage{}, err
+	}
+	// 输出的 CSV 前后有说明文字，只保留以引号开头的行：第一行是计数器路径，第二行是采样值
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, `"`) {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < 2 {
+		return gpuUsage{}, errNoGPUUsage
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.Join(lines[:2], "\n"))).ReadAll()
+	if err != nil || len(records) < 2 || len(records[0]) != len(records[1]) {
+		return gpuUsage{}, errNoGPUUsage
+	}
+
+	prefix := "pid_" + strconv.Itoa(pid) + "_"
+	engines := map[string]float64{}
+	for i := 1; i < len(records[0]); i++ {
+		instance := records[0][i]
+		start, end := strings.IndexByte(instance, '('), strings.LastIndexByte(instance, ')')
+		if start < 0 || end < start || !strings.HasPrefix(instance[start+1:end], prefix) {
+			continue
+		}
+		_, engine, ok := strings.Cut(instance[start+1:end], "engtype_")
+		value, err := strconv.ParseFloat(strings.TrimSpace(records[1][i]), 64)
+		if ok && err == nil {
+			engines[engine] += value
+		}
+	}
+	// 进程没有使用GPU时没有对应的实例，使用率为0
+	gpu, encoder := 0.0, min(engines["VideoEncode"], 100)
+	for _, value := range engines {
+		gpu = max(gpu, min(value, 100))
+	}
+	return gpuUsage{percent: &gpu, encoderPercent: &encoder, source: "perfcounter"}, nil
+}
+
+// platformGPUUsage 优先使用性能计数器（只统计 ffmpeg 进程，支持所有厂商的显卡），失败时使用 nvidia-smi
+func platformGPUUsage(pid int) (gpuUsage, error) {
+	usage, err := perfCounterGPUUsage(pid)
+	if err == nil {
+		return usage, nil
+	}
+	if usage, nvidiaErr := nvidiaGPUUsage(); nvidiaErr == nil {
+		return usage, nil
+	}
+	return gpuUsage{}, errors.Join(errNoGPUUsage, err)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+)
+
+// libraryRootOf 返回包含 path 的视频库根目录（下载目录、转码目录或已启用的视频库文件夹），path 需为绝对路径
+func libraryRootOf(path string) (libraryRoot, bool) {
+	roots := libraryRoots()
+	for _, folder := range currentSettings().LibraryFolders {
+		if folder.Enabled {
+			roots = append(roots, folder.root())
+		}
+	}
+	for _, root := range roots {
+		if root.dir != "" && pathWithin(path, absPath(root.dir)) {
+			return root, true
+		}
+	}
+	return libraryRoot{}, false
+}
+
+// OpenContainingFolder opens the system file manager with the file selected
+// OpenContainingFolder 在系统文件管理器（资源管理器、Finder 或 Linux 桌面的文件管理器）中打开文件所在的文件夹并选中文件，
+// 只允许打开视频库根目录（下载目录、转码目录和已启用的视频库文件夹）内的文件或文件夹
+func (a *App) OpenContainingFolder(path string) (string, error) {
+	path = absPath(path)
+	root, ok := libraryRootOf(path)
+	if !ok {
+		return "", errorf(msgPathOutsideLibrary, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", errorf(msgFileNotFound, path)
+	}
+	if err := revealInFileManager(path); err != nil {
+		slog.Error("打开所在文件夹失败", "path", path, "error", err)
+		return "", errorf(msgOpenFolderFailed, err)
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"path":   path,
+		"root":   root.name,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
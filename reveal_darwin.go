@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// revealInFileManager 在 Finder 中显示并选中文件
+func revealInFileManager(path string) error {
+	if output, err := exec.Command("open", "-R", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
+
+// openWithDefault 用与文件类型关联的默认程序打开文件
+func openWithDefault(path string) error {
+	if output, err := exec.Command("open", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
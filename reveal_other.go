@@ -0,0 +1,32 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"log/slog"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// revealInFileManager 通过 D-Bus 的 org.freedesktop.FileManager1 接口让文件管理器打开文件所在的文件夹并选中文件，
+// Nautilus、Dolphin、Nemo 等都支持；不支持时用 xdg-open 打开所在文件夹
+func revealInFileManager(path string) error {
+	// dbus-send 用逗号分隔数组元素，路径中的逗号需要转义
+	fileURL := strings.ReplaceAll((&url.URL{Scheme: "file", Path: path}).String(), ",", "%2C")
+	cmd := exec.Command("dbus-send", "--session", "--print-reply", "--dest=org.freedesktop.FileManager1",
+		"--type=method_call", "/org/freedesktop/FileManager1", "org.freedesktop.FileManager1.ShowItems",
+		"array:string:"+fileURL, "string:")
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	slog.Debug("通过 FileManager1 显示文件失败，打开所在文件夹", "path", path, "error", err, "output", string(output))
+	return exec.Command("xdg-open", filepath.Dir(path)).Start()
+}
+
+// openWithDefault 通过 xdg-open 用桌面环境中与文件类型关联的默认程序打开文件
+func openWithDefault(path string) error {
+	return exec.Command("xdg-open", path).Start()
+}
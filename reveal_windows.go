@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// revealInFileManager 在资源管理器中打开文件所在的文件夹并选中文件。
+// explorer 要求路径紧跟在 /select, 之后并整体加引号，因此直接指定命令行；explorer 的退出码不可靠，只启动不等待
+func revealInFileManager(path string) error {
+	cmd := exec.Command("explorer")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `explorer /select,"` + path + `"`}
+	return cmd.Start()
+}
+
+// openWithDefault 通过 ShellExecute 用与文件类型关联的默认程序打开文件
+func openWithDefault(path string) error {
+	verb, err := windows.UTF16PtrFromString("open")
+	if err != nil {
+		return err
+	}
+	file, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	return windows.ShellExecute(0, verb, file, nil, nil, windows.SW_SHOWNORMAL)
+}
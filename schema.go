@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// taskSchemaVersion is the current version of the persisted task structure
+// taskSchemaVersion 当前持久化任务结构的版本号。修改 DownloadTask/TranscodeTask 的字段含义
+// 或状态取值时递增此版本，并在 taskRecordMigrations 末尾追加对应的迁移函数
+const taskSchemaVersion = 2
+
+// 任务类型，迁移函数据此区分下载和转码记录
+const (
+	taskKindDownload  = "download"
+	taskKindTranscode = "transcode"
+)
+
+// taskRecordMigration 将单条任务记录原地升级一个版本
+type taskRecordMigration func(kind string, record map[string]interface{})
+
+// taskRecordMigrations[i] 将记录从版本 i+1 升级到版本 i+2
+var taskRecordMigrations = []taskRecordMigration{
+	// 1 -> 2: 旧版本在任务结束后保留了已失效的pid，移除以免被误用于终止其他进程
+	func(kind string, record map[string]interface{}) {
+		status, _ := record["status"].(string)
+		active := status == "downloading" || status == "transcoding"
+		if !active {
+			delete(record, "pid")
+		}
+	},
+}
+
+// taskFile 是JSON进度文件的外层结构，旧版本（版本1）直接保存任务数组
+type taskFile struct {
+	Version int             `json:"version"`
+	Tasks   json.RawMessage `json:"tasks"`
+}
+
+// migrateTaskRecords 将原始任务数组从 version 升级到当前版本
+func migrateTaskRecords(kind string, version int, raw []byte) ([]byte, error) {
+	if version == taskSchemaVersion {
+		return raw, nil
+	}
+	if version > taskSchemaVersion {
+		return nil, fmt.Errorf("任务数据版本 %d 高于当前支持的版本 %d，请升级应用", version, taskSchemaVersion)
+	}
+	if version < 1 {
+		return nil, fmt.Errorf("无效的任务数据版本: %d", version)
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("解析任务数据失败: %w", err)
+	}
+	for v := version; v < taskSchemaVersion; v++ {
+		for _, record := range records {
+			taskRecordMigrations[v-1](kind, record)
+		}
+	}
+	return json.Marshal(records)
+}
+
+// decodeTaskList 解析JSON进度文件内容（支持旧版本的纯数组格式），升级到当前版本后解码到 v
+func decodeTaskList(kind string, data []byte, v interface{}) error {
+	version := 1
+	raw := bytes.TrimSpace(data)
+	if len(raw) > 0 && raw[0] == '{' {
+		var file taskFile
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return fmt.Errorf("解析任务文件失败: %w", err)
+		}
+		version = file.Version
+		raw = file.Tasks
+	}
+
+	migrated, err := migrateTaskRecords(kind, version, raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(migrated, v)
+}
+
+// encodeTaskList 生成带版本号的JSON进度文件内容
+func encodeTaskList(tasks interface{}) ([]byte, error) {
+	raw, err := json.Marshal(tasks)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(taskFile{Version: taskSchemaVersion, Tasks: raw}, "", "  ")
+}
+
+// decodeTaskRecord 将单条任务记录从 version 升级到当前版本后解码到 v
+func decodeTaskRecord(kind string, version int, data []byte, v interface{}) error {
+	if version == taskSchemaVersion {
+		return json.Unmarshal(data, v)
+	}
+	migrated, err := migrateTaskRecords(kind, version, append(append([]byte{'['}, data...), ']'))
+	if err != nil {
+		return err
+	}
+	var records []json.RawMessage
+	if err := json.Unmarshal(migrated, &records); err != nil {
+		return err
+	}
+	return json.Unmarshal(records[0], v)
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 元数据刮削的数据源
+const (
+	scraperTMDB = "tmdb"
+	scraperTVDB = "tvdb"
+)
+
+const (
+	// matchesFile 视频匹配结果的缓存文件名，保存在数据目录中
+	matchesFile = "library_matches.json"
+	tmdbAPI     = "https://api.themoviedb.org/3"
+	// tmdbPosterBase TMDB 海报地址前缀，w342 为海报宽度
+	tmdbPosterBase = "https://image.tmdb.org/t/p/w342"
+	tvdbAPI        = "https://api4.thetvdb.com/v4"
+	// scraperRequestInterval 两次查询之间的间隔，避免超过数据源的请求频率限制
+	scraperRequestInterval = 300 * time.Millisecond
+	// EventLibraryScraped 后台匹配完一个视频后推送，负载为 {path, match}，没有找到匹配时 match 为 null
+	EventLibraryScraped = "library:scraped"
+)
+
+// MediaMatch is a movie or show found on TMDB/TVDB
+// MediaMatch 在 TMDB/TVDB 上匹配到的电影或剧集
+type MediaMatch struct {
+	Provider string `json:"provider"`
+	ID       string `json:"id"`
+	// 类型：movie, tv
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Year      int    `json:"year,omitempty"`
+	PosterURL string `json:"posterUrl,omitempty"`
+	Overview  string `json:"overview,omitempty"`
+	// 用户手动选择的匹配，自动匹配不会覆盖
+	Manual bool `json:"manual,omitempty"`
+}
+
+// matchEntry 缓存的匹配结果，Match 为空表示没有找到，数据源变化后重新匹配（手动匹配除外）
+type matchEntry struct {
+	Provider string      `json:"provider"`
+	Match    *MediaMatch `json:"match,omitempty"`
+}
+
+// scraperLanguage 界面语言对应的 TMDB 语言和 TVDB 语言代码
+func scraperLanguage() (string, string) {
+	if currentSettings().Language == localeEn {
+		return "en-US", "eng"
+	}
+	return "zh-CN", "zho"
+}
+
+// mediaScraper 在后台为视频库中的视频查询 TMDB/TVDB，结果缓存到数据目录
+type mediaScraper struct {
+	mu      sync.Mutex
+	entries map[string]matchEntry
+	dirty   bool
+	queue   videoQueue
+	// queries 本次运行中查询过的标题，同一剧集的多个文件只查询一次
+	queries map[string]*MediaMatch
+	// TVDB 登录令牌及对应的API密钥
+	tvdbToken string
+	tvdbKey   string
+	client    *http.Client
+}
+
+func newMediaScraper() *mediaScraper {
+	return &mediaScraper{
+		entries: make(map[string]matchEntry),
+		queries: make(map[string]*MediaMatch),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// load 读取匹配结果缓存，文件不存在时从空缓存开始
+func (s *mediaScraper) load() {
+	data, err := readFileWithRecovery(dataPath(matchesFile), func(data []byte) error {
+		var entries map[string]matchEntry
+		return json.Unmarshal(data, &entries)
+	})
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("读取视频匹配缓存失败", "error", err)
+		}
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		slog.Error("解析视频匹配缓存失败", "error", err)
+	}
+}
+
+// save 写入匹配结果缓存，没有变化时跳过
+func (s *mediaScraper) save() {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(s.entries)
+	s.dirty = false
+	s.mu.Unlock()
+	if err != nil {
+		slog.Error("保存视频匹配缓存失败", "error", err)
+		return
+	}
+	if err := writeFileAtomic(dataPath(matchesFile), data, 0644); err != nil {
+		slog.Error("保存视频匹配缓存失败", "error", err)
+	}
+}
+
+// attach 填写已缓存的匹配结果，provider 不为空时将没有匹配过的视频加入队列，返回是否需要启动后台匹配
+func (s *mediaScraper) attach(videos []LibraryVideo, provider string) bool {
+	s.mu.Lock()
+	var missing []LibraryVideo
+	for i := range videos {
+		entry, ok := s.entries[videos[i].Path]
+		if ok {
+			videos[i].Match = entry.Match
+		}
+		if provider != "" && (!ok || (entry.Provider != provider && (entry.Match == nil || !entry.Match.Manual))) {
+			missing = append(missing, videos[i])
+		}
+	}
+	s.mu.Unlock()
+	return s.queue.push(missing)
+}
+
+// store 记录视频的匹配结果
+func (s *mediaScraper) store(path string, entry matchEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[path] = entry
+	s.dirty = true
+}
+
+// remove 删除视频的匹配结果，下次获取视频库时重新自动匹配
+func (s *mediaScraper) remove(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, path)
+	s.dirty = true
+}
+
+// rename 将视频的匹配结果转移到新路径，keep 为 true 时同时保留原路径的匹配结果（例如创建硬链接）
+func (s *mediaScraper) rename(from, to string, keep bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[from]
+	if !ok {
+		return
+	}
+	s.entries[to] = entry
+	if !keep {
+		delete(s.entries, from)
+	}
+	s.dirty = true
+}
+
+// do 发送请求并解析 JSON 响应，返回 HTTP 状态码
+func (s *mediaScraper) do(req *http.Request, v interface{}) (int, error) {
+	req.Header.Set("User-Agent", "SeedParser/"+appVersion)
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, errorf(msgServerReturnedStatus, resp.Status)
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(v)
+}
+
+// searchTMDB 在 TMDB 中搜索电影和剧集。API密钥可以是 v3 API Key 或 v4 读取令牌
+func (s *mediaScraper) searchTMDB(ctx context.Context, apiKey, query string) ([]MediaMatch, error) {
+	language, _ := scraperLanguage()
+	params := url.Values{"query": {query}, "language": {language}, "include_adult": {"false"}}
+	// v4 读取令牌是 JWT，通过请求头传入；v3 API Key 通过查询参数传入
+	bearer := strings.Count(apiKey, ".") == 2
+	if !bearer {
+		params.Set("api_key", apiKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tmdbAPI+"/search/multi?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if bearer {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	var result struct {
+		Results []struct {
+			ID           int    `json:"id"`
+			MediaType    string `json:"media_type"`
+			Title        string `json:"title"`
+			Name         string `json:"name"`
+			ReleaseDate  string `json:"release_date"`
+			FirstAirDate string `json:"first_air_date"`
+			PosterPath   string `json:"poster_path"`
+			Overview     string `json:"overview"`
+		} `json:"results"`
+	}
+	if _, err := s.do(req, &result); err != nil {
+		return nil, err
+	}
+	matches := []MediaMatch{}
+	for _, r := range result.Results {
+		if r.MediaType != "movie" && r.MediaType != "tv" {
+			continue
+		}
+		match := MediaMatch{
+			Provider: scraperTMDB,
+			ID:       strconv.Itoa(r.ID),
+			Type:     r.MediaType,
+			Title:    r.Title,
+			Overview: r.Overview,
+		}
+		date := r.ReleaseDate
+		if r.MediaType == "tv" {
+			match.Title, date = r.Name, r.FirstAirDate
+		}
+		if len(date) >= 4 {
+			match.Year, _ = strconv.Atoi(date[:4])
+		}
+		if r.PosterPath != "" {
+			match.PosterURL = tmdbPosterBase + r.PosterPath
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// tvdbLogin 使用API密钥登录 TVDB，令牌有效期一个月，在本次运行中复用
+func (s *mediaScraper) tvdbLogin(ctx context.Context, apiKey string) (string, error) {
+	s.mu.Lock()
+	if s.tvdbToken != "" && s.tvdbKey == apiKey {
+		token := s.tvdbToken
+		s.mu.Unlock()
+		return token, nil
+	}
+	s.mu.Unlock()
+
+	body, err := json.Marshal(map[string]string{"apikey": apiKey})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tvdbAPI+"/login", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var result struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if _, err := s.do(req, &result); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.tvdbToken, s.tvdbKey = result.Data.Token, apiKey
+	s.mu.Unlock()
+	return result.Data.Token, nil
+}
+
+// searchTVDB 在 TVDB 中搜索剧集和电影，标题和简介优先使用界面语言的翻译
+func (s *mediaScraper) searchTVDB(ctx context.Context, apiKey, query string) ([]MediaMatch, error) {
+	token, err := s.tvdbLogin(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tvdbAPI+"/search?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var result struct {
+		Data []struct {
+			TVDBID       string            `json:"tvdb_id"`
+			Type         string            `json:"type"`
+			Name         string            `json:"name"`
+			Year         string            `json:"year"`
+			ImageURL     string            `json:"image_url"`
+			Overview     string            `json:"overview"`
+			Translations map[string]string `json:"translations"`
+			Overviews    map[string]string `json:"overviews"`
+		} `json:"data"`
+	}
+	if status, err := s.do(req, &result); err != nil {
+		if status == http.StatusUnauthorized {
+			// 令牌已过期，下次查询时重新登录
+			s.mu.Lock()
+			s.tvdbToken = ""
+			s.mu.Unlock()
+		}
+		return nil, err
+	}
+	_, language := scraperLanguage()
+	matches := []MediaMatch{}
+	for _, r := range result.Data {
+		if r.Type != "series" && r.Type != "movie" {
+			continue
+		}
+		match := MediaMatch{
+			Provider:  scraperTVDB,
+			ID:        r.TVDBID,
+			Type:      "movie",
+			Title:     r.Name,
+			PosterURL: r.ImageURL,
+			Overview:  r.Overview,
+		}
+		if r.Type == "series" {
+			match.Type = "tv"
+		}
+		if title := r.Translations[language]; title != "" {
+			match.Title = title
+		}
+		if overview := r.Overviews[language]; overview != "" {
+			match.Overview = overview
+		}
+		match.Year, _ = strconv.Atoi(r.Year)
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// search 使用设置中的数据源搜索，未启用刮削时返回错误
+func (s *mediaScraper) search(ctx context.Context, query string) ([]MediaMatch, error) {
+	current := currentSettings()
+	var matches []MediaMatch
+	var err error
+	switch current.MetadataProvider {
+	case scraperTMDB:
+		matches, err = s.searchTMDB(ctx, current.TMDBAPIKey, query)
+	case scraperTVDB:
+		matches, err = s.searchTVDB(ctx, current.TVDBAPIKey, query)
+	default:
+		return nil, errorf(msgScraperDisabled)
+	}
+	if err != nil {
+		return nil, errorf(msgScraperRequestFailed, current.MetadataProvider, err)
+	}
+	return matches, nil
+}
+
+// bestMatch 选择搜索结果中的最佳匹配：剧集文件优先选择剧集、其他文件优先选择电影，
+// 年份已知时优先选择同一年的结果，都不满足时使用第一个结果
+func bestMatch(matches []MediaMatch, parsed ParsedName) *MediaMatch {
+	if len(matches) == 0 {
+		return nil
+	}
+	wantType := "movie"
+	if parsed.IsEpisode() {
+		wantType = "tv"
+	}
+	best, bestScore := 0, -1
+	for i, m := range matches {
+		score := 0
+		if m.Type == wantType {
+			score += 2
+		}
+		if parsed.Year > 0 && m.Year == parsed.Year {
+			score += 3
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return &matches[best]
+}
+
+// match 自动匹配一个视频，同一标题在本次运行中只查询一次
+func (s *mediaScraper) match(ctx context.Context, video LibraryVideo) (*MediaMatch, error) {
+	parsed := video.Parsed
+	if parsed.Title == "" {
+		return nil, nil
+	}
+	key := strings.ToLower(parsed.Title) + "|" + strconv.Itoa(parsed.Year) + "|" + strconv.FormatBool(parsed.IsEpisode())
+	s.mu.Lock()
+	match, ok := s.queries[key]
+	s.mu.Unlock()
+	if ok {
+		return match, nil
+	}
+
+	matches, err := s.search(ctx, parsed.Title)
+	if err != nil {
+		return nil, err
+	}
+	match = bestMatch(matches, parsed)
+	s.mu.Lock()
+	s.queries[key] = match
+	s.mu.Unlock()
+	time.Sleep(scraperRequestInterval)
+	return match, nil
+}
+
+// attachMatches 为视频库中的视频填写已缓存的匹配结果，启用了刮削时在后台匹配其余视频，
+// 每匹配完一个推送 EventLibraryScraped 事件
+func (a *App) attachMatches(videos []LibraryVideo) {
+	current := currentSettings()
+	if a.scraper.attach(videos, current.MetadataProvider) {
+		go a.scrapeQueuedVideos()
+	}
+}
+
+// scrapeQueuedVideos 逐个匹配队列中的视频，查询失败（网络错误、密钥无效）时停止，之后获取视频库时重试
+func (a *App) scrapeQueuedVideos() {
+	defer a.scraper.save()
+	for {
+		video, ok := a.scraper.queue.next()
+		if !ok {
+			return
+		}
+		provider := currentSettings().MetadataProvider
+		if provider == "" {
+			a.scraper.queue.clear()
+			return
+		}
+		match, err := a.scraper.match(context.Background(), video)
+		if err != nil {
+			slog.Warn("匹配视频元数据失败", "path", video.Path, "error", err)
+			a.scraper.queue.clear()
+			return
+		}
+		a.scraper.store(video.Path, matchEntry{Provider: provider, Match: match})
+		a.emitEvent(EventLibraryScraped, map[string]interface{}{
+			"path":  video.Path,
+			"match": match,
+		})
+	}
+}
+
+// SearchMediaMetadata searches the configured provider for manual matching
+// SearchMediaMetadata 在设置的数据源（TMDB 或 TVDB）中搜索电影和剧集，用于手动匹配
+func (a *App) SearchMediaMetadata(query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", errorf(msgEmptySearchQuery)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	matches, err := a.scraper.search(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"results": matches,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// SetMediaMatch manually matches a library video
+// SetMediaMatch 手动指定视频的匹配结果，matchData 为 SearchMediaMetadata 返回的一个结果，自动匹配不会覆盖
+func (a *App) SetMediaMatch(path string, matchData string) (string, error) {
+	var match MediaMatch
+	if err := json.Unmarshal([]byte(matchData), &match); err != nil {
+		return "", errorf(msgParseMatchFailed, err)
+	}
+	match.Manual = true
+	a.scraper.store(path, matchEntry{Provider: match.Provider, Match: &match})
+	a.scraper.save()
+	a.emitEvent(EventLibraryScraped, map[string]interface{}{
+		"path":  path,
+		"match": &match,
+	})
+
+	response := map[string]interface{}{
+		"status": "success",
+		"match":  match,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// ClearMediaMatch removes a video's match so it is matched again automatically
+// ClearMediaMatch 清除视频的匹配结果（包括手动匹配），下次获取视频库时重新自动匹配
+func (a *App) ClearMediaMatch(path string) (string, error) {
+	a.scraper.remove(path)
+	a.scraper.save()
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Match cleared",
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// extraSearchDirs 从 Finder 或 Dock 启动的程序只有 /usr/bin:/bin:/usr/sbin:/sbin，
+// 不包含 Homebrew 和 MacPorts 的安装目录，在其中查找不到 ffmpeg
+var extraSearchDirs = []string{"/opt/homebrew/bin", "/usr/local/bin", "/opt/local/bin"}
+
+// extendSearchPath 将程序包 Contents/MacOS 目录和常用安装目录加入 PATH，已存在的目录不重复添加
+func extendSearchPath() {
+	dirs := filepath.SplitList(os.Getenv("PATH"))
+	existing := make(map[string]bool)
+	for _, dir := range dirs {
+		existing[dir] = true
+	}
+	candidates := extraSearchDirs
+	if exe, err := os.Executable(); err == nil {
+		candidates = append([]string{filepath.Dir(exe)}, candidates...)
+	}
+	for _, dir := range candidates {
+		if existing[dir] {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+			existing[dir] = true
+		}
+	}
+	os.Setenv("PATH", strings.Join(dirs, string(os.PathListSeparator)))
+}
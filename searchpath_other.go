@@ -0,0 +1,6 @@
+//go:build !darwin
+
+package main
+
+// extendSearchPath 只有 macOS 从 Finder 启动的程序 PATH 不完整，其他平台无需处理
+func extendSearchPath() {}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// errPathOutsideRoot 请求的路径不在允许访问的目录内
+var errPathOutsideRoot = errors.New("路径不在允许访问的目录内")
+
+// resolveServedPath 将 URL 中相对于 root 的路径（已解码，使用 / 分隔）转换为文件路径：
+// 拒绝 ..、绝对路径、盘符和 Windows 保留名称等非本地路径，并解析符号链接，确保最终的文件仍在 root 内
+// （root 本身可以是符号链接）。只比较字符串前缀会把 downloads_evil 当作 downloads 内的路径，这里按路径层级比较
+func resolveServedPath(root, relPath string) (string, error) {
+	rel := filepath.FromSlash(strings.TrimPrefix(relPath, "/"))
+	if rel == "" {
+		rel = "."
+	}
+	if strings.ContainsRune(rel, 0) || !filepath.IsLocal(rel) {
+		return "", errPathOutsideRoot
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	realRoot, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return "", err
+	}
+	realPath, err := filepath.EvalSymlinks(filepath.Join(realRoot, rel))
+	if err != nil {
+		return "", err
+	}
+	// 文件或其上级目录是指向 root 外的符号链接
+	if !pathWithin(realPath, realRoot) {
+		return "", errPathOutsideRoot
+	}
+	return realPath, nil
+}
+
+// serveFromRoot 提供 root 目录中的文件，支持 Range 请求；没有文件访问令牌或路径越界时返回 403，文件或目录不存在时返回 404
+func serveFromRoot(w http.ResponseWriter, r *http.Request, root, relPath string) {
+	if !requireFileToken(w, r) {
+		return
+	}
+	path, err := resolveServedPath(root, relPath)
+	switch {
+	case errors.Is(err, errPathOutsideRoot):
+		slog.Warn("拒绝访问目录外的文件", "root", root, "path", relPath, "remote", r.RemoteAddr)
+		http.Error(w, "Access denied", http.StatusForbidden)
+	case errors.Is(err, fs.ErrNotExist):
+		http.NotFound(w, r)
+	case err != nil:
+		http.Error(w, "Invalid file path", http.StatusBadRequest)
+	default:
+		serveMediaFile(w, r, path)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// newServeTestRoot 创建提供文件的目录 downloads 和同级的 downloads_evil、outside 目录
+func newServeTestRoot(t *testing.T) (root, outside string) {
+	t.Helper()
+	base := t.TempDir()
+	root = filepath.Join(base, "downloads")
+	outside = filepath.Join(base, "outside")
+	for _, file := range []string{
+		filepath.Join(root, "movie.mp4"),
+		filepath.Join(root, "sub", "dir", "episode.mkv"),
+		filepath.Join(base, "downloads_evil", "secret.txt"),
+		filepath.Join(outside, "secret.txt"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(filepath.Base(file)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, outside
+}
+
+// symlinkOrSkip 创建符号链接，系统不支持（例如没有权限的 Windows）时跳过测试
+func symlinkOrSkip(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("无法创建符号链接: %v", err)
+	}
+}
+
+func TestResolveServedPath(t *testing.T) {
+	root, _ := newServeTestRoot(t)
+
+	// 盘符在 Windows 上是绝对路径；其他系统上只是普通的文件名，解析为 root 内不存在的文件
+	driveErr := fs.ErrNotExist
+	if runtime.GOOS == "windows" {
+		driveErr = errPathOutsideRoot
+	}
+
+	tests := []struct {
+		name    string
+		relPath string
+		want    string
+		wantErr error
+	}{
+		{"文件", "movie.mp4", "movie.mp4", nil},
+		{"子目录中的文件", "sub/dir/episode.mkv", "sub/dir/episode.mkv", nil},
+		{"开头的斜杠", "/movie.mp4", "movie.mp4", nil},
+		{"目录内的 ..", "sub/dir/../../movie.mp4", "movie.mp4", nil},
+		{"上级目录", "../outside/secret.txt", "", errPathOutsideRoot},
+		{"多层 ..", "sub/../../outside/secret.txt", "", errPathOutsideRoot},
+		{"名称相同前缀的目录", "../downloads_evil/secret.txt", "", errPathOutsideRoot},
+		// URL 中的路径去掉开头的一个斜杠后仍然是绝对路径
+		{"绝对路径", "//etc/passwd", "", errPathOutsideRoot},
+		{"UNC 路径", "//server/share/secret.txt", "", errPathOutsideRoot},
+		{"盘符", "C:/Windows/win.ini", "", driveErr},
+		{"空字节", "movie.mp4\x00.txt", "", errPathOutsideRoot},
+		{"不存在的文件", "missing.mp4", "", fs.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveServedPath(root, tt.relPath)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("resolveServedPath(%q) = %q, %v，应返回 %v", tt.relPath, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveServedPath(%q) 失败: %v", tt.relPath, err)
+			}
+			realRoot, _ := filepath.EvalSymlinks(root)
+			if want := filepath.Join(realRoot, filepath.FromSlash(tt.want)); got != want {
+				t.Fatalf("resolveServedPath(%q) = %q，应为 %q", tt.relPath, got, want)
+			}
+		})
+	}
+}
+
+func TestResolveServedPathSymlinks(t *testing.T) {
+	root, outside := newServeTestRoot(t)
+	symlinkOrSkip(t, outside, filepath.Join(root, "linked-dir"))
+	symlinkOrSkip(t, filepath.Join(outside, "secret.txt"), filepath.Join(root, "linked-file.txt"))
+	symlinkOrSkip(t, filepath.Join(root, "movie.mp4"), filepath.Join(root, "inside-link.mp4"))
+
+	for _, relPath := range []string{"linked-dir/secret.txt", "linked-file.txt"} {
+		if _, err := resolveServedPath(root, relPath); !errors.Is(err, errPathOutsideRoot) {
+			t.Errorf("指向目录外的符号链接 %q 应被拒绝，实际为 %v", relPath, err)
+		}
+	}
+	if _, err := resolveServedPath(root, "inside-link.mp4"); err != nil {
+		t.Errorf("指向目录内的符号链接应可以访问: %v", err)
+	}
+
+	// root 本身是符号链接
+	linkedRoot := filepath.Join(filepath.Dir(root), "root-link")
+	symlinkOrSkip(t, root, linkedRoot)
+	if _, err := resolveServedPath(linkedRoot, "movie.mp4"); err != nil {
+		t.Errorf("通过符号链接的 root 访问文件失败: %v", err)
+	}
+}
+
+func TestServeFromRoot(t *testing.T) {
+	root, _ := newServeTestRoot(t)
+	// 与 main.go 中 /downloads/ 的处理相同
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveFromRoot(w, r, root, strings.TrimPrefix(r.URL.Path, "/downloads/"))
+	})
+	token := "?token=" + fileAccessToken()
+
+	tests := []struct {
+		name   string
+		target string
+		want   int
+		body   string
+	}{
+		{"文件", "/downloads/movie.mp4" + token, http.StatusOK, "movie.mp4"},
+		{"子目录中的文件", "/downloads/sub/dir/episode.mkv" + token, http.StatusOK, "episode.mkv"},
+		{"没有令牌", "/downloads/movie.mp4", http.StatusForbidden, ""},
+		{"错误的令牌", "/downloads/movie.mp4?token=wrong", http.StatusForbidden, ""},
+		{"上级目录", "/downloads/../outside/secret.txt" + token, http.StatusForbidden, ""},
+		{"编码的 ..", "/downloads/%2e%2e/outside/secret.txt" + token, http.StatusForbidden, ""},
+		{"编码的斜杠", "/downloads/..%2foutside%2fsecret.txt" + token, http.StatusForbidden, ""},
+		{"两次编码的 ..", "/downloads/%252e%252e/outside/secret.txt" + token, http.StatusNotFound, ""},
+		{"名称相同前缀的目录", "/downloads/../downloads_evil/secret.txt" + token, http.StatusForbidden, ""},
+		{"不存在的文件", "/downloads/missing.mp4" + token, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.want {
+				t.Fatalf("%s 返回 %d，应为 %d", tt.target, w.Code, tt.want)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Fatalf("%s 返回的内容为 %q，应为 %q", tt.target, w.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// apiPrefix REST API 路径前缀，方法名直接对应 App 上绑定给前端的方法
+const apiPrefix = "/api/"
+
+// serverOptions 无界面服务器模式的命令行参数
+type serverOptions struct {
+	host string
+	port int
+}
+
+// parseServerFlags parses the command line, reporting whether server mode was requested
+// parseServerFlags 解析命令行参数，返回是否以服务器模式运行；
+// 只有出现 --server 时才解析，避免与Wails开发模式的参数冲突
+func parseServerFlags(args []string) (serverOptions, bool, error) {
+	requested := false
+	for _, arg := range args {
+		if arg == "--server" || arg == "-server" {
+			requested = true
+			break
+		}
+	}
+	if !requested {
+		return serverOptions{}, false, nil
+	}
+
+	var opts serverOptions
+	fs := flag.NewFlagSet("SeedParser", flag.ContinueOnError)
+	fs.Bool("server", false, "以无界面服务器模式运行，通过REST API提供下载和转码功能")
+	fs.StringVar(&opts.host, "host", "0.0.0.0", "REST API 监听地址")
+	fs.IntVar(&opts.port, "port", 0, "REST API 监听端口，默认使用设置中的端口")
+	if err := fs.Parse(args); err != nil {
+		return opts, true, err
+	}
+	return opts, true, nil
+}
+
+// apiMethod 可以通过REST API调用的方法
+type apiMethod struct {
+	name   string
+	method reflect.Value
+	// check 在调用前检查参数，拒绝只能在桌面端使用的参数，为空表示不检查
+	check func(args []reflect.Value) error
+}
+
+// remoteMethods 可以通过REST API和WebSocket调用的方法（浏览器界面、命令行、局域网客户端和插件）。
+// 其余绑定方法只给桌面端前端使用：文件访问令牌、打开本机对话框或程序、修改数据目录、安装更新、加载插件，
+// 以及传入原始 ffmpeg 参数，这些操作会让能登录的局域网客户端访问本机文件或运行任意程序。新增的方法需要在这里显式加入
+var remoteMethods = map[string]bool{
+	"AddMagnetLink":              true,
+	"AddTranscodeTask":           true,
+	"AddTranscodeTaskWithPreset": true,
+	"AppendChunk":                true,
+	"ApplyOrganize":              true,
+	"BeginUpload":                true,
+	"CancelChecksums":            true,
+	"CancelDownload":             true,
+	"CancelIntegrityCheck":       true,
+	"CancelTorrentVerify":        true,
+	"CancelTranscode":            true,
+	"CancelUpload":               true,
+	"CheckForUpdate":             true,
+	"ClearHistory":               true,
+	"ClearMediaMatch":            true,
+	"ClearThumbnailCache":        true,
+	"ConvertSubtitle":            true,
+	"DeleteDownloadEntry":        true,
+	"DeleteHistoryTask":          true,
+	"DeleteLibraryFile":          true,
+	"DownloadSubtitle":           true,
+	"DownloadTorrentFiles":       true,
+	"DownloadWithTool":           true,
+	"EditTorrentFile":            true,
+	"EnumerateDrives":            true,
+	"ExportChecksums":            true,
+	"ExtractArchives":            true,
+	"ExtractSubtitle":            true,
+	"FindDuplicates":             true,
+	"FinishUpload":               true,
+	"GenerateAPIToken":           true,
+	"GenerateMagnetLink":         true,
+	"GetChecksums":               true,
+	"GetContinueWatching":        true,
+	"GetDataDir":                 true,
+	"GetDiskSpace":               true,
+	"GetDiskUsage":               true,
+	"GetDownloadStatus":          true,
+	"GetGlobalStats":             true,
+	"GetHistory":                 true,
+	"GetIntegrityCheck":          true,
+	"GetPlugins":                 true,
+	"GetPublicTrackers":          true,
+	"GetQueueAction":             true,
+	"GetRecentLogs":              true,
+	"GetRecentlyAdded":           true,
+	"GetRemoteAccess":            true,
+	"GetRemovedTasks":            true,
+	"GetSettings":                true,
+	"GetSubtitleTracks":          true,
+	"GetSystemInfo":              true,
+	"GetTranscodePresets":        true,
+	"GetTranscodeStatus":         true,
+	"GetUploadStatus":            true,
+	"GetVideoLibrary":            true,
+	"ParseMagnetLink":            true,
+	"ParseTorrentFile":           true,
+	"ParseTorrentFiles":          true,
+	"ParseTorrentURL":            true,
+	"PauseAll":                   true,
+	"PauseAllDownloads":          true,
+	"PauseDownload":              true,
+	"PreviewOrganize":            true,
+	"QueryDownloads":             true,
+	"QueryTranscodes":            true,
+	"QueryVideoLibrary":          true,
+	"RescanLibrary":              true,
+	"ResumeAll":                  true,
+	"ResumeAllDownloads":         true,
+	"ResumeDownload":             true,
+	"SavePlaybackPosition":       true,
+	"SaveSettings":               true,
+	"SearchMediaMetadata":        true,
+	"SearchSubtitles":            true,
+	"ServeVideoFile":             true,
+	"SetDownloadPriority":        true,
+	"SetMediaMatch":              true,
+	"SetQueueAction":             true,
+	"SetTaskNote":                true,
+	"StartChecksums":             true,
+	"StartIntegrityCheck":        true,
+	"StartTranscode":             true,
+	"StartWaitingTask":           true,
+	"StopLiveTranscode":          true,
+	"TestNotification":           true,
+	"TestWebhook":                true,
+	"UndoRemove":                 true,
+	"UploadFile":                 true,
+	"VerifyAgainstTorrent":       true,
+}
+
+// remoteArgChecks 可以远程调用、但部分参数只能在桌面端修改的方法：外部程序的路径和原始 ffmpeg 参数
+var remoteArgChecks = map[string]func(args []reflect.Value) error{
+	"SaveSettings": func(args []reflect.Value) error {
+		return checkRemoteSettings(args[0].String())
+	},
+	"StartTranscode": func(args []reflect.Value) error {
+		return checkRemoteTranscode(args[0].String())
+	},
+}
+
+// apiMethods 收集 remoteMethods 中返回 (string, error) 的方法
+func apiMethods(app *App) map[string]apiMethod {
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	methods := make(map[string]apiMethod)
+	v := reflect.ValueOf(app)
+	t := v.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		mt := m.Type
+		if mt.NumOut() != 2 || mt.Out(0).Kind() != reflect.String || mt.Out(1) != errorType || !remoteMethods[m.Name] {
+			continue
+		}
+		methods[m.Name] = apiMethod{name: m.Name, method: v.Method(i), check: remoteArgChecks[m.Name]}
+	}
+	return methods
+}
+
+// call 按方法参数类型解码JSON数组形式的参数并调用方法
+func (m apiMethod) call(body []byte) (string, error) {
+	mt := m.method.Type()
+	var rawArgs []json.RawMessage
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &rawArgs); err != nil {
+			return "", errorf(msgArgsMustBeArray, err)
+		}
+	}
+	if len(rawArgs) != mt.NumIn() {
+		return "", errorf(msgWrongArgCount, m.name, mt.NumIn(), len(rawArgs))
+	}
+
+	args := make([]reflect.Value, mt.NumIn())
+	for i := range args {
+		arg := reflect.New(mt.In(i))
+		if err := json.Unmarshal(rawArgs[i], arg.Interface()); err != nil {
+			return "", errorf(msgInvalidArg, i+1, err)
+		}
+		args[i] = arg.Elem()
+	}
+	if m.check != nil {
+		if err := m.check(args); err != nil {
+			return "", err
+		}
+	}
+
+	out := m.method.Call(args)
+	if err, _ := out[1].Interface().(error); err != nil {
+		return "", err
+	}
+	// 远程客户端使用登录会话访问文件，返回的地址中不包含文件访问令牌
+	return stripFileToken(out[0].String()), nil
+}
+
+// writeJSONError 以统一格式返回错误；本地化错误同时返回消息键 code，脚本可以据此判断错误类型
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	response := map[string]interface{}{
+		"status":  "error",
+		"message": err.Error(),
+	}
+	if key := errorKey(err); key != "" {
+		response["code"] = key
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// newAPIHandler builds the REST API handler exposing the App's bound methods
+// newAPIHandler 创建REST API处理器：
+//
+//	GET  /api/            列出所有可调用的方法及参数个数
+//	POST /api/{Method}    请求体为JSON参数数组，例如 ["task-1"]
+//
+// 调用方法只接受 Content-Type 为 application/json 的 POST 请求：浏览器中的其他网页无法在不经过CORS预检的情况下
+// 发送这样的请求，也就不能借用户的登录状态调用方法。方法返回的JSON字符串原样作为响应体，出错时返回 {"status":"error","message":...}
+func newAPIHandler(app *App) http.Handler {
+	methods := apiMethods(app)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+		if name == "" {
+			var list []map[string]interface{}
+			for _, m := range methods {
+				list = append(list, map[string]interface{}{
+					"name": m.name,
+					"args": m.method.Type().NumIn(),
+				})
+			}
+			sort.Slice(list, func(i, j int) bool {
+				return list[i]["name"].(string) < list[j]["name"].(string)
+			})
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"status":  "success",
+				"methods": list,
+			})
+			return
+		}
+
+		m, ok := methods[name]
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, errorf(msgUnknownMethod, name))
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeJSONError(w, http.StatusMethodNotAllowed, errorf(msgUsePost, name))
+			return
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeJSONError(w, http.StatusUnsupportedMediaType, errorf(msgJSONContentType))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, errorf(msgReadRequestFailed, err))
+			return
+		}
+		result, err := m.call(body)
+		if err != nil {
+			slog.Error("API调用失败", "method", name, "error", err)
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, result)
+	})
+}
+
+// runServer runs the download/transcode engine without a window, serving the REST API until interrupted
+// runServer 以无界面模式运行下载和转码引擎，提供REST API，收到中断信号后清理并退出
+func runServer(app *App, opts serverOptions) error {
+	port := opts.port
+	if port == 0 {
+		port = currentSettings().ServerPort
+	}
+	addr := net.JoinHostPort(opts.host, strconv.Itoa(port))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app.headless = true
+	app.startup(ctx)
+
+	if err := writeLocalToken(); err != nil {
+		slog.Error("保存本机API令牌失败", "error", err)
+	}
+	defer removeLocalToken()
+	if !authRequired(currentSettings()) {
+		// 本机浏览器打开带令牌的地址后使用会话Cookie
+		localURL := "http://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(port)) + "/?token=" + localAPIToken()
+		slog.Warn("未设置访问密码或API令牌，只允许本机访问", "addr", addr, "url", localURL)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           newRemoteHandler(app),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("REST API 服务已启动", "addr", addr, "websocket", wsPath)
+		errCh <- server.ListenAndServe()
+	}()
+
+	var serveErr error
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr = fmt.Errorf("REST API 服务异常退出: %w", err)
+		}
+	case <-ctx.Done():
+		slog.Info("收到退出信号，正在关闭 REST API 服务")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("关闭 REST API 服务失败", "error", err)
+	}
+	app.shutdown(shutdownCtx)
+	return serveErr
+}
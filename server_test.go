@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRemoteMethodsExist(t *testing.T) {
+	methods := apiMethods(&App{})
+	for name := range remoteMethods {
+		if _, ok := methods[name]; !ok {
+			t.Errorf("remoteMethods 中的 %s 不是 App 上返回 (string, error) 的方法", name)
+		}
+	}
+	for name := range remoteArgChecks {
+		if !remoteMethods[name] {
+			t.Errorf("%s 有远程参数检查，但不在 remoteMethods 中", name)
+		}
+	}
+}
+
+func TestDesktopOnlyMethodsNotExposed(t *testing.T) {
+	methods := apiMethods(&App{})
+	for _, name := range []string{"GetFileAccessToken", "AddTranscodeTaskWithParams", "SetDataDir", "PlayExternal", "OpenContainingFolder", "InstallUpdate", "ReloadPlugins", "SelectVideoFile"} {
+		if _, ok := methods[name]; ok {
+			t.Errorf("%s 不应通过REST API和WebSocket提供", name)
+		}
+	}
+}
+
+func TestRemoteSaveSettingsRejectsToolPaths(t *testing.T) {
+	setDataDir(t.TempDir())
+	previous := currentSettings()
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		settings = previous
+		settingsMu.Unlock()
+	})
+
+	methods := apiMethods(&App{})
+	for _, setting := range []string{"ffmpegPath", "torrentPath", "extractorPath", "toolsDir", "vlcPath", "mpvPath"} {
+		body, _ := json.Marshal([]string{`{"` + setting + `":"/tmp/evil"}`})
+		if _, err := methods["SaveSettings"].call(body); errorKey(err) != msgDesktopOnlySetting {
+			t.Errorf("远程修改 %s 返回 %v，应被拒绝", setting, err)
+		}
+	}
+	if got := currentSettings().FFmpegPath; got != previous.FFmpegPath {
+		t.Fatalf("被拒绝的设置已生效: ffmpegPath 为 %s", got)
+	}
+
+	// 其他设置和未改变的程序路径可以远程保存
+	unchanged, _ := json.Marshal(map[string]interface{}{"ffmpegPath": previous.FFmpegPath, "theme": "dark"})
+	if err := checkRemoteSettings(string(unchanged)); err != nil {
+		t.Fatalf("没有修改程序路径的设置被拒绝: %v", err)
+	}
+}
+
+func TestRemoteStartTranscodeRejectsFFmpegParams(t *testing.T) {
+	methods := apiMethods(&App{})
+	body, _ := json.Marshal([]string{`{"fileName":"movie.mkv","ffmpegParams":"-y /etc/passwd"}`})
+	if _, err := methods["StartTranscode"].call(body); errorKey(err) != msgDesktopOnlyFFmpegParams {
+		t.Fatalf("远程传入 FFmpeg 参数返回 %v，应被拒绝", err)
+	}
+	if err := checkRemoteTranscode(`{"fileName":"movie.mkv","ffmpegParams":""}`); err != nil {
+		t.Fatalf("没有 FFmpeg 参数的转码被拒绝: %v", err)
+	}
+}
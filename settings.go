@@ -0,0 +1,511 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// settingsFile 设置文件名，保存在数据目录中
+const settingsFile = "settings.json"
+
+// Settings holds user configurable options
+// Settings 用户可配置的选项，路径留空表示使用默认值，限速为0表示不限速
+type Settings struct {
+	// 下载目录，留空使用数据目录下的 downloads
+	DownloadDir string `json:"downloadDir"`
+	// 转码目录，留空使用数据目录下的 transcode
+	TranscodeDir string `json:"transcodeDir"`
+	// 外部工具目录，留空时在程序所在目录下的 tools 中查找 torrent 和 ffmpeg
+	ToolsDir string `json:"toolsDir"`
+	// torrent 命令路径，留空在工具目录和 PATH 中查找
+	TorrentPath string `json:"torrentPath"`
+	// ffmpeg 路径，留空在工具目录（包括 ffmpeg、ffmpeg/bin 子目录）和 PATH 中查找
+	FFmpegPath string `json:"ffmpegPath"`
+	// VLC 和 mpv 的路径，留空在工具目录、PATH 和常见安装位置中查找
+	VLCPath string `json:"vlcPath"`
+	MPVPath string `json:"mpvPath"`
+	// 默认的外部播放器：default（系统默认程序）, vlc, mpv
+	ExternalPlayer string `json:"externalPlayer"`
+	// 解压 RAR、7z 和分卷压缩包的程序（7z 或 unrar）路径，留空在工具目录、PATH 和 7-Zip 安装位置中查找
+	ExtractorPath string `json:"extractorPath"`
+	// 下载完成后自动解压其中的压缩包
+	AutoExtract bool `json:"autoExtract"`
+	// 在公共种子和磁力链接中追加公共 Tracker，改善 Tracker 失效的种子的连接；私有种子不追加
+	ExtraTrackers bool `json:"extraTrackers"`
+	// 公共 Tracker 列表的地址（每行一个 Tracker 的文本），每天更新一次；留空使用内置列表
+	ExtraTrackersURL string `json:"extraTrackersUrl"`
+	// 下载限速（KB/s），对新启动的下载生效
+	DownloadSpeedLimit int64 `json:"downloadSpeedLimit"`
+	// 上传限速（KB/s），对新启动的下载生效
+	UploadSpeedLimit int64 `json:"uploadSpeedLimit"`
+	// 本地文件服务（下载目录、转码目录和视频库的文件和播放地址）的总速度限制（KB/s），0表示不限速；调整速度后对正在传输的文件立即生效
+	FileServerSpeedLimit int64 `json:"fileServerSpeedLimit"`
+	// 同时进行的下载任务数
+	MaxConcurrentDownloads int `json:"maxConcurrentDownloads"`
+	// 启动时自动恢复上次关闭时正在下载的任务；关闭时这些任务改为暂停
+	ResumeInterruptedDownloads bool `json:"resumeInterruptedDownloads"`
+	// 同时进行的转码任务数
+	MaxConcurrentTranscodes int `json:"maxConcurrentTranscodes"`
+	// 上传待转码视频的大小上限（MB），0表示不限制
+	MaxUploadSizeMB int64 `json:"maxUploadSizeMB"`
+	// 下载或转码目录所在磁盘剩余空间低于此值（MB）时暂停下载和转码，空间恢复后自动继续；0表示不检查
+	MinFreeSpaceMB int64 `json:"minFreeSpaceMB"`
+	// 界面主题：dark, light
+	Theme string `json:"theme"`
+	// 界面和错误消息的语言：zh-Hans, en
+	Language string `json:"language"`
+	// 启动时打开的页面
+	StartPage string `json:"startPage"`
+	// 日志级别：debug, info, warn, error
+	LogLevel string `json:"logLevel"`
+	// 服务器模式（--server）、本机API（命令行使用）和局域网访问的监听端口
+	ServerPort int `json:"serverPort"`
+	// 桌面模式下开启局域网访问，可以在手机浏览器中查看和管理任务
+	RemoteAccess bool `json:"remoteAccess"`
+	// 远程访问的用户名，留空时接受任意用户名
+	RemoteUsername string `json:"remoteUsername"`
+	// 远程访问的密码；未设置密码和API令牌时只允许本机访问
+	RemotePassword string `json:"remotePassword"`
+	// 脚本和命令行使用的API令牌，通过 Authorization: Bearer 传入
+	APIToken string `json:"apiToken"`
+	// 网页登录会话的有效期（小时）
+	SessionHours int `json:"sessionHours"`
+	// 前端资源（脚本、样式、图片）和缩略图的浏览器缓存时间（小时），0表示每次使用前都向服务器验证
+	StaticCacheHours int `json:"staticCacheHours"`
+	// 任务完成或失败时显示系统通知
+	Notifications bool `json:"notifications"`
+	// 接收任务生命周期事件的 Webhook
+	Webhooks []Webhook `json:"webhooks"`
+	// Telegram 机器人令牌（从 @BotFather 获取），留空不启用
+	TelegramToken string `json:"telegramToken"`
+	// 允许使用机器人的聊天ID，任务完成和失败也会报告到这些聊天
+	TelegramChatIDs []int64 `json:"telegramChatIds"`
+	// 关闭窗口时最小化到托盘，任务在后台继续，通过托盘菜单退出
+	MinimizeToTray bool `json:"minimizeToTray"`
+	// 登录系统时自动启动（窗口最小化），未完成的下载随之继续
+	LaunchAtLogin bool `json:"launchAtLogin"`
+	// 每天自动检查新版本，发现新版本时显示通知
+	CheckUpdates bool `json:"checkUpdates"`
+	// 历史记录（已结束的任务）保留天数，0表示不按时间清理
+	HistoryRetentionDays int `json:"historyRetentionDays"`
+	// 最多保留的历史记录条数，0表示不限制
+	HistoryMaxEntries int `json:"historyMaxEntries"`
+	// 有下载或转码任务进行中时阻止系统自动休眠
+	PreventSleep bool `json:"preventSleep"`
+	// 视频库扫描子目录的最大层数，0表示不限制
+	LibraryMaxDepth int `json:"libraryMaxDepth"`
+	// 视频库忽略小于该大小（MB）的视频文件，例如种子中附带的预览片段，0表示不过滤
+	LibraryMinSizeMB int64 `json:"libraryMinSizeMB"`
+	// 额外的视频库文件夹（外接硬盘、NAS挂载目录等），与下载目录和转码目录一起显示在视频库中
+	LibraryFolders []LibraryFolder `json:"libraryFolders"`
+	// 视频库元数据的数据源：tmdb, tvdb，留空不刮削
+	MetadataProvider string `json:"metadataProvider"`
+	// TMDB 的 API Key（v3）或读取令牌（v4）
+	TMDBAPIKey string `json:"tmdbApiKey"`
+	// TVDB 的 API Key（v4）
+	TVDBAPIKey string `json:"tvdbApiKey"`
+	// OpenSubtitles 的 API Key，用于搜索和下载字幕
+	OpenSubtitlesAPIKey string `json:"openSubtitlesApiKey"`
+	// 搜索字幕的语言，逗号分隔的 OpenSubtitles 语言代码，例如 en,zh-cn，留空搜索所有语言
+	SubtitleLanguages string `json:"subtitleLanguages"`
+	// 整理视频的目标目录，留空时整理到下载目录中
+	OrganizeDir string `json:"organizeDir"`
+	// 整理方式：move（移动）或 hardlink（硬链接，原文件保留在下载目录中继续做种，需要在同一个磁盘上）
+	OrganizeMode string `json:"organizeMode"`
+	// 剧集和电影的整理模板，相对于整理目录，可用 {Title} {Year} {Season} {Episode} {EpisodeEnd} {Ext} {Name}，
+	// {Season:00} 表示补零到两位
+	OrganizeShowTemplate  string `json:"organizeShowTemplate"`
+	OrganizeMovieTemplate string `json:"organizeMovieTemplate"`
+	// 在局域网中作为 DLNA 媒体服务器提供视频库，电视和游戏机可以直接浏览和播放
+	DLNAEnabled bool `json:"dlnaEnabled"`
+	// DLNA 服务器在电视上显示的名称，留空使用 SeedParser (计算机名)
+	DLNAName string `json:"dlnaName"`
+	// DLNA 服务的端口，不能与API端口相同
+	DLNAPort int `json:"dlnaPort"`
+	// 使用电池供电时暂停转码（挂起 ffmpeg 进程），接通电源后自动恢复
+	BatteryPauseTranscodes bool `json:"batteryPauseTranscodes"`
+	// 使用电池供电时的下载限速（KB/s），0表示不改变
+	BatteryDownloadLimit int64 `json:"batteryDownloadLimit"`
+	// CPU 或显卡温度、系统负载超过上限时限制转码，降温后自动恢复
+	ThermalGovernor bool `json:"thermalGovernor"`
+	// 超过上限时的处理：pause 挂起转码进程，threads 减少之后启动的转码使用的线程数
+	ThermalAction string `json:"thermalAction"`
+	// CPU 和显卡温度上限（℃），0表示不检查；macOS 无法读取温度
+	MaxCPUTemperature int `json:"maxCpuTemperature"`
+	MaxGPUTemperature int `json:"maxGpuTemperature"`
+	// 整个系统的CPU使用率上限（%），0表示不检查
+	MaxSystemLoad int `json:"maxSystemLoad"`
+	// 停用的插件名称，插件默认启用
+	DisabledPlugins []string `json:"disabledPlugins"`
+}
+
+// defaultSettings 返回默认设置
+func defaultSettings() Settings {
+	return Settings{
+		MaxConcurrentDownloads:     1,
+		ResumeInterruptedDownloads: true,
+		MaxConcurrentTranscodes:    1,
+		MaxUploadSizeMB:            20 * 1024,
+		MinFreeSpaceMB:             1024,
+		Theme:                      "dark",
+		Language:                   defaultLocale,
+		StartPage:                  "dashboard",
+		LogLevel:                   "info",
+		ServerPort:                 8686,
+		SessionHours:               7 * 24,
+		StaticCacheHours:           24,
+		Notifications:              true,
+		CheckUpdates:               true,
+		PreventSleep:               true,
+		LibraryMaxDepth:            10,
+		OrganizeMode:               organizeMove,
+		OrganizeShowTemplate:       defaultOrganizeShowTemplate,
+		OrganizeMovieTemplate:      defaultOrganizeMovieTemplate,
+		DLNAPort:                   dlnaDefaultPort,
+		ThermalAction:              thermalActionPause,
+		MaxCPUTemperature:          90,
+		MaxGPUTemperature:          85,
+		SubtitleLanguages:          defaultSubtitleLanguages,
+		ExternalPlayer:             playerDefault,
+	}
+}
+
+var (
+	settingsMu sync.RWMutex
+	settings   = defaultSettings()
+)
+
+// currentSettings 返回当前设置的副本
+func currentSettings() Settings {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return settings
+}
+
+// validate 检查设置是否有效，并将路径转换为绝对路径
+func (s *Settings) validate() error {
+	if s.MaxConcurrentDownloads < 1 {
+		return errorf(msgInvalidConcurrentDownloads)
+	}
+	if s.MaxConcurrentTranscodes < 1 {
+		return errorf(msgInvalidConcurrentTranscodes)
+	}
+	if s.DownloadSpeedLimit < 0 || s.UploadSpeedLimit < 0 || s.BatteryDownloadLimit < 0 || s.FileServerSpeedLimit < 0 {
+		return errorf(msgInvalidSpeedLimit)
+	}
+	if s.Theme != "dark" && s.Theme != "light" {
+		return errorf(msgInvalidTheme, s.Theme)
+	}
+	if !validLocale(s.Language) {
+		return errorf(msgInvalidLanguage, s.Language)
+	}
+	if _, err := parseLogLevel(s.LogLevel); err != nil {
+		return err
+	}
+	if s.ServerPort < 1 || s.ServerPort > 65535 {
+		return errorf(msgInvalidPort, s.ServerPort)
+	}
+	if s.DLNAPort < 1 || s.DLNAPort > 65535 || s.DLNAPort == s.ServerPort {
+		return errorf(msgInvalidDLNAPort, s.DLNAPort)
+	}
+	if s.RemoteAccess && s.RemotePassword == "" {
+		return errorf(msgRemoteAccessNeedsPassword)
+	}
+	if s.SessionHours < 1 {
+		return errorf(msgInvalidSessionHours)
+	}
+	if s.StaticCacheHours < 0 {
+		return errorf(msgInvalidStaticCacheHours)
+	}
+	if s.MaxUploadSizeMB < 0 {
+		return errorf(msgInvalidMaxUploadSize)
+	}
+	if s.MinFreeSpaceMB < 0 {
+		return errorf(msgInvalidMinFreeSpace)
+	}
+	if s.ThermalAction != thermalActionPause && s.ThermalAction != thermalActionThreads {
+		return errorf(msgInvalidThermalAction, s.ThermalAction)
+	}
+	if s.MaxCPUTemperature < 0 || s.MaxGPUTemperature < 0 || s.MaxSystemLoad < 0 || s.MaxSystemLoad > 100 {
+		return errorf(msgInvalidThermalLimits)
+	}
+	if s.HistoryRetentionDays < 0 || s.HistoryMaxEntries < 0 {
+		return errorf(msgInvalidHistoryRetention)
+	}
+	if s.LibraryMaxDepth < 0 || s.LibraryMinSizeMB < 0 {
+		return errorf(msgInvalidLibraryScan)
+	}
+	for i := range s.Webhooks {
+		if err := s.Webhooks[i].validate(); err != nil {
+			return err
+		}
+	}
+	switch s.MetadataProvider {
+	case "":
+	case scraperTMDB, scraperTVDB:
+		key := s.TMDBAPIKey
+		if s.MetadataProvider == scraperTVDB {
+			key = s.TVDBAPIKey
+		}
+		if strings.TrimSpace(key) == "" {
+			return errorf(msgMetadataKeyRequired, strings.ToUpper(s.MetadataProvider))
+		}
+	default:
+		return errorf(msgInvalidMetadataProvider, s.MetadataProvider)
+	}
+	languages, err := normalizeSubtitleLanguages(s.SubtitleLanguages)
+	if err != nil {
+		return err
+	}
+	s.SubtitleLanguages = languages
+	s.OpenSubtitlesAPIKey = strings.TrimSpace(s.OpenSubtitlesAPIKey)
+	switch s.ExternalPlayer {
+	case playerDefault, playerVLC, playerMPV:
+	default:
+		return errorf(msgInvalidPlayer, s.ExternalPlayer)
+	}
+	s.ExtraTrackersURL = strings.TrimSpace(s.ExtraTrackersURL)
+	if s.ExtraTrackersURL != "" {
+		if u, err := url.Parse(s.ExtraTrackersURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errorf(msgInvalidTrackerListURL, s.ExtraTrackersURL)
+		}
+	}
+	if s.OrganizeMode != organizeMove && s.OrganizeMode != organizeHardlink {
+		return errorf(msgInvalidOrganizeMode, s.OrganizeMode)
+	}
+	for _, template := range []string{s.OrganizeShowTemplate, s.OrganizeMovieTemplate} {
+		if err := validateOrganizeTemplate(template); err != nil {
+			return err
+		}
+	}
+	folderIDs := make(map[string]bool)
+	folderPaths := make(map[string]bool)
+	for i := range s.LibraryFolders {
+		folder := &s.LibraryFolders[i]
+		if err := folder.validate(); err != nil {
+			return err
+		}
+		if folderIDs[folder.ID] || folderPaths[folder.Path] {
+			return errorf(msgDuplicateLibraryFolder, folder.Path)
+		}
+		folderIDs[folder.ID] = true
+		folderPaths[folder.Path] = true
+	}
+
+	for _, path := range []*string{&s.DownloadDir, &s.TranscodeDir, &s.ToolsDir, &s.TorrentPath, &s.FFmpegPath, &s.VLCPath, &s.MPVPath, &s.ExtractorPath, &s.OrganizeDir} {
+		*path = strings.TrimSpace(*path)
+		if *path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*path)
+		if err != nil {
+			return errorf(msgInvalidPath, *path, err)
+		}
+		*path = abs
+	}
+	if s.ToolsDir != "" {
+		if info, err := os.Stat(s.ToolsDir); err != nil || !info.IsDir() {
+			return errorf(msgToolsDirNotFound, s.ToolsDir)
+		}
+	}
+	for _, tool := range []string{s.TorrentPath, s.FFmpegPath, s.VLCPath, s.MPVPath, s.ExtractorPath} {
+		if tool == "" {
+			continue
+		}
+		if info, err := os.Stat(tool); err != nil || info.IsDir() {
+			return errorf(msgToolNotFound, tool)
+		}
+	}
+	return nil
+}
+
+// loadSettings loads the settings file from the data directory
+// loadSettings 从数据目录加载设置，文件不存在时使用默认设置；缺失的字段保留默认值
+func loadSettings() error {
+	loaded := defaultSettings()
+	data, err := readFileWithRecovery(dataPath(settingsFile), func(data []byte) error {
+		var s Settings
+		return json.Unmarshal(data, &s)
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取设置文件失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("解析设置文件失败: %w", err)
+	}
+	if err := loaded.validate(); err != nil {
+		return fmt.Errorf("设置文件无效: %w", err)
+	}
+
+	settingsMu.Lock()
+	settings = loaded
+	settingsMu.Unlock()
+	applyLogLevel(loaded)
+	return nil
+}
+
+// saveSettings 校验并保存设置，s 中的路径会被转换为绝对路径
+func saveSettings(s *Settings) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	for _, dir := range []string{s.DownloadDir, s.TranscodeDir} {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errorf(msgCreateDirFailed, err)
+		}
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(dataPath(settingsFile), data, 0644); err != nil {
+		return errorf(msgSaveSettingsFailed, err)
+	}
+
+	settingsMu.Lock()
+	settings = *s
+	settingsMu.Unlock()
+	applyLogLevel(*s)
+	return nil
+}
+
+// applyLogLevel 按设置修改日志级别，立即生效
+func applyLogLevel(s Settings) {
+	if level, err := parseLogLevel(s.LogLevel); err == nil {
+		logLevel.Set(level)
+	}
+}
+
+// GetSettings returns the current settings
+// GetSettings 获取当前设置
+func (a *App) GetSettings() (string, error) {
+	response := map[string]interface{}{
+		"status":    "success",
+		"settings":  currentSettings(),
+		"defaults":  defaultSettings(),
+		"languages": supportedLocales,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// desktopOnlySettings 指定本机运行的程序的设置，只能在桌面端修改：通过REST API和WebSocket修改后，
+// 能登录的局域网客户端就可以让程序运行任意命令
+var desktopOnlySettings = []struct {
+	name  string
+	value func(s Settings) string
+}{
+	{"toolsDir", func(s Settings) string { return s.ToolsDir }},
+	{"torrentPath", func(s Settings) string { return s.TorrentPath }},
+	{"ffmpegPath", func(s Settings) string { return s.FFmpegPath }},
+	{"vlcPath", func(s Settings) string { return s.VLCPath }},
+	{"mpvPath", func(s Settings) string { return s.MPVPath }},
+	{"extractorPath", func(s Settings) string { return s.ExtractorPath }},
+	{"disabledPlugins", func(s Settings) string { return strings.Join(s.DisabledPlugins, "\n") }},
+}
+
+// checkRemoteSettings 检查通过REST API或WebSocket保存的设置，desktopOnlySettings 中的设置与当前值不同时返回错误
+func checkRemoteSettings(settingsData string) error {
+	previous := currentSettings()
+	s := previous
+	if err := json.Unmarshal([]byte(settingsData), &s); err != nil {
+		return errorf(msgParseSettingsFailed, err)
+	}
+	for _, setting := range desktopOnlySettings {
+		if setting.value(s) != setting.value(previous) {
+			return errorf(msgDesktopOnlySetting, setting.name)
+		}
+	}
+	return nil
+}
+
+// SaveSettings validates, saves and applies new settings
+// SaveSettings 校验并保存设置，立即生效：目录和程序路径对之后的任务生效，
+// 并发数提高时立即启动等待中的任务，限速对新启动的下载生效，局域网访问、DLNA 服务和开机自动启动按设置开启或关闭
+func (a *App) SaveSettings(settingsData string) (string, error) {
+	previous := currentSettings()
+	s := previous
+	if err := json.Unmarshal([]byte(settingsData), &s); err != nil {
+		return "", errorf(msgParseSettingsFailed, err)
+	}
+	if err := saveSettings(&s); err != nil {
+		return "", err
+	}
+
+	// 修改了用户名或密码，已登录的网页需要重新登录
+	if s.RemoteUsername != previous.RemoteUsername || s.RemotePassword != previous.RemotePassword {
+		remoteSessions.clear()
+	}
+
+	// 并发数可能已提高，尝试启动等待中的任务
+	if a.tasks != nil {
+		a.startNextWaitingTask()
+		if err := a.startNextTranscodeTask(); err != nil {
+			slog.Error("启动等待的转码任务失败", "error", err)
+		}
+		// 保留策略可能已收紧，立即清理历史记录
+		if s.HistoryRetentionDays != previous.HistoryRetentionDays || s.HistoryMaxEntries != previous.HistoryMaxEntries {
+			a.pruneHistory()
+		}
+		a.updateSleepInhibit()
+		// 电池和温度相关设置变化时立即应用，重启下载可能需要几秒，在后台进行
+		thermalChanged := s.ThermalGovernor != previous.ThermalGovernor || s.ThermalAction != previous.ThermalAction ||
+			s.MaxCPUTemperature != previous.MaxCPUTemperature || s.MaxGPUTemperature != previous.MaxGPUTemperature ||
+			s.MaxSystemLoad != previous.MaxSystemLoad
+		if s.MinFreeSpaceMB != previous.MinFreeSpaceMB || s.DownloadDir != previous.DownloadDir || s.TranscodeDir != previous.TranscodeDir {
+			go a.checkDiskSpace()
+		}
+		if s.BatteryPauseTranscodes != previous.BatteryPauseTranscodes || s.BatteryDownloadLimit != previous.BatteryDownloadLimit || thermalChanged {
+			go func() {
+				if thermalChanged {
+					a.checkThermal()
+				}
+				a.applyPowerState()
+			}()
+		}
+	}
+	a.applyTelegram()
+	if s.LaunchAtLogin != previous.LaunchAtLogin {
+		if err := setAutostart(s.LaunchAtLogin); err != nil {
+			return "", errorf(msgAutostartFailed, err)
+		}
+	}
+	if !a.headless {
+		if err := a.applyRemoteAccess(); err != nil {
+			return "", errorf(msgRemoteAccessFailed, err)
+		}
+	}
+	if err := a.applyDLNA(); err != nil {
+		return "", errorf(msgDLNAFailed, err)
+	}
+	a.applyLibraryWatcher()
+
+	response := map[string]interface{}{
+		"status":   "success",
+		"message":  "Settings saved successfully",
+		"settings": s,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
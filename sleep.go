@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// sleepCheckInterval 检查是否有进行中的任务、是否需要阻止系统休眠的间隔
+const sleepCheckInterval = 15 * time.Second
+
+// sleepReason 阻止休眠时向系统说明的原因
+const sleepReason = "SeedParser 正在下载或转码"
+
+// sleepGuard 有下载或转码任务进行中时阻止系统自动休眠，队列空闲后恢复
+type sleepGuard struct {
+	mu sync.Mutex
+	// release 恢复系统休眠，非nil表示正在阻止休眠
+	release func()
+	// failed 当前系统不支持阻止休眠，本次运行不再尝试
+	failed bool
+	cancel context.CancelFunc
+}
+
+// activeTaskCount 返回正在下载和正在转码的任务数
+func (a *App) activeTaskCount() (downloading, transcoding int) {
+	if a.tasks == nil {
+		return 0, 0
+	}
+	for _, t := range a.tasks.Downloads() {
+		if t.Status == "downloading" {
+			downloading++
+		}
+	}
+	for _, t := range a.tasks.Transcodes() {
+		if t.Status == "transcoding" {
+			transcoding++
+		}
+	}
+	return downloading, transcoding
+}
+
+// updateSleepInhibit 按设置和当前任务阻止或恢复系统休眠
+func (a *App) updateSleepInhibit() {
+	downloading, transcoding := a.activeTaskCount()
+	want := currentSettings().PreventSleep && downloading+transcoding > 0
+
+	g := &a.sleep
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch {
+	case want && g.release == nil && !g.failed:
+		release, err := inhibitSleep(sleepReason)
+		if err != nil {
+			g.failed = true
+			slog.Warn("无法阻止系统休眠", "error", err)
+			return
+		}
+		g.release = release
+		slog.Info("有进行中的任务，已阻止系统休眠", "downloading", downloading, "transcoding", transcoding)
+	case !want && g.release != nil:
+		g.release()
+		g.release = nil
+		slog.Info("已恢复系统休眠")
+	}
+}
+
+// startSleepGuard 定期检查任务，有进行中的任务时阻止系统休眠
+func (a *App) startSleepGuard() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.sleep.cancel = cancel
+	a.updateSleepInhibit()
+	go func() {
+		ticker := time.NewTicker(sleepCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.updateSleepInhibit()
+			}
+		}
+	}()
+}
+
+// stopSleepGuard 停止检查并恢复系统休眠
+func (a *App) stopSleepGuard() {
+	g := &a.sleep
+	if g.cancel != nil {
+		g.cancel()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.release != nil {
+		g.release()
+		g.release = nil
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// inhibitSleep 运行 caffeinate 阻止系统空闲休眠，返回恢复函数。
+// -w 使 caffeinate 在程序意外退出时自行结束
+func inhibitSleep(reason string) (func(), error) {
+	cmd := exec.Command("caffeinate", "-i", "-w", strconv.Itoa(os.Getpid()))
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}, nil
+}
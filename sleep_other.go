@@ -0,0 +1,25 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// inhibitSleep 通过 systemd-inhibit 阻止系统空闲休眠和挂起，返回恢复函数。
+// 锁由子进程持有：tail --pid 在程序意外退出时自行结束，恢复时结束整个进程组
+func inhibitSleep(reason string) (func(), error) {
+	cmd := exec.Command("systemd-inhibit", "--what=idle:sleep", "--who=SeedParser", "--why="+reason, "--mode=block",
+		"tail", "--pid="+strconv.Itoa(os.Getpid()), "-f", "/dev/null")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return func() {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+		cmd.Wait()
+	}, nil
+}
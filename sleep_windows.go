@@ -0,0 +1,39 @@
+package main
+
+import (
+	goruntime "runtime"
+	"sync"
+)
+
+var procSetThreadExecutionState = kernel32.NewProc("SetThreadExecutionState")
+
+const (
+	esContinuous     = 0x80000000
+	esSystemRequired = 0x00000001
+)
+
+// inhibitSleep 通过 SetThreadExecutionState 阻止系统自动休眠（不影响关闭显示器），返回恢复函数。
+// 执行状态属于调用的线程，因此在锁定的线程上设置并保持到恢复
+func inhibitSleep(reason string) (func(), error) {
+	done := make(chan struct{})
+	result := make(chan error, 1)
+	go func() {
+		goruntime.LockOSThread()
+		defer goruntime.UnlockOSThread()
+		if r, _, err := procSetThreadExecutionState.Call(esContinuous | esSystemRequired); r == 0 {
+			result <- err
+			return
+		}
+		result <- nil
+		<-done
+		procSetThreadExecutionState.Call(esContinuous)
+	}()
+	if err := <-result; err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}, nil
+}
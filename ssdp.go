@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ssdpAddr SSDP 组播地址
+	ssdpAddr = "239.255.255.250:1900"
+	// ssdpMaxAge 宣告的有效期（秒）
+	ssdpMaxAge = 1800
+	// ssdpNotifyInterval 重新宣告的间隔，小于有效期的一半
+	ssdpNotifyInterval = 10 * time.Minute
+)
+
+// ssdpInterface 一个可以收发组播的网卡及其IPv4地址
+type ssdpInterface struct {
+	iface net.Interface
+	addrs []*net.IPNet
+}
+
+// ssdpInterfaces 返回已启用、支持组播的局域网网卡
+func ssdpInterfaces() []ssdpInterface {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		slog.Warn("获取网卡列表失败", "error", err)
+		return nil
+	}
+	var result []ssdpInterface
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		var ipv4 []*net.IPNet
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				ipv4 = append(ipv4, ipNet)
+			}
+		}
+		if len(ipv4) > 0 {
+			result = append(result, ssdpInterface{iface: iface, addrs: ipv4})
+		}
+	}
+	return result
+}
+
+// localIPFor 返回与对方在同一网段的本机地址，用于设备描述地址
+func (s ssdpInterface) localIPFor(remote net.IP) net.IP {
+	for _, addr := range s.addrs {
+		if addr.Contains(remote) {
+			return addr.IP
+		}
+	}
+	return s.addrs[0].IP
+}
+
+// ssdpTargets 宣告和响应搜索的类型，以及对应的 USN
+func ssdpTargets(uuid string) [][2]string {
+	udn := "uuid:" + uuid
+	return [][2]string{
+		{"upnp:rootdevice", udn + "::upnp:rootdevice"},
+		{udn, udn},
+		{dlnaDeviceType, udn + "::" + dlnaDeviceType},
+		{dlnaContentDirectory, udn + "::" + dlnaContentDirectory},
+		{dlnaConnectionManager, udn + "::" + dlnaConnectionManager},
+	}
+}
+
+// ssdpLocation 返回设备描述地址
+func ssdpLocation(ip net.IP, port int) string {
+	return "http://" + net.JoinHostPort(ip.String(), strconv.Itoa(port)) + dlnaDescriptionPath
+}
+
+// runSSDP 在所有网卡上响应 M-SEARCH 搜索并定期宣告，ctx 取消时发送 byebye 并退出
+func runSSDP(ctx context.Context, uuid string, port int) {
+	group, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		slog.Error("解析SSDP地址失败", "error", err)
+		return
+	}
+	interfaces := ssdpInterfaces()
+	if len(interfaces) == 0 {
+		slog.Warn("没有可用于DLNA的网卡")
+	}
+
+	var wg sync.WaitGroup
+	for _, iface := range interfaces {
+		conn, err := net.ListenMulticastUDP("udp4", &iface.iface, group)
+		if err != nil {
+			slog.Warn("监听SSDP失败", "interface", iface.iface.Name, "error", err)
+			continue
+		}
+		wg.Add(1)
+		go func(iface ssdpInterface) {
+			defer wg.Done()
+			serveSSDP(conn, iface, uuid, port)
+		}(iface)
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
+	}
+
+	notifySSDP(interfaces, group, uuid, port, "ssdp:alive")
+	ticker := time.NewTicker(ssdpNotifyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			notifySSDP(interfaces, group, uuid, port, "ssdp:alive")
+		case <-ctx.Done():
+			notifySSDP(interfaces, group, uuid, port, "ssdp:byebye")
+			wg.Wait()
+			return
+		}
+	}
+}
+
+// notifySSDP 从每个网卡的地址发送宣告
+func notifySSDP(interfaces []ssdpInterface, group *net.UDPAddr, uuid string, port int, nts string) {
+	for _, iface := range interfaces {
+		for _, addr := range iface.addrs {
+			conn, err := net.DialUDP("udp4", &net.UDPAddr{IP: addr.IP}, group)
+			if err != nil {
+				slog.Debug("发送SSDP宣告失败", "interface", iface.iface.Name, "error", err)
+				continue
+			}
+			for _, target := range ssdpTargets(uuid) {
+				var b strings.Builder
+				b.WriteString("NOTIFY * HTTP/1.1\r\n")
+				fmt.Fprintf(&b, "HOST: %s\r\n", ssdpAddr)
+				if nts == "ssdp:alive" {
+					fmt.Fprintf(&b, "CACHE-CONTROL: max-age=%d\r\n", ssdpMaxAge)
+					fmt.Fprintf(&b, "LOCATION: %s\r\n", ssdpLocation(addr.IP, port))
+					fmt.Fprintf(&b, "SERVER: %s\r\n", dlnaServerHeader())
+				}
+				fmt.Fprintf(&b, "NT: %s\r\nNTS: %s\r\nUSN: %s\r\n\r\n", target[0], nts, target[1])
+				conn.Write([]byte(b.String()))
+			}
+			conn.Close()
+		}
+	}
+}
+
+// serveSSDP 响应网卡上收到的 M-SEARCH 搜索，连接关闭时退出
+func serveSSDP(conn *net.UDPConn, iface ssdpInterface, uuid string, port int) {
+	buf := make([]byte, 2048)
+	for {
+		n, remote, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(buf[:n])))
+		if err != nil || req.Method != "M-SEARCH" || req.Header.Get("MAN") != `"ssdp:discover"` {
+			continue
+		}
+		st := req.Header.Get("ST")
+		for _, target := range ssdpTargets(uuid) {
+			if st != "ssdp:all" && st != target[0] {
+				continue
+			}
+			var b strings.Builder
+			b.WriteString("HTTP/1.1 200 OK\r\n")
+			fmt.Fprintf(&b, "CACHE-CONTROL: max-age=%d\r\n", ssdpMaxAge)
+			fmt.Fprintf(&b, "DATE: %s\r\n", time.Now().UTC().Format(http.TimeFormat))
+			b.WriteString("EXT:\r\n")
+			fmt.Fprintf(&b, "LOCATION: %s\r\n", ssdpLocation(iface.localIPFor(remote.IP), port))
+			fmt.Fprintf(&b, "SERVER: %s\r\n", dlnaServerHeader())
+			fmt.Fprintf(&b, "ST: %s\r\nUSN: %s\r\n\r\n", target[0], target[1])
+			if _, err := conn.WriteToUDP([]byte(b.String()), remote); err != nil {
+				slog.Debug("响应SSDP搜索失败", "remote", remote, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// statsFile 累计统计文件名，保存在数据目录中
+const statsFile = "stats.json"
+
+// TransferStats holds transfer and transcode totals
+// TransferStats 传输和转码的累计数据
+type TransferStats struct {
+	BytesDownloaded     int64   `json:"bytesDownloaded"`
+	BytesUploaded       int64   `json:"bytesUploaded"`
+	DownloadsCompleted  int     `json:"downloadsCompleted"`
+	TranscodesCompleted int     `json:"transcodesCompleted"`
+	TranscodesFailed    int     `json:"transcodesFailed"`
+	TranscodeSeconds    float64 `json:"transcodeSeconds"` // 转码耗时合计
+	GPUTranscodes       int     `json:"gpuTranscodes"`
+	CPUTranscodes       int     `json:"cpuTranscodes"`
+}
+
+// statsTracker 记录本次运行和累计的统计数据，累计数据在任务结束和程序关闭时写入数据目录
+type statsTracker struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	session   TransferStats
+	lifetime  TransferStats
+	dirty     bool
+	// durations 本次运行结束的转码任务耗时，用于 Prometheus 指标
+	durations histogram
+}
+
+func newStatsTracker() *statsTracker {
+	return &statsTracker{
+		startedAt: time.Now(),
+		durations: newHistogram(transcodeDurationBuckets),
+	}
+}
+
+// load 读取累计统计，文件不存在时从0开始
+func (s *statsTracker) load() {
+	data, err := readFileWithRecovery(dataPath(statsFile), func(data []byte) error {
+		var stats TransferStats
+		return json.Unmarshal(data, &stats)
+	})
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("读取统计数据失败", "error", err)
+		}
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := json.Unmarshal(data, &s.lifetime); err != nil {
+		slog.Error("解析统计数据失败", "error", err)
+	}
+}
+
+// save 写入累计统计，没有变化时跳过
+func (s *statsTracker) save() {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return
+	}
+	data, err := json.MarshalIndent(s.lifetime, "", "  ")
+	s.dirty = false
+	s.mu.Unlock()
+	if err != nil {
+		slog.Error("保存统计数据失败", "error", err)
+		return
+	}
+	if err := writeFileAtomic(dataPath(statsFile), data, 0644); err != nil {
+		slog.Error("保存统计数据失败", "error", err)
+	}
+}
+
+// update 同时修改本次运行和累计的统计
+func (s *statsTracker) update(change func(stats *TransferStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(&s.session)
+	change(&s.lifetime)
+	s.dirty = true
+}
+
+// addTransfer 记录新下载和上传的字节数
+func (s *statsTracker) addTransfer(downloaded, uploaded int64) {
+	if downloaded <= 0 && uploaded <= 0 {
+		return
+	}
+	s.update(func(stats *TransferStats) {
+		stats.BytesDownloaded += downloaded
+		stats.BytesUploaded += uploaded
+	})
+}
+
+// downloadFinished 记录完成的下载任务
+func (s *statsTracker) downloadFinished(task DownloadTask) {
+	if task.Status != "completed" {
+		return
+	}
+	s.update(func(stats *TransferStats) {
+		stats.DownloadsCompleted++
+	})
+	s.save()
+}
+
+// transcodeFinished 记录结束的转码任务：耗时以及使用GPU还是CPU
+func (s *statsTracker) transcodeFinished(task TranscodeTask) {
+	if task.Status != "completed" && task.Status != "failed" {
+		return
+	}
+	elapsed := task.EndTime.Sub(task.StartTime).Seconds()
+	if elapsed > 0 {
+		s.mu.Lock()
+		s.durations.observe(elapsed)
+		s.mu.Unlock()
+	}
+	s.update(func(stats *TransferStats) {
+		if task.Status == "completed" {
+			stats.TranscodesCompleted++
+		} else {
+			stats.TranscodesFailed++
+		}
+		if elapsed > 0 {
+			stats.TranscodeSeconds += elapsed
+		}
+		if task.UsedGPU {
+			stats.GPUTranscodes++
+		} else {
+			stats.CPUTranscodes++
+		}
+	})
+	s.save()
+}
+
+// snapshot 返回本次运行和累计统计的副本
+func (s *statsTracker) snapshot() (TransferStats, TransferStats, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session, s.lifetime, s.startedAt
+}
+
+// transcodeDurations 返回本次运行转码耗时直方图的副本
+func (s *statsTracker) transcodeDurations() histogram {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.durations.clone()
+}
+
+// GetGlobalStats returns session and lifetime totals plus current activity
+// GetGlobalStats 获取全局统计：本次运行和累计的传输量、转码耗时、GPU/CPU转码次数，以及当前的任务数和速度。
+// torrent 命令不输出连接的节点数，因此不提供节点统计
+func (a *App) GetGlobalStats() (string, error) {
+	session, lifetime, startedAt := a.stats.snapshot()
+
+	var downloading, waiting, paused, transcoding int
+	var speed int64
+	if a.tasks != nil {
+		for _, t := range a.tasks.Downloads() {
+			switch t.Status {
+			case "downloading":
+				downloading++
+				speed += t.Speed
+			case "waiting":
+				waiting++
+			case "paused":
+				paused++
+			}
+		}
+		for _, t := range a.tasks.Transcodes() {
+			if t.Status == "transcoding" {
+				transcoding++
+			}
+		}
+	}
+
+	response := map[string]interface{}{
+		"status":         "success",
+		"version":        appVersion,
+		"platform":       runtime.GOOS + "/" + runtime.GOARCH,
+		"session":        session,
+		"lifetime":       lifetime,
+		"sessionSeconds": int64(time.Since(startedAt).Seconds()),
+		"transcodeHours": map[string]float64{
+			"session":  session.TranscodeSeconds / 3600,
+			"lifetime": lifetime.TranscodeSeconds / 3600,
+		},
+		"current": map[string]interface{}{
+			"downloading":   downloading,
+			"waiting":       waiting,
+			"paused":        paused,
+			"transcoding":   transcoding,
+			"downloadSpeed": speed,
+		},
+	}
+	if a.taskLoadError != "" {
+		response["taskLoadError"] = a.taskLoadError
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
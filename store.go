@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// DownloadTask represents a torrent download task
+// DownloadTask 表示一个种子下载任务，JSON字段与前端使用的下载任务结构保持一致
+type DownloadTask struct {
+	TaskID        string   `json:"taskId"`
+	MagnetLink    string   `json:"magnetLink"`
+	Status        string   `json:"status"` // waiting, downloading, paused, completed, cancelled
+	TotalSize     int64    `json:"totalSize"`
+	Downloaded    int64    `json:"downloaded"`
+	SelectedFiles []string `json:"selectedFiles"`
+	FileName      string   `json:"fileName"`
+	StartTime     string   `json:"startTime"`
+	EndTime       string   `json:"endTime,omitempty"`
+	LastUpdate    string   `json:"lastUpdate,omitempty"`
+	OutputDir     string   `json:"outputDir"`
+	Speed         int64    `json:"speed"`
+	Percentage    float64  `json:"percentage"`
+	PID           int      `json:"pid,omitempty"`
+	PausedBy      string   `json:"pausedBy,omitempty"`     // 暂停原因：shutdown 表示程序关闭时暂停，disk 表示磁盘空间不足，all 表示全部暂停，用户暂停时为空
+	DeletedFiles  []string `json:"deletedFiles,omitempty"` // 已从视频库中删除的文件（绝对路径）
+	NameEncoding  string   `json:"nameEncoding,omitempty"` // 种子文件名的编码（gbk、shift_jis 等），下载完成后据此修正乱码的文件名
+	Priority      string   `json:"priority,omitempty"`     // 优先级：high、normal、low，为空时按 normal 处理
+	Note          string   `json:"note,omitempty"`         // 用户填写的备注，见 SetTaskNote
+	Label         string   `json:"label,omitempty"`        // 颜色标签：red、orange、yellow、green、blue、purple、gray
+}
+
+// TaskStore persists download and transcode tasks
+// TaskStore 任务持久化接口，Save* 为按任务的插入或更新，避免每次进度更新都重写全部数据
+type TaskStore interface {
+	// ListDownloads 按添加顺序返回所有下载任务
+	ListDownloads() ([]DownloadTask, error)
+	// GetDownload 获取指定下载任务，不存在时返回 false
+	GetDownload(taskID string) (DownloadTask, bool, error)
+	// SaveDownload 插入或更新下载任务
+	SaveDownload(task DownloadTask) error
+	// DeleteDownload 删除下载任务
+	DeleteDownload(taskID string) error
+
+	// ListTranscodes 按添加顺序返回所有转码任务
+	ListTranscodes() ([]TranscodeTask, error)
+	// GetTranscode 获取指定转码任务，不存在时返回 false
+	GetTranscode(taskID string) (TranscodeTask, bool, error)
+	// SaveTranscode 插入或更新转码任务
+	SaveTranscode(task TranscodeTask) error
+	// DeleteTranscode 删除转码任务
+	DeleteTranscode(taskID string) error
+
+	// Close 关闭存储
+	Close() error
+}
+
+// 旧版本使用的JSON进度文件，首次打开数据库时导入
+const (
+	legacyDownloadProgressFile  = "download_progress.json"
+	legacyTranscodeProgressFile = "transcode_progress.json"
+)
+
+// importLegacyProgressFiles imports tasks from the JSON progress files used by older releases
+// importLegacyProgressFiles 将旧版本JSON进度文件中的任务导入到存储中，导入成功后将文件重命名为 .migrated
+func importLegacyProgressFiles(store TaskStore, downloadFile string, transcodeFile string) error {
+	if data, err := os.ReadFile(downloadFile); err == nil {
+		var downloads []DownloadTask
+		if err := decodeTaskList(taskKindDownload, data, &downloads); err != nil {
+			return fmt.Errorf("解析旧版下载进度文件失败: %w", err)
+		}
+		for _, task := range downloads {
+			if err := store.SaveDownload(task); err != nil {
+				return fmt.Errorf("导入下载任务 %s 失败: %w", task.TaskID, err)
+			}
+		}
+		if err := os.Rename(downloadFile, downloadFile+".migrated"); err != nil {
+			return fmt.Errorf("重命名旧版下载进度文件失败: %w", err)
+		}
+		slog.Info("已导入旧版下载任务", "file", downloadFile, "count", len(downloads))
+	}
+
+	if data, err := os.ReadFile(transcodeFile); err == nil {
+		var transcodes []TranscodeTask
+		if err := decodeTaskList(taskKindTranscode, data, &transcodes); err != nil {
+			return fmt.Errorf("解析旧版转码进度文件失败: %w", err)
+		}
+		for _, task := range transcodes {
+			if err := store.SaveTranscode(task); err != nil {
+				return fmt.Errorf("导入转码任务 %s 失败: %w", task.TaskID, err)
+			}
+		}
+		if err := os.Rename(transcodeFile, transcodeFile+".migrated"); err != nil {
+			return fmt.Errorf("重命名旧版转码进度文件失败: %w", err)
+		}
+		slog.Info("已导入旧版转码任务", "file", transcodeFile, "count", len(transcodes))
+	}
+
+	return nil
+}
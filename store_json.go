@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// jsonTaskStore is a TaskStore backed by the JSON progress files
+// jsonTaskStore 基于JSON进度文件的任务存储，在SQLite不可用时作为后备；
+// 所有写入都是原子的，并保留上一个有效版本的 .bak 备份
+type jsonTaskStore struct {
+	mu            sync.Mutex
+	downloadFile  string
+	transcodeFile string
+}
+
+// newJSONTaskStore 创建JSON文件任务存储
+func newJSONTaskStore(downloadFile string, transcodeFile string) *jsonTaskStore {
+	return &jsonTaskStore{
+		downloadFile:  downloadFile,
+		transcodeFile: transcodeFile,
+	}
+}
+
+// readJSONList 读取任务文件并升级到当前版本，损坏时从备份恢复，文件不存在时返回空列表
+func readJSONList(kind string, path string, v interface{}) error {
+	data, err := readFileWithRecovery(path, func(data []byte) error {
+		return decodeTaskList(kind, data, v)
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return decodeTaskList(kind, data, v)
+}
+
+// writeJSONList 原子写入带版本号的任务文件
+func writeJSONList(path string, v interface{}) error {
+	data, err := encodeTaskList(v)
+	if err != nil {
+		return fmt.Errorf("生成进度信息失败: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("写入进度文件失败: %w", err)
+	}
+	return nil
+}
+
+func (s *jsonTaskStore) loadDownloads() ([]DownloadTask, error) {
+	tasks := []DownloadTask{}
+	if err := readJSONList(taskKindDownload, s.downloadFile, &tasks); err != nil {
+		return nil, fmt.Errorf("读取下载进度文件失败: %w", err)
+	}
+	return tasks, nil
+}
+
+func (s *jsonTaskStore) loadTranscodes() ([]TranscodeTask, error) {
+	tasks := []TranscodeTask{}
+	if err := readJSONList(taskKindTranscode, s.transcodeFile, &tasks); err != nil {
+		return nil, fmt.Errorf("读取转码进度文件失败: %w", err)
+	}
+	return tasks, nil
+}
+
+// ListDownloads 按添加顺序返回所有下载任务
+func (s *jsonTaskStore) ListDownloads() ([]DownloadTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadDownloads()
+}
+
+// GetDownload 获取指定下载任务
+func (s *jsonTaskStore) GetDownload(taskID string) (DownloadTask, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.loadDownloads()
+	if err != nil {
+		return DownloadTask{}, false, err
+	}
+	for _, task := range tasks {
+		if task.TaskID == taskID {
+			return task, true, nil
+		}
+	}
+	return DownloadTask{}, false, nil
+}
+
+// SaveDownload 插入或更新下载任务
+func (s *jsonTaskStore) SaveDownload(task DownloadTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.loadDownloads()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range tasks {
+		if tasks[i].TaskID == task.TaskID {
+			tasks[i] = task
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		tasks = append(tasks, task)
+	}
+	return writeJSONList(s.downloadFile, tasks)
+}
+
+// DeleteDownload 删除下载任务
+func (s *jsonTaskStore) DeleteDownload(taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.loadDownloads()
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		if tasks[i].TaskID == taskID {
+			tasks = append(tasks[:i], tasks[i+1:]...)
+			return writeJSONList(s.downloadFile, tasks)
+		}
+	}
+	return nil
+}
+
+// ListTranscodes 按添加顺序返回所有转码任务
+func (s *jsonTaskStore) ListTranscodes() ([]TranscodeTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadTranscodes()
+}
+
+// GetTranscode 获取指定转码任务
+func (s *jsonTaskStore) GetTranscode(taskID string) (TranscodeTask, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.loadTranscodes()
+	if err != nil {
+		return TranscodeTask{}, false, err
+	}
+	for _, task := range tasks {
+		if task.TaskID == taskID {
+			return task, true, nil
+		}
+	}
+	return TranscodeTask{}, false, nil
+}
+
+// SaveTranscode 插入或更新转码任务
+func (s *jsonTaskStore) SaveTranscode(task TranscodeTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.loadTranscodes()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range tasks {
+		if tasks[i].TaskID == task.TaskID {
+			tasks[i] = task
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		tasks = append(tasks, task)
+	}
+	return writeJSONList(s.transcodeFile, tasks)
+}
+
+// DeleteTranscode 删除转码任务
+func (s *jsonTaskStore) DeleteTranscode(taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks, err := s.loadTranscodes()
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		if tasks[i].TaskID == taskID {
+			tasks = append(tasks[:i], tasks[i+1:]...)
+			return writeJSONList(s.transcodeFile, tasks)
+		}
+	}
+	return nil
+}
+
+// Close JSON文件存储无需释放资源
+func (s *jsonTaskStore) Close() error {
+	return nil
+}
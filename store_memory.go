@@ -0,0 +1,114 @@
+package main
+
+import "sync"
+
+// memoryTaskStore is a TaskStore that keeps tasks in memory only
+// memoryTaskStore 只保存在内存中的任务存储，在数据库和JSON进度文件都无法读取时使用：
+// 程序可以继续添加和管理任务，但退出后不会保留，也不会覆盖无法读取的原有数据
+type memoryTaskStore struct {
+	mu         sync.Mutex
+	downloads  []DownloadTask
+	transcodes []TranscodeTask
+}
+
+// newMemoryTaskStore 创建空的内存任务存储
+func newMemoryTaskStore() *memoryTaskStore {
+	return &memoryTaskStore{}
+}
+
+// ListDownloads 按添加顺序返回所有下载任务
+func (s *memoryTaskStore) ListDownloads() ([]DownloadTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]DownloadTask{}, s.downloads...), nil
+}
+
+// GetDownload 获取指定下载任务
+func (s *memoryTaskStore) GetDownload(taskID string) (DownloadTask, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, task := range s.downloads {
+		if task.TaskID == taskID {
+			return task, true, nil
+		}
+	}
+	return DownloadTask{}, false, nil
+}
+
+// SaveDownload 插入或更新下载任务
+func (s *memoryTaskStore) SaveDownload(task DownloadTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.downloads {
+		if s.downloads[i].TaskID == task.TaskID {
+			s.downloads[i] = task
+			return nil
+		}
+	}
+	s.downloads = append(s.downloads, task)
+	return nil
+}
+
+// DeleteDownload 删除下载任务
+func (s *memoryTaskStore) DeleteDownload(taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.downloads {
+		if s.downloads[i].TaskID == taskID {
+			s.downloads = append(s.downloads[:i], s.downloads[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// ListTranscodes 按添加顺序返回所有转码任务
+func (s *memoryTaskStore) ListTranscodes() ([]TranscodeTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]TranscodeTask{}, s.transcodes...), nil
+}
+
+// GetTranscode 获取指定转码任务
+func (s *memoryTaskStore) GetTranscode(taskID string) (TranscodeTask, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, task := range s.transcodes {
+		if task.TaskID == taskID {
+			return task, true, nil
+		}
+	}
+	return TranscodeTask{}, false, nil
+}
+
+// SaveTranscode 插入或更新转码任务
+func (s *memoryTaskStore) SaveTranscode(task TranscodeTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.transcodes {
+		if s.transcodes[i].TaskID == task.TaskID {
+			s.transcodes[i] = task
+			return nil
+		}
+	}
+	s.transcodes = append(s.transcodes, task)
+	return nil
+}
+
+// DeleteTranscode 删除转码任务
+func (s *memoryTaskStore) DeleteTranscode(taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.transcodes {
+		if s.transcodes[i].TaskID == taskID {
+			s.transcodes = append(s.transcodes[:i], s.transcodes[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// Close 关闭存储
+func (s *memoryTaskStore) Close() error {
+	return nil
+}
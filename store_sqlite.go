@@ -0,0 +1,227 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// taskDatabaseFile 任务数据库文件名
+const taskDatabaseFile = "seedparser.db"
+
+// sqliteMigrations 按顺序执行的数据库迁移，下标+1即为迁移后的 user_version
+var sqliteMigrations = []string{
+	// 1: 初始表结构，任务内容以JSON保存，常用查询字段单独成列
+	`CREATE TABLE IF NOT EXISTS downloads (
+		task_id    TEXT PRIMARY KEY,
+		status     TEXT NOT NULL,
+		start_time TEXT NOT NULL DEFAULT '',
+		data       TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_downloads_status ON downloads(status);
+	CREATE TABLE IF NOT EXISTS transcodes (
+		task_id    TEXT PRIMARY KEY,
+		status     TEXT NOT NULL,
+		start_time TEXT NOT NULL DEFAULT '',
+		data       TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_transcodes_status ON transcodes(status);`,
+	// 2: 记录每行任务数据的结构版本，读取旧版本数据时按 taskRecordMigrations 升级
+	`ALTER TABLE downloads ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE transcodes ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 1;`,
+}
+
+// sqliteTaskStore is a TaskStore backed by an embedded SQLite database
+// sqliteTaskStore 基于嵌入式SQLite的任务存储
+type sqliteTaskStore struct {
+	db *sql.DB
+}
+
+// openSQLiteTaskStore opens (creating if needed) the task database and applies pending migrations
+// openSQLiteTaskStore 打开任务数据库（不存在时创建）并执行未完成的迁移
+func openSQLiteTaskStore(path string) (*sqliteTaskStore, error) {
+	dsn := "file:" + url.PathEscape(path) + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开任务数据库失败: %w", err)
+	}
+	// SQLite同一时间只允许一个写入者，使用单连接避免 SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	store := &sqliteTaskStore{db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// migrate 根据 PRAGMA user_version 执行未完成的迁移
+func (s *sqliteTaskStore) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("读取数据库版本失败: %w", err)
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("开始数据库迁移失败: %w", err)
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("执行数据库迁移 %d 失败: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("更新数据库版本失败: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("提交数据库迁移 %d 失败: %w", i+1, err)
+		}
+		slog.Info("数据库已迁移到版本", "version", i+1)
+	}
+	return nil
+}
+
+// ListDownloads 按添加顺序返回所有下载任务
+func (s *sqliteTaskStore) ListDownloads() ([]DownloadTask, error) {
+	rows, err := s.db.Query("SELECT schema_version, data FROM downloads ORDER BY rowid")
+	if err != nil {
+		return nil, fmt.Errorf("查询下载任务失败: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []DownloadTask{}
+	for rows.Next() {
+		var version int
+		var data string
+		if err := rows.Scan(&version, &data); err != nil {
+			return nil, fmt.Errorf("读取下载任务失败: %w", err)
+		}
+		var task DownloadTask
+		if err := decodeTaskRecord(taskKindDownload, version, []byte(data), &task); err != nil {
+			return nil, fmt.Errorf("解析下载任务失败: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// GetDownload 获取指定下载任务
+func (s *sqliteTaskStore) GetDownload(taskID string) (DownloadTask, bool, error) {
+	var task DownloadTask
+	var version int
+	var data string
+	err := s.db.QueryRow("SELECT schema_version, data FROM downloads WHERE task_id = ?", taskID).Scan(&version, &data)
+	if err == sql.ErrNoRows {
+		return task, false, nil
+	}
+	if err != nil {
+		return task, false, fmt.Errorf("查询下载任务失败: %w", err)
+	}
+	if err := decodeTaskRecord(taskKindDownload, version, []byte(data), &task); err != nil {
+		return task, false, fmt.Errorf("解析下载任务失败: %w", err)
+	}
+	return task, true, nil
+}
+
+// SaveDownload 插入或更新下载任务
+func (s *sqliteTaskStore) SaveDownload(task DownloadTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("生成下载任务数据失败: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT INTO downloads (task_id, status, start_time, schema_version, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(task_id) DO UPDATE SET status = excluded.status, start_time = excluded.start_time,
+			schema_version = excluded.schema_version, data = excluded.data`,
+		task.TaskID, task.Status, task.StartTime, taskSchemaVersion, string(data))
+	if err != nil {
+		return fmt.Errorf("保存下载任务失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteDownload 删除下载任务
+func (s *sqliteTaskStore) DeleteDownload(taskID string) error {
+	if _, err := s.db.Exec("DELETE FROM downloads WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("删除下载任务失败: %w", err)
+	}
+	return nil
+}
+
+// ListTranscodes 按添加顺序返回所有转码任务
+func (s *sqliteTaskStore) ListTranscodes() ([]TranscodeTask, error) {
+	rows, err := s.db.Query("SELECT schema_version, data FROM transcodes ORDER BY rowid")
+	if err != nil {
+		return nil, fmt.Errorf("查询转码任务失败: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []TranscodeTask{}
+	for rows.Next() {
+		var version int
+		var data string
+		if err := rows.Scan(&version, &data); err != nil {
+			return nil, fmt.Errorf("读取转码任务失败: %w", err)
+		}
+		var task TranscodeTask
+		if err := decodeTaskRecord(taskKindTranscode, version, []byte(data), &task); err != nil {
+			return nil, fmt.Errorf("解析转码任务失败: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// GetTranscode 获取指定转码任务
+func (s *sqliteTaskStore) GetTranscode(taskID string) (TranscodeTask, bool, error) {
+	var task TranscodeTask
+	var version int
+	var data string
+	err := s.db.QueryRow("SELECT schema_version, data FROM transcodes WHERE task_id = ?", taskID).Scan(&version, &data)
+	if err == sql.ErrNoRows {
+		return task, false, nil
+	}
+	if err != nil {
+		return task, false, fmt.Errorf("查询转码任务失败: %w", err)
+	}
+	if err := decodeTaskRecord(taskKindTranscode, version, []byte(data), &task); err != nil {
+		return task, false, fmt.Errorf("解析转码任务失败: %w", err)
+	}
+	return task, true, nil
+}
+
+// SaveTranscode 插入或更新转码任务
+func (s *sqliteTaskStore) SaveTranscode(task TranscodeTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("生成转码任务数据失败: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT INTO transcodes (task_id, status, start_time, schema_version, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(task_id) DO UPDATE SET status = excluded.status, start_time = excluded.start_time,
+			schema_version = excluded.schema_version, data = excluded.data`,
+		task.TaskID, task.Status, task.StartTime.Format(time.RFC3339), taskSchemaVersion, string(data))
+	if err != nil {
+		return fmt.Errorf("保存转码任务失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteTranscode 删除转码任务
+func (s *sqliteTaskStore) DeleteTranscode(taskID string) error {
+	if _, err := s.db.Exec("DELETE FROM transcodes WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("删除转码任务失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭数据库
+func (s *sqliteTaskStore) Close() error {
+	return s.db.Close()
+}
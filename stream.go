@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// streamURLPrefix 视频库文件的播放地址前缀，完整地址为 /stream/<ID>/<文件名>，文件名部分可以省略，
+// 只用于外部播放器从地址中识别格式
+const streamURLPrefix = "/stream/"
+
+// videoContentTypes 视频的 Content-Type，部分扩展名不在系统的类型表中，按扩展名推断会得到错误的类型
+var videoContentTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/x-m4v",
+	".mkv":  "video/x-matroska",
+	".webm": "video/webm",
+	".mov":  "video/quicktime",
+	".avi":  "video/x-msvideo",
+	".wmv":  "video/x-ms-wmv",
+	".flv":  "video/x-flv",
+	".ts":   "video/mp2t",
+}
+
+// subtitleContentTypes 字幕的 Content-Type，系统的类型表通常没有这些扩展名，按内容推断会得到 text/plain 或 application/octet-stream
+var subtitleContentTypes = map[string]string{
+	".vtt": "text/vtt; charset=utf-8",
+	".srt": "application/x-subrip; charset=utf-8",
+	".ass": "text/x-ssa; charset=utf-8",
+	".ssa": "text/x-ssa; charset=utf-8",
+}
+
+// mediaContentType 返回视频和字幕的 Content-Type，其他文件返回空字符串，由 ServeContent 按扩展名或内容推断
+func mediaContentType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if contentType, ok := videoContentTypes[ext]; ok {
+		return contentType
+	}
+	return subtitleContentTypes[ext]
+}
+
+// streamID 返回视频的播放ID：根目录名和相对路径的 base64url 编码，不依赖扫描结果，重启后仍然有效
+func streamID(root, relPath string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(root + "/" + relPath))
+}
+
+// streamURL 返回视频的播放地址，包含文件访问令牌
+func streamURL(root, relPath string) string {
+	return withFileToken(streamURLPrefix + streamID(root, relPath) + "/" + url.PathEscape(filepath.Base(filepath.FromSlash(relPath))))
+}
+
+// libraryRootByName 按名称查找视频库根目录：下载目录、转码目录或已启用的视频库文件夹
+func libraryRootByName(name string) (libraryRoot, bool) {
+	for _, root := range libraryRoots() {
+		if root.name == name {
+			return root, true
+		}
+	}
+	if folder, ok := libraryFolder(name); ok && folder.Enabled {
+		return folder.root(), true
+	}
+	return libraryRoot{}, false
+}
+
+// resolveStream 将播放ID转换为文件路径，只允许访问视频库根目录内的视频文件
+func resolveStream(id string) (string, bool) {
+	decoded, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return "", false
+	}
+	name, relPath, ok := strings.Cut(string(decoded), "/")
+	if !ok || !videoExtensions[strings.ToLower(filepath.Ext(relPath))] {
+		return "", false
+	}
+	root, ok := libraryRootByName(name)
+	if !ok {
+		return "", false
+	}
+	path, err := resolveServedPath(root.dir, relPath)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// serveStream 提供视频库中的视频，支持 Range 请求，播放器可以直接跳转到任意位置而不需要下载整个文件
+func serveStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, errorf(msgUseGet, r.URL.Path))
+		return
+	}
+	if !requireFileToken(w, r) {
+		return
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, streamURLPrefix), "/")
+	path, ok := resolveStream(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	serveMediaFile(w, r, path)
+}
+
+// serveMediaFile 提供文件内容：按扩展名设置视频和字幕的 Content-Type，声明 Accept-Ranges，
+// 由 ServeContent 处理 Range、If-Range 和 If-Modified-Since，播放器可以跳转到任意位置，大文件可以分段获取
+func serveMediaFile(w http.ResponseWriter, r *http.Request, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	if contentType := mediaContentType(path); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	// ETag 和 Last-Modified 让播放器重新请求时可以得到 304，Range 请求可以用 If-Range 确认文件没有变化
+	w.Header().Set("ETag", fileETag(info))
+	// 返回 206 部分内容，Range 无效时返回 416；设置了本地文件服务限速时按速度发送
+	http.ServeContent(throttleResponse(w, r), r, info.Name(), info.ModTime(), file)
+}
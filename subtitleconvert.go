@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// subtitleConvertTimeout 提取或转换一个字幕的超时，从 MKV 中提取需要读取整个文件
+const subtitleConvertTimeout = 10 * time.Minute
+
+// subtitleFormat 可以输出的字幕格式对应的 ffmpeg 编码器和封装格式
+type subtitleFormat struct {
+	codec string
+	muxer string
+}
+
+// subtitleFormats 提取和转换字幕支持的输出格式，内置播放器只能显示 VTT
+var subtitleFormats = map[string]subtitleFormat{
+	"srt": {codec: "srt", muxer: "srt"},
+	"vtt": {codec: "webvtt", muxer: "webvtt"},
+	"ass": {codec: "ass", muxer: "ass"},
+	"ssa": {codec: "ssa", muxer: "ass"},
+}
+
+// textSubtitleCodecs 文本字幕的编码，PGS、VobSub 等图片字幕需要 OCR，不能转换为文本格式
+var textSubtitleCodecs = map[string]bool{
+	"subrip":   true,
+	"srt":      true,
+	"ass":      true,
+	"ssa":      true,
+	"webvtt":   true,
+	"mov_text": true,
+	"text":     true,
+	"microdvd": true,
+}
+
+// SubtitleTrack is a subtitle stream embedded in a video
+// SubtitleTrack 视频中内嵌的字幕轨道
+type SubtitleTrack struct {
+	// 在字幕轨道中的序号（从0开始），提取时使用
+	Index int    `json:"index"`
+	Codec string `json:"codec"`
+	// 轨道的语言标签，通常是 ISO 639-2 代码，例如 chi、eng
+	Language string `json:"language,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Default  bool   `json:"default"`
+	Forced   bool   `json:"forced"`
+	// 是否为文本字幕，图片字幕不能提取为文本格式
+	Text bool `json:"text"`
+}
+
+// probeSubtitleTracks 使用 ffprobe 读取视频中的字幕轨道
+func probeSubtitleTracks(ffprobePath, path string) ([]SubtitleTrack, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, ffprobePath, "-v", "error", "-print_format", "json", "-show_streams", "-select_streams", "s", path)
+	hideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, err
+	}
+	tracks := []SubtitleTrack{}
+	for i, stream := range probe.Streams {
+		tracks = append(tracks, SubtitleTrack{
+			Index:    i,
+			Codec:    stream.CodecName,
+			Language: stream.Tags.Language,
+			Title:    stream.Tags.Title,
+			Default:  stream.Disposition.Default != 0,
+			Forced:   stream.Disposition.Forced != 0,
+			Text:     textSubtitleCodecs[stream.CodecName],
+		})
+	}
+	return tracks, nil
+}
+
+// runSubtitleFFmpeg 使用 ffmpeg 输出字幕到 target：先写入临时文件，成功后重命名，失败时不留下不完整的文件
+func runSubtitleFFmpeg(target string, format subtitleFormat, input ...string) error {
+	ffmpegPath, err := ffmpegToolPath()
+	if err != nil {
+		return err
+	}
+	tmp := target + ".part"
+	defer os.Remove(tmp)
+
+	ctx, cancel := context.WithTimeout(context.Background(), subtitleConvertTimeout)
+	defer cancel()
+	args := append([]string{"-v", "error", "-nostdin", "-y"}, input...)
+	args = append(args, "-c:s", format.codec, "-f", format.muxer, tmp)
+	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+	hideWindow(cmd)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return errors.New(strings.TrimSpace(string(out)))
+		}
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+// subtitleCue 一条字幕：开始、结束时间和各行文字
+type subtitleCue struct {
+	start time.Duration
+	end   time.Duration
+	lines []string
+}
+
+// assOverrideTags ASS 的覆盖标签，例如 {\an8}、{\i1}、{\pos(320,50)}
+var assOverrideTags = regexp.MustCompile(`\{[^}]*\}`)
+
+// parseSubtitleTime 解析 SRT、VTT 和 ASS 的时间：[h:]mm:ss[.,]小数，ASS 的小数为百分之一秒；
+// 小数精确到 0.1 毫秒后四舍五入到毫秒
+func parseSubtitleTime(value string) (time.Duration, bool) {
+	clock, frac, _ := strings.Cut(strings.Replace(strings.TrimSpace(value), ",", ".", 1), ".")
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 || strings.Trim(frac, "0123456789") != "" {
+		return 0, false
+	}
+	seconds := 0
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || strings.HasPrefix(part, "+") {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	tenths, _ := strconv.Atoi((frac + "0000")[:4])
+	return time.Duration(seconds)*time.Second + time.Duration((tenths+5)/10)*time.Millisecond, true
+}
+
+// formatSubtitleTime 输出 hh:mm:ss,mmm（SRT）或 hh:mm:ss.mmm（VTT）格式的时间
+func formatSubtitleTime(d time.Duration, sep byte) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// cueLines 去掉空行：SRT 和 VTT 中的空行表示一条字幕结束
+func cueLines(lines []string) []string {
+	var kept []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+// parseTimedCues 解析 SRT 或 VTT：按空行分段，包含 --> 的行为时间，之后的行为文字；
+// 时间之前的序号或标识、VTT 的文件头、NOTE 和 STYLE 段落被忽略
+func parseTimedCues(text string) []subtitleCue {
+	var cues []subtitleCue
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		for i, line := range lines {
+			start, end, ok := strings.Cut(line, "-->")
+			if !ok {
+				continue
+			}
+			// VTT 的时间后面可以有位置等设置，例如 00:01.000 --> 00:02.000 align:start
+			fields := strings.Fields(end)
+			if len(fields) == 0 {
+				break
+			}
+			startTime, ok1 := parseSubtitleTime(start)
+			endTime, ok2 := parseSubtitleTime(fields[0])
+			if text := cueLines(lines[i+1:]); ok1 && ok2 && len(text) > 0 {
+				cues = append(cues, subtitleCue{start: startTime, end: endTime, lines: text})
+			}
+			break
+		}
+	}
+	return cues
+}
+
+// parseASSCues 解析 ASS 或 SSA 的 [Events] 段落中的 Dialogue，按 Format 行确定字段的位置，
+// 去掉覆盖标签，\N 换行，\h 换成空格，按开始时间排序
+func parseASSCues(text string) []subtitleCue {
+	var cues []subtitleCue
+	format := []string{"layer", "start", "end", "style", "name", "marginl", "marginr", "marginv", "effect", "text"}
+	events := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			events = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !events || !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Format":
+			format = strings.Split(strings.ToLower(strings.ReplaceAll(value, " ", "")), ",")
+		case "Dialogue":
+			// 文字是最后一个字段，其中可以包含逗号
+			fields := strings.SplitN(value, ",", len(format))
+			if len(fields) != len(format) {
+				continue
+			}
+			var cue subtitleCue
+			startOK, endOK := false, false
+			for i, name := range format {
+				switch name {
+				case "start":
+					cue.start, startOK = parseSubtitleTime(fields[i])
+				case "end":
+					cue.end, endOK = parseSubtitleTime(fields[i])
+				case "text":
+					dialogue := assOverrideTags.ReplaceAllString(fields[i], "")
+					dialogue = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(dialogue)
+					cue.lines = cueLines(strings.Split(dialogue, "\n"))
+				}
+			}
+			if startOK && endOK && len(cue.lines) > 0 {
+				cues = append(cues, cue)
+			}
+		}
+	}
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].start < cues[j].start })
+	return cues
+}
+
+// convertSubtitleText 将 srt、vtt、ass、ssa 格式的字幕转换为 srt 或 vtt，去掉 UTF-8 BOM，
+// 换行统一为 \n；不支持的格式或没有解析到字幕时返回 false
+func convertSubtitleText(text, from, to string) (string, bool) {
+	text = strings.TrimPrefix(text, "\ufeff")
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	var cues []subtitleCue
+	switch from {
+	case "srt", "vtt":
+		cues = parseTimedCues(text)
+	case "ass", "ssa":
+		cues = parseASSCues(text)
+	}
+	if len(cues) == 0 || (to != "srt" && to != "vtt") {
+		return "", false
+	}
+
+	var b strings.Builder
+	sep := byte(',')
+	if to == "vtt" {
+		b.WriteString("WEBVTT\n\n")
+		sep = '.'
+	}
+	for i, cue := range cues {
+		if to == "srt" {
+			fmt.Fprintf(&b, "%d\n", i+1)
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", formatSubtitleTime(cue.start, sep), formatSubtitleTime(cue.end, sep), strings.Join(cue.lines, "\n"))
+	}
+	return b.String(), true
+}
+
+// convertSubtitleFile 转换字幕文件：UTF-8 编码的 srt、vtt、ass、ssa 转换为 srt 或 vtt 时直接在程序中转换，
+// 不需要 ffmpeg；其他格式、编码和转换为 ass、ssa 时使用 ffmpeg
+func convertSubtitleFile(source, from, target, to string) error {
+	if data, err := os.ReadFile(source); err == nil && utf8.Valid(data) {
+		if text, ok := convertSubtitleText(string(data), from, to); ok {
+			return writeFileAtomic(target, []byte(text), 0644)
+		}
+	}
+	return runSubtitleFFmpeg(target, subtitleFormats[to], "-i", source)
+}
+
+// findLibrarySubtitle 在视频库中查找字幕文件及其所属的视频
+func (a *App) findLibrarySubtitle(path string) (LibraryVideo, LibrarySubtitle, error) {
+	videos, _, err := a.libraryVideos()
+	if err != nil {
+		return LibraryVideo{}, LibrarySubtitle{}, err
+	}
+	path = filepath.Clean(path)
+	for _, video := range videos {
+		for _, subtitle := range video.Subtitles {
+			if subtitle.Path == path {
+				return video, subtitle, nil
+			}
+		}
+	}
+	return LibraryVideo{}, LibrarySubtitle{}, errorf(msgFileNotFound, path)
+}
+
+// GetSubtitleTracks gets the subtitle tracks embedded in a library video
+// GetSubtitleTracks 获取视频库中视频内嵌的字幕轨道，text 为 false 的图片字幕（PGS、VobSub）不能提取
+func (a *App) GetSubtitleTracks(path string) (string, error) {
+	video, err := a.findLibraryVideo(path)
+	if err != nil {
+		return "", err
+	}
+	ffprobePath, err := ffprobeToolPath()
+	if err != nil {
+		return "", err
+	}
+	tracks, err := probeSubtitleTracks(ffprobePath, video.Path)
+	if err != nil {
+		return "", errorf(msgReadSubtitleTracksFailed, err)
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"tracks": tracks,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// ExtractSubtitle extracts an embedded subtitle track next to the video
+// ExtractSubtitle 将视频内嵌的文本字幕轨道提取为 srt、vtt、ass 或 ssa 文件，保存到视频旁边（视频名称.语言.扩展名），
+// track 为 GetSubtitleTracks 返回的序号
+func (a *App) ExtractSubtitle(path string, track int, format string) (string, error) {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	output, ok := subtitleFormats[format]
+	if !ok {
+		return "", errorf(msgInvalidSubtitleFormat, format)
+	}
+	video, err := a.findLibraryVideo(path)
+	if err != nil {
+		return "", err
+	}
+	ffprobePath, err := ffprobeToolPath()
+	if err != nil {
+		return "", err
+	}
+	tracks, err := probeSubtitleTracks(ffprobePath, video.Path)
+	if err != nil {
+		return "", errorf(msgReadSubtitleTracksFailed, err)
+	}
+	if track < 0 || track >= len(tracks) {
+		return "", errorf(msgSubtitleTrackNotFound, track)
+	}
+	if !tracks[track].Text {
+		return "", errorf(msgImageSubtitle, tracks[track].Codec)
+	}
+
+	language := strings.ToLower(tracks[track].Language)
+	if !subtitleLanguagePattern.MatchString(language) || language == "und" {
+		language = ""
+	}
+	target := subtitleTarget(video.Path, language, "."+format)
+	if err := runSubtitleFFmpeg(target, output, "-i", video.Path, "-map", "0:s:"+strconv.Itoa(track)); err != nil {
+		return "", errorf(msgConvertSubtitleFailed, err)
+	}
+	slog.Info("已提取字幕", "video", video.Path, "track", track, "subtitle", target)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":   "success",
+		"subtitle": a.savedSubtitle(video, target),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// ConvertSubtitle converts a subtitle file to another format
+// ConvertSubtitle 将视频库中的字幕文件转换为 srt、vtt、ass 或 ssa 格式，保存在原文件旁边（只替换扩展名，已存在时加序号），
+// 转换为 vtt 后可以在内置播放器中显示。文本字幕转换为 srt 或 vtt 不需要 ffmpeg
+func (a *App) ConvertSubtitle(path string, format string) (string, error) {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	if _, ok := subtitleFormats[format]; !ok {
+		return "", errorf(msgInvalidSubtitleFormat, format)
+	}
+	video, subtitle, err := a.findLibrarySubtitle(path)
+	if err != nil {
+		return "", err
+	}
+	if subtitle.Format == format {
+		return "", errorf(msgInvalidSubtitleFormat, format)
+	}
+
+	target := availableSubtitlePath(strings.TrimSuffix(subtitle.Path, filepath.Ext(subtitle.Path)), "."+format)
+	if err := convertSubtitleFile(subtitle.Path, subtitle.Format, target, format); err != nil {
+		return "", errorf(msgConvertSubtitleFailed, err)
+	}
+	slog.Info("已转换字幕", "subtitle", subtitle.Path, "target", target)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":   "success",
+		"subtitle": a.savedSubtitle(video, target),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
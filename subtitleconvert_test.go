@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSubtitleTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"00:00:01,500", 1500 * time.Millisecond, true},
+		{"01:02:03.004", time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond, true},
+		{"02:03.250", 2*time.Minute + 3250*time.Millisecond, true},
+		// ASS 的小数为百分之一秒
+		{"0:00:05.12", 5120 * time.Millisecond, true},
+		{"0:00:05.1", 5100 * time.Millisecond, true},
+		// 超过毫秒的精度四舍五入
+		{"00:00:01,2345", 1235 * time.Millisecond, true},
+		{"00:00:01,2344", 1234 * time.Millisecond, true},
+		{"00:00:59.9996", time.Minute, true},
+		{"00:00:07", 7 * time.Second, true},
+		{"1.500", 0, false},
+		{"00:-1:00.000", 0, false},
+		{"00:01:00.5e3", 0, false},
+		{"aa:bb:cc", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSubtitleTime(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseSubtitleTime(%q) = %v, %v，应为 %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestConvertSubtitleText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "SRT 转 VTT，去掉 BOM 和 CRLF，保留多行字幕",
+			text: "\ufeff1\r\n00:00:01,2345 --> 00:00:03,000\r\nFirst line\r\nSecond line\r\n\r\n2\r\n00:00:04,000 --> 00:00:05,500\r\n<i>Italic</i>\r\n",
+			from: "srt",
+			to:   "vtt",
+			want: "WEBVTT\n\n" +
+				"00:00:01.235 --> 00:00:03.000\nFirst line\nSecond line\n\n" +
+				"00:00:04.000 --> 00:00:05.500\n<i>Italic</i>\n\n",
+		},
+		{
+			name: "VTT 转 SRT，忽略文件头、NOTE、标识和位置设置",
+			text: "WEBVTT - title\n\nNOTE a comment\n\nintro\n00:01.000 --> 00:02.500 align:start line:0\nHello\n\n" +
+				"01:00:00.000 --> 01:00:01.000\nLine one\nLine two\n",
+			from: "vtt",
+			to:   "srt",
+			want: "1\n00:00:01,000 --> 00:00:02,500\nHello\n\n" +
+				"2\n01:00:00,000 --> 01:00:01,000\nLine one\nLine two\n\n",
+		},
+		{
+			name: "ASS 转 SRT，去掉覆盖标签，\\N 换行，按开始时间排序",
+			text: "\ufeff[Script Info]\nTitle: Test\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n[Events]\n" +
+				"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Dialogue: 0,0:00:05.00,0:00:06.50,Default,,0,0,0,,{\\i1}Second{\\i0}, with a comma\n" +
+				"Comment: 0,0:00:00.00,0:00:09.00,Default,,0,0,0,,Not shown\n" +
+				"Dialogue: 0,0:00:01.25,0:00:02.07,Default,,0,0,0,,{\\an8\\pos(320,50)}Top\\NSecond\\hline\n" +
+				"Dialogue: 0,0:00:03.00,0:00:04.00,Default,,0,0,0,,{\\p1}\n",
+			from: "ass",
+			to:   "srt",
+			want: "1\n00:00:01,250 --> 00:00:02,070\nTop\nSecond line\n\n" +
+				"2\n00:00:05,000 --> 00:00:06,500\nSecond, with a comma\n\n",
+		},
+		{
+			name: "SSA 使用 Format 行中的字段顺序",
+			text: "[Events]\nFormat: Marked, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Dialogue: Marked=0,0:00:10.10,0:00:12.00,Default,,0000,0000,0000,,Old style\n",
+			from: "ssa",
+			to:   "vtt",
+			want: "WEBVTT\n\n00:00:10.100 --> 00:00:12.000\nOld style\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := convertSubtitleText(tt.text, tt.from, tt.to)
+			if !ok || got != tt.want {
+				t.Fatalf("转换结果为（%v）:\n%q\n应为:\n%q", ok, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertSubtitleTextUnsupported(t *testing.T) {
+	srt := "1\n00:00:01,000 --> 00:00:02,000\nHello\n"
+	tests := []struct {
+		name string
+		text string
+		from string
+		to   string
+	}{
+		{"没有字幕", "WEBVTT\n\nNOTE only a note\n", "vtt", "srt"},
+		{"不支持的输入格式", srt, "sub", "vtt"},
+		{"输出 ASS 使用 ffmpeg", srt, "srt", "ass"},
+	}
+	for _, tt := range tests {
+		if _, ok := convertSubtitleText(tt.text, tt.from, tt.to); ok {
+			t.Errorf("%s: 不应在程序中转换", tt.name)
+		}
+	}
+}
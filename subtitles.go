@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	openSubtitlesAPI = "https://api.opensubtitles.com/api/v1"
+	// subtitleHashChunk OpenSubtitles 哈希读取的文件头尾长度
+	subtitleHashChunk = 64 * 1024
+	// maxSubtitleSize 下载字幕的大小上限
+	maxSubtitleSize = 10 * 1024 * 1024
+	// defaultSubtitleLanguages 默认搜索的字幕语言，OpenSubtitles 的语言代码
+	defaultSubtitleLanguages = "en,zh-cn"
+)
+
+// subtitleExtensions 字幕文件扩展名，与视频同名的字幕文件显示在视频库中
+var subtitleExtensions = map[string]bool{
+	".srt": true,
+	".ass": true,
+	".ssa": true,
+	".vtt": true,
+	".sub": true,
+}
+
+// subtitleLanguagePattern OpenSubtitles 的语言代码，例如 en、zh-cn、pt-br
+var subtitleLanguagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]{2,4})?$`)
+
+// LibrarySubtitle is a subtitle file next to a library video
+// LibrarySubtitle 与视频放在一起的字幕文件，例如 第1集.zh-cn.srt
+type LibrarySubtitle struct {
+	Name string `json:"name"`
+	// 完整路径
+	Path string `json:"path"`
+	// 文件名中视频名称之后的部分，例如 zh-cn、en.forced，没有时为空
+	Language string `json:"language,omitempty"`
+	// 字幕格式：srt, ass, ssa, vtt, sub
+	Format string `json:"format"`
+	URL    string `json:"url"`
+}
+
+// SubtitleResult is a subtitle found on OpenSubtitles
+// SubtitleResult 在 OpenSubtitles 上找到的字幕
+type SubtitleResult struct {
+	// 下载时使用的文件ID
+	FileID   int    `json:"fileId"`
+	FileName string `json:"fileName"`
+	Language string `json:"language"`
+	// 字幕对应的发布名称
+	Release   string `json:"release"`
+	Title     string `json:"title"`
+	Year      int    `json:"year,omitempty"`
+	Season    int    `json:"season,omitempty"`
+	Episode   int    `json:"episode,omitempty"`
+	Downloads int    `json:"downloads"`
+	// 通过文件哈希匹配，时间轴与视频一致的可能性最大
+	HashMatch       bool `json:"hashMatch"`
+	HearingImpaired bool `json:"hearingImpaired"`
+}
+
+// normalizeSubtitleLanguages 检查字幕语言列表，转换为小写并排序（OpenSubtitles 要求参数有序，否则会重定向）
+func normalizeSubtitleLanguages(languages string) (string, error) {
+	var codes []string
+	for _, code := range strings.Split(languages, ",") {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+		if !subtitleLanguagePattern.MatchString(code) {
+			return "", errorf(msgInvalidSubtitleLanguage, code)
+		}
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ","), nil
+}
+
+// videoSubtitles 从视频所在目录的字幕文件中找出属于视频的字幕：文件名与视频相同，或以视频名称加点号开头
+func (r libraryRoot) videoSubtitles(video LibraryVideo, names []string) []LibrarySubtitle {
+	base := strings.ToLower(strings.TrimSuffix(video.Name, filepath.Ext(video.Name)))
+	dir := path.Dir(video.RelPath)
+	var subtitles []LibrarySubtitle
+	for _, name := range names {
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		if strings.ToLower(stem) != base && !strings.HasPrefix(strings.ToLower(stem), base+".") {
+			continue
+		}
+		relPath := name
+		if dir != "." {
+			relPath = dir + "/" + name
+		}
+		subtitle := LibrarySubtitle{
+			Name:   name,
+			Path:   filepath.Join(filepath.Dir(video.Path), name),
+			Format: strings.ToLower(ext[1:]),
+			URL:    r.fileURL(relPath),
+		}
+		if len(stem) > len(base) {
+			subtitle.Language = stem[len(base)+1:]
+		}
+		subtitles = append(subtitles, subtitle)
+	}
+	return subtitles
+}
+
+// openSubtitlesHash 计算 OpenSubtitles 的文件哈希：文件大小加上开头和结尾各 64KB 按 64 位小端整数求和。
+// 文件小于 64KB 时返回空字符串，只按名称搜索
+func openSubtitlesHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+	if size < subtitleHashChunk {
+		return "", nil
+	}
+
+	hash := uint64(size)
+	buf := make([]byte, subtitleHashChunk)
+	for _, offset := range []int64{0, size - subtitleHashChunk} {
+		if _, err := file.ReadAt(buf, offset); err != nil {
+			return "", err
+		}
+		for i := 0; i < len(buf); i += 8 {
+			hash += binary.LittleEndian.Uint64(buf[i:])
+		}
+	}
+	return fmt.Sprintf("%016x", hash), nil
+}
+
+// openSubtitlesClient 调用 OpenSubtitles REST API，需要在设置中填写API密钥
+type openSubtitlesClient struct {
+	apiKey string
+	client *http.Client
+}
+
+// newOpenSubtitlesClient 使用设置中的API密钥创建客户端，未填写时返回错误
+func newOpenSubtitlesClient() (*openSubtitlesClient, error) {
+	apiKey := strings.TrimSpace(currentSettings().OpenSubtitlesAPIKey)
+	if apiKey == "" {
+		return nil, errorf(msgSubtitleKeyRequired)
+	}
+	return &openSubtitlesClient{apiKey: apiKey, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// do 发送请求并解析 JSON 响应，失败时使用接口返回的错误信息
+func (c *openSubtitlesClient) do(req *http.Request, v interface{}) error {
+	// OpenSubtitles 要求 User-Agent 包含应用名称和版本
+	req.Header.Set("User-Agent", "SeedParser v"+appVersion)
+	req.Header.Set("Api-Key", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errorf(msgSubtitleRequestFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&failure) == nil && failure.Message != "" {
+			return errorf(msgSubtitleRequestFailed, failure.Message)
+		}
+		return errorf(msgSubtitleRequestFailed, errorf(msgServerReturnedStatus, resp.Status))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errorf(msgSubtitleRequestFailed, err)
+	}
+	return nil
+}
+
+// search 搜索视频的字幕：按文件哈希和标题（query 为空时使用从文件名解析的标题）搜索设置中的语言，
+// 哈希匹配的结果在前，其余按下载次数排序
+func (c *openSubtitlesClient) search(ctx context.Context, video LibraryVideo, query string) ([]SubtitleResult, error) {
+	params := url.Values{}
+	if languages := currentSettings().SubtitleLanguages; languages != "" {
+		params.Set("languages", languages)
+	}
+	hash, err := openSubtitlesHash(video.Path)
+	if err != nil {
+		return nil, err
+	}
+	if hash != "" {
+		params.Set("moviehash", hash)
+	}
+	if query == "" {
+		query = video.Parsed.Title
+	}
+	if query == "" {
+		query = strings.TrimSuffix(video.Name, filepath.Ext(video.Name))
+	}
+	params.Set("query", strings.ToLower(query))
+	if video.Parsed.IsEpisode() {
+		params.Set("season_number", strconv.Itoa(video.Parsed.Season))
+		params.Set("episode_number", strconv.Itoa(video.Parsed.Episode))
+	} else if video.Parsed.Year > 0 {
+		params.Set("year", strconv.Itoa(video.Parsed.Year))
+	}
+
+	// Encode 按参数名排序
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, openSubtitlesAPI+"/subtitles?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Data []struct {
+			Attributes struct {
+				Language        string `json:"language"`
+				DownloadCount   int    `json:"download_count"`
+				HearingImpaired bool   `json:"hearing_impaired"`
+				Release         string `json:"release"`
+				MoviehashMatch  bool   `json:"moviehash_match"`
+				FeatureDetails  struct {
+					Title         string `json:"title"`
+					MovieName     string `json:"movie_name"`
+					Year          int    `json:"year"`
+					SeasonNumber  int    `json:"season_number"`
+					EpisodeNumber int    `json:"episode_number"`
+				} `json:"feature_details"`
+				Files []struct {
+					FileID   int    `json:"file_id"`
+					FileName string `json:"file_name"`
+				} `json:"files"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return nil, err
+	}
+
+	results := []SubtitleResult{}
+	for _, item := range result.Data {
+		attrs := item.Attributes
+		title := attrs.FeatureDetails.MovieName
+		if title == "" {
+			title = attrs.FeatureDetails.Title
+		}
+		// 多个文件的字幕（按CD分割）只提供第一个文件
+		if len(attrs.Files) == 0 {
+			continue
+		}
+		results = append(results, SubtitleResult{
+			FileID:          attrs.Files[0].FileID,
+			FileName:        attrs.Files[0].FileName,
+			Language:        attrs.Language,
+			Release:         attrs.Release,
+			Title:           title,
+			Year:            attrs.FeatureDetails.Year,
+			Season:          attrs.FeatureDetails.SeasonNumber,
+			Episode:         attrs.FeatureDetails.EpisodeNumber,
+			Downloads:       attrs.DownloadCount,
+			HashMatch:       attrs.MoviehashMatch,
+			HearingImpaired: attrs.HearingImpaired,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].HashMatch != results[j].HashMatch {
+			return results[i].HashMatch
+		}
+		return results[i].Downloads > results[j].Downloads
+	})
+	return results, nil
+}
+
+// download 获取字幕文件的下载地址并下载，返回字幕内容、文件名和今天剩余的下载次数
+func (c *openSubtitlesClient) download(ctx context.Context, fileID int) ([]byte, string, int, error) {
+	body, err := json.Marshal(map[string]int{"file_id": fileID})
+	if err != nil {
+		return nil, "", 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, openSubtitlesAPI+"/download", bytes.NewReader(body))
+	if err != nil {
+		return nil, "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var link struct {
+		Link      string `json:"link"`
+		FileName  string `json:"file_name"`
+		Remaining int    `json:"remaining"`
+	}
+	if err := c.do(req, &link); err != nil {
+		return nil, "", 0, err
+	}
+
+	// 下载地址是临时的文件地址，不需要API密钥
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, link.Link, nil)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	req.Header.Set("User-Agent", "SeedParser v"+appVersion)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, "", 0, errorf(msgSubtitleRequestFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", 0, errorf(msgSubtitleRequestFailed, errorf(msgServerReturnedStatus, resp.Status))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSubtitleSize))
+	if err != nil {
+		return nil, "", 0, errorf(msgSubtitleRequestFailed, err)
+	}
+	return data, link.FileName, link.Remaining, nil
+}
+
+// subtitleTarget 返回保存字幕的路径：视频名称.语言.扩展名，已存在时加序号
+func subtitleTarget(videoPath, language, fileName string) string {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if !subtitleExtensions[ext] {
+		ext = ".srt"
+	}
+	base := strings.TrimSuffix(videoPath, filepath.Ext(videoPath))
+	if language != "" {
+		base += "." + language
+	}
+	return availableSubtitlePath(base, ext)
+}
+
+// availableSubtitlePath 返回 base 加扩展名的路径，已存在时在扩展名前加序号
+func availableSubtitlePath(base, ext string) string {
+	target := base + ext
+	for i := 2; ; i++ {
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			return target
+		}
+		target = base + "." + strconv.Itoa(i) + ext
+	}
+}
+
+// savedSubtitle 返回新保存的字幕在视频库中的信息，并清除视频库文件夹的扫描缓存，下次获取视频库时显示新字幕
+func (a *App) savedSubtitle(video LibraryVideo, target string) LibrarySubtitle {
+	a.library.invalidate(video.Root)
+	root, _ := libraryRootByName(video.Root)
+	return root.videoSubtitles(video, []string{filepath.Base(target)})[0]
+}
+
+// SearchSubtitles searches OpenSubtitles for subtitles of a library video
+// SearchSubtitles 在 OpenSubtitles 上搜索视频库中视频的字幕，按文件哈希和标题搜索设置中的语言，
+// query 为空时使用从文件名解析的标题；需要在设置中填写 OpenSubtitles API 密钥
+func (a *App) SearchSubtitles(path string, query string) (string, error) {
+	client, err := newOpenSubtitlesClient()
+	if err != nil {
+		return "", err
+	}
+	video, err := a.findLibraryVideo(path)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	results, err := client.search(ctx, video, strings.TrimSpace(query))
+	if err != nil {
+		return "", err
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":  "success",
+		"results": results,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// DownloadSubtitle downloads a subtitle from OpenSubtitles next to a library video
+// DownloadSubtitle 下载 SearchSubtitles 返回的字幕，保存到视频旁边（视频名称.语言.扩展名），
+// 返回保存的字幕和今天剩余的下载次数
+func (a *App) DownloadSubtitle(path string, fileID int, language string) (string, error) {
+	client, err := newOpenSubtitlesClient()
+	if err != nil {
+		return "", err
+	}
+	video, err := a.findLibraryVideo(path)
+	if err != nil {
+		return "", err
+	}
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language != "" && !subtitleLanguagePattern.MatchString(language) {
+		return "", errorf(msgInvalidSubtitleLanguage, language)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	data, fileName, remaining, err := client.download(ctx, fileID)
+	if err != nil {
+		return "", err
+	}
+
+	target := subtitleTarget(video.Path, language, fileName)
+	if err := writeFileAtomic(target, data, 0644); err != nil {
+		return "", errorf(msgSaveSubtitleFailed, err)
+	}
+	slog.Info("已下载字幕", "video", video.Path, "subtitle", target, "remaining", remaining)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":    "success",
+		"subtitle":  a.savedSubtitle(video, target),
+		"remaining": remaining,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"sync"
+)
+
+// SystemInfo is the system the app is running on
+// SystemInfo 运行程序的系统信息，用于设置页面的“关于”和问题反馈
+type SystemInfo struct {
+	AppVersion string `json:"appVersion"`
+	// 操作系统和架构，与 GOOS、GOARCH 相同
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+	// 系统名称和版本，例如 Windows 11 23H2 (22631)、macOS 14.5、Ubuntu 24.04 LTS (6.8.0-31-generic)
+	OSVersion string `json:"osVersion"`
+	CPUModel  string `json:"cpuModel"`
+	// 逻辑处理器数
+	CPUCores int `json:"cpuCores"`
+	// 物理内存总量（字节），无法读取时为0
+	MemoryTotal uint64   `json:"memoryTotal"`
+	GPUs        []string `json:"gpus"`
+	GoVersion   string   `json:"goVersion"`
+	// 运行模式：desktop 或 server
+	Mode string `json:"mode"`
+}
+
+// gpuList 显卡名称列表，只在第一次使用时检测，与GPU类型检测共用
+var gpuList = sync.OnceValues(listGPUs)
+
+// staticSystemInfo 系统版本、处理器和内存在程序运行期间不会变化，只在第一次调用时读取
+var staticSystemInfo = sync.OnceValue(func() SystemInfo {
+	info := SystemInfo{
+		AppVersion: appVersion,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		OSVersion:  osVersion(),
+		CPUModel:   cpuModel(),
+		CPUCores:   runtime.NumCPU(),
+		GoVersion:  runtime.Version(),
+		GPUs:       []string{},
+	}
+	if total, err := totalMemory(); err == nil {
+		info.MemoryTotal = total
+	} else {
+		slog.Warn("读取内存总量失败", "error", err)
+	}
+	if gpus, err := gpuList(); err == nil && len(gpus) > 0 {
+		info.GPUs = gpus
+	}
+	return info
+})
+
+// GetSystemInfo returns the OS, CPU, memory and GPUs of this computer
+// GetSystemInfo 返回系统版本、处理器型号和核心数、内存总量、显卡和程序版本，用于“关于”页面和问题反馈
+func (a *App) GetSystemInfo() (string, error) {
+	info := staticSystemInfo()
+	info.Mode = "desktop"
+	if a.headless {
+		info.Mode = "server"
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"system": info,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// sysctlValue 使用 sysctl 读取系统参数
+func sysctlValue(name string) (string, error) {
+	output, err := exec.Command("sysctl", "-n", name).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// osVersion 使用 sw_vers 读取 macOS 版本，例如 macOS 14.5 (23F79)
+func osVersion() string {
+	version, err := exec.Command("sw_vers", "-productVersion").Output()
+	if err != nil {
+		return "macOS"
+	}
+	name := "macOS " + strings.TrimSpace(string(version))
+	if build, err := exec.Command("sw_vers", "-buildVersion").Output(); err == nil {
+		name += " (" + strings.TrimSpace(string(build)) + ")"
+	}
+	return name
+}
+
+// cpuModel 返回处理器型号，例如 Apple M2 Pro、Intel(R) Core(TM) i7-9750H
+func cpuModel() string {
+	model, _ := sysctlValue("machdep.cpu.brand_string")
+	return model
+}
+
+// totalMemory 返回物理内存总量
+func totalMemory() (uint64, error) {
+	value, err := sysctlValue("hw.memsize")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
@@ -0,0 +1,71 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// osVersion 返回发行版名称（/etc/os-release 的 PRETTY_NAME）和内核版本
+func osVersion() string {
+	name := "Linux"
+	if data, err := os.ReadFile("/etc/os-release"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+				name = strings.Trim(value, `"'`)
+				break
+			}
+		}
+	}
+	if kernel, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		name += " (" + strings.TrimSpace(string(kernel)) + ")"
+	}
+	return name
+}
+
+// cpuModel 从 /proc/cpuinfo 读取处理器型号，ARM 设备没有 model name 时使用 Model 或 Hardware
+func cpuModel() string {
+	file, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		key = strings.TrimSpace(key)
+		if ok && fields[key] == "" {
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+	for _, key := range []string{"model name", "Model", "Hardware"} {
+		if fields[key] != "" {
+			return fields[key]
+		}
+	}
+	return ""
+}
+
+// totalMemory 从 /proc/meminfo 读取物理内存总量
+func totalMemory() (uint64, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// MemTotal:       16303132 kB
+		if value, ok := strings.CutPrefix(line, "MemTotal:"); ok {
+			kb, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kb * 1024, nil
+		}
+	}
+	return 0, errors.New("MemTotal not found in /proc/meminfo")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	// windowsVersionKey 系统版本信息的注册表项
+	windowsVersionKey = `SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+	// processorKey 第一个处理器的注册表项，ProcessorNameString 为处理器型号
+	processorKey = `HARDWARE\DESCRIPTION\System\CentralProcessor\0`
+	// windows11Build Windows 11 的第一个版本号，Windows 11 的注册表 ProductName 仍然是 Windows 10
+	windows11Build = 22000
+)
+
+var procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+
+// memoryStatusEx MEMORYSTATUSEX 结构
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// registryString 读取 HKEY_LOCAL_MACHINE 下的字符串值，读取失败时返回空字符串
+func registryString(path, name string) string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer key.Close()
+	value, _, _ := key.GetStringValue(name)
+	return strings.TrimSpace(value)
+}
+
+// osVersion 返回系统名称、版本和内部版本号，例如 Windows 11 Pro 23H2 (22631)
+func osVersion() string {
+	version := windows.RtlGetVersion()
+	name := registryString(windowsVersionKey, "ProductName")
+	if name == "" {
+		name = fmt.Sprintf("Windows %d.%d", version.MajorVersion, version.MinorVersion)
+	}
+	if version.BuildNumber >= windows11Build {
+		name = strings.Replace(name, "Windows 10", "Windows 11", 1)
+	}
+	if display := registryString(windowsVersionKey, "DisplayVersion"); display != "" {
+		name += " " + display
+	}
+	return fmt.Sprintf("%s (%d)", name, version.BuildNumber)
+}
+
+// cpuModel 从注册表读取处理器型号
+func cpuModel() string {
+	return registryString(processorKey, "ProcessorNameString")
+}
+
+// totalMemory 使用 GlobalMemoryStatusEx 读取物理内存总量
+func totalMemory() (uint64, error) {
+	status := memoryStatusEx{}
+	status.Length = uint32(unsafe.Sizeof(status))
+	if r, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status))); r == 0 {
+		return 0, err
+	}
+	return status.TotalPhys, nil
+}
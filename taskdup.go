@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+)
+
+// magnetInfoHash 返回磁力链接中的 info-hash（有 v1 时使用 v1），无法解析时返回空字符串
+func magnetInfoHash(magnetLink string) string {
+	magnet, err := parseMagnet(magnetLink)
+	if err != nil {
+		return ""
+	}
+	if magnet.InfoHash != "" {
+		return magnet.InfoHash
+	}
+	return magnet.InfoHashV2
+}
+
+// findDuplicateDownload 查找 info-hash 相同的下载任务；已取消的任务可能已删除文件，不算重复
+func (a *App) findDuplicateDownload(magnetLink string) (DownloadTask, bool) {
+	hash := magnetInfoHash(magnetLink)
+	if hash == "" {
+		return DownloadTask{}, false
+	}
+	for _, task := range a.tasks.Downloads() {
+		if task.Status != "cancelled" && magnetInfoHash(task.MagnetLink) == hash {
+			return task, true
+		}
+	}
+	return DownloadTask{}, false
+}
+
+// mergeSelectedFiles 合并两次选择的文件，任一为空（下载所有文件）时结果为空；返回新增的文件
+func mergeSelectedFiles(existing, selected []string) ([]string, []string) {
+	if len(existing) == 0 || len(selected) == 0 {
+		return nil, nil
+	}
+	merged := slices.Clone(existing)
+	var added []string
+	for _, file := range selected {
+		if !slices.Contains(merged, file) {
+			merged = append(merged, file)
+			added = append(added, file)
+		}
+	}
+	return merged, added
+}
+
+// duplicateDownloadResponse 添加的种子或磁力链接已有下载任务时，将新选择的文件合并到已有任务，
+// 返回 status 为 exists 的响应，不再创建下载到同一目录的第二个任务
+func (a *App) duplicateDownloadResponse(task DownloadTask, selectedFiles []string) (string, error) {
+	merged, added := mergeSelectedFiles(task.SelectedFiles, selectedFiles)
+	allFiles := len(task.SelectedFiles) > 0 && len(merged) == 0
+	if len(added) > 0 || allFiles {
+		if updated, ok := a.tasks.UpdateDownload(task.TaskID, func(t *DownloadTask) {
+			t.SelectedFiles = merged
+		}); ok {
+			task = updated
+			a.emitDownloadProgress(task, true)
+		}
+	}
+	slog.Info("下载任务已存在", "taskId", task.TaskID, "status", task.Status, "addedFiles", len(added), "allFiles", allFiles)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":        "exists",
+		"message":       "Download task already exists",
+		"taskId":        task.TaskID,
+		"taskStatus":    task.Status,
+		"magnetLink":    task.MagnetLink,
+		"fileName":      task.FileName,
+		"selectedFiles": task.SelectedFiles,
+		"addedFiles":    added,
+		"outputDir":     task.OutputDir,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// taskFlushInterval 内存中任务数据定期写入存储的间隔
+const taskFlushInterval = 2 * time.Second
+
+// taskIDSeq 任务ID中的递增序号，区分同一时刻创建的任务
+var taskIDSeq atomic.Uint64
+
+// newTaskID 生成任务ID：前缀、创建时间（纳秒）和递增序号，同一秒内连续添加多个任务时也不会重复
+func newTaskID(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d", prefix, time.Now().UnixNano(), taskIDSeq.Add(1))
+}
+
+// TaskManager is the in-memory source of truth for all tasks
+// TaskManager 任务的内存唯一数据源，所有读写都在锁内完成，
+// 进度变化定期批量写入存储，状态变化立即写入
+type TaskManager struct {
+	mu         sync.Mutex
+	flushMu    sync.Mutex // 串行化写入与删除，避免已删除的任务被重新写回
+	store      TaskStore
+	downloads  []DownloadTask
+	transcodes []TranscodeTask
+
+	// 待写入存储的任务ID
+	dirtyDownloads  map[string]bool
+	dirtyTranscodes map[string]bool
+
+	flushNow chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewTaskManager loads all tasks from the store into memory
+// NewTaskManager 从存储中加载全部任务到内存
+func NewTaskManager(store TaskStore) (*TaskManager, error) {
+	downloads, err := store.ListDownloads()
+	if err != nil {
+		return nil, err
+	}
+	transcodes, err := store.ListTranscodes()
+	if err != nil {
+		return nil, err
+	}
+	return &TaskManager{
+		store:           store,
+		downloads:       downloads,
+		transcodes:      transcodes,
+		dirtyDownloads:  make(map[string]bool),
+		dirtyTranscodes: make(map[string]bool),
+		flushNow:        make(chan struct{}, 1),
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}, nil
+}
+
+// Start 启动后台持久化协程
+func (m *TaskManager) Start() {
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(taskFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-m.flushNow:
+			case <-m.stop:
+				if err := m.Flush(); err != nil {
+					slog.Error("写入任务数据失败", "error", err)
+				}
+				return
+			}
+			if err := m.Flush(); err != nil {
+				slog.Error("写入任务数据失败", "error", err)
+			}
+		}
+	}()
+}
+
+// Stop 停止后台持久化协程，并在返回前写入所有未保存的修改
+func (m *TaskManager) Stop() {
+	select {
+	case <-m.stop:
+		// 已经停止
+	default:
+		close(m.stop)
+	}
+	<-m.done
+}
+
+// Flush 将所有已修改的任务写入存储
+func (m *TaskManager) Flush() error {
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
+	return m.flushLocked()
+}
+
+// flushLocked 写入所有已修改的任务，调用方持有 flushMu
+func (m *TaskManager) flushLocked() error {
+	m.mu.Lock()
+	var downloads []DownloadTask
+	for _, task := range m.downloads {
+		if m.dirtyDownloads[task.TaskID] {
+			downloads = append(downloads, task)
+		}
+	}
+	var transcodes []TranscodeTask
+	for _, task := range m.transcodes {
+		if m.dirtyTranscodes[task.TaskID] {
+			transcodes = append(transcodes, task)
+		}
+	}
+	m.dirtyDownloads = make(map[string]bool)
+	m.dirtyTranscodes = make(map[string]bool)
+	m.mu.Unlock()
+
+	// 写入存储时不持有锁，避免阻塞进度更新
+	var firstErr error
+	for _, task := range downloads {
+		if err := m.store.SaveDownload(task); err != nil {
+			m.markDownloadDirty(task.TaskID)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	for _, task := range transcodes {
+		if err := m.store.SaveTranscode(task); err != nil {
+			m.markTranscodeDirty(task.TaskID)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Relocate 写入所有未保存的修改并关闭当前存储，然后调用 move 复制数据文件并打开新的存储，
+// 期间不会写入存储。move 失败时应重新打开原来的存储并返回错误，返回的存储在两种情况下都会继续使用
+func (m *TaskManager) Relocate(move func() (TaskStore, error)) error {
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
+
+	if err := m.flushLocked(); err != nil {
+		return err
+	}
+	if err := m.store.Close(); err != nil {
+		return err
+	}
+	store, err := move()
+	if store != nil {
+		m.store = store
+	}
+	return err
+}
+
+// requestFlush 通知后台协程尽快写入
+func (m *TaskManager) requestFlush() {
+	select {
+	case m.flushNow <- struct{}{}:
+	default:
+	}
+}
+
+func (m *TaskManager) markDownloadDirty(taskID string) {
+	m.mu.Lock()
+	m.dirtyDownloads[taskID] = true
+	m.mu.Unlock()
+}
+
+func (m *TaskManager) markTranscodeDirty(taskID string) {
+	m.mu.Lock()
+	m.dirtyTranscodes[taskID] = true
+	m.mu.Unlock()
+}
+
+// Downloads 返回所有下载任务的副本
+func (m *TaskManager) Downloads() []DownloadTask {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tasks := make([]DownloadTask, len(m.downloads))
+	copy(tasks, m.downloads)
+	return tasks
+}
+
+// Download 返回指定下载任务的副本
+func (m *TaskManager) Download(taskID string) (DownloadTask, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, task := range m.downloads {
+		if task.TaskID == taskID {
+			return task, true
+		}
+	}
+	return DownloadTask{}, false
+}
+
+// AddDownload 添加下载任务并立即持久化；已有相同ID的任务时返回错误，不覆盖原任务
+func (m *TaskManager) AddDownload(task DownloadTask) error {
+	m.mu.Lock()
+	for _, existing := range m.downloads {
+		if existing.TaskID == task.TaskID {
+			m.mu.Unlock()
+			return errorf(msgDuplicateTaskID, task.TaskID)
+		}
+	}
+	m.downloads = append(m.downloads, task)
+	m.dirtyDownloads[task.TaskID] = true
+	m.mu.Unlock()
+	m.requestFlush()
+	return nil
+}
+
+// UpdateDownload 在锁内修改指定下载任务，返回修改后的副本；
+// 状态发生变化时立即持久化，否则等待下一次定期写入
+func (m *TaskManager) UpdateDownload(taskID string, update func(task *DownloadTask)) (DownloadTask, bool) {
+	m.mu.Lock()
+	for i := range m.downloads {
+		if m.downloads[i].TaskID == taskID {
+			oldStatus := m.downloads[i].Status
+			update(&m.downloads[i])
+			task := m.downloads[i]
+			m.dirtyDownloads[taskID] = true
+			m.mu.Unlock()
+			if task.Status != oldStatus {
+				m.requestFlush()
+			}
+			return task, true
+		}
+	}
+	m.mu.Unlock()
+	return DownloadTask{}, false
+}
+
+// RemoveDownload 删除下载任务
+func (m *TaskManager) RemoveDownload(taskID string) error {
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
+
+	m.mu.Lock()
+	for i := range m.downloads {
+		if m.downloads[i].TaskID == taskID {
+			m.downloads = append(m.downloads[:i], m.downloads[i+1:]...)
+			break
+		}
+	}
+	delete(m.dirtyDownloads, taskID)
+	m.mu.Unlock()
+	return m.store.DeleteDownload(taskID)
+}
+
+// Transcodes 返回所有转码任务的副本
+func (m *TaskManager) Transcodes() []TranscodeTask {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tasks := make([]TranscodeTask, len(m.transcodes))
+	copy(tasks, m.transcodes)
+	return tasks
+}
+
+// Transcode 返回指定转码任务的副本
+func (m *TaskManager) Transcode(taskID string) (TranscodeTask, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, task := range m.transcodes {
+		if task.TaskID == taskID {
+			return task, true
+		}
+	}
+	return TranscodeTask{}, false
+}
+
+// AddTranscode 添加转码任务并立即持久化；已有相同ID的任务时返回错误，不覆盖原任务
+func (m *TaskManager) AddTranscode(task TranscodeTask) error {
+	m.mu.Lock()
+	for _, existing := range m.transcodes {
+		if existing.TaskID == task.TaskID {
+			m.mu.Unlock()
+			return errorf(msgDuplicateTaskID, task.TaskID)
+		}
+	}
+	m.transcodes = append(m.transcodes, task)
+	m.dirtyTranscodes[task.TaskID] = true
+	m.mu.Unlock()
+	m.requestFlush()
+	return nil
+}
+
+// UpdateTranscode 在锁内修改指定转码任务，返回修改后的副本；
+// 状态发生变化时立即持久化，否则等待下一次定期写入
+func (m *TaskManager) UpdateTranscode(taskID string, update func(task *TranscodeTask)) (TranscodeTask, bool) {
+	m.mu.Lock()
+	for i := range m.transcodes {
+		if m.transcodes[i].TaskID == taskID {
+			oldStatus := m.transcodes[i].Status
+			update(&m.transcodes[i])
+			task := m.transcodes[i]
+			m.dirtyTranscodes[taskID] = true
+			m.mu.Unlock()
+			if task.Status != oldStatus {
+				m.requestFlush()
+			}
+			return task, true
+		}
+	}
+	m.mu.Unlock()
+	return TranscodeTask{}, false
+}
+
+// RemoveTranscode 删除转码任务
+func (m *TaskManager) RemoveTranscode(taskID string) error {
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
+
+	m.mu.Lock()
+	for i := range m.transcodes {
+		if m.transcodes[i].TaskID == taskID {
+			m.transcodes = append(m.transcodes[:i], m.transcodes[i+1:]...)
+			break
+		}
+	}
+	delete(m.dirtyTranscodes, taskID)
+	m.mu.Unlock()
+	return m.store.DeleteTranscode(taskID)
+}
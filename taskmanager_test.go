@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestNewTaskIDUnique(t *testing.T) {
+	// cliAdd 等在同一秒内连续添加多个任务
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := newTaskID("task")
+		if seen[id] {
+			t.Fatalf("任务ID重复: %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestAddTaskRejectsDuplicateID(t *testing.T) {
+	tasks, err := NewTaskManager(newMemoryTaskStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.AddDownload(DownloadTask{TaskID: "task-1", FileName: "first.mkv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.AddDownload(DownloadTask{TaskID: "task-1", FileName: "second.mkv"}); errorKey(err) != msgDuplicateTaskID {
+		t.Fatalf("添加重复的下载任务返回 %v，应被拒绝", err)
+	}
+	if task, _ := tasks.Download("task-1"); task.FileName != "first.mkv" || len(tasks.Downloads()) != 1 {
+		t.Fatalf("重复的下载任务覆盖了原任务: %+v", task)
+	}
+
+	if err := tasks.AddTranscode(TranscodeTask{TaskID: "transcode-1", InputFile: "first.mkv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.AddTranscode(TranscodeTask{TaskID: "transcode-1", InputFile: "second.mkv"}); errorKey(err) != msgDuplicateTaskID {
+		t.Fatalf("添加重复的转码任务返回 %v，应被拒绝", err)
+	}
+	if transcodes := tasks.Transcodes(); len(transcodes) != 1 || transcodes[0].InputFile != "first.mkv" {
+		t.Fatalf("重复的转码任务覆盖了原任务: %+v", transcodes)
+	}
+}
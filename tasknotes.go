@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTaskNoteLength 任务备注的最大字符数
+const maxTaskNoteLength = 2000
+
+// taskLabels 任务可以使用的颜色标签，为空表示没有标签
+var taskLabels = map[string]bool{
+	"red":    true,
+	"orange": true,
+	"yellow": true,
+	"green":  true,
+	"blue":   true,
+	"purple": true,
+	"gray":   true,
+}
+
+// SetTaskNote sets the note and color label of a download or transcode task
+// SetTaskNote 设置下载或转码任务的备注和颜色标签（red、orange、yellow、green、blue、purple、gray），
+// 两者为空时清除。备注和标签随任务保存，任何状态的任务都可以修改
+func (a *App) SetTaskNote(taskId string, note string, label string) (string, error) {
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > maxTaskNoteLength {
+		return "", errorf(msgTaskNoteTooLong, maxTaskNoteLength)
+	}
+	if label != "" && !taskLabels[label] {
+		return "", errorf(msgInvalidTaskLabel, label)
+	}
+
+	taskType := "download"
+	if task, found := a.tasks.UpdateDownload(taskId, func(t *DownloadTask) {
+		t.Note, t.Label = note, label
+	}); found {
+		a.emitDownloadProgress(task, true)
+	} else if task, found := a.tasks.UpdateTranscode(taskId, func(t *TranscodeTask) {
+		t.Note, t.Label = note, label
+	}); found {
+		taskType = "transcode"
+		a.emitTranscodeProgress(task, true)
+	} else {
+		return "", errorf(msgTaskNotFound, taskId)
+	}
+	// 状态没有变化时任务只在定期写入时保存，备注需要立即保存
+	a.tasks.requestFlush()
+	slog.Info("设置任务备注", "taskId", taskId, "label", label)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":   "success",
+		"message":  "Task note updated",
+		"taskId":   taskId,
+		"taskType": taskType,
+		"note":     note,
+		"label":    label,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
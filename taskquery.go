@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// queryDateLayout 只有日期的时间条件的格式，按本地时间的整天计算
+const queryDateLayout = "2006-01-02"
+
+// TaskQuery filters and pages download or transcode tasks
+// TaskQuery 下载或转码任务的查询条件，所有条件都为空时返回按添加顺序排列的全部任务
+type TaskQuery struct {
+	// 搜索文字，匹配文件名和备注，不区分大小写，多个词需要全部匹配
+	Search string `json:"search"`
+	// 任务状态，例如 downloading、paused、completed
+	Statuses []string `json:"statuses"`
+	// 颜色标签（任务分类），见 SetTaskNote；"none" 匹配没有标签的任务
+	Labels []string `json:"labels"`
+	// 任务开始时间（startTime）的范围，RFC3339 时间或 2006-01-02 格式的日期（包含当天），为空表示不限
+	From string `json:"from"`
+	To   string `json:"to"`
+	// 是否倒序（最近添加的在前）
+	Desc   bool `json:"desc"`
+	Offset int  `json:"offset"`
+	// 每页数量，0表示返回全部
+	Limit int `json:"limit"`
+
+	from, to time.Time
+	terms    []string
+}
+
+// parseTaskQuery 解析并检查查询条件，queryData 为空时返回空条件
+func parseTaskQuery(queryData string) (TaskQuery, error) {
+	var query TaskQuery
+	if strings.TrimSpace(queryData) != "" {
+		if err := json.Unmarshal([]byte(queryData), &query); err != nil {
+			return query, errorf(msgParseTaskQueryFailed, err)
+		}
+	}
+	for _, label := range query.Labels {
+		if label != "none" && !taskLabels[label] {
+			return query, errorf(msgInvalidTaskLabel, label)
+		}
+	}
+	if query.Offset < 0 || query.Limit < 0 {
+		return query, errorf(msgInvalidTaskQuery)
+	}
+
+	var err error
+	if query.from, err = parseQueryTime(query.From, false); err != nil {
+		return query, err
+	}
+	if query.to, err = parseQueryTime(query.To, true); err != nil {
+		return query, err
+	}
+	query.terms = strings.Fields(strings.ToLower(query.Search))
+	return query, nil
+}
+
+// parseQueryTime 解析时间条件，只有日期时 end 为 true 返回第二天零点（包含当天），为空时返回零值
+func parseQueryTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(queryDateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, errorf(msgInvalidQueryTime, value)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// matches 判断任务是否满足过滤条件，text 为搜索的文字（文件名和备注）
+func (q TaskQuery) matches(status, label string, startTime time.Time, text string) bool {
+	if !containsFold(q.Statuses, status) {
+		return false
+	}
+	if label == "" {
+		label = "none"
+	}
+	if !containsFold(q.Labels, label) {
+		return false
+	}
+	// 开始时间未知的任务不满足时间范围
+	if !q.from.IsZero() && (startTime.IsZero() || startTime.Before(q.from)) {
+		return false
+	}
+	if !q.to.IsZero() && (startTime.IsZero() || !startTime.Before(q.to)) {
+		return false
+	}
+	text = strings.ToLower(text)
+	for _, term := range q.terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryTasks 按查询条件过滤、排序并分页，返回当前页的任务和满足条件的任务数
+func queryTasks[T any](query *TaskQuery, tasks []T, keep func(T) bool) ([]T, int) {
+	matched := filterTasks(tasks, keep)
+	if matched == nil {
+		matched = []T{}
+	}
+	// 任务按添加顺序保存，倒序时直接反转
+	if query.Desc {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+	total := len(matched)
+	if query.Offset > total {
+		query.Offset = total
+	}
+	matched = matched[query.Offset:]
+	if query.Limit > 0 && len(matched) > query.Limit {
+		matched = matched[:query.Limit]
+	}
+	return matched, total
+}
+
+// queryDownloadTasks 按查询条件过滤下载任务，按文件名和备注搜索
+func queryDownloadTasks(query *TaskQuery, downloads []DownloadTask) ([]DownloadTask, int) {
+	return queryTasks(query, downloads, func(t DownloadTask) bool {
+		startTime, _ := time.Parse(time.RFC3339, t.StartTime)
+		return query.matches(t.Status, t.Label, startTime, t.FileName+"\n"+t.Note)
+	})
+}
+
+// queryTranscodeTasks 按查询条件过滤转码任务，按输入、输出文件名和备注搜索
+func queryTranscodeTasks(query *TaskQuery, transcodes []TranscodeTask) ([]TranscodeTask, int) {
+	return queryTasks(query, transcodes, func(t TranscodeTask) bool {
+		text := filepath.Base(t.InputFile) + "\n" + filepath.Base(t.OutputFile) + "\n" + t.Note
+		return query.matches(t.Status, t.Label, t.StartTime, text)
+	})
+}
+
+// QueryDownloads searches, filters and pages download tasks
+// QueryDownloads 按条件查询下载任务，queryData 为 TaskQuery 的 JSON（为空时返回全部任务）：按文件名和备注搜索，
+// 按状态、标签和开始时间过滤并分页。total 为满足条件的任务数，任务的字段与 GetDownloadStatus 相同
+func (a *App) QueryDownloads(queryData string) (string, error) {
+	query, err := parseTaskQuery(queryData)
+	if err != nil {
+		return "", err
+	}
+	tasks, total := queryDownloadTasks(&query, a.tasks.Downloads())
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":    "success",
+		"tasks":     tasks,
+		"total":     total,
+		"offset":    query.Offset,
+		"limit":     query.Limit,
+		"pausedAll": a.pausedAll.Load(),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// QueryTranscodes searches, filters and pages transcode tasks
+// QueryTranscodes 按条件查询转码任务，queryData 为 TaskQuery 的 JSON（为空时返回全部任务）：按输入、输出文件名和备注搜索，
+// 按状态、标签和开始时间过滤并分页。total 为满足条件的任务数，任务的字段与 GetTranscodeStatus 相同
+func (a *App) QueryTranscodes(queryData string) (string, error) {
+	query, err := parseTaskQuery(queryData)
+	if err != nil {
+		return "", err
+	}
+	tasks, total := queryTranscodeTasks(&query, a.tasks.Transcodes())
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"tasks":  tasks,
+		"total":  total,
+		"offset": query.Offset,
+		"limit":  query.Limit,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
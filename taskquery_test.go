@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseTaskQueryErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		queryData string
+		want      msgKey
+	}{
+		{"无效的JSON", "{", msgParseTaskQueryFailed},
+		{"未知的标签", `{"labels":["pink"]}`, msgInvalidTaskLabel},
+		{"负数的偏移", `{"offset":-1}`, msgInvalidTaskQuery},
+		{"负数的数量", `{"limit":-1}`, msgInvalidTaskQuery},
+		{"无效的时间", `{"from":"yesterday"}`, msgInvalidQueryTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseTaskQuery(tt.queryData); errorKey(err) != tt.want {
+				t.Fatalf("错误为 %v，应为 %s", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseTaskQuery(""); err != nil {
+		t.Fatalf("空条件不应返回错误: %v", err)
+	}
+}
+
+func TestTaskQueryMatches(t *testing.T) {
+	day := time.Date(2024, 5, 10, 23, 30, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		queryData string
+		status    string
+		label     string
+		startTime time.Time
+		want      bool
+	}{
+		{"空条件匹配全部", "", "paused", "", time.Time{}, true},
+		{"所有搜索词都匹配", `{"search":"Movie 预告"}`, "completed", "", day, true},
+		{"有搜索词不匹配", `{"search":"movie trailer"}`, "completed", "", day, false},
+		{"状态匹配", `{"statuses":["waiting","completed"]}`, "completed", "", day, true},
+		{"状态不匹配", `{"statuses":["downloading"]}`, "completed", "", day, false},
+		{"没有标签匹配 none", `{"labels":["none"]}`, "completed", "", day, true},
+		{"标签不匹配", `{"labels":["red"]}`, "completed", "blue", day, false},
+		{"结束日期包含当天", `{"from":"2024-05-10","to":"2024-05-10"}`, "completed", "", day, true},
+		{"早于开始日期", `{"from":"2024-05-11"}`, "completed", "", day, false},
+		{"开始时间未知", `{"to":"2024-05-10"}`, "completed", "", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := parseTaskQuery(tt.queryData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := query.matches(tt.status, tt.label, tt.startTime, "Movie.2024.mkv\n电影预告"); got != tt.want {
+				t.Fatalf("匹配结果为 %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryTasksPaging(t *testing.T) {
+	query, err := parseTaskQuery(`{"desc":true,"offset":1,"limit":2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, total := queryTasks(&query, []int{1, 2, 3, 4, 5}, func(n int) bool { return n != 3 })
+	if total != 4 || len(page) != 2 || page[0] != 4 || page[1] != 2 {
+		t.Fatalf("返回 %v（共 %d 个），应为 [4 2]（共 4 个）", page, total)
+	}
+
+	query, _ = parseTaskQuery(`{"offset":10}`)
+	if page, total := queryTasks(&query, []int{1, 2}, func(int) bool { return true }); len(page) != 0 || total != 2 || query.Offset != 2 {
+		t.Fatalf("超出范围的偏移返回 %v（共 %d 个，偏移 %d）", page, total, query.Offset)
+	}
+}
+
+func TestQueryDownloadsAndTranscodes(t *testing.T) {
+	tasks, err := NewTaskManager(newMemoryTaskStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{tasks: tasks}
+	tasks.AddDownload(DownloadTask{TaskID: "d1", FileName: "Movie.mkv", Status: "completed", Label: "red"})
+	tasks.AddDownload(DownloadTask{TaskID: "d2", FileName: "Show.mkv", Status: "downloading", Note: "movie night"})
+	tasks.AddDownload(DownloadTask{TaskID: "d3", FileName: "Music.flac", Status: "waiting"})
+	tasks.AddTranscode(TranscodeTask{TaskID: "t1", InputFile: "/in/movie.mkv", OutputFile: "/out/movie.mp4", Status: "completed"})
+	tasks.AddTranscode(TranscodeTask{TaskID: "t2", InputFile: "/in/show.mkv", OutputFile: "/out/show.mp4", Status: "waiting", Label: "green"})
+
+	var result struct {
+		Tasks []struct {
+			TaskID string `json:"taskId"`
+		} `json:"tasks"`
+		Total int `json:"total"`
+	}
+	taskIDs := func() []string {
+		var ids []string
+		for _, task := range result.Tasks {
+			ids = append(ids, task.TaskID)
+		}
+		return ids
+	}
+
+	data, err := a.QueryDownloads(`{"search":"movie"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		t.Fatal(err)
+	}
+	if ids := taskIDs(); result.Total != 2 || len(ids) != 2 || ids[0] != "d1" || ids[1] != "d2" {
+		t.Fatalf("下载任务的查询结果为 %v（共 %d 个），应为 [d1 d2]", ids, result.Total)
+	}
+
+	data, err = a.QueryTranscodes(`{"labels":["none"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		t.Fatal(err)
+	}
+	if ids := taskIDs(); result.Total != 1 || len(ids) != 1 || ids[0] != "t1" {
+		t.Fatalf("转码任务的查询结果为 %v（共 %d 个），应为 [t1]", ids, result.Total)
+	}
+
+	// 没有查询条件时返回全部任务
+	data, err = a.QueryDownloads("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || len(result.Tasks) != 3 {
+		t.Fatalf("空条件返回 %d 个任务，应为 3 个", len(result.Tasks))
+	}
+
+	if _, err := a.QueryDownloads(`{"limit":-1}`); errorKey(err) != msgInvalidTaskQuery {
+		t.Fatalf("无效的分页参数返回 %v", err)
+	}
+
+	// GetDownloadStatus 仍然只有一个参数并返回全部任务，REST API 和 WebSocket 的 status 命令不受影响
+	data, err = a.GetDownloadStatus("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Tasks = nil
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Tasks) != 3 {
+		t.Fatalf("GetDownloadStatus 返回 %d 个任务，应为 3 个", len(result.Tasks))
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// telegramAPI Telegram Bot API 地址
+	telegramAPI = "https://api.telegram.org"
+	// telegramPollTimeout getUpdates 长轮询的等待时间（秒）
+	telegramPollTimeout = 50
+	// telegramRetryDelay 请求失败后重新轮询的间隔
+	telegramRetryDelay = 10 * time.Second
+	// telegramMaxTorrentSize 接收的种子文件大小上限
+	telegramMaxTorrentSize = 10 * 1024 * 1024
+)
+
+// magnetPattern 匹配消息中的磁力链接
+var magnetPattern = regexp.MustCompile(`magnet:\?\S+`)
+
+// telegramHelp 机器人的帮助信息
+const telegramHelp = `发送磁力链接或 .torrent 文件即可添加下载任务。
+/status 查看正在进行的任务
+/id 查看当前聊天ID`
+
+type telegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+type telegramMessage struct {
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Text     string `json:"text"`
+	Caption  string `json:"caption"`
+	Document *struct {
+		FileID   string `json:"file_id"`
+		FileName string `json:"file_name"`
+		FileSize int64  `json:"file_size"`
+	} `json:"document"`
+}
+
+// telegramBot 通过长轮询接收消息的 Telegram 机器人：接收磁力链接和种子文件，报告任务完成和失败
+type telegramBot struct {
+	mu     sync.Mutex
+	token  string
+	cancel context.CancelFunc
+	client *http.Client
+}
+
+func newTelegramBot() *telegramBot {
+	return &telegramBot{client: &http.Client{Timeout: (telegramPollTimeout + 10) * time.Second}}
+}
+
+// applyTelegram 按设置启动、重启或停止 Telegram 机器人
+func (a *App) applyTelegram() {
+	token := currentSettings().TelegramToken
+	b := a.telegram
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cancel != nil {
+		if b.token == token {
+			return
+		}
+		b.cancel()
+		b.cancel = nil
+		slog.Info("Telegram机器人已停止")
+	}
+	b.token = token
+	if token == "" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	go b.run(ctx, a, token)
+	slog.Info("Telegram机器人已启动")
+}
+
+// stopTelegram 停止 Telegram 机器人
+func (a *App) stopTelegram() {
+	b := a.telegram
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel != nil {
+		b.cancel()
+		b.cancel = nil
+	}
+}
+
+// call 调用 Bot API 方法，result 不为nil时解码返回结果
+func (b *telegramBot) call(ctx context.Context, token, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, telegramAPI+"/bot"+token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// 错误信息中的URL包含令牌，只返回底层错误
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("%s: 解析响应失败: %w", method, err)
+	}
+	if !apiResp.OK {
+		return fmt.Errorf("%s: %s", method, apiResp.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(apiResp.Result, result)
+	}
+	return nil
+}
+
+// run 长轮询接收消息，直到 ctx 取消
+func (b *telegramBot) run(ctx context.Context, a *App, token string) {
+	var offset int64
+	for ctx.Err() == nil {
+		var updates []telegramUpdate
+		err := b.call(ctx, token, "getUpdates", map[string]interface{}{
+			"offset":          offset,
+			"timeout":         telegramPollTimeout,
+			"allowed_updates": []string{"message"},
+		}, &updates)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("Telegram轮询失败", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(telegramRetryDelay):
+			}
+			continue
+		}
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message != nil {
+				b.handleMessage(ctx, a, token, update.Message)
+			}
+		}
+	}
+}
+
+// send 向聊天发送文字消息
+func (b *telegramBot) send(ctx context.Context, token string, chatID int64, text string) {
+	err := b.call(ctx, token, "sendMessage", map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}, nil)
+	if err != nil {
+		slog.Warn("发送Telegram消息失败", "chatId", chatID, "error", err)
+	}
+}
+
+// chatAllowed 判断聊天是否在设置的允许列表中
+func chatAllowed(chatID int64) bool {
+	for _, id := range currentSettings().TelegramChatIDs {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleMessage 处理一条消息：只有允许的聊天可以添加任务
+func (b *telegramBot) handleMessage(ctx context.Context, a *App, token string, msg *telegramMessage) {
+	chatID := msg.Chat.ID
+	text := strings.TrimSpace(msg.Text)
+	if text == "/id" || (text == "/start" && !chatAllowed(chatID)) {
+		b.send(ctx, token, chatID, fmt.Sprintf("当前聊天ID: %d\n在 SeedParser 设置中添加该ID后即可使用机器人。", chatID))
+		return
+	}
+	if !chatAllowed(chatID) {
+		slog.Warn("忽略未授权的Telegram聊天", "chatId", chatID)
+		b.send(ctx, token, chatID, fmt.Sprintf("此聊天未授权，请在 SeedParser 设置中添加聊天ID: %d", chatID))
+		return
+	}
+
+	switch {
+	case msg.Document != nil && strings.HasSuffix(strings.ToLower(msg.Document.FileName), ".torrent"):
+		b.send(ctx, token, chatID, b.addTorrentDocument(ctx, a, token, msg))
+	case magnetPattern.MatchString(text + " " + msg.Caption):
+		var replies []string
+		for _, magnet := range magnetPattern.FindAllString(text+" "+msg.Caption, -1) {
+			result, err := a.AddMagnetLink(magnet)
+			if err != nil {
+				replies = append(replies, "添加失败: "+err.Error())
+				continue
+			}
+			var added struct {
+				Status   string `json:"status"`
+				TaskID   string `json:"taskId"`
+				FileName string `json:"fileName"`
+			}
+			json.Unmarshal([]byte(result), &added)
+			if added.Status == "exists" {
+				replies = append(replies, fmt.Sprintf("下载任务已存在 %s: %s", added.TaskID, added.FileName))
+				continue
+			}
+			replies = append(replies, fmt.Sprintf("已添加下载任务 %s: %s", added.TaskID, added.FileName))
+		}
+		b.send(ctx, token, chatID, strings.Join(replies, "\n"))
+	case text == "/status":
+		b.send(ctx, token, chatID, a.telegramStatus())
+	default:
+		b.send(ctx, token, chatID, telegramHelp)
+	}
+}
+
+// addTorrentDocument 下载聊天中发送的种子文件并添加下载任务，返回回复内容
+func (b *telegramBot) addTorrentDocument(ctx context.Context, a *App, token string, msg *telegramMessage) string {
+	if msg.Document.FileSize > telegramMaxTorrentSize {
+		return "种子文件过大"
+	}
+	var file struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := b.call(ctx, token, "getFile", map[string]string{"file_id": msg.Document.FileID}, &file); err != nil {
+		return "获取种子文件失败: " + err.Error()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, telegramAPI+"/file/bot"+token+"/"+file.FilePath, nil)
+	if err != nil {
+		return "下载种子文件失败"
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "下载种子文件失败"
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, telegramMaxTorrentSize))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return "下载种子文件失败"
+	}
+
+	fileData, _ := json.Marshal(map[string]string{
+		"content":  base64.StdEncoding.EncodeToString(content),
+		"fileName": msg.Document.FileName,
+	})
+	result, err := a.DownloadTorrentFiles(string(fileData), []string{})
+	if err != nil {
+		return "添加失败: " + err.Error()
+	}
+	var added struct {
+		Status string `json:"status"`
+		TaskID string `json:"taskId"`
+	}
+	json.Unmarshal([]byte(result), &added)
+	if added.Status == "exists" {
+		return fmt.Sprintf("下载任务已存在 %s: %s", added.TaskID, msg.Document.FileName)
+	}
+	return fmt.Sprintf("已添加下载任务 %s: %s", added.TaskID, msg.Document.FileName)
+}
+
+// telegramStatus 正在进行和等待中的任务摘要
+func (a *App) telegramStatus() string {
+	var lines []string
+	for _, t := range a.tasks.Downloads() {
+		if t.Status == "downloading" || t.Status == "waiting" || t.Status == "paused" {
+			lines = append(lines, fmt.Sprintf("⬇️ %s %.1f%% %s/s [%s]", t.FileName, t.Percentage, formatBytes(t.Speed), t.Status))
+		}
+	}
+	for _, t := range a.tasks.Transcodes() {
+		if t.Status == "transcoding" || t.Status == "waiting" {
+			lines = append(lines, fmt.Sprintf("🎞️ %s %.1f%% [%s]", t.OutputFile, t.Progress*100, t.Status))
+		}
+	}
+	if len(lines) == 0 {
+		return "没有正在进行的任务"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// report 向允许的聊天报告任务完成和失败
+func (b *telegramBot) report(payload WebhookPayload) {
+	if payload.Event != WebhookTaskCompleted && payload.Event != WebhookTaskFailed {
+		return
+	}
+	b.mu.Lock()
+	token, running := b.token, b.cancel != nil
+	b.mu.Unlock()
+	if !running {
+		return
+	}
+
+	kind := "下载"
+	if payload.TaskType == "transcode" {
+		kind = "转码"
+	}
+	text := fmt.Sprintf("✅ %s完成: %s", kind, payload.Name)
+	if payload.Event == WebhookTaskFailed {
+		text = fmt.Sprintf("❌ %s失败: %s", kind, payload.Name)
+		if payload.Error != "" {
+			text += "\n" + payload.Error
+		}
+	}
+	for _, chatID := range currentSettings().TelegramChatIDs {
+		go b.send(context.Background(), token, chatID, text)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// thermalCheckInterval 读取温度和系统负载的间隔
+	thermalCheckInterval = 15 * time.Second
+	// thermalHysteresis 恢复转码前温度（℃）和系统负载（百分点）需要低于上限的幅度，避免在上限附近反复暂停和恢复
+	thermalHysteresis = 10
+	// thermalMinHold 触发后至少保持的时间：暂停转码后系统负载会立即下降，没有这个间隔会很快恢复再次触发
+	thermalMinHold = 2 * time.Minute
+	// pausedByThermal TranscodeTask.PausedBy 的值，表示转码进程因温度或系统负载过高而挂起
+	pausedByThermal = "thermal"
+
+	// 温度或负载过高时的处理方式
+	thermalActionPause   = "pause"
+	thermalActionThreads = "threads"
+
+	// 正常和降温时 CPU 编码使用的线程数，降温时只对之后启动的转码生效（ffmpeg 运行中不能修改线程数）
+	transcodeThreads        = 4
+	transcodeThreadsReduced = 2
+)
+
+// thermalReading 一次温度和系统负载读数，无法读取的项为空
+type thermalReading struct {
+	cpuTemp *float64
+	gpuTemp *float64
+	load    *float64
+}
+
+// thermalGovernor 按温度和系统负载暂停转码或减少转码线程数，降温后自动恢复
+type thermalGovernor struct {
+	mu  sync.Mutex
+	hot bool
+	// hotSince 本次触发的时间
+	hotSince time.Time
+	cancel   context.CancelFunc
+}
+
+// cpuTimesSample 上一次读取的系统CPU空闲时间和总时间，用于计算两次读取之间的CPU使用率
+var cpuTimesSample struct {
+	mu          sync.Mutex
+	idle, total time.Duration
+}
+
+// cpuTimesLoad 按与上一次读数的差值计算系统CPU使用率，第一次读取时没有可比较的读数
+func cpuTimesLoad(idle, total time.Duration) (float64, bool) {
+	cpuTimesSample.mu.Lock()
+	defer cpuTimesSample.mu.Unlock()
+	previousIdle, previousTotal := cpuTimesSample.idle, cpuTimesSample.total
+	cpuTimesSample.idle, cpuTimesSample.total = idle, total
+	if previousTotal == 0 || total <= previousTotal {
+		return 0, false
+	}
+	busy := (total - previousTotal) - (idle - previousIdle)
+	return max(0, min(100, float64(busy)/float64(total-previousTotal)*100)), true
+}
+
+// nvidiaGPUTemperature 使用 nvidia-smi 读取显卡温度，有多块显卡时取最高值
+func nvidiaGPUTemperature() (float64, error) {
+	path := nvidiaSMIPath()
+	if path == "" {
+		return 0, errNoGPUUsage
+	}
+	cmd := exec.Command(path, "--query-gpu=temperature.gpu", "--format=csv,noheader,nounits")
+	hideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+	highest := -1.0
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if value, err := strconv.ParseFloat(strings.TrimSpace(line), 64); err == nil && value > highest {
+			highest = value
+		}
+	}
+	if highest < 0 {
+		return 0, fmt.Errorf("unexpected nvidia-smi output: %q", output)
+	}
+	return highest, nil
+}
+
+// readThermal 只读取设置了上限的项：显卡温度优先使用 nvidia-smi，其次使用系统提供的读数
+func readThermal(s Settings) thermalReading {
+	var reading thermalReading
+	if s.MaxCPUTemperature > 0 || s.MaxGPUTemperature > 0 {
+		cpu, gpu := platformTemperatures()
+		if s.MaxCPUTemperature > 0 {
+			reading.cpuTemp = cpu
+		}
+		if s.MaxGPUTemperature > 0 {
+			if value, err := nvidiaGPUTemperature(); err == nil {
+				reading.gpuTemp = &value
+			} else {
+				reading.gpuTemp = gpu
+			}
+		}
+	}
+	if s.MaxSystemLoad > 0 {
+		if value, err := systemLoadPercent(); err == nil {
+			reading.load = &value
+		} else {
+			slog.Debug("读取系统负载失败", "error", err)
+		}
+	}
+	return reading
+}
+
+// exceeds 返回超过上限的读数说明，margin 为低于上限的幅度（判断是否已降温时使用 thermalHysteresis）
+func (r thermalReading) exceeds(s Settings, margin float64) string {
+	var over []string
+	check := func(name string, value *float64, limit int, unit string) {
+		if value != nil && limit > 0 && *value >= float64(limit)-margin {
+			over = append(over, fmt.Sprintf("%s %.0f%s", name, *value, unit))
+		}
+	}
+	check("CPU", r.cpuTemp, s.MaxCPUTemperature, "°C")
+	check("GPU", r.gpuTemp, s.MaxGPUTemperature, "°C")
+	check("load", r.load, s.MaxSystemLoad, "%")
+	return strings.Join(over, ", ")
+}
+
+// thermalHot 是否正处于温度或负载过高的状态
+func (a *App) thermalHot() bool {
+	a.thermal.mu.Lock()
+	defer a.thermal.mu.Unlock()
+	return a.thermal.hot
+}
+
+// transcodesPausedForThermal 温度或负载过高且设置为暂停时，挂起转码并且不启动新的转码任务
+func (a *App) transcodesPausedForThermal() bool {
+	s := currentSettings()
+	return s.ThermalGovernor && s.ThermalAction == thermalActionPause && a.thermalHot()
+}
+
+// transcodeThreadCount 返回新启动的 CPU 编码使用的线程数，温度或负载过高且设置为减少线程数时减半
+func (a *App) transcodeThreadCount() int {
+	s := currentSettings()
+	if s.ThermalGovernor && s.ThermalAction == thermalActionThreads && a.thermalHot() {
+		return transcodeThreadsReduced
+	}
+	return transcodeThreads
+}
+
+// checkThermal 读取温度和系统负载，超过上限或降温后调整转码。修改相关设置后也会调用
+func (a *App) checkThermal() {
+	s := currentSettings()
+	reading := thermalReading{}
+	if s.ThermalGovernor {
+		reading = readThermal(s)
+	}
+
+	a.thermal.mu.Lock()
+	changed := false
+	switch {
+	case !a.thermal.hot && s.ThermalGovernor:
+		if over := reading.exceeds(s, 0); over != "" {
+			slog.Warn("温度或系统负载过高，限制转码", "readings", over, "action", s.ThermalAction)
+			a.thermal.hot, a.thermal.hotSince, changed = true, time.Now(), true
+		}
+	case a.thermal.hot && !s.ThermalGovernor:
+		a.thermal.hot, changed = false, true
+	case a.thermal.hot && time.Since(a.thermal.hotSince) >= thermalMinHold:
+		if reading.exceeds(s, thermalHysteresis) == "" {
+			slog.Info("温度和系统负载已恢复正常，恢复转码")
+			a.thermal.hot, changed = false, true
+		}
+	}
+	a.thermal.mu.Unlock()
+	if changed {
+		a.applyTranscodePause()
+	}
+}
+
+// startThermalGovernor 定期检查温度和系统负载，没有开启时只在设置变化后检查
+func (a *App) startThermalGovernor() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.thermal.cancel = cancel
+	go func() {
+		ticker := time.NewTicker(thermalCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if currentSettings().ThermalGovernor {
+					a.checkThermal()
+				}
+			}
+		}
+	}()
+}
+
+// stopThermalGovernor 停止检查温度，挂起的转码进程由 stopPowerMonitor 恢复
+func (a *App) stopThermalGovernor() {
+	if a.thermal.cancel != nil {
+		a.thermal.cancel()
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// platformTemperatures macOS 读取温度传感器需要管理员权限（powermetrics），不读取
+func platformTemperatures() (cpu, gpu *float64) {
+	return nil, nil
+}
+
+// systemLoadPercent 用1分钟平均负载除以逻辑处理器数估算系统CPU使用率，最高为100
+func systemLoadPercent() (float64, error) {
+	loadavg, err := sysctlValue("vm.loadavg")
+	if err != nil {
+		return 0, err
+	}
+	// 格式为 { 1.50 1.40 1.30 }
+	fields := strings.Fields(strings.Trim(loadavg, "{} "))
+	if len(fields) == 0 {
+		return 0, errors.New("unexpected vm.loadavg output")
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	value, err := sysctlValue("hw.logicalcpu")
+	if err != nil {
+		return 0, err
+	}
+	cpus, err := strconv.Atoi(value)
+	if err != nil || cpus <= 0 {
+		return 0, errors.New("unexpected hw.logicalcpu output")
+	}
+	return min(100, load/float64(cpus)*100), nil
+}
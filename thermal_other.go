@@ -0,0 +1,108 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hwmonCPUDrivers 和 hwmonGPUDrivers 按 hwmon 驱动名称区分CPU和显卡的温度传感器
+var (
+	hwmonCPUDrivers = map[string]bool{"coretemp": true, "k10temp": true, "zenpower": true, "cpu_thermal": true, "soc_thermal": true}
+	hwmonGPUDrivers = map[string]bool{"amdgpu": true, "radeon": true, "nouveau": true}
+)
+
+// readMillidegrees 读取以千分之一摄氏度为单位的温度文件
+func readMillidegrees(path string) (float64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	return value / 1000, true
+}
+
+// platformTemperatures 从 /sys/class/hwmon 读取CPU和显卡温度，有多个传感器时取最高值；
+// 没有 hwmon 驱动的CPU（部分虚拟机和ARM设备）使用 /sys/class/thermal 中的CPU温区
+func platformTemperatures() (cpu, gpu *float64) {
+	highest := func(current *float64, value float64) *float64 {
+		if current == nil || value > *current {
+			return &value
+		}
+		return current
+	}
+	dirs, _ := filepath.Glob("/sys/class/hwmon/hwmon*")
+	for _, dir := range dirs {
+		name, err := os.ReadFile(filepath.Join(dir, "name"))
+		if err != nil {
+			continue
+		}
+		driver := strings.TrimSpace(string(name))
+		if !hwmonCPUDrivers[driver] && !hwmonGPUDrivers[driver] {
+			continue
+		}
+		inputs, _ := filepath.Glob(filepath.Join(dir, "temp*_input"))
+		for _, input := range inputs {
+			value, ok := readMillidegrees(input)
+			if !ok {
+				continue
+			}
+			if hwmonCPUDrivers[driver] {
+				cpu = highest(cpu, value)
+			} else {
+				gpu = highest(gpu, value)
+			}
+		}
+	}
+	if cpu == nil {
+		zones, _ := filepath.Glob("/sys/class/thermal/thermal_zone*")
+		for _, zone := range zones {
+			kind, err := os.ReadFile(filepath.Join(zone, "type"))
+			if err != nil {
+				continue
+			}
+			switch strings.TrimSpace(string(kind)) {
+			case "x86_pkg_temp", "cpu-thermal", "cpu_thermal", "soc-thermal":
+				if value, ok := readMillidegrees(filepath.Join(zone, "temp")); ok {
+					cpu = highest(cpu, value)
+				}
+			}
+		}
+	}
+	return cpu, gpu
+}
+
+// systemLoadPercent 按 /proc/stat 中所有CPU的时间计算与上一次读取之间的CPU使用率，iowait 计为空闲
+func systemLoadPercent() (float64, error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0, err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	// cpu user nice system idle iowait irq softirq steal，之后的 guest 已包含在 user 中
+	if len(fields) < 9 || fields[0] != "cpu" {
+		return 0, errors.New("invalid /proc/stat format")
+	}
+	var idle, total uint64
+	for i, field := range fields[1:9] {
+		value, _ := strconv.ParseUint(field, 10, 64)
+		total += value
+		if i == 3 || i == 4 {
+			idle += value
+		}
+	}
+	load, ok := cpuTimesLoad(time.Duration(idle)*time.Second/clockTicks, time.Duration(total)*time.Second/clockTicks)
+	if !ok {
+		return 0, errors.New("no previous CPU sample")
+	}
+	return load, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// thermalZoneScript 读取 ACPI 温区温度，单位为0.1开尔文；多数电脑需要管理员权限，部分主板不提供
+const thermalZoneScript = `Get-CimInstance -Namespace root/wmi -ClassName MSAcpi_ThermalZoneTemperature | ForEach-Object { $_.CurrentTemperature }`
+
+var procGetSystemTimes = kernel32.NewProc("GetSystemTimes")
+
+// thermalZoneUnavailable 读取 ACPI 温区失败后不再尝试，避免每次检查都启动 PowerShell
+var thermalZoneUnavailable atomic.Bool
+
+// platformTemperatures 使用 ACPI 温区温度作为CPU温度，有多个温区时取最高值；Windows 没有通用的显卡温度接口
+func platformTemperatures() (cpu, gpu *float64) {
+	if thermalZoneUnavailable.Load() {
+		return nil, nil
+	}
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", thermalZoneScript)
+	hideWindow(cmd)
+	output, err := cmd.Output()
+	for _, line := range strings.Fields(string(output)) {
+		value, err := strconv.ParseFloat(line, 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+		celsius := value/10 - 273.15
+		if cpu == nil || celsius > *cpu {
+			cpu = &celsius
+		}
+	}
+	if err != nil || cpu == nil {
+		thermalZoneUnavailable.Store(true)
+	}
+	return cpu, nil
+}
+
+// systemLoadPercent 使用 GetSystemTimes 计算与上一次读取之间的系统CPU使用率，内核时间已包含空闲时间
+func systemLoadPercent() (float64, error) {
+	var idle, kernel, user windows.Filetime
+	if r, _, err := procGetSystemTimes.Call(uintptr(unsafe.Pointer(&idle)), uintptr(unsafe.Pointer(&kernel)), uintptr(unsafe.Pointer(&user))); r == 0 {
+		return 0, err
+	}
+	load, ok := cpuTimesLoad(filetimeDuration(idle), filetimeDuration(kernel)+filetimeDuration(user))
+	if !ok {
+		return 0, errors.New("no previous CPU sample")
+	}
+	return load, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// thumbnailsDirName 缩略图目录名，位于数据目录中
+	thumbnailsDirName = "thumbnails"
+	// thumbnailURLPrefix 缩略图地址前缀，完整地址为 /thumbnails/<文件哈希>.jpg
+	thumbnailURLPrefix = "/thumbnails/"
+	// thumbnailWidth 缩略图宽度，高度按比例缩放
+	thumbnailWidth = 320
+	// thumbnailTimeout 生成单个缩略图的超时
+	thumbnailTimeout = time.Minute
+	// hashChunkSize 计算文件哈希时读取开头和结尾的字节数
+	hashChunkSize = 64 * 1024
+)
+
+// thumbnailNamePattern 缩略图文件名，用于检查请求地址
+var thumbnailNamePattern = regexp.MustCompile(`^[0-9a-f]{32}\.jpg$`)
+
+// thumbnailsDir 返回缩略图目录
+func thumbnailsDir() string {
+	return dataPath(thumbnailsDirName)
+}
+
+// thumbnailPath 返回文件哈希对应的缩略图路径
+func thumbnailPath(hash string) string {
+	return filepath.Join(thumbnailsDir(), hash+".jpg")
+}
+
+// thumbnailURL 返回文件哈希对应的缩略图地址，包含文件访问令牌
+func thumbnailURL(hash string) string {
+	return withFileToken(thumbnailURLPrefix + hash + ".jpg")
+}
+
+// thumbnailExists 判断缩略图是否已生成
+func thumbnailExists(hash string) bool {
+	info, err := os.Stat(thumbnailPath(hash))
+	return err == nil && info.Size() > 0
+}
+
+// fileHash 根据文件大小和开头、结尾各 64KB 计算哈希，不读取整个文件，
+// 文件移动或重命名后哈希不变，可以继续使用已生成的缩略图
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, info.Size())
+	if _, err := io.CopyN(h, f, hashChunkSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	if info.Size() > 2*hashChunkSize {
+		if _, err := f.Seek(-hashChunkSize, io.SeekEnd); err != nil {
+			return "", err
+		}
+		if _, err := io.CopyN(h, f, hashChunkSize); err != nil && err != io.EOF {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32], nil
+}
+
+// thumbnailSeek 截取画面的位置：时长的10%，跳过片头的黑屏；时长未知时使用第10秒
+func thumbnailSeek(duration float64) float64 {
+	if duration > 0 {
+		return duration / 10
+	}
+	return 10
+}
+
+// extractFrame 使用 ffmpeg 截取 seek 秒处的一帧，缩放到 thumbnailWidth 宽后保存为 JPEG；截取位置超出视频长度时返回错误
+func extractFrame(ctx context.Context, ffmpegPath, input, output string, seek float64) error {
+	cmd := exec.CommandContext(ctx, ffmpegPath, "-v", "error", "-y",
+		"-ss", strconv.FormatFloat(seek, 'f', 2, 64), "-i", input,
+		"-frames:v", "1", "-an", "-sn", "-vf", "scale="+strconv.Itoa(thumbnailWidth)+":-2", "-q:v", "4", output)
+	hideWindow(cmd)
+	out, err := cmd.CombinedOutput()
+	if info, statErr := os.Stat(output); err == nil && statErr == nil && info.Size() > 0 {
+		return nil
+	}
+	if len(out) > 0 {
+		return errors.New(strings.TrimSpace(string(out)))
+	}
+	if err == nil {
+		err = errors.New("ffmpeg did not write a frame")
+	}
+	return err
+}
+
+// generateThumbnail 使用 ffmpeg 截取一帧作为缩略图，截取位置超出视频长度时改为从开头截取
+func generateThumbnail(ffmpegPath, input, output string, duration float64) error {
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return err
+	}
+	tmp := output + ".tmp.jpg"
+	defer os.Remove(tmp)
+
+	var lastErr error
+	for _, seek := range []float64{thumbnailSeek(duration), 0} {
+		ctx, cancel := context.WithTimeout(context.Background(), thumbnailTimeout)
+		lastErr = extractFrame(ctx, ffmpegPath, input, tmp, seek)
+		cancel()
+		if lastErr == nil {
+			return os.Rename(tmp, output)
+		}
+	}
+	return lastErr
+}
+
+// ensureThumbnail 为视频生成缩略图，已生成或上次生成失败（文件变化前不再重试）时跳过，结果记录在 entry 中
+func ensureThumbnail(ffmpegPath, path string, entry *metadataEntry) {
+	if entry.Hash == "" {
+		hash, err := fileHash(path)
+		if err != nil {
+			slog.Warn("计算文件哈希失败", "path", path, "error", err)
+			return
+		}
+		entry.Hash = hash
+	}
+	if entry.ThumbnailError != "" || thumbnailExists(entry.Hash) {
+		return
+	}
+	var duration float64
+	if entry.Metadata != nil {
+		duration = entry.Metadata.Duration
+	}
+	if err := generateThumbnail(ffmpegPath, path, thumbnailPath(entry.Hash), duration); err != nil {
+		slog.Warn("生成缩略图失败", "path", path, "error", err)
+		entry.ThumbnailError = err.Error()
+	}
+}
+
+// serveThumbnail 提供缩略图，文件名是内容哈希，内容不会变化，按设置的缓存时间缓存并以文件名作为 ETag
+func serveThumbnail(w http.ResponseWriter, r *http.Request) {
+	if !requireFileToken(w, r) {
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, thumbnailURLPrefix)
+	if !thumbnailNamePattern.MatchString(name) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", staticCacheControl(true))
+	w.Header().Set("ETag", `"`+strings.TrimSuffix(name, filepath.Ext(name))+`"`)
+	http.ServeFile(w, r, filepath.Join(thumbnailsDir(), name))
+}
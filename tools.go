@@ -0,0 +1,106 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// toolsDirName 程序自带的外部工具所在的目录名
+const toolsDirName = "tools"
+
+// executableName 返回当前平台的可执行文件名，Windows 上加 .exe 后缀
+func executableName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// toolSearchDirs 返回查找外部工具的目录，按顺序为：设置中的工具目录、程序所在目录下的 tools、
+// 程序所在目录、工作目录下的 tools（wails dev 运行时程序在临时目录中）
+func toolSearchDirs() []string {
+	var dirs []string
+	if dir := currentSettings().ToolsDir; dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		dirs = append(dirs, filepath.Join(filepath.Dir(exe), toolsDirName), filepath.Dir(exe))
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, filepath.Join(cwd, toolsDirName))
+	}
+	return dirs
+}
+
+// resolveTool 查找外部工具：设置了路径时直接使用，否则在 toolSearchDirs 及其子目录 subdirs 中查找，
+// 最后在 PATH 中查找。每个目录先查找平台子目录（例如 tools/darwin-arm64），
+// 工具目录中只有其他架构的程序时优先使用 PATH 中的版本。找不到时返回 PATH 查找的错误
+func resolveTool(configured, name string, subdirs ...string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	file := executableName(name)
+	var emulated string
+	for _, dir := range toolSearchDirs() {
+		for _, base := range []string{filepath.Join(dir, platformDirName()), dir} {
+			for _, subdir := range append([]string{""}, subdirs...) {
+				path := filepath.Join(base, subdir, file)
+				if info, err := os.Stat(path); err != nil || info.IsDir() {
+					continue
+				}
+				if runsNatively(path) {
+					return path, nil
+				}
+				if emulated == "" {
+					emulated = path
+				}
+			}
+		}
+	}
+	path, err := exec.LookPath(name)
+	if err != nil && emulated != "" {
+		slog.Warn("只找到其他架构的程序，将通过模拟运行", "path", emulated, "arch", runtime.GOARCH)
+		return emulated, nil
+	}
+	return path, err
+}
+
+// torrentToolPath 返回 torrent 命令路径，未设置时查找程序自带的版本和 PATH
+func torrentToolPath() (string, error) {
+	path, err := resolveTool(currentSettings().TorrentPath, "torrent")
+	if err != nil {
+		return "", errorf(msgTorrentNotFound, err)
+	}
+	return path, nil
+}
+
+// ffprobeToolPath 返回 ffprobe 路径：设置了 ffmpeg 路径且同一目录中有 ffprobe 时使用该文件，否则与 ffmpeg 相同的方式查找
+func ffprobeToolPath() (string, error) {
+	configured := ""
+	if ffmpeg := currentSettings().FFmpegPath; ffmpeg != "" {
+		path := filepath.Join(filepath.Dir(ffmpeg), executableName("ffprobe"))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			configured = path
+		}
+	}
+	path, err := resolveTool(configured, "ffprobe", "ffmpeg", filepath.Join("ffmpeg", "bin"))
+	if err != nil {
+		return "", errorf(msgFFprobeNotFound, err)
+	}
+	return path, nil
+}
+
+// ffmpegToolPath 返回 ffmpeg 路径，未设置时查找程序自带的版本（tools/ffmpeg 或 tools/ffmpeg/bin）和 PATH
+func ffmpegToolPath() (string, error) {
+	path, err := resolveTool(currentSettings().FFmpegPath, "ffmpeg", "ffmpeg", filepath.Join("ffmpeg", "bin"))
+	if err != nil {
+		return "", errorf(msgFFmpegNotFound, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+// TorrentEdit is a set of changes to a torrent file
+// TorrentEdit 对种子的修改，没有设置的字段保持不变
+type TorrentEdit struct {
+	// 添加的 Tracker，每个 Tracker 单独一层，已存在的忽略
+	AddTrackers []string `json:"addTrackers"`
+	// 删除的 Tracker
+	RemoveTrackers []string `json:"removeTrackers"`
+	// 注释和创建工具，空字符串表示删除
+	Comment   *string `json:"comment"`
+	CreatedBy *string `json:"createdBy"`
+	// 删除 Web 种子（url-list、httpseeds）
+	StripWebSeeds bool `json:"stripWebSeeds"`
+	// 私有标记，修改后 info-hash 会改变
+	Private *bool `json:"private"`
+}
+
+// validTrackerURL 检查 Tracker 地址：支持 http、https、udp 和 wss
+func validTrackerURL(tracker string) bool {
+	u, err := url.Parse(tracker)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "udp", "ws", "wss":
+		return true
+	}
+	return false
+}
+
+// setBencodeKey 设置字典中的值，value 为 nil 时删除
+func setBencodeKey(dict map[string]bencode.Bytes, key string, value interface{}) error {
+	if value == nil {
+		delete(dict, key)
+		return nil
+	}
+	encoded, err := bencode.Marshal(value)
+	if err != nil {
+		return err
+	}
+	dict[key] = encoded
+	return nil
+}
+
+// editTrackers 按修改后的 Tracker 列表更新 announce 和 announce-list
+func editTrackers(dict map[string]bencode.Bytes, mi *metainfo.MetaInfo, edit TorrentEdit) error {
+	if len(edit.AddTrackers) == 0 && len(edit.RemoveTrackers) == 0 {
+		return nil
+	}
+	removed := make([]string, 0, len(edit.RemoveTrackers))
+	for _, tracker := range edit.RemoveTrackers {
+		removed = append(removed, strings.TrimSpace(tracker))
+	}
+	var tiers [][]string
+	var all []string
+	for _, tier := range mi.UpvertedAnnounceList() {
+		var kept []string
+		for _, tracker := range tier {
+			if !slices.Contains(removed, tracker) && !slices.Contains(all, tracker) {
+				kept = append(kept, tracker)
+				all = append(all, tracker)
+			}
+		}
+		if len(kept) > 0 {
+			tiers = append(tiers, kept)
+		}
+	}
+	for _, tracker := range edit.AddTrackers {
+		tracker = strings.TrimSpace(tracker)
+		if tracker == "" || slices.Contains(all, tracker) {
+			continue
+		}
+		if !validTrackerURL(tracker) {
+			return errorf(msgInvalidTracker, tracker)
+		}
+		tiers = append(tiers, []string{tracker})
+		all = append(all, tracker)
+	}
+
+	if len(tiers) == 0 {
+		// 没有 Tracker 的种子只能通过 DHT 下载
+		delete(dict, "announce")
+		delete(dict, "announce-list")
+		return nil
+	}
+	if err := setBencodeKey(dict, "announce", tiers[0][0]); err != nil {
+		return err
+	}
+	return setBencodeKey(dict, "announce-list", tiers)
+}
+
+// editPrivate 修改 info 字典中的私有标记；其他字段保持原始字节，未修改时 info-hash 不变
+func editPrivate(dict map[string]bencode.Bytes, private bool) error {
+	var info map[string]bencode.Bytes
+	if err := bencode.Unmarshal(dict["info"], &info); err != nil {
+		return err
+	}
+	var value interface{}
+	if private {
+		value = 1
+	}
+	if err := setBencodeKey(info, "private", value); err != nil {
+		return err
+	}
+	return setBencodeKey(dict, "info", info)
+}
+
+// editTorrent 修改种子并重新编码。按字典逐个字段修改，未知的字段（例如 PT 站添加的字段）保留原样
+func editTorrent(data []byte, edit TorrentEdit) ([]byte, error) {
+	mi, err := metainfo.Load(bytes.NewReader(data))
+	if err != nil {
+		return nil, errorf(msgNotTorrentFile, err)
+	}
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return nil, errorf(msgNotTorrentFile, err)
+	}
+	var dict map[string]bencode.Bytes
+	if err := bencode.Unmarshal(data, &dict); err != nil {
+		return nil, errorf(msgNotTorrentFile, err)
+	}
+
+	if err := editTrackers(dict, mi, edit); err != nil {
+		return nil, err
+	}
+	optional := func(value *string) interface{} {
+		if strings.TrimSpace(*value) == "" {
+			return nil
+		}
+		return strings.TrimSpace(*value)
+	}
+	if edit.Comment != nil {
+		if err := setBencodeKey(dict, "comment", optional(edit.Comment)); err != nil {
+			return nil, err
+		}
+		delete(dict, "comment.utf-8")
+	}
+	if edit.CreatedBy != nil {
+		if err := setBencodeKey(dict, "created by", optional(edit.CreatedBy)); err != nil {
+			return nil, err
+		}
+	}
+	if edit.StripWebSeeds {
+		delete(dict, "url-list")
+		delete(dict, "httpseeds")
+	}
+	if edit.Private != nil {
+		current := info.Private != nil && *info.Private
+		if *edit.Private != current {
+			if err := editPrivate(dict, *edit.Private); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return bencode.Marshal(dict)
+}
+
+// EditTorrentFile modifies a torrent and returns the new torrent file
+// EditTorrentFile 修改种子（添加或删除 Tracker、修改注释和创建工具、删除 Web 种子、切换私有标记）并生成新的种子文件。
+// fileData 与 ParseTorrentFile 相同，editData 为 TorrentEdit 的JSON；返回新种子的解析结果，
+// content 为 Base64 编码的新种子内容。修改私有标记会改变 info-hash，infoHashChanged 为 true
+func (a *App) EditTorrentFile(fileData string, editData string) (string, error) {
+	var req struct {
+		Content  string `json:"content"`
+		FileName string `json:"fileName"`
+	}
+	if err := json.Unmarshal([]byte(fileData), &req); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		return "", err
+	}
+	var edit TorrentEdit
+	if err := json.Unmarshal([]byte(editData), &edit); err != nil {
+		return "", err
+	}
+
+	original, err := parseTorrentData(data, req.FileName)
+	if err != nil {
+		return "", errorf(msgNotTorrentFile, err)
+	}
+	edited, err := editTorrent(data, edit)
+	if err != nil {
+		slog.Warn("修改种子失败", "fileName", req.FileName, "error", err)
+		return "", err
+	}
+	fileName := strings.TrimSuffix(req.FileName, filepath.Ext(req.FileName)) + ".edited.torrent"
+	torrentInfo, err := parseTorrentData(edited, fileName)
+	if err != nil {
+		return "", err
+	}
+	infoHashChanged := torrentInfo.Metadata.InfoHash != original.Metadata.InfoHash
+	slog.Info("已修改种子", "fileName", req.FileName, "infoHash", torrentInfo.Metadata.InfoHash, "infoHashChanged", infoHashChanged)
+
+	// 构建响应
+	response := struct {
+		TorrentInfoResponse
+		Status          string `json:"status"`
+		Content         string `json:"content"`
+		InfoHashChanged bool   `json:"infoHashChanged"`
+	}{
+		TorrentInfoResponse: torrentInfo,
+		Status:              "success",
+		Content:             base64.StdEncoding.EncodeToString(edited),
+		InfoHashChanged:     infoHashChanged,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
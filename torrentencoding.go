@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// torrentEncodings 支持的种子文件名编码，自动识别时按此顺序尝试，得分相同时靠前的优先
+var torrentEncodings = []struct {
+	name     string
+	encoding encoding.Encoding
+}{
+	{"gbk", simplifiedchinese.GB18030},
+	{"shift_jis", japanese.ShiftJIS},
+	{"big5", traditionalchinese.Big5},
+	{"euc-kr", korean.EUCKR},
+	{"euc-jp", japanese.EUCJP},
+}
+
+// torrentEncodingAliases 种子 encoding 字段中常见的编码名称
+var torrentEncodingAliases = map[string]string{
+	"gbk":       "gbk",
+	"gb2312":    "gbk",
+	"gb18030":   "gbk",
+	"cp936":     "gbk",
+	"shift_jis": "shift_jis",
+	"shift-jis": "shift_jis",
+	"sjis":      "shift_jis",
+	"cp932":     "shift_jis",
+	"big5":      "big5",
+	"cp950":     "big5",
+	"euc-kr":    "euc-kr",
+	"cp949":     "euc-kr",
+	"euc-jp":    "euc-jp",
+}
+
+// lookupTorrentEncoding 按名称返回编码，不支持的名称返回 nil
+func lookupTorrentEncoding(name string) encoding.Encoding {
+	name = torrentEncodingAliases[strings.ToLower(strings.TrimSpace(name))]
+	for _, candidate := range torrentEncodings {
+		if candidate.name == name {
+			return candidate.encoding
+		}
+	}
+	return nil
+}
+
+// decodeTorrentString 用指定编码解码文件名，无法解码（出现替换字符）时返回 false
+func decodeTorrentString(enc encoding.Encoding, s string) (string, bool) {
+	decoded, err := enc.NewDecoder().String(s)
+	if err != nil || strings.ContainsRune(decoded, utf8.RuneError) || !utf8.ValidString(decoded) {
+		return "", false
+	}
+	return decoded, true
+}
+
+// textScore 评估解码结果是否像正常的文字：ASCII、常用汉字、假名、谚文和全角标点得分，
+// 半角片假名、私用区和罕见字符扣分（用错误的编码解码通常会得到这些字符）
+func textScore(s string) int {
+	score := 0
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			if unicode.IsPrint(r) {
+				score++
+			} else {
+				score -= 2
+			}
+		case r >= 0x4E00 && r <= 0x9FFF, // 中日韩统一表意文字
+			r >= 0x3040 && r <= 0x30FF, // 平假名、片假名
+			r >= 0xAC00 && r <= 0xD7AF, // 谚文音节
+			r >= 0x3000 && r <= 0x303F, // 中日韩标点
+			r >= 0xFF01 && r <= 0xFF5E: // 全角 ASCII
+			score += 2
+		case r >= 0xFF61 && r <= 0xFF9F, // 半角片假名
+			r >= 0xE000 && r <= 0xF8FF, // 私用区
+			r >= 0x3400 && r <= 0x4DBF, // 扩展A区的罕见汉字
+			unicode.IsControl(r):
+			score -= 3
+		}
+	}
+	return score
+}
+
+// gbkRareHanzi 统计 GB2312 以外的汉字（GBK 扩展的繁体字和罕见字）：简体中文的文件名几乎只使用 GB2312 中的字，
+// 用 GBK 解码 Shift-JIS、Big5 的文件名时通常得到这些字
+func gbkRareHanzi(s string) int {
+	encoder := simplifiedchinese.GBK.NewEncoder()
+	count := 0
+	for _, r := range s {
+		if r < 0x4E00 || r > 0x9FFF {
+			continue
+		}
+		// GB2312 的汉字区：第一个字节 0xB0-0xF7，第二个字节 0xA1-0xFE
+		b, err := encoder.Bytes([]byte(string(r)))
+		if err != nil || len(b) != 2 || b[0] < 0xB0 || b[0] > 0xF7 || b[1] < 0xA1 {
+			count++
+		}
+	}
+	return count
+}
+
+// torrentRawNames 返回种子中没有 UTF-8 版本（name.utf-8、path.utf-8）且不是有效 UTF-8 的名称和路径
+func torrentRawNames(info *metainfo.Info) []string {
+	var names []string
+	if info.NameUtf8 == "" && !utf8.ValidString(info.Name) {
+		names = append(names, info.Name)
+	}
+	for _, f := range info.Files {
+		if len(f.PathUtf8) > 0 {
+			continue
+		}
+		for _, part := range f.Path {
+			if !utf8.ValidString(part) {
+				names = append(names, part)
+			}
+		}
+	}
+	return names
+}
+
+// detectTorrentEncoding 识别种子文件名的编码：老的中文、日文客户端直接使用系统编码（GBK、Shift-JIS 等）保存文件名。
+// 文件名都是 UTF-8（或有 UTF-8 版本）时返回空字符串；优先使用种子 encoding 字段声明的编码，
+// 否则用每种编码解码所有文件名，选择能全部解码且最像正常文字的编码
+func detectTorrentEncoding(mi *metainfo.MetaInfo, info *metainfo.Info) string {
+	names := torrentRawNames(info)
+	if len(names) == 0 {
+		return ""
+	}
+	decodesAll := func(encName string, enc encoding.Encoding) (int, bool) {
+		score := 0
+		for _, name := range names {
+			decoded, ok := decodeTorrentString(enc, name)
+			if !ok {
+				return 0, false
+			}
+			score += textScore(decoded)
+			// 按 GBK 解码得到的 GB2312 以外的汉字不得分
+			if encName == "gbk" {
+				score -= 2 * gbkRareHanzi(decoded)
+			}
+		}
+		return score, true
+	}
+	if declared := lookupTorrentEncoding(mi.Encoding); declared != nil {
+		if _, ok := decodesAll("", declared); ok {
+			return torrentEncodingAliases[strings.ToLower(strings.TrimSpace(mi.Encoding))]
+		}
+	}
+	best, bestScore := "", 0
+	for _, candidate := range torrentEncodings {
+		score, ok := decodesAll(candidate.name, candidate.encoding)
+		if ok && (best == "" || score > bestScore) {
+			best, bestScore = candidate.name, score
+		}
+	}
+	return best
+}
+
+// torrentDisplayName 返回名称的 UTF-8 形式：有 UTF-8 版本时使用 UTF-8 版本，否则按编码解码，
+// 仍然无法解码时替换无效的字节
+func torrentDisplayName(raw, utf8Name string, enc encoding.Encoding) string {
+	if utf8Name != "" {
+		return utf8Name
+	}
+	if utf8.ValidString(raw) {
+		return raw
+	}
+	if enc != nil {
+		if decoded, ok := decodeTorrentString(enc, raw); ok {
+			return decoded
+		}
+	}
+	return strings.ToValidUTF8(raw, "\uFFFD")
+}
+
+// normalizeTorrentNames 将种子中的名称和路径改为 UTF-8，返回识别到的编码（文件名已是 UTF-8 时为空）。
+// 修改的是解析得到的 info，不影响种子的 info-hash
+func normalizeTorrentNames(mi *metainfo.MetaInfo, info *metainfo.Info) string {
+	name := detectTorrentEncoding(mi, info)
+	var enc encoding.Encoding
+	if name != "" {
+		enc = lookupTorrentEncoding(name)
+		slog.Info("种子文件名不是 UTF-8，按识别到的编码转换", "encoding", name, "declared", mi.Encoding)
+	}
+	info.Name = torrentDisplayName(info.Name, info.NameUtf8, enc)
+	info.NameUtf8 = ""
+	for i := range info.Files {
+		f := &info.Files[i]
+		if len(f.PathUtf8) > 0 {
+			f.Path, f.PathUtf8 = f.PathUtf8, nil
+			continue
+		}
+		for j, part := range f.Path {
+			f.Path[j] = torrentDisplayName(part, "", enc)
+		}
+	}
+	return name
+}
+
+// torrentsDir 保存需要修正文件名的下载任务的种子，下载完成后删除
+func torrentsDir() string {
+	return dataPath("torrents")
+}
+
+// saveTaskTorrent 保存下载任务的种子，下载完成后用于修正文件名
+func saveTaskTorrent(taskID string, data []byte) error {
+	if err := os.MkdirAll(torrentsDir(), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(torrentsDir(), taskID+".torrent"), data, 0644)
+}
+
+// rememberNameEncoding 种子的文件名不是 UTF-8 时保存种子并在任务中记录编码，torrent 命令会按原始字节保存文件，
+// 下载完成后由 fixDownloadNames 改为正确的名称
+func (a *App) rememberNameEncoding(taskID string, data []byte) {
+	mi, err := metainfo.Load(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return
+	}
+	name := detectTorrentEncoding(mi, &info)
+	if name == "" {
+		return
+	}
+	if err := saveTaskTorrent(taskID, data); err != nil {
+		slog.Warn("保存任务种子失败，下载完成后无法修正文件名", "taskId", taskID, "error", err)
+		return
+	}
+	a.tasks.UpdateDownload(taskID, func(t *DownloadTask) {
+		t.NameEncoding = name
+	})
+	slog.Info("种子文件名不是 UTF-8，下载完成后修正文件名", "taskId", taskID, "encoding", name)
+}
+
+// onDiskNames 返回 torrent 命令保存的名称的可能形式：Linux 上为原始字节；Windows 上每个无效的字节变为 U+FFFD
+func onDiskNames(raw string) []string {
+	names := []string{raw}
+	if lossy := string([]rune(raw)); lossy != raw {
+		names = append(names, lossy)
+	}
+	return names
+}
+
+// renameRawPath 将 dir 下按原始编码保存的路径逐级改为解码后的名称，已改过的部分（例如同一目录中的其他文件）跳过
+func renameRawPath(dir string, raw, decoded []string) {
+	for i := range raw {
+		target := filepath.Join(dir, decoded[i])
+		if _, err := os.Lstat(target); err != nil {
+			for _, name := range onDiskNames(raw[i]) {
+				source := filepath.Join(dir, name)
+				if _, err := os.Lstat(source); err != nil {
+					continue
+				}
+				if err := os.Rename(source, target); err != nil {
+					slog.Warn("修正文件名失败", "path", source, "target", target, "error", err)
+					return
+				}
+				break
+			}
+		}
+		dir = target
+	}
+}
+
+// fixDownloadNames 下载完成后将按 GBK、Shift-JIS 等编码保存的乱码文件名改为正确的名称，
+// 只处理添加时识别到编码并保存了种子的任务
+func (a *App) fixDownloadNames(task DownloadTask) {
+	if task.NameEncoding == "" {
+		return
+	}
+	path := filepath.Join(torrentsDir(), task.TaskID+".torrent")
+	defer os.Remove(path)
+	mi, err := metainfo.LoadFromFile(path)
+	if err != nil {
+		slog.Warn("读取任务种子失败，无法修正文件名", "taskId", task.TaskID, "error", err)
+		return
+	}
+	raw, err := mi.UnmarshalInfo()
+	if err != nil {
+		slog.Warn("解析任务种子失败，无法修正文件名", "taskId", task.TaskID, "error", err)
+		return
+	}
+	decoded, _ := mi.UnmarshalInfo()
+	normalizeTorrentNames(mi, &decoded)
+
+	// torrent 命令优先使用 UTF-8 版本的名称，有 UTF-8 版本的不需要修正
+	rawRoot := raw.Name
+	if raw.NameUtf8 != "" {
+		rawRoot = raw.NameUtf8
+	}
+	if !raw.IsDir() {
+		renameRawPath(task.OutputDir, []string{rawRoot}, []string{decoded.Name})
+	} else {
+		for i, f := range raw.Files {
+			if isPaddingFile(f) {
+				continue
+			}
+			rawPath := f.Path
+			if len(f.PathUtf8) > 0 {
+				rawPath = f.PathUtf8
+			}
+			renameRawPath(task.OutputDir, append([]string{rawRoot}, rawPath...), append([]string{decoded.Name}, decoded.Files[i].Path...))
+		}
+	}
+	slog.Info("已修正下载的文件名", "taskId", task.TaskID, "encoding", task.NameEncoding, "name", decoded.Name)
+	a.library.invalidate(libraryRootDownload)
+}
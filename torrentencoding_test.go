@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+// 老客户端按系统编码保存的种子名称和文件名
+var torrentEncodingFixtures = []struct {
+	name     string
+	encoding string
+	rawName  string
+	rawFile  string
+	wantName string
+	wantFile string
+}{
+	{"GBK", "gbk", "\xb5\xe7\xd3\xb0\xba\xcf\xbc\xaf", "\xb5\xda\x30\x31\xbc\xaf.mkv", "电影合集", "第01集.mkv"},
+	{"Big5", "big5", "\xb9\x71\xbc\x76\xa6\x58\xb6\xb0", "\xb2\xc4\x30\x31\xb6\xb0.mkv", "電影合集", "第01集.mkv"},
+	{"Shift-JIS", "shift_jis", "\x89\x66\x89\xe6\x82\xcc\x83\x5e\x83\x43\x83\x67\x83\x8b", "\x91\xe6\x30\x31\x98\x62.mkv", "映画のタイトル", "第01話.mkv"},
+}
+
+func fixtureInfo(name, file string) metainfo.Info {
+	return metainfo.Info{Name: name, Files: []metainfo.FileInfo{{Path: []string{file}, Length: 1}}}
+}
+
+func TestDetectTorrentEncoding(t *testing.T) {
+	for _, tt := range torrentEncodingFixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			info := fixtureInfo(tt.rawName, tt.rawFile)
+			if got := detectTorrentEncoding(&metainfo.MetaInfo{}, &info); got != tt.encoding {
+				t.Fatalf("识别的编码为 %q，应为 %q", got, tt.encoding)
+			}
+		})
+	}
+
+	// 优先使用种子声明的编码
+	big5 := torrentEncodingFixtures[1]
+	info := fixtureInfo(big5.rawName, big5.rawFile)
+	if got := detectTorrentEncoding(&metainfo.MetaInfo{Encoding: "CP950"}, &info); got != "big5" {
+		t.Fatalf("声明为 CP950 时识别的编码为 %q，应为 big5", got)
+	}
+
+	// 有 UTF-8 版本或本身就是 UTF-8 时不需要转换
+	info = fixtureInfo("电影合集", "第01集.mkv")
+	if got := detectTorrentEncoding(&metainfo.MetaInfo{Encoding: "GBK"}, &info); got != "" {
+		t.Fatalf("UTF-8 的名称识别为 %q", got)
+	}
+	gbk := torrentEncodingFixtures[0]
+	info = fixtureInfo(gbk.rawName, gbk.rawFile)
+	info.NameUtf8 = gbk.wantName
+	info.Files[0].PathUtf8 = []string{gbk.wantFile}
+	if got := detectTorrentEncoding(&metainfo.MetaInfo{}, &info); got != "" {
+		t.Fatalf("有 UTF-8 版本的名称识别为 %q", got)
+	}
+}
+
+func TestNormalizeTorrentNames(t *testing.T) {
+	for _, tt := range torrentEncodingFixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			info := fixtureInfo(tt.rawName, tt.rawFile)
+			if got := normalizeTorrentNames(&metainfo.MetaInfo{}, &info); got != tt.encoding {
+				t.Fatalf("返回的编码为 %q，应为 %q", got, tt.encoding)
+			}
+			if info.Name != tt.wantName || info.Files[0].Path[0] != tt.wantFile {
+				t.Fatalf("转换后的名称为 %q、%q，应为 %q、%q", info.Name, info.Files[0].Path[0], tt.wantName, tt.wantFile)
+			}
+		})
+	}
+
+	t.Run("UTF-8 不变", func(t *testing.T) {
+		info := fixtureInfo("Movie 电影", "Movie 电影.mkv")
+		if got := normalizeTorrentNames(&metainfo.MetaInfo{}, &info); got != "" {
+			t.Fatalf("UTF-8 的名称返回编码 %q", got)
+		}
+		if info.Name != "Movie 电影" || info.Files[0].Path[0] != "Movie 电影.mkv" {
+			t.Fatalf("UTF-8 的名称被修改: %q、%q", info.Name, info.Files[0].Path[0])
+		}
+	})
+
+	t.Run("使用 UTF-8 版本", func(t *testing.T) {
+		gbk := torrentEncodingFixtures[0]
+		info := fixtureInfo(gbk.rawName, gbk.rawFile)
+		info.NameUtf8 = "UTF-8 名称"
+		info.Files[0].PathUtf8 = []string{"UTF-8 文件.mkv"}
+		normalizeTorrentNames(&metainfo.MetaInfo{}, &info)
+		if info.Name != "UTF-8 名称" || info.NameUtf8 != "" || info.Files[0].Path[0] != "UTF-8 文件.mkv" || info.Files[0].PathUtf8 != nil {
+			t.Fatalf("没有使用 UTF-8 版本: %+v", info)
+		}
+	})
+}
+
+func TestTorrentDisplayNameInvalid(t *testing.T) {
+	// 无法解码时替换无效的字节
+	if got := torrentDisplayName("a\xffb", "", nil); got != "a�b" {
+		t.Fatalf("torrentDisplayName 返回 %q", got)
+	}
+	if got := torrentDisplayName("\x81", "", lookupTorrentEncoding("shift_jis")); got != "�" {
+		t.Fatalf("不完整的 Shift-JIS 字节返回 %q", got)
+	}
+}
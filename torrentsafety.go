@@ -0,0 +1,80 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// 种子中可疑文件的原因
+const (
+	// dangerExecutable 可执行文件或安装包
+	dangerExecutable = "executable"
+	// dangerScript 脚本，双击即可运行
+	dangerScript = "script"
+	// dangerDoubleExtension 伪装成视频、图片或文档的可执行文件，例如 movie.mp4.exe
+	dangerDoubleExtension = "doubleExtension"
+	// dangerSpoofedName 文件名包含从右到左覆盖字符，显示的扩展名与实际不同
+	dangerSpoofedName = "spoofedName"
+)
+
+// executableExtensions 可执行文件和安装包的扩展名
+var executableExtensions = map[string]bool{
+	".exe": true,
+	".scr": true,
+	".com": true,
+	".pif": true,
+	".msi": true,
+	".dll": true,
+	".cpl": true,
+	".lnk": true,
+	".jar": true,
+	".apk": true,
+	".dmg": true,
+	".pkg": true,
+	".app": true,
+}
+
+// scriptExtensions 脚本文件扩展名
+var scriptExtensions = map[string]bool{
+	".bat": true,
+	".cmd": true,
+	".ps1": true,
+	".vbs": true,
+	".vbe": true,
+	".js":  true,
+	".jse": true,
+	".wsf": true,
+	".hta": true,
+	".reg": true,
+	".sh":  true,
+}
+
+// disguiseExtensions 可执行文件常伪装成的扩展名
+var disguiseExtensions = map[string]bool{
+	".pdf":  true,
+	".doc":  true,
+	".docx": true,
+	".txt":  true,
+	".nfo":  true,
+}
+
+// fileDanger 判断文件是否可疑，返回原因；正常文件返回空字符串
+func fileDanger(name string) string {
+	// U+202E 让 "movie\u202e4pm.exe" 显示为 "movieexe.mp4"；其他双向控制字符也可以用来打乱显示顺序
+	if strings.ContainsAny(name, "\u202e\u202d\u2066\u2067\u2068") {
+		return dangerSpoofedName
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if !executableExtensions[ext] && !scriptExtensions[ext] {
+		return ""
+	}
+	// 去掉扩展名后再看一次，用空格把真正的扩展名挤出显示范围的也能识别
+	inner := strings.ToLower(filepath.Ext(strings.TrimSpace(strings.TrimSuffix(name, filepath.Ext(name)))))
+	if videoExtensions[inner] || audioExtensions[inner] || imageExtensions[inner] || subtitleExtensions[inner] || disguiseExtensions[inner] {
+		return dangerDoubleExtension
+	}
+	if scriptExtensions[ext] {
+		return dangerScript
+	}
+	return dangerExecutable
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// 种子中文件的类型
+const (
+	fileTypeVideo   = "video"
+	fileTypeAudio   = "audio"
+	fileTypeImage   = "image"
+	fileTypeArchive = "archive"
+	fileTypeOther   = "other"
+)
+
+// audioExtensions 音频文件扩展名，包括音乐专辑常见的无损格式
+var audioExtensions = map[string]bool{
+	".mp3":  true,
+	".flac": true,
+	".ape":  true,
+	".wav":  true,
+	".aac":  true,
+	".m4a":  true,
+	".ogg":  true,
+	".opus": true,
+	".wma":  true,
+	".dts":  true,
+	".ac3":  true,
+	".dsf":  true,
+}
+
+// imageExtensions 图片文件扩展名
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+	".bmp":  true,
+	".tif":  true,
+	".tiff": true,
+	".heic": true,
+}
+
+// FileTypeStats is the count and total size of files of one type
+// FileTypeStats 一种类型的文件数和总大小
+type FileTypeStats struct {
+	Count int   `json:"count"`
+	Size  int64 `json:"size"`
+}
+
+// TorrentFileStats is the file-type breakdown of a torrent
+// TorrentFileStats 种子中各类型文件的统计；没有视频和音频时 hasPlayableMedia 为 false，有可疑文件时 dangerous 不为0，前端据此提示
+type TorrentFileStats struct {
+	Video            FileTypeStats `json:"video"`
+	Audio            FileTypeStats `json:"audio"`
+	Image            FileTypeStats `json:"image"`
+	Archive          FileTypeStats `json:"archive"`
+	Other            FileTypeStats `json:"other"`
+	HasPlayableMedia bool          `json:"hasPlayableMedia"`
+	// 可执行文件、脚本和伪装扩展名的文件，不为0时前端显示警告
+	Dangerous FileTypeStats `json:"dangerous"`
+}
+
+// fileTypeOf 按扩展名返回文件类型，分卷压缩包（.part01.rar、.001 等）也算作压缩包
+func fileTypeOf(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch {
+	case videoExtensions[ext]:
+		return fileTypeVideo
+	case audioExtensions[ext]:
+		return fileTypeAudio
+	case imageExtensions[ext]:
+		return fileTypeImage
+	}
+	if archive, _ := archiveVolume(name); archive {
+		return fileTypeArchive
+	}
+	return fileTypeOther
+}
+
+// torrentFileStats 按类型统计种子中的文件
+func torrentFileStats(files []FileInfo) TorrentFileStats {
+	var stats TorrentFileStats
+	for _, file := range files {
+		var typeStats *FileTypeStats
+		switch file.Type {
+		case fileTypeVideo:
+			typeStats = &stats.Video
+		case fileTypeAudio:
+			typeStats = &stats.Audio
+		case fileTypeImage:
+			typeStats = &stats.Image
+		case fileTypeArchive:
+			typeStats = &stats.Archive
+		default:
+			typeStats = &stats.Other
+		}
+		typeStats.Count++
+		typeStats.Size += file.Size
+		if file.Danger != "" {
+			stats.Dangerous.Count++
+			stats.Dangerous.Size += file.Size
+		}
+	}
+	stats.HasPlayableMedia = stats.Video.Count > 0 || stats.Audio.Count > 0
+	return stats
+}
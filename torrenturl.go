@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// torrentURLMaxSize 从网址下载的种子文件大小上限，超过时多半不是种子文件
+	torrentURLMaxSize = 10 * 1024 * 1024
+	// torrentURLTimeout 下载种子文件的超时，包括连接、重定向和读取
+	torrentURLTimeout = 30 * time.Second
+)
+
+// torrentURLFileName 返回种子的文件名：优先使用 Content-Disposition 中的文件名，其次使用网址路径的最后一部分
+func torrentURLFileName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(strings.ReplaceAll(params["filename"], "\\", "/")); name != "" && name != "." && name != "/" {
+			return name
+		}
+	}
+	name := path.Base(resp.Request.URL.Path)
+	if name == "" || name == "." || name == "/" {
+		name = resp.Request.URL.Hostname()
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".torrent") {
+		name += ".torrent"
+	}
+	return name
+}
+
+// fetchTorrentURL 通过 HTTP 下载种子文件，限制大小和时间；返回内容和文件名
+func fetchTorrentURL(ctx context.Context, rawURL string) ([]byte, string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, "", errorf(msgInvalidTorrentURL, rawURL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, torrentURLTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", errorf(msgInvalidTorrentURL, rawURL)
+	}
+	req.Header.Set("User-Agent", "SeedParser/"+appVersion)
+	req.Header.Set("Accept", "application/x-bittorrent, */*")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", errorf(msgFetchTorrentFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", errorf(msgFetchTorrentFailed, fmt.Errorf("HTTP %d", resp.StatusCode))
+	}
+	if resp.ContentLength > torrentURLMaxSize {
+		return nil, "", errorf(msgTorrentTooLarge, torrentURLMaxSize/1024/1024)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, torrentURLMaxSize+1))
+	if err != nil {
+		return nil, "", errorf(msgFetchTorrentFailed, err)
+	}
+	if len(data) > torrentURLMaxSize {
+		return nil, "", errorf(msgTorrentTooLarge, torrentURLMaxSize/1024/1024)
+	}
+	// 种子文件是 bencode 字典，以 d 开头；需要登录的站点通常返回 HTML 页面
+	if len(data) == 0 || data[0] != 'd' {
+		return nil, "", errorf(msgNotTorrentFile, resp.Header.Get("Content-Type"))
+	}
+	return data, torrentURLFileName(resp), nil
+}
+
+// ParseTorrentURL downloads a torrent file from a URL and parses it
+// ParseTorrentURL 从网址下载种子文件（最大10MB，超时30秒）并解析，返回与 ParseTorrentFile 相同的信息；
+// content 为 Base64 编码的种子内容，可以直接传给 DownloadTorrentFiles 添加下载
+func (a *App) ParseTorrentURL(rawURL string) (string, error) {
+	data, fileName, err := fetchTorrentURL(context.Background(), rawURL)
+	if err != nil {
+		slog.Warn("下载种子文件失败", "url", rawURL, "error", err)
+		return "", err
+	}
+	torrentInfo, err := parseTorrentData(data, fileName)
+	if err != nil {
+		return "", errorf(msgNotTorrentFile, err)
+	}
+	slog.Info("已从网址解析种子", "url", rawURL, "fileName", fileName, "files", len(torrentInfo.Files))
+
+	// 构建响应
+	response := struct {
+		TorrentInfoResponse
+		Status  string `json:"status"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	}{
+		TorrentInfoResponse: torrentInfo,
+		Status:              "success",
+		URL:                 rawURL,
+		Content:             base64.StdEncoding.EncodeToString(data),
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+// EventTorrentVerifyProgress 按种子校验本地文件的进度，负载为 {checkedPieces, totalPieces, goodPieces, percent}，
+// 按 progressEventInterval 节流
+const EventTorrentVerifyProgress = "torrent:verify"
+
+// VerifiedFile is the verification result of one file in a torrent
+// VerifiedFile 种子中一个文件的校验结果
+type VerifiedFile struct {
+	Index int    `json:"index"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	// 本地文件是否存在，大小不同时 sizeMismatch 为 true
+	Exists       bool  `json:"exists"`
+	SizeMismatch bool  `json:"sizeMismatch,omitempty"`
+	GoodBytes    int64 `json:"goodBytes"`
+	// 完成度（0-100），按校验通过的分块覆盖的字节计算
+	Percent float64 `json:"percent"`
+}
+
+// TorrentVerifyResult is the result of verifying local data against a torrent
+// TorrentVerifyResult 按种子校验本地文件的结果
+type TorrentVerifyResult struct {
+	// 种子内容在本地的路径：单文件种子为文件，多文件种子为文件夹
+	Root        string         `json:"root"`
+	TotalPieces int            `json:"totalPieces"`
+	GoodPieces  int            `json:"goodPieces"`
+	TotalBytes  int64          `json:"totalBytes"`
+	GoodBytes   int64          `json:"goodBytes"`
+	Percent     float64        `json:"percent"`
+	Complete    bool           `json:"complete"`
+	Files       []VerifiedFile `json:"files"`
+	Elapsed     float64        `json:"elapsed"`
+}
+
+// torrentVerifier 同一时间只运行一次校验
+type torrentVerifier struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// verifyFile 种子中的文件在数据流中的位置；填充文件不在磁盘上，内容全部为0
+type verifyFile struct {
+	path    string
+	offset  int64
+	length  int64
+	padding bool
+	file    *os.File
+	// 文件不存在或大小不同时，涉及的分块都算作失败
+	missing bool
+}
+
+// resolveTorrentRoot 返回种子内容在本地的路径：localPath 可以是种子内容本身（文件或文件夹），
+// 也可以是包含种子内容的下载目录
+func resolveTorrentRoot(info *metainfo.Info, localPath string) (string, error) {
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return "", errorf(msgFileNotFound, localPath)
+	}
+	if !stat.IsDir() {
+		if info.IsDir() {
+			return "", errorf(msgFileNotFound, filepath.Join(localPath, info.Name))
+		}
+		return localPath, nil
+	}
+	if _, err := os.Stat(filepath.Join(localPath, info.Name)); err == nil {
+		return filepath.Join(localPath, info.Name), nil
+	}
+	if info.IsDir() {
+		return localPath, nil
+	}
+	return "", errorf(msgFileNotFound, filepath.Join(localPath, info.Name))
+}
+
+// readVerifyRange 读取数据流中 [offset, offset+len(buf)) 的内容，返回是否所有涉及的文件都存在且读取成功
+func readVerifyRange(files []*verifyFile, offset int64, buf []byte) bool {
+	ok := true
+	end := offset + int64(len(buf))
+	for _, f := range files {
+		if f.offset+f.length <= offset || f.offset >= end {
+			continue
+		}
+		start := max(offset, f.offset)
+		stop := min(end, f.offset+f.length)
+		part := buf[start-offset : stop-offset]
+		switch {
+		case f.padding:
+			clear(part)
+		case f.missing:
+			ok = false
+		default:
+			if _, err := f.file.ReadAt(part, start-f.offset); err != nil && !errors.Is(err, io.EOF) {
+				ok = false
+			}
+		}
+	}
+	return ok
+}
+
+// verifyTorrentData 逐块计算 SHA-1 并与种子中的哈希比较，progress 在每个分块校验后调用
+func verifyTorrentData(ctx context.Context, info *metainfo.Info, root string, progress func(checked, good int)) (TorrentVerifyResult, error) {
+	result := TorrentVerifyResult{Root: root, TotalPieces: info.NumPieces(), Files: []VerifiedFile{}}
+
+	var files []*verifyFile
+	var offset int64
+	for i, f := range info.UpvertedFiles() {
+		vf := &verifyFile{offset: offset, length: f.Length, padding: isPaddingFile(f)}
+		offset += f.Length
+		if vf.padding {
+			files = append(files, vf)
+			continue
+		}
+		vf.path = root
+		if info.IsDir() {
+			vf.path = filepath.Join(append([]string{root}, f.BestPath()...)...)
+		}
+		verified := VerifiedFile{Index: i, Path: filepath.ToSlash(filepath.Join(f.BestPath()...)), Size: f.Length}
+		if !info.IsDir() {
+			verified.Path = info.BestName()
+		}
+		if stat, err := os.Stat(vf.path); err == nil && !stat.IsDir() {
+			verified.Exists = true
+			verified.SizeMismatch = stat.Size() != f.Length
+			if file, err := os.Open(vf.path); err == nil && !verified.SizeMismatch {
+				vf.file = file
+				defer file.Close()
+			}
+		}
+		vf.missing = vf.file == nil
+		result.Files = append(result.Files, verified)
+		result.TotalBytes += f.Length
+		files = append(files, vf)
+	}
+
+	// 每个本地文件对应的结果，用于累计校验通过的字节
+	fileResults := make([]*VerifiedFile, len(files))
+	next := 0
+	for i, f := range files {
+		if !f.padding {
+			fileResults[i] = &result.Files[next]
+			next++
+		}
+	}
+
+	buf := make([]byte, info.PieceLength)
+	for i := 0; i < result.TotalPieces; i++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		piece := info.Piece(i)
+		data := buf[:piece.Length()]
+		readable := readVerifyRange(files, piece.Offset(), data)
+		sum := sha1.Sum(data)
+		if readable && bytes.Equal(sum[:], info.Pieces[i*sha1.Size:(i+1)*sha1.Size]) {
+			result.GoodPieces++
+			start, end := piece.Offset(), piece.Offset()+piece.Length()
+			for j, f := range files {
+				if fileResults[j] == nil || f.offset+f.length <= start || f.offset >= end {
+					continue
+				}
+				overlap := min(end, f.offset+f.length) - max(start, f.offset)
+				fileResults[j].GoodBytes += overlap
+				result.GoodBytes += overlap
+			}
+		}
+		progress(i+1, result.GoodPieces)
+	}
+
+	for i := range result.Files {
+		if f := &result.Files[i]; f.Size > 0 {
+			f.Percent = float64(f.GoodBytes) / float64(f.Size) * 100
+		} else if f.Exists {
+			f.Percent = 100
+		}
+	}
+	if result.TotalBytes > 0 {
+		result.Percent = float64(result.GoodBytes) / float64(result.TotalBytes) * 100
+	}
+	result.Complete = result.GoodPieces == result.TotalPieces
+	return result, nil
+}
+
+// VerifyAgainstTorrent hashes local files against the pieces of a torrent
+// VerifyAgainstTorrent 按种子的分块哈希校验本地已有的文件，返回完成度和每个文件的校验结果，用于给在其他地方下载的内容做种。
+// torrentData 与 ParseTorrentFile 的参数相同；localPath 为种子内容（文件或文件夹）或包含种子内容的下载目录。
+// 需要读取全部文件，进度通过 EventTorrentVerifyProgress 推送，可以用 CancelTorrentVerify 取消
+func (a *App) VerifyAgainstTorrent(torrentData string, localPath string) (string, error) {
+	var req struct {
+		Content  string `json:"content"`
+		FileName string `json:"fileName"`
+	}
+	if err := json.Unmarshal([]byte(torrentData), &req); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		return "", err
+	}
+	mi, err := metainfo.Load(bytes.NewReader(data))
+	if err != nil {
+		return "", errorf(msgNotTorrentFile, err)
+	}
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return "", errorf(msgNotTorrentFile, err)
+	}
+	if !info.HasV1() || len(info.Pieces) != info.NumPieces()*sha1.Size {
+		return "", errorf(msgTorrentV2Only)
+	}
+	normalizeTorrentNames(mi, &info)
+	root, err := resolveTorrentRoot(&info, absPath(localPath))
+	if err != nil {
+		return "", err
+	}
+
+	verifier := a.torrentVerify
+	verifier.mu.Lock()
+	if verifier.cancel != nil {
+		verifier.mu.Unlock()
+		return "", errorf(msgTorrentVerifyRunning)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	verifier.cancel = cancel
+	verifier.mu.Unlock()
+	defer func() {
+		verifier.mu.Lock()
+		verifier.cancel = nil
+		verifier.mu.Unlock()
+		cancel()
+	}()
+
+	started := time.Now()
+	slog.Info("开始按种子校验本地文件", "fileName", req.FileName, "root", root, "pieces", info.NumPieces())
+	result, err := verifyTorrentData(ctx, &info, root, func(checked, good int) {
+		if a.throttle.allow(EventTorrentVerifyProgress, checked == info.NumPieces()) {
+			a.emitEvent(EventTorrentVerifyProgress, map[string]interface{}{
+				"checkedPieces": checked,
+				"totalPieces":   info.NumPieces(),
+				"goodPieces":    good,
+				"percent":       float64(checked) / float64(max(info.NumPieces(), 1)) * 100,
+			})
+		}
+	})
+	if err != nil {
+		slog.Info("按种子校验本地文件已取消", "root", root)
+		return "", errorf(msgTorrentVerifyCancelled)
+	}
+	result.Elapsed = time.Since(started).Seconds()
+	slog.Info("按种子校验本地文件完成", "root", root, "goodPieces", result.GoodPieces, "totalPieces", result.TotalPieces,
+		"percent", result.Percent, "elapsed", time.Since(started).Round(time.Millisecond))
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"result": result,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// CancelTorrentVerify cancels the running torrent verification
+// CancelTorrentVerify 取消正在进行的按种子校验
+func (a *App) CancelTorrentVerify() (string, error) {
+	verifier := a.torrentVerify
+	verifier.mu.Lock()
+	cancel := verifier.cancel
+	verifier.mu.Unlock()
+	if cancel == nil {
+		return "", errorf(msgTorrentVerifyNotRunning)
+	}
+	cancel()
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// trackerListFile 下载的公共 Tracker 列表的缓存，保存在数据目录中
+	trackerListFile = "trackers.txt"
+	// trackerListMaxAge 缓存的 Tracker 列表超过这个时间后在后台重新下载
+	trackerListMaxAge = 24 * time.Hour
+	// trackerListMaxSize Tracker 列表的大小上限
+	trackerListMaxSize = 1 << 20
+)
+
+// bundledTrackers 内置的公共 Tracker，没有设置列表地址或下载失败时使用
+var bundledTrackers = []string{
+	"udp://tracker.opentrackr.org:1337/announce",
+	"udp://open.demonii.com:1337/announce",
+	"udp://open.stealth.si:80/announce",
+	"udp://tracker.torrent.eu.org:451/announce",
+	"udp://exodus.desync.com:6969/announce",
+	"udp://explodie.org:6969/announce",
+	"udp://opentracker.io:6969/announce",
+	"udp://tracker.dler.org:6969/announce",
+	"https://tracker.opentrackr.org:443/announce",
+	"http://tracker.opentrackr.org:1337/announce",
+}
+
+// trackerList 公共 Tracker 列表：优先使用从设置的地址下载的列表，下载的列表缓存到数据目录
+type trackerList struct {
+	mu         sync.Mutex
+	trackers   []string
+	source     string
+	updated    time.Time
+	refreshing bool
+}
+
+var publicTrackers = &trackerList{}
+
+// parseTrackerList 解析每行一个 Tracker 的列表，忽略空行、注释和无效的地址
+func parseTrackerList(data []byte) []string {
+	var trackers []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || !validTrackerURL(line) {
+			continue
+		}
+		trackers = appendUnique(trackers, line)
+	}
+	return trackers
+}
+
+// fetchTrackerList 下载 Tracker 列表并保存到缓存
+func fetchTrackerList(listURL string) ([]string, error) {
+	req, err := http.NewRequest(http.MethodGet, listURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "SeedParser/"+appVersion)
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, trackerListMaxSize))
+	if err != nil {
+		return nil, err
+	}
+	trackers := parseTrackerList(data)
+	if len(trackers) == 0 {
+		return nil, fmt.Errorf("列表中没有有效的 Tracker")
+	}
+	// 第一行记录列表地址，设置的地址改变后不再使用缓存
+	cache := "# " + listURL + "\n" + strings.Join(trackers, "\n") + "\n"
+	if err := writeFileAtomic(dataPath(trackerListFile), []byte(cache), 0644); err != nil {
+		slog.Warn("保存 Tracker 列表失败", "error", err)
+	}
+	return trackers, nil
+}
+
+// refresh 重新下载 Tracker 列表，失败时保留原来的列表
+func (l *trackerList) refresh(listURL string) error {
+	trackers, err := fetchTrackerList(listURL)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refreshing = false
+	if err != nil {
+		slog.Warn("下载公共 Tracker 列表失败", "url", listURL, "error", err)
+		return errorf(msgFetchTrackersFailed, err)
+	}
+	l.trackers, l.source, l.updated = trackers, listURL, time.Now()
+	slog.Info("已更新公共 Tracker 列表", "url", listURL, "trackers", len(trackers))
+	return nil
+}
+
+// get 返回当前的 Tracker 列表：设置了列表地址时使用缓存的列表，缓存过期时在后台更新，
+// 不等待下载完成；没有可用的列表时使用内置列表
+func (l *trackerList) get() []string {
+	listURL := currentSettings().ExtraTrackersURL
+	if listURL == "" {
+		return bundledTrackers
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.source != listURL {
+		// 启动后第一次使用或地址改变时读取缓存文件，缓存的是其他地址的列表时忽略
+		l.trackers, l.updated, l.source = nil, time.Time{}, listURL
+		if info, err := os.Stat(dataPath(trackerListFile)); err == nil {
+			data, err := os.ReadFile(dataPath(trackerListFile))
+			if err == nil && bytes.HasPrefix(data, []byte("# "+listURL+"\n")) {
+				l.trackers, l.updated = parseTrackerList(data), info.ModTime()
+			}
+		}
+	}
+	if time.Since(l.updated) > trackerListMaxAge && !l.refreshing {
+		l.refreshing = true
+		go l.refresh(listURL)
+	}
+	if len(l.trackers) == 0 {
+		return bundledTrackers
+	}
+	return l.trackers
+}
+
+// withPublicTrackers 在磁力链接中追加公共 Tracker（已有的跳过），设置中未开启时原样返回
+func withPublicTrackers(magnetLink string) string {
+	if !currentSettings().ExtraTrackers {
+		return magnetLink
+	}
+	query, err := url.ParseQuery(strings.TrimPrefix(magnetLink, "magnet:?"))
+	if err != nil {
+		return magnetLink
+	}
+	var extra []string
+	for _, tracker := range publicTrackers.get() {
+		if !slices.Contains(query["tr"], tracker) && !slices.Contains(extra, tracker) {
+			extra = append(extra, tracker)
+		}
+	}
+	for _, tracker := range extra {
+		magnetLink += "&tr=" + url.QueryEscape(tracker)
+	}
+	return magnetLink
+}
+
+// GetPublicTrackers returns the public tracker list appended to magnet links
+// GetPublicTrackers 返回追加到磁力链接中的公共 Tracker 列表；refresh 为 true 时立即从设置的地址重新下载
+func (a *App) GetPublicTrackers(refresh bool) (string, error) {
+	s := currentSettings()
+	if refresh && s.ExtraTrackersURL != "" {
+		publicTrackers.mu.Lock()
+		publicTrackers.refreshing = true
+		publicTrackers.mu.Unlock()
+		if err := publicTrackers.refresh(s.ExtraTrackersURL); err != nil {
+			return "", err
+		}
+	}
+	trackers := publicTrackers.get()
+
+	publicTrackers.mu.Lock()
+	source, updated := "bundled", time.Time{}
+	if s.ExtraTrackersURL != "" && len(publicTrackers.trackers) > 0 {
+		source, updated = s.ExtraTrackersURL, publicTrackers.updated
+	}
+	publicTrackers.mu.Unlock()
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":   "success",
+		"enabled":  s.ExtraTrackers,
+		"source":   source,
+		"updated":  updated,
+		"trackers": trackers,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// transferChunkSize 限速时每次写入响应的最大字节数，写入前等待相应的时间
+	transferChunkSize = 32 * 1024
+	// transferBurst 限速器允许的突发时长：空闲一段时间后最多立即发送这么长时间的流量
+	transferBurst = 200 * time.Millisecond
+)
+
+// transferLimiter 本地文件服务的总带宽限制，所有正在传输的文件共享同一个限额，
+// 播放多个视频时总流量也不超过设置的速度，避免占满磁盘和网络影响正在进行的下载和转码
+type transferLimiter struct {
+	mu sync.Mutex
+	// next 下一次写入可以开始的时间，每次写入按字节数和速度向后推移
+	next time.Time
+}
+
+// fileTransfers 本地文件服务（/downloads/、/transcode/、/stream/ 等）的限速器
+var fileTransfers transferLimiter
+
+// wait 为 n 字节的写入预留带宽，按速度 rate（字节/秒）等待到可以写入的时间；客户端断开时返回错误
+func (l *transferLimiter) wait(ctx context.Context, n int, rate int64) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now.Add(-transferBurst)) {
+		l.next = now.Add(-transferBurst)
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / float64(rate) * float64(time.Second)))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledWriter 按设置的速度写入响应，每次写入前读取设置，修改限速后对正在传输的文件立即生效
+type throttledWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+}
+
+func (t throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), transferChunkSize)
+		if rate := currentSettings().FileServerSpeedLimit * 1024; rate > 0 {
+			if err := fileTransfers.wait(t.ctx, n, rate); err != nil {
+				return written, err
+			}
+		}
+		m, err := t.ResponseWriter.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// throttleResponse 设置了本地文件服务限速时返回限速的 ResponseWriter，否则返回 w 本身
+func throttleResponse(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	if currentSettings().FileServerSpeedLimit <= 0 {
+		return w
+	}
+	return throttledWriter{ResponseWriter: w, ctx: r.Context()}
+}
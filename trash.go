@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// absPath 返回绝对路径，失败时返回清理后的原路径
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// pathWithin 判断 path 是否为 root 本身或位于 root 目录中
+func pathWithin(path, root string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
+
+// downloadContains 判断下载任务的内容（下载目录中以种子名称命名的文件或文件夹）是否包含 path
+func downloadContains(task DownloadTask, path string) bool {
+	if task.FileName == "" || task.OutputDir == "" {
+		return false
+	}
+	return pathWithin(path, absPath(filepath.Join(task.OutputDir, task.FileName)))
+}
+
+// downloadUses 判断删除 path（文件或文件夹）是否会影响下载任务的内容
+func downloadUses(task DownloadTask, path string) bool {
+	if task.FileName == "" || task.OutputDir == "" {
+		return false
+	}
+	root := absPath(filepath.Join(task.OutputDir, task.FileName))
+	return pathWithin(path, root) || pathWithin(root, path)
+}
+
+// transcodeUses 判断删除 path（文件或文件夹）是否会影响转码任务的输入或输出文件
+func transcodeUses(task TranscodeTask, path string) bool {
+	return pathWithin(absPath(task.InputFile), path) || pathWithin(absPath(task.OutputFile), path)
+}
+
+// checkFileNotInUse 检查文件或文件夹没有被进行中的下载或转码任务使用，否则删除会导致任务失败
+func (a *App) checkFileNotInUse(path string) error {
+	for _, task := range a.tasks.Downloads() {
+		if !downloadFinished(task.Status) && downloadUses(task, path) {
+			return errorf(msgFileInUse, filepath.Base(path))
+		}
+	}
+	for _, task := range a.tasks.Transcodes() {
+		if !transcodeFinished(task.Status) && transcodeUses(task, path) {
+			return errorf(msgFileInUse, filepath.Base(path))
+		}
+	}
+	return nil
+}
+
+// markFileDeleted 在引用了已删除文件或文件夹的下载和转码任务中记录已删除，返回更新的任务数
+func (a *App) markFileDeleted(path string) int {
+	updated := 0
+	for _, task := range a.tasks.Downloads() {
+		if downloadUses(task, path) {
+			a.tasks.UpdateDownload(task.TaskID, func(t *DownloadTask) {
+				t.DeletedFiles = appendUnique(t.DeletedFiles, path)
+			})
+			updated++
+		}
+	}
+	for _, task := range a.tasks.Transcodes() {
+		if transcodeUses(task, path) {
+			a.tasks.UpdateTranscode(task.TaskID, func(t *TranscodeTask) {
+				t.DeletedFiles = appendUnique(t.DeletedFiles, path)
+			})
+			updated++
+		}
+	}
+	return updated
+}
+
+// appendUnique 将 value 追加到 values 中，已存在时不重复追加
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// removeFile 删除文件：permanent 为 true 时直接删除，否则移到系统回收站
+func removeFile(path string, permanent bool) error {
+	if permanent {
+		return os.Remove(path)
+	}
+	return moveToTrash(path)
+}
+
+// DeleteLibraryFile deletes a library video, moving it to the recycle bin unless permanent is set
+// DeleteLibraryFile 删除视频库中的视频及其外挂字幕：默认移到系统回收站（Windows 回收站、macOS 废纸篓、
+// Linux 桌面回收站），permanent 为 true 时直接删除。正在下载或转码的文件不能删除；引用该文件的任务会记录文件已删除，
+// 视频信息、匹配结果和播放进度缓存一并清除
+func (a *App) DeleteLibraryFile(path string, permanent bool) (string, error) {
+	video, err := a.findLibraryVideo(path)
+	if err != nil {
+		return "", err
+	}
+	target := absPath(video.Path)
+	if err := a.checkFileNotInUse(target); err != nil {
+		return "", err
+	}
+	if err := removeFile(video.Path, permanent); err != nil {
+		slog.Error("删除视频失败", "path", video.Path, "permanent", permanent, "error", err)
+		return "", errorf(msgDeleteFileFailed, err)
+	}
+	slog.Info("已删除视频", "path", video.Path, "permanent", permanent)
+
+	// 外挂字幕只属于这个视频，一并删除；失败时只记录日志
+	var subtitles []string
+	for _, subtitle := range video.Subtitles {
+		if err := removeFile(subtitle.Path, permanent); err != nil {
+			slog.Warn("删除字幕失败", "path", subtitle.Path, "error", err)
+			continue
+		}
+		subtitles = append(subtitles, subtitle.Path)
+	}
+
+	a.media.remove(video.Path)
+	a.scraper.remove(video.Path)
+	a.playback.remove(video.Path)
+	a.media.save()
+	a.scraper.save()
+	a.playback.save()
+	tasks := a.markFileDeleted(target)
+	a.library.invalidate(video.Root)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":    "success",
+		"path":      video.Path,
+		"permanent": permanent,
+		"subtitles": subtitles,
+		"tasks":     tasks,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// moveToTrash 通过 Finder 将文件移到废纸篓，可以在 Finder 中“放回原处”
+func moveToTrash(path string) error {
+	script := fmt.Sprintf("tell application \"Finder\" to delete POSIX file %s", appleScriptString(path))
+	if output, err := exec.Command("osascript", "-e", script).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
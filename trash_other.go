@@ -0,0 +1,143 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// moveToTrash 将文件移到回收站：优先使用 gio trash（桌面环境都支持），
+// 没有 gio 时按 freedesktop.org 回收站规范自行移动
+func moveToTrash(path string) error {
+	if gio, err := exec.LookPath("gio"); err == nil {
+		output, err := exec.Command(gio, "trash", path).CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		// gio 无法处理时（例如没有桌面会话）再按规范移动
+		slog.Warn("gio trash 失败，按回收站规范移动", "path", path, "error", err, "output", strings.TrimSpace(string(output)))
+	}
+	return freedesktopTrash(path)
+}
+
+// homeTrashDir 返回用户主回收站 $XDG_DATA_HOME/Trash，默认为 ~/.local/share/Trash
+func homeTrashDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "Trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
+
+// trashDirFor 返回文件应移入的回收站目录和 .trashinfo 中路径的相对基准：与用户主目录在同一设备时使用主回收站，
+// 否则使用所在设备根目录的 .Trash/$uid（管理员创建的带粘滞位的 .Trash）或 .Trash-$uid
+func trashDirFor(path string) (trashDir string, base string, err error) {
+	homeTrash, err := homeTrashDir()
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(homeTrash, 0700); err != nil {
+		return "", "", err
+	}
+	fileDevice, err := deviceOf(path)
+	if err != nil {
+		return "", "", err
+	}
+	if homeDevice, err := deviceOf(homeTrash); err == nil && homeDevice == fileDevice {
+		return homeTrash, "", nil
+	}
+
+	topDir, err := volumeOf(path)
+	if err != nil {
+		return "", "", err
+	}
+	uid := strconv.Itoa(os.Getuid())
+	shared := filepath.Join(topDir, ".Trash")
+	if info, err := os.Lstat(shared); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		dir := filepath.Join(shared, uid)
+		if err := os.MkdirAll(dir, 0700); err == nil {
+			return dir, topDir, nil
+		}
+	}
+	dir := filepath.Join(topDir, ".Trash-"+uid)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+	return dir, topDir, nil
+}
+
+// freedesktopTrash 按 freedesktop.org 回收站规范移动文件：先独占创建 info/<名称>.trashinfo 占用名称，
+// 再将文件移到 files/<名称>，文件管理器可以据此还原
+func freedesktopTrash(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	trashDir, base, err := trashDirFor(path)
+	if err != nil {
+		return err
+	}
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	// 设备根目录的回收站中记录相对路径，设备挂载到其他位置后仍可还原
+	recorded := path
+	if base != "" {
+		if rel, err := filepath.Rel(base, path); err == nil {
+			recorded = rel
+		}
+	}
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: recorded}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+	stem := name[:len(name)-len(ext)]
+	for i := 2; ; i++ {
+		infoPath := filepath.Join(infoDir, name+".trashinfo")
+		f, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			// 回收站中可能留有没有 .trashinfo 的同名文件，不能覆盖
+			if _, statErr := os.Lstat(filepath.Join(filesDir, name)); statErr == nil {
+				f.Close()
+				os.Remove(infoPath)
+				err = os.ErrExist
+			}
+		}
+		if errors.Is(err, os.ErrExist) {
+			name = stem + "." + strconv.Itoa(i) + ext
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(info)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(path, filepath.Join(filesDir, name))
+		}
+		if err != nil {
+			os.Remove(infoPath)
+		}
+		return err
+	}
+}
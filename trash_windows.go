@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSHFileOperationW = shell32.NewProc("SHFileOperationW")
+
+// SHFileOperationW 的操作和选项
+const (
+	foDelete           = 0x0003
+	fofSilent          = 0x0004
+	fofNoConfirmation  = 0x0010
+	fofAllowUndo       = 0x0040
+	fofNoErrorUI       = 0x0400
+	fofNoConfirmMkdir  = 0x0200
+	trashOperationFlag = fofSilent | fofNoConfirmation | fofAllowUndo | fofNoErrorUI | fofNoConfirmMkdir
+)
+
+// shFileOpStruct SHFILEOPSTRUCTW 结构
+type shFileOpStruct struct {
+	Hwnd                 uintptr
+	Func                 uint32
+	From                 *uint16
+	To                   *uint16
+	Flags                uint16
+	AnyOperationsAborted int32
+	NameMappings         uintptr
+	ProgressTitle        *uint16
+}
+
+// moveToTrash 通过 SHFileOperationW 将文件移到回收站，不显示确认和进度窗口
+func moveToTrash(path string) error {
+	// pFrom 是以两个空字符结尾的路径列表
+	from, err := windows.UTF16FromString(path)
+	if err != nil {
+		return err
+	}
+	from = append(from, 0)
+	op := shFileOpStruct{
+		Func:  foDelete,
+		From:  &from[0],
+		Flags: trashOperationFlag,
+	}
+	if r, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op))); r != 0 {
+		return fmt.Errorf("SHFileOperationW 错误码 0x%x", r)
+	}
+	if op.AnyOperationsAborted != 0 {
+		return fmt.Errorf("移到回收站的操作被取消")
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// trayTooltip 托盘图标的提示文字：正在进行的下载和转码任务数及总体进度
+func (a *App) trayTooltip() string {
+	if a.tasks == nil {
+		return "SeedParser"
+	}
+
+	var downloading int
+	var totalSize, downloaded int64
+	for _, t := range a.tasks.Downloads() {
+		if t.Status == "downloading" {
+			downloading++
+			totalSize += t.TotalSize
+			downloaded += t.Downloaded
+		}
+	}
+	var transcoding int
+	var progress float64
+	for _, t := range a.tasks.Transcodes() {
+		if t.Status == "transcoding" {
+			transcoding++
+			progress += t.Progress
+		}
+	}
+
+	var parts []string
+	if downloading > 0 {
+		percentage := 0.0
+		if totalSize > 0 {
+			percentage = float64(downloaded) / float64(totalSize) * 100
+		}
+		parts = append(parts, fmt.Sprintf("下载 %d 个 %.0f%%", downloading, percentage))
+	}
+	if transcoding > 0 {
+		parts = append(parts, fmt.Sprintf("转码 %d 个 %.0f%%", transcoding, progress/float64(transcoding)*100))
+	}
+	if len(parts) == 0 {
+		return "SeedParser - 没有进行中的任务"
+	}
+	return "SeedParser - " + strings.Join(parts, " · ")
+}
+
+// showWindow 从托盘恢复主窗口
+func (a *App) showWindow() {
+	runtime.WindowShow(a.ctx)
+	runtime.WindowUnminimise(a.ctx)
+}
+
+// quit 通过托盘菜单或更新退出：跳过最小化到托盘，正常关闭程序
+func (a *App) quit() {
+	a.quitting.Store(true)
+	runtime.Quit(a.ctx)
+}
+
+// hideToTray 关闭窗口时按设置隐藏到托盘，返回是否已隐藏
+func (a *App) hideToTray(ctx context.Context) bool {
+	if a.quitting.Load() || !currentSettings().MinimizeToTray || !a.trayRunning() {
+		return false
+	}
+	slog.Info("窗口已最小化到托盘")
+	runtime.WindowHide(ctx)
+	return true
+}
+
+// PauseAllDownloads pauses every downloading or waiting task
+// PauseAllDownloads 暂停所有下载中和等待中的任务，返回暂停的任务数
+func (a *App) PauseAllDownloads() (string, error) {
+	var paused int
+	for _, t := range a.tasks.Downloads() {
+		if t.Status != "downloading" && t.Status != "waiting" {
+			continue
+		}
+		if _, err := a.PauseDownload(t.TaskID); err != nil {
+			slog.Warn("暂停下载任务失败", "taskId", t.TaskID, "error", err)
+			continue
+		}
+		paused++
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Downloads paused successfully",
+		"count":   paused,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// ResumeAllDownloads resumes every paused task
+// ResumeAllDownloads 恢复所有已暂停的任务，超出同时下载任务数的任务进入等待队列
+func (a *App) ResumeAllDownloads() (string, error) {
+	var resumed int
+	for _, t := range a.tasks.Downloads() {
+		if t.Status != "paused" {
+			continue
+		}
+		if _, err := a.ResumeDownload(t.TaskID); err != nil {
+			slog.Warn("恢复下载任务失败", "taskId", t.TaskID, "error", err)
+			continue
+		}
+		resumed++
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Downloads resumed successfully",
+		"count":   resumed,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
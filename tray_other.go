@@ -0,0 +1,15 @@
+//go:build !windows
+
+package main
+
+// traySupported 目前只在 Windows 上显示托盘图标
+const traySupported = false
+
+// trayIcon 非Windows平台没有托盘图标
+type trayIcon struct{}
+
+func (a *App) startTray() {}
+
+func (a *App) stopTray() {}
+
+func (a *App) trayRunning() bool { return false }
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+	goruntime "runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                 = windows.NewLazySystemDLL("user32.dll")
+	shell32                = windows.NewLazySystemDLL("shell32.dll")
+	kernel32               = windows.NewLazySystemDLL("kernel32.dll")
+	procRegisterClassExW   = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW    = user32.NewProc("CreateWindowExW")
+	procDestroyWindow      = user32.NewProc("DestroyWindow")
+	procDefWindowProcW     = user32.NewProc("DefWindowProcW")
+	procGetMessageW        = user32.NewProc("GetMessageW")
+	procTranslateMessage   = user32.NewProc("TranslateMessage")
+	procDispatchMessageW   = user32.NewProc("DispatchMessageW")
+	procPostMessageW       = user32.NewProc("PostMessageW")
+	procPostQuitMessage    = user32.NewProc("PostQuitMessage")
+	procRegisterWindowMsgW = user32.NewProc("RegisterWindowMessageW")
+	procCreatePopupMenu    = user32.NewProc("CreatePopupMenu")
+	procAppendMenuW        = user32.NewProc("AppendMenuW")
+	procTrackPopupMenu     = user32.NewProc("TrackPopupMenu")
+	procDestroyMenu        = user32.NewProc("DestroyMenu")
+	procGetCursorPos       = user32.NewProc("GetCursorPos")
+	procSetForegroundWnd   = user32.NewProc("SetForegroundWindow")
+	procLoadIconW          = user32.NewProc("LoadIconW")
+	procDestroyIcon        = user32.NewProc("DestroyIcon")
+	procShellNotifyIconW   = shell32.NewProc("Shell_NotifyIconW")
+	procExtractIconExW     = shell32.NewProc("ExtractIconExW")
+	procGetModuleHandleW   = kernel32.NewProc("GetModuleHandleW")
+)
+
+const (
+	wmDestroy       = 0x0002
+	wmClose         = 0x0010
+	wmNull          = 0x0000
+	wmLButtonUp     = 0x0202
+	wmLButtonDblClk = 0x0203
+	wmRButtonUp     = 0x0205
+	wmApp           = 0x8000
+	// wmTrayCallback 托盘图标的鼠标消息
+	wmTrayCallback = wmApp + 1
+
+	nimAdd     = 0x0
+	nimModify  = 0x1
+	nimDelete  = 0x2
+	nifMessage = 0x1
+	nifIcon    = 0x2
+	nifTip     = 0x4
+
+	mfString       = 0x0
+	mfSeparator    = 0x800
+	tpmReturnCmd   = 0x100
+	tpmNoNotify    = 0x80
+	tpmRightButton = 0x2
+
+	// hwndMessage 只接收消息、不显示的窗口
+	hwndMessage    = ^uintptr(2)
+	idiApplication = 32512
+
+	// trayUpdateInterval 刷新托盘提示文字的间隔
+	trayUpdateInterval = 2 * time.Second
+)
+
+// traySupported 当前平台是否支持托盘图标
+const traySupported = true
+
+// 托盘菜单项
+const (
+	trayMenuShow = iota + 1
+	trayMenuPauseAll
+	trayMenuResumeAll
+	trayMenuOpenDownloads
+	trayMenuQuit
+)
+
+type notifyIconData struct {
+	CbSize           uint32
+	HWnd             uintptr
+	UID              uint32
+	UFlags           uint32
+	UCallbackMessage uint32
+	HIcon            uintptr
+	SzTip            [128]uint16
+	DwState          uint32
+	DwStateMask      uint32
+	SzInfo           [256]uint16
+	UVersion         uint32
+	SzInfoTitle      [64]uint16
+	DwInfoFlags      uint32
+	GuidItem         struct {
+		Data1 uint32
+		Data2 uint16
+		Data3 uint16
+		Data4 [8]byte
+	}
+	HBalloonIcon uintptr
+}
+
+type wndClassEx struct {
+	CbSize        uint32
+	Style         uint32
+	LpfnWndProc   uintptr
+	CbClsExtra    int32
+	CbWndExtra    int32
+	HInstance     uintptr
+	HIcon         uintptr
+	HCursor       uintptr
+	HbrBackground uintptr
+	LpszMenuName  *uint16
+	LpszClassName *uint16
+	HIconSm       uintptr
+}
+
+type winMsg struct {
+	HWnd    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      struct{ X, Y int32 }
+	Private uint32
+}
+
+// trayIcon 通知区域图标：一个隐藏的消息窗口接收图标的鼠标消息，在独立的系统线程上运行消息循环
+type trayIcon struct {
+	mu      sync.Mutex
+	hwnd    uintptr
+	icon    uintptr
+	stopped chan struct{}
+	// taskbarCreated 资源管理器重启后广播的消息，需要重新添加图标
+	taskbarCreated uint32
+}
+
+var (
+	// trayApp 窗口过程中使用的 App，窗口过程无法携带上下文
+	trayApp *App
+	// trayWndProc 窗口过程的回调只创建一次，回调数量有上限
+	trayWndProc     uintptr
+	trayWndProcOnce sync.Once
+)
+
+// startTray 显示托盘图标，失败时只记录日志
+func (a *App) startTray() {
+	trayApp = a
+	ready := make(chan struct{})
+	go a.runTray(ready)
+	<-ready
+}
+
+// trayRunning 托盘图标是否正在显示
+func (a *App) trayRunning() bool {
+	a.tray.mu.Lock()
+	defer a.tray.mu.Unlock()
+	return a.tray.hwnd != 0
+}
+
+// stopTray 移除托盘图标并结束消息循环
+func (a *App) stopTray() {
+	a.tray.mu.Lock()
+	hwnd, stopped := a.tray.hwnd, a.tray.stopped
+	a.tray.mu.Unlock()
+	if hwnd == 0 {
+		return
+	}
+	procPostMessageW.Call(hwnd, wmClose, 0, 0)
+	<-stopped
+}
+
+// runTray 创建消息窗口和托盘图标并运行消息循环，窗口必须在创建它的线程上处理消息
+func (a *App) runTray(ready chan struct{}) {
+	goruntime.LockOSThread()
+	defer goruntime.UnlockOSThread()
+
+	hwnd, err := a.createTrayWindow()
+	if err != nil {
+		slog.Error("创建托盘图标失败", "error", err)
+		close(ready)
+		return
+	}
+	t := &a.tray
+	t.mu.Lock()
+	t.hwnd = hwnd
+	t.stopped = make(chan struct{})
+	t.icon = loadTrayIcon()
+	t.mu.Unlock()
+	if err := t.notify(nimAdd, a.trayTooltip()); err != nil {
+		slog.Error("添加托盘图标失败", "error", err)
+	}
+	close(ready)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(trayUpdateInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				t.notify(nimModify, a.trayTooltip())
+			}
+		}
+	}()
+
+	var msg winMsg
+	for {
+		r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if int32(r) <= 0 {
+			break
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+	close(done)
+
+	t.mu.Lock()
+	if t.icon != 0 {
+		procDestroyIcon.Call(t.icon)
+	}
+	t.hwnd, t.icon = 0, 0
+	close(t.stopped)
+	t.mu.Unlock()
+}
+
+// createTrayWindow 注册窗口类并创建只接收消息的窗口
+func (a *App) createTrayWindow() (uintptr, error) {
+	trayWndProcOnce.Do(func() {
+		trayWndProc = syscall.NewCallback(trayWindowProc)
+	})
+	className, _ := windows.UTF16PtrFromString("SeedParserTray")
+	instance, _, _ := procGetModuleHandleW.Call(0)
+	wc := wndClassEx{
+		LpfnWndProc:   trayWndProc,
+		HInstance:     instance,
+		LpszClassName: className,
+	}
+	wc.CbSize = uint32(unsafe.Sizeof(wc))
+	// 重复启动时类已注册，注册失败可以忽略，创建窗口失败才是错误
+	procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
+
+	hwnd, _, err := procCreateWindowExW.Call(0, uintptr(unsafe.Pointer(className)), 0, 0, 0, 0, 0, 0, hwndMessage, 0, instance, 0)
+	if hwnd == 0 {
+		return 0, err
+	}
+	taskbarCreated, _ := windows.UTF16PtrFromString("TaskbarCreated")
+	r, _, _ := procRegisterWindowMsgW.Call(uintptr(unsafe.Pointer(taskbarCreated)))
+	a.tray.taskbarCreated = uint32(r)
+	return hwnd, nil
+}
+
+// loadTrayIcon 使用程序文件中的图标，读取失败时使用系统默认图标
+func loadTrayIcon() uintptr {
+	if exe, err := os.Executable(); err == nil {
+		path, _ := windows.UTF16PtrFromString(exe)
+		var small uintptr
+		if n, _, _ := procExtractIconExW.Call(uintptr(unsafe.Pointer(path)), 0, 0, uintptr(unsafe.Pointer(&small)), 1); n > 0 && small != 0 {
+			return small
+		}
+	}
+	icon, _, _ := procLoadIconW.Call(0, idiApplication)
+	return icon
+}
+
+// notify 添加、修改或删除托盘图标，tip 超出长度时截断
+func (t *trayIcon) notify(action uint32, tip string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.hwnd == 0 {
+		return nil
+	}
+	nid := notifyIconData{
+		HWnd:             t.hwnd,
+		UID:              1,
+		UFlags:           nifMessage | nifIcon | nifTip,
+		UCallbackMessage: wmTrayCallback,
+		HIcon:            t.icon,
+	}
+	nid.CbSize = uint32(unsafe.Sizeof(nid))
+	tip16, _ := windows.UTF16FromString(tip)
+	if len(tip16) > len(nid.SzTip) {
+		tip16 = append(tip16[:len(nid.SzTip)-1], 0)
+	}
+	copy(nid.SzTip[:], tip16)
+	if r, _, err := procShellNotifyIconW.Call(uintptr(action), uintptr(unsafe.Pointer(&nid))); r == 0 {
+		return err
+	}
+	return nil
+}
+
+// trayWindowProc 处理托盘图标的鼠标消息：单击或双击显示主窗口，右键显示菜单
+func trayWindowProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr {
+	a := trayApp
+	switch {
+	case msg == wmTrayCallback:
+		switch lParam & 0xffff {
+		case wmLButtonUp, wmLButtonDblClk:
+			a.showWindow()
+		case wmRButtonUp:
+			a.showTrayMenu(hwnd)
+		}
+		return 0
+	case msg == a.tray.taskbarCreated && msg != 0:
+		a.tray.notify(nimAdd, a.trayTooltip())
+		return 0
+	case msg == wmClose:
+		a.tray.notify(nimDelete, "")
+		procDestroyWindow.Call(hwnd)
+		return 0
+	case msg == wmDestroy:
+		procPostQuitMessage.Call(0)
+		return 0
+	}
+	r, _, _ := procDefWindowProcW.Call(hwnd, uintptr(msg), wParam, lParam)
+	return r
+}
+
+// showTrayMenu 在鼠标位置显示托盘菜单并执行选择的操作
+func (a *App) showTrayMenu(hwnd uintptr) {
+	menu, _, _ := procCreatePopupMenu.Call()
+	if menu == 0 {
+		return
+	}
+	defer procDestroyMenu.Call(menu)
+
+	items := []struct {
+		id    uintptr
+		title string
+	}{
+		{trayMenuShow, "显示主窗口"},
+		{0, ""},
+		{trayMenuPauseAll, "全部暂停"},
+		{trayMenuResumeAll, "全部继续"},
+		{trayMenuOpenDownloads, "打开下载目录"},
+		{0, ""},
+		{trayMenuQuit, "退出"},
+	}
+	for _, item := range items {
+		if item.id == 0 {
+			procAppendMenuW.Call(menu, mfSeparator, 0, 0)
+			continue
+		}
+		title, _ := windows.UTF16PtrFromString(item.title)
+		procAppendMenuW.Call(menu, mfString, item.id, uintptr(unsafe.Pointer(title)))
+	}
+
+	var pt struct{ X, Y int32 }
+	procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	// 菜单所属窗口需要在前台，否则点击菜单外部时菜单不会关闭
+	procSetForegroundWnd.Call(hwnd)
+	cmd, _, _ := procTrackPopupMenu.Call(menu, tpmReturnCmd|tpmNoNotify|tpmRightButton, uintptr(pt.X), uintptr(pt.Y), 0, hwnd, 0)
+	procPostMessageW.Call(hwnd, wmNull, 0, 0)
+
+	// 菜单操作可能较慢（暂停需要终止进程），不阻塞消息循环
+	switch cmd {
+	case trayMenuShow:
+		a.showWindow()
+	case trayMenuPauseAll:
+		go a.PauseAll()
+	case trayMenuResumeAll:
+		go a.ResumeAll()
+	case trayMenuOpenDownloads:
+		if err := exec.Command("explorer", downloadsDir()).Start(); err != nil {
+			slog.Error("打开下载目录失败", "error", err)
+		}
+	case trayMenuQuit:
+		go a.quit()
+	}
+}
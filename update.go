@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// appVersion 当前版本，发布时通过 -ldflags "-X main.appVersion=1.2.3" 设置
+var appVersion = "1.0.0"
+
+// updatePublicKey 校验更新包签名的 Ed25519 公钥（base64），发布时通过
+// -ldflags "-X main.updatePublicKey=..." 设置；未设置时只检查和提示新版本，不自动安装
+var updatePublicKey = ""
+
+const (
+	// updateRepo 发布新版本的 GitHub 仓库
+	updateRepo = "kamisamadasikede/SeedParser"
+	// updateCheckDelay 启动后第一次检查更新前的等待时间，避免拖慢启动
+	updateCheckDelay = time.Minute
+	// updateCheckInterval 自动检查更新的间隔
+	updateCheckInterval = 24 * time.Hour
+	// updateSignatureSuffix 更新包签名文件的后缀：<安装包>.sig 中是对安装包 SHA-512 摘要的
+	// Ed25519ph 签名（base64）
+	updateSignatureSuffix = ".sig"
+	// EventUpdateAvailable 发现新版本时推送给前端的事件
+	EventUpdateAvailable = "update:available"
+)
+
+// ReleaseInfo describes the latest published release
+// ReleaseInfo 最新发布版本的信息
+type ReleaseInfo struct {
+	Version     string `json:"version"`
+	Name        string `json:"name"`
+	Notes       string `json:"notes"`
+	URL         string `json:"url"`
+	PublishedAt string `json:"publishedAt"`
+	// AssetName 当前平台的安装包，没有时为空
+	AssetName string `json:"assetName"`
+	AssetURL  string `json:"assetUrl"`
+	AssetSize int64  `json:"assetSize"`
+	// SignatureURL 安装包签名文件的地址，没有签名时不能自动安装
+	SignatureURL string `json:"signatureUrl"`
+}
+
+type githubRelease struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Body        string `json:"body"`
+	HTMLURL     string `json:"html_url"`
+	PublishedAt string `json:"published_at"`
+	Assets      []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+		Size int64  `json:"size"`
+	} `json:"assets"`
+}
+
+// updateChecker 后台定期检查新版本，每个版本只提示一次
+type updateChecker struct {
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	notified string
+	// installing 正在下载安装包，避免重复下载
+	installing bool
+}
+
+func newUpdateChecker() *updateChecker {
+	return &updateChecker{}
+}
+
+// parseVersion 解析 v1.2.3 形式的版本号，忽略 - 之后的预发布标记
+func parseVersion(v string) ([3]int, error) {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "-")
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, fmt.Errorf("无效的版本号: %s", v)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, fmt.Errorf("无效的版本号: %s", v)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// newerVersion 判断 latest 是否比 current 新
+func newerVersion(latest, current string) bool {
+	l, err := parseVersion(latest)
+	if err != nil {
+		return false
+	}
+	c, err := parseVersion(current)
+	if err != nil {
+		return true
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// updateAssetFor 选择当前平台的安装包：Windows 为 .exe 安装程序，其他平台按文件名中的系统名称匹配。
+// 文件名中包含架构（arm64、x86_64 等）时只选择当前架构的安装包，优先于不区分架构的安装包
+func updateAssetFor(names []string) (string, bool) {
+	fallback := ""
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		if strings.HasSuffix(lower, updateSignatureSuffix) {
+			continue
+		}
+		forOS := (goruntime.GOOS == "windows" && strings.HasSuffix(lower, ".exe")) ||
+			(goruntime.GOOS != "windows" && strings.Contains(lower, goruntime.GOOS))
+		if !forOS {
+			continue
+		}
+		switch nameArch(name) {
+		case goruntime.GOARCH:
+			return name, true
+		case "":
+			if fallback == "" {
+				fallback = name
+			}
+		}
+	}
+	return fallback, fallback != ""
+}
+
+// fetchLatestRelease 从 GitHub 获取最新的正式版本
+func fetchLatestRelease(ctx context.Context) (ReleaseInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/repos/"+updateRepo+"/releases/latest", nil)
+	if err != nil {
+		return ReleaseInfo{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "SeedParser/"+appVersion)
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return ReleaseInfo{}, errorf(msgCheckUpdateFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ReleaseInfo{}, errorf(msgCheckUpdateFailed, errorf(msgServerReturnedStatus, resp.Status))
+	}
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return ReleaseInfo{}, errorf(msgParseReleaseFailed, err)
+	}
+
+	info := ReleaseInfo{
+		Version:     strings.TrimPrefix(release.TagName, "v"),
+		Name:        release.Name,
+		Notes:       release.Body,
+		URL:         release.HTMLURL,
+		PublishedAt: release.PublishedAt,
+	}
+	names := make([]string, len(release.Assets))
+	for i, asset := range release.Assets {
+		names[i] = asset.Name
+	}
+	if name, ok := updateAssetFor(names); ok {
+		for _, asset := range release.Assets {
+			switch asset.Name {
+			case name:
+				info.AssetName, info.AssetURL, info.AssetSize = asset.Name, asset.URL, asset.Size
+			case name + updateSignatureSuffix:
+				info.SignatureURL = asset.URL
+			}
+		}
+	}
+	return info, nil
+}
+
+// startUpdateChecks 按设置在后台定期检查更新，发现新版本时显示通知
+func (a *App) startUpdateChecks() {
+	u := a.updater
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	u.cancel = cancel
+	go func() {
+		delay := updateCheckDelay
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = updateCheckInterval
+			if !currentSettings().CheckUpdates {
+				continue
+			}
+			a.checkUpdateInBackground(ctx)
+		}
+	}()
+}
+
+// stopUpdateChecks 停止后台检查更新
+func (a *App) stopUpdateChecks() {
+	u := a.updater
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.cancel != nil {
+		u.cancel()
+		u.cancel = nil
+	}
+}
+
+// checkUpdateInBackground 检查一次更新，新版本只通知一次
+func (a *App) checkUpdateInBackground(ctx context.Context) {
+	info, err := fetchLatestRelease(ctx)
+	if err != nil {
+		slog.Warn("自动检查更新失败", "error", err)
+		return
+	}
+	if !newerVersion(info.Version, appVersion) {
+		slog.Debug("已是最新版本", "version", appVersion)
+		return
+	}
+
+	u := a.updater
+	u.mu.Lock()
+	first := u.notified != info.Version
+	u.notified = info.Version
+	u.mu.Unlock()
+	if !first {
+		return
+	}
+	slog.Info("发现新版本", "current", appVersion, "latest", info.Version)
+	a.emitEvent(EventUpdateAvailable, info)
+	a.notify(tr(msgNotifyUpdateAvailable), tr(msgNotifyUpdateReleased, info.Version), "")
+}
+
+// downloadUpdate 下载安装包到数据目录并校验签名，返回安装包路径
+func downloadUpdate(ctx context.Context, info ReleaseInfo) (string, error) {
+	if updatePublicKey == "" {
+		return "", errorf(msgNoUpdateKey)
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(updatePublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return "", errorf(msgInvalidUpdateKey)
+	}
+	if info.AssetURL == "" {
+		return "", errorf(msgNoUpdateAsset)
+	}
+	if info.SignatureURL == "" {
+		return "", errorf(msgNoUpdateSignature)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Minute}
+	get := func(url string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", "SeedParser/"+appVersion)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, errorf(msgServerReturnedStatus, resp.Status)
+		}
+		return resp, nil
+	}
+
+	sigResp, err := get(info.SignatureURL)
+	if err != nil {
+		return "", errorf(msgDownloadSigFailed, err)
+	}
+	sigData, err := io.ReadAll(io.LimitReader(sigResp.Body, 4096))
+	sigResp.Body.Close()
+	if err != nil {
+		return "", errorf(msgDownloadSigFailed, err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigData)))
+	if err != nil {
+		return "", errorf(msgInvalidSignature, err)
+	}
+
+	dir := dataPath("updates")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errorf(msgCreateDirFailed, err)
+	}
+	path := filepath.Join(dir, filepath.Base(info.AssetName))
+	tmp := path + ".part"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return "", errorf(msgCreateFileFailed, err)
+	}
+	defer os.Remove(tmp)
+
+	resp, err := get(info.AssetURL)
+	if err != nil {
+		out.Close()
+		return "", errorf(msgDownloadUpdateFailed, err)
+	}
+	defer resp.Body.Close()
+	digest := sha512.New()
+	_, err = io.Copy(io.MultiWriter(out, digest), resp.Body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", errorf(msgDownloadUpdateFailed, err)
+	}
+
+	// 先校验签名再重命名，未通过校验的文件不会留在磁盘上
+	if err := ed25519.VerifyWithOptions(publicKey, digest.Sum(nil), signature, &ed25519.Options{Hash: crypto.SHA512}); err != nil {
+		return "", errorf(msgVerifyUpdateFailed, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", errorf(msgSaveUpdateFailed, err)
+	}
+	return path, nil
+}
+
+// CheckForUpdate queries GitHub for the latest release
+// CheckForUpdate 检查是否有新版本
+func (a *App) CheckForUpdate() (string, error) {
+	info, err := fetchLatestRelease(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	response := map[string]interface{}{
+		"status":         "success",
+		"currentVersion": appVersion,
+		"available":      newerVersion(info.Version, appVersion),
+		"release":        info,
+		// 能否自动下载安装：需要签名公钥、当前平台的安装包和签名文件
+		"canInstall": updatePublicKey != "" && info.AssetURL != "" && info.SignatureURL != "" && goruntime.GOOS == "windows",
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// InstallUpdate downloads the latest release, verifies its signature and runs the installer
+// InstallUpdate 下载最新版本的安装包并校验签名，然后启动安装程序并退出，由安装程序替换文件
+func (a *App) InstallUpdate() (string, error) {
+	if goruntime.GOOS != "windows" {
+		return "", errorf(msgUpdateWindowsOnly)
+	}
+	if a.headless {
+		return "", errorf(msgUpdateHeadless)
+	}
+	u := a.updater
+	u.mu.Lock()
+	if u.installing {
+		u.mu.Unlock()
+		return "", errorf(msgUpdateInProgress)
+	}
+	u.installing = true
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		u.installing = false
+		u.mu.Unlock()
+	}()
+
+	ctx := context.Background()
+	info, err := fetchLatestRelease(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !newerVersion(info.Version, appVersion) {
+		return "", errorf(msgAlreadyLatest, appVersion)
+	}
+	slog.Info("下载更新", "version", info.Version, "asset", info.AssetName)
+	path, err := downloadUpdate(ctx, info)
+	if err != nil {
+		slog.Error("下载更新失败", "error", err)
+		return "", err
+	}
+	if err := runInstaller(path); err != nil {
+		return "", errorf(msgStartInstallerFailed, err)
+	}
+	slog.Info("已启动安装程序，程序即将退出", "path", path)
+
+	// 返回结果后再退出，让前端收到响应；安装程序会等待文件释放
+	go func() {
+		time.Sleep(time.Second)
+		a.quitConfirmed.Store(true)
+		a.quit()
+	}()
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Installer started",
+		"version": info.Version,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// runInstaller 启动 Inno Setup 安装程序，静默安装到原来的目录
+func runInstaller(path string) error {
+	return exec.Command(path, "/SILENT", "/NORESTART", "/SUPPRESSMSGBOXES").Start()
+}
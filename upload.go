@@ -0,0 +1,732 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// uploadChunkSize 建议的分块大小，前端按此大小切分文件
+	uploadChunkSize = 4 << 20
+	// uploadMaxChunkSize 单个分块的大小上限，内存占用不超过几个分块
+	uploadMaxChunkSize = 16 << 20
+	// uploadIdleTimeout 超过这个时间没有收到分块的上传关闭临时文件，已上传的部分保留在磁盘上，之后可以继续
+	uploadIdleTimeout = time.Hour
+	// uploadResumeTTL 中断的上传保留的时间，超过后删除临时文件
+	uploadResumeTTL = 7 * 24 * time.Hour
+)
+
+// EventUploadProgress 上传和保存文件的进度，负载为 UploadProgress；进度按 progressEventInterval 节流，
+// 完成、失败和取消立即推送
+const EventUploadProgress = "upload:progress"
+
+// 上传的状态
+const (
+	uploadStatusReceiving = "receiving"
+	uploadStatusCompleted = "completed"
+	uploadStatusFailed    = "failed"
+	uploadStatusCancelled = "cancelled"
+)
+
+// uploadReadyTimeout StartTranscode 等待正在保存的上传文件完成的最长时间
+const uploadReadyTimeout = 30 * time.Second
+
+// uploadWriteSize UploadFile 在后台保存文件时每次写入的大小，每次写入后推送进度
+const uploadWriteSize = 1 << 20
+
+// UploadProgress is the progress of an upload
+// UploadProgress 上传的进度：分块上传为已接收的字节数，UploadFile 为已写入磁盘的字节数
+type UploadProgress struct {
+	UploadID   string  `json:"uploadId"`
+	FileName   string  `json:"fileName"`
+	FilePath   string  `json:"filePath"`
+	Written    int64   `json:"written"`
+	Total      int64   `json:"total"`
+	Percentage float64 `json:"percentage"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	// 转码目录中已有内容相同的视频时为该视频的路径，上传的内容没有保存，转码时使用已有的视频
+	ExistingPath string `json:"existingPath,omitempty"`
+}
+
+// emitUploadProgress 记录并推送上传进度，force 为 true 表示状态变化需要立即推送
+func (a *App) emitUploadProgress(progress UploadProgress, force bool) {
+	if progress.Total > 0 {
+		progress.Percentage = float64(progress.Written) / float64(progress.Total) * 100
+	} else if progress.Status == uploadStatusCompleted {
+		progress.Percentage = 100
+	}
+	a.uploads.record(progress)
+	key := EventUploadProgress + ":" + progress.UploadID
+	if progress.Status != uploadStatusReceiving {
+		force = true
+		defer a.throttle.forget(key)
+	}
+	if !a.throttle.allow(key, force) {
+		return
+	}
+	a.emitEvent(EventUploadProgress, progress)
+}
+
+// progress 返回分块上传的进度
+func (u *uploadSession) progress(status string) UploadProgress {
+	return UploadProgress{
+		UploadID: u.id,
+		FileName: u.fileName,
+		FilePath: u.path,
+		Written:  u.received,
+		Total:    u.size,
+		Status:   status,
+	}
+}
+
+// uploadSession 一个分块上传：分块按顺序写入 .part 临时文件，同时计算整个文件的 SHA-256，完成后改为正式的文件名
+type uploadSession struct {
+	mu         sync.Mutex
+	id         string
+	fileName   string
+	subDirName string
+	path       string
+	size       int64
+	// 客户端在 BeginUpload 时提供的整个文件的 SHA-256（小写十六进制），用于识别继续上传的是否为同一文件
+	checksum   string
+	received   int64
+	file       *os.File
+	hash       hash.Hash
+	lastActive time.Time
+}
+
+// partPath 上传中的临时文件
+func (u *uploadSession) partPath() string {
+	return u.path + ".part"
+}
+
+// statePath 记录上传信息的文件，上传中断或程序重启后用于继续上传
+func (u *uploadSession) statePath() string {
+	return u.partPath() + ".json"
+}
+
+// uploadState 保存在 .part.json 中的上传信息；已接收的字节数就是临时文件的大小，文件的修改时间为最后一次开始或继续上传的时间
+type uploadState struct {
+	UploadID string `json:"uploadId"`
+	FileName string `json:"fileName"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// saveState 保存上传信息
+func (u *uploadSession) saveState() error {
+	data, err := json.Marshal(uploadState{UploadID: u.id, FileName: u.fileName, Size: u.size, SHA256: u.checksum})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(u.statePath(), data, 0644)
+}
+
+// reopen 打开磁盘上保留的临时文件继续上传：已接收的字节数为临时文件的大小，重新计算已接收部分的 SHA-256
+func (u *uploadSession) reopen() error {
+	file, err := os.OpenFile(u.partPath(), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	received, err := io.Copy(u.hash, file)
+	if err != nil || received > u.size {
+		file.Close()
+		return fmt.Errorf("临时文件无效: %d/%d 字节, %v", received, u.size, err)
+	}
+	u.file, u.received = file, received
+	return nil
+}
+
+// compareStored 比较重复发送的分块与临时文件中已保存的内容
+func (u *uploadSession) compareStored(offset int64, data []byte) error {
+	stored := make([]byte, len(data))
+	if _, err := u.file.ReadAt(stored, offset); err != nil {
+		return errorf(msgCreateFileFailed, err)
+	}
+	if !bytes.Equal(stored, data) {
+		return errorf(msgUploadChunkConflict, offset)
+	}
+	return nil
+}
+
+// validSHA256 判断是否为小写十六进制的 SHA-256
+func validSHA256(sum string) bool {
+	if len(sum) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil && sum == strings.ToLower(sum)
+}
+
+// uploadRecord 上传的状态：接收中（receiving）、已完成（completed）、失败（failed）或已取消（cancelled），
+// 包括 UploadFile 在后台保存的文件；结束后保留 uploadIdleTimeout，供 GetUploadStatus 和 StartTranscode 查询
+type uploadRecord struct {
+	progress UploadProgress
+	updated  time.Time
+	// 上传结束（不再是 receiving）时关闭
+	done chan struct{}
+}
+
+// uploadManager 进行中的分块上传和所有上传的状态
+type uploadManager struct {
+	mu       sync.Mutex
+	sessions map[string]*uploadSession
+	// recordsMu 只保护 records：持有 session.mu 时推送进度会记录状态，不能与 mu 嵌套
+	recordsMu sync.Mutex
+	records   map[string]*uploadRecord
+	// 转码目录中视频的 SHA-256，用于识别重复上传
+	hashes uploadHashIndex
+}
+
+// newUploadManager 创建上传管理器
+func newUploadManager() *uploadManager {
+	return &uploadManager{
+		sessions: make(map[string]*uploadSession),
+		records:  make(map[string]*uploadRecord),
+	}
+}
+
+// record 更新上传的状态，上传结束时唤醒等待的 StartTranscode
+func (m *uploadManager) record(progress UploadProgress) {
+	m.recordsMu.Lock()
+	defer m.recordsMu.Unlock()
+	rec, ok := m.records[progress.UploadID]
+	if !ok || (rec.progress.Status != uploadStatusReceiving && progress.Status == uploadStatusReceiving) {
+		// 新的上传，或者结束后又继续的上传
+		rec = &uploadRecord{done: make(chan struct{})}
+		m.records[progress.UploadID] = rec
+	}
+	wasReceiving := rec.progress.Status == "" || rec.progress.Status == uploadStatusReceiving
+	rec.progress, rec.updated = progress, time.Now()
+	if wasReceiving && progress.Status != uploadStatusReceiving {
+		close(rec.done)
+	}
+}
+
+// status 返回上传的状态
+func (m *uploadManager) status(id string) (UploadProgress, bool) {
+	m.recordsMu.Lock()
+	defer m.recordsMu.Unlock()
+	rec, ok := m.records[id]
+	if !ok {
+		return UploadProgress{}, false
+	}
+	return rec.progress, true
+}
+
+// latest 返回上传到 path 的最近一次上传
+func (m *uploadManager) latest(path string) (*uploadRecord, bool) {
+	m.recordsMu.Lock()
+	defer m.recordsMu.Unlock()
+	var found *uploadRecord
+	for _, rec := range m.records {
+		if rec.progress.FilePath == path && (found == nil || rec.updated.After(found.updated)) {
+			found = rec
+		}
+	}
+	return found, found != nil
+}
+
+// waitReady 等待上传到 path 的文件可以使用，返回转码时使用的文件：通常为 path，与已有视频重复时为已有视频的路径。
+// 正在保存时最多等待 timeout，上传失败或超时返回错误；不是通过上传得到的文件（例如下载的视频）直接返回
+func (m *uploadManager) waitReady(path string, timeout time.Duration) (string, error) {
+	rec, ok := m.latest(path)
+	if !ok {
+		return path, nil
+	}
+	select {
+	case <-rec.done:
+	case <-time.After(timeout):
+		return "", errorf(msgUploadNotReady, filepath.Base(path))
+	}
+	m.recordsMu.Lock()
+	progress := rec.progress
+	m.recordsMu.Unlock()
+	switch progress.Status {
+	case uploadStatusCompleted:
+		if progress.ExistingPath != "" {
+			return progress.ExistingPath, nil
+		}
+		return path, nil
+	case uploadStatusFailed:
+		return "", errorf(msgUploadFailed, progress.Error)
+	}
+	return "", errorf(msgUploadNotReady, filepath.Base(path))
+}
+
+// get 返回上传，不存在时返回错误
+func (m *uploadManager) get(id string) (*uploadSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, errorf(msgUploadNotFound, id)
+	}
+	return session, nil
+}
+
+// remove 结束上传，discard 为 true 时删除临时文件；否则临时文件保留在磁盘上，之后可以继续上传
+func (m *uploadManager) remove(session *uploadSession, discard bool) {
+	m.mu.Lock()
+	delete(m.sessions, session.id)
+	m.mu.Unlock()
+	session.file.Close()
+	if discard {
+		os.Remove(session.partPath())
+		os.Remove(session.statePath())
+	}
+}
+
+// resume 查找可以继续的上传：内存中上传到同一文件、大小和 SHA-256 都相同的上传，或者磁盘上保留的临时文件
+// （上传中断后关闭或程序重启）。大小或 SHA-256 不同的旧上传视为另一个文件，删除其临时文件后重新开始
+func (m *uploadManager) resume(path string, size int64, checksum string) (*uploadSession, bool) {
+	m.mu.Lock()
+	var stale *uploadSession
+	for _, session := range m.sessions {
+		if session.path == path {
+			if session.size == size && session.checksum == checksum {
+				m.mu.Unlock()
+				return session, true
+			}
+			stale = session
+		}
+	}
+	m.mu.Unlock()
+	if stale != nil {
+		stale.mu.Lock()
+		m.remove(stale, true)
+		stale.mu.Unlock()
+	}
+
+	session := &uploadSession{path: path, size: size, checksum: checksum, hash: sha256.New(), lastActive: time.Now()}
+	data, err := os.ReadFile(session.statePath())
+	if err != nil {
+		return nil, false
+	}
+	var state uploadState
+	if err := json.Unmarshal(data, &state); err != nil || state.UploadID == "" {
+		return nil, false
+	}
+	if state.Size != size || state.SHA256 != checksum {
+		// 同名的另一个文件，之前的内容不能继续使用
+		slog.Info("中断的上传与本次上传的文件不同，重新开始", "file", path)
+		return nil, false
+	}
+	session.id, session.fileName = state.UploadID, state.FileName
+	if err := session.reopen(); err != nil {
+		slog.Warn("无法继续中断的上传，重新开始", "file", path, "error", err)
+		return nil, false
+	}
+	return session, true
+}
+
+// cleanupStaleUploads 删除超过 uploadResumeTTL 没有继续的中断上传
+func cleanupStaleUploads() {
+	states, _ := filepath.Glob(filepath.Join(transcodeDir(), "*", "*.part.json"))
+	for _, state := range states {
+		info, err := os.Stat(state)
+		if err != nil || time.Since(info.ModTime()) < uploadResumeTTL {
+			continue
+		}
+		slog.Info("删除长时间未继续的上传", "file", strings.TrimSuffix(state, ".json"))
+		os.Remove(strings.TrimSuffix(state, ".json"))
+		os.Remove(state)
+	}
+}
+
+// cleanup 关闭长时间没有收到分块的上传，已上传的部分保留在磁盘上；同时删除过期的中断上传。
+// AppendChunk 等在持有 session.mu 时调用 remove 获取 m.mu，这里先复制上传列表并释放 m.mu，再逐个锁定上传，
+// 两个锁只按 session.mu、m.mu 的顺序获取
+func (m *uploadManager) cleanup() {
+	m.mu.Lock()
+	sessions := make([]*uploadSession, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.mu.Unlock()
+	for _, session := range sessions {
+		session.mu.Lock()
+		if time.Since(session.lastActive) > uploadIdleTimeout {
+			slog.Info("关闭长时间未收到分块的上传，之后可以继续", "uploadId", session.id, "fileName", session.fileName, "received", session.received)
+			m.remove(session, false)
+		}
+		session.mu.Unlock()
+	}
+	m.recordsMu.Lock()
+	for id, rec := range m.records {
+		if rec.progress.Status != uploadStatusReceiving && time.Since(rec.updated) > uploadIdleTimeout {
+			delete(m.records, id)
+		}
+	}
+	m.recordsMu.Unlock()
+	cleanupStaleUploads()
+}
+
+// uploadSniffSize 识别文件内容类型时使用的开头字节数，与 http.DetectContentType 相同
+const uploadSniffSize = 512
+
+// videoExtensionList 返回允许上传的扩展名列表，用于错误消息
+func videoExtensionList() string {
+	exts := make([]string, 0, len(videoExtensions))
+	for ext := range videoExtensions {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return strings.Join(exts, ", ")
+}
+
+// validateUpload 开始上传前检查扩展名和大小：只接受视频文件，大小不超过设置的上限
+func validateUpload(fileName string, size int64) error {
+	if !videoExtensions[strings.ToLower(filepath.Ext(fileName))] {
+		return errorf(msgUploadUnsupportedType, fileName, videoExtensionList())
+	}
+	if limit := currentSettings().MaxUploadSizeMB << 20; limit > 0 && size > limit {
+		return errorf(msgUploadTooLarge, formatBytes(size), formatBytes(limit))
+	}
+	return nil
+}
+
+// validateUploadContent 按文件开头的内容识别类型，拒绝文本、图片、压缩包等明显不是视频的文件；
+// 识别不出类型的二进制数据（例如 MPEG-TS、MOV）视为视频，由 FFmpeg 在转码时判断
+func validateUploadContent(fileName string, head []byte) error {
+	mediaType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	switch {
+	case strings.HasPrefix(mediaType, "video/"), mediaType == "application/octet-stream", mediaType == "application/ogg":
+		return nil
+	}
+	return errorf(msgUploadNotVideo, mediaType, fileName)
+}
+
+// uploadTarget 返回上传文件在转码目录中的保存位置：与文件名同名的子目录
+func uploadTarget(fileName string) (string, string, error) {
+	baseName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	dir := filepath.Join(transcodeDir(), baseName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", errorf(msgCreateDirFailed, err)
+	}
+	return filepath.Join(dir, fileName), baseName, nil
+}
+
+// BeginUpload starts or resumes a chunked upload of a video to the transcode directory
+// BeginUpload 开始分块上传视频到转码目录，返回 uploadId、建议的分块大小 chunkSize 和已接收的字节数 received。
+// checksum 为整个文件的 SHA-256（十六进制），FinishUpload 时用同一值校验。
+// 之后从 received 开始按顺序调用 AppendChunk 上传每个分块，最后调用 FinishUpload；内存占用与文件大小无关。
+// 同名、大小和 SHA-256 都相同的文件上传中断（网络断开、页面刷新或程序重启）后再次调用时继续之前的上传，resumed 为 true；
+// 同名的其他文件重新开始上传。只接受视频扩展名、大小不超过设置的上限（maxUploadSizeMB）的文件，第一个分块的内容不是视频时结束上传
+func (a *App) BeginUpload(fileName string, size int64, checksum string) (string, error) {
+	fileName = filepath.Base(strings.TrimSpace(fileName))
+	if fileName == "" || fileName == "." || fileName == string(filepath.Separator) || size < 0 {
+		return "", errorf(msgInvalidArg, 1, fileName)
+	}
+	checksum = strings.ToLower(strings.TrimSpace(checksum))
+	if !validSHA256(checksum) {
+		return "", errorf(msgUploadChecksumRequired)
+	}
+	if err := validateUpload(fileName, size); err != nil {
+		return "", err
+	}
+	a.uploads.cleanup()
+
+	path, subDirName, err := uploadTarget(fileName)
+	if err != nil {
+		return "", err
+	}
+	session, resumed := a.uploads.resume(path, size, checksum)
+	if resumed {
+		session.mu.Lock()
+		session.lastActive = time.Now()
+		session.subDirName = subDirName
+		session.mu.Unlock()
+		slog.Info("继续中断的上传", "uploadId", session.id, "fileName", fileName, "received", session.received, "size", size)
+	} else {
+		if volume, err := volumeUsage(filepath.Dir(path)); err == nil && volume.Available < uint64(size) {
+			return "", errorf(msgNotEnoughSpace, formatBytes(size), formatBytes(int64(volume.Available)))
+		}
+		id, err := randomToken(16)
+		if err != nil {
+			return "", err
+		}
+		session = &uploadSession{
+			id:         id,
+			fileName:   fileName,
+			subDirName: subDirName,
+			path:       path,
+			size:       size,
+			checksum:   checksum,
+			hash:       sha256.New(),
+			lastActive: time.Now(),
+		}
+		session.file, err = os.Create(session.partPath())
+		if err != nil {
+			return "", errorf(msgCreateFileFailed, err)
+		}
+		slog.Info("开始分块上传", "uploadId", id, "fileName", fileName, "size", size)
+	}
+	// 保存上传信息（继续上传时刷新修改时间），保存失败只影响程序重启后继续上传
+	if err := session.saveState(); err != nil {
+		slog.Warn("保存上传信息失败", "uploadId", session.id, "error", err)
+	}
+	a.uploads.mu.Lock()
+	a.uploads.sessions[session.id] = session
+	a.uploads.mu.Unlock()
+	a.emitUploadProgress(session.progress(uploadStatusReceiving), true)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":    "success",
+		"uploadId":  session.id,
+		"chunkSize": uploadChunkSize,
+		"fileName":  fileName,
+		"received":  session.received,
+		"resumed":   resumed,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// AppendChunk appends one chunk to a chunked upload
+// AppendChunk 上传一个分块：offset 为分块在文件中的位置，必须等于已接收的字节数；chunk 为 Base64 编码的内容，
+// checksum 为分块的 SHA-256（十六进制，留空不校验）。重复发送已接收的分块（例如超时后重试）时与已保存的内容比较，
+// 相同时忽略，不同时返回错误；offset 不连续时返回错误，可以从响应的 received 继续上传
+func (a *App) AppendChunk(uploadID string, offset int64, chunk string, checksum string) (string, error) {
+	session, err := a.uploads.get(uploadID)
+	if err != nil {
+		return "", err
+	}
+	if base64.StdEncoding.DecodedLen(len(chunk)) > uploadMaxChunkSize {
+		return "", errorf(msgUploadChunkTooLarge, uploadMaxChunkSize/1024/1024)
+	}
+	data, err := base64.StdEncoding.DecodeString(chunk)
+	if err != nil {
+		return "", errorf(msgInvalidArg, 3, err)
+	}
+	if checksum != "" {
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+			return "", errorf(msgUploadChecksumMismatch, offset)
+		}
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.lastActive = time.Now()
+	switch {
+	case offset >= 0 && offset+int64(len(data)) <= session.received:
+		// 已经接收过的分块，内容必须与已保存的相同
+		if err := session.compareStored(offset, data); err != nil {
+			slog.Warn("重复发送的分块与已接收的内容不同", "uploadId", uploadID, "offset", offset, "error", err)
+			return "", err
+		}
+	case offset != session.received:
+		return "", errorf(msgUploadOffsetMismatch, offset, session.received)
+	case session.received+int64(len(data)) > session.size:
+		return "", errorf(msgUploadChunkTooLarge, uploadMaxChunkSize/1024/1024)
+	default:
+		// 第一个分块包含文件头，内容不是视频时结束上传
+		if session.received == 0 {
+			if err := validateUploadContent(session.fileName, data[:min(len(data), uploadSniffSize)]); err != nil {
+				slog.Warn("拒绝上传的文件", "uploadId", uploadID, "fileName", session.fileName, "error", err)
+				a.uploads.remove(session, true)
+				progress := session.progress(uploadStatusFailed)
+				progress.Error = err.Error()
+				a.emitUploadProgress(progress, true)
+				return "", err
+			}
+		}
+		if _, err := session.file.Write(data); err != nil {
+			slog.Error("写入上传的分块失败", "uploadId", uploadID, "error", err)
+			a.uploads.remove(session, true)
+			err = errorf(msgCreateFileFailed, err)
+			progress := session.progress(uploadStatusFailed)
+			progress.Error = err.Error()
+			a.emitUploadProgress(progress, true)
+			return "", err
+		}
+		session.hash.Write(data)
+		session.received += int64(len(data))
+		a.emitUploadProgress(session.progress(uploadStatusReceiving), false)
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":   "success",
+		"received": session.received,
+		"size":     session.size,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// FinishUpload completes a chunked upload
+// FinishUpload 完成分块上传：所有分块都已接收后将临时文件改为正式的文件名，checksum 为整个文件的 SHA-256，
+// 必须与 BeginUpload 时的相同，与接收的内容不一致时删除临时文件。
+// 返回与 UploadFile 相同的 fileName、filePath 和 subDirName，以及服务端计算的 sha256；
+// 转码目录中已有内容相同的视频时删除上传的内容，duplicate 为 true，filePath 和 existingPath 为已有视频的路径
+func (a *App) FinishUpload(uploadID string, checksum string) (string, error) {
+	session, err := a.uploads.get(uploadID)
+	if err != nil {
+		return "", err
+	}
+	if !validSHA256(strings.ToLower(checksum)) {
+		return "", errorf(msgUploadChecksumRequired)
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.received != session.size {
+		return "", errorf(msgUploadIncomplete, session.received, session.size)
+	}
+	// 上传失败时删除临时文件并通知前端
+	fail := func(err error) (string, error) {
+		progress := session.progress(uploadStatusFailed)
+		progress.Error = err.Error()
+		a.emitUploadProgress(progress, true)
+		return "", err
+	}
+	sum := hex.EncodeToString(session.hash.Sum(nil))
+	if !strings.EqualFold(sum, checksum) || sum != session.checksum {
+		a.uploads.remove(session, true)
+		return fail(errorf(msgUploadChecksumMismatch, 0))
+	}
+	if err := session.file.Sync(); err != nil {
+		a.uploads.remove(session, true)
+		return fail(errorf(msgCreateFileFailed, err))
+	}
+	// 转码目录中已有内容相同的视频时不保存第二份，直接使用已有的视频
+	if existing, ok := a.uploads.hashes.find(sum, session.size); ok {
+		a.uploads.remove(session, true)
+		// 删除为这次上传创建的空目录，目录不为空时不会删除
+		os.Remove(filepath.Dir(session.path))
+		slog.Info("上传的文件与已有视频相同，使用已有的视频", "uploadId", uploadID, "fileName", session.fileName, "existing", existing, "sha256", sum)
+		progress := session.progress(uploadStatusCompleted)
+		progress.ExistingPath = existing
+		a.emitUploadProgress(progress, true)
+		return duplicateUploadResponse(existing, session.size, sum)
+	}
+	a.uploads.remove(session, false)
+	os.Remove(session.statePath())
+	if err := os.Rename(session.partPath(), session.path); err != nil {
+		os.Remove(session.partPath())
+		return fail(errorf(msgCreateFileFailed, err))
+	}
+	a.uploads.hashes.add(session.path, sum)
+	slog.Info("分块上传完成", "uploadId", uploadID, "file", session.path, "size", session.size, "sha256", sum)
+	a.emitUploadProgress(session.progress(uploadStatusCompleted), true)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":     "success",
+		"message":    "File uploaded successfully",
+		"fileName":   session.fileName,
+		"filePath":   session.path,
+		"subDirName": session.subDirName,
+		"size":       session.size,
+		"sha256":     sum,
+		"duplicate":  false,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// duplicateUploadResponse 上传的文件与已有视频相同时 FinishUpload 的响应：fileName、filePath 和 subDirName 指向已有的视频
+func duplicateUploadResponse(existing string, size int64, sum string) (string, error) {
+	fileName := filepath.Base(existing)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status":       "success",
+		"message":      "File already exists",
+		"fileName":     fileName,
+		"filePath":     existing,
+		"subDirName":   filepath.Base(filepath.Dir(existing)),
+		"size":         size,
+		"sha256":       sum,
+		"duplicate":    true,
+		"existingPath": existing,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// CancelUpload cancels a chunked upload and deletes the partial file
+// CancelUpload 取消分块上传并删除已上传的部分
+func (a *App) CancelUpload(uploadID string) (string, error) {
+	session, err := a.uploads.get(uploadID)
+	if err != nil {
+		return "", err
+	}
+	session.mu.Lock()
+	a.uploads.remove(session, true)
+	session.mu.Unlock()
+	slog.Info("已取消分块上传", "uploadId", uploadID, "fileName", session.fileName)
+	a.emitUploadProgress(session.progress(uploadStatusCancelled), true)
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
+
+// GetUploadStatus returns the state of an upload
+// GetUploadStatus 返回上传的状态：status 为 receiving（接收或保存中）、completed、failed 或 cancelled，
+// 以及已写入的字节数 written 和总大小 total；UploadFile 和分块上传都可以查询，结束后保留一小时
+func (a *App) GetUploadStatus(uploadID string) (string, error) {
+	progress, ok := a.uploads.status(uploadID)
+	if !ok {
+		return "", errorf(msgUploadNotFound, uploadID)
+	}
+
+	// 构建响应
+	response := map[string]interface{}{
+		"status": "success",
+		"upload": progress,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newUploadTestApp 创建使用临时数据目录的 App，只包含上传需要的部分
+func newUploadTestApp(t *testing.T) *App {
+	t.Helper()
+	setDataDir(t.TempDir())
+	return &App{uploads: newUploadManager(), throttle: newEventThrottle(progressEventInterval)}
+}
+
+// testChecksum 返回内容的 SHA-256（十六进制）
+func testChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// beginUploadResponse BeginUpload 的响应
+type beginUploadResponse struct {
+	UploadID string `json:"uploadId"`
+	Received int64  `json:"received"`
+	Resumed  bool   `json:"resumed"`
+}
+
+// beginTestUpload 开始上传 content 并返回响应
+func beginTestUpload(t *testing.T, a *App, fileName string, content []byte) beginUploadResponse {
+	t.Helper()
+	result, err := a.BeginUpload(fileName, int64(len(content)), testChecksum(content))
+	if err != nil {
+		t.Fatalf("BeginUpload(%s) 失败: %v", fileName, err)
+	}
+	var response beginUploadResponse
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// 上传分块的同时开始其他上传（BeginUpload 会清理空闲的上传），两者获取锁的顺序不能相反
+func TestConcurrentBeginAndAppend(t *testing.T) {
+	// 单核机器上也让多个协程同时运行，否则很难遇到锁顺序相反的情况
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	a := newUploadTestApp(t)
+	const chunks = 1000
+	chunk := make([]byte, 1024)
+	encoded := base64.StdEncoding.EncodeToString(chunk)
+	content := make([]byte, chunks*len(chunk))
+	uploadID := beginTestUpload(t, a, "main.mkv", content).UploadID
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < chunks; i++ {
+			if _, err := a.AppendChunk(uploadID, int64(i*len(chunk)), encoded, ""); err != nil {
+				t.Errorf("AppendChunk 失败: %v", err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			result, err := a.BeginUpload(fmt.Sprintf("other-%d.mkv", i), int64(len(chunk)), testChecksum(chunk))
+			if err != nil {
+				t.Errorf("BeginUpload 失败: %v", err)
+				return
+			}
+			var response struct {
+				UploadID string `json:"uploadId"`
+			}
+			json.Unmarshal([]byte(result), &response)
+			id := response.UploadID
+			if _, err := a.AppendChunk(id, 0, encoded, ""); err != nil {
+				t.Errorf("AppendChunk 失败: %v", err)
+				return
+			}
+			if _, err := a.CancelUpload(id); err != nil {
+				t.Errorf("CancelUpload 失败: %v", err)
+				return
+			}
+		}
+	}()
+	// BeginUpload 中的清理比较慢，另外不停地清理以增加与 AppendChunk 同时获取锁的机会
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				a.uploads.cleanup()
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(stop)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("上传死锁")
+	}
+	if _, err := a.FinishUpload(uploadID, testChecksum(content)); err != nil {
+		t.Fatalf("FinishUpload 失败: %v", err)
+	}
+}
+
+// 上传中断后，只有内容相同的文件才能继续之前的上传，同名、同样大小的其他文件重新开始
+func TestResumeRequiresSameContent(t *testing.T) {
+	a := newUploadTestApp(t)
+	first := bytes.Repeat([]byte{1}, 2048)
+	other := bytes.Repeat([]byte{2}, 2048)
+	begin := beginTestUpload(t, a, "movie.mkv", first)
+	if _, err := a.AppendChunk(begin.UploadID, 0, base64.StdEncoding.EncodeToString(first[:1024]), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟程序重启：内存中的上传丢失，临时文件保留在磁盘上
+	session, _ := a.uploads.get(begin.UploadID)
+	a.uploads.remove(session, false)
+	a.uploads = newUploadManager()
+	if resumed := beginTestUpload(t, a, "movie.mkv", first); !resumed.Resumed || resumed.Received != 1024 {
+		t.Fatalf("相同的文件应继续上传，实际为 %+v", resumed)
+	}
+
+	fresh := beginTestUpload(t, a, "movie.mkv", other)
+	if fresh.Resumed || fresh.Received != 0 {
+		t.Fatalf("内容不同的文件应重新开始上传，实际为 %+v", fresh)
+	}
+	for offset := 0; offset < len(other); offset += 1024 {
+		if _, err := a.AppendChunk(fresh.UploadID, int64(offset), base64.StdEncoding.EncodeToString(other[offset:offset+1024]), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := a.FinishUpload(fresh.UploadID, testChecksum(other))
+	if err != nil {
+		t.Fatalf("FinishUpload 失败: %v", err)
+	}
+	var finished struct {
+		FilePath string `json:"filePath"`
+	}
+	json.Unmarshal([]byte(result), &finished)
+	if saved, err := os.ReadFile(finished.FilePath); err != nil || !bytes.Equal(saved, other) {
+		t.Fatalf("保存的文件内容不正确 (%v)", err)
+	}
+}
+
+// 重复发送的分块与已保存的内容比较，内容不同时返回错误
+func TestAppendChunkComparesResentChunk(t *testing.T) {
+	a := newUploadTestApp(t)
+	content := bytes.Repeat([]byte{3}, 2048)
+	uploadID := beginTestUpload(t, a, "movie.mkv", content).UploadID
+	chunk := base64.StdEncoding.EncodeToString(content[:1024])
+	for i := 0; i < 2; i++ {
+		if _, err := a.AppendChunk(uploadID, 0, chunk, ""); err != nil {
+			t.Fatalf("第 %d 次发送相同的分块失败: %v", i+1, err)
+		}
+	}
+	changed := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{4}, 1024))
+	if _, err := a.AppendChunk(uploadID, 0, changed, ""); errorKey(err) != msgUploadChunkConflict {
+		t.Fatalf("内容不同的重复分块应被拒绝，实际为 %v", err)
+	}
+}
+
+func TestUploadChecksumRequired(t *testing.T) {
+	a := newUploadTestApp(t)
+	content := bytes.Repeat([]byte{5}, 1024)
+	if _, err := a.BeginUpload("movie.mkv", int64(len(content)), ""); errorKey(err) != msgUploadChecksumRequired {
+		t.Fatalf("没有 SHA-256 时 BeginUpload 返回 %v", err)
+	}
+	uploadID := beginTestUpload(t, a, "movie.mkv", content).UploadID
+	if _, err := a.AppendChunk(uploadID, 0, base64.StdEncoding.EncodeToString(content), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.FinishUpload(uploadID, ""); errorKey(err) != msgUploadChecksumRequired {
+		t.Fatalf("没有 SHA-256 时 FinishUpload 返回 %v", err)
+	}
+	if _, err := a.FinishUpload(uploadID, testChecksum(nil)); errorKey(err) != msgUploadChecksumMismatch {
+		t.Fatalf("SHA-256 不一致时 FinishUpload 返回 %v", err)
+	}
+}
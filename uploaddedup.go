@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// uploadHashesFile 转码目录中视频的 SHA-256 缓存，用于识别重复上传
+const uploadHashesFile = "upload-hashes.json"
+
+// uploadHashEntry 一个视频的 SHA-256，文件大小或修改时间变化后失效
+type uploadHashEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	SHA256  string    `json:"sha256"`
+}
+
+// uploadHashIndex 转码目录中视频文件的路径到 SHA-256 的缓存：上传完成后记录上传的文件，
+// 查找时只计算大小相同、还没有缓存的视频，避免每次上传都读取整个转码目录
+type uploadHashIndex struct {
+	mu      sync.Mutex
+	entries map[string]uploadHashEntry
+}
+
+// load 读取缓存，只在第一次使用时读取；调用时已持有锁
+func (x *uploadHashIndex) load() {
+	if x.entries != nil {
+		return
+	}
+	x.entries = make(map[string]uploadHashEntry)
+	data, err := readFileWithRecovery(dataPath(uploadHashesFile), func(data []byte) error {
+		var entries map[string]uploadHashEntry
+		return json.Unmarshal(data, &entries)
+	})
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("读取上传文件的哈希缓存失败", "error", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &x.entries); err != nil {
+		slog.Error("解析上传文件的哈希缓存失败", "error", err)
+	}
+}
+
+// save 写入缓存；调用时已持有锁
+func (x *uploadHashIndex) save() {
+	data, err := json.Marshal(x.entries)
+	if err == nil {
+		err = writeFileAtomic(dataPath(uploadHashesFile), data, 0644)
+	}
+	if err != nil {
+		slog.Error("保存上传文件的哈希缓存失败", "error", err)
+	}
+}
+
+// add 记录刚保存的文件的 SHA-256
+func (x *uploadHashIndex) add(path, sum string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.load()
+	x.entries[path] = uploadHashEntry{Size: info.Size(), ModTime: info.ModTime(), SHA256: sum}
+	x.save()
+}
+
+// find 在转码目录中查找大小和 SHA-256 都相同的视频，返回其路径。已删除或修改过的文件从缓存中移除，
+// 大小相同但没有缓存的视频在这里计算 SHA-256（大小完全相同的不同视频很少见）
+func (x *uploadHashIndex) find(sum string, size int64) (string, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.load()
+	dirty := false
+	defer func() {
+		if dirty {
+			x.save()
+		}
+	}()
+
+	for path, entry := range x.entries {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+			delete(x.entries, path)
+			dirty = true
+			continue
+		}
+		if entry.Size == size && entry.SHA256 == sum {
+			return path, true
+		}
+	}
+
+	found := ""
+	filepath.WalkDir(transcodeDir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !videoExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		if _, ok := x.entries[path]; ok {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() != size {
+			return nil
+		}
+		hashes, err := hashFileChecksums(context.Background(), path, []string{checksumSHA256}, func(int64) {})
+		if err != nil {
+			slog.Warn("计算视频的 SHA-256 失败", "file", path, "error", err)
+			return nil
+		}
+		x.entries[path] = uploadHashEntry{Size: info.Size(), ModTime: info.ModTime(), SHA256: hashes[checksumSHA256]}
+		dirty = true
+		if hashes[checksumSHA256] == sum {
+			found = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, found != ""
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"syscall"
+)
+
+// mntNoWait getfsstat 的 MNT_NOWAIT，使用缓存的数据，不等待无响应的网络文件系统（syscall 包中没有该常量）
+const mntNoWait = 2
+
+// listVolumes 使用 getfsstat 列出挂载的卷和网络共享，跳过 devfs、autofs 等虚拟文件系统
+// 以及 /System/Volumes 下的系统内部卷
+func listVolumes() ([]volumeInfo, error) {
+	n, err := syscall.Getfsstat(nil, mntNoWait)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]syscall.Statfs_t, n)
+	if n, err = syscall.Getfsstat(stats, mntNoWait); err != nil {
+		return nil, err
+	}
+
+	var volumes []volumeInfo
+	for _, stat := range stats[:n] {
+		device := cString(stat.Mntfromname[:])
+		mountPoint := cString(stat.Mntonname[:])
+		fsType := cString(stat.Fstypename[:])
+		network := fsType == "smbfs" || fsType == "nfs" || fsType == "afpfs" || fsType == "webdav"
+		if !strings.HasPrefix(device, "/dev/") && !network {
+			continue
+		}
+		if strings.HasPrefix(mountPoint, "/System/Volumes/") {
+			continue
+		}
+		volumes = append(volumes, volumeInfo{Path: mountPoint, Device: device, FSType: fsType})
+	}
+	return volumes, nil
+}
+
+// cString 将以0结尾的 C 字符串转换为 Go 字符串
+func cString(b []int8) string {
+	buf := make([]byte, 0, len(b))
+	for _, c := range b {
+		if c == 0 {
+			break
+		}
+		buf = append(buf, byte(c))
+	}
+	return string(buf)
+}
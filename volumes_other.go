@@ -0,0 +1,48 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// mountsFile 当前进程可见的挂载点
+const mountsFile = "/proc/self/mounts"
+
+// listVolumes 列出挂载的块设备（/dev 下的设备）和网络文件系统，跳过 proc、tmpfs 等虚拟文件系统
+func listVolumes() ([]volumeInfo, error) {
+	file, err := os.Open(mountsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var volumes []volumeInfo
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 设备 挂载点 文件系统类型 选项 ...，路径中的空格写作 \040
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		device, mountPoint, fsType := fields[0], unescapeMountPath(fields[1]), fields[2]
+		network := fsType == "nfs" || fsType == "nfs4" || fsType == "cifs" || fsType == "smb3"
+		if !strings.HasPrefix(device, "/dev/") && !network {
+			continue
+		}
+		if strings.HasPrefix(device, "/dev/loop") || seen[mountPoint] {
+			continue
+		}
+		seen[mountPoint] = true
+		volumes = append(volumes, volumeInfo{Path: mountPoint, Device: device, FSType: fsType})
+	}
+	return volumes, scanner.Err()
+}
+
+// unescapeMountPath 还原 /proc/self/mounts 中转义的空格、制表符、换行和反斜杠
+func unescapeMountPath(path string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(path)
+}
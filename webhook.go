@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+)
+
+// 任务生命周期事件，作为 Webhook 负载的 event 字段
+const (
+	WebhookTaskAdded     = "task.added"
+	WebhookTaskStarted   = "task.started"
+	WebhookTaskCompleted = "task.completed"
+	WebhookTaskFailed    = "task.failed"
+)
+
+const (
+	// webhookTimeout 单次请求超时
+	webhookTimeout = 10 * time.Second
+	// webhookRetries 请求失败后的重试次数，间隔依次加倍
+	webhookRetries = 3
+	// webhookSignatureHeader 配置了密钥时，请求体的 HMAC-SHA256 签名
+	webhookSignatureHeader = "X-SeedParser-Signature"
+)
+
+// Webhook is a user configured endpoint receiving task lifecycle events
+// Webhook 接收任务生命周期事件的地址
+type Webhook struct {
+	URL string `json:"url"`
+	// 负载格式：json（默认，完整的任务信息）、discord、slack
+	Format string `json:"format"`
+	// 订阅的事件，留空表示全部
+	Events []string `json:"events"`
+	// 签名密钥，设置后请求头 X-SeedParser-Signature 为 sha256=<请求体的HMAC>
+	Secret string `json:"secret,omitempty"`
+}
+
+// validate 检查 Webhook 配置
+func (w *Webhook) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errorf(msgInvalidWebhookURL, w.URL)
+	}
+	switch w.Format {
+	case "":
+		w.Format = "json"
+	case "json", "discord", "slack":
+	default:
+		return errorf(msgInvalidWebhookFormat, w.Format)
+	}
+	for _, event := range w.Events {
+		switch event {
+		case WebhookTaskAdded, WebhookTaskStarted, WebhookTaskCompleted, WebhookTaskFailed:
+		default:
+			return errorf(msgInvalidWebhookEvent, event)
+		}
+	}
+	return nil
+}
+
+// subscribed 判断是否订阅了事件
+func (w Webhook) subscribed(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPayload is the JSON body sent for the json format
+// WebhookPayload json 格式的请求体
+type WebhookPayload struct {
+	Event    string      `json:"event"`
+	Time     string      `json:"time"`
+	TaskType string      `json:"taskType"` // download, transcode
+	TaskID   string      `json:"taskId"`
+	Name     string      `json:"name"`
+	Status   string      `json:"status"`
+	Error    string      `json:"error,omitempty"`
+	Task     interface{} `json:"task"`
+}
+
+// downloadWebhookPayload 由下载任务生成负载
+func downloadWebhookPayload(event string, task DownloadTask) WebhookPayload {
+	name := task.FileName
+	if name == "" {
+		name = task.TaskID
+	}
+	payload := WebhookPayload{
+		Event:    event,
+		Time:     time.Now().Format(time.RFC3339),
+		TaskType: "download",
+		TaskID:   task.TaskID,
+		Name:     name,
+		Status:   task.Status,
+		Task:     task,
+	}
+	if event == WebhookTaskFailed {
+		payload.Error = "下载异常中断"
+	}
+	return payload
+}
+
+// transcodeWebhookPayload 由转码任务生成负载
+func transcodeWebhookPayload(event string, task TranscodeTask) WebhookPayload {
+	return WebhookPayload{
+		Event:    event,
+		Time:     time.Now().Format(time.RFC3339),
+		TaskType: "transcode",
+		TaskID:   task.TaskID,
+		Name:     filepath.Base(task.InputFile),
+		Status:   task.Status,
+		Error:    task.Error,
+		Task:     task,
+	}
+}
+
+// webhookEventText 事件的简短描述，用于聊天工具格式
+var webhookEventText = map[string]string{
+	WebhookTaskAdded:     "已添加",
+	WebhookTaskStarted:   "已开始",
+	WebhookTaskCompleted: "已完成",
+	WebhookTaskFailed:    "失败",
+}
+
+// body 按 Webhook 格式生成请求体
+func (w Webhook) body(payload WebhookPayload) ([]byte, error) {
+	kind := "下载"
+	if payload.TaskType == "transcode" {
+		kind = "转码"
+	}
+	text := fmt.Sprintf("[SeedParser] %s%s: %s", kind, webhookEventText[payload.Event], payload.Name)
+	if payload.Error != "" {
+		text += "（" + payload.Error + "）"
+	}
+
+	switch w.Format {
+	case "discord":
+		return json.Marshal(map[string]string{"content": text})
+	case "slack":
+		return json.Marshal(map[string]string{"text": text})
+	default:
+		return json.Marshal(payload)
+	}
+}
+
+// send 发送一次请求，失败时按间隔加倍重试
+func (w Webhook) send(payload WebhookPayload) error {
+	body, err := w.body(payload)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: webhookTimeout}
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		err = w.post(client, body)
+		if err == nil || attempt == webhookRetries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (w Webhook) post(client *http.Client, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SeedParser-Webhook")
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errorf(msgServerReturnedStatus, resp.Status)
+	}
+	return nil
+}
+
+// publishTaskEvent 发布任务生命周期事件：发送给 Webhook，由 Telegram 机器人报告完成和失败，并运行订阅了该事件的插件
+func (a *App) publishTaskEvent(payload WebhookPayload) {
+	a.fireWebhooks(payload)
+	a.telegram.report(payload)
+	a.runPluginHooks(payload)
+}
+
+// fireWebhooks 在后台向订阅了该事件的所有 Webhook 发送负载
+func (a *App) fireWebhooks(payload WebhookPayload) {
+	for _, hook := range currentSettings().Webhooks {
+		if !hook.subscribed(payload.Event) {
+			continue
+		}
+		go func(hook Webhook) {
+			if err := hook.send(payload); err != nil {
+				slog.Warn("发送Webhook失败", "url", hook.URL, "event", payload.Event, "taskId", payload.TaskID, "error", err)
+			}
+		}(hook)
+	}
+}
+
+// TestWebhook sends a sample event to a webhook URL
+// TestWebhook 向指定地址发送一条测试事件，返回是否成功
+func (a *App) TestWebhook(webhookURL string, format string) (string, error) {
+	hook := Webhook{URL: webhookURL, Format: format}
+	if err := hook.validate(); err != nil {
+		return "", err
+	}
+	payload := WebhookPayload{
+		Event:    WebhookTaskCompleted,
+		Time:     time.Now().Format(time.RFC3339),
+		TaskType: "download",
+		TaskID:   "test",
+		Name:     "SeedParser Webhook 测试",
+		Status:   "completed",
+	}
+	body, err := hook.body(payload)
+	if err != nil {
+		return "", err
+	}
+	if err := hook.post(&http.Client{Timeout: webhookTimeout}, body); err != nil {
+		return "", errorf(msgSendWebhookFailed, err)
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Webhook delivered",
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
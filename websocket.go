@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsPath WebSocket 远程控制端点
+	wsPath = "/ws"
+	// wsSendBuffer 每个连接待发送消息的缓冲数，客户端跟不上时丢弃进度事件
+	wsSendBuffer = 64
+	// wsWriteTimeout 单条消息的写入超时
+	wsWriteTimeout = 10 * time.Second
+	// wsPingInterval 心跳间隔，超过 wsPongTimeout 未收到响应则断开
+	wsPingInterval = 30 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	// wsMaxMessageSize 客户端消息的最大字节数（添加任务时包含Base64编码的种子文件）
+	wsMaxMessageSize = 16 * 1024 * 1024
+)
+
+// wsCommandAliases 常用命令的简写，其余命令直接使用方法名
+var wsCommandAliases = map[string]string{
+	"add":    "DownloadTorrentFiles",
+	"cancel": "CancelDownload",
+	"pause":  "PauseDownload",
+	"resume": "ResumeDownload",
+	"status": "GetDownloadStatus",
+}
+
+// wsMessage WebSocket 消息
+//
+// 服务端推送事件:  {"type":"event","event":"download:progress","data":{...}}
+// 客户端发送命令:  {"id":1,"command":"pause","args":["task-1"]}
+// 服务端返回结果:  {"type":"result","id":1,"result":{...}} 或 {"type":"error","id":1,"error":"..."}
+//
+// command 可以是 wsCommandAliases 中的简写，也可以是任意 REST API 方法名，args 与 REST API 的参数数组一致
+type wsMessage struct {
+	Type    string          `json:"type,omitempty"`
+	ID      interface{}     `json:"id,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	Data    interface{}     `json:"data,omitempty"`
+	Command string          `json:"command,omitempty"`
+	Args    json.RawMessage `json:"args,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// wsClient 一个WebSocket连接
+type wsClient struct {
+	conn *websocket.Conn
+	send chan wsMessage
+}
+
+// wsHub 管理所有WebSocket连接并广播事件
+type wsHub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]bool
+}
+
+// newWSHub 创建WebSocket连接管理器
+func newWSHub() *wsHub {
+	return &wsHub{clients: make(map[*wsClient]bool)}
+}
+
+func (h *wsHub) add(c *wsClient) {
+	h.mu.Lock()
+	h.clients[c] = true
+	h.mu.Unlock()
+}
+
+func (h *wsHub) remove(c *wsClient) {
+	h.mu.Lock()
+	if h.clients[c] {
+		delete(h.clients, c)
+		close(c.send)
+	}
+	h.mu.Unlock()
+}
+
+// closeAll 断开所有连接，连接的读取协程随后会将其移除
+func (h *wsHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		c.conn.Close()
+	}
+}
+
+// broadcast 向所有连接推送事件，发送缓冲已满的连接跳过本次事件；事件中的地址去掉文件访问令牌
+func (h *wsHub) broadcast(event string, data interface{}) {
+	if data != nil {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			slog.Error("生成WebSocket事件失败", "event", event, "error", err)
+			return
+		}
+		data = json.RawMessage(stripFileToken(string(payload)))
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msg := wsMessage{Type: "event", Event: event, Data: data}
+	for c := range h.clients {
+		select {
+		case c.send <- msg:
+		default:
+		}
+	}
+}
+
+// reply 向单个连接发送命令结果，连接已关闭或发送缓冲已满时丢弃
+func (h *wsHub) reply(c *wsClient, msg wsMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.clients[c] {
+		return
+	}
+	select {
+	case c.send <- msg:
+	default:
+		slog.Warn("WebSocket发送缓冲已满，丢弃命令结果", "id", msg.ID)
+	}
+}
+
+// wsUpgrader 使用默认的来源检查：浏览器发起的连接的 Origin 必须与请求的 Host 相同，
+// 其他网站的页面不能连接本机或局域网中的服务读取任务事件；命令行客户端不发送 Origin，不受限制
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// newWebSocketHandler creates the WebSocket remote-control handler
+// newWebSocketHandler 创建WebSocket远程控制处理器：推送任务进度事件，并接收控制命令
+func newWebSocketHandler(app *App) http.Handler {
+	methods := apiMethods(app)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			slog.Error("WebSocket握手失败", "error", err)
+			return
+		}
+		client := &wsClient{conn: conn, send: make(chan wsMessage, wsSendBuffer)}
+		app.hub.add(client)
+		slog.Info("WebSocket客户端已连接", "remote", r.RemoteAddr)
+
+		go client.writeLoop()
+		client.readLoop(app.hub, methods)
+
+		app.hub.remove(client)
+		conn.Close()
+		slog.Info("WebSocket客户端已断开", "remote", r.RemoteAddr)
+	})
+}
+
+// writeLoop 发送消息和心跳，send 关闭后退出
+func (c *wsClient) writeLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteTimeout))
+				return
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readLoop 读取并执行客户端命令，连接断开后返回
+func (c *wsClient) readLoop(hub *wsHub, methods map[string]apiMethod) {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.Warn("WebSocket连接异常关闭", "error", err)
+			}
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+
+		// 命令可能耗时较长（如启动下载），在独立协程中执行，不阻塞读取
+		go func(msg wsMessage) {
+			hub.reply(c, executeWSCommand(methods, msg))
+		}(msg)
+	}
+}
+
+// executeWSCommand 执行一条客户端命令并生成回复
+func executeWSCommand(methods map[string]apiMethod, msg wsMessage) wsMessage {
+	name := msg.Command
+	if alias, ok := wsCommandAliases[name]; ok {
+		name = alias
+	}
+	m, ok := methods[name]
+	if !ok {
+		return wsMessage{Type: "error", ID: msg.ID, Error: errorf(msgUnknownMethod, msg.Command).Error()}
+	}
+
+	result, err := m.call(msg.Args)
+	if err != nil {
+		slog.Error("WebSocket命令执行失败", "command", msg.Command, "error", err)
+		return wsMessage{Type: "error", ID: msg.ID, Error: err.Error()}
+	}
+	return wsMessage{Type: "result", ID: msg.ID, Result: json.RawMessage(result)}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocketRejectsCrossOrigin(t *testing.T) {
+	server := httptest.NewServer(newWebSocketHandler(&App{hub: newWSHub()}))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + wsPath
+
+	tests := []struct {
+		name   string
+		origin string
+		ok     bool
+	}{
+		{"其他网站", "https://evil.example", false},
+		{"本服务的页面", server.URL, true},
+		{"命令行客户端", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			conn, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+			if conn != nil {
+				conn.Close()
+			}
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("连接失败: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("其他网站的页面不应能建立连接")
+			}
+			if resp == nil || resp.StatusCode != http.StatusForbidden {
+				t.Fatalf("应返回 403，实际为 %v", resp)
+			}
+		})
+	}
+}
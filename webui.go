@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// webBridgePath 网页版桥接脚本路径，用 REST API 和 WebSocket 代替Wails运行时
+const webBridgePath = "/seedparser-bridge.js"
+
+// webBridgeScript 定义 window.go.main.App 和 window.runtime，使桌面端前端无需修改即可在浏览器中运行：
+// 绑定方法通过 POST /api/{方法名} 调用，事件通过 /ws 接收
+const webBridgeScript = `(function () {
+  var listeners = {};
+  var call = function (name, args) {
+    return fetch('/api/' + name, {
+      method: 'POST',
+      credentials: 'same-origin',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(args)
+    }).then(function (res) {
+      return res.text().then(function (text) {
+        if (res.ok) {
+          return text;
+        }
+        var message = text;
+        try { message = JSON.parse(text).message || text; } catch (e) {}
+        throw message;
+      });
+    });
+  };
+  window.go = { main: { App: new Proxy({}, {
+    get: function (_, name) {
+      return function () { return call(name, Array.prototype.slice.call(arguments)); };
+    }
+  }) } };
+
+  var connect = function () {
+    var ws = new WebSocket((location.protocol === 'https:' ? 'wss://' : 'ws://') + location.host + '/ws');
+    ws.onmessage = function (e) {
+      var msg = JSON.parse(e.data);
+      if (msg.type !== 'event') {
+        return;
+      }
+      (listeners[msg.event] || []).slice().forEach(function (l) {
+        if (l.max > 0 && --l.max === 0) {
+          off(msg.event, l);
+        }
+        l.callback(msg.data);
+      });
+    };
+    ws.onclose = function () { setTimeout(connect, 3000); };
+  };
+  var off = function (name, listener) {
+    listeners[name] = (listeners[name] || []).filter(function (l) { return l !== listener; });
+  };
+  connect();
+
+  var noop = function () {};
+  window.runtime = new Proxy({
+    EventsOnMultiple: function (name, callback, max) {
+      var listener = { callback: callback, max: max };
+      (listeners[name] = listeners[name] || []).push(listener);
+      return function () { off(name, listener); };
+    },
+    EventsOff: function () {
+      Array.prototype.slice.call(arguments).forEach(function (name) { delete listeners[name]; });
+    },
+    EventsOffAll: function () { listeners = {}; },
+    EventsEmit: noop,
+    BrowserOpenURL: function (url) { window.open(url, '_blank'); },
+    Environment: function () { return Promise.resolve({ buildType: 'web', platform: 'web', arch: '' }); }
+  }, {
+    // 窗口控制等桌面端功能在浏览器中忽略
+    get: function (target, name) { return target[name] || noop; }
+  });
+})();
+`
+
+// newWebUIHandler serves the embedded frontend for browsers
+// newWebUIHandler 提供内嵌的前端页面，在 index.html 中注入桥接脚本
+func newWebUIHandler() http.Handler {
+	dist, err := fs.Sub(assets, "frontend/dist")
+	if err != nil {
+		slog.Error("加载前端资源失败", "error", err)
+		return http.NotFoundHandler()
+	}
+	index, err := fs.ReadFile(dist, "index.html")
+	if err != nil {
+		slog.Error("加载前端页面失败", "error", err)
+		return http.NotFoundHandler()
+	}
+	// 桥接脚本必须在前端模块之前执行
+	index = bytes.Replace(index, []byte("<head>"), []byte(`<head><script src="`+webBridgePath+`"></script>`), 1)
+	files := http.FileServer(http.FS(dist))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case webBridgePath:
+			w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+			w.Write([]byte(webBridgeScript))
+		case "/", "/index.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(index)
+		default:
+			files.ServeHTTP(w, r)
+		}
+	})
+}
+
+// newRemoteHandler builds the handler shared by server mode and LAN access
+// newRemoteHandler 创建服务器模式和局域网访问共用的处理器：REST API、WebSocket、Prometheus 指标、下载/转码文件和网页界面，
+// 各路由的认证要求见 authRoutes
+func newRemoteHandler(app *App) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(authPrefix, newAuthHandler())
+	mux.Handle(apiPrefix, newAPIHandler(app))
+	mux.Handle(wsPath, newWebSocketHandler(app))
+	mux.Handle(metricsPath, newMetricsHandler(app))
+	// 下载和转码目录的文件访问与桌面模式一致
+	mux.Handle("/", customMiddleware(newWebUIHandler()))
+	return requireAuth(mux)
+}
+
+// remoteServer 桌面模式下的本机API和局域网访问服务
+type remoteServer struct {
+	mu     sync.Mutex
+	server *http.Server
+	host   string
+	port   int
+}
+
+// remoteHost 桌面模式的监听地址：总是在本机回环地址上提供API供命令行使用（需要本机API令牌），开启局域网访问后监听所有网卡
+func remoteHost(s Settings) string {
+	if s.RemoteAccess {
+		return "0.0.0.0"
+	}
+	return "127.0.0.1"
+}
+
+// applyRemoteAccess 按设置启动或重启本机API和局域网访问服务
+func (a *App) applyRemoteAccess() error {
+	s := currentSettings()
+	host := remoteHost(s)
+	a.remote.mu.Lock()
+	defer a.remote.mu.Unlock()
+
+	if a.remote.server != nil {
+		if a.remote.host == host && a.remote.port == s.ServerPort {
+			return nil
+		}
+		a.stopRemoteLocked()
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(s.ServerPort))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           newRemoteHandler(a),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("局域网访问服务异常退出", "error", err)
+		}
+	}()
+	a.remote.server = server
+	a.remote.host = host
+	a.remote.port = s.ServerPort
+	if err := writeLocalToken(); err != nil {
+		slog.Error("保存本机API令牌失败", "error", err)
+	}
+	slog.Info("API服务已启动", "addr", addr, "lan", s.RemoteAccess)
+	return nil
+}
+
+// stopRemote 停止本机API和局域网访问服务
+func (a *App) stopRemote() {
+	a.remote.mu.Lock()
+	defer a.remote.mu.Unlock()
+	a.stopRemoteLocked()
+}
+
+func (a *App) stopRemoteLocked() {
+	if a.remote.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.remote.server.Shutdown(ctx); err != nil {
+		slog.Error("关闭API服务失败", "error", err)
+	}
+	a.remote.server = nil
+	removeLocalToken()
+	// Shutdown 不会关闭已升级的WebSocket连接，需要单独断开
+	a.hub.closeAll()
+	slog.Info("API服务已关闭")
+}
+
+// localAddresses 返回本机的局域网IPv4地址，用于显示访问地址
+func localAddresses() []string {
+	addrs := []string{}
+	ifaceAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return addrs
+	}
+	for _, addr := range ifaceAddrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.To4() == nil {
+			continue
+		}
+		addrs = append(addrs, ipNet.IP.String())
+	}
+	return addrs
+}
+
+// GetRemoteAccess returns the LAN access status and the addresses to open on other devices
+// GetRemoteAccess 获取局域网访问状态和可在手机浏览器中打开的地址
+func (a *App) GetRemoteAccess() (string, error) {
+	a.remote.mu.Lock()
+	lan := a.remote.server != nil && a.remote.host != "127.0.0.1"
+	port := a.remote.port
+	a.remote.mu.Unlock()
+
+	urls := []string{}
+	if lan {
+		for _, ip := range localAddresses() {
+			urls = append(urls, "http://"+net.JoinHostPort(ip, strconv.Itoa(port))+"/")
+		}
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"enabled": lan,
+		"port":    port,
+		"urls":    urls,
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}